@@ -0,0 +1,177 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"k8s.io/klog/v2"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/authz"
+)
+
+// includedConfig is a policy fragment of the include directory, e.g. of a
+// tenant. Only the lists of the authorization are merged into the config.
+type includedConfig struct {
+	AuthorizationConfig *authz.Config `json:"authorization,omitempty"`
+}
+
+// includePath returns the include directory of the config file, relative
+// paths are relative to the directory of the config file.
+func includePath(configFileName, include string) string {
+	if include == "" || filepath.IsAbs(include) {
+		return include
+	}
+	return filepath.Join(filepath.Dir(configFileName), include)
+}
+
+// includeFiles returns the YAML and JSON files of the directory, sorted by
+// name. Hidden files are skipped, e.g. the ..data directory of ConfigMaps.
+func includeFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read include directory: %w", err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") || entry.IsDir() {
+			continue
+		}
+		switch filepath.Ext(name) {
+		case ".yaml", ".yml", ".json":
+			files = append(files, filepath.Join(dir, name))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// mergeIncludes appends the static rules, scopes and denied users and groups
+// of the policy fragments of the directory to the authorization of the
+// config file. Fragments with other fields are an error, as their merge
+// would be ambiguous.
+func mergeIncludes(configFile *configfile, dir string) error {
+	files, err := includeFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read included file: %w", err)
+		}
+		j, err := yaml.YAMLToJSON(b)
+		if err != nil {
+			return fmt.Errorf("failed to parse included file %s: %w", file, err)
+		}
+		fragment := includedConfig{}
+		decoder := json.NewDecoder(bytes.NewReader(j))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&fragment); err != nil {
+			return fmt.Errorf("failed to parse included file %s: %w", file, err)
+		}
+		if fragment.AuthorizationConfig == nil {
+			continue
+		}
+
+		included := *fragment.AuthorizationConfig
+		merged := configFile.AuthorizationConfig
+		if merged == nil {
+			merged = &authz.Config{}
+			configFile.AuthorizationConfig = merged
+		}
+		merged.Static = append(merged.Static, included.Static...)
+		merged.Scopes = append(merged.Scopes, included.Scopes...)
+		merged.DenyUsers = append(merged.DenyUsers, included.DenyUsers...)
+		merged.DenyGroups = append(merged.DenyGroups, included.DenyGroups...)
+
+		included.Static, included.Scopes, included.DenyUsers, included.DenyGroups = nil, nil, nil, nil
+		if !reflect.DeepEqual(included, authz.Config{}) {
+			return fmt.Errorf("included file %s: only static, scopes, denyUsers and denyGroups of the authorization can be included", file)
+		}
+	}
+	return nil
+}
+
+// includeDigest returns the SHA-256 of the names and contents of the files
+// of the include directory.
+func includeDigest(dir string) (string, error) {
+	files, err := includeFiles(dir)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00", filepath.Base(file), len(b))
+		h.Write(b)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// watchIncludes reloads the inputs whenever the files of the include
+// directory change, such that tenant policies don't require a SIGHUP.
+func watchIncludes(ctx context.Context, dir string, interval time.Duration, reload *reloader) {
+	last, err := includeDigest(dir)
+	if err != nil {
+		klog.ErrorS(err, "Failed to read include directory", "path", dir)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		digest, err := includeDigest(dir)
+		if err != nil {
+			klog.ErrorS(err, "Failed to read include directory", "path", dir)
+			continue
+		}
+		if digest == last {
+			continue
+		}
+		last = digest
+
+		klog.InfoS("Included files changed, reloading", "path", dir)
+		if err := reload.reload(); err != nil {
+			klog.ErrorS(err, "Failed to reload, keeping the previous inputs")
+		}
+	}
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseConfigFileIncludes(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "tenants"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	write("config.yaml", `
+include: tenants
+authorization:
+  static:
+  - path: /metrics
+    verb: get
+`)
+	write("tenants/tenant-b.yaml", `
+authorization:
+  static:
+  - user:
+      groups: ["tenant-b"]
+    path: /tenants/b
+    verb: get
+`)
+	write("tenants/tenant-a.json", `{"authorization": {"denyUsers": ["mallory"]}}`)
+	write("tenants/README.md", "ignored")
+	write("tenants/.hidden.yaml", "ignored: true")
+
+	configFile, err := parseConfigFile(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	static := configFile.AuthorizationConfig.Static
+	if len(static) != 2 || static[0].Path != "/metrics" || static[1].Path != "/tenants/b" {
+		t.Errorf("want the static rules of the config file and the fragment, got %+v", static)
+	}
+	if deny := configFile.AuthorizationConfig.DenyUsers; len(deny) != 1 || deny[0] != "mallory" {
+		t.Errorf("want the denied users of the fragment, got %v", deny)
+	}
+
+	digest, err := includeDigest(filepath.Join(dir, "tenants"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	write("tenants/tenant-c.yaml", `
+authorization:
+  resourceAttributes:
+    resource: pods
+`)
+	if _, err := parseConfigFile(filepath.Join(dir, "config.yaml")); err == nil {
+		t.Error("want an error for a fragment with fields that can't be merged")
+	}
+
+	write("tenants/tenant-c.yaml", `
+authorisation:
+  static: []
+`)
+	if _, err := parseConfigFile(filepath.Join(dir, "config.yaml")); err == nil {
+		t.Error("want an error for a fragment with unknown fields")
+	}
+
+	changed, err := includeDigest(filepath.Join(dir, "tenants"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed == digest {
+		t.Error("want the digest to change with the included files")
+	}
+}
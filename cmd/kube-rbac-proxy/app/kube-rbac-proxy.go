@@ -17,6 +17,7 @@ limitations under the License.
 package app
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"crypto/x509"
@@ -31,6 +32,7 @@ import (
 	"path"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -40,22 +42,37 @@ import (
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apiserver/pkg/authentication/authenticator"
-	"k8s.io/apiserver/pkg/authorization/union"
+	"k8s.io/apiserver/pkg/authentication/request/bearertoken"
+	"k8s.io/apiserver/pkg/authentication/request/union"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	authzunion "k8s.io/apiserver/pkg/authorization/union"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
 	certutil "k8s.io/client-go/util/cert"
 	k8sapiflag "k8s.io/component-base/cli/flag"
 	"k8s.io/component-base/cli/globalflag"
 	"k8s.io/component-base/logs"
+	logsapi "k8s.io/component-base/logs/api/v1"
+	"k8s.io/component-base/metrics/legacyregistry"
 	"k8s.io/component-base/term"
 	"k8s.io/component-base/version/verflag"
 	"k8s.io/klog/v2"
 
 	"github.com/brancz/kube-rbac-proxy/cmd/kube-rbac-proxy/app/options"
+	"github.com/brancz/kube-rbac-proxy/pkg/audit"
 	"github.com/brancz/kube-rbac-proxy/pkg/authn"
 	"github.com/brancz/kube-rbac-proxy/pkg/authz"
+	"github.com/brancz/kube-rbac-proxy/pkg/faults"
+	"github.com/brancz/kube-rbac-proxy/pkg/features"
 	"github.com/brancz/kube-rbac-proxy/pkg/filters"
 	"github.com/brancz/kube-rbac-proxy/pkg/proxy"
 	rbac_proxy_tls "github.com/brancz/kube-rbac-proxy/pkg/tls"
@@ -79,6 +96,10 @@ that can perform RBAC authorization against the Kubernetes API using SubjectAcce
 		RunE: func(cmd *cobra.Command, args []string) error {
 			verflag.PrintAndExitIfRequested()
 
+			if err := logsapi.ValidateAndApply(o.Logs, features.DefaultFeatureGate); err != nil {
+				return err
+			}
+
 			fs := cmd.Flags()
 
 			k8sapiflag.PrintFlags(fs)
@@ -93,6 +114,10 @@ that can perform RBAC authorization against the Kubernetes API using SubjectAcce
 				return err
 			}
 
+			if o.DumpEffectiveConfig {
+				return dumpEffectiveConfig(cmd.OutOrStdout(), completedOptions.effectiveConfig())
+			}
+
 			return Run(completedOptions)
 		},
 		Args: func(cmd *cobra.Command, args []string) error {
@@ -113,6 +138,8 @@ that can perform RBAC authorization against the Kubernetes API using SubjectAcce
 		fs.AddFlagSet(f)
 	}
 
+	cmd.AddCommand(newGenSidecarCommand(), newGenRBACCommand(), newGenStaticAuthCommand(), newGenMonitoringCommand())
+
 	cols, _, _ := term.TerminalSize(cmd.OutOrStdout())
 	k8sapiflag.SetUsageAndHelpFunc(cmd, namedFlagSets, cols)
 
@@ -120,40 +147,193 @@ that can perform RBAC authorization against the Kubernetes API using SubjectAcce
 }
 
 type configfile struct {
-	AuthorizationConfig *authz.Config `json:"authorization,omitempty"`
+	AuthorizationConfig *authz.Config                  `json:"authorization,omitempty"`
+	ResponseHeaders     []filters.ResponseHeaderConfig `json:"responseHeaders,omitempty"`
+	QueryParameters     []filters.QueryParameterConfig `json:"queryParameters,omitempty"`
+	FlushIntervals      []proxy.FlushIntervalConfig    `json:"flushIntervals,omitempty"`
+	Listeners           []listenerConfig               `json:"listeners,omitempty"`
+	IdentityMappings    []authn.IdentityMapping        `json:"identityMappings,omitempty"`
+	ProxyEndpoints      *proxyEndpointsConfig          `json:"proxyEndpoints,omitempty"`
+	AccessLog           []filters.AccessLogConfig      `json:"accessLog,omitempty"`
+	Canaries            []proxy.CanaryConfig           `json:"canaries,omitempty"`
+	PriorityLevels      []filters.PriorityLevelConfig  `json:"priorityLevels,omitempty"`
+	UpstreamRouting     *proxy.UpstreamRoutingConfig   `json:"upstreamRouting,omitempty"`
+	StatusRewrites      []proxy.StatusRewriteConfig    `json:"upstreamStatusRewrites,omitempty"`
+	Routes              []proxy.RouteConfig            `json:"routes,omitempty"`
+	MethodOverride      *filters.MethodOverrideConfig  `json:"methodOverride,omitempty"`
+	// Include is a directory of policy fragments, which are merged into
+	// the authorization.
+	Include string `json:"include,omitempty"`
 }
 
 type completedProxyRunOptions struct {
 	insecureListenAddress string // DEPRECATED
-	secureListenAddress   string
+	secureListenAddresses []string
 	proxyEndpointsPort    int
 
-	upstreamURL      *url.URL
-	upstreamForceH2C bool
-	upstreamCABundle *x509.CertPool
+	upstreamURL              *url.URL
+	upstreamForceH2C         bool
+	upstreamCABundle         *x509.CertPool
+	upstreamCAFile           string
+	upstreamCAPEM            []byte
+	upstreamErrorDiagnostics bool
+	additionalUpstreamURLs   []*url.URL
+	upstreamAffinity         string
+	upstreamService          string
+	upstreamServicePort      string
+	upstreamDialOptions      upstreamDialOptions
+	upstreamConnectionMaxAge time.Duration
+	upstreamPingInterval     time.Duration
+	upstreamPingTimeout      time.Duration
+	upstreamExpectContinue   time.Duration
+	upstreamSigningKeyFile   string
+	upstreamMirrorURL        *url.URL
+	upstreamMirrorRate       float64
+	upstreamMirrorTimeout    time.Duration
+	compressionLevel         int
+	compressionMinSize       int64
+	weakETagMaxSize          int64
+	connectionBandwidthLimit int
+	totalBandwidthLimit      int
+	flushInterval            time.Duration
+	flushIntervals           []proxy.FlushIntervalConfig
+	canaries                 []proxy.CanaryConfig
+	upstreamRouting          *proxy.UpstreamRoutingConfig
+	upstreamRouter           *proxy.UpstreamRouter
+	statusRewrites           []proxy.StatusRewriteConfig
+	routes                   []proxy.RouteConfig
 
 	http2Disable bool
 	http2Options *http2.Server
 
+	maxHeaderBytes  int
+	requestLimits   filters.RequestLimits
+	bodyBuffer      filters.BodyBuffer
+	responseHeaders []filters.ResponseHeaderConfig
+	accessLog       []filters.AccessLogConfig
+	queryParameters []filters.QueryParameterConfig
+	methodOverride  *filters.MethodOverrideConfig
+
 	auth *proxy.Config
 	tls  *options.TLSConfig
+	// listeners are served next to the secure listen addresses, with their
+	// own authorization.
+	listeners []listenerConfig
+	// proxyEndpoints is nil, unless the endpoints of the
+	// --proxy-endpoints-port are authorized.
+	proxyEndpoints *proxyEndpointsConfig
 
 	kubeClient *kubernetes.Clientset
 
-	allowPaths  []string
-	ignorePaths []string
+	allowPaths      []filters.AllowPath
+	ignorePaths     []string
+	authRequestPath string
+	selfCheckPath   string
+	webhookPath     string
+	// webhookDelegate passes reviews of the webhook on to the delegated
+	// authorization.
+	webhookDelegate bool
+	connectionExtra bool
+	probes          filters.ProbeConfig
+	// landingPageRoutes are listed by the landing page, which is served
+	// if they aren't nil.
+	landingPageRoutes []string
+	// maintenance is nil, unless --maintenance-mode or --maintenance-paths
+	// is set.
+	maintenance *filters.Maintenance
+	// methodDenial is nil, if --denied-methods is empty.
+	methodDenial *filters.MethodDenial
+	// bearerChallenge is nil, if --auth-challenge-realm is empty.
+	bearerChallenge *filters.BearerChallenge
+	// priorityLevels is nil, unless the config file has priorityLevels.
+	priorityLevels *filters.PriorityLevels
+
+	// configFileName is empty, unless --config-file is set.
+	configFileName     string
+	configFileSections []byte
+	// configInclude is the include directory of the config file, if any.
+	configInclude string
+
+	shutdownDrainPeriod time.Duration
+	serverTiming        bool
+	memory              memoryTuning
+
+	authorizationCacheWatch bool
+	cacheBypassGroups       []string
+	logAuthorizationGrants  bool
+	authorizationLocalRBAC  bool
+	startupPermissionCheck  bool
+	// authorizationMetrics counts decisions by attributes, if not nil.
+	authorizationMetrics *authz.DecisionMetrics
+	denialEvents         authz.DenialEventsConfig
+
+	decisionExport *audit.ExportConfig
+
+	faults *faults.Config
 }
 
 func Complete(o *options.ProxyRunOptions) (*completedProxyRunOptions, error) {
 	var err error
 	completed := &completedProxyRunOptions{
 		insecureListenAddress: o.InsecureListenAddress,
-		secureListenAddress:   o.SecureListenAddress,
+		secureListenAddresses: o.SecureListenAddress,
 		proxyEndpointsPort:    o.ProxyEndpointsPort,
 		upstreamForceH2C:      o.UpstreamForceH2C,
 
-		allowPaths:  o.AllowPaths,
-		ignorePaths: o.IgnorePaths,
+		upstreamErrorDiagnostics: o.UpstreamErrorDiagnostics,
+		upstreamAffinity:         o.UpstreamAffinity,
+		upstreamService:          o.UpstreamService,
+		upstreamServicePort:      o.UpstreamServicePort,
+		upstreamDialOptions: upstreamDialOptions{
+			DNSServer:    o.UpstreamDNSServer,
+			IPFamily:     o.UpstreamIPFamily,
+			LocalAddress: o.UpstreamLocalAddress,
+			KeepAlive:    o.UpstreamKeepAlive,
+		},
+		upstreamConnectionMaxAge: o.UpstreamConnectionMaxAge,
+		upstreamPingInterval:     o.UpstreamPingInterval,
+		upstreamPingTimeout:      o.UpstreamPingTimeout,
+		upstreamExpectContinue:   o.UpstreamExpectContinue,
+		upstreamSigningKeyFile:   o.UpstreamSigningKeyFile,
+		upstreamMirrorRate:       o.UpstreamMirrorRate,
+		upstreamMirrorTimeout:    o.UpstreamMirrorTimeout,
+		compressionLevel:         o.CompressionLevel,
+		compressionMinSize:       o.CompressionMinSize,
+		weakETagMaxSize:          o.WeakETagMaxSize,
+		connectionBandwidthLimit: o.ConnectionBandwidthLimit,
+		totalBandwidthLimit:      o.TotalBandwidthLimit,
+		flushInterval:            o.UpstreamFlushInterval,
+
+		ignorePaths:     o.IgnorePaths,
+		authRequestPath: o.AuthRequestPath,
+		selfCheckPath:   o.SelfCheckPath,
+		webhookPath:     o.AuthorizationWebhookPath,
+		webhookDelegate: o.AuthorizationWebhookDelegate,
+		connectionExtra: o.AuthorizationConnectionExtra,
+		probes: filters.ProbeConfig{
+			Paths:           o.ProbePaths,
+			UserAgentPrefix: o.ProbeUserAgent,
+		},
+
+		authorizationCacheWatch: o.AuthorizationCacheWatch,
+		cacheBypassGroups:       o.CacheBypassGroups,
+		logAuthorizationGrants:  o.LogAuthorizationGrants,
+		authorizationLocalRBAC:  o.AuthorizationLocalRBAC,
+		startupPermissionCheck:  o.StartupPermissionCheck,
+		denialEvents:            o.AuthorizationDenialEvents,
+
+		decisionExport: o.DecisionExport,
+
+		faults: o.Faults,
+	}
+
+	if o.AuthorizationMetricsMaxSeries > 0 {
+		completed.authorizationMetrics = authz.NewDecisionMetrics(o.AuthorizationMetricsMaxSeries)
+	}
+
+	completed.allowPaths, err = filters.ParseAllowPaths(o.AllowPaths)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --allow-paths: %w", err)
 	}
 
 	completed.upstreamURL, err = url.Parse(o.Upstream)
@@ -161,44 +341,207 @@ func Complete(o *options.ProxyRunOptions) (*completedProxyRunOptions, error) {
 		return nil, fmt.Errorf("failed to parse upstream URL: %w", err)
 	}
 
-	if upstreamCAPath := o.UpstreamCAFile; len(upstreamCAPath) > 0 {
-		upstreamCAPEM, err := os.ReadFile(upstreamCAPath)
+	for _, upstream := range o.AdditionalUpstreams {
+		upstreamURL, err := url.Parse(upstream)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("failed to parse additional upstream URL: %w", err)
+		}
+		completed.additionalUpstreamURLs = append(completed.additionalUpstreamURLs, upstreamURL)
+	}
+
+	if o.UpstreamMirror != "" {
+		completed.upstreamMirrorURL, err = url.Parse(o.UpstreamMirror)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse upstream mirror URL: %w", err)
+		}
+		if scheme := completed.upstreamMirrorURL.Scheme; (scheme != "http" && scheme != "https") || completed.upstreamMirrorURL.Host == "" {
+			return nil, fmt.Errorf("upstream mirror URL %q must be an http or https URL with a host", o.UpstreamMirror)
 		}
+	}
 
-		upstreamCACertPool := x509.NewCertPool()
-		if ok := upstreamCACertPool.AppendCertsFromPEM(upstreamCAPEM); !ok {
-			return nil, errors.New("error parsing upstream CA certificate")
+	if upstreamCAPath := o.UpstreamCAFile; len(upstreamCAPath) > 0 {
+		completed.upstreamCABundle, completed.upstreamCAPEM, err = loadUpstreamCA(upstreamCAPath)
+		if err != nil {
+			return nil, err
 		}
-		completed.upstreamCABundle = upstreamCACertPool
+		completed.upstreamCAFile = upstreamCAPath
 	}
 
 	completed.auth = o.Auth
+	completed.auth.Authentication.OIDC.Proxy = newEgressProxy(o.EgressProxyURL, o.EgressNoProxy)
+	completed.auth.Authentication.Cloud.Proxy = completed.auth.Authentication.OIDC.Proxy
 	completed.tls = o.TLS
 
 	if configFileName := o.ConfigFileName; len(configFileName) > 0 {
-		completed.auth.Authorization, err = parseAuthorizationConfigFile(configFileName)
+		configFile, err := parseConfigFile(configFileName)
 		if err != nil {
 			return nil, fmt.Errorf("failed to read the config file: %w", err)
 		}
+		// The sections are taken before they are completed, to be compared
+		// with the config file on reloads.
+		completed.configFileName = configFileName
+		completed.configInclude = includePath(configFileName, configFile.Include)
+		completed.configFileSections, err = configFileSections(configFile)
+		if err != nil {
+			return nil, err
+		}
+		// Config files without authorization, e.g. of the response headers
+		// only, keep the default.
+		if configFile.AuthorizationConfig != nil {
+			completed.auth.Authorization = configFile.AuthorizationConfig
+		}
+
+		if err := filters.ValidateResponseHeaders(configFile.ResponseHeaders); err != nil {
+			return nil, fmt.Errorf("invalid config file: %w", err)
+		}
+		completed.responseHeaders = configFile.ResponseHeaders
+
+		if err := filters.ValidateAccessLog(configFile.AccessLog); err != nil {
+			return nil, fmt.Errorf("invalid config file: %w", err)
+		}
+		completed.accessLog = configFile.AccessLog
+
+		if err := filters.ValidateQueryParameters(configFile.QueryParameters); err != nil {
+			return nil, fmt.Errorf("invalid config file: %w", err)
+		}
+		completed.queryParameters = configFile.QueryParameters
+
+		if err := filters.ValidateMethodOverride(configFile.MethodOverride); err != nil {
+			return nil, fmt.Errorf("invalid config file: %w", err)
+		}
+		completed.methodOverride = configFile.MethodOverride
+
+		if err := proxy.ValidateFlushIntervals(configFile.FlushIntervals); err != nil {
+			return nil, fmt.Errorf("invalid config file: %w", err)
+		}
+		completed.flushIntervals = configFile.FlushIntervals
+
+		if err := proxy.ValidateCanaries(configFile.Canaries); err != nil {
+			return nil, fmt.Errorf("invalid config file: %w", err)
+		}
+		completed.canaries = configFile.Canaries
+
+		if err := proxy.ValidateStatusRewrites(configFile.StatusRewrites); err != nil {
+			return nil, fmt.Errorf("invalid config file: %w", err)
+		}
+		completed.statusRewrites = configFile.StatusRewrites
+
+		if err := proxy.ValidateRoutes(configFile.Routes); err != nil {
+			return nil, fmt.Errorf("invalid config file: %w", err)
+		}
+		completed.routes = configFile.Routes
+
+		if configFile.UpstreamRouting != nil {
+			if err := configFile.UpstreamRouting.Validate(); err != nil {
+				return nil, fmt.Errorf("invalid config file: %w", err)
+			}
+			completed.upstreamRouter, err = proxy.NewUpstreamRouter(configFile.UpstreamRouting)
+			if err != nil {
+				return nil, fmt.Errorf("invalid config file: %w", err)
+			}
+			completed.upstreamRouting = configFile.UpstreamRouting
+		}
+
+		if err := filters.ValidatePriorityLevels(configFile.PriorityLevels); err != nil {
+			return nil, fmt.Errorf("invalid config file: %w", err)
+		}
+		if len(configFile.PriorityLevels) > 0 {
+			completed.priorityLevels = filters.NewPriorityLevels(configFile.PriorityLevels)
+		}
+
+		completed.listeners = configFile.Listeners
+		completed.proxyEndpoints = configFile.ProxyEndpoints
+		completed.auth.Authentication.Cloud.IdentityMappings = configFile.IdentityMappings
 	}
 
-	kubeconfig, err := initKubeConfig(o.KubeconfigLocation)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+	if err := completeAuthorization(completed.auth.Authorization); err != nil {
+		return nil, err
 	}
 
-	if o.QPS > 0 {
-		kubeconfig.QPS = o.QPS
+	if len(completed.listeners) > 0 && len(completed.secureListenAddresses) == 0 {
+		return nil, errors.New("listeners require --secure-listen-address, they share its TLS configuration")
 	}
-	if o.Burst > 0 {
-		kubeconfig.Burst = o.Burst
+	for i := range completed.listeners {
+		l := &completed.listeners[i]
+		if l.Address == "" {
+			return nil, fmt.Errorf("listener %d has no address", i)
+		}
+		if l.Authorization == nil {
+			l.Authorization = &authz.Config{}
+		}
+		if err := completeAuthorization(l.Authorization); err != nil {
+			return nil, fmt.Errorf("invalid authorization of listener %s: %w", l.Address, err)
+		}
+		if err := authn.ValidateAudiences(l.Audiences); err != nil {
+			return nil, fmt.Errorf("invalid audiences of listener %s: %w", l.Address, err)
+		}
 	}
 
-	completed.kubeClient, err = kubernetes.NewForConfig(kubeconfig)
-	if err != nil {
-		return nil, fmt.Errorf("failed to instantiate Kubernetes client: %w", err)
+	if completed.proxyEndpoints != nil {
+		if completed.proxyEndpointsPort == 0 {
+			return nil, errors.New("proxyEndpoints requires --proxy-endpoints-port")
+		}
+		if completed.proxyEndpoints.Authorization == nil {
+			completed.proxyEndpoints.Authorization = &authz.Config{}
+		}
+		if err := completeAuthorization(completed.proxyEndpoints.Authorization); err != nil {
+			return nil, fmt.Errorf("invalid authorization of proxyEndpoints: %w", err)
+		}
+	}
+
+	if o.MaintenanceMode || len(o.MaintenancePaths) > 0 {
+		completed.maintenance = filters.NewMaintenance(o.MaintenancePaths, o.MaintenanceRetryAfter, o.MaintenanceMode)
+	}
+
+	if len(o.DeniedMethods) > 0 {
+		completed.methodDenial = &filters.MethodDenial{
+			Methods: sets.New(o.DeniedMethods...),
+			Status:  o.DeniedMethodsStatus,
+		}
+	}
+
+	if o.AuthChallengeRealm != "" {
+		completed.bearerChallenge = &filters.BearerChallenge{
+			Realm:      o.AuthChallengeRealm,
+			ErrorCodes: o.AuthChallengeErrorCodes,
+		}
+	}
+
+	completed.shutdownDrainPeriod = o.ShutdownDrainPeriod
+	completed.serverTiming = o.ServerTiming
+	completed.memory = memoryTuning{
+		gcPercent: o.GCPercent,
+		limit:     o.MemoryLimit,
+		ballast:   o.MemoryBallast,
+	}
+
+	if o.LandingPage {
+		completed.landingPageRoutes = append([]string{}, o.LandingPageRoutes...)
+	}
+
+	// The effective config can be dumped without access to a cluster.
+	if !o.DumpEffectiveConfig {
+		kubeconfig, err := initKubeConfig(o.KubeconfigLocation)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+		}
+
+		setRateLimits(kubeconfig, o)
+
+		completed.kubeClient, err = kubernetes.NewForConfig(kubeconfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to instantiate Kubernetes client: %w", err)
+		}
+	}
+
+	completed.maxHeaderBytes = o.MaxHeaderBytes
+	completed.requestLimits = filters.RequestLimits{
+		MaxHeaders:   o.MaxHeaders,
+		MaxURLLength: o.MaxURLLength,
+	}
+	completed.bodyBuffer = filters.BodyBuffer{
+		MaxSize: o.RequestBodyBufferSize,
+		Hash:    o.DecisionExportBodyHash,
 	}
 
 	completed.http2Disable = o.HTTP2Disable
@@ -213,11 +556,149 @@ func Complete(o *options.ProxyRunOptions) (*completedProxyRunOptions, error) {
 	return completed, nil
 }
 
+// completeAuthorization validates the authorization config and completes
+// its resource attributes in place.
+func completeAuthorization(authzConfig *authz.Config) error {
+	if authzConfig == nil {
+		return nil
+	}
+
+	if authzConfig.Rewrites != nil && authzConfig.Rewrites.Audit != nil {
+		if _, err := audit.NewRedactor(authzConfig.Rewrites.Audit.Redaction, authzConfig.Rewrites.Audit.TruncateLength); err != nil {
+			return fmt.Errorf("invalid rewrites audit configuration: %w", err)
+		}
+	}
+
+	if authzConfig.Rewrites != nil {
+		if err := authzConfig.Rewrites.ValidateDenialDetails(); err != nil {
+			return fmt.Errorf("invalid rewrites configuration: %w", err)
+		}
+	}
+
+	if exec := authzConfig.AttributesExec; exec != nil {
+		if len(exec.Command) == 0 {
+			return errors.New("attributesExec must have a command")
+		}
+		if len(authzConfig.AllResourceAttributes()) > 0 || authzConfig.Rewrites != nil {
+			return errors.New("attributesExec must not be set along with resourceAttributes, methodResourceAttributes or rewrites")
+		}
+		if exec.Timeout.Duration < 0 {
+			return errors.New("attributesExec timeout must not be negative")
+		}
+		if exec.Timeout.Duration == 0 {
+			exec.Timeout.Duration = 5 * time.Second
+		}
+	}
+
+	if authzConfig.APIServerPaths {
+		if len(authzConfig.AllResourceAttributes()) > 0 || authzConfig.Rewrites != nil || authzConfig.AttributesExec != nil {
+			return errors.New("apiServerPaths must not be set along with resourceAttributes, methodResourceAttributes, rewrites or attributesExec")
+		}
+	}
+
+	if webhook := authzConfig.ConstraintsWebhook; webhook != nil {
+		if err := webhook.Validate(); err != nil {
+			return err
+		}
+		if _, err := authz.NewConstraintsWebhook(webhook); err != nil {
+			return err
+		}
+	}
+
+	for method := range authzConfig.MethodResourceAttributes {
+		if method == "" || strings.ToUpper(method) != method {
+			return fmt.Errorf("methodResourceAttributes must be keyed by upper case HTTP methods, got %q", method)
+		}
+	}
+
+	for _, resourceAttributes := range authzConfig.AllResourceAttributes() {
+		if err := resourceAttributes.ExpandEnv(); err != nil {
+			return err
+		}
+		for _, field := range []string{resourceAttributes.Namespace, resourceAttributes.APIGroup, resourceAttributes.APIVersion, resourceAttributes.Resource, resourceAttributes.Subresource, resourceAttributes.Name} {
+			if _, err := proxy.ParseTemplate(field); err != nil {
+				return fmt.Errorf("invalid template in resource attributes: %w", err)
+			}
+		}
+
+		if nsFrom := resourceAttributes.NamespaceFrom; nsFrom != nil {
+			if nsFrom.Pod && nsFrom.HTTPHeader != "" {
+				return errors.New("namespaceFrom must not set pod and httpHeader at the same time")
+			}
+			// The namespace is taken from exactly one source, rather than
+			// overriding one with the other.
+			if resourceAttributes.Namespace != "" && (nsFrom.Pod || nsFrom.HTTPHeader != "") {
+				return errors.New("namespaceFrom must not be set along with namespace")
+			}
+
+			if nsFrom.Pod {
+				var err error
+				resourceAttributes.Namespace, err = podNamespace()
+				if err != nil {
+					return fmt.Errorf("failed to determine pod namespace: %w", err)
+				}
+				klog.InfoS("Using pod namespace for resource attributes", "namespace", resourceAttributes.Namespace)
+			}
+		}
+	}
+
+	return nil
+}
+
+// setRateLimits sets the client-side rate limits of the Kubernetes client.
+func setRateLimits(kubeconfig *rest.Config, o *options.ProxyRunOptions) {
+	if o.QPS > 0 {
+		kubeconfig.QPS = o.QPS
+	}
+	if o.Burst > 0 {
+		kubeconfig.Burst = o.Burst
+	}
+	if o.PriorityAndFairness {
+		// A negative QPS disables the client-side rate limiter, the API
+		// server's priority and fairness signals back-pressure with 429s,
+		// which client-go retries according to Retry-After.
+		kubeconfig.QPS = -1
+	}
+}
+
 func Run(cfg *completedProxyRunOptions) error {
 	var authenticator authenticator.Request
+	var login *authn.OIDCLogin
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	hash, err := configHash(cfg.effectiveConfig())
+	if err != nil {
+		return err
+	}
+	var currentVersion atomic.Pointer[versionInfo]
+	currentVersion.Store(newVersionInfo(hash))
+	klog.InfoS("Starting kube-rbac-proxy", "version", currentVersion.Load())
+
+	cfg.memory.apply()
+
+	// The file-based inputs are reloaded on SIGHUP.
+	reload := &reloader{}
+	features.DefaultMutableFeatureGate.AddMetrics()
+
+	readiness := filters.NewReadiness()
+
+	if cfg.startupPermissionCheck {
+		permissions := []authz.Permission{authz.SubjectAccessReviewPermission}
+		if cfg.auth.Authentication.OIDC.IssuerURL == "" {
+			permissions = append(permissions, authz.TokenReviewPermission)
+		}
+		if cfg.denialEvents.Threshold > 0 {
+			permissions = append(permissions, authz.EventPermissions...)
+		}
+		checkCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		err := authz.CheckPermissions(checkCtx, cfg.kubeClient.AuthorizationV1(), permissions)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("missing permissions: %w", err)
+		}
+	}
+
 	// If OIDC configuration provided, use oidc authenticator
 	if cfg.auth.Authentication.OIDC.IssuerURL != "" {
 		oidcAuthenticator, err := authn.NewOIDCAuthenticator(ctx, cfg.auth.Authentication.OIDC)
@@ -227,9 +708,18 @@ func Run(cfg *completedProxyRunOptions) error {
 
 		go oidcAuthenticator.Run(ctx)
 		authenticator = oidcAuthenticator
+		readiness.AddWarmUpCheck("oidc-discovery", oidcAuthenticator.HealthCheck)
+
+		if cfg.auth.Authentication.OIDC.LoginRedirectURL != "" {
+			login, err = authn.NewOIDCLogin(cfg.auth.Authentication.OIDC, oidcAuthenticator)
+			if err != nil {
+				return fmt.Errorf("failed to set up OIDC login: %w", err)
+			}
+			authenticator = union.New(oidcAuthenticator, login)
+		}
 	} else {
 		//Use Delegating authenticator
-		klog.Infof("Valid token audiences: %s", strings.Join(cfg.auth.Authentication.Token.Audiences, ", "))
+		klog.InfoS("Valid token audiences", "audiences", cfg.auth.Authentication.Token.Audiences)
 
 		tokenClient := cfg.kubeClient.AuthenticationV1()
 		delegatingAuthenticator, err := authn.NewDelegatingAuthenticator(tokenClient, cfg.auth.Authentication)
@@ -239,87 +729,471 @@ func Run(cfg *completedProxyRunOptions) error {
 
 		go delegatingAuthenticator.Run(ctx)
 		authenticator = delegatingAuthenticator
+		if cfg.auth.Authentication.X509.ClientCAFile != "" {
+			reload.add("client CA file", func() (func(), error) {
+				return delegatingAuthenticator.PrepareReload(ctx)
+			})
+		}
+
+		if token := cfg.auth.Authentication.Token; token.CacheFile != "" {
+			tokenCache, err := authn.NewPersistentTokenCache(token.CacheFile, token.CacheKeyFile, token.CacheMaxAge)
+			if err != nil {
+				return fmt.Errorf("failed to load token cache: %w", err)
+			}
+
+			go tokenCache.Run(ctx)
+			authenticator = tokenCache.WithFallback(authenticator)
+		}
+		authenticator = authn.WithAudiencePatterns(authenticator)
+
+		if token := cfg.auth.Authentication.Token; len(token.ServiceAccountNamespaces) > 0 {
+			authenticator = authn.WithServiceAccountNamespaces(authenticator, token.ServiceAccountNamespaces, token.Audiences)
+		}
+	}
+
+	if tokenFile := cfg.auth.Authentication.Token.AuthFile; tokenFile != "" {
+		tokenFileAuthenticator, err := authn.NewTokenFileAuthenticator(tokenFile, cfg.tls.ReloadInterval)
+		if err != nil {
+			return fmt.Errorf("failed to instantiate token file authenticator: %w", err)
+		}
+
+		go tokenFileAuthenticator.Run(ctx)
+		reload.add("token auth file", tokenFileAuthenticator.PrepareReload)
+		authenticator = union.New(bearertoken.New(tokenFileAuthenticator), authenticator)
+	}
+
+	if cloudAuthenticator := authn.NewCloudAuthenticator(ctx, cfg.auth.Authentication.Cloud); cloudAuthenticator != nil {
+		authenticator = union.New(cloudAuthenticator, authenticator)
+	}
+
+	if cfg.auth.Authentication.LDAP.URL != "" {
+		ldapResolver, err := authn.NewLDAPGroupResolver(cfg.auth.Authentication.LDAP)
+		if err != nil {
+			return fmt.Errorf("failed to instantiate LDAP group resolver: %w", err)
+		}
+		authenticator = authn.WithLDAPGroups(authenticator, ldapResolver)
+	}
+
+	if cfg.faults.Enabled() {
+		klog.InfoS("Warning: injecting faults for resilience testing", "faults", *cfg.faults)
 	}
+	authenticator = faults.WithAuthenticationFailures(authenticator, cfg.faults.AuthenticationFailureRate)
 
 	sarClient := cfg.kubeClient.AuthorizationV1()
 	sarAuthorizer, err := authz.NewSarAuthorizer(sarClient)
 	if err != nil {
 		return fmt.Errorf("failed to create sar authorizer: %w", err)
 	}
+	readiness.AddWarmUpCheck("subjectaccessreview", func(ctx context.Context) error {
+		// Any decision tells that SubjectAccessReviews can be created.
+		_, _, err := sarAuthorizer.Authorize(ctx, authorizer.AttributesRecord{
+			User: &user.DefaultInfo{Name: "system:anonymous"},
+			Verb: "get",
+			Path: "/readyz",
+		})
+		return err
+	})
+	go readiness.Run(ctx, time.Second)
+
+	rbacAuthorizer := faults.WithSARLatency(sarAuthorizer, cfg.faults.SARLatency, cfg.faults.SARLatencyRate)
+	var localRBACAuthorizer *authz.LocalRBACAuthorizer
+	if cfg.authorizationLocalRBAC {
+		localRBACAuthorizer = authz.NewLocalRBACAuthorizer(cfg.kubeClient, rbacAuthorizer)
+		rbacAuthorizer = localRBACAuthorizer
+	}
+
+	var exporter *audit.Exporter
+	if cfg.decisionExport.Sink != "" {
+		sink, err := audit.NewSink(cfg.decisionExport)
+		if err != nil {
+			return fmt.Errorf("failed to create decision export sink: %w", err)
+		}
+
+		exporter = audit.NewExporter(sink, cfg.decisionExport.BufferSize)
+		go exporter.Run(ctx)
+
+		if cfg.methodDenial != nil {
+			cfg.methodDenial.Exporter = exporter
+		}
+	}
+
+	var denialEvents *authz.DenialEvents
+	if cfg.denialEvents.Threshold > 0 {
+		pod, err := podReference()
+		if err != nil {
+			return fmt.Errorf("failed to determine the pod for denial events: %w", err)
+		}
+		broadcaster := record.NewBroadcaster()
+		broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: cfg.kubeClient.CoreV1().Events(pod.Namespace)})
+		defer broadcaster.Shutdown()
+		recorder := broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "kube-rbac-proxy"})
+		denialEvents = authz.NewDenialEvents(recorder, pod, cfg.denialEvents)
+	}
 
-	staticAuthorizer, err := authz.NewStaticAuthorizer(cfg.auth.Authorization.Static)
+	grantLoggingAuthorizer := authz.WithGrantLogging(rbacAuthorizer, cfg.logAuthorizationGrants)
+	authorizer, err := newAuthorizer(cfg.auth.Authorization, grantLoggingAuthorizer, exporter, cfg.authorizationMetrics, denialEvents)
 	if err != nil {
-		return fmt.Errorf("failed to create static authorizer: %w", err)
+		return err
 	}
 
-	authorizer := union.New(
-		staticAuthorizer,
-		sarAuthorizer,
-	)
+	reviewAuthorizer, err := newWebhookReviewAuthorizer(cfg, cfg.auth.Authorization, authorizer, exporter)
+	if err != nil {
+		return err
+	}
+
+	upstreamDialer, err := newUpstreamDialer(cfg.upstreamDialOptions)
+	if err != nil {
+		return fmt.Errorf("failed to set up upstream dialer: %w", err)
+	}
+
+	var connectionRecycler *proxy.ConnectionRecycler
+	if cfg.upstreamConnectionMaxAge > 0 {
+		connectionRecycler = proxy.NewConnectionRecycler(cfg.upstreamConnectionMaxAge)
+		if upstreamDialer == nil {
+			upstreamDialer = (&net.Dialer{
+				Timeout:   30 * time.Second,
+				KeepAlive: 30 * time.Second,
+			}).DialContext
+		}
+		upstreamDialer = connectionRecycler.DialContext(upstreamDialer)
+	}
+
+	newUpstreamTransport := func(upstreamCABundle *x509.CertPool) (http.RoundTripper, error) {
+		upstreamTransport, err := initTransport(upstreamCABundle, cfg.tls.UpstreamClientCertFile, cfg.tls.UpstreamClientKeyFile, cfg.tls.UpstreamKeyPassphrase, upstreamDialer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up upstream TLS connection: %w", err)
+		}
+
+		upstreamTransport, err = withExpectContinueTimeout(upstreamTransport, cfg.upstreamExpectContinue)
+		if err != nil {
+			return nil, err
+		}
+
+		if cfg.upstreamPingInterval > 0 {
+			upstreamTransport, err = withHTTP2Pings(upstreamTransport, cfg.upstreamPingInterval, cfg.upstreamPingTimeout)
+			if err != nil {
+				return nil, fmt.Errorf("failed to set up upstream HTTP/2 pings: %w", err)
+			}
+		}
+		return upstreamTransport, nil
+	}
 
-	upstreamTransport, err := initTransport(cfg.upstreamCABundle, cfg.tls.UpstreamClientCertFile, cfg.tls.UpstreamClientKeyFile)
+	upstreamTransport, err := newUpstreamTransport(cfg.upstreamCABundle)
 	if err != nil {
-		return fmt.Errorf("failed to set up upstream TLS connection: %w", err)
+		return err
+	}
+
+	if cfg.upstreamCAFile != "" {
+		reloadable, err := newReloadableTransport(upstreamTransport)
+		if err != nil {
+			return err
+		}
+		upstreamCAPEM := cfg.upstreamCAPEM
+		reload.add("upstream CA file", func() (func(), error) {
+			pool, pem, err := loadUpstreamCA(cfg.upstreamCAFile)
+			if err != nil {
+				return nil, err
+			}
+			if bytes.Equal(pem, upstreamCAPEM) {
+				return func() {}, nil
+			}
+			t, err := newUpstreamTransport(pool)
+			if err != nil {
+				return nil, err
+			}
+			return func() {
+				// The transport was built alike, storing it can't fail.
+				_ = reloadable.store(t)
+				upstreamCAPEM = pem
+			}, nil
+		})
+		upstreamTransport = reloadable
+	}
+
+	reverseProxy := httputil.NewSingleHostReverseProxy(cfg.upstreamURL)
+	reverseProxy.Transport = upstreamTransport
+	reverseProxy.ErrorHandler = proxy.NewUpstreamErrorHandler(cfg.upstreamErrorDiagnostics)
+	reverseProxy.FlushInterval = cfg.flushInterval
+
+	var gr run.Group
+
+	if cfg.authorizationCacheWatch {
+		rbacWatcher := authz.NewRBACWatcher(cfg.kubeClient, sarAuthorizer)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		gr.Add(func() error {
+			return rbacWatcher.Run(ctx)
+		}, func(error) {
+			cancel()
+		})
+	}
+
+	if localRBACAuthorizer != nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		gr.Add(func() error {
+			return localRBACAuthorizer.Run(ctx)
+		}, func(error) {
+			cancel()
+		})
+	}
+
+	if len(cfg.additionalUpstreamURLs) > 0 || cfg.upstreamService != "" {
+		// With service discovery, --upstream is used until the first sync.
+		balancer, err := proxy.NewBalancer(append([]*url.URL{cfg.upstreamURL}, cfg.additionalUpstreamURLs...), cfg.upstreamAffinity)
+		if err != nil {
+			return fmt.Errorf("failed to set up upstream balancing: %w", err)
+		}
+		reverseProxy.Director = balancer.Director
+		reverseProxy.ModifyResponse = balancer.ModifyResponse
+
+		if cfg.upstreamService != "" {
+			namespace, name, _ := strings.Cut(cfg.upstreamService, "/")
+			discovery := proxy.NewEndpointSliceDiscovery(cfg.kubeClient, namespace, name, cfg.upstreamServicePort, cfg.upstreamURL, balancer)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			gr.Add(func() error {
+				return discovery.Run(ctx)
+			}, func(error) {
+				cancel()
+			})
+		}
+	}
+
+	if len(cfg.canaries) > 0 {
+		canaryRouter, err := proxy.NewCanaryRouter(cfg.canaries)
+		if err != nil {
+			return fmt.Errorf("failed to set up canary routing: %w", err)
+		}
+		reverseProxy.Director = canaryRouter.Director(reverseProxy.Director)
+	}
+
+	if cfg.upstreamRouter != nil {
+		// The selected upstream takes precedence over the canaries.
+		reverseProxy.Director = cfg.upstreamRouter.Director(reverseProxy.Director)
+	}
+
+	if login != nil {
+		// The session cookie is the credential of the browser login.
+		reverseProxy.Director = proxy.WithoutCookie(reverseProxy.Director, authn.LoginSessionCookieName)
+	}
+
+	if len(cfg.statusRewrites) > 0 {
+		statusRewriter := proxy.NewStatusRewriter(cfg.statusRewrites)
+
+		modifyResponse := reverseProxy.ModifyResponse
+		reverseProxy.ModifyResponse = func(resp *http.Response) error {
+			if modifyResponse != nil {
+				if err := modifyResponse(resp); err != nil {
+					return err
+				}
+			}
+			return statusRewriter.ModifyResponse(resp)
+		}
+	}
+
+	if cfg.weakETagMaxSize > 0 {
+		// The ETags are of the uncompressed content.
+		etagger := proxy.NewETagger(cfg.weakETagMaxSize)
+
+		modifyResponse := reverseProxy.ModifyResponse
+		reverseProxy.ModifyResponse = func(resp *http.Response) error {
+			if modifyResponse != nil {
+				if err := modifyResponse(resp); err != nil {
+					return err
+				}
+			}
+			return etagger.ModifyResponse(resp)
+		}
+	}
+
+	if cfg.compressionLevel != 0 {
+		compressor, err := proxy.NewCompressor(cfg.compressionLevel, cfg.compressionMinSize)
+		if err != nil {
+			return fmt.Errorf("failed to set up response compression: %w", err)
+		}
+
+		modifyResponse := reverseProxy.ModifyResponse
+		reverseProxy.ModifyResponse = func(resp *http.Response) error {
+			if modifyResponse != nil {
+				if err := modifyResponse(resp); err != nil {
+					return err
+				}
+			}
+			return compressor.ModifyResponse(resp)
+		}
 	}
 
-	proxy := httputil.NewSingleHostReverseProxy(cfg.upstreamURL)
-	proxy.Transport = upstreamTransport
+	if cfg.connectionBandwidthLimit > 0 || cfg.totalBandwidthLimit > 0 {
+		// The limits apply to the bytes sent, i.e. after the compression.
+		bandwidthLimiter := proxy.NewBandwidthLimiter(cfg.connectionBandwidthLimit, cfg.totalBandwidthLimit)
+
+		modifyResponse := reverseProxy.ModifyResponse
+		reverseProxy.ModifyResponse = func(resp *http.Response) error {
+			if modifyResponse != nil {
+				if err := modifyResponse(resp); err != nil {
+					return err
+				}
+			}
+			return bandwidthLimiter.ModifyResponse(resp)
+		}
+	}
 
 	if cfg.upstreamForceH2C {
 		// Force http/2 for connections to the upstream i.e. do not start with HTTP1.1 UPGRADE req to
 		// initialize http/2 session.
 		// See https://github.com/golang/go/issues/14141#issuecomment-219212895 for more context
-		proxy.Transport = &http2.Transport{
+		reverseProxy.Transport = &http2.Transport{
 			// Allow http schema. This doesn't automatically disable TLS
-			AllowHTTP: true,
+			AllowHTTP:       true,
+			ReadIdleTimeout: cfg.upstreamPingInterval,
+			PingTimeout:     cfg.upstreamPingTimeout,
 			// Do disable TLS.
 			// In combination with the schema check above. We could enforce h2c against the upstream server
 			DialTLS: func(netw, addr string, cfg *tls.Config) (net.Conn, error) {
+				if upstreamDialer != nil {
+					return upstreamDialer(context.Background(), netw, addr)
+				}
 				return net.Dial(netw, addr)
 			},
 		}
 	}
 
-	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-		ignorePathFound := false
-		for _, pathIgnored := range cfg.ignorePaths {
-			ignorePathFound, err = path.Match(pathIgnored, req.URL.Path)
+	if connectionRecycler != nil {
+		reverseProxy.Transport = connectionRecycler.RoundTripper(reverseProxy.Transport)
+		go connectionRecycler.Run(ctx)
+	}
+
+	if cfg.upstreamMirrorURL != nil {
+		// The mirror is connected to like the upstream, and receives the
+		// requests as signed for the upstream.
+		mirror := proxy.NewMirror(cfg.upstreamMirrorURL, cfg.upstreamMirrorRate, cfg.upstreamMirrorTimeout, upstreamTransport)
+		reverseProxy.Transport = mirror.RoundTripper(reverseProxy.Transport)
+	}
+
+	if cfg.upstreamSigningKeyFile != "" {
+		signer, err := proxy.NewRequestSigner(cfg.upstreamSigningKeyFile, cfg.auth.Authentication.Header)
+		if err != nil {
+			return fmt.Errorf("failed to set up upstream request signing: %w", err)
+		}
+		reverseProxy.Transport = signer.RoundTripper(reverseProxy.Transport)
+	}
+	reverseProxy.Transport = faults.WithUpstreamErrors(reverseProxy.Transport, cfg.faults.UpstreamErrorRate)
+	if cfg.bodyBuffer.MaxSize > 0 {
+		reverseProxy.Transport = proxy.WithConnectionResetRetry(reverseProxy.Transport)
+	}
+
+	proxyHandler := proxy.WithFlushIntervals(reverseProxy, cfg.flushIntervals)
+
+	rootHandler := newReloadableHandler(newProxyHandler(cfg, proxyHandler, authenticator, login, authorizer, reviewAuthorizer, cfg.auth.Authorization, cfg.auth.Authentication.Token.Audiences))
+	if cfg.configFileName != "" {
+		reload.add("config file", func() (func(), error) {
+			authzConfig, err := reloadAuthorization(cfg.configFileName, cfg.configFileSections)
 			if err != nil {
-				http.Error(
-					w,
-					http.StatusText(http.StatusInternalServerError),
-					http.StatusInternalServerError,
-				)
-				return
+				return nil, err
 			}
-			if ignorePathFound {
-				break
+			reloadedAuthorizer, err := newAuthorizer(authzConfig, grantLoggingAuthorizer, exporter, cfg.authorizationMetrics, denialEvents)
+			if err != nil {
+				return nil, err
 			}
+			reloadedReviewAuthorizer, err := newWebhookReviewAuthorizer(cfg, authzConfig, reloadedAuthorizer, exporter)
+			if err != nil {
+				return nil, err
+			}
+			handler := newProxyHandler(cfg, proxyHandler, authenticator, login, reloadedAuthorizer, reloadedReviewAuthorizer, authzConfig, cfg.auth.Authentication.Token.Audiences)
+
+			effective := cfg.effectiveConfig()
+			effective.Authorization = authzConfig
+			hash, err := configHash(effective)
+			if err != nil {
+				return nil, err
+			}
+
+			return func() {
+				rootHandler.store(handler)
+				cfg.auth.Authorization = authzConfig
+				currentVersion.Store(newVersionInfo(hash))
+			}, nil
+		})
+
+		if cfg.configInclude != "" {
+			ctx, cancel := context.WithCancel(context.Background())
+			gr.Add(func() error {
+				watchIncludes(ctx, cfg.configInclude, cfg.tls.ReloadInterval, reload)
+				return nil
+			}, func(error) {
+				cancel()
+			})
 		}
+	}
 
-		if !ignorePathFound {
-			handlerFunc := proxy.ServeHTTP
-			handlerFunc = filters.WithAuthHeaders(cfg.auth.Authentication.Header, handlerFunc)
-			handlerFunc = filters.WithAuthorization(authorizer, cfg.auth.Authorization, handlerFunc)
-			handlerFunc = filters.WithAuthentication(authenticator, cfg.auth.Authentication.Token.Audiences, handlerFunc)
-			handlerFunc(w, req)
+	type listenerHandler struct {
+		address string
+		handler http.Handler
+	}
+	var listenerHandlers []listenerHandler
+	for _, l := range cfg.listeners {
+		listenerRBACAuthorizer := grantLoggingAuthorizer
+		if l.StaticOnly {
+			listenerRBACAuthorizer = nil
+		}
+		listenerAuthorizer, err := newAuthorizer(l.Authorization, listenerRBACAuthorizer, exporter, cfg.authorizationMetrics, denialEvents)
+		if err != nil {
+			return fmt.Errorf("failed to create authorizer of listener %s: %w", l.Address, err)
+		}
+		listenerReviewAuthorizer, err := newWebhookReviewAuthorizer(cfg, l.Authorization, listenerAuthorizer, exporter)
+		if err != nil {
+			return fmt.Errorf("failed to create authorizer of listener %s: %w", l.Address, err)
+		}
 
-			return
+		audiences := l.Audiences
+		if len(audiences) == 0 {
+			audiences = cfg.auth.Authentication.Token.Audiences
 		}
+		listenerHandlers = append(listenerHandlers, listenerHandler{
+			address: l.Address,
+			handler: newProxyHandler(cfg, proxyHandler, authenticator, login, listenerAuthorizer, listenerReviewAuthorizer, l.Authorization, audiences),
+		})
+	}
 
-		proxy.ServeHTTP(w, req)
-	})
-	handler = filters.WithAllowPaths(cfg.allowPaths, handler)
+	authorizeProxyEndpoints := func(h http.HandlerFunc) http.HandlerFunc { return h }
+	if cfg.proxyEndpoints != nil {
+		endpointsRBACAuthorizer := grantLoggingAuthorizer
+		if cfg.proxyEndpoints.StaticOnly {
+			endpointsRBACAuthorizer = nil
+		}
+		endpointsAuthorizer, err := newAuthorizer(cfg.proxyEndpoints.Authorization, endpointsRBACAuthorizer, exporter, cfg.authorizationMetrics, denialEvents)
+		if err != nil {
+			return fmt.Errorf("failed to create authorizer of proxy endpoints: %w", err)
+		}
+		authorizeProxyEndpoints = func(h http.HandlerFunc) http.HandlerFunc {
+			h = filters.WithAuthorization(endpointsAuthorizer, cfg.proxyEndpoints.Authorization, h)
+			return filters.WithAuthenticationChallenge(authenticator, cfg.auth.Authentication.Token.Audiences, cfg.bearerChallenge, h)
+		}
+	}
 
-	mux := http.NewServeMux()
-	mux.Handle("/", handler)
+	// The debug endpoints change the state of the proxy, e.g. flushing the
+	// authorization cache causes a burst of SubjectAccessReviews, so they are
+	// authorized also without proxyEndpoints, as non-resource URLs, e.g.
+	// create of /debug/authorization-cache/flush.
+	authorizeDebugEndpoints := func(h http.HandlerFunc) http.HandlerFunc { return h }
+	if cfg.proxyEndpoints == nil {
+		debugConfig := &authz.Config{DenyUsers: cfg.auth.Authorization.DenyUsers, DenyGroups: cfg.auth.Authorization.DenyGroups}
+		debugAuthorizer, err := newAuthorizer(debugConfig, grantLoggingAuthorizer, exporter, cfg.authorizationMetrics, denialEvents)
+		if err != nil {
+			return fmt.Errorf("failed to create authorizer of debug endpoints: %w", err)
+		}
+		authorizeDebugEndpoints = func(h http.HandlerFunc) http.HandlerFunc {
+			h = filters.WithAuthorization(debugAuthorizer, debugConfig, h)
+			return filters.WithAuthenticationChallenge(authenticator, cfg.auth.Authentication.Token.Audiences, cfg.bearerChallenge, h)
+		}
+	}
 
-	var gr run.Group
 	{
-		if cfg.secureListenAddress != "" {
+		if len(cfg.secureListenAddresses) > 0 {
 			srv := &http.Server{
-				Handler:   mux,
-				TLSConfig: &tls.Config{},
+				Handler:        rootHandler,
+				TLSConfig:      &tls.Config{},
+				MaxHeaderBytes: cfg.maxHeaderBytes,
 			}
 
 			if cfg.tls.CertFile == "" && cfg.tls.KeyFile == "" {
@@ -340,10 +1214,17 @@ func Run(cfg *completedProxyRunOptions) error {
 				srv.TLSConfig.Certificates = []tls.Certificate{cert}
 			} else {
 				klog.Info("Reading certificate files")
-				r, err := rbac_proxy_tls.NewCertReloader(cfg.tls.CertFile, cfg.tls.KeyFile, cfg.tls.ReloadInterval)
+				var r *rbac_proxy_tls.CertReloader
+				var err error
+				if cfg.tls.KeyPKCS11URI != "" {
+					r, err = rbac_proxy_tls.NewPKCS11CertReloader(cfg.tls.CertFile, cfg.tls.KeyPKCS11URI, cfg.tls.ReloadInterval)
+				} else {
+					r, err = rbac_proxy_tls.NewCertReloader(cfg.tls.CertFile, cfg.tls.KeyFile, cfg.tls.KeyPassphrase, cfg.tls.ReloadInterval)
+				}
 				if err != nil {
 					return fmt.Errorf("failed to initialize certificate reloader: %w", err)
 				}
+				reload.add("serving certificate", r.PrepareReload)
 
 				srv.TLSConfig.GetCertificate = r.GetCertificate
 
@@ -355,6 +1236,48 @@ func Run(cfg *completedProxyRunOptions) error {
 				})
 			}
 
+			var verifyConnection []func(tls.ConnectionState) error
+
+			var sni *rbac_proxy_tls.SNISelector
+			if len(cfg.tls.SNICertKeys) > 0 || len(cfg.tls.SNIClientCAFiles) > 0 {
+				defaultCert := srv.TLSConfig.GetCertificate
+				if defaultCert == nil {
+					// Returning no certificate falls back to the self-signed one.
+					defaultCert = func(*tls.ClientHelloInfo) (*tls.Certificate, error) { return nil, nil }
+				}
+				sni = rbac_proxy_tls.NewSNISelector(defaultCert)
+
+				for _, nkc := range cfg.tls.SNICertKeys {
+					r, err := rbac_proxy_tls.NewCertReloader(nkc.CertFile, nkc.KeyFile, cfg.tls.KeyPassphrase, cfg.tls.ReloadInterval)
+					if err != nil {
+						return fmt.Errorf("failed to initialize SNI certificate reloader: %w", err)
+					}
+					reload.add("SNI certificate "+nkc.CertFile, r.PrepareReload)
+					if err := sni.AddCertificate(r, nkc.Names); err != nil {
+						return err
+					}
+
+					ctx, cancel := context.WithCancel(context.Background())
+					gr.Add(func() error {
+						return r.Watch(ctx)
+					}, func(error) {
+						cancel()
+					})
+				}
+
+				for name, caFile := range cfg.tls.SNIClientCAFiles {
+					if err := sni.AddClientCA(name, caFile); err != nil {
+						return err
+					}
+				}
+
+				srv.TLSConfig.GetCertificate = sni.GetCertificate
+				verifyConnection = append(verifyConnection, sni.VerifyConnection)
+				// The client CA is required by the server name, whereas requests
+				// are routed by their Host.
+				srv.Handler = sni.WithHostCheck(srv.Handler)
+			}
+
 			version, err := k8sapiflag.TLSVersion(cfg.tls.MinVersion)
 			if err != nil {
 				return fmt.Errorf("TLS version invalid: %w", err)
@@ -367,7 +1290,68 @@ func Run(cfg *completedProxyRunOptions) error {
 
 			srv.TLSConfig.CipherSuites = cipherSuiteIDs
 			srv.TLSConfig.MinVersion = version
-			srv.TLSConfig.ClientAuth = tls.RequestClientCert
+			srv.TLSConfig.ClientAuth, err = rbac_proxy_tls.ClientAuthType(cfg.tls.ClientAuthPolicy)
+			if err != nil {
+				return err
+			}
+			x509 := cfg.auth.Authentication.X509
+			completeChains := x509.IntermediatesFile != "" || len(x509.AIAHosts) > 0
+			var chains *rbac_proxy_tls.ChainVerifier
+			if srv.TLSConfig.ClientAuth == tls.RequireAndVerifyClientCert || completeChains {
+				var policy *rbac_proxy_tls.CertificatePolicy
+				if len(cfg.tls.ClientCertRequiredEKUs) > 0 || len(cfg.tls.ClientCertSignatureAlgorithms) > 0 {
+					policy, err = rbac_proxy_tls.ParseCertificatePolicy(cfg.tls.ClientCertRequiredEKUs, cfg.tls.ClientCertSignatureAlgorithms)
+					if err != nil {
+						return fmt.Errorf("invalid client certificate policy: %w", err)
+					}
+				}
+				chains, err = rbac_proxy_tls.NewChainVerifier(x509.ClientCAFile, x509.IntermediatesFile, x509.AIAHosts, policy)
+				if err != nil {
+					return fmt.Errorf("failed to initialize client certificate chain verifier: %w", err)
+				}
+				reload.add("client certificate chains", chains.PrepareReload)
+			}
+			if srv.TLSConfig.ClientAuth == tls.RequireAndVerifyClientCert {
+				// The CAs are still sent to clients as acceptable authorities.
+				srv.TLSConfig.ClientCAs, err = certutil.NewPool(x509.ClientCAFile)
+				if err != nil {
+					return fmt.Errorf("failed to load client CA: %w", err)
+				}
+				// The chain verifier verifies the client certificate instead of
+				// the handshake, such that failures are diagnosed by reason.
+				srv.TLSConfig.ClientAuth = tls.RequireAnyClientCert
+				verifyConnection = append([]func(tls.ConnectionState) error{chains.VerifyConnection}, verifyConnection...)
+			}
+			if completeChains {
+				srv.Handler = chains.WithCompletedChains(srv.Handler)
+			}
+
+			if x509.CRLFile != "" || x509.OCSPCheck {
+				revocation, err := rbac_proxy_tls.NewRevocationChecker(x509.CRLFile, cfg.tls.ReloadInterval, x509.OCSPCheck, x509.ClientCAFile)
+				if err != nil {
+					return fmt.Errorf("failed to initialize client certificate revocation checker: %w", err)
+				}
+
+				verifyConnection = append(verifyConnection, revocation.VerifyConnection)
+
+				ctx, cancel := context.WithCancel(context.Background())
+				gr.Add(func() error {
+					return revocation.Watch(ctx)
+				}, func(error) {
+					cancel()
+				})
+			}
+
+			if len(verifyConnection) > 0 {
+				srv.TLSConfig.VerifyConnection = func(cs tls.ConnectionState) error {
+					for _, verify := range verifyConnection {
+						if err := verify(cs); err != nil {
+							return err
+						}
+					}
+					return nil
+				}
+			}
 
 			if cfg.http2Disable {
 				// HTTP/2 is temporarily disabled due to CVE-2023-44487
@@ -386,26 +1370,81 @@ func Run(cfg *completedProxyRunOptions) error {
 				}
 			}
 
-			gr.Add(func() error {
-				klog.Infof("Starting TCP socket on %v", cfg.secureListenAddress)
-				l, err := net.Listen("tcp", cfg.secureListenAddress)
-				if err != nil {
-					return fmt.Errorf("failed to listen on secure address: %w", err)
+			several := len(cfg.secureListenAddresses) > 1
+			for _, addr := range cfg.secureListenAddresses {
+				gr.Add(func() error {
+					klog.InfoS("Starting TCP socket", "address", addr)
+					l, err := net.Listen(listenNetwork(addr, several), addr)
+					if err != nil {
+						return fmt.Errorf("failed to listen on secure address: %w", err)
+					}
+					defer l.Close()
+
+					klog.InfoS("Listening securely", "address", addr)
+					tlsListener := tls.NewListener(l, srv.TLSConfig)
+					return srv.Serve(tlsListener)
+				}, func(err error) {
+					if err := srv.Shutdown(context.Background()); err != nil {
+						klog.ErrorS(err, "Failed to gracefully shutdown server")
+					}
+				})
+			}
+
+			for _, l := range listenerHandlers {
+				listenerSrv := &http.Server{
+					Handler:        l.handler,
+					TLSConfig:      srv.TLSConfig.Clone(),
+					MaxHeaderBytes: cfg.maxHeaderBytes,
+				}
+				if completeChains {
+					listenerSrv.Handler = chains.WithCompletedChains(listenerSrv.Handler)
+				}
+				if sni != nil {
+					listenerSrv.Handler = sni.WithHostCheck(listenerSrv.Handler)
 				}
-				defer l.Close()
 
-				klog.Infof("Listening securely on %v", cfg.secureListenAddress)
-				tlsListener := tls.NewListener(l, srv.TLSConfig)
-				return srv.Serve(tlsListener)
-			}, func(err error) {
-				if err := srv.Shutdown(context.Background()); err != nil {
-					klog.Errorf("failed to gracefully shutdown server: %+v", err)
+				if cfg.http2Disable {
+					listenerSrv.TLSNextProto = make(map[string]func(*http.Server, *tls.Conn, http.Handler))
+				} else {
+					if err := http2.ConfigureServer(listenerSrv, cfg.http2Options); err != nil {
+						return fmt.Errorf("failed to configure http2 server: %w", err)
+					}
 				}
-			})
+
+				gr.Add(func() error {
+					klog.InfoS("Starting TCP socket", "address", l.address)
+					listener, err := net.Listen("tcp", l.address)
+					if err != nil {
+						return fmt.Errorf("failed to listen on listener address: %w", err)
+					}
+					defer listener.Close()
+
+					klog.InfoS("Listening securely with its own authorization", "address", l.address)
+					tlsListener := tls.NewListener(listener, listenerSrv.TLSConfig)
+					return listenerSrv.Serve(tlsListener)
+				}, func(err error) {
+					if err := listenerSrv.Shutdown(context.Background()); err != nil {
+						klog.ErrorS(err, "Failed to gracefully shutdown listener server", "address", l.address)
+					}
+				})
+			}
 
 			if cfg.proxyEndpointsPort != 0 {
+				endpointsMux := http.NewServeMux()
+				endpointsMux.Handle("/metrics", legacyregistry.Handler())
+				endpointsMux.HandleFunc("/version", func(w http.ResponseWriter, req *http.Request) {
+					versionHandler(currentVersion.Load())(w, req)
+				})
+				endpointsMux.Handle("/debug/authorization-cache/flush", authorizeDebugEndpoints(sarAuthorizer.FlushHandler()))
+				if cfg.maintenance != nil {
+					endpointsMux.Handle("/debug/maintenance", authorizeDebugEndpoints(cfg.maintenance.Handler()))
+				}
+
+				// The probes are never authorized, kubelets don't authenticate.
 				proxyEndpointsMux := http.NewServeMux()
 				proxyEndpointsMux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte("ok")) })
+				proxyEndpointsMux.Handle("/readyz", readiness.Handler())
+				proxyEndpointsMux.Handle("/", authorizeProxyEndpoints(endpointsMux.ServeHTTP))
 
 				proxyEndpointsSrv := &http.Server{
 					Handler:   proxyEndpointsMux,
@@ -418,48 +1457,50 @@ func Run(cfg *completedProxyRunOptions) error {
 					// Transport.TLSNextProto (for clients) or Server.TLSNextProto
 					// (for servers) to a non-nil, empty map.
 					// https://pkg.go.dev/net/http
-					srv.TLSNextProto = make(map[string]func(*http.Server, *tls.Conn, http.Handler))
+					proxyEndpointsSrv.TLSNextProto = make(map[string]func(*http.Server, *tls.Conn, http.Handler))
 					// For reference:
 					// https://github.com/kubernetes/kubernetes/blob/de054fbf9422d778568946de21a48c7330a6c1b7/staging/src/k8s.io/apiserver/pkg/server/secure_serving.go#L55-L59
-					srv.TLSConfig.NextProtos = []string{"http/1.1"}
+					proxyEndpointsSrv.TLSConfig.NextProtos = []string{"http/1.1"}
 				} else {
 					if err := http2.ConfigureServer(proxyEndpointsSrv, cfg.http2Options); err != nil {
 						return fmt.Errorf("failed to configure http2 server: %w", err)
 					}
 				}
 
-				gr.Add(func() error {
-					host, _, err := net.SplitHostPort(cfg.secureListenAddress)
-					if err != nil {
-						return fmt.Errorf("failed to split %q into host and port: %w", cfg.secureListenAddress, err)
-					}
-					endpointsAddr := net.JoinHostPort(host, strconv.Itoa(cfg.proxyEndpointsPort))
-
-					klog.Infof("Starting TCP socket on %v", endpointsAddr)
-					proxyListener, err := net.Listen("tcp", endpointsAddr)
-					if err != nil {
-						return fmt.Errorf("failed to listen on secure address: %w", err)
-					}
-					defer proxyListener.Close()
-
-					klog.Infof("Listening securely on %v for proxy endpoints", endpointsAddr)
-					tlsListener := tls.NewListener(proxyListener, srv.TLSConfig)
-					return proxyEndpointsSrv.Serve(tlsListener)
-				}, func(err error) {
-					if err := proxyEndpointsSrv.Shutdown(context.Background()); err != nil {
-						klog.Errorf("failed to gracefully shutdown proxy endpoints server: %+v", err)
-					}
-				})
+				for _, addr := range cfg.secureListenAddresses {
+					gr.Add(func() error {
+						host, _, err := net.SplitHostPort(addr)
+						if err != nil {
+							return fmt.Errorf("failed to split %q into host and port: %w", addr, err)
+						}
+						endpointsAddr := net.JoinHostPort(host, strconv.Itoa(cfg.proxyEndpointsPort))
+
+						klog.InfoS("Starting TCP socket", "address", endpointsAddr)
+						proxyListener, err := net.Listen(listenNetwork(endpointsAddr, several), endpointsAddr)
+						if err != nil {
+							return fmt.Errorf("failed to listen on secure address: %w", err)
+						}
+						defer proxyListener.Close()
+
+						klog.InfoS("Listening securely for proxy endpoints", "address", endpointsAddr)
+						tlsListener := tls.NewListener(proxyListener, proxyEndpointsSrv.TLSConfig)
+						return proxyEndpointsSrv.Serve(tlsListener)
+					}, func(err error) {
+						if err := proxyEndpointsSrv.Shutdown(context.Background()); err != nil {
+							klog.ErrorS(err, "Failed to gracefully shutdown proxy endpoints server")
+						}
+					})
+				}
 			}
 		}
 	}
 	{
 		if cfg.insecureListenAddress != "" {
-			srv := &http.Server{}
+			srv := &http.Server{MaxHeaderBytes: cfg.maxHeaderBytes}
 			if cfg.http2Disable {
-				srv.Handler = mux
+				srv.Handler = rootHandler
 			} else {
-				srv.Handler = h2c.NewHandler(mux, cfg.http2Options)
+				srv.Handler = h2c.NewHandler(rootHandler, cfg.http2Options)
 			}
 
 			l, err := net.Listen("tcp", cfg.insecureListenAddress)
@@ -468,31 +1509,60 @@ func Run(cfg *completedProxyRunOptions) error {
 			}
 
 			gr.Add(func() error {
-				klog.Infof("Listening insecurely on %v", cfg.insecureListenAddress)
+				klog.InfoS("Listening insecurely", "address", cfg.insecureListenAddress)
 				return srv.Serve(l)
 			}, func(err error) {
 				if err := srv.Shutdown(context.Background()); err != nil {
-					klog.Errorf("failed to gracefully shutdown server: %+v", err)
+					klog.ErrorS(err, "Failed to gracefully shutdown server")
 				}
 				if err := l.Close(); err != nil {
-					klog.Errorf("failed to gracefully close listener: %+v", err)
+					klog.ErrorS(err, "Failed to gracefully close listener")
 				}
 			})
 		}
 	}
 	{
 		sig := make(chan os.Signal, 1)
+		done := make(chan struct{})
 		gr.Add(func() error {
 			signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
-			<-sig
-			klog.Info("received interrupt, shutting down")
+			select {
+			case <-sig:
+			case <-done:
+				return nil
+			}
+
+			// Unready proxies are taken out of the endpoints, while they
+			// keep serving the requests still routed to them.
+			readiness.Drain()
+			klog.InfoS("Received interrupt, draining before shutting down", "drainPeriod", cfg.shutdownDrainPeriod)
+			select {
+			case <-time.After(cfg.shutdownDrainPeriod):
+			case <-done:
+			}
 			return nil
 		}, func(err error) {
-			close(sig)
+			signal.Stop(sig)
+			close(done)
+		})
+	}
+	{
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		usr1 := make(chan os.Signal, 1)
+		signal.Notify(usr1, syscall.SIGUSR1)
+		done := make(chan struct{})
+		gr.Add(func() error {
+			handleSignals(hup, usr1, done, reload, cfg.maintenance)
+			return nil
+		}, func(error) {
+			signal.Stop(hup)
+			signal.Stop(usr1)
+			close(done)
 		})
 	}
 
-	if len(cfg.secureListenAddress) == 0 && len(cfg.insecureListenAddress) == 0 {
+	if len(cfg.secureListenAddresses) == 0 && len(cfg.insecureListenAddress) == 0 {
 		return fmt.Errorf("no listen address provided")
 	}
 
@@ -503,6 +1573,165 @@ func Run(cfg *completedProxyRunOptions) error {
 	return nil
 }
 
+// newAuthorizer returns the authorizer of the authorization config. Without
+// an rbacAuthorizer, requests are only authorized by the static rules and
+// scopes.
+func newAuthorizer(authzConfig *authz.Config, rbacAuthorizer authorizer.Authorizer, exporter *audit.Exporter, decisionMetrics *authz.DecisionMetrics, denialEvents *authz.DenialEvents) (authorizer.Authorizer, error) {
+	staticAuthorizer, err := authz.NewStaticAuthorizer(authzConfig.Static)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create static authorizer: %w", err)
+	}
+
+	scopeAuthorizer, err := authz.NewScopeAuthorizer(authzConfig.Scopes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scope authorizer: %w", err)
+	}
+
+	authorizers := []authorizer.Authorizer{
+		authz.NewDenyAuthorizer(authzConfig.DenyUsers, authzConfig.DenyGroups),
+		staticAuthorizer,
+		scopeAuthorizer,
+	}
+	if rbacAuthorizer != nil {
+		authorizers = append(authorizers, rbacAuthorizer)
+	}
+	a := authz.WithDecisionMetrics(authzunion.New(authorizers...), decisionMetrics)
+	a = authz.WithDenialEvents(a, denialEvents)
+
+	if exporter != nil {
+		a = audit.WithDecisionExport(a, exporter)
+	}
+	return a, nil
+}
+
+// newWebhookReviewAuthorizer returns the authorizer of the reviews of the
+// authorization webhook. Reviews are of any user, so they are only passed on
+// to the delegated authorization, which answers with the permissions of the
+// proxy, with --authorization-webhook-delegate.
+func newWebhookReviewAuthorizer(cfg *completedProxyRunOptions, authzConfig *authz.Config, a authorizer.Authorizer, exporter *audit.Exporter) (authorizer.Authorizer, error) {
+	if cfg.webhookPath == "" || cfg.webhookDelegate {
+		return a, nil
+	}
+	return newAuthorizer(authzConfig, nil, exporter, cfg.authorizationMetrics, nil)
+}
+
+// newProxyHandler returns the handler authenticating and authorizing
+// requests to the upstream with the authorizer of the authorization config.
+func newProxyHandler(
+	cfg *completedProxyRunOptions,
+	proxyHandler http.HandlerFunc,
+	authenticator authenticator.Request,
+	login *authn.OIDCLogin,
+	authorizer authorizer.Authorizer,
+	reviewAuthorizer authorizer.Authorizer,
+	authzConfig *authz.Config,
+	audiences []string,
+) http.HandlerFunc {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ignorePathFound := false
+		for _, pathIgnored := range cfg.ignorePaths {
+			var err error
+			ignorePathFound, err = path.Match(pathIgnored, req.URL.Path)
+			if err != nil {
+				http.Error(
+					w,
+					http.StatusText(http.StatusInternalServerError),
+					http.StatusInternalServerError,
+				)
+				return
+			}
+			if ignorePathFound {
+				break
+			}
+		}
+
+		if !ignorePathFound {
+			handlerFunc := filters.WithPhase(filters.PhaseUpstream, proxyHandler)
+			if cfg.upstreamRouter != nil {
+				handlerFunc = cfg.upstreamRouter.WithUpstream(authzConfig.Rewrites, handlerFunc)
+			}
+			handlerFunc = filters.WithAuthHeaders(cfg.auth.Authentication.Header, handlerFunc)
+			handlerFunc = filters.WithAuthorizationConstraints(authzConfig, handlerFunc)
+			handlerFunc = filters.WithQueryParameters(cfg.queryParameters, handlerFunc)
+			handlerFunc = filters.WithAuthorization(authorizer, authzConfig, handlerFunc)
+			handlerFunc = filters.WithAllowPathIdentities(cfg.allowPaths, handlerFunc)
+			handlerFunc = filters.WithConnectionExtra(cfg.connectionExtra, handlerFunc)
+			handlerFunc = filters.WithImpersonation(cfg.auth.Authentication.Impersonation, authorizer, handlerFunc)
+			handlerFunc = filters.WithPhase(filters.PhaseAuthorization, handlerFunc)
+			handlerFunc = filters.WithRequestBodyBuffer(cfg.bodyBuffer, handlerFunc)
+			handlerFunc = filters.WithPriorityLevels(cfg.priorityLevels, handlerFunc)
+			handlerFunc = filters.WithCacheBypass(cfg.cacheBypassGroups, authenticator, handlerFunc)
+			handlerFunc = filters.WithAuthenticationChallenge(authenticator, audiences, cfg.bearerChallenge, handlerFunc)
+			handlerFunc = filters.WithLoginRedirect(login, handlerFunc)
+			handlerFunc = filters.WithPhase(filters.PhaseAuthentication, handlerFunc)
+			handlerFunc = filters.WithPhaseTiming(cfg.serverTiming, handlerFunc)
+			handlerFunc(w, req)
+
+			return
+		}
+
+		proxyHandler(w, req)
+	})
+	handler = filters.WithResponseHeaders(cfg.responseHeaders, handler)
+	handler = proxy.WithRequestMetrics(cfg.routes, handler)
+	handler = filters.WithAccessLog(cfg.accessLog, handler)
+	handler = filters.WithMaintenance(cfg.maintenance, handler)
+	handler = filters.WithAllowPaths(filters.AllowPathPatterns(cfg.allowPaths), handler)
+	handler = filters.WithProbes(cfg.probes, handler)
+	handler = filters.WithClientAuthHeaderProtection(cfg.auth.Authentication.Header, handler)
+
+	mux := http.NewServeMux()
+	mux.Handle("/", handler)
+
+	if cfg.authRequestPath != "" {
+		authRequestHandler := filters.AuthRequestIdentity(cfg.auth.Authentication.Header)
+		authRequestHandler = filters.WithQueryParameters(cfg.queryParameters, authRequestHandler)
+		authRequestHandler = filters.WithAuthorization(authorizer, authzConfig, authRequestHandler)
+		authRequestHandler = filters.WithAllowPathIdentities(cfg.allowPaths, authRequestHandler)
+		authRequestHandler = filters.WithConnectionExtra(cfg.connectionExtra, authRequestHandler)
+		authRequestHandler = filters.WithImpersonation(cfg.auth.Authentication.Impersonation, authorizer, authRequestHandler)
+		authRequestHandler = filters.WithAuthenticationChallenge(authenticator, audiences, cfg.bearerChallenge, authRequestHandler)
+		// The allowed paths apply to the original request, as they do to
+		// the proxied ones.
+		authRequestHandler = filters.WithAllowPaths(filters.AllowPathPatterns(cfg.allowPaths), authRequestHandler)
+		authRequestHandler = filters.WithOriginalRequest(authRequestHandler)
+		mux.Handle(cfg.authRequestPath, authRequestHandler)
+	}
+
+	if cfg.selfCheckPath != "" {
+		selfCheckHandler := filters.SelfCheck(authorizer, authzConfig, cfg.allowPaths)
+		selfCheckHandler = filters.WithConnectionExtra(cfg.connectionExtra, selfCheckHandler)
+		selfCheckHandler = filters.WithImpersonation(cfg.auth.Authentication.Impersonation, authorizer, selfCheckHandler)
+		selfCheckHandler = filters.WithCacheBypass(cfg.cacheBypassGroups, authenticator, selfCheckHandler)
+		selfCheckHandler = filters.WithAuthenticationChallenge(authenticator, audiences, cfg.bearerChallenge, selfCheckHandler)
+		mux.Handle(cfg.selfCheckPath, selfCheckHandler)
+	}
+
+	if cfg.landingPageRoutes != nil {
+		landingPageHandler := filters.LandingPage(authorizer, authzConfig, cfg.allowPaths, cfg.landingPageRoutes)
+		landingPageHandler = filters.WithConnectionExtra(cfg.connectionExtra, landingPageHandler)
+		landingPageHandler = filters.WithImpersonation(cfg.auth.Authentication.Impersonation, authorizer, landingPageHandler)
+		landingPageHandler = filters.WithAuthenticationChallenge(authenticator, audiences, cfg.bearerChallenge, landingPageHandler)
+		landingPageHandler = filters.WithLoginRedirect(login, landingPageHandler)
+		// Other methods and paths are proxied.
+		mux.Handle("GET /{$}", landingPageHandler)
+	}
+
+	if login != nil {
+		mux.Handle(login.CallbackPath(), login)
+	}
+
+	if cfg.webhookPath != "" {
+		webhookHandler := filters.AuthorizationWebhook(authorizer, reviewAuthorizer)
+		webhookHandler = filters.WithAllowPathIdentities(cfg.allowPaths, webhookHandler)
+		webhookHandler = filters.WithAuthenticationChallenge(authenticator, audiences, cfg.bearerChallenge, webhookHandler)
+		webhookHandler = filters.WithAllowPaths(filters.AllowPathPatterns(cfg.allowPaths), webhookHandler)
+		mux.Handle(cfg.webhookPath, webhookHandler)
+	}
+
+	return filters.WithRequestLogger(filters.WithRequestLimits(cfg.requestLimits, filters.WithConnection(filters.WithMethodOverride(cfg.methodOverride, filters.WithMethodDenial(cfg.methodDenial, mux.ServeHTTP)))))
+}
+
 // Returns intiliazed config, allows local usage (outside cluster) based on provided kubeconfig or in-cluter
 func initKubeConfig(kcLocation string) (*rest.Config, error) {
 	if kcLocation != "" {
@@ -521,8 +1750,47 @@ func initKubeConfig(kcLocation string) (*rest.Config, error) {
 	return kubeConfig, nil
 }
 
-func parseAuthorizationConfigFile(filePath string) (*authz.Config, error) {
-	klog.Infof("Reading config file: %s", filePath)
+const serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// podNamespace returns the namespace from the POD_NAMESPACE environment
+// variable, usually populated by the downward API, or from the mounted
+// service account.
+func podNamespace() (string, error) {
+	if ns := os.Getenv("POD_NAMESPACE"); ns != "" {
+		return ns, nil
+	}
+
+	ns, err := os.ReadFile(serviceAccountNamespaceFile)
+	if err != nil {
+		return "", fmt.Errorf("POD_NAMESPACE is not set and the service account namespace is not readable: %w", err)
+	}
+
+	return strings.TrimSpace(string(ns)), nil
+}
+
+// podReference returns the pod of the proxy from the POD_NAME, POD_NAMESPACE
+// and POD_UID environment variables, usually populated by the downward API.
+// Without the UID, events on the pod are missing in kubectl describe.
+func podReference() (*corev1.ObjectReference, error) {
+	name := os.Getenv("POD_NAME")
+	if name == "" {
+		return nil, errors.New("POD_NAME is not set")
+	}
+	namespace, err := podNamespace()
+	if err != nil {
+		return nil, err
+	}
+	return &corev1.ObjectReference{
+		Kind:       "Pod",
+		APIVersion: "v1",
+		Namespace:  namespace,
+		Name:       name,
+		UID:        types.UID(os.Getenv("POD_UID")),
+	}, nil
+}
+
+func parseConfigFile(filePath string) (*configfile, error) {
+	klog.InfoS("Reading config file", "path", filePath)
 	b, err := os.ReadFile(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read resource-attribute file: %w", err)
@@ -534,5 +1802,11 @@ func parseAuthorizationConfigFile(filePath string) (*authz.Config, error) {
 		return nil, fmt.Errorf("failed to parse config file content: %w", err)
 	}
 
-	return configFile.AuthorizationConfig, nil
+	if configFile.Include != "" {
+		if err := mergeIncludes(&configFile, includePath(filePath, configFile.Include)); err != nil {
+			return nil, err
+		}
+	}
+
+	return &configFile, nil
 }
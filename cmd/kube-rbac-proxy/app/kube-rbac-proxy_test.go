@@ -17,23 +17,43 @@ limitations under the License.
 package app
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"reflect"
 	"testing"
 
+	"github.com/brancz/kube-rbac-proxy/cmd/kube-rbac-proxy/app/options"
+	"github.com/brancz/kube-rbac-proxy/pkg/authn"
 	"github.com/brancz/kube-rbac-proxy/pkg/authz"
+	"github.com/brancz/kube-rbac-proxy/pkg/filters"
+	"github.com/brancz/kube-rbac-proxy/pkg/proxy"
 	"github.com/google/go-cmp/cmp"
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 )
 
-func Test_parseAuthorizationConfigFile(t *testing.T) {
+// responseHeadersConfigFile is a config file without authorization.
+const responseHeadersConfigFile = `responseHeaders:
+  - paths:
+      - /dashboard/*
+    headers:
+      Cache-Control: no-store
+      X-Content-Type-Options: nosniff`
+
+func Test_parseConfigFile(t *testing.T) {
 	tmpDir := t.TempDir()
 	filePath := filepath.Join(tmpDir, "configfile.yaml")
 
 	tests := []struct {
 		name        string
 		fileContent string
-		want        *authz.Config
+		want        *configfile
 		wantErr     bool
 	}{
 		{
@@ -54,17 +74,17 @@ func Test_parseAuthorizationConfigFile(t *testing.T) {
       subresource: metrics
       namespace: default
       verb: get`,
-			want: &authz.Config{
+			want: &configfile{AuthorizationConfig: &authz.Config{
 				Rewrites: &authz.SubjectAccessReviewRewrites{
 					ByQueryParameter: &authz.QueryParameterRewriteConfig{
 						Name: "namespace",
 					},
 				},
-				ResourceAttributes: &authz.ResourceAttributes{
+				ResourceAttributes: authz.ResourceAttributesList{{
 					Resource:    "namespaces",
 					Subresource: "metrics",
 					Namespace:   "{{ .Value }}",
-				},
+				}},
 				Static: []authz.StaticAuthorizationConfig{
 					{
 						User: authz.UserConfig{
@@ -77,7 +97,7 @@ func Test_parseAuthorizationConfigFile(t *testing.T) {
 						Verb:            "get",
 					},
 				},
-			},
+			}},
 		},
 		{
 			name: "non-resources",
@@ -88,7 +108,7 @@ func Test_parseAuthorizationConfigFile(t *testing.T) {
       resourceRequest: false
       verb: get
       path: /metrics`,
-			want: &authz.Config{
+			want: &configfile{AuthorizationConfig: &authz.Config{
 				Static: []authz.StaticAuthorizationConfig{
 					{
 						User: authz.UserConfig{
@@ -99,6 +119,95 @@ func Test_parseAuthorizationConfigFile(t *testing.T) {
 						Path:            "/metrics",
 					},
 				},
+			}},
+		},
+		{
+			name:        "response headers",
+			fileContent: responseHeadersConfigFile,
+			want: &configfile{
+				ResponseHeaders: []filters.ResponseHeaderConfig{
+					{
+						Paths: []string{"/dashboard/*"},
+						Headers: map[string]string{
+							"Cache-Control":          "no-store",
+							"X-Content-Type-Options": "nosniff",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "listeners",
+			fileContent: `listeners:
+  - address: :9443
+    staticOnly: true
+    audiences: ["debug"]
+    authorization:
+      static:
+        - user:
+            name: system:serviceaccount:default:debugger
+          verb: get
+          path: /debug/pprof/`,
+			want: &configfile{
+				Listeners: []listenerConfig{
+					{
+						Address:    ":9443",
+						StaticOnly: true,
+						Audiences:  []string{"debug"},
+						Authorization: &authz.Config{
+							Static: []authz.StaticAuthorizationConfig{
+								{
+									User: authz.UserConfig{
+										Name: "system:serviceaccount:default:debugger",
+									},
+									Verb: "get",
+									Path: "/debug/pprof/",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "proxyEndpoints",
+			fileContent: `proxyEndpoints:
+  staticOnly: true
+  authorization:
+    static:
+      - user:
+          groups: ["monitoring"]
+        verb: get
+        path: /metrics`,
+			want: &configfile{
+				ProxyEndpoints: &proxyEndpointsConfig{
+					StaticOnly: true,
+					Authorization: &authz.Config{
+						Static: []authz.StaticAuthorizationConfig{
+							{
+								User: authz.UserConfig{
+									Groups: []string{"monitoring"},
+								},
+								Verb: "get",
+								Path: "/metrics",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "upstreamStatusRewrites",
+			fileContent: `upstreamStatusRewrites:
+- paths: ["/api/*"]
+  statuses:
+    401: 502
+    403: 502`,
+			want: &configfile{
+				StatusRewrites: []proxy.StatusRewriteConfig{{
+					Paths:    []string{"/api/*"},
+					Statuses: map[int]int{401: 502, 403: 502},
+				}},
 			},
 		},
 	}
@@ -108,14 +217,136 @@ func Test_parseAuthorizationConfigFile(t *testing.T) {
 				t.Fatalf("failed to write file: %v", err)
 			}
 
-			got, err := parseAuthorizationConfigFile(filePath)
+			got, err := parseConfigFile(filePath)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("parseAuthorizationConfigFile() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("parseConfigFile() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("parseAuthorizationConfigFile(): %s", cmp.Diff(got, tt.want))
+				t.Errorf("parseConfigFile(): %s", cmp.Diff(got, tt.want))
 			}
 		})
 	}
 }
+
+func TestCompleteWithoutAuthorization(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configFile, []byte(responseHeadersConfigFile), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	o := options.NewProxyRunOptions()
+	o.Upstream = "http://127.0.0.1:8081"
+	o.ConfigFileName = configFile
+	o.DumpEffectiveConfig = true
+	cfg, err := Complete(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.auth.Authorization == nil {
+		t.Fatal("want the default authorization of a config file without authorization")
+	}
+	if len(cfg.responseHeaders) != 1 {
+		t.Errorf("want the response headers of the config file, got %+v", cfg.responseHeaders)
+	}
+	if _, err := newAuthorizer(cfg.auth.Authorization, nil, nil, nil, nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestSetRateLimits(t *testing.T) {
+	for _, tt := range []struct {
+		name            string
+		qps             float32
+		burst           int
+		pnf             bool
+		wantQPS         float32
+		wantBurst       int
+		wantRateLimiter bool
+	}{
+		{
+			name:            "defaults",
+			wantBurst:       10,
+			wantRateLimiter: true,
+		},
+		{
+			name:            "qps and burst",
+			qps:             50,
+			burst:           100,
+			wantQPS:         50,
+			wantBurst:       100,
+			wantRateLimiter: true,
+		},
+		{
+			name:      "priority and fairness",
+			pnf:       true,
+			wantQPS:   -1,
+			wantBurst: 10,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			o := options.NewProxyRunOptions()
+			o.QPS = tt.qps
+			o.Burst = tt.burst
+			o.PriorityAndFairness = tt.pnf
+
+			kubeconfig := &rest.Config{Host: "https://kubernetes.default.svc", Burst: 10}
+			setRateLimits(kubeconfig, o)
+			if kubeconfig.QPS != tt.wantQPS || kubeconfig.Burst != tt.wantBurst {
+				t.Errorf("want QPS %v and burst %d, got %v and %d", tt.wantQPS, tt.wantBurst, kubeconfig.QPS, kubeconfig.Burst)
+			}
+
+			client, err := kubernetes.NewForConfig(kubeconfig)
+			if err != nil {
+				t.Fatal(err)
+			}
+			restClient, ok := client.CoreV1().RESTClient().(*rest.RESTClient)
+			if !ok {
+				t.Fatalf("unexpected REST client %T", client.CoreV1().RESTClient())
+			}
+			if got := restClient.GetRateLimiter() != nil; got != tt.wantRateLimiter {
+				t.Errorf("want rate limiter %t, got %t", tt.wantRateLimiter, got)
+			}
+		})
+	}
+}
+
+func TestAuthRequestAllowPathIdentities(t *testing.T) {
+	allowPaths, err := filters.ParseAllowPaths([]string{"/debug/*@group=system:masters", "/metrics"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &completedProxyRunOptions{
+		auth: &proxy.Config{
+			Authentication: &authn.AuthnConfig{
+				Header: &authn.AuthnHeaderConfig{UserFieldName: "x-remote-user", GroupsFieldName: "x-remote-groups"},
+				Token:  &authn.TokenConfig{},
+			},
+			Authorization: &authz.Config{},
+		},
+		allowPaths:      allowPaths,
+		authRequestPath: "/auth",
+	}
+	alice := authenticator.RequestFunc(func(*http.Request) (*authenticator.Response, bool, error) {
+		return &authenticator.Response{User: &user.DefaultInfo{Name: "alice", Groups: []string{"developers"}}}, true, nil
+	})
+	allowAll := authorizer.AuthorizerFunc(func(context.Context, authorizer.Attributes) (authorizer.Decision, string, error) {
+		return authorizer.DecisionAllow, "", nil
+	})
+	handler := newProxyHandler(cfg, func(http.ResponseWriter, *http.Request) {}, alice, nil, allowAll, allowAll, cfg.auth.Authorization, nil)
+
+	for uri, want := range map[string]int{
+		"/debug/pprof": http.StatusForbidden,
+		"/metrics":     http.StatusOK,
+		"/other":       http.StatusNotFound,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/auth", nil)
+		req.Header.Set("X-Original-Method", http.MethodGet)
+		req.Header.Set("X-Original-URI", uri)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("want %d for an auth request of %s, got %d", want, uri, rec.Code)
+		}
+	}
+}
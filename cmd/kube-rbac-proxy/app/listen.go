@@ -0,0 +1,72 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"net"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/authz"
+)
+
+// listenerConfig describes a secure listener with its own authorization,
+// e.g. to serve /debug with static rules only next to /metrics authorized
+// by SubjectAccessReviews. Listeners share the TLS configuration, the
+// authentication and the upstream of the secure listen addresses.
+type listenerConfig struct {
+	Address       string        `json:"address"`
+	Authorization *authz.Config `json:"authorization,omitempty"`
+	// StaticOnly authorizes requests by the static rules and scopes only,
+	// without sending SubjectAccessReviews.
+	StaticOnly bool `json:"staticOnly,omitempty"`
+	// Audiences of tokens accepted by the listener, defaults to the
+	// --auth-token-audiences.
+	Audiences []string `json:"audiences,omitempty"`
+}
+
+// proxyEndpointsConfig authorizes requests to the endpoints of the
+// --proxy-endpoints-port, other than the probes /healthz and /readyz.
+type proxyEndpointsConfig struct {
+	Authorization *authz.Config `json:"authorization,omitempty"`
+	// StaticOnly authorizes requests by the static rules and scopes only,
+	// without sending SubjectAccessReviews.
+	StaticOnly bool `json:"staticOnly,omitempty"`
+}
+
+// listenNetwork returns the network to listen on the address with. A single
+// address keeps the dual-stack behavior of "tcp". With several addresses, IP
+// addresses are bound to their own family, such that e.g. [::]:8443 and
+// 0.0.0.0:8443 can be bound next to each other.
+func listenNetwork(addr string, several bool) string {
+	if !several {
+		return "tcp"
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "tcp"
+	}
+
+	ip := net.ParseIP(host)
+	switch {
+	case ip == nil:
+		return "tcp"
+	case ip.To4() != nil:
+		return "tcp4"
+	default:
+		return "tcp6"
+	}
+}
@@ -0,0 +1,37 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import "testing"
+
+func TestListenNetwork(t *testing.T) {
+	for _, tt := range []struct {
+		addr    string
+		several bool
+		want    string
+	}{
+		{addr: "[::]:8443", want: "tcp"},
+		{addr: ":8443", several: true, want: "tcp"},
+		{addr: "[::]:8443", several: true, want: "tcp6"},
+		{addr: "0.0.0.0:8443", several: true, want: "tcp4"},
+		{addr: "localhost:8443", several: true, want: "tcp"},
+	} {
+		if got := listenNetwork(tt.addr, tt.several); got != tt.want {
+			t.Errorf("%s (several=%t): want %s, got %s", tt.addr, tt.several, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"runtime/debug"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+)
+
+var memoryBallastBytes = metrics.NewGauge(
+	&metrics.GaugeOpts{
+		Namespace:      "kube_rbac_proxy",
+		Subsystem:      "memory",
+		Name:           "ballast_bytes",
+		Help:           "Size of the memory ballast. The GC settings and the heap are exposed by the go_gc and go_memory metrics of the runtime.",
+		StabilityLevel: metrics.ALPHA,
+	},
+)
+
+func init() {
+	legacyregistry.MustRegister(memoryBallastBytes)
+}
+
+// ballast is never read, it is kept alive to raise the heap size the next
+// collection is triggered at. Its pages are never written, so they aren't
+// resident.
+var ballast []byte
+
+// memoryTuning configures the garbage collector of the Go runtime.
+type memoryTuning struct {
+	// gcPercent overrides GOGC, if not 0.
+	gcPercent int
+	// limit overrides GOMEMLIMIT, if greater than 0.
+	limit int64
+	// ballast is allocated, if greater than 0.
+	ballast int64
+}
+
+func (t memoryTuning) apply() {
+	if t.gcPercent != 0 {
+		previous := debug.SetGCPercent(t.gcPercent)
+		klog.InfoS("Set GC percent", "percent", t.gcPercent, "previous", previous)
+	}
+	if t.limit > 0 {
+		previous := debug.SetMemoryLimit(t.limit)
+		klog.InfoS("Set memory limit", "bytes", t.limit, "previous", previous)
+	}
+	if t.ballast > 0 {
+		ballast = make([]byte, t.ballast)
+		memoryBallastBytes.Set(float64(t.ballast))
+		klog.InfoS("Allocated memory ballast", "bytes", t.ballast)
+	}
+}
@@ -0,0 +1,52 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"math"
+	"runtime/debug"
+	"testing"
+)
+
+func TestMemoryTuning(t *testing.T) {
+	gcPercent := debug.SetGCPercent(100)
+	memoryLimit := debug.SetMemoryLimit(math.MaxInt64)
+	t.Cleanup(func() {
+		debug.SetGCPercent(gcPercent)
+		debug.SetMemoryLimit(memoryLimit)
+		ballast = nil
+	})
+
+	memoryTuning{gcPercent: 200, limit: 1 << 30, ballast: 1 << 20}.apply()
+
+	if got := debug.SetGCPercent(100); got != 200 {
+		t.Errorf("want GC percent 200, got %d", got)
+	}
+	if got := debug.SetMemoryLimit(-1); got != 1<<30 {
+		t.Errorf("want memory limit %d, got %d", 1<<30, got)
+	}
+	if len(ballast) != 1<<20 {
+		t.Errorf("want ballast of %d bytes, got %d", 1<<20, len(ballast))
+	}
+
+	// Unset values keep the settings of the runtime.
+	debug.SetGCPercent(150)
+	memoryTuning{}.apply()
+	if got := debug.SetGCPercent(100); got != 150 {
+		t.Errorf("want GC percent 150 kept, got %d", got)
+	}
+}
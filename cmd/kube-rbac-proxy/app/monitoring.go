@@ -0,0 +1,270 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/proxy"
+)
+
+const (
+	monitoringRequestsMetric = "kube_rbac_proxy_requests_total"
+	monitoringErrorRatio     = "kube_rbac_proxy:requests:error_ratio_rate"
+	monitoringTokenFile      = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+)
+
+// monitoringWindows are the windows of the error ratios the multiwindow,
+// multi-burn-rate alerts of the SRE workbook are built from.
+var monitoringWindows = []string{"5m", "30m", "1h", "2h", "6h", "1d", "3d"}
+
+// burnRateAlert fires, if the error ratios of both the long and the short
+// window burn the error budget at the factor, for either of the pairs.
+type burnRateAlert struct {
+	severity string
+	windows  [2][2]string
+	factors  [2]float64
+}
+
+var burnRateAlerts = []burnRateAlert{
+	// 2% and 5% of a 30 day budget within 1h and 6h.
+	{severity: "critical", windows: [2][2]string{{"1h", "5m"}, {"6h", "30m"}}, factors: [2]float64{14.4, 6}},
+	// 10% of a 30 day budget within 1d and 3d.
+	{severity: "warning", windows: [2][2]string{{"1d", "2h"}, {"3d", "6h"}}, factors: [2]float64{3, 1}},
+}
+
+type monitoringOptions struct {
+	name               string
+	namespace          string
+	configFile         string
+	objective          float64
+	port               string
+	selector           map[string]string
+	insecureSkipVerify bool
+}
+
+func newGenMonitoringCommand() *cobra.Command {
+	o := &monitoringOptions{
+		namespace: "default",
+		objective: 0.99,
+		port:      "proxy-endpoints",
+	}
+
+	cmd := &cobra.Command{
+		Use:   "gen-monitoring",
+		Short: "Generate a ServiceMonitor and SLO burn-rate rules for the proxy",
+		Long: `Generate a ServiceMonitor scraping the metrics of the proxy and a PrometheusRule
+with the error ratios of its requests and burn-rate alerts on them, for the
+Prometheus operator.
+
+The error ratio is the share of 5xx responses by route and upstream, recorded
+for the windows of multiwindow, multi-burn-rate alerts. An alert is generated
+per route of the routes of --config-file, with its objective or --objective,
+and for the requests matching no route, with --objective.
+
+The ServiceMonitor selects the Service exposing the --proxy-endpoints-port of
+the proxy and authenticates with the token of the ServiceAccount of
+Prometheus, which must be allowed to get /metrics.`,
+		SilenceUsage: true,
+		Args:         cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			b, err := o.generate()
+			if err != nil {
+				return err
+			}
+			_, err = cmd.OutOrStdout().Write(b)
+			return err
+		},
+	}
+
+	fs := cmd.Flags()
+	fs.StringVar(&o.name, "name", o.name, "The name of the Service of the proxy, which is the job label of its metrics.")
+	fs.StringVar(&o.namespace, "namespace", o.namespace, "The namespace of the Service.")
+	fs.StringVar(&o.configFile, "config-file", o.configFile, "The config file of the proxy, for its routes and their objectives.")
+	fs.Float64Var(&o.objective, "objective", o.objective, "The share of requests that must not fail with a 5xx status, for routes without an objective and the requests matching no route.")
+	fs.StringVar(&o.port, "port", o.port, "The name of the port of the Service serving /metrics.")
+	fs.StringToStringVar(&o.selector, "selector", o.selector, "The labels selecting the Service. Defaults to app.kubernetes.io/name=<name>.")
+	fs.BoolVar(&o.insecureSkipVerify, "insecure-skip-verify", o.insecureSkipVerify, "Don't verify the serving certificate of the proxy, e.g. if it is self-signed.")
+
+	return cmd
+}
+
+func (o *monitoringOptions) validate() error {
+	var errs []error
+
+	if o.name == "" {
+		errs = append(errs, errors.New("--name is required"))
+	}
+	if o.port == "" {
+		errs = append(errs, errors.New("--port is required"))
+	}
+	if o.objective <= 0 || o.objective >= 1 {
+		errs = append(errs, fmt.Errorf("--objective must be between 0 and 1, got %v", o.objective))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (o *monitoringOptions) generate() ([]byte, error) {
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+
+	var routes []proxy.RouteConfig
+	if o.configFile != "" {
+		configFile, err := parseConfigFile(o.configFile)
+		if err != nil {
+			return nil, err
+		}
+		if err := proxy.ValidateRoutes(configFile.Routes); err != nil {
+			return nil, fmt.Errorf("invalid config file: %w", err)
+		}
+		routes = configFile.Routes
+	}
+
+	var buf bytes.Buffer
+	for i, obj := range []interface{}{o.serviceMonitor(), o.prometheusRule(routes)} {
+		b, err := yaml.Marshal(obj)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+		buf.Write(b)
+	}
+	return buf.Bytes(), nil
+}
+
+func (o *monitoringOptions) objectName() string {
+	return o.name + "-" + sidecarName
+}
+
+// serviceMonitor is untyped, as the types of the Prometheus operator are no
+// dependency of the proxy.
+func (o *monitoringOptions) serviceMonitor() map[string]interface{} {
+	selector := o.selector
+	if len(selector) == 0 {
+		selector = map[string]string{"app.kubernetes.io/name": o.name}
+	}
+
+	tlsConfig := map[string]interface{}{"serverName": o.name + "." + o.namespace + ".svc"}
+	if o.insecureSkipVerify {
+		tlsConfig = map[string]interface{}{"insecureSkipVerify": true}
+	}
+
+	return map[string]interface{}{
+		"apiVersion": "monitoring.coreos.com/v1",
+		"kind":       "ServiceMonitor",
+		"metadata": map[string]interface{}{
+			"name":      o.objectName(),
+			"namespace": o.namespace,
+		},
+		"spec": map[string]interface{}{
+			"selector": map[string]interface{}{"matchLabels": selector},
+			"endpoints": []interface{}{
+				map[string]interface{}{
+					"port":            o.port,
+					"scheme":          "https",
+					"path":            "/metrics",
+					"bearerTokenFile": monitoringTokenFile,
+					"tlsConfig":       tlsConfig,
+				},
+			},
+		},
+	}
+}
+
+// prometheusRule records the error ratios by route and upstream and alerts
+// per route, such that the alerts carry the upstream that burns the budget.
+func (o *monitoringOptions) prometheusRule(routes []proxy.RouteConfig) map[string]interface{} {
+	job := strconv.Quote(o.name)
+
+	var records []interface{}
+	for _, w := range monitoringWindows {
+		records = append(records, map[string]interface{}{
+			"record": monitoringErrorRatio + w,
+			"expr": fmt.Sprintf("sum by (namespace, job, route, upstream) (rate(%[1]s{job=%[2]s,code=~\"5..\"}[%[3]s]))\n/\nsum by (namespace, job, route, upstream) (rate(%[1]s{job=%[2]s}[%[3]s]))",
+				monitoringRequestsMetric, job, w),
+		})
+	}
+
+	objectives := []proxy.RouteConfig{}
+	for _, r := range routes {
+		if r.Objective == 0 {
+			r.Objective = o.objective
+		}
+		objectives = append(objectives, r)
+	}
+	objectives = append(objectives, proxy.RouteConfig{Name: proxy.OtherRoute, Objective: o.objective})
+
+	var alerts []interface{}
+	for _, r := range objectives {
+		for _, a := range burnRateAlerts {
+			alerts = append(alerts, o.burnRateAlert(r, a))
+		}
+	}
+
+	return map[string]interface{}{
+		"apiVersion": "monitoring.coreos.com/v1",
+		"kind":       "PrometheusRule",
+		"metadata": map[string]interface{}{
+			"name":      o.objectName(),
+			"namespace": o.namespace,
+		},
+		"spec": map[string]interface{}{
+			"groups": []interface{}{
+				map[string]interface{}{"name": o.objectName() + ".rules", "rules": records},
+				map[string]interface{}{"name": o.objectName() + ".alerts", "rules": alerts},
+			},
+		},
+	}
+}
+
+func (o *monitoringOptions) burnRateAlert(route proxy.RouteConfig, a burnRateAlert) map[string]interface{} {
+	selector := fmt.Sprintf("{job=%s,route=%s}", strconv.Quote(o.name), strconv.Quote(route.Name))
+	objective := strconv.FormatFloat(route.Objective, 'f', -1, 64)
+
+	var expr string
+	for i, windows := range a.windows {
+		if i > 0 {
+			expr += "\nor\n"
+		}
+		threshold := fmt.Sprintf("(%s * (1 - %s))", strconv.FormatFloat(a.factors[i], 'f', -1, 64), objective)
+		expr += fmt.Sprintf("(\n  %[1]s%[2]s%[3]s > %[5]s\nand\n  %[1]s%[4]s%[3]s > %[5]s\n)",
+			monitoringErrorRatio, windows[0], selector, windows[1], threshold)
+	}
+
+	return map[string]interface{}{
+		"alert": "KubeRBACProxyErrorBudgetBurn",
+		"expr":  expr,
+		"labels": map[string]interface{}{
+			"severity": a.severity,
+			"route":    route.Name,
+		},
+		"annotations": map[string]interface{}{
+			"summary":     "The proxy burns the error budget of a route too fast.",
+			"description": fmt.Sprintf("Requests of route %s to upstream {{ $labels.upstream }} of {{ $labels.namespace }}/{{ $labels.job }} fail with 5xx at a rate exhausting the error budget of the objective %s early.", route.Name, objective),
+		},
+	}
+}
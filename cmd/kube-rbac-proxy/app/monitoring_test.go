@@ -0,0 +1,125 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ghodss/yaml"
+)
+
+func TestGenMonitoring(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config-file.yaml")
+	if err := os.WriteFile(configFile, []byte("routes:\n- name: query\n  paths: [/api/v1/query]\n  objective: 0.999\n- name: api\n  paths: [/api/*]\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	o := &monitoringOptions{
+		name:       "prometheus",
+		namespace:  "monitoring",
+		configFile: configFile,
+		objective:  0.99,
+		port:       "proxy-endpoints",
+	}
+	b, err := o.generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs := strings.Split(string(b), "---\n")
+	if len(docs) != 2 {
+		t.Fatalf("want a ServiceMonitor and a PrometheusRule, got %s", b)
+	}
+
+	var monitor struct {
+		Kind string `json:"kind"`
+		Spec struct {
+			Selector struct {
+				MatchLabels map[string]string `json:"matchLabels"`
+			} `json:"selector"`
+			Endpoints []struct {
+				Port string `json:"port"`
+				Path string `json:"path"`
+			} `json:"endpoints"`
+		} `json:"spec"`
+	}
+	if err := yaml.Unmarshal([]byte(docs[0]), &monitor); err != nil {
+		t.Fatal(err)
+	}
+	if monitor.Kind != "ServiceMonitor" || monitor.Spec.Selector.MatchLabels["app.kubernetes.io/name"] != "prometheus" {
+		t.Errorf("want a ServiceMonitor selecting the Service by name, got %s", docs[0])
+	}
+	if len(monitor.Spec.Endpoints) != 1 || monitor.Spec.Endpoints[0].Port != "proxy-endpoints" || monitor.Spec.Endpoints[0].Path != "/metrics" {
+		t.Errorf("want /metrics of the proxy-endpoints port scraped, got %s", docs[0])
+	}
+
+	var rule struct {
+		Kind string `json:"kind"`
+		Spec struct {
+			Groups []struct {
+				Rules []struct {
+					Record string            `json:"record"`
+					Alert  string            `json:"alert"`
+					Expr   string            `json:"expr"`
+					Labels map[string]string `json:"labels"`
+				} `json:"rules"`
+			} `json:"groups"`
+		} `json:"spec"`
+	}
+	if err := yaml.Unmarshal([]byte(docs[1]), &rule); err != nil {
+		t.Fatal(err)
+	}
+	if rule.Kind != "PrometheusRule" || len(rule.Spec.Groups) != 2 {
+		t.Fatalf("want a PrometheusRule with recording and alerting rules, got %s", docs[1])
+	}
+	records := rule.Spec.Groups[0].Rules
+	if len(records) != len(monitoringWindows) || records[0].Record != "kube_rbac_proxy:requests:error_ratio_rate5m" ||
+		!strings.Contains(records[0].Expr, `kube_rbac_proxy_requests_total{job="prometheus",code=~"5.."}[5m]`) {
+		t.Errorf("want an error ratio per window, got %s", docs[1])
+	}
+
+	thresholds := map[string]string{}
+	for _, alert := range rule.Spec.Groups[1].Rules {
+		if alert.Labels["severity"] == "critical" {
+			thresholds[alert.Labels["route"]] = alert.Expr
+		}
+	}
+	for route, want := range map[string]string{
+		"query": `error_ratio_rate1h{job="prometheus",route="query"} > (14.4 * (1 - 0.999))`,
+		"api":   `error_ratio_rate6h{job="prometheus",route="api"} > (6 * (1 - 0.99))`,
+		"other": `error_ratio_rate5m{job="prometheus",route="other"} > (14.4 * (1 - 0.99))`,
+	} {
+		if !strings.Contains(thresholds[route], want) {
+			t.Errorf("want the critical alert of route %s to contain %s, got %q", route, want, thresholds[route])
+		}
+	}
+}
+
+func TestGenMonitoringValidation(t *testing.T) {
+	for _, o := range []*monitoringOptions{
+		{objective: 0.99, port: "https"},
+		{name: "app", objective: 0.99},
+		{name: "app", objective: 1, port: "https"},
+		{name: "app", port: "https"},
+	} {
+		if err := o.validate(); err == nil {
+			t.Errorf("want error for %+v", o)
+		}
+	}
+}
@@ -17,55 +17,154 @@ limitations under the License.
 package options
 
 import (
+	"compress/gzip"
 	"fmt"
+	"net"
+	"net/http"
+	"net/url"
 	"path"
+	"slices"
+	"strings"
 	"time"
 
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	k8sapiflag "k8s.io/component-base/cli/flag"
+	logsapi "k8s.io/component-base/logs/api/v1"
+	_ "k8s.io/component-base/logs/json/register"
 	"k8s.io/klog/v2"
 
+	"github.com/brancz/kube-rbac-proxy/pkg/audit"
 	"github.com/brancz/kube-rbac-proxy/pkg/authn"
 	"github.com/brancz/kube-rbac-proxy/pkg/authz"
+	"github.com/brancz/kube-rbac-proxy/pkg/faults"
+	"github.com/brancz/kube-rbac-proxy/pkg/features"
 	"github.com/brancz/kube-rbac-proxy/pkg/proxy"
+	rbac_proxy_tls "github.com/brancz/kube-rbac-proxy/pkg/tls"
 	"github.com/spf13/pflag"
 )
 
 type ProxyRunOptions struct {
 	ConfigFileName string
+	// DumpEffectiveConfig prints the effective config instead of running.
+	DumpEffectiveConfig bool
 
 	InsecureListenAddress string
-	SecureListenAddress   string
+	SecureListenAddress   []string
 	ProxyEndpointsPort    int
 
-	Upstream           string
-	UpstreamForceH2C   bool
-	UpstreamCAFile     string
-	Auth               *proxy.Config
-	TLS                *TLSConfig
-	KubeconfigLocation string
-	AllowPaths         []string
-	IgnorePaths        []string
+	Upstream                 string
+	UpstreamForceH2C         bool
+	UpstreamCAFile           string
+	UpstreamErrorDiagnostics bool
+	AdditionalUpstreams      []string
+	UpstreamAffinity         string
+	UpstreamService          string
+	UpstreamServicePort      string
+	UpstreamDNSServer        string
+	UpstreamIPFamily         string
+	UpstreamLocalAddress     string
+	UpstreamFlushInterval    time.Duration
+	UpstreamConnectionMaxAge time.Duration
+	UpstreamKeepAlive        time.Duration
+	UpstreamPingInterval     time.Duration
+	UpstreamPingTimeout      time.Duration
+	UpstreamExpectContinue   time.Duration
+	UpstreamSigningKeyFile   string
+	UpstreamMirror           string
+	UpstreamMirrorRate       float64
+	UpstreamMirrorTimeout    time.Duration
+	EgressProxyURL           string
+	EgressNoProxy            string
+	CompressionLevel         int
+	CompressionMinSize       int64
+	WeakETagMaxSize          int64
+	ConnectionBandwidthLimit int
+	TotalBandwidthLimit      int
+	Auth                     *proxy.Config
+	TLS                      *TLSConfig
+	KubeconfigLocation       string
+	AllowPaths               []string
+	IgnorePaths              []string
+	ProbePaths               []string
+	ProbeUserAgent           string
+	MaintenanceMode          bool
+	MaintenancePaths         []string
+	MaintenanceRetryAfter    time.Duration
+	DeniedMethods            []string
+	DeniedMethodsStatus      int
+	AuthChallengeRealm       string
+	AuthChallengeErrorCodes  bool
+	ShutdownDrainPeriod      time.Duration
+	ServerTiming             bool
+	GCPercent                int
+	MemoryLimit              int64
+	MemoryBallast            int64
+	AuthRequestPath          string
+	SelfCheckPath            string
+	LandingPage              bool
+	LandingPageRoutes        []string
+	AuthorizationWebhookPath string
+	// AuthorizationWebhookDelegate sends the reviews of the authorization
+	// webhook on to the API server, if the proxy's own rules don't decide.
+	AuthorizationWebhookDelegate bool
+	AuthorizationCacheWatch      bool
+	CacheBypassGroups            []string
+	LogAuthorizationGrants       bool
+	AuthorizationLocalRBAC       bool
+	// AuthorizationMetricsMaxSeries enables the decision metrics by
+	// attributes, if greater than 0.
+	AuthorizationMetricsMaxSeries int
+	AuthorizationConnectionExtra  bool
+	AuthorizationDenialEvents     authz.DenialEventsConfig
+	StartupPermissionCheck        bool
 
 	HTTP2Disable              bool
 	HTTP2MaxConcurrentStreams uint32
 	HTTP2MaxSize              uint32
 
-	QPS   float32
-	Burst int
+	MaxHeaderBytes int
+	MaxHeaders     int
+	MaxURLLength   int
+
+	RequestBodyBufferSize  int64
+	DecisionExportBodyHash bool
+
+	QPS                 float32
+	Burst               int
+	PriorityAndFairness bool
+
+	DecisionExport *audit.ExportConfig
+
+	Faults *faults.Config
+
+	Logs *logsapi.LoggingConfiguration
 
 	flagSet *pflag.FlagSet
 }
 
 type TLSConfig struct {
-	CertFile       string
-	KeyFile        string
+	CertFile      string
+	KeyFile       string
+	KeyPassphrase rbac_proxy_tls.Passphrase
+	// KeyPKCS11URI is the RFC 7512 URI of the key on a PKCS #11 token,
+	// instead of the KeyFile.
+	KeyPKCS11URI   string
 	MinVersion     string
 	CipherSuites   []string
 	ReloadInterval time.Duration
+	// ClientAuthPolicy is one of none, request or require-verify.
+	ClientAuthPolicy string
+	// ClientCertRequiredEKUs and ClientCertSignatureAlgorithms restrict the
+	// client certificates accepted by require-verify.
+	ClientCertRequiredEKUs        []string
+	ClientCertSignatureAlgorithms []string
+
+	SNICertKeys      []k8sapiflag.NamedCertKey
+	SNIClientCAFiles map[string]string
 
 	UpstreamClientCertFile string
 	UpstreamClientKeyFile  string
+	UpstreamKeyPassphrase  rbac_proxy_tls.Passphrase
 }
 
 func NewProxyRunOptions() *ProxyRunOptions {
@@ -75,11 +174,16 @@ func NewProxyRunOptions() *ProxyRunOptions {
 				X509:   &authn.X509Config{},
 				Header: &authn.AuthnHeaderConfig{},
 				OIDC:   &authn.OIDCConfig{},
+				LDAP:   &authn.LDAPConfig{},
+				Cloud:  &authn.CloudConfig{},
 				Token:  &authn.TokenConfig{},
 			},
 			Authorization: &authz.Config{},
 		},
-		TLS: &TLSConfig{},
+		TLS:            &TLSConfig{},
+		DecisionExport: &audit.ExportConfig{},
+		Faults:         &faults.Config{},
+		Logs:           logsapi.NewLoggingConfiguration(),
 	}
 }
 
@@ -89,31 +193,113 @@ func (o *ProxyRunOptions) Flags() k8sapiflag.NamedFlagSets {
 
 	// kube-rbac-proxy flags
 	flagset.StringVar(&o.InsecureListenAddress, "insecure-listen-address", "", "[DEPRECATED] The address the kube-rbac-proxy HTTP server should listen on.")
-	flagset.StringVar(&o.SecureListenAddress, "secure-listen-address", "", "The address the kube-rbac-proxy HTTPs server should listen on.")
+	flagset.StringSliceVar(&o.SecureListenAddress, "secure-listen-address", nil, "Comma-separated list of addresses the kube-rbac-proxy HTTPs server should listen on. A single address like :8443 listens dual-stack, several addresses like [::]:8443,0.0.0.0:8443 are bound to their own IP family.")
 	flagset.StringVar(&o.Upstream, "upstream", "", "The upstream URL to proxy to once requests have successfully been authenticated and authorized.")
-	flagset.BoolVar(&o.UpstreamForceH2C, "upstream-force-h2c", false, "Force h2c to communiate with the upstream. This is required when the upstream speaks h2c(http/2 cleartext - insecure variant of http/2) only. For example, go-grpc server in the insecure mode, such as helm's tiller w/o TLS, speaks h2c only")
+	flagset.BoolVar(&o.UpstreamForceH2C, "upstream-force-h2c", false, "Force h2c to communiate with the upstream. This is required when the upstream speaks h2c(http/2 cleartext - insecure variant of http/2) only. For example, go-grpc server in the insecure mode, such as helm's tiller w/o TLS, speaks h2c only. Requires the feature gate UpstreamH2C, which is enabled by default.")
 	flagset.StringVar(&o.UpstreamCAFile, "upstream-ca-file", "", "The CA the upstream uses for TLS connection. This is required when the upstream uses TLS and its own CA certificate")
+	flagset.StringSliceVar(&o.AdditionalUpstreams, "additional-upstreams", nil, "Comma-separated list of further upstream URLs serving the same content as --upstream. Requests are balanced across all upstreams.")
+	flagset.StringVar(&o.UpstreamAffinity, "upstream-affinity", proxy.AffinityNone, "Session affinity when balancing across --upstream and --additional-upstreams. One of none, cookie (pins clients by a cookie, which isn't passed on to the upstreams) or user (pins authenticated users by the hash of their name).")
+	flagset.StringVar(&o.UpstreamService, "upstream-service", "", "A Service in the form namespace/name, whose ready endpoints are discovered via its EndpointSlices and used as upstreams. The scheme and path of the upstream URLs are taken from --upstream. Requires permissions to list and watch endpointslices in the namespace. Cannot be used with --additional-upstreams.")
+	flagset.StringVar(&o.UpstreamServicePort, "upstream-service-port", "", "The name of the endpoint port used with --upstream-service. May be omitted, if the Service has a single port.")
+	flagset.StringVar(&o.UpstreamDNSServer, "upstream-dns-server", "", "The address of a DNS server (host or host:port) used to resolve the upstream instead of the system resolver.")
+	flagset.StringVar(&o.UpstreamIPFamily, "upstream-ip-family", "", "Restrict connections to the upstream to one IP family, either ipv4 or ipv6. By default both are used.")
+	flagset.StringVar(&o.UpstreamLocalAddress, "upstream-local-address", "", "The local IP address connections to the upstream originate from, for multi-homed nodes.")
+	flagset.DurationVar(&o.UpstreamConnectionMaxAge, "upstream-connection-max-age", 0, "If set, connections to the upstream are closed once they reached this age, such that they are dialed again and the upstream hostname is resolved again, e.g. after the IP of its Service changed. Requests on older HTTP/1 connections are sent with Connection: close, idle connections are closed in this interval. Disabled by default.")
+	flagset.DurationVar(&o.UpstreamKeepAlive, "upstream-tcp-keepalive", 30*time.Second, "The period of TCP keep-alive probes on connections to the upstream, such that half-open connections, e.g. dropped by NAT or a sidecar, are detected by the kernel. A negative value disables keep-alive probes.")
+	flagset.DurationVar(&o.UpstreamPingInterval, "upstream-http2-ping-interval", 0, "If set, HTTP/2 connections to the upstream are pinged once no frame was read from them for this interval, and closed if the ping isn't answered within --upstream-http2-ping-timeout, failing the requests on them with 502 instead of leaving long streams hanging. Disabled by default.")
+	flagset.DurationVar(&o.UpstreamPingTimeout, "upstream-http2-ping-timeout", 15*time.Second, "The timeout of the pings of --upstream-http2-ping-interval.")
+	flagset.DurationVar(&o.UpstreamExpectContinue, "upstream-expect-continue-timeout", time.Second, "The maximum time to wait for the 100 Continue of the upstream to requests with an Expect: 100-continue header, before their body is sent anyway. Clients are sent the 100 Continue only after their request is authorized, such that denied clients don't upload the body. Zero sends bodies without waiting.")
+	flagset.DurationVar(&o.UpstreamFlushInterval, "upstream-flush-interval", 0, "The interval at which responses of the upstream are flushed to the client. A negative value flushes immediately after each write. Server-sent events and responses of unknown length are always flushed immediately. The interval can be overridden per path in the config file.")
+	flagset.StringVar(&o.UpstreamSigningKeyFile, "upstream-signing-key-file", "", "If set, requests to the upstream are signed with an HMAC-SHA256 over the method, the request URI, a timestamp and the identity headers, using the shared secret of at least 32 bytes in this file. The signature is sent in the X-Kube-Rbac-Proxy-Signature header, the timestamp in the X-Kube-Rbac-Proxy-Signature-Timestamp header.")
+	flagset.StringVar(&o.UpstreamMirror, "upstream-mirror", "", "If set, copies of authenticated requests are sent to this secondary upstream URL in the background, e.g. to validate a new version of the upstream with production traffic. Its responses are discarded. The path of the URL is ignored, the mirror receives the paths the upstream receives. Requests with a body are only mirrored, if the body is buffered with --request-body-buffer-size. At most 100 mirrored requests are in flight, further ones are dropped.")
+	flagset.Float64Var(&o.UpstreamMirrorRate, "upstream-mirror-rate", 1, "The rate between 0 and 1 of the authenticated requests sent to --upstream-mirror.")
+	flagset.DurationVar(&o.UpstreamMirrorTimeout, "upstream-mirror-timeout", 10*time.Second, "The timeout of the requests sent to --upstream-mirror, including reading their responses.")
+	flagset.IntVar(&o.CompressionLevel, "compression-level", 0, "If set, uncompressed upstream responses are gzipped for clients accepting gzip, with a level between 1 (fastest, least CPU) and 9 (smallest). Responses compressed by the upstream are passed through. Disabled by default.")
+	flagset.Int64Var(&o.CompressionMinSize, "compression-min-size", 1024, "The minimum size in bytes of responses compressed with --compression-level. Responses of unknown size are always compressed.")
+	flagset.Int64Var(&o.WeakETagMaxSize, "weak-etag-max-size", 0, "If greater than 0, successful GET responses of up to this size in bytes without an ETag are tagged with a weak ETag of their content, unless they are marked no-store, and requests with a matching If-None-Match are answered with 304. The responses are buffered to be hashed. Disabled by default.")
+	flagset.IntVar(&o.ConnectionBandwidthLimit, "connection-bandwidth-limit", 0, "The maximum rate in bytes per second at which upstream responses are sent to a client connection, shared by its concurrent requests. Reading from the upstream is throttled alike. Unlimited if 0.")
+	flagset.IntVar(&o.TotalBandwidthLimit, "total-bandwidth-limit", 0, "The maximum rate in bytes per second at which upstream responses are sent to all clients together. Unlimited if 0.")
+	flagset.BoolVar(&o.UpstreamErrorDiagnostics, "upstream-error-diagnostics", false, "When set, 502 responses contain the reason and the error of the failed upstream request. Might expose details about the upstream network to clients.")
 	flagset.StringVar(&o.ConfigFileName, "config-file", "", "Configuration file to configure kube-rbac-proxy.")
-	flagset.StringSliceVar(&o.AllowPaths, "allow-paths", nil, "Comma-separated list of paths against which kube-rbac-proxy pattern-matches the incoming request. If the request doesn't match, kube-rbac-proxy responds with a 404 status code. If omitted, the incoming request path isn't checked. Cannot be used with --ignore-paths.")
+	flagset.BoolVar(&o.DumpEffectiveConfig, "dump-effective-config", false, "If set, the effective configuration of the flags and the config file is printed as YAML and the proxy exits, e.g. to migrate flags to the config file. Its configHash is the one reported by /version.")
+	flagset.StringSliceVar(&o.AllowPaths, "allow-paths", nil, "Comma-separated list of paths against which kube-rbac-proxy pattern-matches the incoming request. If the request doesn't match, kube-rbac-proxy responds with a 404 status code. If omitted, the incoming request path isn't checked. A path can be restricted to a user or the members of a group, by a trailing @user= or @group=, e.g. /debug/*@user=alice or /debug/*@group=system:masters, other authenticated users are answered with 403 unless another matching path allows them. Cannot be used with --ignore-paths.")
 	flagset.StringSliceVar(&o.IgnorePaths, "ignore-paths", nil, "Comma-separated list of paths against which kube-rbac-proxy pattern-matches the incoming request. If the requst matches, it will proxy the request without performing an authentication or authorization check. Cannot be used with --allow-paths.")
-	flagset.IntVar(&o.ProxyEndpointsPort, "proxy-endpoints-port", 0, "The port to securely serve proxy-specific endpoints (such as '/healthz'). Uses the host from the '--secure-listen-address'.")
+	flagset.StringSliceVar(&o.ProbePaths, "probe-paths", nil, "Comma-separated list of paths against which kube-rbac-proxy pattern-matches kubelet probes, identified by --probe-user-agent. Matching GET and HEAD requests are answered by kube-rbac-proxy with 200 without authentication and without contacting the upstream, such that the probes don't require RBAC permissions.")
+	flagset.StringVar(&o.ProbeUserAgent, "probe-user-agent", "kube-probe/", "The prefix of the User-Agent header identifying kubelet probes for --probe-paths.")
+	flagset.BoolVar(&o.MaintenanceMode, "maintenance-mode", false, "If set, kube-rbac-proxy starts in maintenance mode, answering requests of --maintenance-paths with 503. Maintenance mode is toggled at runtime by SIGUSR1 or by PUT /debug/maintenance?enabled=true|false on the --proxy-endpoints-port, by users allowed to update the non-resource URL /debug/maintenance. Without --maintenance-mode and --maintenance-paths, SIGUSR1 is ignored. Probes of --probe-paths are still answered.")
+	flagset.StringSliceVar(&o.MaintenancePaths, "maintenance-paths", nil, "Comma-separated list of paths against which kube-rbac-proxy pattern-matches requests answered with 503 in maintenance mode, which can be switched at runtime as of --maintenance-mode. If omitted, all requests are.")
+	flagset.DurationVar(&o.MaintenanceRetryAfter, "maintenance-retry-after", time.Minute, "The duration clients are asked to wait in the Retry-After header of responses in maintenance mode.")
+	flagset.StringSliceVar(&o.DeniedMethods, "denied-methods", []string{http.MethodConnect, http.MethodTrace}, "Comma-separated list of request methods answered with --denied-methods-status before authentication, also on --ignore-paths. A deny decision is exported for each denied request. Other methods without a verb mapping are authorized with the \"*\" verb and proxied. Set to an empty list to proxy all methods.")
+	flagset.IntVar(&o.DeniedMethodsStatus, "denied-methods-status", http.StatusMethodNotAllowed, "The status code requests of --denied-methods are answered with.")
+	flagset.StringVar(&o.AuthRequestPath, "auth-request-path", "", "If set, an endpoint compatible with NGINX auth_request and Traefik ForwardAuth is served at this path (e.g. /authz). It authenticates and authorizes the original request, given by the X-Original-Method/X-Original-URI or X-Forwarded-Method/X-Forwarded-Uri headers, and responds with 200, 401 or 403, with 404 if the original URI is outside of --allow-paths, or with 400 if the headers are missing. On success the identity is returned in the headers named by --auth-header-user-field-name and --auth-header-groups-field-name.")
+	flagset.StringVar(&o.AuthorizationWebhookPath, "authorization-webhook-path", "", "If set, the SubjectAccessReview webhook API of authorization.k8s.io/v1 is served at this path (e.g. /apis/authorization.k8s.io/v1/subjectaccessreviews), answering reviews with the decision of the deny lists, the static and the scope rules, see --authorization-webhook-delegate, so that other components can delegate to its policy. Callers must be authorized to create the path as a non-resource URL.")
+	flagset.BoolVar(&o.AuthorizationWebhookDelegate, "authorization-webhook-delegate", false, "If set, reviews of the --authorization-webhook-path that the deny lists, the static and the scope rules don't decide are passed on to the delegated authorization, i.e. the local RBAC authorizer and SubjectAccessReviews of the API server, with the permissions of the proxy. Otherwise they aren't allowed, such that callers can't query the API server about any user through the proxy.")
+	flagset.StringVar(&o.SelfCheckPath, "self-check-path", "", "If set, authenticated users can check at this path (e.g. /apis/authorization/self) whether they would be authorized for a hypothetical request, given by the method and uri query parameters and header parameters like \"X-Namespace: foo\". The response lists the decision for each of the generated authorization attributes as JSON.")
+	flagset.BoolVar(&o.LandingPage, "landing-page", false, "If set, GET requests of / are answered by kube-rbac-proxy instead of the upstream, with a page showing the authenticated user, its groups and which of the --landing-page-routes it may access.")
+	flagset.StringSliceVar(&o.LandingPageRoutes, "landing-page-routes", nil, "Comma-separated list of paths the --landing-page lists, each authorized like a GET request to the upstream.")
+	flagset.IntVar(&o.ProxyEndpointsPort, "proxy-endpoints-port", 0, "The port to securely serve proxy-specific endpoints (such as '/healthz', '/readyz', '/metrics' and a POST '/debug/authorization-cache/flush' endpoint, which is authorized as the create of its non-resource URL). Uses the host from the '--secure-listen-address'. '/readyz' fails until the OIDC issuer was discovered and a first SubjectAccessReview succeeded, and during the --shutdown-drain-period.")
+	flagset.DurationVar(&o.ShutdownDrainPeriod, "shutdown-drain-period", 0, "The duration kube-rbac-proxy keeps serving after SIGTERM, while '/readyz' on the --proxy-endpoints-port fails, such that endpoints controllers and service meshes stop routing traffic to it before it shuts down. Should be shorter than the terminationGracePeriodSeconds of the Pod.")
+	flagset.BoolVar(&o.ServerTiming, "server-timing", false, "If set, the time spent authenticating, authorizing and waiting for the upstream is sent to clients in the Server-Timing header of responses. The times are always observed in the kube_rbac_proxy_request_phase_duration_seconds metric.")
+	flagset.IntVar(&o.GCPercent, "gc-percent", 0, "If set, the garbage collection target percentage, overriding the GOGC environment variable. Higher values trade memory for fewer collections, a negative value disables the collector unless the --memory-limit is reached.")
+	flagset.Int64Var(&o.MemoryLimit, "memory-limit", 0, "If greater than 0, the soft memory limit of the Go runtime in bytes, overriding the GOMEMLIMIT environment variable. The collector runs more often when the limit is approached, it should be below the memory limit of the container.")
+	flagset.Int64Var(&o.MemoryBallast, "memory-ballast", 0, "If greater than 0, a ballast of this size in bytes is allocated on startup, which raises the heap size collections are triggered at without being resident. Prefer --memory-limit, the ballast is kept for runtimes tuned with it.")
 
 	// TLS flags
 	flagset.StringVar(&o.TLS.CertFile, "tls-cert-file", "", "File containing the default x509 Certificate for HTTPS. (CA cert, if any, concatenated after server cert)")
 	flagset.StringVar(&o.TLS.KeyFile, "tls-private-key-file", "", "File containing the default x509 private key matching --tls-cert-file.")
+	flagset.StringVar(&o.TLS.KeyPKCS11URI, "tls-private-key-pkcs11-uri", "", "If set, the private key matching --tls-cert-file is on a PKCS #11 token, e.g. of an HSM or a TPM, identified by this RFC 7512 URI, e.g. pkcs11:token=proxy;object=serving?module-path=/usr/lib/softhsm/libsofthsm2.so&pin-source=/etc/pin. Requires a build with cgo and the pkcs11 build tag.")
+	flagset.StringVar(&o.TLS.KeyPassphrase.File, "tls-private-key-passphrase-file", "", "If set, the --tls-private-key-file and the keys of --tls-sni-cert-key, if encrypted as PKCS #8, are decrypted with the passphrase in this file. It is read again when a key is reloaded.")
+	flagset.StringVar(&o.TLS.KeyPassphrase.Env, "tls-private-key-passphrase-env", "", "If set, the --tls-private-key-file and the keys of --tls-sni-cert-key, if encrypted as PKCS #8, are decrypted with the passphrase in this environment variable.")
 	flagset.StringVar(&o.TLS.MinVersion, "tls-min-version", "VersionTLS12", "Minimum TLS version supported. Value must match version names from https://golang.org/pkg/crypto/tls/#pkg-constants.")
 	flagset.StringSliceVar(&o.TLS.CipherSuites, "tls-cipher-suites", nil, "Comma-separated list of cipher suites for the server. Values are from tls package constants (https://golang.org/pkg/crypto/tls/#pkg-constants). If omitted, the default Go cipher suites will be used")
 	flagset.DurationVar(&o.TLS.ReloadInterval, "tls-reload-interval", time.Minute, "The interval at which to watch for TLS certificate changes, by default set to 1 minute.")
+	flagset.StringVar(&o.TLS.ClientAuthPolicy, "tls-client-auth-policy", rbac_proxy_tls.ClientAuthRequest, "Whether the secure listeners ask for client certificates, one of none, request or require-verify. With request, clients without a certificate may authenticate with tokens. With require-verify, handshakes without a client certificate signed by --client-ca-file fail, failures are counted by reason.")
+	flagset.StringSliceVar(&o.TLS.ClientCertRequiredEKUs, "tls-client-cert-required-ekus", nil, "Comma-separated list of extended key usages, all of which client certificates must list, by name, e.g. clientAuth, or as dotted OID. Unlike without the list, certificates without the extension are rejected. Requires --tls-client-auth-policy=require-verify.")
+	flagset.StringSliceVar(&o.TLS.ClientCertSignatureAlgorithms, "tls-client-cert-signature-algorithms", nil, "Comma-separated list of accepted signature algorithms of client certificates and their intermediates, e.g. ECDSA-SHA256,SHA256-RSAPSS. Any algorithm is accepted, if empty. Requires --tls-client-auth-policy=require-verify.")
+	flagset.Var(k8sapiflag.NewNamedCertKeyArray(&o.TLS.SNICertKeys), "tls-sni-cert-key", "A pair of x509 certificate and private key file paths, optionally suffixed with a list of domain patterns which are fully qualified domain names, possibly with prefixed wildcard segments. If no domain patterns are provided, the names of the certificate are extracted. The domain patterns also allow IP addresses, but IPs should only be used if the client uses the IP address as SNI. Certificates are selected by the server name of the TLS handshake, falling back to --tls-cert-file. Examples: \"example.crt,example.key\" or \"foo.crt,foo.key:*.foo.com,foo.com\".")
+	flagset.StringToStringVar(&o.TLS.SNIClientCAFiles, "tls-sni-client-ca-file", nil, "Comma-separated list of domain pattern=CA file pairs. TLS handshakes for a matching server name are rejected, unless they present a client certificate signed by one of the authorities in the CA file. Requests with a matching Host on connections of another server name are rejected with 421 Misdirected Request. The identity of the client is still determined by --client-ca-file.")
 	flagset.StringVar(&o.TLS.UpstreamClientCertFile, "upstream-client-cert-file", "", "If set, the client will be used to authenticate the proxy to upstream. Requires --upstream-client-key-file to be set, too.")
 	flagset.StringVar(&o.TLS.UpstreamClientKeyFile, "upstream-client-key-file", "", "The key matching the certificate from --upstream-client-cert-file. If set, requires --upstream-client-cert-file to be set, too.")
+	flagset.StringVar(&o.TLS.UpstreamKeyPassphrase.File, "upstream-client-key-passphrase-file", "", "If set, the --upstream-client-key-file, if encrypted as PKCS #8, is decrypted with the passphrase in this file.")
+	flagset.StringVar(&o.TLS.UpstreamKeyPassphrase.Env, "upstream-client-key-passphrase-env", "", "If set, the --upstream-client-key-file, if encrypted as PKCS #8, is decrypted with the passphrase in this environment variable.")
 
 	// Auth flags
 	flagset.StringVar(&o.Auth.Authentication.X509.ClientCAFile, "client-ca-file", "", "If set, any request presenting a client certificate signed by one of the authorities in the client-ca-file is authenticated with an identity corresponding to the CommonName of the client certificate.")
+	flagset.DurationVar(&o.Auth.Authentication.X509.ConnectionCacheTTL, "client-cert-connection-cache-ttl", 0, "If set, the user of a verified client certificate is cached for the duration per TLS connection, skipping the verification for further requests on the same connection. The cache is flushed when the --client-ca-file changes. Disabled by default.")
+	flagset.StringVar(&o.Auth.Authentication.X509.CRLFile, "client-crl-file", "", "If set, TLS handshakes presenting a client certificate revoked by the PEM or DER encoded CRL are rejected. The file is reloaded in the --tls-reload-interval. The CRL must be signed by a CA of --client-ca-file. While the CRL is past its next update, handshakes presenting a client certificate fail. Requires --client-ca-file to be set.")
+	flagset.BoolVar(&o.Auth.Authentication.X509.OCSPCheck, "client-ocsp-check", false, "If set, the OCSP responder of a client certificate is queried during the TLS handshake and revoked certificates are rejected. Unreachable responders don't fail the handshake. Requires --client-ca-file to be set.")
+	flagset.StringVar(&o.Auth.Authentication.X509.IntermediatesFile, "client-ca-intermediates-file", "", "If set, the chains presented by clients are completed with the intermediates of the PEM file, such that clients may present their leaf certificate only. The file is reloaded on SIGHUP. Requires --client-ca-file to be set.")
+	flagset.StringSliceVar(&o.Auth.Authentication.X509.AIAHosts, "client-ca-aia-hosts", nil, "Comma-separated list of hosts, from which missing intermediates of client certificates are fetched by the Authority Information Access URLs of the certificates. URLs of other hosts are ignored, as they come from unverified certificates. Requires --client-ca-file to be set.")
+	flagset.BoolVar(&o.Auth.Authentication.Impersonation, "auth-impersonation", false, "If set, authenticated users, e.g. a front proxy, may act as another user with the Impersonate-User, Impersonate-Group, Impersonate-Uid and Impersonate-Extra-* headers. Like for the API server, each asserted attribute requires the impersonate verb on users, groups, serviceaccounts, uids or userextras.")
 	flagset.BoolVar(&o.Auth.Authentication.Header.Enabled, "auth-header-fields-enabled", false, "When set to true, kube-rbac-proxy adds auth-related fields to the headers of http requests sent to the upstream")
 	flagset.StringVar(&o.Auth.Authentication.Header.UserFieldName, "auth-header-user-field-name", "x-remote-user", "The name of the field inside a http(2) request header to tell the upstream server about the user's name")
 	flagset.StringVar(&o.Auth.Authentication.Header.GroupsFieldName, "auth-header-groups-field-name", "x-remote-groups", "The name of the field inside a http(2) request header to tell the upstream server about the user's groups")
 	flagset.StringVar(&o.Auth.Authentication.Header.GroupSeparator, "auth-header-groups-field-separator", "|", "The separator string used for concatenating multiple group names in a groups header field's value")
-	flagset.StringSliceVar(&o.Auth.Authentication.Token.Audiences, "auth-token-audiences", []string{}, "Comma-separated list of token audiences to accept. By default a token does not have to have any specific audience. It is recommended to set a specific audience.")
+	flagset.IntVar(&o.Auth.Authentication.Header.GroupsMaxValueSize, "auth-header-groups-field-max-value-size", 0, "If greater than 0, the groups are split across several values of the groups header field, each of at most this size in bytes, so that upstreams limiting the size of header values accept users with many groups. The upstream must read all values of the field. By default all groups are joined into one value.")
+	flagset.IntVar(&o.Auth.Authentication.Header.GroupsMaxSize, "auth-header-groups-field-max-size", 0, "If greater than 0, the total size in bytes of the groups header field values is capped, requests of users whose groups exceed it are handled by --auth-header-groups-field-overflow. Unlimited by default.")
+	flagset.StringVar(&o.Auth.Authentication.Header.GroupsOverflow, "auth-header-groups-field-overflow", authn.GroupsOverflowReject, "How requests are handled, whose groups exceed --auth-header-groups-field-max-size, either reject (with 431) or truncate, which passes on the groups that fit. Both are counted in kube_rbac_proxy_auth_header_groups_overflows_total.")
+	flagset.StringVar(&o.Auth.Authentication.Header.ClientFields, "auth-header-fields-from-client", "", "How requests are handled, whose clients already sent the --auth-header-user-field-name or --auth-header-groups-field-name headers, either strip or reject (with 400). By default the headers are only overwritten for authenticated requests and reach the upstream unchanged for --ignore-paths. Requires --auth-header-fields-enabled.")
+	flagset.StringVar(&o.AuthChallengeRealm, "auth-challenge-realm", "", "The realm of the WWW-Authenticate: Bearer challenge of 401 responses, as of RFC 6750, e.g. kube-rbac-proxy. The challenge is omitted, if empty.")
+	flagset.BoolVar(&o.AuthChallengeErrorCodes, "auth-challenge-error-codes", true, "If set, the challenge of requests whose bearer token was rejected carries error=\"invalid_token\", such that clients can tell a rejected token from a missing one.")
+	flagset.StringSliceVar(&o.Auth.Authentication.Token.ServiceAccountNamespaces, "auth-token-service-account-namespaces", nil, "Comma-separated list of namespaces whose service account tokens are accepted. If set, service account tokens of other namespaces, or issued for none of the --auth-token-audiences, are rejected from their claims before the TokenReview, and the namespace of the reviewed service account is checked again. Other tokens are not affected.")
+	flagset.StringVar(&o.Auth.Authentication.Token.AuthFile, "token-auth-file", "", "If set, the static bearer tokens of the CSV file are authenticated, with records of token, user name, uid and optionally groups like for the API server: token,user,uid,\"group1,group2\". Other tokens are authenticated as before. The file is reloaded in the --tls-reload-interval.")
+	flagset.StringSliceVar(&o.Auth.Authentication.Token.Audiences, "auth-token-audiences", []string{}, "Comma-separated list of token audiences to accept. By default a token does not have to have any specific audience. It is recommended to set a specific audience. Audiences containing a * are wildcard patterns, e.g. https://*.example.com, audiences prefixed with regexp: are regular expressions matching whole audiences. The audiences of a token matching the patterns are checked by the TokenReview.")
+	flagset.StringVar(&o.Auth.Authentication.Token.CacheFile, "auth-token-cache-file", "", "If set, the users of bearer tokens reviewed successfully with TokenReviews are persisted in this file, e.g. on a volume surviving restarts, encrypted with --auth-token-cache-key-file. Tokens whose TokenReview fails with an error, e.g. as the API server is unavailable, are authenticated from the file, such that a restart of the proxy during an outage doesn't lock out clients. Rejected tokens are removed. The file holds hashes of the tokens only.")
+	flagset.StringVar(&o.Auth.Authentication.Token.CacheKeyFile, "auth-token-cache-key-file", "", "File containing a secret of at least 32 bytes, e.g. from a Secret, the --auth-token-cache-file is encrypted with.")
+	flagset.DurationVar(&o.Auth.Authentication.Token.CacheMaxAge, "auth-token-cache-max-age", time.Hour, "How long after its last successful TokenReview a token is authenticated from the --auth-token-cache-file.")
+	flagset.BoolVar(&o.AuthorizationCacheWatch, "authorization-cache-rbac-watch", false, "If set, the cached SubjectAccessReview decisions are flushed whenever Roles, RoleBindings, ClusterRoles or ClusterRoleBindings change, such that revoked permissions take effect within seconds. Requires permissions to list and watch these resources cluster-wide.")
+	flagset.StringSliceVar(&o.CacheBypassGroups, "cache-bypass-groups", nil, "Comma-separated list of groups, whose members can force a fresh TokenReview and SubjectAccessReviews, instead of cached results, with the X-KRP-No-Cache request header, e.g. to debug the propagation of permissions. The fresh decisions replace the cached ones. The header is ignored for other users and not proxied.")
+	flagset.BoolVar(&o.AuthorizationLocalRBAC, "authorization-local-rbac", false, "If set, requests are evaluated against Roles, RoleBindings, ClusterRoles and ClusterRoleBindings watched from the API server, and only requests not allowed by them are sent as a SubjectAccessReview. The evaluation mirrors the RBAC authorizer of the API server, but requests allowed locally bypass its other authorizers, e.g. webhooks or the Node authorizer, which can't deny them. Requires permissions to list and watch these resources cluster-wide and the alpha feature gate LocalRBACAuthorizer.")
+	flagset.BoolVar(&o.AuthorizationConnectionExtra, "authorization-connection-extra", false, "When set, the remote IP, the TLS server name and version and the client certificate fingerprint of the client connection are added to the extra info of the user in the SubjectAccessReviews, with keys prefixed with kube-rbac-proxy.io/. As decisions are cached per extra info, the cache is less effective.")
+	flagset.IntVar(&o.AuthorizationMetricsMaxSeries, "authorization-metrics-max-series", 0, "If greater than 0, the authorization decisions are counted by namespace, resource, verb and decision in the kube_rbac_proxy_authorization_attribute_decisions_total metric, e.g. to spot clients hammering denied resources. Beyond this number of distinct namespace, resource and verb combinations, decisions are counted with the label values \"other\". Disabled by default.")
+	flagset.IntVar(&o.AuthorizationDenialEvents.Threshold, "authorization-denial-events-threshold", 0, "If greater than 0, a Warning event is emitted on the pod of the proxy, once a user is denied this number of times within --authorization-denial-events-window, at most once per user and window. The pod is named by the POD_NAME, POD_NAMESPACE and POD_UID environment variables, usually populated by the downward API. Requires the create and patch permissions on events. Disabled by default.")
+	flagset.DurationVar(&o.AuthorizationDenialEvents.Window, "authorization-denial-events-window", time.Minute, "The window in which the denials of a user are counted for --authorization-denial-events-threshold.")
+	flagset.BoolVar(&o.LogAuthorizationGrants, "log-authorization-grants", false, "If set, the RoleBinding or ClusterRoleBinding and the role that allowed a request are logged, as reported by the SubjectAccessReview. They are also logged at verbosity 4 and above.")
+	flagset.BoolVar(&o.StartupPermissionCheck, "startup-permission-check", true, "If set, kube-rbac-proxy confirms on startup with SelfSubjectAccessReviews that it is allowed to create TokenReviews and SubjectAccessReviews, and fails with the missing permissions instead of failing each request. Permissions that can't be checked, e.g. as the API server is unavailable, are logged and skipped. Set to false to start without the check.")
+
+	//Authn cloud flags
+	flagset.StringVar(&o.Auth.Authentication.Cloud.GCPAudience, "auth-gcp-audience", "", "If set, Google-signed identity tokens of GCP service accounts issued for this audience are authenticated, with the email of the service account as username. The tokens must be requested in the full format, to contain the email.")
+	flagset.StringVar(&o.Auth.Authentication.Cloud.AWSClusterID, "auth-aws-cluster-id", "", "If set, tokens of AWS IAM identities generated for this cluster ID, e.g. by \"aws eks get-token --cluster-name\", are authenticated by STS, with the ARN of the IAM user or role as username. Sessions of an assumed role are authenticated as the role.")
 
 	//Authn OIDC flags
 	flagset.StringVar(&o.Auth.Authentication.OIDC.IssuerURL, "oidc-issuer", "", "The URL of the OpenID issuer, only HTTPS scheme will be accepted. If set, it will be used to verify the OIDC JSON Web Token (JWT).")
@@ -123,28 +309,82 @@ func (o *ProxyRunOptions) Flags() k8sapiflag.NamedFlagSets {
 	flagset.StringVar(&o.Auth.Authentication.OIDC.UsernamePrefix, "oidc-username-prefix", "", "If provided, the username will be prefixed with this value to prevent conflicts with other authentication strategies.")
 	flagset.StringVar(&o.Auth.Authentication.OIDC.GroupsPrefix, "oidc-groups-prefix", "", "If provided, all groups will be prefixed with this value to prevent conflicts with other authentication strategies.")
 	flagset.StringArrayVar(&o.Auth.Authentication.OIDC.SupportedSigningAlgs, "oidc-sign-alg", []string{"RS256"}, "Supported signing algorithms, default RS256")
+	flagset.StringSliceVar(&o.Auth.Authentication.OIDC.UsernameFallbackClaims, "oidc-username-fallback-claims", nil, "Comma-separated list of claims the username is taken from, in order, if the --oidc-username-claim is missing, e.g. azp,appid for the access tokens Azure AD issues to applications. The --oidc-username-prefix applies alike.")
+	flagset.DurationVar(&o.Auth.Authentication.OIDC.ClockSkew, "oidc-clock-skew", 0, "The tolerated difference between the clocks of the OpenID issuer and the proxy. Tokens expired or not yet valid by no more than this are accepted, e.g. tokens of cloud identity providers presented by scrapers outside of the cluster.")
+	flagset.StringVar(&o.EgressProxyURL, "egress-proxy-url", "", "The URL of the proxy used to reach the OpenID issuer for discovery and key fetches, and the cloud providers for --auth-gcp-audience and --auth-aws-cluster-id. If not set, the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables are honored.")
+	flagset.StringVar(&o.EgressNoProxy, "egress-no-proxy", "", "Comma-separated list of hosts, domains and CIDRs reached without --egress-proxy-url, in the format of NO_PROXY.")
+	flagset.StringVar(&o.Auth.Authentication.OIDC.LoginRedirectURL, "oidc-login-redirect-url", "", "If set, unauthenticated browsers are logged in with the authorization code flow with PKCE of the --oidc-issuer, and this URL of the proxy, which must be registered with the issuer, receives the authorization code. The identity of the ID token is kept in an encrypted session cookie, requests are authorized with it as with bearer tokens. Requires --oidc-login-client-secret-file and --oidc-login-cookie-secret-file.")
+	flagset.StringVar(&o.Auth.Authentication.OIDC.LoginClientSecretFile, "oidc-login-client-secret-file", "", "File containing the client secret of the --oidc-clientID for the browser login.")
+	flagset.StringVar(&o.Auth.Authentication.OIDC.LoginCookieSecretFile, "oidc-login-cookie-secret-file", "", "File containing a secret of at least 32 bytes the session cookies of the browser login are encrypted with. Changing the secret logs out all users.")
+	flagset.DurationVar(&o.Auth.Authentication.OIDC.LoginSessionTTL, "oidc-login-session-ttl", 8*time.Hour, "The lifetime of the session cookies of the browser login. Changes of the identity at the issuer, e.g. of its groups, only apply after a new login.")
+	flagset.StringSliceVar(&o.Auth.Authentication.OIDC.LoginScopes, "oidc-login-scopes", []string{"openid", "email", "profile"}, "Comma-separated list of scopes requested with the browser login, must include openid.")
+	flagset.StringVar(&o.Auth.Authentication.OIDC.LoginSessionStore, "oidc-login-session-store", authn.SessionStoreCookie, "Where the sessions of the browser login are kept. One of cookie (the encrypted session is the cookie, no state is kept), memory (the cookie holds an ID of a session kept in memory, lost on restarts and not shared by replicas) or redis (the cookie holds an ID of a session kept in --oidc-login-redis-address, shared by replicas).")
+	flagset.StringVar(&o.Auth.Authentication.OIDC.LoginRedisAddress, "oidc-login-redis-address", "", "The host:port of the Redis server of --oidc-login-session-store=redis.")
+	flagset.StringVar(&o.Auth.Authentication.OIDC.LoginRedisPasswordFile, "oidc-login-redis-password-file", "", "File containing the password of the Redis server.")
+	flagset.StringVar(&o.Auth.Authentication.OIDC.LoginRedisCAFile, "oidc-login-redis-ca-file", "", "If set, connections to the Redis server use TLS, and its certificate is verified by one of the authorities in this file.")
 	flagset.StringVar(&o.Auth.Authentication.OIDC.CAFile, "oidc-ca-file", "", "If set, the OpenID server's certificate will be verified by one of the authorities in the oidc-ca-file, otherwise the host's root CA set will be used.")
 
+	//Authn LDAP flags
+	flagset.StringVar(&o.Auth.Authentication.LDAP.URL, "ldap-url", "", "If set, the groups of authenticated users are extended by their groups in this LDAP server, e.g. ldaps://ldap.example.com:636.")
+	flagset.StringVar(&o.Auth.Authentication.LDAP.BindDN, "ldap-bind-dn", "", "The DN to bind to the LDAP server with. If omitted, the search is anonymous.")
+	flagset.StringVar(&o.Auth.Authentication.LDAP.BindPasswordFile, "ldap-bind-password-file", "", "File containing the password for --ldap-bind-dn.")
+	flagset.StringVar(&o.Auth.Authentication.LDAP.CAFile, "ldap-ca-file", "", "If set, the LDAP server's certificate will be verified by one of the authorities in the ldap-ca-file, otherwise the host's root CA set will be used.")
+	flagset.StringVar(&o.Auth.Authentication.LDAP.BaseDN, "ldap-group-base-dn", "", "The base DN to search groups in.")
+	flagset.StringVar(&o.Auth.Authentication.LDAP.GroupFilter, "ldap-group-filter", "(member=uid={username})", "The LDAP filter selecting the groups of a user. {username} is replaced by the escaped name of the authenticated user.")
+	flagset.StringVar(&o.Auth.Authentication.LDAP.GroupAttribute, "ldap-group-attribute", "cn", "The attribute of the group entries used as group name.")
+	flagset.DurationVar(&o.Auth.Authentication.LDAP.Timeout, "ldap-timeout", 5*time.Second, "The timeout for connecting to and searching the LDAP server.")
+	flagset.DurationVar(&o.Auth.Authentication.LDAP.CacheTTL, "ldap-cache-ttl", 5*time.Minute, "The duration the LDAP groups of a user are cached. Disabled, if 0.")
+
 	//Kubeconfig flag
 	flagset.StringVar(&o.KubeconfigLocation, "kubeconfig", "", "Path to a kubeconfig file, specifying how to connect to the API server. If unset, in-cluster configuration will be used")
 	flagset.Float32Var(&o.QPS, "kube-api-qps", 0, "queries per second to the api, kube-client starts client-side throttling, when breached")
 	flagset.IntVar(&o.Burst, "kube-api-burst", 0, "kube-api burst value; needed when kube-api-qps is set")
+	flagset.BoolVar(&o.PriorityAndFairness, "kube-api-priority-and-fairness", false, "Disable client-side throttling of the TokenReview and SubjectAccessReview clients and rely on the API Priority and Fairness of the API server instead. Cannot be used with --kube-api-qps or --kube-api-burst.")
 
 	// HTTP2 flags
 	flagset.BoolVar(&o.HTTP2Disable, "http2-disable", false, "Disable HTTP/2 support")
 	flagset.Uint32Var(&o.HTTP2MaxConcurrentStreams, "http2-max-concurrent-streams", 100, "The maximum number of concurrent streams per HTTP/2 connection.")
 	flagset.Uint32Var(&o.HTTP2MaxSize, "http2-max-size", 256*1024, "The maximum number of bytes that the server will accept for frame size and buffer per stream in a HTTP/2 request.")
 
+	// Request limit flags
+	flagset.IntVar(&o.MaxHeaderBytes, "max-header-bytes", http.DefaultMaxHeaderBytes, "The maximum number of bytes of the request headers, including the request line. Larger requests are rejected with 431 by the HTTP server before they are handled, so unlike the rejections of --max-headers and --max-url-length, they are not counted in kube_rbac_proxy_rejected_requests_total.")
+	flagset.IntVar(&o.MaxHeaders, "max-headers", 0, "The maximum number of request header values. Requests with more headers are rejected with 431. Unlimited if 0.")
+	flagset.IntVar(&o.MaxURLLength, "max-url-length", 0, "The maximum length of the request URL. Requests with longer URLs are rejected with 414. Unlimited if 0.")
+	flagset.Int64Var(&o.RequestBodyBufferSize, "request-body-buffer-size", 0, "If set, request bodies of authenticated requests up to this size in bytes are buffered in memory, such that requests are retried once when the connection to the upstream is reset, which might process non-idempotent requests twice. Larger bodies and bodies of requests with an Expect: 100-continue header are streamed and not retried. Disabled if 0.")
+
+	// Decision export flags
+	flagset.StringVar(&o.DecisionExport.Sink, "decision-export-sink", "", "If set, every authorization decision is exported asynchronously to the given sink. One of: http, syslog.")
+	flagset.StringVar(&o.DecisionExport.Address, "decision-export-address", "", "The address of the decision export sink. For the http sink the URL the decisions are POSTed to as JSON, for the syslog sink [tcp|udp://]host:port.")
+	flagset.IntVar(&o.DecisionExport.BufferSize, "decision-export-buffer-size", 1000, "The maximum number of decisions buffered for export. Decisions are dropped, if the buffer is full.")
+	flagset.BoolVar(&o.DecisionExportBodyHash, "decision-export-body-hash", false, "If set, the SHA-256 of request bodies buffered by --request-body-buffer-size is added to the exported decisions.")
+
+	// Fault injection flags, hidden as they are meant for resilience testing only
+	flagset.DurationVar(&o.Faults.SARLatency, "fault-sar-latency", 0, "The latency injected into authorization decisions of the SubjectAccessReview authorizer.")
+	flagset.Float64Var(&o.Faults.SARLatencyRate, "fault-sar-latency-rate", 0, "The rate between 0 and 1 of authorization decisions delayed by --fault-sar-latency.")
+	flagset.Float64Var(&o.Faults.AuthenticationFailureRate, "fault-authentication-failure-rate", 0, "The rate between 0 and 1 of authentications failing with an error.")
+	flagset.Float64Var(&o.Faults.UpstreamErrorRate, "fault-upstream-error-rate", 0, "The rate between 0 and 1 of upstream requests failing with 502.")
+	for _, name := range []string{"fault-sar-latency", "fault-sar-latency-rate", "fault-authentication-failure-rate", "fault-upstream-error-rate"} {
+		if err := flagset.MarkHidden(name); err != nil {
+			panic(err)
+		}
+	}
+
+	// Feature gates
+	features.DefaultMutableFeatureGate.AddFlag(flagset)
+
 	// disabled flags
 	o.addDisabledFlags(flagset)
 
+	// Logging flags, e.g. --logging-format=json
+	logsapi.AddFlags(o.Logs, namedFlagSets.FlagSet("logging"))
+
 	return namedFlagSets
 }
 
 func (o *ProxyRunOptions) Validate() error {
 	var errs []error
 
-	hasCerts := !(o.TLS.CertFile == "") && !(o.TLS.KeyFile == "")
+	hasCerts := !(o.TLS.CertFile == "") && !(o.TLS.KeyFile == "" && o.TLS.KeyPKCS11URI == "")
 	hasInsecureListenAddress := o.InsecureListenAddress != ""
 	if !hasCerts || hasInsecureListenAddress {
 		klog.Warning(`
@@ -195,6 +435,307 @@ For more information, please go to https://github.com/brancz/kube-rbac-proxy/iss
 		}
 	}
 
+	for _, probePath := range o.ProbePaths {
+		_, err := path.Match(probePath, "")
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to verify probe path: %s", probePath))
+		}
+	}
+
+	for _, maintenancePath := range o.MaintenancePaths {
+		_, err := path.Match(maintenancePath, "")
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to verify maintenance path: %s", maintenancePath))
+		}
+	}
+	if o.AuthorizationMetricsMaxSeries < 0 {
+		errs = append(errs, fmt.Errorf("--authorization-metrics-max-series must not be negative"))
+	}
+	if o.AuthorizationDenialEvents.Threshold < 0 {
+		errs = append(errs, fmt.Errorf("--authorization-denial-events-threshold must not be negative"))
+	}
+	if o.AuthorizationDenialEvents.Threshold > 0 && o.AuthorizationDenialEvents.Window <= 0 {
+		errs = append(errs, fmt.Errorf("--authorization-denial-events-window must be positive"))
+	}
+
+	if o.UpstreamConnectionMaxAge < 0 {
+		errs = append(errs, fmt.Errorf("--upstream-connection-max-age must not be negative"))
+	}
+	if o.WeakETagMaxSize < 0 {
+		errs = append(errs, fmt.Errorf("--weak-etag-max-size must not be negative"))
+	}
+	if o.UpstreamMirrorRate < 0 || o.UpstreamMirrorRate > 1 {
+		errs = append(errs, fmt.Errorf("--upstream-mirror-rate must be between 0 and 1"))
+	}
+	if o.UpstreamMirror != "" && o.UpstreamMirrorTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("--upstream-mirror-timeout must be positive"))
+	}
+	if token := o.Auth.Authentication.Token; token.CacheFile != "" || token.CacheKeyFile != "" {
+		if token.CacheFile == "" || token.CacheKeyFile == "" {
+			errs = append(errs, fmt.Errorf("--auth-token-cache-file and --auth-token-cache-key-file must be set together"))
+		}
+		if token.CacheMaxAge <= 0 {
+			errs = append(errs, fmt.Errorf("--auth-token-cache-max-age must be positive"))
+		}
+		if o.Auth.Authentication.OIDC.IssuerURL != "" {
+			errs = append(errs, fmt.Errorf("--auth-token-cache-file cannot be used with --oidc-issuer, the cache is of TokenReviews"))
+		}
+	}
+	if oidc := o.Auth.Authentication.OIDC; oidc.LoginRedirectURL != "" {
+		if u, err := url.Parse(oidc.LoginRedirectURL); err != nil || !u.IsAbs() || u.Path == "" {
+			errs = append(errs, fmt.Errorf("--oidc-login-redirect-url must be an absolute URL with a path"))
+		}
+		if oidc.IssuerURL == "" {
+			errs = append(errs, fmt.Errorf("--oidc-login-redirect-url requires --oidc-issuer"))
+		}
+		if oidc.LoginClientSecretFile == "" || oidc.LoginCookieSecretFile == "" {
+			errs = append(errs, fmt.Errorf("--oidc-login-redirect-url requires --oidc-login-client-secret-file and --oidc-login-cookie-secret-file"))
+		}
+		if !slices.Contains(oidc.LoginScopes, "openid") {
+			errs = append(errs, fmt.Errorf("--oidc-login-scopes must include openid"))
+		}
+		if oidc.LoginSessionTTL <= 0 {
+			errs = append(errs, fmt.Errorf("--oidc-login-session-ttl must be positive"))
+		}
+		switch oidc.LoginSessionStore {
+		case authn.SessionStoreCookie, authn.SessionStoreMemory:
+		case authn.SessionStoreRedis:
+			if oidc.LoginRedisAddress == "" {
+				errs = append(errs, fmt.Errorf("--oidc-login-session-store=redis requires --oidc-login-redis-address"))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("unknown --oidc-login-session-store %q", oidc.LoginSessionStore))
+		}
+	}
+	if o.UpstreamExpectContinue < 0 {
+		errs = append(errs, fmt.Errorf("--upstream-expect-continue-timeout must not be negative"))
+	}
+	if o.UpstreamPingInterval < 0 {
+		errs = append(errs, fmt.Errorf("--upstream-http2-ping-interval must not be negative"))
+	}
+	if o.UpstreamPingInterval > 0 && o.UpstreamPingTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("--upstream-http2-ping-timeout must be positive"))
+	}
+
+	if o.MemoryLimit < 0 {
+		errs = append(errs, fmt.Errorf("--memory-limit must not be negative"))
+	}
+	if o.MemoryBallast < 0 {
+		errs = append(errs, fmt.Errorf("--memory-ballast must not be negative"))
+	}
+	if o.MemoryLimit > 0 && o.MemoryBallast >= o.MemoryLimit {
+		errs = append(errs, fmt.Errorf("--memory-ballast must be smaller than --memory-limit"))
+	}
+
+	if o.ShutdownDrainPeriod < 0 {
+		errs = append(errs, fmt.Errorf("--shutdown-drain-period must not be negative"))
+	}
+
+	if o.MaintenanceRetryAfter < 0 {
+		errs = append(errs, fmt.Errorf("--maintenance-retry-after must not be negative"))
+	}
+
+	for _, method := range o.DeniedMethods {
+		if method == "" || strings.ToUpper(method) != method {
+			errs = append(errs, fmt.Errorf("--denied-methods must be upper-case methods, got %q", method))
+		}
+	}
+	if o.DeniedMethodsStatus < 400 || o.DeniedMethodsStatus > 599 {
+		errs = append(errs, fmt.Errorf("--denied-methods-status must be a 4xx or 5xx status code"))
+	}
+
+	// The realm is sent as a quoted string.
+	if strings.ContainsFunc(o.AuthChallengeRealm, func(r rune) bool { return r < ' ' || r > '~' || r == '"' || r == '\\' }) {
+		errs = append(errs, fmt.Errorf("--auth-challenge-realm must consist of printable ASCII characters other than quotes and backslashes"))
+	}
+
+	if o.ConnectionBandwidthLimit < 0 || o.TotalBandwidthLimit < 0 {
+		errs = append(errs, fmt.Errorf("--connection-bandwidth-limit and --total-bandwidth-limit must not be negative"))
+	}
+
+	if o.RequestBodyBufferSize < 0 {
+		errs = append(errs, fmt.Errorf("--request-body-buffer-size must not be negative"))
+	}
+	if o.DecisionExportBodyHash && o.RequestBodyBufferSize == 0 {
+		errs = append(errs, fmt.Errorf("--decision-export-body-hash requires --request-body-buffer-size"))
+	}
+
+	if o.AuthRequestPath != "" && !strings.HasPrefix(o.AuthRequestPath, "/") {
+		errs = append(errs, fmt.Errorf("--auth-request-path must start with /"))
+	}
+	if o.SelfCheckPath != "" && !strings.HasPrefix(o.SelfCheckPath, "/") {
+		errs = append(errs, fmt.Errorf("--self-check-path must start with /"))
+	}
+	if len(o.LandingPageRoutes) > 0 && !o.LandingPage {
+		errs = append(errs, fmt.Errorf("--landing-page-routes requires --landing-page"))
+	}
+	for _, route := range o.LandingPageRoutes {
+		if !strings.HasPrefix(route, "/") {
+			errs = append(errs, fmt.Errorf("--landing-page-routes must start with /, got %q", route))
+		}
+	}
+	if o.AuthorizationWebhookPath != "" && !strings.HasPrefix(o.AuthorizationWebhookPath, "/") {
+		errs = append(errs, fmt.Errorf("--authorization-webhook-path must start with /"))
+	}
+	if o.AuthorizationWebhookDelegate && o.AuthorizationWebhookPath == "" {
+		errs = append(errs, fmt.Errorf("--authorization-webhook-delegate requires --authorization-webhook-path to be set"))
+	}
+	if o.AuthorizationLocalRBAC && !features.DefaultFeatureGate.Enabled(features.LocalRBACAuthorizer) {
+		errs = append(errs, fmt.Errorf("--authorization-local-rbac requires --feature-gates=%s=true", features.LocalRBACAuthorizer))
+	}
+	if o.UpstreamForceH2C && !features.DefaultFeatureGate.Enabled(features.UpstreamH2C) {
+		errs = append(errs, fmt.Errorf("--upstream-force-h2c requires --feature-gates=%s=true", features.UpstreamH2C))
+	}
+
+	x509 := o.Auth.Authentication.X509
+	if (x509.CRLFile != "" || x509.OCSPCheck) && x509.ClientCAFile == "" {
+		errs = append(errs, fmt.Errorf("--client-crl-file and --client-ocsp-check require --client-ca-file to be set"))
+	}
+	if (x509.IntermediatesFile != "" || len(x509.AIAHosts) > 0) && x509.ClientCAFile == "" {
+		errs = append(errs, fmt.Errorf("--client-ca-intermediates-file and --client-ca-aia-hosts require --client-ca-file to be set"))
+	}
+
+	if _, err := rbac_proxy_tls.ClientAuthType(o.TLS.ClientAuthPolicy); err != nil {
+		errs = append(errs, fmt.Errorf("invalid --tls-client-auth-policy: %w", err))
+	}
+	if o.TLS.ClientAuthPolicy == rbac_proxy_tls.ClientAuthRequireVerify && x509.ClientCAFile == "" {
+		errs = append(errs, fmt.Errorf("--tls-client-auth-policy=%s requires --client-ca-file to be set", rbac_proxy_tls.ClientAuthRequireVerify))
+	}
+	if len(o.TLS.ClientCertRequiredEKUs) > 0 || len(o.TLS.ClientCertSignatureAlgorithms) > 0 {
+		if o.TLS.ClientAuthPolicy != rbac_proxy_tls.ClientAuthRequireVerify {
+			errs = append(errs, fmt.Errorf("--tls-client-cert-required-ekus and --tls-client-cert-signature-algorithms require --tls-client-auth-policy=%s", rbac_proxy_tls.ClientAuthRequireVerify))
+		}
+		if _, err := rbac_proxy_tls.ParseCertificatePolicy(o.TLS.ClientCertRequiredEKUs, o.TLS.ClientCertSignatureAlgorithms); err != nil {
+			errs = append(errs, fmt.Errorf("invalid client certificate policy: %w", err))
+		}
+	}
+	if o.TLS.ClientAuthPolicy == rbac_proxy_tls.ClientAuthNone && (x509.ClientCAFile != "" || len(o.TLS.SNIClientCAFiles) > 0) {
+		errs = append(errs, fmt.Errorf("--tls-client-auth-policy=%s cannot be used with --client-ca-file or --tls-sni-client-ca-file", rbac_proxy_tls.ClientAuthNone))
+	}
+	if err := o.TLS.KeyPassphrase.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("--tls-private-key-passphrase-file and --tls-private-key-passphrase-env: %w", err))
+	}
+	if o.TLS.KeyPassphrase.IsSet() && o.TLS.KeyFile == "" && len(o.TLS.SNICertKeys) == 0 {
+		errs = append(errs, fmt.Errorf("--tls-private-key-passphrase-file and --tls-private-key-passphrase-env require --tls-private-key-file or --tls-sni-cert-key to be set"))
+	}
+	if o.TLS.KeyPKCS11URI != "" {
+		if o.TLS.KeyFile != "" || o.TLS.KeyPassphrase.IsSet() {
+			errs = append(errs, fmt.Errorf("--tls-private-key-pkcs11-uri cannot be used with --tls-private-key-file or its passphrase"))
+		}
+		if o.TLS.CertFile == "" {
+			errs = append(errs, fmt.Errorf("--tls-private-key-pkcs11-uri requires --tls-cert-file to be set"))
+		}
+		if _, err := rbac_proxy_tls.ParsePKCS11URI(o.TLS.KeyPKCS11URI); err != nil {
+			errs = append(errs, fmt.Errorf("invalid --tls-private-key-pkcs11-uri: %w", err))
+		}
+	}
+	if err := o.TLS.UpstreamKeyPassphrase.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("--upstream-client-key-passphrase-file and --upstream-client-key-passphrase-env: %w", err))
+	}
+	if o.TLS.UpstreamKeyPassphrase.IsSet() && o.TLS.UpstreamClientKeyFile == "" {
+		errs = append(errs, fmt.Errorf("--upstream-client-key-passphrase-file and --upstream-client-key-passphrase-env require --upstream-client-key-file to be set"))
+	}
+
+	if o.UpstreamService != "" {
+		if namespace, name, ok := strings.Cut(o.UpstreamService, "/"); !ok || namespace == "" || name == "" {
+			errs = append(errs, fmt.Errorf("--upstream-service must be of the form namespace/name"))
+		}
+		if len(o.AdditionalUpstreams) > 0 {
+			errs = append(errs, fmt.Errorf("cannot use --upstream-service and --additional-upstreams together"))
+		}
+	}
+
+	if o.Auth.Authentication.Header.GroupsMaxValueSize < 0 {
+		errs = append(errs, fmt.Errorf("--auth-header-groups-field-max-value-size must not be negative"))
+	}
+	if o.Auth.Authentication.Header.GroupsMaxSize < 0 {
+		errs = append(errs, fmt.Errorf("--auth-header-groups-field-max-size must not be negative"))
+	}
+	switch o.Auth.Authentication.Header.GroupsOverflow {
+	case authn.GroupsOverflowReject, authn.GroupsOverflowTruncate:
+	default:
+		errs = append(errs, fmt.Errorf("unknown --auth-header-groups-field-overflow %q, must be reject or truncate", o.Auth.Authentication.Header.GroupsOverflow))
+	}
+
+	switch o.Auth.Authentication.Header.ClientFields {
+	case "", authn.ClientHeaderFieldsStrip, authn.ClientHeaderFieldsReject:
+	default:
+		errs = append(errs, fmt.Errorf("unknown --auth-header-fields-from-client %q, must be strip or reject", o.Auth.Authentication.Header.ClientFields))
+	}
+
+	if err := authn.ValidateAudiences(o.Auth.Authentication.Token.Audiences); err != nil {
+		errs = append(errs, fmt.Errorf("invalid --auth-token-audiences: %w", err))
+	}
+
+	if ldapConfig := o.Auth.Authentication.LDAP; ldapConfig.URL != "" {
+		if ldapConfig.BaseDN == "" {
+			errs = append(errs, fmt.Errorf("--ldap-group-base-dn is required for --ldap-url"))
+		}
+		if !strings.Contains(ldapConfig.GroupFilter, authn.LDAPUsernamePlaceholder) {
+			errs = append(errs, fmt.Errorf("--ldap-group-filter must contain %s", authn.LDAPUsernamePlaceholder))
+		}
+		if ldapConfig.Timeout <= 0 {
+			errs = append(errs, fmt.Errorf("--ldap-timeout must be greater than 0"))
+		}
+	}
+
+	switch o.UpstreamIPFamily {
+	case "", "ipv4", "ipv6":
+	default:
+		errs = append(errs, fmt.Errorf("unknown --upstream-ip-family %q, must be ipv4 or ipv6", o.UpstreamIPFamily))
+	}
+
+	if o.EgressProxyURL != "" {
+		if u, err := url.Parse(o.EgressProxyURL); err != nil || u.Host == "" {
+			errs = append(errs, fmt.Errorf("--egress-proxy-url must be an absolute URL"))
+		}
+	}
+	if o.EgressNoProxy != "" && o.EgressProxyURL == "" {
+		errs = append(errs, fmt.Errorf("--egress-no-proxy requires --egress-proxy-url to be set"))
+	}
+
+	if o.UpstreamLocalAddress != "" && net.ParseIP(o.UpstreamLocalAddress) == nil {
+		errs = append(errs, fmt.Errorf("--upstream-local-address must be an IP address"))
+	}
+
+	if o.CompressionLevel != 0 && (o.CompressionLevel < gzip.BestSpeed || o.CompressionLevel > gzip.BestCompression) {
+		errs = append(errs, fmt.Errorf("--compression-level must be between %d and %d", gzip.BestSpeed, gzip.BestCompression))
+	}
+
+	switch o.UpstreamAffinity {
+	case proxy.AffinityNone, proxy.AffinityCookie, proxy.AffinityUser:
+	default:
+		errs = append(errs, fmt.Errorf("unknown --upstream-affinity %q", o.UpstreamAffinity))
+	}
+
+	if o.MaxHeaderBytes <= 0 {
+		errs = append(errs, fmt.Errorf("--max-header-bytes must be greater than 0"))
+	}
+
+	if o.PriorityAndFairness && (o.QPS != 0 || o.Burst != 0) {
+		errs = append(errs, fmt.Errorf("cannot use --kube-api-priority-and-fairness together with --kube-api-qps or --kube-api-burst"))
+	}
+
+	if err := o.Faults.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("invalid fault injection: %w", err))
+	}
+	if o.Faults.Enabled() && !features.DefaultFeatureGate.Enabled(features.FaultInjection) {
+		errs = append(errs, fmt.Errorf("the --fault-* flags require --feature-gates=%s=true", features.FaultInjection))
+	}
+
+	switch o.DecisionExport.Sink {
+	case "":
+	case audit.SinkHTTP, audit.SinkSyslog:
+		if o.DecisionExport.Address == "" {
+			errs = append(errs, fmt.Errorf("--decision-export-address is required for --decision-export-sink=%s", o.DecisionExport.Sink))
+		}
+		if o.DecisionExport.BufferSize <= 0 {
+			errs = append(errs, fmt.Errorf("--decision-export-buffer-size must be greater than 0"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown --decision-export-sink %q", o.DecisionExport.Sink))
+	}
+
 	// Removed upstream flags shouldn't be use
 	if err := o.validateDisabledFlags(); err != nil {
 		errs = append(errs, err)
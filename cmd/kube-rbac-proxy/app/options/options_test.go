@@ -0,0 +1,103 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/component-base/featuregate"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/features"
+)
+
+func TestValidateFeatureGates(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		feature featuregate.Feature
+		set     func(o *ProxyRunOptions)
+		want    string
+	}{
+		{
+			name:    "local RBAC authorizer",
+			feature: features.LocalRBACAuthorizer,
+			set:     func(o *ProxyRunOptions) { o.AuthorizationLocalRBAC = true },
+			want:    "--authorization-local-rbac requires",
+		},
+		{
+			name:    "h2c upstream",
+			feature: features.UpstreamH2C,
+			set:     func(o *ProxyRunOptions) { o.UpstreamForceH2C = true },
+			want:    "--upstream-force-h2c requires",
+		},
+		{
+			name:    "fault injection",
+			feature: features.FaultInjection,
+			set:     func(o *ProxyRunOptions) { o.Faults.UpstreamErrorRate = 0.5 },
+			want:    "the --fault-* flags require",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o := NewProxyRunOptions()
+			o.Flags()
+			tc.set(o)
+
+			// Gates enabled by default, e.g. UpstreamH2C, can be disabled.
+			enabled := features.DefaultFeatureGate.Enabled(tc.feature)
+			if err := features.DefaultMutableFeatureGate.SetFromMap(map[string]bool{string(tc.feature): false}); err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() {
+				_ = features.DefaultMutableFeatureGate.SetFromMap(map[string]bool{string(tc.feature): enabled})
+			})
+
+			if err := o.Validate(); err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("want the feature gate required, got %v", err)
+			}
+
+			if err := features.DefaultMutableFeatureGate.SetFromMap(map[string]bool{string(tc.feature): true}); err != nil {
+				t.Fatal(err)
+			}
+			if err := o.Validate(); err != nil && strings.Contains(err.Error(), tc.want) {
+				t.Errorf("want the flag allowed with the feature gate, got %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateAuthChallengeRealm(t *testing.T) {
+	for realm, valid := range map[string]bool{
+		"":                true,
+		"kube-rbac-proxy": true,
+		"metrics of foo":  true,
+		`a"b`:             false,
+		`a\b`:             false,
+		"a\nb":            false,
+	} {
+		t.Run(realm, func(t *testing.T) {
+			o := NewProxyRunOptions()
+			o.Flags()
+			o.AuthChallengeRealm = realm
+
+			err := o.Validate()
+			invalid := err != nil && strings.Contains(err.Error(), "--auth-challenge-realm")
+			if invalid == valid {
+				t.Errorf("want realm %q valid %t, got %v", realm, valid, err)
+			}
+		})
+	}
+}
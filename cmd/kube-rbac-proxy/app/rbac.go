@@ -0,0 +1,293 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/authz"
+	"github.com/brancz/kube-rbac-proxy/pkg/proxy"
+)
+
+type rbacOptions struct {
+	name       string
+	configFile string
+	verbs      []string
+	values     []string
+	paths      []string
+}
+
+func newGenRBACCommand() *cobra.Command {
+	o := &rbacOptions{
+		name:  "kube-rbac-proxy-client",
+		verbs: []string{"get"},
+		paths: []string{"/metrics"},
+	}
+
+	cmd := &cobra.Command{
+		Use:   "gen-rbac",
+		Short: "Generate the RBAC roles clients of the kube-rbac-proxy need",
+		Long: `Generate the RBAC roles clients of the kube-rbac-proxy need to be authorized.
+
+The rules are derived from the resourceAttributes of the config file, and
+from the methodResourceAttributes with the verb of their method. Without any
+resource attributes, a ClusterRole for the non-resource URLs of --paths is
+generated. A fixed namespace results in a Role, a namespace taken from the
+pod or a request header results in a ClusterRole to be bound per namespace.
+
+If the attributes are rewritten, the {{ .Value }} templates are expanded for
+each --value. Without values, templated names are left out and templated
+namespaces result in a ClusterRole, which grants more than necessary.
+Templated API groups and resources must be expandable with --value.`,
+		SilenceUsage: true,
+		Args:         cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			b, err := o.generate()
+			if err != nil {
+				return err
+			}
+			_, err = cmd.OutOrStdout().Write(b)
+			return err
+		},
+	}
+
+	fs := cmd.Flags()
+	fs.StringVar(&o.name, "name", o.name, "The name of the generated roles.")
+	fs.StringVar(&o.configFile, "config-file", o.configFile, "The config file of the proxy.")
+	fs.StringSliceVar(&o.verbs, "verbs", o.verbs, "The verbs clients need, see methodVerbs for how they map to HTTP methods.")
+	fs.StringSliceVar(&o.values, "value", o.values, "The values clients rewrite the attributes with, may be repeated.")
+	fs.StringSliceVar(&o.paths, "paths", o.paths, "The non-resource URLs clients request, if no resourceAttributes are configured.")
+
+	return cmd
+}
+
+func (o *rbacOptions) generate() ([]byte, error) {
+	if o.name == "" {
+		return nil, errors.New("--name is required")
+	}
+	if o.configFile == "" {
+		return nil, errors.New("--config-file is required")
+	}
+	if len(o.verbs) == 0 {
+		return nil, errors.New("--verbs must not be empty")
+	}
+
+	configFile, err := parseConfigFile(o.configFile)
+	if err != nil {
+		return nil, err
+	}
+	authzConfig := configFile.AuthorizationConfig
+	if authzConfig == nil {
+		authzConfig = &authz.Config{}
+	}
+	for _, resourceAttributes := range authzConfig.AllResourceAttributes() {
+		if err := resourceAttributes.ExpandEnv(); err != nil {
+			return nil, err
+		}
+	}
+
+	roles, err := o.roles(authzConfig)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	for i, obj := range roles {
+		b, err := yaml.Marshal(obj)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+		buf.Write(b)
+	}
+	return buf.Bytes(), nil
+}
+
+// roles returns a Role per namespace the rules apply to, and a ClusterRole
+// for the rules that aren't bound to a namespace. Unknown namespaces and names
+// widen the rules, unknown API groups and resources are an error.
+func (o *rbacOptions) roles(cfg *authz.Config) ([]interface{}, error) {
+	// The attributes of a method are authorized with the verb of the
+	// method, the others with the verbs of the flag.
+	type source struct {
+		attrs *authz.ResourceAttributes
+		verbs []string
+	}
+	var sources []source
+	for _, attrs := range cfg.ResourceAttributes {
+		if attrs != nil {
+			sources = append(sources, source{attrs: attrs, verbs: o.verbs})
+		}
+	}
+	for _, method := range sets.List(sets.KeySet(cfg.MethodResourceAttributes)) {
+		for _, attrs := range cfg.MethodResourceAttributes[method] {
+			if attrs != nil {
+				verbs := []string{proxy.VerbForMethod(method, cfg.MethodVerbs)}
+				if verbs[0] == "get" && len(cfg.WatchQueryParameters) > 0 {
+					verbs = append(verbs, "watch")
+				}
+				sources = append(sources, source{attrs: attrs, verbs: verbs})
+			}
+		}
+	}
+	if len(sources) == 0 {
+		return []interface{}{o.clusterRole([]rbacv1.PolicyRule{{
+			NonResourceURLs: o.paths,
+			Verbs:           o.verbs,
+		}})}, nil
+	}
+
+	// The values only matter to rewritten attributes.
+	values := []string{""}
+	if cfg.Rewrites != nil && len(o.values) > 0 {
+		values = o.values
+	}
+
+	// Rules are grouped by namespace, resource and verbs, "" being
+	// cluster-wide.
+	type ruleKey struct{ namespace, apiGroup, resource, verbs string }
+	names := map[ruleKey]sets.Set[string]{}
+	for _, src := range sources {
+		attrs := src.attrs
+		for _, value := range values {
+			expand := func(s string) (string, bool) {
+				if !isTemplate(s) {
+					return s, true
+				}
+				if cfg.Rewrites == nil || value == "" {
+					return "", false
+				}
+				expanded, err := expandValue(s, value)
+				if err != nil {
+					klog.InfoS("Warning: template can't be expanded", "template", s, "value", value, "err", err)
+					return "", false
+				}
+				return expanded, true
+			}
+
+			namespace, _ := expand(attrs.Namespace)
+			if attrs.NamespaceFrom != nil {
+				namespace = ""
+			}
+			apiGroup, ok := expand(attrs.APIGroup)
+			if !ok {
+				return nil, fmt.Errorf("apiGroup %q can't be expanded, it must be a template over the --value of rewrites", attrs.APIGroup)
+			}
+			resource, ok := expand(attrs.Resource)
+			if !ok {
+				return nil, fmt.Errorf("resource %q can't be expanded, it must be a template over the --value of rewrites", attrs.Resource)
+			}
+			subresource, ok := expand(attrs.Subresource)
+			if !ok {
+				return nil, fmt.Errorf("subresource %q can't be expanded, it must be a template over the --value of rewrites", attrs.Subresource)
+			}
+			if subresource != "" {
+				resource += "/" + subresource
+			}
+
+			key := ruleKey{namespace: namespace, apiGroup: apiGroup, resource: resource, verbs: strings.Join(src.verbs, ",")}
+			if names[key] == nil {
+				names[key] = sets.New[string]()
+			}
+			// An unknown name must not restrict the rule to the empty name.
+			if name, ok := expand(attrs.Name); ok && name != "" {
+				names[key].Insert(name)
+			} else {
+				names[key].Insert("*")
+			}
+		}
+	}
+
+	rules := map[string][]rbacv1.PolicyRule{}
+	for key, resourceNames := range names {
+		rule := rbacv1.PolicyRule{
+			APIGroups: []string{key.apiGroup},
+			Resources: []string{key.resource},
+			Verbs:     strings.Split(key.verbs, ","),
+		}
+		if !resourceNames.Has("*") {
+			rule.ResourceNames = sets.List(resourceNames)
+		}
+		rules[key.namespace] = append(rules[key.namespace], rule)
+	}
+
+	var roles []interface{}
+	for _, namespace := range sets.List(sets.KeySet(rules)) {
+		nsRules := rules[namespace]
+		sort.Slice(nsRules, func(i, j int) bool {
+			return ruleSortKey(nsRules[i]) < ruleSortKey(nsRules[j])
+		})
+
+		if namespace == "" {
+			roles = append(roles, o.clusterRole(nsRules))
+			continue
+		}
+		roles = append(roles, &rbacv1.Role{
+			TypeMeta: metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "Role"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      o.name,
+				Namespace: namespace,
+			},
+			Rules: nsRules,
+		})
+	}
+	return roles, nil
+}
+
+func ruleSortKey(rule rbacv1.PolicyRule) string {
+	return rule.APIGroups[0] + "/" + rule.Resources[0] + "/" + strings.Join(rule.Verbs, ",")
+}
+
+func (o *rbacOptions) clusterRole(rules []rbacv1.PolicyRule) *rbacv1.ClusterRole {
+	return &rbacv1.ClusterRole{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole"},
+		ObjectMeta: metav1.ObjectMeta{Name: o.name},
+		Rules:      rules,
+	}
+}
+
+func isTemplate(s string) bool {
+	return strings.Contains(s, "{{")
+}
+
+// expandValue expands the attribute template the same way the proxy does for
+// rewritten requests.
+func expandValue(s, value string) (string, error) {
+	tmpl, err := template.New("valueTemplate").Parse(s)
+	if err != nil {
+		return "", err
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, struct{ Value string }{Value: value}); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
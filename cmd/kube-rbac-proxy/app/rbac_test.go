@@ -0,0 +1,160 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/authz"
+)
+
+func TestGenRBACRoles(t *testing.T) {
+	o := &rbacOptions{name: "client", verbs: []string{"get"}, paths: []string{"/metrics"}}
+	namespaceRewrite := &authz.SubjectAccessReviewRewrites{
+		ByQueryParameter: &authz.QueryParameterRewriteConfig{Name: "namespace"},
+	}
+	metricsRule := func(resourceNames ...string) rbacv1.PolicyRule {
+		return rbacv1.PolicyRule{
+			APIGroups:     []string{""},
+			Resources:     []string{"namespaces/metrics"},
+			ResourceNames: resourceNames,
+			Verbs:         []string{"get"},
+		}
+	}
+	role := func(namespace string, rules ...rbacv1.PolicyRule) *rbacv1.Role {
+		return &rbacv1.Role{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "Role"},
+			ObjectMeta: metav1.ObjectMeta{Name: "client", Namespace: namespace},
+			Rules:      rules,
+		}
+	}
+
+	for _, tt := range []struct {
+		name    string
+		config  *authz.Config
+		values  []string
+		want    []interface{}
+		wantErr bool
+	}{
+		{
+			name:   "non-resource",
+			config: &authz.Config{},
+			want: []interface{}{o.clusterRole([]rbacv1.PolicyRule{{
+				NonResourceURLs: []string{"/metrics"},
+				Verbs:           []string{"get"},
+			}})},
+		},
+		{
+			name: "fixed namespace",
+			config: &authz.Config{ResourceAttributes: authz.ResourceAttributesList{{
+				Namespace: "default", Resource: "namespaces", Subresource: "metrics", Name: "default",
+			}}},
+			want: []interface{}{role("default", metricsRule("default"))},
+		},
+		{
+			name: "namespace from pod",
+			config: &authz.Config{ResourceAttributes: authz.ResourceAttributesList{{
+				NamespaceFrom: &authz.NamespaceSource{Pod: true}, Resource: "namespaces", Subresource: "metrics",
+			}}},
+			want: []interface{}{o.clusterRole([]rbacv1.PolicyRule{metricsRule()})},
+		},
+		{
+			name: "method resource attributes",
+			config: &authz.Config{
+				ResourceAttributes: authz.ResourceAttributesList{{
+					Namespace: "default", Resource: "namespaces", Subresource: "metrics",
+				}},
+				MethodResourceAttributes: map[string]authz.ResourceAttributesList{
+					"POST": {{Namespace: "default", Resource: "pods", Subresource: "debug"}},
+				},
+			},
+			want: []interface{}{role("default", metricsRule(), rbacv1.PolicyRule{
+				APIGroups: []string{""},
+				Resources: []string{"pods/debug"},
+				Verbs:     []string{"create"},
+			})},
+		},
+		{
+			name: "rewritten without values",
+			config: &authz.Config{
+				Rewrites: namespaceRewrite,
+				ResourceAttributes: authz.ResourceAttributesList{{
+					Namespace: "{{ .Value }}", Resource: "namespaces", Subresource: "metrics", Name: "{{ .Value }}",
+				}},
+			},
+			want: []interface{}{o.clusterRole([]rbacv1.PolicyRule{metricsRule()})},
+		},
+		{
+			name: "rewritten with values",
+			config: &authz.Config{
+				Rewrites: namespaceRewrite,
+				ResourceAttributes: authz.ResourceAttributesList{{
+					Namespace: "{{ .Value }}", Resource: "namespaces", Subresource: "metrics", Name: "{{ .Value }}",
+				}},
+			},
+			values: []string{"b", "a"},
+			want:   []interface{}{role("a", metricsRule("a")), role("b", metricsRule("b"))},
+		},
+		{
+			name: "templates over the user",
+			config: &authz.Config{
+				Rewrites: namespaceRewrite,
+				ResourceAttributes: authz.ResourceAttributesList{{
+					Namespace: "{{ .User.Name }}", Resource: "namespaces", Subresource: "metrics", Name: "{{ .User.Name }}",
+				}},
+			},
+			values: []string{"a"},
+			want:   []interface{}{o.clusterRole([]rbacv1.PolicyRule{metricsRule()})},
+		},
+		{
+			name: "templated resource without values",
+			config: &authz.Config{
+				Rewrites: namespaceRewrite,
+				ResourceAttributes: authz.ResourceAttributesList{{
+					Namespace: "default", Resource: "{{ .Value }}",
+				}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "templated API group over the user",
+			config: &authz.Config{
+				Rewrites: namespaceRewrite,
+				ResourceAttributes: authz.ResourceAttributesList{{
+					Namespace: "default", APIGroup: "{{ .User.Name }}", Resource: "pods",
+				}},
+			},
+			values:  []string{"a"},
+			wantErr: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			o.values = tt.values
+			got, err := o.roles(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("want error %t, got %v", tt.wantErr, err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("roles mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/authz"
+	"github.com/brancz/kube-rbac-proxy/pkg/filters"
+)
+
+var (
+	reloads = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      "kube_rbac_proxy",
+			Subsystem:      "config",
+			Name:           "reloads_total",
+			Help:           "Number of reloads of the file-based inputs on SIGHUP by result, success or failure.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"result"},
+	)
+	lastReloadSuccess = metrics.NewGauge(
+		&metrics.GaugeOpts{
+			Namespace:      "kube_rbac_proxy",
+			Subsystem:      "config",
+			Name:           "last_reload_success_timestamp_seconds",
+			Help:           "Timestamp of the last successful reload of the file-based inputs.",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+)
+
+func init() {
+	legacyregistry.MustRegister(reloads, lastReloadSuccess)
+}
+
+// prepareFunc reads and validates an input, the returned function applies
+// it and must not fail.
+type prepareFunc func() (func(), error)
+
+// reloader reloads the file-based inputs all or nothing: every input is read
+// and validated first, and they are only applied if all of them are valid.
+type reloader struct {
+	mu     sync.Mutex // serializes reloads and protects inputs
+	inputs []reloadInput
+}
+
+type reloadInput struct {
+	name    string
+	prepare prepareFunc
+}
+
+func (r *reloader) add(name string, prepare prepareFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.inputs = append(r.inputs, reloadInput{name: name, prepare: prepare})
+}
+
+func (r *reloader) reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var (
+		commits []func()
+		errs    []error
+	)
+	for _, input := range r.inputs {
+		commit, err := input.prepare()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", input.name, err))
+			continue
+		}
+		commits = append(commits, commit)
+	}
+	if len(errs) > 0 {
+		reloads.WithLabelValues("failure").Inc()
+		return utilerrors.NewAggregate(errs)
+	}
+
+	for _, commit := range commits {
+		commit()
+	}
+	reloads.WithLabelValues("success").Inc()
+	lastReloadSuccess.SetToCurrentTime()
+	klog.InfoS("Reloaded the file-based inputs", "inputs", len(r.inputs))
+	return nil
+}
+
+// reloadableHandler serves with a handler, which is replaced on reloads.
+type reloadableHandler struct {
+	handler atomic.Pointer[http.HandlerFunc]
+}
+
+// handleSignals reloads the file-based inputs on SIGHUP and toggles the
+// maintenance mode on SIGUSR1, until done is closed. SIGHUP toggled the
+// maintenance mode, before it reloaded the inputs.
+func handleSignals(hup, usr1 <-chan os.Signal, done <-chan struct{}, reload *reloader, maintenance *filters.Maintenance) {
+	for {
+		select {
+		case <-hup:
+			klog.InfoS("Received SIGHUP, reloading file-based inputs")
+			if err := reload.reload(); err != nil {
+				klog.ErrorS(err, "Failed to reload, keeping the previous inputs")
+			}
+		case <-usr1:
+			if maintenance == nil {
+				klog.InfoS("Ignoring SIGUSR1, neither --maintenance-mode nor --maintenance-paths is set")
+				continue
+			}
+			klog.Warning("Received SIGUSR1, toggling maintenance mode")
+			maintenance.Toggle()
+		case <-done:
+			return
+		}
+	}
+}
+
+func newReloadableHandler(handler http.HandlerFunc) *reloadableHandler {
+	h := &reloadableHandler{}
+	h.handler.Store(&handler)
+	return h
+}
+
+func (h *reloadableHandler) store(handler http.HandlerFunc) {
+	h.handler.Store(&handler)
+}
+
+func (h *reloadableHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	(*h.handler.Load())(w, req)
+}
+
+// configFileSections returns the sections of the config file that can't be
+// reloaded, i.e. all but the authorization.
+func configFileSections(configFile *configfile) ([]byte, error) {
+	sections := *configFile
+	sections.AuthorizationConfig = nil
+	return json.Marshal(&sections)
+}
+
+// reloadAuthorization reads the config file again and returns its completed
+// authorization. Changes of other sections require a restart.
+func reloadAuthorization(configFileName string, sections []byte) (*authz.Config, error) {
+	configFile, err := parseConfigFile(configFileName)
+	if err != nil {
+		return nil, err
+	}
+
+	changed, err := configFileSections(configFile)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(changed, sections) {
+		return nil, errors.New("only the authorization of the config file can be reloaded, other changes require a restart")
+	}
+
+	// As on startup, a config file without authorization has the default.
+	if configFile.AuthorizationConfig == nil {
+		configFile.AuthorizationConfig = &authz.Config{}
+	}
+	if err := completeAuthorization(configFile.AuthorizationConfig); err != nil {
+		return nil, err
+	}
+	return configFile.AuthorizationConfig, nil
+}
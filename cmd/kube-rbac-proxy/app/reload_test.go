@@ -0,0 +1,166 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/authz"
+	"github.com/brancz/kube-rbac-proxy/pkg/filters"
+)
+
+func TestReloaderAllOrNothing(t *testing.T) {
+	var applied []string
+	valid := func(name string) prepareFunc {
+		return func() (func(), error) {
+			return func() { applied = append(applied, name) }, nil
+		}
+	}
+	invalid := func() (func(), error) {
+		return nil, errors.New("invalid")
+	}
+
+	r := &reloader{}
+	r.add("a", valid("a"))
+	r.add("b", invalid)
+	r.add("c", valid("c"))
+	if err := r.reload(); err == nil {
+		t.Fatal("want an error for an invalid input")
+	}
+	if len(applied) != 0 {
+		t.Fatalf("want no input applied if one is invalid, got %v", applied)
+	}
+
+	r = &reloader{}
+	r.add("a", valid("a"))
+	r.add("c", valid("c"))
+	if err := r.reload(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(applied) != 2 {
+		t.Fatalf("want all inputs applied, got %v", applied)
+	}
+}
+
+func TestHandleSignals(t *testing.T) {
+	var reloads int
+	r := &reloader{}
+	r.add("a", func() (func(), error) {
+		return func() { reloads++ }, nil
+	})
+	maintenance := filters.NewMaintenance(nil, time.Minute, false)
+
+	hup, usr1 := make(chan os.Signal), make(chan os.Signal)
+	done, finished := make(chan struct{}), make(chan struct{})
+	go func() {
+		handleSignals(hup, usr1, done, r, maintenance)
+		close(finished)
+	}()
+
+	hup <- syscall.SIGHUP
+	hup <- syscall.SIGHUP
+	usr1 <- syscall.SIGUSR1
+	close(done)
+	<-finished
+
+	if reloads != 2 {
+		t.Errorf("want SIGHUP to reload the inputs, got %d reloads", reloads)
+	}
+	if !maintenance.Enabled() {
+		t.Errorf("want SIGUSR1 to toggle the maintenance mode")
+	}
+
+	// Without maintenance mode, SIGUSR1 is ignored.
+	done, finished = make(chan struct{}), make(chan struct{})
+	go func() {
+		handleSignals(hup, usr1, done, r, nil)
+		close(finished)
+	}()
+	usr1 <- syscall.SIGUSR1
+	close(done)
+	<-finished
+}
+
+func TestReloadAuthorization(t *testing.T) {
+	const headers = `
+responseHeaders:
+- paths: ["/metrics"]
+  headers:
+    X-Frame-Options: DENY
+`
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(headers + `
+authorization:
+  static:
+  - path: /metrics
+    verb: get
+`)
+	configFile, err := parseConfigFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sections, err := configFileSections(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	write(headers + `
+authorization:
+  static:
+  - path: /debug
+    verb: get
+`)
+	authzConfig, err := reloadAuthorization(path, sections)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(authzConfig.Static) != 1 || authzConfig.Static[0].Path != "/debug" {
+		t.Errorf("want the reloaded static rules, got %+v", authzConfig.Static)
+	}
+
+	write(`
+authorization:
+  static:
+  - path: /debug
+    verb: get
+`)
+	if _, err := reloadAuthorization(path, sections); err == nil {
+		t.Error("want an error for changes of other sections")
+	}
+
+	write(headers)
+	authzConfig, err = reloadAuthorization(path, sections)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(authzConfig, &authz.Config{}) {
+		t.Errorf("want the default authorization of a config file without authorization, got %+v", authzConfig)
+	}
+}
@@ -0,0 +1,243 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/component-base/version"
+)
+
+const (
+	sidecarName       = "kube-rbac-proxy"
+	sidecarConfigDir  = "/etc/kube-rbac-proxy"
+	sidecarConfigFile = "config-file.yaml"
+
+	sidecarOutputPatch     = "patch"
+	sidecarOutputResources = "resources"
+)
+
+type sidecarOptions struct {
+	name           string
+	namespace      string
+	serviceAccount string
+	configFile     string
+	image          string
+	upstreamPort   int
+	securePort     int
+	output         string
+}
+
+func newGenSidecarCommand() *cobra.Command {
+	o := &sidecarOptions{
+		namespace:      "default",
+		serviceAccount: "default",
+		image:          "quay.io/brancz/kube-rbac-proxy:" + version.Get().GitVersion,
+		securePort:     8443,
+		output:         sidecarOutputPatch,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "gen-sidecar",
+		Short: "Generate the manifests to inject the kube-rbac-proxy as a sidecar",
+		Long: `Generate the manifests to inject the kube-rbac-proxy as a sidecar into a workload.
+
+With --output=patch, a strategic merge patch adding the container and its
+config volume to the pod template is printed, e.g. for
+"kubectl patch deployment <name> --patch-file". With --output=resources, the
+ConfigMap holding the config file and the RBAC objects the proxy needs are
+printed, e.g. for "kubectl apply -f -".`,
+		SilenceUsage: true,
+		Args:         cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			b, err := o.generate()
+			if err != nil {
+				return err
+			}
+			_, err = cmd.OutOrStdout().Write(b)
+			return err
+		},
+	}
+
+	fs := cmd.Flags()
+	fs.StringVar(&o.name, "name", o.name, "The name of the workload, used to name the generated objects.")
+	fs.StringVar(&o.namespace, "namespace", o.namespace, "The namespace of the workload.")
+	fs.StringVar(&o.serviceAccount, "service-account", o.serviceAccount, "The ServiceAccount the workload runs as, which is granted the permissions of the proxy.")
+	fs.StringVar(&o.configFile, "config-file", o.configFile, "The config file of the proxy, which is stored in a ConfigMap.")
+	fs.StringVar(&o.image, "image", o.image, "The image of the proxy container.")
+	fs.IntVar(&o.upstreamPort, "upstream-port", o.upstreamPort, "The port of the workload container the proxy forwards to on localhost.")
+	fs.IntVar(&o.securePort, "secure-port", o.securePort, "The port the proxy listens on.")
+	fs.StringVar(&o.output, "output", o.output, "What to generate, one of patch or resources.")
+
+	return cmd
+}
+
+func (o *sidecarOptions) validate() error {
+	var errs []error
+
+	if o.name == "" {
+		errs = append(errs, errors.New("--name is required"))
+	}
+	if o.configFile == "" {
+		errs = append(errs, errors.New("--config-file is required"))
+	}
+	if o.upstreamPort <= 0 || o.upstreamPort > 65535 {
+		errs = append(errs, fmt.Errorf("--upstream-port must be a valid port, got %d", o.upstreamPort))
+	}
+	if o.securePort <= 0 || o.securePort > 65535 {
+		errs = append(errs, fmt.Errorf("--secure-port must be a valid port, got %d", o.securePort))
+	}
+	if o.upstreamPort == o.securePort {
+		errs = append(errs, fmt.Errorf("--upstream-port and --secure-port must differ, both are %d", o.securePort))
+	}
+	if o.output != sidecarOutputPatch && o.output != sidecarOutputResources {
+		errs = append(errs, fmt.Errorf("--output must be one of %s or %s, got %q", sidecarOutputPatch, sidecarOutputResources, o.output))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (o *sidecarOptions) generate() ([]byte, error) {
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+
+	// Refuse to generate manifests for a config file the proxy won't accept.
+	if _, err := parseConfigFile(o.configFile); err != nil {
+		return nil, err
+	}
+	config, err := os.ReadFile(o.configFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	if o.output == sidecarOutputPatch {
+		return yaml.Marshal(o.patch())
+	}
+
+	var buf bytes.Buffer
+	for i, obj := range o.resources(string(config)) {
+		b, err := yaml.Marshal(obj)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+		buf.Write(b)
+	}
+	return buf.Bytes(), nil
+}
+
+func (o *sidecarOptions) configMapName() string {
+	return o.name + "-" + sidecarName
+}
+
+// patch returns a strategic merge patch for the pod template of a workload.
+// It is untyped, as the zero values of typed workloads would reset fields
+// like the selector.
+func (o *sidecarOptions) patch() map[string]interface{} {
+	container := corev1.Container{
+		Name:  sidecarName,
+		Image: o.image,
+		Args: []string{
+			"--secure-listen-address=0.0.0.0:" + strconv.Itoa(o.securePort),
+			"--upstream=http://127.0.0.1:" + strconv.Itoa(o.upstreamPort) + "/",
+			"--config-file=" + path.Join(sidecarConfigDir, sidecarConfigFile),
+		},
+		Ports: []corev1.ContainerPort{{
+			Name:          "https",
+			ContainerPort: int32(o.securePort),
+		}},
+		VolumeMounts: []corev1.VolumeMount{{
+			Name:      sidecarName + "-config",
+			MountPath: sidecarConfigDir,
+			ReadOnly:  true,
+		}},
+		SecurityContext: &corev1.SecurityContext{
+			AllowPrivilegeEscalation: new(bool),
+		},
+	}
+	volume := corev1.Volume{
+		Name: sidecarName + "-config",
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: o.configMapName()},
+			},
+		},
+	}
+
+	return map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []corev1.Container{container},
+					"volumes":    []corev1.Volume{volume},
+				},
+			},
+		},
+	}
+}
+
+// resources returns the ConfigMap of the proxy and the RBAC objects that
+// allow it to create TokenReviews and SubjectAccessReviews. The ClusterRole
+// is shared by all workloads.
+func (o *sidecarOptions) resources(config string) []interface{} {
+	return []interface{}{
+		&corev1.ConfigMap{
+			TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      o.configMapName(),
+				Namespace: o.namespace,
+			},
+			Data: map[string]string{sidecarConfigFile: config},
+		},
+		&rbacv1.ClusterRole{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole"},
+			ObjectMeta: metav1.ObjectMeta{Name: sidecarName},
+			Rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{"authentication.k8s.io"}, Resources: []string{"tokenreviews"}, Verbs: []string{"create"}},
+				{APIGroups: []string{"authorization.k8s.io"}, Resources: []string{"subjectaccessreviews"}, Verbs: []string{"create"}},
+			},
+		},
+		&rbacv1.ClusterRoleBinding{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRoleBinding"},
+			ObjectMeta: metav1.ObjectMeta{Name: sidecarName + ":" + o.namespace + ":" + o.name},
+			RoleRef: rbacv1.RoleRef{
+				APIGroup: rbacv1.GroupName,
+				Kind:     "ClusterRole",
+				Name:     sidecarName,
+			},
+			Subjects: []rbacv1.Subject{{
+				Kind:      rbacv1.ServiceAccountKind,
+				Name:      o.serviceAccount,
+				Namespace: o.namespace,
+			}},
+		},
+	}
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ghodss/yaml"
+)
+
+func TestGenSidecar(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config-file.yaml")
+	if err := os.WriteFile(configFile, []byte("authorization:\n  static:\n  - path: /metrics\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	o := &sidecarOptions{
+		name:           "app",
+		namespace:      "monitoring",
+		serviceAccount: "app",
+		configFile:     configFile,
+		image:          "quay.io/brancz/kube-rbac-proxy:v0.18.0",
+		upstreamPort:   8081,
+		securePort:     8443,
+		output:         sidecarOutputPatch,
+	}
+
+	b, err := o.generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var patch struct {
+		Spec struct {
+			Template struct {
+				Spec struct {
+					Containers []struct {
+						Args []string `json:"args"`
+					} `json:"containers"`
+					Volumes []struct {
+						ConfigMap struct {
+							Name string `json:"name"`
+						} `json:"configMap"`
+					} `json:"volumes"`
+				} `json:"spec"`
+			} `json:"template"`
+		} `json:"spec"`
+	}
+	if err := yaml.Unmarshal(b, &patch); err != nil {
+		t.Fatal(err)
+	}
+	podSpec := patch.Spec.Template.Spec
+	if len(podSpec.Containers) != 1 || !strings.Contains(strings.Join(podSpec.Containers[0].Args, " "), "--upstream=http://127.0.0.1:8081/") {
+		t.Errorf("want a container proxying to port 8081, got %s", b)
+	}
+	if len(podSpec.Volumes) != 1 || podSpec.Volumes[0].ConfigMap.Name != "app-kube-rbac-proxy" {
+		t.Errorf("want the config volume, got %s", b)
+	}
+
+	o.output = sidecarOutputResources
+	b, err = o.generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs := strings.Split(string(b), "---\n")
+	if len(docs) != 3 {
+		t.Fatalf("want a ConfigMap, a ClusterRole and a ClusterRoleBinding, got %s", b)
+	}
+	if !strings.Contains(docs[0], "path: /metrics") {
+		t.Errorf("want the config file in the ConfigMap, got %s", docs[0])
+	}
+	if !strings.Contains(docs[2], "name: kube-rbac-proxy:monitoring:app") {
+		t.Errorf("want a binding for the workload, got %s", docs[2])
+	}
+}
+
+func TestGenSidecarValidation(t *testing.T) {
+	for _, o := range []*sidecarOptions{
+		{configFile: "config.yaml", upstreamPort: 8081, securePort: 8443, output: sidecarOutputPatch},
+		{name: "app", upstreamPort: 8081, securePort: 8443, output: sidecarOutputPatch},
+		{name: "app", configFile: "config.yaml", securePort: 8443, output: sidecarOutputPatch},
+		{name: "app", configFile: "config.yaml", upstreamPort: 8443, securePort: 8443, output: sidecarOutputPatch},
+		{name: "app", configFile: "config.yaml", upstreamPort: 8081, securePort: 8443, output: "json"},
+	} {
+		if err := o.validate(); err == nil {
+			t.Errorf("want error for %+v", o)
+		}
+	}
+}
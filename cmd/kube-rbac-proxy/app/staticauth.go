@@ -0,0 +1,169 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/authz"
+	"github.com/brancz/kube-rbac-proxy/pkg/proxy"
+)
+
+// openAPIMethods are the operations of an OpenAPI path item, the other fields
+// of a path item are ignored.
+var openAPIMethods = sets.New("get", "put", "post", "delete", "options", "head", "patch", "trace")
+
+type staticAuthOptions struct {
+	specFile   string
+	configFile string
+	user       string
+}
+
+// openAPISpec holds the fields of an OpenAPI 3 or Swagger 2 document that
+// the static rules are derived from.
+type openAPISpec struct {
+	BasePath string                                `json:"basePath,omitempty"`
+	Paths    map[string]map[string]json.RawMessage `json:"paths"`
+}
+
+func newGenStaticAuthCommand() *cobra.Command {
+	o := &staticAuthOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "gen-static-auth",
+		Short: "Generate a static authorization config from an OpenAPI document",
+		Long: `Generate a static authorization config skeleton from the OpenAPI or Swagger
+document of the upstream.
+
+A rule is generated for each verb:path of the operations of the document. The
+verb is derived from the method of the operation, with the methodVerbs of
+--config-file if given. The basePath of Swagger documents is prepended to the
+paths.
+
+Static rules match paths exactly, paths with templates such as /pets/{id}
+have to be edited before the config is used.`,
+		SilenceUsage: true,
+		Args:         cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			b, err := o.generate()
+			if err != nil {
+				return err
+			}
+			_, err = cmd.OutOrStdout().Write(b)
+			return err
+		},
+	}
+
+	fs := cmd.Flags()
+	fs.StringVar(&o.specFile, "spec-file", o.specFile, "The OpenAPI or Swagger document of the upstream, in JSON or YAML.")
+	fs.StringVar(&o.configFile, "config-file", o.configFile, "The config file of the proxy, for its methodVerbs.")
+	fs.StringVar(&o.user, "user", o.user, "The user the generated rules allow.")
+
+	return cmd
+}
+
+func (o *staticAuthOptions) generate() ([]byte, error) {
+	if o.specFile == "" {
+		return nil, errors.New("--spec-file is required")
+	}
+	// A rule without a user allows any authenticated user.
+	if o.user == "" {
+		return nil, errors.New("--user is required")
+	}
+
+	var methodVerbs map[string]string
+	if o.configFile != "" {
+		configFile, err := parseConfigFile(o.configFile)
+		if err != nil {
+			return nil, err
+		}
+		if configFile.AuthorizationConfig != nil {
+			methodVerbs = configFile.AuthorizationConfig.MethodVerbs
+		}
+	}
+
+	b, err := os.ReadFile(o.specFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spec file: %w", err)
+	}
+	spec := openAPISpec{}
+	if err := yaml.Unmarshal(b, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse spec file content: %w", err)
+	}
+
+	rules, err := o.rules(&spec, methodVerbs)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(configfile{AuthorizationConfig: &authz.Config{Static: rules}})
+}
+
+// rules returns a rule per distinct verb and path of the operations of the
+// spec, sorted by path and verb.
+func (o *staticAuthOptions) rules(spec *openAPISpec, methodVerbs map[string]string) ([]authz.StaticAuthorizationConfig, error) {
+	if len(spec.Paths) == 0 {
+		return nil, errors.New("spec file has no paths")
+	}
+
+	seen := sets.New[string]()
+	var rules []authz.StaticAuthorizationConfig
+	for p, item := range spec.Paths {
+		if !strings.HasPrefix(p, "/") {
+			return nil, fmt.Errorf("path %q must start with a slash", p)
+		}
+		// Trailing slashes are kept, they are significant for static rules.
+		p = strings.TrimSuffix(spec.BasePath, "/") + p
+		if strings.Contains(p, "{") {
+			klog.Warningf("Path %q is templated, its rules must be edited to match requests", p)
+		}
+
+		for method := range item {
+			if !openAPIMethods.Has(method) {
+				continue
+			}
+			verb := proxy.VerbForMethod(strings.ToUpper(method), methodVerbs)
+			// Methods mapping to the same verb, e.g. HEAD and OPTIONS, share a rule.
+			if key := verb + ":" + p; !seen.Has(key) {
+				seen.Insert(key)
+				rules = append(rules, authz.StaticAuthorizationConfig{
+					User: authz.UserConfig{Name: o.user},
+					Verb: verb,
+					Path: p,
+				})
+			}
+		}
+	}
+
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].Path != rules[j].Path {
+			return rules[i].Path < rules[j].Path
+		}
+		return rules[i].Verb < rules[j].Verb
+	})
+	return rules, nil
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/authz"
+)
+
+func TestGenStaticAuthRules(t *testing.T) {
+	o := &staticAuthOptions{user: "client"}
+	rule := func(verb, path string) authz.StaticAuthorizationConfig {
+		return authz.StaticAuthorizationConfig{User: authz.UserConfig{Name: "client"}, Verb: verb, Path: path}
+	}
+
+	for _, tt := range []struct {
+		name        string
+		spec        string
+		methodVerbs map[string]string
+		want        []authz.StaticAuthorizationConfig
+		wantErr     bool
+	}{
+		{
+			name: "openapi",
+			spec: `
+openapi: 3.0.0
+paths:
+  /pets:
+    summary: Pets
+    get: {}
+    head: {}
+    options: {}
+    post: {}
+  /pets/{id}/:
+    parameters: []
+    delete: {}
+`,
+			want: []authz.StaticAuthorizationConfig{
+				rule("*", "/pets"),
+				rule("create", "/pets"),
+				rule("get", "/pets"),
+				rule("delete", "/pets/{id}/"),
+			},
+		},
+		{
+			name: "swagger base path",
+			spec: `{"swagger": "2.0", "basePath": "/api/", "paths": {"/query": {"post": {}}}}`,
+			want: []authz.StaticAuthorizationConfig{rule("create", "/api/query")},
+		},
+		{
+			name:        "method verbs",
+			spec:        `{"paths": {"/query": {"post": {}}}}`,
+			methodVerbs: map[string]string{"POST": "get"},
+			want:        []authz.StaticAuthorizationConfig{rule("get", "/query")},
+		},
+		{
+			name:    "no paths",
+			spec:    `{"openapi": "3.0.0"}`,
+			wantErr: true,
+		},
+		{
+			name:    "relative path",
+			spec:    `{"paths": {"pets": {"get": {}}}}`,
+			wantErr: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := openAPISpec{}
+			if err := yaml.Unmarshal([]byte(tt.spec), &spec); err != nil {
+				t.Fatal(err)
+			}
+			got, err := o.rules(&spec, tt.methodVerbs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unexpected rules (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -17,23 +17,167 @@ limitations under the License.
 package app
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
+	"net/url"
+	"os"
+	"sync/atomic"
 	"time"
+
+	"golang.org/x/net/http/httpproxy"
+	"golang.org/x/net/http2"
+
+	rbac_proxy_tls "github.com/brancz/kube-rbac-proxy/pkg/tls"
 )
 
-func initTransport(upstreamCAPool *x509.CertPool, upstreamClientCertPath, upstreamClientKeyPath string) (http.RoundTripper, error) {
+type dialContextFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// upstreamDialOptions customize how connections to the upstream are dialed.
+type upstreamDialOptions struct {
+	// DNSServer is the address of the DNS server resolving the upstream.
+	DNSServer string
+	// IPFamily restricts connections to "ipv4" or "ipv6".
+	IPFamily string
+	// LocalAddress is the local IP connections originate from.
+	LocalAddress string
+	// KeepAlive is the period of TCP keep-alive probes, zero keeps the
+	// default of 30s and a negative value disables them.
+	KeepAlive time.Duration
+}
+
+// newUpstreamDialer returns a dial function honoring the options, or nil if
+// no option is set.
+func newUpstreamDialer(opts upstreamDialOptions) (dialContextFunc, error) {
+	if opts == (upstreamDialOptions{}) {
+		return nil, nil
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	if opts.KeepAlive != 0 {
+		dialer.KeepAlive = opts.KeepAlive
+	}
+
+	if opts.LocalAddress != "" {
+		ip := net.ParseIP(opts.LocalAddress)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid local address %q", opts.LocalAddress)
+		}
+		dialer.LocalAddr = &net.TCPAddr{IP: ip}
+	}
+
+	if opts.DNSServer != "" {
+		dnsServer := opts.DNSServer
+		if _, _, err := net.SplitHostPort(dnsServer); err != nil {
+			dnsServer = net.JoinHostPort(dnsServer, "53")
+		}
+		dnsDialer := &net.Dialer{Timeout: 5 * time.Second}
+		dialer.Resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				return dnsDialer.DialContext(ctx, network, dnsServer)
+			},
+		}
+	}
+
+	var family string
+	switch opts.IPFamily {
+	case "":
+	case "ipv4":
+		family = "4"
+	case "ipv6":
+		family = "6"
+	default:
+		return nil, fmt.Errorf("unknown IP family %q", opts.IPFamily)
+	}
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if family != "" && (network == "tcp" || network == "udp") {
+			network += family
+		}
+		return dialer.DialContext(ctx, network, addr)
+	}, nil
+}
+
+// withHTTP2Pings configures HTTP/2 connections of the transport to be
+// pinged, once no frame was read from them for the interval. Connections
+// not answering the ping within the timeout are closed, failing their
+// streams instead of leaving long-running requests hanging on half-open
+// connections. HTTP/1 connections are left to TCP keep-alive.
+func withHTTP2Pings(rt http.RoundTripper, interval, timeout time.Duration) (http.RoundTripper, error) {
+	t, ok := rt.(*http.Transport)
+	if !ok {
+		return nil, fmt.Errorf("unexpected transport %T", rt)
+	}
+	// The default transport is shared and might have registered its
+	// bundled HTTP/2 implementation already.
+	t = t.Clone()
+	t.TLSNextProto = nil
+
+	h2, err := http2.ConfigureTransports(t)
+	if err != nil {
+		return nil, err
+	}
+	h2.ReadIdleTimeout = interval
+	h2.PingTimeout = timeout
+
+	return t, nil
+}
+
+// withExpectContinueTimeout sets the time to wait for the 100 Continue of the
+// upstream, before bodies of requests expecting it are sent anyway.
+func withExpectContinueTimeout(rt http.RoundTripper, timeout time.Duration) (http.RoundTripper, error) {
+	t, ok := rt.(*http.Transport)
+	if !ok {
+		return nil, fmt.Errorf("unexpected transport %T", rt)
+	}
+	// The default transport is shared.
+	t = t.Clone()
+	t.ExpectContinueTimeout = timeout
+	return t, nil
+}
+
+// newEgressProxy returns the proxy function for requests leaving the
+// cluster, or nil if no proxy is set, in which case the HTTP_PROXY,
+// HTTPS_PROXY and NO_PROXY environment variables are honored.
+func newEgressProxy(proxyURL, noProxy string) func(*http.Request) (*url.URL, error) {
+	if proxyURL == "" {
+		return nil
+	}
+
+	proxyFunc := (&httpproxy.Config{
+		HTTPProxy:  proxyURL,
+		HTTPSProxy: proxyURL,
+		NoProxy:    noProxy,
+	}).ProxyFunc()
+
+	return func(req *http.Request) (*url.URL, error) {
+		return proxyFunc(req.URL)
+	}
+}
+
+func initTransport(upstreamCAPool *x509.CertPool, upstreamClientCertPath, upstreamClientKeyPath string, upstreamClientKeyPassphrase rbac_proxy_tls.Passphrase, dialContext dialContextFunc) (http.RoundTripper, error) {
 	if upstreamCAPool == nil {
-		return http.DefaultTransport, nil
+		if dialContext == nil {
+			return http.DefaultTransport, nil
+		}
+
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.DialContext = dialContext
+		return transport, nil
 	}
 
 	var certKeyPair tls.Certificate
 	if len(upstreamClientCertPath) > 0 {
 		var err error
-		certKeyPair, err = tls.LoadX509KeyPair(upstreamClientCertPath, upstreamClientKeyPath)
+		certKeyPair, err = rbac_proxy_tls.LoadKeyPair(upstreamClientCertPath, upstreamClientKeyPath, upstreamClientKeyPassphrase)
 		if err != nil {
 			return nil, fmt.Errorf("failed to read upstream client cert/key: %w", err)
 		}
@@ -60,5 +204,57 @@ func initTransport(upstreamCAPool *x509.CertPool, upstreamClientCertPath, upstre
 		transport.TLSClientConfig.Certificates = []tls.Certificate{certKeyPair}
 	}
 
+	if dialContext != nil {
+		transport.DialContext = dialContext
+	}
+
 	return transport, nil
 }
+
+// loadUpstreamCA reads the CA certificates of the upstream.
+func loadUpstreamCA(path string) (*x509.CertPool, []byte, error) {
+	upstreamCAPEM, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	upstreamCACertPool := x509.NewCertPool()
+	if ok := upstreamCACertPool.AppendCertsFromPEM(upstreamCAPEM); !ok {
+		return nil, nil, errors.New("error parsing upstream CA certificate")
+	}
+	return upstreamCACertPool, upstreamCAPEM, nil
+}
+
+// reloadableTransport delegates to the upstream transport, which is
+// replaced when the upstream CA file is reloaded. Requests in flight finish
+// on the previous transport.
+type reloadableTransport struct {
+	transport atomic.Pointer[http.Transport]
+}
+
+func newReloadableTransport(rt http.RoundTripper) (*reloadableTransport, error) {
+	r := &reloadableTransport{}
+	if err := r.store(rt); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *reloadableTransport) store(rt http.RoundTripper) error {
+	t, ok := rt.(*http.Transport)
+	if !ok {
+		return fmt.Errorf("unexpected transport %T", rt)
+	}
+	if previous := r.transport.Swap(t); previous != nil {
+		previous.CloseIdleConnections()
+	}
+	return nil
+}
+
+func (r *reloadableTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return r.transport.Load().RoundTrip(req)
+}
+
+func (r *reloadableTransport) CloseIdleConnections() {
+	r.transport.Load().CloseIdleConnections()
+}
@@ -16,6 +16,7 @@ limitations under the License.
 package app
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/tls"
@@ -27,6 +28,7 @@ import (
 	"math/big"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"net/http/httputil"
 	"os"
 	"path/filepath"
@@ -35,10 +37,12 @@ import (
 
 	certutil "k8s.io/client-go/util/cert"
 	"k8s.io/client-go/util/keyutil"
+
+	rbac_proxy_tls "github.com/brancz/kube-rbac-proxy/pkg/tls"
 )
 
 func TestInitTransportWithDefault(t *testing.T) {
-	roundTripper, err := initTransport(nil, "", "")
+	roundTripper, err := initTransport(nil, "", "", rbac_proxy_tls.Passphrase{}, nil)
 	if err != nil {
 		t.Errorf("want err to be nil, but got %v", err)
 		return
@@ -57,7 +61,7 @@ func TestInitTransportWithCustomCA(t *testing.T) {
 	upstreamCAPool := x509.NewCertPool()
 	upstreamCAPool.AppendCertsFromPEM(upstreamCAPEM)
 
-	roundTripper, err := initTransport(upstreamCAPool, "", "")
+	roundTripper, err := initTransport(upstreamCAPool, "", "", rbac_proxy_tls.Passphrase{}, nil)
 	if err != nil {
 		t.Fatalf("want err to be nil, but got %v", err)
 	}
@@ -131,7 +135,7 @@ func TestInitTransportWithClientCertAuth(t *testing.T) {
 
 	serverCA := x509.NewCertPool()
 	serverCA.AppendCertsFromPEM(cert)
-	roundTripper, err := initTransport(serverCA, clientCertPath, clientKeyPath)
+	roundTripper, err := initTransport(serverCA, clientCertPath, clientKeyPath, rbac_proxy_tls.Passphrase{}, nil)
 	if err != nil {
 		t.Errorf("want err to be nil, but got %v", err)
 		return
@@ -202,3 +206,133 @@ func generateClientCert(t *testing.T) ([]byte, []byte, *x509.CertPool, error) {
 
 	return certPEM, privKeyPEM, caPool, nil
 }
+
+func TestNewUpstreamDialer(t *testing.T) {
+	if dial, err := newUpstreamDialer(upstreamDialOptions{}); err != nil || dial != nil {
+		t.Errorf("want no dialer without options, got %v", err)
+	}
+
+	for _, opts := range []upstreamDialOptions{
+		{IPFamily: "ipx"},
+		{LocalAddress: "localhost"},
+	} {
+		if _, err := newUpstreamDialer(opts); err == nil {
+			t.Errorf("want error for %+v", opts)
+		}
+	}
+
+	l, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	dial, err := newUpstreamDialer(upstreamDialOptions{IPFamily: "ipv4", LocalAddress: "127.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := dial(context.Background(), "tcp", l.Addr().String())
+	if err != nil {
+		t.Fatalf("want ipv4 connection, got %v", err)
+	}
+	if got := conn.LocalAddr().(*net.TCPAddr).IP.String(); got != "127.0.0.1" {
+		t.Errorf("want local address 127.0.0.1, got %s", got)
+	}
+	conn.Close()
+
+	if dial, err := newUpstreamDialer(upstreamDialOptions{KeepAlive: -1}); err != nil || dial == nil {
+		t.Errorf("want dialer without keep-alive, got %v", err)
+	}
+
+	dial, err = newUpstreamDialer(upstreamDialOptions{IPFamily: "ipv6"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conn, err := dial(context.Background(), "tcp", l.Addr().String()); err == nil {
+		conn.Close()
+		t.Error("want ipv6 dialer to refuse an ipv4 address")
+	}
+}
+
+func TestWithHTTP2Pings(t *testing.T) {
+	if _, err := withHTTP2Pings(http.DefaultTransport, time.Second, time.Second); err != nil {
+		t.Fatalf("want default transport to be configured, got %v", err)
+	}
+
+	upstream := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	upstream.EnableHTTP2 = true
+	upstream.StartTLS()
+	defer upstream.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(upstream.Certificate())
+	transport, err := initTransport(pool, "", "", rbac_proxy_tls.Passphrase{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport, err = withHTTP2Pings(transport, time.Second, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := (&http.Client{Transport: transport}).Get(upstream.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.ProtoMajor != 2 {
+		t.Errorf("want HTTP/2, got %s", resp.Proto)
+	}
+}
+
+func TestNewEgressProxy(t *testing.T) {
+	if proxy := newEgressProxy("", ""); proxy != nil {
+		t.Error("want no proxy function without a proxy URL")
+	}
+
+	proxy := newEgressProxy("http://proxy.internal:3128", "10.0.0.0/8,.cluster.local")
+	for target, want := range map[string]string{
+		"https://login.example.com/.well-known/openid-configuration": "http://proxy.internal:3128",
+		"https://dex.auth.svc.cluster.local/keys":                    "",
+		"https://10.1.2.3/keys":                                      "",
+	} {
+		req, err := http.NewRequest(http.MethodGet, target, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := proxy(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var gotURL string
+		if got != nil {
+			gotURL = got.String()
+		}
+		if gotURL != want {
+			t.Errorf("want proxy %q for %s, got %q", want, target, gotURL)
+		}
+	}
+}
+
+func TestWithExpectContinueTimeout(t *testing.T) {
+	transport, err := withExpectContinueTimeout(http.DefaultTransport, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := transport.(*http.Transport).ExpectContinueTimeout; got != 5*time.Second {
+		t.Errorf("want timeout of 5s, got %s", got)
+	}
+	if http.DefaultTransport.(*http.Transport).ExpectContinueTimeout == 5*time.Second {
+		t.Error("want the default transport to be untouched")
+	}
+}
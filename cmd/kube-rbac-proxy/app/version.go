@@ -0,0 +1,144 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	apimachineryversion "k8s.io/apimachinery/pkg/version"
+	"k8s.io/component-base/version"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/authn"
+	"github.com/brancz/kube-rbac-proxy/pkg/authz"
+	"github.com/brancz/kube-rbac-proxy/pkg/features"
+	"github.com/brancz/kube-rbac-proxy/pkg/proxy"
+)
+
+// effectiveConfig is the policy relevant part of the configuration after
+// defaulting and reading the config file.
+type effectiveConfig struct {
+	Upstream            string                       `json:"upstream"`
+	AdditionalUpstreams []string                     `json:"additionalUpstreams,omitempty"`
+	UpstreamService     string                       `json:"upstreamService,omitempty"`
+	Canaries            []proxy.CanaryConfig         `json:"canaries,omitempty"`
+	UpstreamRouting     *proxy.UpstreamRoutingConfig `json:"upstreamRouting,omitempty"`
+	AllowPaths          []string                     `json:"allowPaths,omitempty"`
+	IgnorePaths         []string                     `json:"ignorePaths,omitempty"`
+	Authentication      *authn.AuthnConfig           `json:"authentication,omitempty"`
+	Authorization       *authz.Config                `json:"authorization,omitempty"`
+	Listeners           []listenerConfig             `json:"listeners,omitempty"`
+	// ProxyEndpoints is omitted, if the proxy endpoints aren't authorized,
+	// keeping the hash of such configurations.
+	ProxyEndpoints *proxyEndpointsConfig `json:"proxyEndpoints,omitempty"`
+}
+
+func (cfg *completedProxyRunOptions) effectiveConfig() *effectiveConfig {
+	var additionalUpstreams []string
+	for _, u := range cfg.additionalUpstreamURLs {
+		additionalUpstreams = append(additionalUpstreams, u.String())
+	}
+	var allowPaths []string
+	for _, p := range cfg.allowPaths {
+		allowPaths = append(allowPaths, p.String())
+	}
+
+	return &effectiveConfig{
+		Upstream:            cfg.upstreamURL.String(),
+		AdditionalUpstreams: additionalUpstreams,
+		UpstreamService:     cfg.upstreamService,
+		Canaries:            cfg.canaries,
+		UpstreamRouting:     cfg.upstreamRouting,
+		AllowPaths:          allowPaths,
+		IgnorePaths:         cfg.ignorePaths,
+		Authentication:      cfg.auth.Authentication,
+		Authorization:       cfg.auth.Authorization,
+		Listeners:           cfg.listeners,
+		ProxyEndpoints:      cfg.proxyEndpoints,
+	}
+}
+
+// configHash returns the SHA-256 of the effective configuration, which
+// allows to tell apart proxies running different policies.
+func configHash(cfg *effectiveConfig) (string, error) {
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal effective config: %w", err)
+	}
+
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// dumpEffectiveConfig writes the effective configuration as YAML, prefixed
+// by a comment with its hash. Its authorization and listeners have the
+// format of the config file.
+func dumpEffectiveConfig(w io.Writer, cfg *effectiveConfig) error {
+	hash, err := configHash(cfg)
+	if err != nil {
+		return err
+	}
+
+	b, err := yaml.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal effective config: %w", err)
+	}
+
+	_, err = fmt.Fprintf(w, "# configHash: %s\n%s", hash, b)
+	return err
+}
+
+type versionInfo struct {
+	apimachineryversion.Info
+	ConfigHash   string          `json:"configHash"`
+	FeatureGates map[string]bool `json:"featureGates"`
+}
+
+func newVersionInfo(configHash string) *versionInfo {
+	return &versionInfo{
+		Info:         version.Get(),
+		ConfigHash:   configHash,
+		FeatureGates: features.Enabled(),
+	}
+}
+
+func (v *versionInfo) String() string {
+	var enabled []string
+	for feature, ok := range v.FeatureGates {
+		if ok {
+			enabled = append(enabled, feature)
+		}
+	}
+	sort.Strings(enabled)
+
+	return fmt.Sprintf("version=%s commit=%s go=%s platform=%s configHash=%s featureGates=%s",
+		v.GitVersion, v.GitCommit, v.GoVersion, v.Platform, v.ConfigHash, strings.Join(enabled, ","))
+}
+
+func versionHandler(v *versionInfo) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(v)
+	}
+}
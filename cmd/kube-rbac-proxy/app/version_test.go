@@ -0,0 +1,112 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ghodss/yaml"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/authz"
+)
+
+func TestConfigHash(t *testing.T) {
+	newConfig := func(path string) *effectiveConfig {
+		return &effectiveConfig{
+			Upstream: "http://127.0.0.1:8081/",
+			Authorization: &authz.Config{
+				Static: []authz.StaticAuthorizationConfig{{Path: path, Verb: "get"}},
+			},
+		}
+	}
+
+	a, err := configHash(newConfig("/metrics"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := configHash(newConfig("/metrics"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := configHash(newConfig("/debug"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if a != b {
+		t.Errorf("want equal configs to have equal hashes, got %q and %q", a, b)
+	}
+	if a == c {
+		t.Errorf("want different configs to have different hashes, got %q", a)
+	}
+}
+
+func TestVersionHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	versionHandler(newVersionInfo("abc")).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/version", nil))
+
+	var got map[string]interface{}
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	for _, key := range []string{"gitVersion", "gitCommit", "goVersion", "configHash", "featureGates"} {
+		if _, ok := got[key]; !ok {
+			t.Errorf("expected %q in version response %v", key, got)
+		}
+	}
+	if got["configHash"] != "abc" {
+		t.Errorf("want configHash abc, got %v", got["configHash"])
+	}
+}
+
+func TestDumpEffectiveConfig(t *testing.T) {
+	cfg := &effectiveConfig{
+		Upstream: "http://127.0.0.1:8081/",
+		Authorization: &authz.Config{
+			ResourceAttributes: authz.ResourceAttributesList{{Namespace: "default", Resource: "services", Subresource: "proxy"}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := dumpEffectiveConfig(&buf, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	hash, err := configHash(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), "# configHash: "+hash+"\n") {
+		t.Errorf("want the dump to start with the config hash, got %q", buf.String())
+	}
+
+	// The authorization can be used as config file.
+	var got configfile
+	if err := yaml.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.AuthorizationConfig, cfg.Authorization) {
+		t.Errorf("want authorization %+v, got %+v", cfg.Authorization, got.AuthorizationConfig)
+	}
+}
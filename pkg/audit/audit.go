@@ -0,0 +1,200 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+)
+
+const (
+	DecisionAllow = "allow"
+	DecisionDeny  = "deny"
+	DecisionError = "error"
+)
+
+var (
+	exportedEvents = metrics.NewCounter(&metrics.CounterOpts{
+		Namespace:      "kube_rbac_proxy",
+		Subsystem:      "decision_export",
+		Name:           "events_total",
+		Help:           "Number of authorization decisions successfully exported.",
+		StabilityLevel: metrics.ALPHA,
+	})
+	droppedEvents = metrics.NewCounter(&metrics.CounterOpts{
+		Namespace:      "kube_rbac_proxy",
+		Subsystem:      "decision_export",
+		Name:           "dropped_events_total",
+		Help:           "Number of authorization decisions dropped, because the export buffer was full.",
+		StabilityLevel: metrics.ALPHA,
+	})
+	failedEvents = metrics.NewCounter(&metrics.CounterOpts{
+		Namespace:      "kube_rbac_proxy",
+		Subsystem:      "decision_export",
+		Name:           "failed_events_total",
+		Help:           "Number of authorization decisions that failed to be written to the sink.",
+		StabilityLevel: metrics.ALPHA,
+	})
+)
+
+func init() {
+	legacyregistry.MustRegister(exportedEvents, droppedEvents, failedEvents)
+}
+
+// Event describes a single authorization decision.
+type Event struct {
+	Time       time.Time  `json:"time"`
+	Decision   string     `json:"decision"`
+	Reason     string     `json:"reason,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	User       string     `json:"user"`
+	Groups     []string   `json:"groups,omitempty"`
+	Attributes Attributes `json:"attributes"`
+	// BodySHA256 is the hex encoded SHA-256 of the buffered request body,
+	// empty if the body wasn't buffered.
+	BodySHA256 string `json:"bodySHA256,omitempty"`
+	// Connection is the client connection the request was received on.
+	Connection *Connection `json:"connection,omitempty"`
+}
+
+// bodyHashKey is the context key of the hash of the request body.
+type bodyHashKey struct{}
+
+// WithBodyHash returns a context carrying the hex encoded SHA-256 of the
+// request body, which is added to the events of the request.
+func WithBodyHash(ctx context.Context, hash string) context.Context {
+	return context.WithValue(ctx, bodyHashKey{}, hash)
+}
+
+// Attributes are the authorizer attributes the decision was made on.
+type Attributes struct {
+	Verb            string `json:"verb,omitempty"`
+	Namespace       string `json:"namespace,omitempty"`
+	APIGroup        string `json:"apiGroup,omitempty"`
+	APIVersion      string `json:"apiVersion,omitempty"`
+	Resource        string `json:"resource,omitempty"`
+	Subresource     string `json:"subresource,omitempty"`
+	Name            string `json:"name,omitempty"`
+	ResourceRequest bool   `json:"resourceRequest"`
+	Path            string `json:"path,omitempty"`
+}
+
+// Sink writes events to an external system.
+type Sink interface {
+	Write(ctx context.Context, e *Event) error
+}
+
+// Exporter ships events asynchronously to a sink. Events are buffered up to
+// a fixed size, events that don't fit into the buffer are dropped, so that
+// a slow sink never blocks request handling.
+type Exporter struct {
+	sink   Sink
+	events chan *Event
+}
+
+// NewExporter creates an Exporter, the Run method must be started explicitly.
+func NewExporter(sink Sink, bufferSize int) *Exporter {
+	return &Exporter{
+		sink:   sink,
+		events: make(chan *Event, bufferSize),
+	}
+}
+
+// Export enqueues the event without blocking.
+func (e *Exporter) Export(ev *Event) {
+	select {
+	case e.events <- ev:
+	default:
+		droppedEvents.Inc()
+	}
+}
+
+// Run writes the buffered events to the sink until the context is done.
+func (e *Exporter) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev := <-e.events:
+			if err := e.sink.Write(ctx, ev); err != nil {
+				klog.V(4).InfoS("Failed to export authorization decision", "err", err)
+				failedEvents.Inc()
+				continue
+			}
+			exportedEvents.Inc()
+		}
+	}
+}
+
+// WithDecisionExport wraps the authorizer, such that every decision is
+// exported.
+func WithDecisionExport(authz authorizer.Authorizer, e *Exporter) authorizer.Authorizer {
+	return authorizer.AuthorizerFunc(func(ctx context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
+		decision, reason, err := authz.Authorize(ctx, a)
+		ev := NewEvent(a, decision, reason, err)
+		ev.BodySHA256, _ = ctx.Value(bodyHashKey{}).(string)
+		ev.Connection, _ = ConnectionFrom(ctx)
+		e.Export(ev)
+		return decision, reason, err
+	})
+}
+
+// NewEvent creates an event from the given authorization result.
+func NewEvent(a authorizer.Attributes, decision authorizer.Decision, reason string, err error) *Event {
+	ev := &Event{
+		Time:     time.Now(),
+		Decision: decisionString(decision),
+		Reason:   reason,
+		Attributes: Attributes{
+			Verb:            a.GetVerb(),
+			Namespace:       a.GetNamespace(),
+			APIGroup:        a.GetAPIGroup(),
+			APIVersion:      a.GetAPIVersion(),
+			Resource:        a.GetResource(),
+			Subresource:     a.GetSubresource(),
+			Name:            a.GetName(),
+			ResourceRequest: a.IsResourceRequest(),
+			Path:            a.GetPath(),
+		},
+	}
+
+	if err != nil {
+		ev.Decision = DecisionError
+		ev.Error = err.Error()
+	}
+
+	if u := a.GetUser(); u != nil {
+		ev.User = u.GetName()
+		ev.Groups = u.GetGroups()
+	}
+
+	return ev
+}
+
+// decisionString returns the effective decision, kube-rbac-proxy treats
+// authorizers having no opinion as a denial.
+func decisionString(d authorizer.Decision) string {
+	if d == authorizer.DecisionAllow {
+		return DecisionAllow
+	}
+	return DecisionDeny
+}
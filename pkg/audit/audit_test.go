@@ -0,0 +1,127 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+)
+
+func TestWithDecisionExport(t *testing.T) {
+	received := make(chan Event, 3)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ev Event
+		if err := json.NewDecoder(r.Body).Decode(&ev); err != nil {
+			t.Errorf("failed to decode event: %v", err)
+		}
+		received <- ev
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	exporter := NewExporter(NewHTTPSink(srv.URL), 10)
+	go exporter.Run(ctx)
+
+	authz := WithDecisionExport(authorizer.AuthorizerFunc(func(ctx context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
+		switch a.GetPath() {
+		case "/allowed":
+			return authorizer.DecisionAllow, "allowed", nil
+		case "/error":
+			return authorizer.DecisionNoOpinion, "", errors.New("boom")
+		default:
+			return authorizer.DecisionNoOpinion, "", nil
+		}
+	}), exporter)
+
+	u := &user.DefaultInfo{Name: "system:foo", Groups: []string{"bar"}}
+	for _, path := range []string{"/allowed", "/denied", "/error"} {
+		reqCtx := WithConnection(WithBodyHash(ctx, "sha-"+path), &Connection{RemoteIP: "10.0.0.1"})
+		_, _, _ = authz.Authorize(reqCtx, authorizer.AttributesRecord{User: u, Verb: "get", Path: path})
+	}
+
+	want := map[string]string{
+		"/allowed": DecisionAllow,
+		"/denied":  DecisionDeny,
+		"/error":   DecisionError,
+	}
+	for range want {
+		select {
+		case ev := <-received:
+			if ev.User != "system:foo" {
+				t.Errorf("want user system:foo, got %q", ev.User)
+			}
+			if ev.BodySHA256 != "sha-"+ev.Attributes.Path {
+				t.Errorf("path %s: want body hash %q, got %q", ev.Attributes.Path, "sha-"+ev.Attributes.Path, ev.BodySHA256)
+			}
+			if ev.Connection == nil || ev.Connection.RemoteIP != "10.0.0.1" {
+				t.Errorf("path %s: want remote IP 10.0.0.1, got %+v", ev.Attributes.Path, ev.Connection)
+			}
+			if ev.Decision != want[ev.Attributes.Path] {
+				t.Errorf("path %s: want decision %q, got %q", ev.Attributes.Path, want[ev.Attributes.Path], ev.Decision)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for exported event")
+		}
+	}
+}
+
+func TestNewConnection(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.1:41234"
+	if c := NewConnection(req); *c != (Connection{RemoteIP: "10.0.0.1"}) {
+		t.Errorf("unexpected plain connection %+v", c)
+	}
+
+	cert := &x509.Certificate{Raw: []byte("certificate")}
+	req.TLS = &tls.ConnectionState{
+		ServerName:       "proxy.example.com",
+		Version:          tls.VersionTLS13,
+		PeerCertificates: []*x509.Certificate{cert},
+	}
+	want := Connection{
+		RemoteIP:         "10.0.0.1",
+		ServerName:       "proxy.example.com",
+		TLSVersion:       "TLS 1.3",
+		ClientCertSHA256: "03d66dd08835c1ca3f128cceacd1f31ac94163096b20f445ae84285bc0832d72",
+	}
+	if c := NewConnection(req); *c != want {
+		t.Errorf("want connection %+v, got %+v", want, c)
+	}
+}
+
+func TestExporterDropsWhenFull(t *testing.T) {
+	exporter := NewExporter(nil, 1)
+
+	exporter.Export(&Event{})
+	exporter.Export(&Event{})
+
+	if l := len(exporter.events); l != 1 {
+		t.Errorf("want 1 buffered event, got %d", l)
+	}
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"net"
+	"net/http"
+)
+
+// Connection describes the client connection a request was received on.
+type Connection struct {
+	// RemoteIP is the IP of the client, or of the last proxy in front of
+	// kube-rbac-proxy.
+	RemoteIP string `json:"remoteIP,omitempty"`
+	// ServerName is the SNI the client sent.
+	ServerName string `json:"serverName,omitempty"`
+	// TLSVersion is the negotiated TLS version, e.g. "TLS 1.3".
+	TLSVersion string `json:"tlsVersion,omitempty"`
+	// ClientCertSHA256 is the hex encoded SHA-256 fingerprint of the
+	// client certificate.
+	ClientCertSHA256 string `json:"clientCertSHA256,omitempty"`
+}
+
+// connectionKey is the context key of the connection of the request.
+type connectionKey struct{}
+
+// WithConnection returns a context carrying the connection, which is added
+// to the events of the request.
+func WithConnection(ctx context.Context, c *Connection) context.Context {
+	return context.WithValue(ctx, connectionKey{}, c)
+}
+
+// ConnectionFrom returns the connection of the context, if any.
+func ConnectionFrom(ctx context.Context) (*Connection, bool) {
+	c, ok := ctx.Value(connectionKey{}).(*Connection)
+	return c, ok
+}
+
+// NewConnection describes the connection of the request.
+func NewConnection(req *http.Request) *Connection {
+	c := &Connection{RemoteIP: req.RemoteAddr}
+	if host, _, err := net.SplitHostPort(req.RemoteAddr); err == nil {
+		c.RemoteIP = host
+	}
+
+	if req.TLS != nil {
+		c.ServerName = req.TLS.ServerName
+		c.TLSVersion = tls.VersionName(req.TLS.Version)
+		if len(req.TLS.PeerCertificates) > 0 {
+			sum := sha256.Sum256(req.TLS.PeerCertificates[0].Raw)
+			c.ClientCertSHA256 = hex.EncodeToString(sum[:])
+		}
+	}
+
+	return c
+}
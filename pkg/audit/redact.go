@@ -0,0 +1,73 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+const (
+	RedactionNone     = "none"
+	RedactionHash     = "hash"
+	RedactionTruncate = "truncate"
+
+	defaultTruncateLength = 8
+)
+
+// Redactor hides sensitive values before they are logged or exported.
+type Redactor struct {
+	mode   string
+	length int
+}
+
+// NewRedactor creates a Redactor for the given mode. An empty mode doesn't
+// redact. The length is the number of characters kept by the truncate mode,
+// defaulting to 8.
+func NewRedactor(mode string, length int) (*Redactor, error) {
+	switch mode {
+	case "", RedactionNone, RedactionHash, RedactionTruncate:
+	default:
+		return nil, fmt.Errorf("unknown redaction %q, must be one of %s, %s or %s", mode, RedactionNone, RedactionHash, RedactionTruncate)
+	}
+
+	if length < 0 {
+		return nil, fmt.Errorf("truncate length must not be negative")
+	}
+	if length == 0 {
+		length = defaultTruncateLength
+	}
+
+	return &Redactor{mode: mode, length: length}, nil
+}
+
+// Redact returns the value in its redacted form. Hashed values are the
+// SHA-256 of the value, so they can still be correlated with known values.
+func (r *Redactor) Redact(value string) string {
+	switch r.mode {
+	case RedactionHash:
+		sum := sha256.Sum256([]byte(value))
+		return "sha256:" + hex.EncodeToString(sum[:])
+	case RedactionTruncate:
+		if runes := []rune(value); len(runes) > r.length {
+			return string(runes[:r.length]) + "..."
+		}
+	}
+
+	return value
+}
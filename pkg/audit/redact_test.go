@@ -0,0 +1,47 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import "testing"
+
+func TestRedactor(t *testing.T) {
+	for _, tt := range []struct {
+		mode   string
+		length int
+		value  string
+		want   string
+	}{
+		{mode: "", value: "tenant-a", want: "tenant-a"},
+		{mode: RedactionNone, value: "tenant-a", want: "tenant-a"},
+		{mode: RedactionHash, value: "tenant-a", want: "sha256:80a707af7dc77ee1228f9127180f3964835e5beb4c4ab0d812f0fe7593579b3a"},
+		{mode: RedactionTruncate, value: "tenant-a", want: "tenant-a"},
+		{mode: RedactionTruncate, value: "tenant-abcdef", want: "tenant-a..."},
+		{mode: RedactionTruncate, length: 3, value: "tenant-a", want: "ten..."},
+	} {
+		r, err := NewRedactor(tt.mode, tt.length)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := r.Redact(tt.value); got != tt.want {
+			t.Errorf("mode %q: want %q, got %q", tt.mode, tt.want, got)
+		}
+	}
+
+	if _, err := NewRedactor("base64", 0); err == nil {
+		t.Error("want error for unknown redaction")
+	}
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	SinkHTTP   = "http"
+	SinkSyslog = "syslog"
+)
+
+// ExportConfig holds the configuration of the decision export.
+type ExportConfig struct {
+	// Sink is the kind of sink, one of "http" or "syslog".
+	// Decisions are not exported, if empty.
+	Sink string
+	// Address is the URL for the http sink and [tcp|udp://]host:port for the
+	// syslog sink.
+	Address string
+	// BufferSize is the maximum number of events kept in memory before
+	// dropping them.
+	BufferSize int
+}
+
+// NewSink creates the sink configured in cfg.
+func NewSink(cfg *ExportConfig) (Sink, error) {
+	switch cfg.Sink {
+	case SinkHTTP:
+		return NewHTTPSink(cfg.Address), nil
+	case SinkSyslog:
+		return NewSyslogSink(cfg.Address)
+	default:
+		return nil, fmt.Errorf("unknown decision export sink %q", cfg.Sink)
+	}
+}
+
+// HTTPSink POSTs every event as JSON to a URL.
+type HTTPSink struct {
+	url    string
+	client *http.Client
+}
+
+func NewHTTPSink(url string) *HTTPSink {
+	return &HTTPSink{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (s *HTTPSink) Write(ctx context.Context, e *Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send event: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// SyslogSink writes every event as JSON in an RFC 5424 message to a remote
+// syslog server.
+type SyslogSink struct {
+	network, addr string
+	hostname      string
+
+	mu   sync.Mutex // protects conn
+	conn net.Conn
+}
+
+// NewSyslogSink creates a syslog sink for an address in the form
+// [tcp|udp://]host:port, defaulting to udp.
+func NewSyslogSink(address string) (*SyslogSink, error) {
+	network, addr := "udp", address
+	if u, err := url.Parse(address); err == nil && u.Host != "" {
+		network, addr = u.Scheme, u.Host
+	}
+	if network != "udp" && network != "tcp" {
+		return nil, fmt.Errorf("unsupported syslog network %q", network)
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "-"
+	}
+
+	return &SyslogSink{
+		network:  network,
+		addr:     addr,
+		hostname: hostname,
+	}, nil
+}
+
+// severityInfo and facilityAuthPriv make up the syslog priority of every
+// message.
+const (
+	severityInfo     = 6
+	facilityAuthPriv = 10
+)
+
+func (s *SyslogSink) Write(ctx context.Context, e *Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	msg := fmt.Sprintf("<%d>1 %s %s kube-rbac-proxy - - - %s\n",
+		facilityAuthPriv*8+severityInfo,
+		e.Time.UTC().Format(time.RFC3339Nano),
+		s.hostname,
+		body,
+	)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, s.network, s.addr)
+		if err != nil {
+			return fmt.Errorf("failed to connect to syslog: %w", err)
+		}
+		s.conn = conn
+	}
+
+	if _, err := s.conn.Write([]byte(msg)); err != nil {
+		// Reconnect on the next write.
+		s.conn.Close()
+		s.conn = nil
+		return fmt.Errorf("failed to write to syslog: %w", err)
+	}
+
+	return nil
+}
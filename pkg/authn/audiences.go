@@ -0,0 +1,159 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authn
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+)
+
+// AudienceRegexpPrefix marks audiences that are regular expressions matching
+// whole audiences, e.g. regexp:https://cluster-[0-9]+\.example\.com.
+// Audiences containing a * are wildcard patterns, where the * matches any
+// characters, e.g. https://*.example.com.
+const AudienceRegexpPrefix = "regexp:"
+
+// audiencePatterns caches the compiled audience patterns.
+var audiencePatterns sync.Map
+
+// ValidateAudiences checks the patterns of the audiences.
+func ValidateAudiences(audiences []string) error {
+	for _, audience := range audiences {
+		if _, _, err := compileAudience(audience); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compileAudience returns the pattern of the audience and true, if it is a
+// pattern.
+func compileAudience(audience string) (*regexp.Regexp, bool, error) {
+	var expr string
+	switch {
+	case strings.HasPrefix(audience, AudienceRegexpPrefix):
+		expr = strings.TrimPrefix(audience, AudienceRegexpPrefix)
+	case strings.Contains(audience, "*"):
+		parts := strings.Split(audience, "*")
+		for i := range parts {
+			parts[i] = regexp.QuoteMeta(parts[i])
+		}
+		expr = strings.Join(parts, ".*")
+	default:
+		return nil, false, nil
+	}
+
+	if pattern, ok := audiencePatterns.Load(audience); ok {
+		return pattern.(*regexp.Regexp), true, nil
+	}
+	pattern, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		return nil, true, fmt.Errorf("invalid audience pattern %q: %w", audience, err)
+	}
+	audiencePatterns.Store(audience, pattern)
+	return pattern, true, nil
+}
+
+// matchAudiences returns the audiences that match one of the configured
+// audiences, exactly or by a pattern.
+func matchAudiences(configured, audiences []string) []string {
+	var matched []string
+	for _, audience := range audiences {
+		for _, c := range configured {
+			pattern, isPattern, err := compileAudience(c)
+			if err != nil {
+				continue
+			}
+			if (isPattern && pattern.MatchString(audience)) || (!isPattern && c == audience) {
+				matched = append(matched, audience)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// WithAudiencePatterns resolves the audience patterns of the request context
+// to the audiences of the token matching them, before the token is reviewed.
+// The TokenReview then checks the token for these audiences. Tokens issued for
+// none of the audiences are rejected.
+func WithAudiencePatterns(auth authenticator.Request) authenticator.Request {
+	return authenticator.RequestFunc(func(req *http.Request) (*authenticator.Response, bool, error) {
+		configured, ok := authenticator.AudiencesFrom(req.Context())
+		if !ok || !hasAudiencePattern(configured) {
+			return auth.AuthenticateRequest(req)
+		}
+
+		token := strings.TrimSpace(strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer "))
+		if token == "" {
+			return auth.AuthenticateRequest(req)
+		}
+
+		var audiences []string
+		for _, audience := range configured {
+			if _, isPattern, _ := compileAudience(audience); !isPattern {
+				audiences = append(audiences, audience)
+			}
+		}
+		for _, audience := range matchAudiences(configured, tokenAudiences(token)) {
+			if !slices.Contains(audiences, audience) {
+				audiences = append(audiences, audience)
+			}
+		}
+		// Without audiences the TokenReview would check the token for the
+		// audiences of the API server.
+		if len(audiences) == 0 {
+			return nil, false, errors.New("token is not issued for any of the audiences")
+		}
+
+		req = req.WithContext(authenticator.WithAudiences(req.Context(), audiences))
+		return auth.AuthenticateRequest(req)
+	})
+}
+
+func hasAudiencePattern(audiences []string) bool {
+	for _, audience := range audiences {
+		if _, isPattern, _ := compileAudience(audience); isPattern {
+			return true
+		}
+	}
+	return false
+}
+
+// tokenAudiences returns the unverified audiences of a JWT.
+func tokenAudiences(token string) []string {
+	payload, ok := tokenPayload(token)
+	if !ok {
+		return nil
+	}
+
+	var claims struct {
+		Audience audience `json:"aud"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil
+	}
+	return claims.Audience
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package authn
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/authentication/user"
+)
+
+func TestWithAudiencePatterns(t *testing.T) {
+	token := func(payload string) string {
+		return "e30." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".sig"
+	}
+
+	for _, tt := range []struct {
+		name       string
+		configured []string
+		token      string
+		want       []string
+		wantErr    bool
+	}{
+		{
+			name:       "exact audiences are kept",
+			configured: []string{"kube-rbac-proxy"},
+			token:      token(`{"aud":"other"}`),
+			want:       []string{"kube-rbac-proxy"},
+		},
+		{
+			name:       "wildcard",
+			configured: []string{"kube-rbac-proxy", "https://*.example.com"},
+			token:      token(`{"aud":["https://cluster-a.example.com","https://kubernetes.default.svc"]}`),
+			want:       []string{"kube-rbac-proxy", "https://cluster-a.example.com"},
+		},
+		{
+			name:       "regexp",
+			configured: []string{`regexp:cluster-[0-9]+`},
+			token:      token(`{"aud":"cluster-42"}`),
+			want:       []string{"cluster-42"},
+		},
+		{
+			name:       "regexp matches whole audiences",
+			configured: []string{`regexp:cluster-[0-9]+`},
+			token:      token(`{"aud":"cluster-42.evil"}`),
+			wantErr:    true,
+		},
+		{
+			name:       "no matching audience",
+			configured: []string{"https://*.example.com"},
+			token:      token(`{"aud":"https://kubernetes.default.svc"}`),
+			wantErr:    true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			auth := WithAudiencePatterns(authenticator.RequestFunc(func(req *http.Request) (*authenticator.Response, bool, error) {
+				got, _ = authenticator.AudiencesFrom(req.Context())
+				return &authenticator.Response{User: &user.DefaultInfo{Name: "alice"}}, true, nil
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			req = req.WithContext(authenticator.WithAudiences(req.Context(), tt.configured))
+
+			_, _, err := auth.AuthenticateRequest(req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("want error %v, got %v", tt.wantErr, err)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("want audiences %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestValidateAudiences(t *testing.T) {
+	if err := ValidateAudiences([]string{"kube-rbac-proxy", "https://*.example.com", "regexp:cluster-[0-9]+"}); err != nil {
+		t.Errorf("want valid audiences, got %v", err)
+	}
+	if err := ValidateAudiences([]string{"regexp:cluster-("}); err == nil {
+		t.Error("want invalid regexp to be rejected")
+	}
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authn
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+)
+
+const (
+	// awsTokenPrefix prefixes the base64 encoded presigned URL of the
+	// STS GetCallerIdentity request.
+	awsTokenPrefix = "k8s-aws-v1."
+	// awsClusterIDHeader is the header the cluster ID is signed in.
+	awsClusterIDHeader = "x-k8s-aws-id"
+	// awsTokenMaxAge is the validity of tokens, as of aws-iam-authenticator.
+	awsTokenMaxAge = 15 * time.Minute
+)
+
+// stsHost matches the global and the regional STS endpoints.
+var stsHost = regexp.MustCompile(`^sts(\.[a-z0-9-]+)?\.amazonaws\.com(\.cn)?$`)
+
+// awsAuthenticator authenticates tokens presigning an STS GetCallerIdentity
+// request, as EKS does. The request is sent to STS, which verifies the
+// signature and returns the ARN of the caller as identity.
+type awsAuthenticator struct {
+	client    *http.Client
+	clusterID string
+	mappings  []IdentityMapping
+
+	// validHost checks the host of the presigned request, it is replaced
+	// in tests.
+	validHost func(host string) bool
+	now       func() time.Time
+}
+
+func newAWSAuthenticator(client *http.Client, config *CloudConfig) *awsAuthenticator {
+	return &awsAuthenticator{
+		client:    client,
+		clusterID: config.AWSClusterID,
+		mappings:  config.IdentityMappings,
+		validHost: stsHost.MatchString,
+		now:       time.Now,
+	}
+}
+
+func (a *awsAuthenticator) AuthenticateToken(ctx context.Context, token string) (*authenticator.Response, bool, error) {
+	if !strings.HasPrefix(token, awsTokenPrefix) {
+		return nil, false, nil
+	}
+
+	stsURL, err := a.presignedURL(token)
+	if err != nil {
+		return nil, false, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, stsURL.String(), nil)
+	if err != nil {
+		return nil, false, err
+	}
+	// STS rejects the signature, unless the token was signed for the
+	// cluster ID.
+	req.Header.Set(awsClusterIDHeader, a.clusterID)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, false, fmt.Errorf("aws: failed to call STS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, fmt.Errorf("aws: STS rejected the token with status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		GetCallerIdentityResponse struct {
+			GetCallerIdentityResult struct {
+				Account string `json:"Account"`
+				Arn     string `json:"Arn"`
+				UserID  string `json:"UserId"`
+			} `json:"GetCallerIdentityResult"`
+		} `json:"GetCallerIdentityResponse"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return nil, false, fmt.Errorf("aws: failed to decode the STS response: %w", err)
+	}
+	result := body.GetCallerIdentityResponse.GetCallerIdentityResult
+
+	arn, err := canonicalARN(result.Arn)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &authenticator.Response{User: mapIdentity(a.mappings, arn, result.UserID)}, true, nil
+}
+
+// presignedURL validates the presigned request of the token, before it is
+// sent to STS.
+func (a *awsAuthenticator) presignedURL(token string) (*url.URL, error) {
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(token, awsTokenPrefix))
+	if err != nil {
+		return nil, errors.New("aws: token is malformed")
+	}
+	u, err := url.Parse(string(b))
+	if err != nil {
+		return nil, errors.New("aws: token is malformed")
+	}
+
+	if u.Scheme != "https" || !a.validHost(u.Host) || u.Path != "/" {
+		return nil, errors.New("aws: token isn't a request to STS")
+	}
+
+	query := u.Query()
+	if query.Get("Action") != "GetCallerIdentity" {
+		return nil, errors.New("aws: token isn't a GetCallerIdentity request")
+	}
+	if !slices.Contains(strings.Split(query.Get("X-Amz-SignedHeaders"), ";"), awsClusterIDHeader) {
+		return nil, fmt.Errorf("aws: token isn't signed with the %s header", awsClusterIDHeader)
+	}
+
+	date, err := time.Parse("20060102T150405Z", query.Get("X-Amz-Date"))
+	if err != nil {
+		return nil, errors.New("aws: token has no valid date")
+	}
+	if a.now().Sub(date) > awsTokenMaxAge {
+		return nil, errors.New("aws: token is expired")
+	}
+
+	return u, nil
+}
+
+// canonicalARN returns the ARN of the role for sessions of an assumed role,
+// as EKS does, and the ARN of IAM users and roles unchanged.
+func canonicalARN(arn string) (string, error) {
+	// arn:partition:service:region:account:resource
+	parts := strings.Split(arn, ":")
+	if len(parts) != 6 || parts[0] != "arn" {
+		return "", fmt.Errorf("aws: malformed ARN %q", arn)
+	}
+	partition, service, account, resource := parts[1], parts[2], parts[4], parts[5]
+
+	switch {
+	case service == "iam":
+		return arn, nil
+	case service == "sts" && strings.HasPrefix(resource, "assumed-role/"):
+		// assumed-role/role-name/session-name
+		segments := strings.Split(resource, "/")
+		if len(segments) != 3 {
+			return "", fmt.Errorf("aws: malformed assumed role ARN %q", arn)
+		}
+		return fmt.Sprintf("arn:%s:iam::%s:role/%s", partition, account, segments[1]), nil
+	}
+
+	return "", fmt.Errorf("aws: unsupported ARN %q", arn)
+}
@@ -0,0 +1,190 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authn
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAWSAuthenticator(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	sts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get(awsClusterIDHeader) != "my-cluster" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"GetCallerIdentityResponse":{"GetCallerIdentityResult":{"Account":"123456789012","Arn":"arn:aws:sts::123456789012:assumed-role/scraper/session","UserId":"AROAEXAMPLE:session"}}}`))
+	}))
+	defer sts.Close()
+	stsHost := strings.TrimPrefix(sts.URL, "https://")
+
+	token := func(host string, query url.Values) string {
+		u := url.URL{Scheme: "https", Host: host, Path: "/", RawQuery: query.Encode()}
+		return awsTokenPrefix + base64.RawURLEncoding.EncodeToString([]byte(u.String()))
+	}
+	query := func(modify func(url.Values)) url.Values {
+		q := url.Values{
+			"Action":              {"GetCallerIdentity"},
+			"Version":             {"2011-06-15"},
+			"X-Amz-Date":          {now.Add(-time.Minute).Format("20060102T150405Z")},
+			"X-Amz-SignedHeaders": {"host;x-k8s-aws-id"},
+		}
+		if modify != nil {
+			modify(q)
+		}
+		return q
+	}
+
+	for _, tt := range []struct {
+		name       string
+		clusterID  string
+		token      string
+		mappings   []IdentityMapping
+		wantOK     bool
+		wantErr    bool
+		wantName   string
+		wantGroups []string
+	}{
+		{
+			name:      "assumed role",
+			clusterID: "my-cluster",
+			token:     token(stsHost, query(nil)),
+			wantOK:    true,
+			wantName:  "arn:aws:iam::123456789012:role/scraper",
+		},
+		{
+			name:      "mapped role",
+			clusterID: "my-cluster",
+			token:     token(stsHost, query(nil)),
+			mappings: []IdentityMapping{
+				{Identity: "arn:aws:iam::123456789012:role/scraper", Username: "scraper", Groups: []string{"monitoring"}},
+			},
+			wantOK:     true,
+			wantName:   "scraper",
+			wantGroups: []string{"monitoring"},
+		},
+		{
+			name:      "other cluster ID",
+			clusterID: "other-cluster",
+			token:     token(stsHost, query(nil)),
+			wantErr:   true,
+		},
+		{
+			name:      "other token format",
+			clusterID: "my-cluster",
+			token:     "e30.e30.sig",
+		},
+		{
+			name:      "other host",
+			clusterID: "my-cluster",
+			token:     token("attacker.example.com", query(nil)),
+			wantErr:   true,
+		},
+		{
+			name:      "other action",
+			clusterID: "my-cluster",
+			token:     token(stsHost, query(func(q url.Values) { q.Set("Action", "AssumeRole") })),
+			wantErr:   true,
+		},
+		{
+			name:      "cluster ID not signed",
+			clusterID: "my-cluster",
+			token:     token(stsHost, query(func(q url.Values) { q.Set("X-Amz-SignedHeaders", "host") })),
+			wantErr:   true,
+		},
+		{
+			name:      "expired",
+			clusterID: "my-cluster",
+			token: token(stsHost, query(func(q url.Values) {
+				q.Set("X-Amz-Date", now.Add(-time.Hour).Format("20060102T150405Z"))
+			})),
+			wantErr: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newAWSAuthenticator(sts.Client(), &CloudConfig{AWSClusterID: tt.clusterID, IdentityMappings: tt.mappings})
+			a.validHost = func(host string) bool { return host == stsHost }
+			a.now = func() time.Time { return now }
+
+			resp, ok, err := a.AuthenticateToken(context.Background(), tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("want error %t, got %v", tt.wantErr, err)
+			}
+			if ok != tt.wantOK {
+				t.Fatalf("want ok %t, got %t", tt.wantOK, ok)
+			}
+			if !ok {
+				return
+			}
+			if resp.User.GetName() != tt.wantName {
+				t.Errorf("want name %q, got %q", tt.wantName, resp.User.GetName())
+			}
+			if !reflect.DeepEqual(resp.User.GetGroups(), tt.wantGroups) {
+				t.Errorf("want groups %v, got %v", tt.wantGroups, resp.User.GetGroups())
+			}
+		})
+	}
+}
+
+func TestCanonicalARN(t *testing.T) {
+	for _, tt := range []struct {
+		arn     string
+		want    string
+		wantErr bool
+	}{
+		{arn: "arn:aws:iam::123456789012:user/jane", want: "arn:aws:iam::123456789012:user/jane"},
+		{arn: "arn:aws:iam::123456789012:role/scraper", want: "arn:aws:iam::123456789012:role/scraper"},
+		{arn: "arn:aws:sts::123456789012:assumed-role/scraper/i-0123", want: "arn:aws:iam::123456789012:role/scraper"},
+		{arn: "arn:aws-cn:sts::123456789012:assumed-role/scraper/i-0123", want: "arn:aws-cn:iam::123456789012:role/scraper"},
+		{arn: "arn:aws:sts::123456789012:federated-user/jane", wantErr: true},
+		{arn: "not-an-arn", wantErr: true},
+	} {
+		t.Run(tt.arn, func(t *testing.T) {
+			got, err := canonicalARN(tt.arn)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("want error %t, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("want %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSTSHost(t *testing.T) {
+	for host, want := range map[string]bool{
+		"sts.amazonaws.com":                  true,
+		"sts.eu-west-1.amazonaws.com":        true,
+		"sts.cn-north-1.amazonaws.com.cn":    true,
+		"sts.amazonaws.com.attacker.example": false,
+		"attacker.example/sts.amazonaws.com": false,
+	} {
+		if got := stsHost.MatchString(host); got != want {
+			t.Errorf("%s: want %t, got %t", host, want, got)
+		}
+	}
+}
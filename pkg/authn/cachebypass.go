@@ -0,0 +1,33 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authn
+
+import "context"
+
+type cacheBypassKey struct{}
+
+// WithCacheBypass returns a context, in which the TokenReviews and the
+// SubjectAccessReviews aren't answered from their caches.
+func WithCacheBypass(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheBypassKey{}, true)
+}
+
+// CacheBypassed returns whether the caches are bypassed in the context.
+func CacheBypassed(ctx context.Context) bool {
+	bypassed, _ := ctx.Value(cacheBypassKey{}).(bool)
+	return bypassed
+}
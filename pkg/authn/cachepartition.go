@@ -0,0 +1,85 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authn
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+)
+
+// CachePartition is the issuer and the audiences of the token a request was
+// authenticated with. Cached decisions are kept apart by partition, such that
+// the decisions of an issuer or audience can be dropped on their own, e.g.
+// while migrating to another identity provider.
+type CachePartition struct {
+	Issuer    string
+	Audiences []string
+}
+
+// Matches tells whether the partition is of the issuer and has the audience,
+// an empty issuer or audience matching any.
+func (p CachePartition) Matches(issuer, audience string) bool {
+	return (issuer == "" || p.Issuer == issuer) &&
+		(audience == "" || slices.Contains(p.Audiences, audience))
+}
+
+type cachePartitionKey struct{}
+
+// WithCachePartition returns a context carrying the partition of the cached
+// decisions of the request.
+func WithCachePartition(ctx context.Context, p CachePartition) context.Context {
+	return context.WithValue(ctx, cachePartitionKey{}, p)
+}
+
+// CachePartitionFrom returns the partition of the context, which is empty,
+// if the request wasn't authenticated with a token.
+func CachePartitionFrom(ctx context.Context) CachePartition {
+	p, _ := ctx.Value(cachePartitionKey{}).(CachePartition)
+	return p
+}
+
+// RequestCachePartition returns the partition of the authenticated request.
+// The issuer is read from the token, which was verified by the
+// authentication, unless the request might have been authenticated by its
+// client certificate. The audiences are those the token was authenticated
+// for, or else those it was to be authenticated for.
+func RequestCachePartition(req *http.Request, resp *authenticator.Response) CachePartition {
+	var p CachePartition
+	if req.TLS != nil && len(req.TLS.PeerCertificates) > 0 {
+		return p
+	}
+	if payload, ok := tokenPayload(bearerToken(req)); ok {
+		var claims struct {
+			Issuer string `json:"iss"`
+		}
+		if err := json.Unmarshal(payload, &claims); err == nil {
+			p.Issuer = claims.Issuer
+		}
+	}
+
+	audiences := []string(resp.Audiences)
+	if len(audiences) == 0 {
+		audiences, _ = authenticator.AudiencesFrom(req.Context())
+	}
+	p.Audiences = slices.Clone(audiences)
+	slices.Sort(p.Audiences)
+	return p
+}
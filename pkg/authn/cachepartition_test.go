@@ -0,0 +1,50 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authn
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+)
+
+func TestRequestCachePartition(t *testing.T) {
+	token := "e30." + base64.RawURLEncoding.EncodeToString([]byte(`{"iss":"https://idp.example.com"}`)) + ".sig"
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req = req.WithContext(authenticator.WithAudiences(req.Context(), authenticator.Audiences{"b", "a"}))
+
+	want := CachePartition{Issuer: "https://idp.example.com", Audiences: []string{"a", "b"}}
+	if got := RequestCachePartition(req, &authenticator.Response{}); !reflect.DeepEqual(got, want) {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+
+	want.Audiences = []string{"c"}
+	if got := RequestCachePartition(req, &authenticator.Response{Audiences: authenticator.Audiences{"c"}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("want the authenticated audiences %+v, got %+v", want, got)
+	}
+
+	req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{}}}
+	if got := RequestCachePartition(req, &authenticator.Response{}); !reflect.DeepEqual(got, CachePartition{}) {
+		t.Errorf("want no partition for client certificates, got %+v", got)
+	}
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authn
+
+import (
+	"net/http"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/cache"
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	x509request "k8s.io/apiserver/pkg/authentication/request/x509"
+	"k8s.io/apiserver/pkg/server/dynamiccertificates"
+)
+
+// connCertCacheSize is the maximum number of TLS connections whose users
+// are cached.
+const connCertCacheSize = 4096
+
+// connCertAuthenticator authenticates requests by their client certificate
+// and caches the user per TLS connection. net/http shares the connection
+// state between all requests of a connection, so the chain is verified
+// only once per connection instead of on every request.
+type connCertAuthenticator struct {
+	x509  authenticator.Request
+	cache *cache.LRUExpireCache
+	ttl   time.Duration
+}
+
+var (
+	_ authenticator.Request        = (*connCertAuthenticator)(nil)
+	_ dynamiccertificates.Listener = (*connCertAuthenticator)(nil)
+)
+
+// newConnCertAuthenticator creates an authenticator that verifies client
+// certificates against the CA. The cache is flushed whenever the CA changes.
+func newConnCertAuthenticator(ca *dynamiccertificates.DynamicFileCAContent, ttl time.Duration) *connCertAuthenticator {
+	a := &connCertAuthenticator{
+		x509:  x509request.NewDynamic(ca.VerifyOptions, x509request.CommonNameUserConversion),
+		cache: cache.NewLRUExpireCache(connCertCacheSize),
+		ttl:   ttl,
+	}
+	ca.AddListener(a)
+
+	return a
+}
+
+func (a *connCertAuthenticator) AuthenticateRequest(req *http.Request) (*authenticator.Response, bool, error) {
+	if req.TLS == nil || len(req.TLS.PeerCertificates) == 0 {
+		return nil, false, nil
+	}
+
+	if resp, ok := a.cache.Get(req.TLS); ok {
+		return resp.(*authenticator.Response), true, nil
+	}
+
+	resp, ok, err := a.x509.AuthenticateRequest(req)
+	if err != nil || !ok {
+		return resp, ok, err
+	}
+
+	// Never cache beyond the expiry of the client certificate.
+	ttl := a.ttl
+	if untilExpiry := time.Until(req.TLS.PeerCertificates[0].NotAfter); untilExpiry < ttl {
+		ttl = untilExpiry
+	}
+	a.cache.Add(req.TLS, resp, ttl)
+
+	return resp, true, nil
+}
+
+// Enqueue is called on changes of the CA and invalidates all cached users.
+func (a *connCertAuthenticator) Enqueue() {
+	a.cache.RemoveAll(func(any) bool { return true })
+}
@@ -0,0 +1,237 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authn
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/server/dynamiccertificates"
+
+	rbac_proxy_tls "github.com/brancz/kube-rbac-proxy/pkg/tls"
+)
+
+func TestConnCertAuthenticator(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	clientCert := newClientCert(t, caFile, "system:foo")
+
+	ca, err := dynamiccertificates.NewDynamicCAContentFromFile("client-ca", caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := newConnCertAuthenticator(ca, time.Minute)
+	connState := &tls.ConnectionState{PeerCertificates: []*x509.Certificate{clientCert}}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.TLS = connState
+	resp, ok, err := a.AuthenticateRequest(req)
+	if err != nil || !ok {
+		t.Fatalf("want authenticated request, got ok=%t, err=%v", ok, err)
+	}
+	if resp.User.GetName() != "system:foo" {
+		t.Errorf("want user system:foo, got %q", resp.User.GetName())
+	}
+
+	// Swap the verifier, a second request on the same connection must be
+	// served from the cache.
+	a.x509 = authenticatorFunc(func(*http.Request) (*authenticator.Response, bool, error) {
+		t.Error("expected cached user, but certificate was verified again")
+		return nil, false, nil
+	})
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.TLS = connState
+	if _, ok, err := a.AuthenticateRequest(req); err != nil || !ok {
+		t.Fatalf("want cached user, got ok=%t, err=%v", ok, err)
+	}
+
+	// A CA change invalidates the cache.
+	a.Enqueue()
+	verified := false
+	a.x509 = authenticatorFunc(func(*http.Request) (*authenticator.Response, bool, error) {
+		verified = true
+		return nil, false, nil
+	})
+	if _, ok, _ := a.AuthenticateRequest(req); ok || !verified {
+		t.Errorf("want certificate to be verified again after CA change, got ok=%t, verified=%t", ok, verified)
+	}
+}
+
+func TestConnCertAuthenticatorWithCompletedChains(t *testing.T) {
+	dir := t.TempDir()
+	rootKey, root := newTestCert(t, nil, nil, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	})
+	intermediateKey, intermediate := newTestCert(t, root, rootKey, &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "intermediate"},
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	})
+	_, client := newTestCert(t, intermediate, intermediateKey, &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "system:foo"},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+
+	caFile := filepath.Join(dir, "ca.pem")
+	intermediatesFile := filepath.Join(dir, "intermediates.pem")
+	for file, cert := range map[string]*x509.Certificate{caFile: root, intermediatesFile: intermediate} {
+		if err := os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ca, err := dynamiccertificates.NewDynamicCAContentFromFile("client-ca", caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier, err := rbac_proxy_tls.NewChainVerifier(caFile, intermediatesFile, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := newConnCertAuthenticator(ca, time.Minute)
+	verifications := 0
+	x509Authenticator := a.x509
+	a.x509 = authenticatorFunc(func(req *http.Request) (*authenticator.Response, bool, error) {
+		verifications++
+		return x509Authenticator.AuthenticateRequest(req)
+	})
+
+	handler := verifier.WithCompletedChains(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+		if _, ok, err := a.AuthenticateRequest(req); err != nil || !ok {
+			t.Errorf("want authenticated request, got ok=%t, err=%v", ok, err)
+		}
+	}))
+
+	// The client presents the leaf only, both requests share the connection.
+	connState := &tls.ConnectionState{PeerCertificates: []*x509.Certificate{client}}
+	for range 2 {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.TLS = connState
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	if verifications != 1 {
+		t.Errorf("want the certificate of the connection verified once, got %d verifications", verifications)
+	}
+}
+
+type authenticatorFunc func(*http.Request) (*authenticator.Response, bool, error)
+
+func (a authenticatorFunc) AuthenticateRequest(req *http.Request) (*authenticator.Response, bool, error) {
+	return a(req)
+}
+
+// newClientCert writes a self-signed CA to caFile and returns a client
+// certificate signed by it.
+func newClientCert(t *testing.T, caFile, commonName string) *x509.Certificate {
+	t.Helper()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	clientKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	clientDER, err := x509.CreateCertificate(rand.Reader, clientTemplate, caCert, &clientKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientCert, err := x509.ParseCertificate(clientDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return clientCert
+}
+
+// newTestCert issues a certificate for the template, signed by the parent, or
+// self-signed, if the parent is nil.
+func newTestCert(t *testing.T, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, tmpl *x509.Certificate) (*ecdsa.PrivateKey, *x509.Certificate) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+	tmpl.NotBefore = time.Now().Add(-time.Hour)
+	tmpl.NotAfter = time.Now().Add(time.Hour)
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key, cert
+}
@@ -0,0 +1,218 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authn
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	gooidc "github.com/coreos/go-oidc"
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/authentication/user"
+)
+
+// notBeforeLeeway is the leeway the verifier of the API server grants for
+// the not-before time of tokens.
+const notBeforeLeeway = time.Minute
+
+// skewVerifier verifies tokens, which are expired or not yet valid by no
+// more than the tolerated clock skew. The authenticator of the API server
+// checks the expiry against the current time, it can't be configured with a
+// clock skew. Only the username claims and the groups claim are mapped.
+type skewVerifier struct {
+	*lazyVerifier
+	config *OIDCConfig
+}
+
+func newSkewVerifier(ctx context.Context, config *OIDCConfig, client *http.Client) *skewVerifier {
+	algs := config.SupportedSigningAlgs
+	if len(algs) == 0 {
+		algs = []string{gooidc.RS256}
+	}
+
+	return &skewVerifier{
+		lazyVerifier: newLazyVerifier(ctx, client, config.IssuerURL, &gooidc.Config{
+			ClientID:             config.ClientID,
+			SupportedSigningAlgs: algs,
+			// The expiry and not-before time are checked with the clock skew.
+			SkipExpiryCheck: true,
+		}),
+		config: config,
+	}
+}
+
+// withinSkew tells from the unverified claims of the token, whether it is
+// expired or not yet valid by no more than the clock skew.
+func (v *skewVerifier) withinSkew(token string, now time.Time) bool {
+	payload, ok := tokenPayload(token)
+	if !ok {
+		return false
+	}
+
+	var claims struct {
+		Expiry    float64 `json:"exp"`
+		NotBefore float64 `json:"nbf"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return false
+	}
+
+	return v.checkTimes(unixTime(claims.Expiry), unixTime(claims.NotBefore), now) == nil &&
+		(now.After(unixTime(claims.Expiry)) || now.Add(notBeforeLeeway).Before(unixTime(claims.NotBefore)))
+}
+
+// checkTimes checks the expiry and the optional not-before time of a token
+// with the clock skew.
+func (v *skewVerifier) checkTimes(expiry, notBefore, now time.Time) error {
+	if expiry.IsZero() {
+		return errors.New("oidc: token has no expiry")
+	}
+	if now.Sub(expiry) > v.config.ClockSkew {
+		return fmt.Errorf("oidc: token is expired (Token Expiry: %v)", expiry)
+	}
+	if !notBefore.IsZero() && notBefore.Sub(now) > v.config.ClockSkew {
+		return fmt.Errorf("oidc: current time %v before the nbf (not before) time: %v", now, notBefore)
+	}
+	return nil
+}
+
+func (v *skewVerifier) authenticate(ctx context.Context, token string) (*authenticator.Response, bool, error) {
+	idToken, err := v.verify(ctx, token)
+	if err != nil {
+		return nil, false, err
+	}
+
+	claims := map[string]json.RawMessage{}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, false, err
+	}
+
+	var notBefore float64
+	if raw, ok := claims["nbf"]; ok {
+		if err := json.Unmarshal(raw, &notBefore); err != nil {
+			return nil, false, fmt.Errorf("oidc: parse 'nbf' claim: %w", err)
+		}
+	}
+	if err := v.checkTimes(idToken.Expiry, unixTime(notBefore), time.Now()); err != nil {
+		return nil, false, err
+	}
+
+	u, err := v.claimsUser(claims)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &authenticator.Response{User: u}, true, nil
+}
+
+// lazyVerifier discovers the issuer on first use, as the authenticator of the
+// API server does asynchronously, and retries the discovery until it
+// succeeds.
+type lazyVerifier struct {
+	ctx    context.Context
+	issuer string
+	config *gooidc.Config
+
+	mu       sync.Mutex
+	verifier *gooidc.IDTokenVerifier
+}
+
+func newLazyVerifier(ctx context.Context, client *http.Client, issuer string, config *gooidc.Config) *lazyVerifier {
+	return &lazyVerifier{
+		ctx:    gooidc.ClientContext(ctx, client),
+		issuer: issuer,
+		config: config,
+	}
+}
+
+func (v *lazyVerifier) verify(ctx context.Context, token string) (*gooidc.IDToken, error) {
+	v.mu.Lock()
+	if v.verifier == nil {
+		provider, err := gooidc.NewProvider(v.ctx, v.issuer)
+		if err != nil {
+			v.mu.Unlock()
+			return nil, fmt.Errorf("oidc: initializing provider: %w", err)
+		}
+		v.verifier = provider.Verifier(v.config)
+	}
+	verifier := v.verifier
+	v.mu.Unlock()
+
+	return verifier.Verify(ctx, token)
+}
+
+// claimsUser maps the claims like the claim mappings of the authenticator of
+// the API server.
+func (v *skewVerifier) claimsUser(claims map[string]json.RawMessage) (user.Info, error) {
+	var name string
+	for _, claim := range usernameClaims(v.config) {
+		raw, ok := claims[claim]
+		if !ok {
+			continue
+		}
+		if err := json.Unmarshal(raw, &name); err != nil {
+			return nil, fmt.Errorf("oidc: parse username claim %q: %w", claim, err)
+		}
+		if claim == "email" {
+			if raw, ok := claims["email_verified"]; ok {
+				var verified bool
+				if err := json.Unmarshal(raw, &verified); err != nil {
+					return nil, fmt.Errorf("oidc: parse 'email_verified' claim: %w", err)
+				}
+				if !verified {
+					return nil, errors.New("oidc: email not verified")
+				}
+			}
+		}
+		break
+	}
+	if name == "" {
+		return nil, fmt.Errorf("oidc: none of the username claims %v present", usernameClaims(v.config))
+	}
+
+	var groups []string
+	if raw, ok := claims[v.config.GroupsClaim]; ok && v.config.GroupsClaim != "" {
+		// The groups claim is either a list or a single group.
+		if err := json.Unmarshal(raw, &groups); err != nil {
+			var group string
+			if err := json.Unmarshal(raw, &group); err != nil {
+				return nil, fmt.Errorf("oidc: parse groups claim %q: %w", v.config.GroupsClaim, err)
+			}
+			groups = []string{group}
+		}
+	}
+	for i := range groups {
+		groups[i] = v.config.GroupsPrefix + groups[i]
+	}
+
+	return &user.DefaultInfo{
+		Name:   v.config.UsernamePrefix + name,
+		Groups: groups,
+	}, nil
+}
+
+func unixTime(seconds float64) time.Time {
+	if seconds == 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(seconds), 0)
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authn
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWithinSkew(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	token := func(payload string) string {
+		return "e30." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".sig"
+	}
+	v := &skewVerifier{config: &OIDCConfig{ClockSkew: 5 * time.Minute}}
+
+	for _, tt := range []struct {
+		name  string
+		token string
+		want  bool
+	}{
+		{name: "valid", token: token(fmt.Sprintf(`{"exp":%d}`, now.Unix()+60))},
+		{name: "expired within the skew", token: token(fmt.Sprintf(`{"exp":%d}`, now.Unix()-60)), want: true},
+		{name: "expired beyond the skew", token: token(fmt.Sprintf(`{"exp":%d}`, now.Unix()-600))},
+		{name: "not yet valid within the skew", token: token(fmt.Sprintf(`{"exp":%d,"nbf":%d}`, now.Unix()+3600, now.Unix()+180)), want: true},
+		{name: "not yet valid within the leeway", token: token(fmt.Sprintf(`{"exp":%d,"nbf":%d}`, now.Unix()+3600, now.Unix()+30))},
+		{name: "not yet valid beyond the skew", token: token(fmt.Sprintf(`{"exp":%d,"nbf":%d}`, now.Unix()+3600, now.Unix()+600))},
+		{name: "no expiry", token: token(`{"sub":"client"}`)},
+		{name: "malformed token", token: "not-a-jwt"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := v.withinSkew(tt.token, now); got != tt.want {
+				t.Errorf("want %t, got %t", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestClaimsUser(t *testing.T) {
+	config := &OIDCConfig{
+		UsernameClaim:          "email",
+		UsernamePrefix:         "oidc:",
+		UsernameFallbackClaims: []string{"azp", "appid"},
+		GroupsClaim:            "groups",
+		GroupsPrefix:           "oidc:",
+	}
+
+	for _, tt := range []struct {
+		name       string
+		claims     string
+		wantName   string
+		wantGroups []string
+		wantErr    bool
+	}{
+		{
+			name:       "username claim",
+			claims:     `{"email":"jane@example.com","azp":"app","groups":["admins"]}`,
+			wantName:   "oidc:jane@example.com",
+			wantGroups: []string{"oidc:admins"},
+		},
+		{
+			name:       "first fallback claim of an Azure AD v2 token",
+			claims:     `{"azp":"11111111-2222","groups":"scrapers"}`,
+			wantName:   "oidc:11111111-2222",
+			wantGroups: []string{"oidc:scrapers"},
+		},
+		{
+			name:     "second fallback claim of an Azure AD v1 token",
+			claims:   `{"appid":"11111111-2222"}`,
+			wantName: "oidc:11111111-2222",
+		},
+		{
+			name:    "unverified email",
+			claims:  `{"email":"jane@example.com","email_verified":false}`,
+			wantErr: true,
+		},
+		{
+			name:    "no username claim",
+			claims:  `{"sub":"client"}`,
+			wantErr: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := map[string]json.RawMessage{}
+			if err := json.Unmarshal([]byte(tt.claims), &claims); err != nil {
+				t.Fatal(err)
+			}
+
+			u, err := (&skewVerifier{config: config}).claimsUser(claims)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("want error, got user %v", u)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if u.GetName() != tt.wantName {
+				t.Errorf("want name %q, got %q", tt.wantName, u.GetName())
+			}
+			if !reflect.DeepEqual(u.GetGroups(), tt.wantGroups) {
+				t.Errorf("want groups %v, got %v", tt.wantGroups, u.GetGroups())
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authn
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/authentication/request/bearertoken"
+	tokencache "k8s.io/apiserver/pkg/authentication/token/cache"
+	tokenunion "k8s.io/apiserver/pkg/authentication/token/union"
+	"k8s.io/apiserver/pkg/authentication/user"
+)
+
+// cloudCacheTTL is the duration successfully authenticated cloud tokens are
+// cached, as authenticating them takes a request to the cloud provider.
+const cloudCacheTTL = 2 * time.Minute
+
+// IdentityMapping maps a cloud identity to a user. Identities without a
+// mapping are authenticated with the identity as username and no groups.
+type IdentityMapping struct {
+	// Identity is the email of a GCP service account or the ARN of an AWS
+	// IAM user or role. Sessions of an assumed role have the ARN of the role.
+	Identity string `json:"identity"`
+	// Username replaces the identity as username, if set.
+	Username string `json:"username,omitempty"`
+	// Groups are the groups of the user.
+	Groups []string `json:"groups,omitempty"`
+}
+
+// NewCloudAuthenticator returns an authenticator of the cloud identities
+// enabled in the config, or nil if none are enabled. Tokens of other formats
+// aren't authenticated, such that the authenticator can be combined with
+// others.
+func NewCloudAuthenticator(ctx context.Context, config *CloudConfig) authenticator.Request {
+	client := &http.Client{
+		Transport: utilnet.SetTransportDefaults(&http.Transport{Proxy: config.Proxy}),
+		Timeout:   30 * time.Second,
+	}
+
+	var tokenAuthenticators []authenticator.Token
+	if config.GCPAudience != "" {
+		tokenAuthenticators = append(tokenAuthenticators, newGCPAuthenticator(ctx, client, config))
+	}
+	if config.AWSClusterID != "" {
+		tokenAuthenticators = append(tokenAuthenticators, newAWSAuthenticator(client, config))
+	}
+	if len(tokenAuthenticators) == 0 {
+		return nil
+	}
+
+	return bearertoken.New(tokencache.New(tokenunion.New(tokenAuthenticators...), false, cloudCacheTTL, 0))
+}
+
+// mapIdentity returns the user of the cloud identity.
+func mapIdentity(mappings []IdentityMapping, identity, uid string) user.Info {
+	u := &user.DefaultInfo{Name: identity, UID: uid}
+	for _, m := range mappings {
+		if m.Identity != identity {
+			continue
+		}
+		if m.Username != "" {
+			u.Name = m.Username
+		}
+		u.Groups = m.Groups
+		break
+	}
+	return u
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authn
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestMapIdentity(t *testing.T) {
+	mappings := []IdentityMapping{
+		{Identity: "scraper@project.iam.gserviceaccount.com", Groups: []string{"monitoring"}},
+		{Identity: "arn:aws:iam::123456789012:role/scraper", Username: "aws-scraper"},
+	}
+
+	u := mapIdentity(mappings, "scraper@project.iam.gserviceaccount.com", "1234")
+	if u.GetName() != "scraper@project.iam.gserviceaccount.com" || u.GetUID() != "1234" || len(u.GetGroups()) != 1 {
+		t.Errorf("want the identity as username and the mapped groups, got %v", u)
+	}
+
+	u = mapIdentity(mappings, "arn:aws:iam::123456789012:role/scraper", "")
+	if u.GetName() != "aws-scraper" || len(u.GetGroups()) != 0 {
+		t.Errorf("want the mapped username, got %v", u)
+	}
+
+	u = mapIdentity(mappings, "other@project.iam.gserviceaccount.com", "")
+	if u.GetName() != "other@project.iam.gserviceaccount.com" || len(u.GetGroups()) != 0 {
+		t.Errorf("want the identity as username and no groups, got %v", u)
+	}
+}
+
+func TestIsGoogleToken(t *testing.T) {
+	token := func(payload string) string {
+		return "e30." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".sig"
+	}
+
+	for _, tt := range []struct {
+		name  string
+		token string
+		want  bool
+	}{
+		{name: "google issuer", token: token(`{"iss":"https://accounts.google.com"}`), want: true},
+		{name: "google issuer without scheme", token: token(`{"iss":"accounts.google.com"}`), want: true},
+		{name: "other issuer", token: token(`{"iss":"https://issuer.example.com"}`)},
+		{name: "malformed token", token: "k8s-aws-v1.aHR0cHM6Ly9zdHMuYW1hem9uYXdzLmNvbS8"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isGoogleToken(tt.token); got != tt.want {
+				t.Errorf("want %t, got %t", tt.want, got)
+			}
+		})
+	}
+}
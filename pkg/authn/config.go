@@ -16,6 +16,12 @@ limitations under the License.
 
 package authn
 
+import (
+	"net/http"
+	"net/url"
+	"time"
+)
+
 // AuthnHeaderConfig contains authentication header settings which enable more information about the user identity to be sent to the upstream
 type AuthnHeaderConfig struct {
 	// When set to true, kube-rbac-proxy adds auth-related fields to the headers of http requests sent to the upstream
@@ -28,14 +34,48 @@ type AuthnHeaderConfig struct {
 	GroupsFieldName string
 	// The separator string used for concatenating multiple group names in a groups header field's value
 	GroupSeparator string
+	// ClientFields is how requests are handled, whose clients already sent
+	// the header fields, one of "strip" or "reject". By default the fields
+	// are only overwritten for authenticated requests.
+	ClientFields string
+	// GroupsMaxValueSize splits the groups across several values of the
+	// groups header field, each of at most this size in bytes, unless a
+	// single group is larger. Zero joins all groups into one value.
+	GroupsMaxValueSize int
+	// GroupsMaxSize caps the total size in bytes of the groups header
+	// field values, zero is unlimited.
+	GroupsMaxSize int
+	// GroupsOverflow is how requests are handled, whose groups exceed
+	// GroupsMaxSize, one of "reject" or "truncate".
+	GroupsOverflow string
 }
 
+const (
+	// ClientHeaderFieldsStrip removes the header fields sent by clients.
+	ClientHeaderFieldsStrip = "strip"
+	// ClientHeaderFieldsReject rejects requests with header fields sent by
+	// clients.
+	ClientHeaderFieldsReject = "reject"
+)
+
+const (
+	// GroupsOverflowReject rejects requests whose groups exceed the size.
+	GroupsOverflowReject = "reject"
+	// GroupsOverflowTruncate passes on the groups that fit into the size.
+	GroupsOverflowTruncate = "truncate"
+)
+
 // AuthnConfig holds all configurations related to authentication options
 type AuthnConfig struct {
 	X509   *X509Config
 	Header *AuthnHeaderConfig
 	OIDC   *OIDCConfig
 	Token  *TokenConfig
+	LDAP   *LDAPConfig
+	Cloud  *CloudConfig
+	// Impersonation enables the Impersonate-* headers, e.g. of a front
+	// proxy.
+	Impersonation bool
 }
 
 // X509Config holds public client certificate used for authentication requests if specified
@@ -43,11 +83,52 @@ type X509Config struct {
 	ClientCAFile              string
 	UpstreamClientCertificate string
 	UpstreamClientKey         string
+	// ConnectionCacheTTL is the duration the user of a verified client
+	// certificate is cached per TLS connection. Caching is disabled, if 0.
+	ConnectionCacheTTL time.Duration
+	// CRLFile is a CRL, which revokes client certificates.
+	CRLFile string
+	// OCSPCheck enables querying the OCSP responder of client certificates.
+	OCSPCheck bool
+	// IntermediatesFile holds intermediates, which complete the chains
+	// presented by clients.
+	IntermediatesFile string
+	// AIAHosts are the hosts, from which missing intermediates are fetched
+	// by the AIA URLs of client certificates. Fetching is disabled, if empty.
+	AIAHosts []string
+}
+
+// LDAPConfig holds configuration for resolving additional groups of
+// authenticated users in LDAP
+type LDAPConfig struct {
+	URL              string
+	BindDN           string
+	BindPasswordFile string
+	CAFile           string
+	BaseDN           string
+	GroupFilter      string
+	GroupAttribute   string
+	Timeout          time.Duration
+	CacheTTL         time.Duration
 }
 
 // TokenConfig holds configuration as to how token authentication is to be done
 type TokenConfig struct {
 	Audiences []string
+	// ServiceAccountNamespaces restricts service account tokens to these
+	// namespaces, if set.
+	ServiceAccountNamespaces []string
+	// AuthFile is a CSV file of static bearer tokens, see
+	// TokenFileAuthenticator.
+	AuthFile string
+	// CacheFile persists the users of reviewed tokens, see
+	// PersistentTokenCache.
+	CacheFile string
+	// CacheKeyFile holds the secret the CacheFile is encrypted with.
+	CacheKeyFile string
+	// CacheMaxAge is how long after its last successful review a token
+	// authenticates from the CacheFile.
+	CacheMaxAge time.Duration
 }
 
 // OIDCConfig represents configuration used for JWT request authentication
@@ -60,4 +141,53 @@ type OIDCConfig struct {
 	GroupsClaim          string
 	GroupsPrefix         string
 	SupportedSigningAlgs []string
+	// UsernameFallbackClaims are the claims the username is taken from, in
+	// order, if the UsernameClaim is missing, e.g. azp and appid for the
+	// access tokens Azure AD issues to applications.
+	UsernameFallbackClaims []string
+	// ClockSkew is the tolerated difference between the clocks of the issuer
+	// and the proxy, when checking the expiry and not-before time of tokens.
+	ClockSkew time.Duration
+	// LoginRedirectURL enables the browser login with the authorization
+	// code flow, it is the URL of the proxy the issuer redirects browsers to.
+	LoginRedirectURL string
+	// LoginClientSecretFile holds the client secret of the ClientID.
+	LoginClientSecretFile string
+	// LoginCookieSecretFile holds the secret of at least 32 bytes the
+	// session cookies are encrypted with.
+	LoginCookieSecretFile string
+	// LoginSessionTTL is the lifetime of the session cookies.
+	LoginSessionTTL time.Duration
+	// LoginScopes are the scopes requested with the browser login.
+	LoginScopes []string
+	// LoginSessionStore is where the sessions of the browser login are
+	// kept, one of "cookie", "memory" or "redis".
+	LoginSessionStore string
+	// LoginRedisAddress is the host:port of the Redis session store.
+	LoginRedisAddress string
+	// LoginRedisPasswordFile holds the password of the Redis session store.
+	LoginRedisPasswordFile string
+	// LoginRedisCAFile enables TLS to the Redis session store, verifying
+	// its certificate with the CA.
+	LoginRedisCAFile string
+	// Proxy is the proxy used to reach the issuer. If nil, the proxy
+	// environment variables are honored.
+	Proxy func(*http.Request) (*url.URL, error) `json:"-"`
+}
+
+// CloudConfig holds configuration for authenticating the identities of cloud
+// providers
+type CloudConfig struct {
+	// GCPAudience enables Google-signed identity tokens, e.g. of GCP service
+	// accounts, issued for this audience.
+	GCPAudience string
+	// AWSClusterID enables tokens presigning an STS GetCallerIdentity request
+	// for this cluster ID, as generated by aws-iam-authenticator or
+	// "aws eks get-token".
+	AWSClusterID string
+	// IdentityMappings map cloud identities to users.
+	IdentityMappings []IdentityMapping
+	// Proxy is the proxy used to reach the cloud providers. If nil, the
+	// proxy environment variables are honored.
+	Proxy func(*http.Request) (*url.URL, error) `json:"-"`
 }
@@ -19,7 +19,9 @@ package authn
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"os"
 	"time"
 
 	"k8s.io/apiserver/pkg/authentication/authenticator"
@@ -27,11 +29,18 @@ import (
 	"k8s.io/apiserver/pkg/server/dynamiccertificates"
 	"k8s.io/apiserver/pkg/server/options"
 	authenticationclient "k8s.io/client-go/kubernetes/typed/authentication/v1"
+	"k8s.io/client-go/util/cert"
+	"k8s.io/klog/v2"
 )
 
 type DelegatingAuthenticator struct {
-	dynamicClientCA      *dynamiccertificates.DynamicFileCAContent
-	requestAuthenticator authenticator.Request
+	clientCAFile          string
+	dynamicClientCA       *dynamiccertificates.DynamicFileCAContent
+	connCertAuthenticator *connCertAuthenticator
+	requestAuthenticator  authenticator.Request
+	// uncachedAuthenticator creates a TokenReview for every request, if the
+	// caches are bypassed.
+	uncachedAuthenticator authenticator.Request
 }
 
 var (
@@ -71,10 +80,36 @@ func NewDelegatingAuthenticator(client authenticationclient.AuthenticationV1Inte
 		return nil, err
 	}
 
-	return &DelegatingAuthenticator{requestAuthenticator: authenticator, dynamicClientCA: p}, nil
+	authenticatorConfig.CacheTTL = 0
+	uncachedAuthenticator, _, err := authenticatorConfig.New()
+	if err != nil {
+		return nil, err
+	}
+
+	delegating := &DelegatingAuthenticator{
+		requestAuthenticator:  authenticator,
+		uncachedAuthenticator: uncachedAuthenticator,
+		clientCAFile:          authn.X509.ClientCAFile,
+		dynamicClientCA:       p,
+	}
+	if p != nil && authn.X509.ConnectionCacheTTL > 0 {
+		delegating.connCertAuthenticator = newConnCertAuthenticator(p, authn.X509.ConnectionCacheTTL)
+	}
+
+	return delegating, nil
 }
 
 func (a *DelegatingAuthenticator) AuthenticateRequest(req *http.Request) (*authenticator.Response, bool, error) {
+	if CacheBypassed(req.Context()) {
+		return a.uncachedAuthenticator.AuthenticateRequest(req)
+	}
+
+	if a.connCertAuthenticator != nil {
+		if resp, ok, err := a.connCertAuthenticator.AuthenticateRequest(req); err == nil && ok {
+			return resp, true, nil
+		}
+	}
+
 	return a.requestAuthenticator.AuthenticateRequest(req)
 }
 
@@ -83,3 +118,26 @@ func (a *DelegatingAuthenticator) Run(ctx context.Context) {
 		a.dynamicClientCA.Run(ctx, 1)
 	}
 }
+
+// PrepareReload parses the client CA file, the returned function reloads it.
+// The file is reloaded on changes anyway, the explicit reload allows
+// reloading several inputs all or nothing.
+func (a *DelegatingAuthenticator) PrepareReload(ctx context.Context) (func(), error) {
+	if a.dynamicClientCA == nil {
+		return func() {}, nil
+	}
+
+	pem, err := os.ReadFile(a.clientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client CA file: %w", err)
+	}
+	if _, err := cert.NewPoolFromBytes(pem); err != nil {
+		return nil, fmt.Errorf("failed to parse client CA file: %w", err)
+	}
+
+	return func() {
+		if err := a.dynamicClientCA.RunOnce(ctx); err != nil {
+			klog.ErrorS(err, "Failed to reload client CA file", "path", a.clientCAFile)
+		}
+	}, nil
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authn
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	gooidc "github.com/coreos/go-oidc"
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+)
+
+// googleIssuer issues the identity tokens of GCP service accounts and
+// workloads. Tokens may have the issuer without scheme, too.
+const googleIssuer = "https://accounts.google.com"
+
+// gcpAuthenticator authenticates Google-signed identity tokens with the email
+// of the service account as identity.
+type gcpAuthenticator struct {
+	*lazyVerifier
+	mappings []IdentityMapping
+}
+
+func newGCPAuthenticator(ctx context.Context, client *http.Client, config *CloudConfig) *gcpAuthenticator {
+	return &gcpAuthenticator{
+		lazyVerifier: newLazyVerifier(ctx, client, googleIssuer, &gooidc.Config{
+			ClientID:             config.GCPAudience,
+			SupportedSigningAlgs: []string{gooidc.RS256},
+		}),
+		mappings: config.IdentityMappings,
+	}
+}
+
+func (a *gcpAuthenticator) AuthenticateToken(ctx context.Context, token string) (*authenticator.Response, bool, error) {
+	if !isGoogleToken(token) {
+		return nil, false, nil
+	}
+
+	idToken, err := a.verify(ctx, token)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var claims struct {
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, false, err
+	}
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, false, errors.New("gcp: identity token has no verified email, it must be requested in the full format")
+	}
+
+	return &authenticator.Response{User: mapIdentity(a.mappings, claims.Email, idToken.Subject)}, true, nil
+}
+
+// isGoogleToken tells from the unverified issuer of the token, whether it is
+// a Google-signed identity token.
+func isGoogleToken(token string) bool {
+	payload, ok := tokenPayload(token)
+	if !ok {
+		return false
+	}
+
+	var claims struct {
+		Issuer string `json:"iss"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return false
+	}
+	return claims.Issuer == googleIssuer || "https://"+claims.Issuer == googleIssuer
+}
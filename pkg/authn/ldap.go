@@ -0,0 +1,176 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authn
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+	"k8s.io/apimachinery/pkg/util/cache"
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/authentication/user"
+	certutil "k8s.io/client-go/util/cert"
+	"k8s.io/klog/v2"
+)
+
+const (
+	ldapCacheSize = 4096
+
+	// LDAPUsernamePlaceholder is replaced by the escaped user name in the
+	// LDAP group filter.
+	LDAPUsernamePlaceholder = "{username}"
+)
+
+// LDAPGroupResolver looks up the groups of a user in LDAP.
+type LDAPGroupResolver struct {
+	config       *LDAPConfig
+	bindPassword string
+	tlsConfig    *tls.Config
+	cache        *cache.LRUExpireCache
+}
+
+// NewLDAPGroupResolver creates an LDAPGroupResolver. The bind password is
+// read once from the configured file.
+func NewLDAPGroupResolver(config *LDAPConfig) (*LDAPGroupResolver, error) {
+	if !strings.Contains(config.GroupFilter, LDAPUsernamePlaceholder) {
+		return nil, fmt.Errorf("LDAP group filter must contain %s", LDAPUsernamePlaceholder)
+	}
+
+	r := &LDAPGroupResolver{
+		config: config,
+		cache:  cache.NewLRUExpireCache(ldapCacheSize),
+	}
+
+	if config.BindPasswordFile != "" {
+		password, err := os.ReadFile(config.BindPasswordFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read LDAP bind password: %w", err)
+		}
+		r.bindPassword = strings.TrimSpace(string(password))
+	}
+
+	if config.CAFile != "" {
+		pool, err := certutil.NewPool(config.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load LDAP CA: %w", err)
+		}
+		r.tlsConfig = &tls.Config{RootCAs: pool}
+	}
+
+	return r, nil
+}
+
+// Groups returns the groups of the user, cached for the configured TTL.
+func (r *LDAPGroupResolver) Groups(username string) ([]string, error) {
+	if groups, ok := r.cache.Get(username); ok {
+		return groups.([]string), nil
+	}
+
+	groups, err := r.search(username)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.config.CacheTTL > 0 {
+		r.cache.Add(username, groups, r.config.CacheTTL)
+	}
+
+	return groups, nil
+}
+
+func (r *LDAPGroupResolver) search(username string) ([]string, error) {
+	opts := []ldap.DialOpt{ldap.DialWithDialer(&net.Dialer{Timeout: r.config.Timeout})}
+	if r.tlsConfig != nil {
+		opts = append(opts, ldap.DialWithTLSConfig(r.tlsConfig))
+	}
+
+	conn, err := ldap.DialURL(r.config.URL, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to LDAP: %w", err)
+	}
+	defer conn.Close()
+	conn.SetTimeout(r.config.Timeout)
+
+	if r.config.BindDN != "" {
+		if err := conn.Bind(r.config.BindDN, r.bindPassword); err != nil {
+			return nil, fmt.Errorf("failed to bind to LDAP: %w", err)
+		}
+	}
+
+	filter := strings.ReplaceAll(r.config.GroupFilter, LDAPUsernamePlaceholder, ldap.EscapeFilter(username))
+	res, err := conn.Search(ldap.NewSearchRequest(
+		r.config.BaseDN,
+		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, int(r.config.Timeout.Seconds()), false,
+		filter,
+		[]string{r.config.GroupAttribute},
+		nil,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to search LDAP groups: %w", err)
+	}
+
+	groups := make([]string, 0, len(res.Entries))
+	for _, entry := range res.Entries {
+		groups = append(groups, entry.GetAttributeValues(r.config.GroupAttribute)...)
+	}
+
+	return groups, nil
+}
+
+// WithLDAPGroups adds the LDAP groups of authenticated users to their
+// groups. If the lookup fails, the user keeps the groups of the original
+// authentication, which can only grant less access.
+func WithLDAPGroups(auth authenticator.Request, resolver *LDAPGroupResolver) authenticator.Request {
+	return authenticator.RequestFunc(func(req *http.Request) (*authenticator.Response, bool, error) {
+		resp, ok, err := auth.AuthenticateRequest(req)
+		if !ok || err != nil {
+			return resp, ok, err
+		}
+
+		ldapGroups, err := resolver.Groups(resp.User.GetName())
+		if err != nil {
+			klog.FromContext(req.Context()).Error(err, "Failed to resolve LDAP groups", "user", resp.User.GetName())
+			return resp, true, nil
+		}
+
+		groups := append([]string{}, resp.User.GetGroups()...)
+		seen := make(map[string]struct{}, len(groups))
+		for _, g := range groups {
+			seen[g] = struct{}{}
+		}
+		for _, g := range ldapGroups {
+			if _, ok := seen[g]; !ok {
+				seen[g] = struct{}{}
+				groups = append(groups, g)
+			}
+		}
+
+		resp.User = &user.DefaultInfo{
+			Name:   resp.User.GetName(),
+			UID:    resp.User.GetUID(),
+			Groups: groups,
+			Extra:  resp.User.GetExtra(),
+		}
+
+		return resp, true, nil
+	})
+}
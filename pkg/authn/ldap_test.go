@@ -0,0 +1,75 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authn
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/authentication/user"
+)
+
+func TestWithLDAPGroups(t *testing.T) {
+	resolver, err := NewLDAPGroupResolver(&LDAPConfig{
+		// Nothing listens on port 1, uncached lookups fail.
+		URL:            "ldap://127.0.0.1:1",
+		GroupFilter:    "(member=uid={username})",
+		GroupAttribute: "cn",
+		Timeout:        time.Second,
+		CacheTTL:       time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolver.cache.Add("alice", []string{"admins", "system:authenticated"}, time.Minute)
+
+	auth := WithLDAPGroups(authenticatorFunc(func(req *http.Request) (*authenticator.Response, bool, error) {
+		return &authenticator.Response{User: &user.DefaultInfo{
+			Name:   req.Header.Get("X-User"),
+			Groups: []string{"system:authenticated"},
+		}}, true, nil
+	}), resolver)
+
+	for _, tt := range []struct {
+		name string
+		want []string
+	}{
+		{name: "alice", want: []string{"system:authenticated", "admins"}},
+		{name: "bob", want: []string{"system:authenticated"}},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-User", tt.name)
+
+		resp, ok, err := auth.AuthenticateRequest(req)
+		if !ok || err != nil {
+			t.Fatalf("%s: want authenticated, got %t, %v", tt.name, ok, err)
+		}
+		if got := resp.User.GetGroups(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: want groups %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestNewLDAPGroupResolverFilter(t *testing.T) {
+	if _, err := NewLDAPGroupResolver(&LDAPConfig{GroupFilter: "(member=uid=alice)"}); err == nil {
+		t.Error("want error for filter without username placeholder")
+	}
+}
@@ -18,18 +18,39 @@ package authn
 
 import (
 	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	utilnet "k8s.io/apimachinery/pkg/util/net"
 	"k8s.io/apiserver/pkg/apis/apiserver"
 	"k8s.io/apiserver/pkg/authentication/authenticator"
 	"k8s.io/apiserver/pkg/authentication/request/bearertoken"
+	"k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/apiserver/pkg/server/dynamiccertificates"
 	"k8s.io/apiserver/plugin/pkg/authenticator/token/oidc"
+	certutil "k8s.io/client-go/util/cert"
 )
 
+// ScopesExtraKey is the key of the user's extra info holding the OAuth2
+// scopes of a validated OIDC token.
+const ScopesExtraKey = "scopes"
+
 type OIDCAuthenticator struct {
 	dynamicClientCA      *dynamiccertificates.DynamicFileCAContent
 	requestAuthenticator authenticator.Request
+	healthCheck          func() error
+	// skewVerifier verifies the tokens rejected for their expiry or
+	// not-before time, if a clock skew is tolerated.
+	skewVerifier *skewVerifier
 }
 
 var (
@@ -43,38 +64,233 @@ func NewOIDCAuthenticator(ctx context.Context, config *OIDCConfig) (*OIDCAuthent
 		return nil, err
 	}
 
-	tokenAuthenticator, err := oidc.New(ctx, oidc.Options{
+	opts := oidc.Options{
 		JWTAuthenticator: apiserver.JWTAuthenticator{
 			Issuer: apiserver.Issuer{
 				URL:       config.IssuerURL,
 				Audiences: []string{config.ClientID},
 			},
 			ClaimMappings: apiserver.ClaimMappings{
-				Username: apiserver.PrefixedClaimOrExpression{
-					Prefix: &config.UsernamePrefix,
-					Claim:  config.UsernameClaim,
-				},
+				Username: usernameMapping(config),
 				Groups: apiserver.PrefixedClaimOrExpression{
 					Prefix: &config.GroupsPrefix,
 					Claim:  config.GroupsClaim,
 				},
 			},
+			ClaimValidationRules: emailVerifiedRules(config),
 		},
 		CAContentProvider:    dyCA,
 		SupportedSigningAlgs: config.SupportedSigningAlgs,
-	})
+	}
+
+	// The client is mutually exclusive with the CA content provider, the
+	// default client of the authenticator can't be configured with a proxy.
+	if config.Proxy != nil {
+		client, err := newOIDCClient(dyCA, config.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		opts.CAContentProvider = nil
+		opts.Client = client
+	}
+
+	tokenAuthenticator, err := oidc.New(ctx, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	return &OIDCAuthenticator{
+	o := &OIDCAuthenticator{
 		dynamicClientCA:      dyCA,
 		requestAuthenticator: bearertoken.New(tokenAuthenticator),
+		healthCheck:          tokenAuthenticator.HealthCheck,
+	}
+
+	if config.ClockSkew > 0 {
+		client, err := newOIDCClient(dyCA, config.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		o.skewVerifier = newSkewVerifier(ctx, config, client)
+	}
+
+	return o, nil
+}
+
+func newOIDCClient(dyCA *dynamiccertificates.DynamicFileCAContent, proxy func(*http.Request) (*url.URL, error)) (*http.Client, error) {
+	roots, err := certutil.NewPoolFromBytes(dyCA.CurrentCABundleContent())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the OIDC CA: %w", err)
+	}
+
+	return &http.Client{
+		Transport: utilnet.SetTransportDefaults(&http.Transport{
+			Proxy:           proxy,
+			TLSClientConfig: &tls.Config{RootCAs: roots},
+		}),
+		Timeout: 30 * time.Second,
 	}, nil
 }
 
+// usernameClaims returns the claims the username is taken from, in order.
+func usernameClaims(config *OIDCConfig) []string {
+	return append([]string{config.UsernameClaim}, config.UsernameFallbackClaims...)
+}
+
+// usernameMapping maps the first present of the username claims to the
+// username. Fallback claims require a CEL expression, as a claim mapping
+// takes a single claim.
+func usernameMapping(config *OIDCConfig) apiserver.PrefixedClaimOrExpression {
+	if len(config.UsernameFallbackClaims) == 0 {
+		return apiserver.PrefixedClaimOrExpression{
+			Prefix: &config.UsernamePrefix,
+			Claim:  config.UsernameClaim,
+		}
+	}
+
+	// The last claim isn't checked for presence, such that tokens with none
+	// of the claims fail the evaluation.
+	claims := usernameClaims(config)
+	expression := celClaim(claims[len(claims)-1])
+	for i := len(claims) - 2; i >= 0; i-- {
+		expression = fmt.Sprintf("%s ? %s : %s", celHasClaim(claims[i]), celClaim(claims[i]), expression)
+	}
+	if config.UsernamePrefix != "" {
+		expression = fmt.Sprintf("%s + (%s)", strconv.Quote(config.UsernamePrefix), expression)
+	}
+
+	return apiserver.PrefixedClaimOrExpression{Expression: expression}
+}
+
+// emailVerifiedRules reject tokens with an unverified email, if the email
+// claim is mapped with an expression. The API server checks the
+// email_verified claim itself, if the email claim is mapped directly.
+func emailVerifiedRules(config *OIDCConfig) []apiserver.ClaimValidationRule {
+	if len(config.UsernameFallbackClaims) == 0 || !slices.Contains(usernameClaims(config), "email") {
+		return nil
+	}
+
+	return []apiserver.ClaimValidationRule{{
+		Expression: "!has(claims.email) || !has(claims.email_verified) || claims.email_verified == true",
+		Message:    "email not verified",
+	}}
+}
+
+var celIdentifier = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+func celClaim(claim string) string {
+	if celIdentifier.MatchString(claim) {
+		return "claims." + claim
+	}
+	return fmt.Sprintf("claims[%s]", strconv.Quote(claim))
+}
+
+func celHasClaim(claim string) string {
+	if celIdentifier.MatchString(claim) {
+		return fmt.Sprintf("has(claims.%s)", claim)
+	}
+	return fmt.Sprintf("%s in claims", strconv.Quote(claim))
+}
+
 func (o *OIDCAuthenticator) AuthenticateRequest(req *http.Request) (*authenticator.Response, bool, error) {
-	return o.requestAuthenticator.AuthenticateRequest(req)
+	token := strings.TrimSpace(strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer "))
+
+	resp, ok, err := o.requestAuthenticator.AuthenticateRequest(req)
+	if (!ok || err != nil) && o.skewVerifier != nil && o.skewVerifier.withinSkew(token, time.Now()) {
+		resp, ok, err = o.skewVerifier.authenticate(req.Context(), token)
+	}
+	if !ok || err != nil {
+		return resp, ok, err
+	}
+
+	if tokenExtra := tokenExtra(token); len(tokenExtra) > 0 {
+		extra := map[string][]string{}
+		for k, v := range resp.User.GetExtra() {
+			extra[k] = v
+		}
+		for k, v := range tokenExtra {
+			extra[k] = v
+		}
+
+		resp.User = &user.DefaultInfo{
+			Name:   resp.User.GetName(),
+			UID:    resp.User.GetUID(),
+			Groups: resp.User.GetGroups(),
+			Extra:  extra,
+		}
+	}
+
+	return resp, true, nil
+}
+
+// tokenExtra returns the extra info of the user derived from the claims of a
+// JWT, the scopes and, for bound service account tokens, the pod, node and
+// credential ID like the API server sets them. They are sent along with
+// SubjectAccessReviews, e.g. for webhooks deciding by the identity of the
+// pod. The token must have been validated before.
+func tokenExtra(token string) map[string][]string {
+	extra := map[string][]string{}
+	if scopes := tokenScopes(token); len(scopes) > 0 {
+		extra[ScopesExtraKey] = scopes
+	}
+	if claims, ok := tokenServiceAccountClaims(token); ok {
+		for k, v := range claims.extra() {
+			extra[k] = v
+		}
+	}
+	return extra
+}
+
+// tokenScopes returns the scopes of a JWT, either from the space-delimited
+// "scope" claim of RFC 8693 or from the "scp" claim used by some providers.
+// The token must have been validated before.
+func tokenScopes(token string) []string {
+	payload, ok := tokenPayload(token)
+	if !ok {
+		return nil
+	}
+
+	var claims struct {
+		Scope string          `json:"scope"`
+		Scp   json.RawMessage `json:"scp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil
+	}
+
+	if claims.Scope != "" {
+		return strings.Fields(claims.Scope)
+	}
+
+	var scp []string
+	if err := json.Unmarshal(claims.Scp, &scp); err == nil {
+		return scp
+	}
+	var scpString string
+	if err := json.Unmarshal(claims.Scp, &scpString); err == nil {
+		return strings.Fields(scpString)
+	}
+
+	return nil
+}
+
+// tokenPayload returns the decoded, unverified payload of a JWT.
+func tokenPayload(token string) ([]byte, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, false
+	}
+	return payload, true
+}
+
+// HealthCheck returns nil once the issuer was discovered and its keys were
+// fetched, the error of the discovery otherwise.
+func (o *OIDCAuthenticator) HealthCheck(_ context.Context) error {
+	return o.healthCheck()
 }
 
 func (o *OIDCAuthenticator) Run(ctx context.Context) {
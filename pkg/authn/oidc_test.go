@@ -0,0 +1,143 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authn
+
+import (
+	"encoding/base64"
+	"reflect"
+	"testing"
+
+	"k8s.io/apiserver/pkg/apis/apiserver"
+	apiservervalidation "k8s.io/apiserver/pkg/apis/apiserver/validation"
+)
+
+func TestTokenScopes(t *testing.T) {
+	token := func(payload string) string {
+		return "e30." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".sig"
+	}
+
+	for _, tt := range []struct {
+		name  string
+		token string
+		want  []string
+	}{
+		{name: "scope claim", token: token(`{"scope":"openid metrics:read"}`), want: []string{"openid", "metrics:read"}},
+		{name: "scp array claim", token: token(`{"scp":["openid","metrics:read"]}`), want: []string{"openid", "metrics:read"}},
+		{name: "scp string claim", token: token(`{"scp":"metrics:read"}`), want: []string{"metrics:read"}},
+		{name: "no scopes", token: token(`{"sub":"client"}`)},
+		{name: "malformed token", token: "not-a-jwt"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tokenScopes(tt.token); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("want %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestTokenExtra(t *testing.T) {
+	token := func(payload string) string {
+		return "e30." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".sig"
+	}
+
+	for _, tt := range []struct {
+		name  string
+		token string
+		want  map[string][]string
+	}{
+		{
+			name:  "bound to pod",
+			token: token(`{"jti":"1234","kubernetes.io":{"namespace":"monitoring","pod":{"name":"scraper-0","uid":"5678"},"node":{"name":"node-1","uid":"9abc"}}}`),
+			want: map[string][]string{
+				"authentication.kubernetes.io/credential-id": {"JTI=1234"},
+				"authentication.kubernetes.io/pod-name":      {"scraper-0"},
+				"authentication.kubernetes.io/pod-uid":       {"5678"},
+				"authentication.kubernetes.io/node-name":     {"node-1"},
+				"authentication.kubernetes.io/node-uid":      {"9abc"},
+			},
+		},
+		{
+			name:  "with scopes",
+			token: token(`{"scope":"metrics:read","kubernetes.io":{"namespace":"monitoring"}}`),
+			want:  map[string][]string{ScopesExtraKey: {"metrics:read"}},
+		},
+		{
+			name:  "legacy service account token",
+			token: token(`{"kubernetes.io/serviceaccount/namespace":"monitoring"}`),
+			want:  map[string][]string{},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tokenExtra(tt.token); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("want %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestUsernameMapping(t *testing.T) {
+	for _, tt := range []struct {
+		name           string
+		config         *OIDCConfig
+		wantExpression string
+		wantRules      int
+	}{
+		{
+			name:   "single claim",
+			config: &OIDCConfig{UsernameClaim: "email"},
+		},
+		{
+			name:           "fallback claims",
+			config:         &OIDCConfig{UsernameClaim: "email", UsernameFallbackClaims: []string{"azp", "appid"}},
+			wantExpression: "has(claims.email) ? claims.email : has(claims.azp) ? claims.azp : claims.appid",
+			wantRules:      1,
+		},
+		{
+			name:           "prefixed fallback claims",
+			config:         &OIDCConfig{UsernameClaim: "sub", UsernamePrefix: "azure:", UsernameFallbackClaims: []string{"appid"}},
+			wantExpression: `"azure:" + (has(claims.sub) ? claims.sub : claims.appid)`,
+		},
+		{
+			name:           "claims not being identifiers",
+			config:         &OIDCConfig{UsernameClaim: "preferred-username", UsernameFallbackClaims: []string{"https://example.com/app"}},
+			wantExpression: `"preferred-username" in claims ? claims["preferred-username"] : claims["https://example.com/app"]`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			jwtAuthenticator := apiserver.JWTAuthenticator{
+				Issuer: apiserver.Issuer{
+					URL:       "https://issuer.example.com",
+					Audiences: []string{"client"},
+				},
+				ClaimMappings: apiserver.ClaimMappings{
+					Username: usernameMapping(tt.config),
+				},
+				ClaimValidationRules: emailVerifiedRules(tt.config),
+			}
+
+			if got := jwtAuthenticator.ClaimMappings.Username.Expression; got != tt.wantExpression {
+				t.Errorf("want expression %q, got %q", tt.wantExpression, got)
+			}
+			if got := len(jwtAuthenticator.ClaimValidationRules); got != tt.wantRules {
+				t.Errorf("want %d claim validation rules, got %d", tt.wantRules, got)
+			}
+			if _, errs := apiservervalidation.CompileAndValidateJWTAuthenticator(jwtAuthenticator, nil); len(errs) > 0 {
+				t.Errorf("want the mapping to be valid, got %v", errs)
+			}
+		})
+	}
+}
@@ -0,0 +1,451 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authn
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	gooidc "github.com/coreos/go-oidc"
+	"golang.org/x/oauth2"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/server/dynamiccertificates"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// LoginSessionCookieName is the name of the cookie holding the
+	// encrypted identity of users logged in with the browser login.
+	LoginSessionCookieName = "kube-rbac-proxy-session"
+	// loginStateCookieName holds the state and the PKCE verifier of a
+	// pending login.
+	loginStateCookieName = "kube-rbac-proxy-login"
+
+	loginStateTTL          = 10 * time.Minute
+	maxCookieSize          = 4096
+	loginExchangeTimeout   = 30 * time.Second
+	defaultLoginSessionTTL = 8 * time.Hour
+)
+
+// loginSession is the content of the session cookie.
+type loginSession struct {
+	Name   string              `json:"name"`
+	UID    string              `json:"uid,omitempty"`
+	Groups []string            `json:"groups,omitempty"`
+	Extra  map[string][]string `json:"extra,omitempty"`
+	Expiry time.Time           `json:"expiry"`
+}
+
+// loginState is the content of the state cookie.
+type loginState struct {
+	State      string    `json:"state"`
+	Verifier   string    `json:"verifier"`
+	RedirectTo string    `json:"redirectTo"`
+	Expiry     time.Time `json:"expiry"`
+}
+
+// OIDCLogin logs in browsers with the authorization code flow with PKCE of
+// the OpenID issuer. The ID token is verified by the OIDC authenticator and
+// the identity is kept in an encrypted session cookie, which authenticates
+// the subsequent requests.
+type OIDCLogin struct {
+	config       *OIDCConfig
+	clientSecret string
+	cookies      *sealer
+	callbackPath string
+	// idTokens authenticates requests bearing the ID token.
+	idTokens authenticator.Request
+	client   *http.Client
+	// store keeps the sessions, if they aren't kept in the cookie.
+	store SessionStore
+	now   func() time.Time
+
+	mu       sync.Mutex
+	endpoint *oauth2.Endpoint
+}
+
+var _ authenticator.Request = (*OIDCLogin)(nil)
+
+// NewOIDCLogin reads the client secret and the cookie secret of the login
+// config. ID tokens are verified by idTokens.
+func NewOIDCLogin(config *OIDCConfig, idTokens authenticator.Request) (*OIDCLogin, error) {
+	clientSecret, err := os.ReadFile(config.LoginClientSecretFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OIDC client secret: %w", err)
+	}
+	cookies, err := newSealer(config.LoginCookieSecretFile, "cookie secret")
+	if err != nil {
+		return nil, err
+	}
+
+	redirectURL, err := url.Parse(config.LoginRedirectURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse login redirect URL: %w", err)
+	}
+
+	client, err := newLoginClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := NewSessionStore(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OIDCLogin{
+		config:       config,
+		clientSecret: strings.TrimSpace(string(clientSecret)),
+		cookies:      cookies,
+		callbackPath: redirectURL.Path,
+		idTokens:     idTokens,
+		client:       client,
+		store:        store,
+		now:          time.Now,
+	}, nil
+}
+
+// newLoginClient returns the client discovering the issuer and exchanging
+// authorization codes, trusting the CA file if set.
+func newLoginClient(config *OIDCConfig) (*http.Client, error) {
+	proxy := config.Proxy
+	if proxy == nil {
+		proxy = http.ProxyFromEnvironment
+	}
+
+	if config.CAFile == "" {
+		return &http.Client{
+			Transport: utilnet.SetTransportDefaults(&http.Transport{Proxy: proxy}),
+			Timeout:   loginExchangeTimeout,
+		}, nil
+	}
+
+	dyCA, err := dynamiccertificates.NewDynamicCAContentFromFile("oidc-login-ca", config.CAFile)
+	if err != nil {
+		return nil, err
+	}
+	return newOIDCClient(dyCA, proxy)
+}
+
+// CallbackPath is the path of the redirect URL, which the issuer redirects
+// browsers to with the authorization code.
+func (l *OIDCLogin) CallbackPath() string {
+	return l.callbackPath
+}
+
+// AuthenticateRequest authenticates requests with a valid session cookie.
+// Invalid or expired sessions aren't an error, such that the browser is
+// sent to the login again.
+func (l *OIDCLogin) AuthenticateRequest(req *http.Request) (*authenticator.Response, bool, error) {
+	session, err := l.session(req)
+	if err != nil || session == nil {
+		return nil, false, err
+	}
+	if l.now().After(session.Expiry) {
+		return nil, false, nil
+	}
+
+	return &authenticator.Response{User: &user.DefaultInfo{
+		Name:   session.Name,
+		UID:    session.UID,
+		Groups: session.Groups,
+		Extra:  session.Extra,
+	}}, true, nil
+}
+
+// session returns the session of the session cookie, nil if there is none.
+// With a session store, the cookie holds the ID of the session.
+func (l *OIDCLogin) session(req *http.Request) (*loginSession, error) {
+	c, err := req.Cookie(LoginSessionCookieName)
+	if err != nil {
+		return nil, nil
+	}
+
+	session := &loginSession{}
+	if l.store == nil {
+		if err := l.decrypt(LoginSessionCookieName, c.Value, session); err != nil {
+			klog.FromContext(req.Context()).V(4).Info("Invalid login session", "err", err)
+			return nil, nil
+		}
+		return session, nil
+	}
+
+	var id string
+	if err := l.decrypt(LoginSessionCookieName, c.Value, &id); err != nil {
+		klog.FromContext(req.Context()).V(4).Info("Invalid login session", "err", err)
+		return nil, nil
+	}
+	b, ok, err := l.store.Load(req.Context(), id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the login session: %w", err)
+	}
+	if !ok {
+		return nil, nil
+	}
+	if err := json.Unmarshal(b, session); err != nil {
+		return nil, fmt.Errorf("failed to parse the login session: %w", err)
+	}
+	return session, nil
+}
+
+// sessionCookieValue returns the value of the session cookie, which is the
+// session itself or the ID of the session saved to the store. The previous
+// session of the browser is deleted from the store.
+func (l *OIDCLogin) sessionCookieValue(req *http.Request, session *loginSession) (any, error) {
+	if l.store == nil {
+		return session, nil
+	}
+
+	if c, err := req.Cookie(LoginSessionCookieName); err == nil {
+		var previous string
+		if l.decrypt(LoginSessionCookieName, c.Value, &previous) == nil {
+			if err := l.store.Delete(req.Context(), previous); err != nil {
+				klog.FromContext(req.Context()).V(2).Info("Unable to delete the previous login session", "err", err)
+			}
+		}
+	}
+
+	b, err := json.Marshal(session)
+	if err != nil {
+		return nil, err
+	}
+	id := randomString()
+	if err := l.store.Save(req.Context(), id, b, l.sessionTTL()); err != nil {
+		return nil, fmt.Errorf("failed to save the login session: %w", err)
+	}
+	return id, nil
+}
+
+// Redirect sends the browser to the authorization endpoint of the issuer,
+// remembering the state, the PKCE verifier and the requested URI in the
+// state cookie.
+func (l *OIDCLogin) Redirect(w http.ResponseWriter, req *http.Request) {
+	endpoint, err := l.oauth2Endpoint(req.Context())
+	if err != nil {
+		klog.FromContext(req.Context()).Error(err, "Unable to discover the OpenID issuer")
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+
+	state := loginState{
+		State:      randomString(),
+		Verifier:   oauth2.GenerateVerifier(),
+		RedirectTo: req.URL.RequestURI(),
+		Expiry:     l.now().Add(loginStateTTL),
+	}
+	if err := l.setCookie(w, req, loginStateCookieName, l.callbackPath, state, loginStateTTL); err != nil {
+		klog.FromContext(req.Context()).Error(err, "Unable to set the login state")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, req, l.oauth2Config(endpoint).AuthCodeURL(state.State, oauth2.S256ChallengeOption(state.Verifier)), http.StatusFound)
+}
+
+// ServeHTTP serves the callback of the login. The authorization code is
+// exchanged for the ID token, whose identity is stored in the session
+// cookie, and the browser is sent back to the URI it requested.
+func (l *OIDCLogin) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	logger := klog.FromContext(req.Context())
+	if req.Method != http.MethodGet {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	c, err := req.Cookie(loginStateCookieName)
+	if err != nil {
+		http.Error(w, "Bad Request: no login in progress", http.StatusBadRequest)
+		return
+	}
+	var state loginState
+	if err := l.decrypt(loginStateCookieName, c.Value, &state); err != nil || l.now().After(state.Expiry) {
+		http.Error(w, "Bad Request: the login expired", http.StatusBadRequest)
+		return
+	}
+	l.deleteCookie(w, req, loginStateCookieName, l.callbackPath)
+
+	query := req.URL.Query()
+	if query.Get("state") != state.State {
+		http.Error(w, "Bad Request: the state doesn't match", http.StatusBadRequest)
+		return
+	}
+	if errCode := query.Get("error"); errCode != "" {
+		logger.V(2).Info("Login refused by the OpenID issuer", "error", errCode, "description", query.Get("error_description"))
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	endpoint, err := l.oauth2Endpoint(req.Context())
+	if err != nil {
+		logger.Error(err, "Unable to discover the OpenID issuer")
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithValue(req.Context(), oauth2.HTTPClient, l.client), loginExchangeTimeout)
+	defer cancel()
+	token, err := l.oauth2Config(endpoint).Exchange(ctx, query.Get("code"), oauth2.VerifierOption(state.Verifier))
+	if err != nil {
+		logger.V(2).Info("Unable to exchange the authorization code", "err", err)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	idToken, ok := token.Extra("id_token").(string)
+	if !ok || idToken == "" {
+		logger.V(2).Info("The token response has no ID token")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	bearer := req.Clone(req.Context())
+	bearer.Header = http.Header{"Authorization": {"Bearer " + idToken}}
+	res, ok, err := l.idTokens.AuthenticateRequest(bearer)
+	if err != nil || !ok {
+		logger.V(2).Info("Unable to authenticate the ID token", "err", err)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	session := loginSession{
+		Name:   res.User.GetName(),
+		UID:    res.User.GetUID(),
+		Groups: res.User.GetGroups(),
+		Extra:  res.User.GetExtra(),
+		Expiry: l.now().Add(l.sessionTTL()),
+	}
+	value, err := l.sessionCookieValue(req, &session)
+	if err == nil {
+		err = l.setCookie(w, req, LoginSessionCookieName, "/", value, l.sessionTTL())
+	}
+	if err != nil {
+		logger.Error(err, "Unable to set the login session", "user", session.Name)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, req, localRedirect(state.RedirectTo), http.StatusFound)
+}
+
+func (l *OIDCLogin) sessionTTL() time.Duration {
+	if l.config.LoginSessionTTL > 0 {
+		return l.config.LoginSessionTTL
+	}
+	return defaultLoginSessionTTL
+}
+
+func (l *OIDCLogin) oauth2Config(endpoint *oauth2.Endpoint) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     l.config.ClientID,
+		ClientSecret: l.clientSecret,
+		Endpoint:     *endpoint,
+		RedirectURL:  l.config.LoginRedirectURL,
+		Scopes:       l.config.LoginScopes,
+	}
+}
+
+// oauth2Endpoint discovers the endpoints of the issuer on first use and
+// retries the discovery until it succeeds.
+func (l *OIDCLogin) oauth2Endpoint(ctx context.Context) (*oauth2.Endpoint, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.endpoint == nil {
+		provider, err := gooidc.NewProvider(gooidc.ClientContext(ctx, l.client), l.config.IssuerURL)
+		if err != nil {
+			return nil, fmt.Errorf("oidc: initializing provider: %w", err)
+		}
+		endpoint := provider.Endpoint()
+		l.endpoint = &endpoint
+	}
+	return l.endpoint, nil
+}
+
+func (l *OIDCLogin) setCookie(w http.ResponseWriter, req *http.Request, name, path string, v any, ttl time.Duration) error {
+	value, err := l.encrypt(name, v)
+	if err != nil {
+		return err
+	}
+
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		MaxAge:   int(ttl.Seconds()),
+		HttpOnly: true,
+		Secure:   req.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	}
+	if s := cookie.String(); len(s) > maxCookieSize {
+		return fmt.Errorf("cookie %s exceeds %d bytes, e.g. by the groups of the user", name, maxCookieSize)
+	}
+	http.SetCookie(w, cookie)
+	return nil
+}
+
+func (l *OIDCLogin) deleteCookie(w http.ResponseWriter, req *http.Request, name, path string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Path:     path,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   req.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// encrypt seals the JSON of v, authenticating the cookie name, such that the
+// state cookie can't be used as session cookie.
+func (l *OIDCLogin) encrypt(name string, v any) (string, error) {
+	sealed, err := l.cookies.seal(name, v)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+func (l *OIDCLogin) decrypt(name, value string, v any) error {
+	sealed, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return err
+	}
+	return l.cookies.open(name, sealed, v)
+}
+
+func randomString() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// localRedirect only allows redirects to paths of the proxy.
+func localRedirect(uri string) string {
+	if !strings.HasPrefix(uri, "/") || strings.HasPrefix(uri, "//") || strings.HasPrefix(uri, "/\\") {
+		return "/"
+	}
+	return uri
+}
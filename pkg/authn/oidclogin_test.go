@@ -0,0 +1,194 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authn
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/authentication/user"
+)
+
+func TestOIDCLogin(t *testing.T) {
+	var challenge string
+	var issuer *httptest.Server
+	issuer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/.well-known/openid-configuration":
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"issuer":                 issuer.URL,
+				"authorization_endpoint": issuer.URL + "/authorize",
+				"token_endpoint":         issuer.URL + "/token",
+				"jwks_uri":               issuer.URL + "/keys",
+			})
+		case "/token":
+			_ = req.ParseForm()
+			sum := sha256.Sum256([]byte(req.PostForm.Get("code_verifier")))
+			if req.PostForm.Get("code") != "code" || base64.RawURLEncoding.EncodeToString(sum[:]) != challenge {
+				http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"access_token":"access","token_type":"Bearer","id_token":"id-token"}`))
+		default:
+			http.NotFound(w, req)
+		}
+	}))
+	defer issuer.Close()
+
+	dir := t.TempDir()
+	clientSecretFile := filepath.Join(dir, "client-secret")
+	cookieSecretFile := filepath.Join(dir, "cookie-secret")
+	if err := os.WriteFile(clientSecretFile, []byte("client-secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(cookieSecretFile, []byte(strings.Repeat("s", 32)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	alice := &user.DefaultInfo{Name: "alice", Groups: []string{"admins"}}
+	idTokens := authenticator.RequestFunc(func(req *http.Request) (*authenticator.Response, bool, error) {
+		if req.Header.Get("Authorization") != "Bearer id-token" {
+			return nil, false, nil
+		}
+		return &authenticator.Response{User: alice}, true, nil
+	})
+
+	for _, store := range []string{SessionStoreCookie, SessionStoreMemory} {
+		t.Run(store, func(t *testing.T) {
+			login, err := NewOIDCLogin(&OIDCConfig{
+				IssuerURL:             issuer.URL,
+				ClientID:              "proxy",
+				LoginRedirectURL:      "https://proxy.example.com/oauth2/callback",
+				LoginClientSecretFile: clientSecretFile,
+				LoginCookieSecretFile: cookieSecretFile,
+				LoginSessionTTL:       time.Hour,
+				LoginScopes:           []string{"openid", "email"},
+				LoginSessionStore:     store,
+			}, idTokens)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if login.CallbackPath() != "/oauth2/callback" {
+				t.Fatalf("want callback path /oauth2/callback, got %s", login.CallbackPath())
+			}
+
+			// The browser is sent to the issuer.
+			w := httptest.NewRecorder()
+			login.Redirect(w, httptest.NewRequest(http.MethodGet, "/dashboard?tab=1", nil))
+			if w.Code != http.StatusFound {
+				t.Fatalf("want redirect to the issuer, got %d", w.Code)
+			}
+			location, err := url.Parse(w.Header().Get("Location"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			query := location.Query()
+			if location.Path != "/authorize" || query.Get("client_id") != "proxy" || query.Get("code_challenge_method") != "S256" || query.Get("scope") != "openid email" {
+				t.Fatalf("unexpected authorization URL %s", location)
+			}
+			challenge = query.Get("code_challenge")
+			state := query.Get("state")
+			stateCookie := w.Result().Cookies()[0]
+
+			callback := func(state string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+				req := httptest.NewRequest(http.MethodGet, "/oauth2/callback?code=code&state="+url.QueryEscape(state), nil)
+				for _, c := range cookies {
+					req.AddCookie(c)
+				}
+				w := httptest.NewRecorder()
+				login.ServeHTTP(w, req)
+				return w
+			}
+
+			if w := callback("other", stateCookie); w.Code != http.StatusBadRequest {
+				t.Errorf("want 400 for a mismatching state, got %d", w.Code)
+			}
+			if w := callback(state); w.Code != http.StatusBadRequest {
+				t.Errorf("want 400 without state cookie, got %d", w.Code)
+			}
+
+			// The issuer sends the browser back with the authorization code.
+			w = callback(state, stateCookie)
+			if w.Code != http.StatusFound || w.Header().Get("Location") != "/dashboard?tab=1" {
+				t.Fatalf("want redirect to the requested URI, got %d to %q", w.Code, w.Header().Get("Location"))
+			}
+			var session *http.Cookie
+			for _, c := range w.Result().Cookies() {
+				if c.Name == LoginSessionCookieName {
+					session = c
+				}
+			}
+			if session == nil || !session.HttpOnly {
+				t.Fatal("want an HTTP-only session cookie")
+			}
+
+			authenticate := func(c *http.Cookie) (user.Info, bool) {
+				req := httptest.NewRequest(http.MethodGet, "/dashboard", nil)
+				req.AddCookie(c)
+				res, ok, err := login.AuthenticateRequest(req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !ok {
+					return nil, false
+				}
+				return res.User, true
+			}
+
+			if u, ok := authenticate(session); !ok || u.GetName() != "alice" || !reflect.DeepEqual(u.GetGroups(), alice.Groups) {
+				t.Errorf("want alice authenticated by the session, got %v", u)
+			}
+			if _, ok := authenticate(&http.Cookie{Name: LoginSessionCookieName, Value: session.Value[:len(session.Value)-2] + "AA"}); ok {
+				t.Error("want tampered sessions rejected")
+			}
+			if _, ok := authenticate(&http.Cookie{Name: LoginSessionCookieName, Value: stateCookie.Value}); ok {
+				t.Error("want state cookies rejected as session")
+			}
+
+			login.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+			if _, ok := authenticate(session); ok {
+				t.Error("want expired sessions rejected")
+			}
+
+		})
+	}
+}
+
+func TestLocalRedirect(t *testing.T) {
+	for uri, want := range map[string]string{
+		"/dashboard?tab=1":    "/dashboard?tab=1",
+		"//evil.example.com":  "/",
+		"/\\evil.example.com": "/",
+		"https://evil.com/":   "/",
+	} {
+		if got := localRedirect(uri); got != want {
+			t.Errorf("%s: want %s, got %s", uri, want, got)
+		}
+	}
+}
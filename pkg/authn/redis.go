@@ -0,0 +1,227 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authn
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	certutil "k8s.io/client-go/util/cert"
+)
+
+const (
+	redisTimeout       = 5 * time.Second
+	redisMaxIdleConns  = 8
+	redisSessionPrefix = "kube-rbac-proxy:session:"
+)
+
+// RedisSessionStore keeps the sessions in Redis, such that the replicas of
+// the proxy share them. It speaks the commands AUTH, SET, GET and DEL of the
+// Redis protocol on pooled connections.
+type RedisSessionStore struct {
+	address   string
+	password  string
+	tlsConfig *tls.Config
+	idle      chan *redisConn
+}
+
+var _ SessionStore = (*RedisSessionStore)(nil)
+
+// NewRedisSessionStore creates a RedisSessionStore for the host:port
+// address. The password is read once from the file, if set. Connections use
+// TLS, if a CA file is given.
+func NewRedisSessionStore(address, passwordFile, caFile string) (*RedisSessionStore, error) {
+	s := &RedisSessionStore{
+		address: address,
+		idle:    make(chan *redisConn, redisMaxIdleConns),
+	}
+
+	if passwordFile != "" {
+		password, err := os.ReadFile(passwordFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Redis password: %w", err)
+		}
+		s.password = strings.TrimSpace(string(password))
+	}
+
+	if caFile != "" {
+		pool, err := certutil.NewPool(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load Redis CA: %w", err)
+		}
+		s.tlsConfig = &tls.Config{RootCAs: pool}
+	}
+
+	return s, nil
+}
+
+func (s *RedisSessionStore) Save(ctx context.Context, id string, session []byte, ttl time.Duration) error {
+	_, err := s.do(ctx, "SET", redisKey(id), string(session), "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	return err
+}
+
+func (s *RedisSessionStore) Load(ctx context.Context, id string) ([]byte, bool, error) {
+	reply, err := s.do(ctx, "GET", redisKey(id))
+	if err != nil || reply == nil {
+		return nil, false, err
+	}
+	return reply, true, nil
+}
+
+func (s *RedisSessionStore) Delete(ctx context.Context, id string) error {
+	_, err := s.do(ctx, "DEL", redisKey(id))
+	return err
+}
+
+// redisKey hashes the session ID, such that the keys in Redis can't be used
+// as cookies.
+func redisKey(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return redisSessionPrefix + hex.EncodeToString(sum[:])
+}
+
+// do sends the command and returns the bulk string reply, nil for a nil
+// reply or a status or integer reply.
+func (s *RedisSessionStore) do(ctx context.Context, args ...string) ([]byte, error) {
+	conn, err := s.conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+
+	deadline := time.Now().Add(redisTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	_ = conn.SetDeadline(deadline)
+
+	reply, err := conn.do(args...)
+	var replyErr redisError
+	if err != nil && !errors.As(err, &replyErr) {
+		// The connection is in an unknown state.
+		conn.Close()
+		return nil, fmt.Errorf("redis %s failed: %w", args[0], err)
+	}
+
+	select {
+	case s.idle <- conn:
+	default:
+		conn.Close()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("redis %s failed: %w", args[0], err)
+	}
+	return reply, nil
+}
+
+func (s *RedisSessionStore) conn(ctx context.Context) (*redisConn, error) {
+	select {
+	case conn := <-s.idle:
+		return conn, nil
+	default:
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+
+	var (
+		c   net.Conn
+		err error
+	)
+	if s.tlsConfig != nil {
+		c, err = (&tls.Dialer{Config: s.tlsConfig}).DialContext(ctx, "tcp", s.address)
+	} else {
+		c, err = (&net.Dialer{}).DialContext(ctx, "tcp", s.address)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	conn := &redisConn{Conn: c, r: bufio.NewReader(c)}
+	if s.password != "" {
+		_ = conn.SetDeadline(time.Now().Add(redisTimeout))
+		if _, err := conn.do("AUTH", s.password); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("authentication failed: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+// redisError is an error reply of Redis.
+type redisError string
+
+func (e redisError) Error() string {
+	return string(e)
+}
+
+type redisConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *redisConn) do(args ...string) ([]byte, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(c.Conn, b.String()); err != nil {
+		return nil, err
+	}
+
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("empty reply")
+	}
+
+	switch line[0] {
+	case '+', ':':
+		return nil, nil
+	case '-':
+		return nil, redisError(line[1:])
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("malformed reply %q", line)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, data); err != nil {
+			return nil, err
+		}
+		return data[:n], nil
+	default:
+		return nil, fmt.Errorf("unexpected reply %q", line)
+	}
+}
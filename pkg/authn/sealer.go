@@ -0,0 +1,90 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authn
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// minSecretLength is the minimal length of the secrets keys are derived from.
+const minSecretLength = 32
+
+// sealer encrypts and authenticates JSON with AES-256-GCM.
+type sealer struct {
+	aead cipher.AEAD
+}
+
+// newSealer reads the secret, the key is derived from it, such that secrets
+// of any length can be used with AES-256.
+func newSealer(secretFile, name string) (*sealer, error) {
+	secret, err := os.ReadFile(secretFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	secret = bytes.TrimSpace(secret)
+	if len(secret) < minSecretLength {
+		return nil, fmt.Errorf("%s must have at least %d bytes", name, minSecretLength)
+	}
+
+	key := sha256.Sum256(secret)
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &sealer{aead: aead}, nil
+}
+
+// seal encrypts the JSON of v. The label is authenticated, such that values
+// sealed for one purpose can't be used for another.
+func (s *sealer) seal(label string, v any) ([]byte, error) {
+	plaintext, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return s.aead.Seal(nonce, nonce, plaintext, []byte(label)), nil
+}
+
+// open decrypts the sealed value into v.
+func (s *sealer) open(label string, sealed []byte, v any) error {
+	if len(sealed) < s.aead.NonceSize() {
+		return errors.New("sealed value too short")
+	}
+
+	nonce, ciphertext := sealed[:s.aead.NonceSize()], sealed[s.aead.NonceSize():]
+	plaintext, err := s.aead.Open(nil, nonce, ciphertext, []byte(label))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(plaintext, v)
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authn
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/authentication/serviceaccount"
+)
+
+// serviceAccountClaims are the claims of bound and legacy service account
+// tokens identifying the namespace, and the objects bound tokens are bound
+// to.
+type serviceAccountClaims struct {
+	Audience   audience `json:"aud"`
+	ID         string   `json:"jti"`
+	Kubernetes *struct {
+		Namespace string       `json:"namespace"`
+		Pod       *boundObject `json:"pod"`
+		Node      *boundObject `json:"node"`
+	} `json:"kubernetes.io"`
+	LegacyNamespace string `json:"kubernetes.io/serviceaccount/namespace"`
+}
+
+type boundObject struct {
+	Name string `json:"name"`
+	UID  string `json:"uid"`
+}
+
+// audience is either a single string or a list of strings.
+type audience []string
+
+func (a *audience) UnmarshalJSON(b []byte) error {
+	var single string
+	if err := json.Unmarshal(b, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	return json.Unmarshal(b, (*[]string)(a))
+}
+
+func (c *serviceAccountClaims) namespace() string {
+	if c.Kubernetes != nil {
+		return c.Kubernetes.Namespace
+	}
+	return c.LegacyNamespace
+}
+
+// extra returns the extra info the API server sets for the users of bound
+// service account tokens.
+func (c *serviceAccountClaims) extra() map[string][]string {
+	if c.Kubernetes == nil {
+		return nil
+	}
+
+	info := &serviceaccount.ServiceAccountInfo{
+		CredentialID: serviceaccount.CredentialIDForJTI(c.ID),
+	}
+	if pod := c.Kubernetes.Pod; pod != nil {
+		info.PodName, info.PodUID = pod.Name, pod.UID
+	}
+	if node := c.Kubernetes.Node; node != nil {
+		info.NodeName, info.NodeUID = node.Name, node.UID
+	}
+	return info.UserInfo().GetExtra()
+}
+
+// WithServiceAccountNamespaces rejects service account tokens of namespaces
+// not in the list. The unverified claims of the token are checked before
+// the token is reviewed, rejecting tokens for other audiences and
+// namespaces without a round trip. The namespace of the authenticated
+// service account is checked again afterwards.
+func WithServiceAccountNamespaces(auth authenticator.Request, namespaces, audiences []string) authenticator.Request {
+	allowed := sets.New(namespaces...)
+
+	return authenticator.RequestFunc(func(req *http.Request) (*authenticator.Response, bool, error) {
+		token := strings.TrimSpace(strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer "))
+		if claims, ok := tokenServiceAccountClaims(token); ok {
+			if len(audiences) > 0 && len(matchAudiences(audiences, claims.Audience)) == 0 {
+				return nil, false, fmt.Errorf("service account token is not issued for any of the audiences %v", audiences)
+			}
+			if ns := claims.namespace(); !allowed.Has(ns) {
+				return nil, false, fmt.Errorf("service account tokens of namespace %q are not allowed", ns)
+			}
+		}
+
+		resp, ok, err := auth.AuthenticateRequest(req)
+		if !ok || err != nil {
+			return resp, ok, err
+		}
+
+		if ns, _, err := serviceaccount.SplitUsername(resp.User.GetName()); err == nil && !allowed.Has(ns) {
+			return nil, false, fmt.Errorf("service account tokens of namespace %q are not allowed", ns)
+		}
+
+		return resp, true, nil
+	})
+}
+
+// tokenServiceAccountClaims returns the unverified claims of a JWT, if it is
+// a service account token.
+func tokenServiceAccountClaims(token string) (*serviceAccountClaims, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, false
+	}
+
+	claims := &serviceAccountClaims{}
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return nil, false
+	}
+	if claims.namespace() == "" {
+		return nil, false
+	}
+
+	return claims, true
+}
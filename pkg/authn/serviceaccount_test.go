@@ -0,0 +1,96 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authn
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/authentication/user"
+)
+
+func TestWithServiceAccountNamespaces(t *testing.T) {
+	jwt := func(claims string) string {
+		return "e30." + base64.RawURLEncoding.EncodeToString([]byte(claims)) + ".c2ln"
+	}
+
+	for _, tt := range []struct {
+		name       string
+		token      string
+		username   string
+		wantReview bool
+		wantOK     bool
+	}{
+		{
+			name:       "allowed namespace",
+			token:      jwt(`{"aud":["proxy"],"kubernetes.io":{"namespace":"monitoring"}}`),
+			username:   "system:serviceaccount:monitoring:prometheus",
+			wantReview: true,
+			wantOK:     true,
+		},
+		{
+			name:  "other namespace",
+			token: jwt(`{"aud":["proxy"],"kubernetes.io":{"namespace":"default"}}`),
+		},
+		{
+			name:  "legacy token of other namespace",
+			token: jwt(`{"kubernetes.io/serviceaccount/namespace":"default"}`),
+		},
+		{
+			name:  "other audience",
+			token: jwt(`{"aud":"api","kubernetes.io":{"namespace":"monitoring"}}`),
+		},
+		{
+			name:       "forged claims",
+			token:      jwt(`{"aud":["proxy"],"kubernetes.io":{"namespace":"monitoring"}}`),
+			username:   "system:serviceaccount:default:default",
+			wantReview: true,
+		},
+		{
+			name:       "not a service account",
+			token:      "opaque",
+			username:   "alice",
+			wantReview: true,
+			wantOK:     true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var reviewed bool
+			auth := WithServiceAccountNamespaces(authenticator.RequestFunc(func(*http.Request) (*authenticator.Response, bool, error) {
+				reviewed = true
+				return &authenticator.Response{User: &user.DefaultInfo{Name: tt.username}}, true, nil
+			}), []string{"monitoring"}, []string{"proxy"})
+
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			_, ok, err := auth.AuthenticateRequest(req)
+
+			if reviewed != tt.wantReview {
+				t.Errorf("want reviewed %v, got %v", tt.wantReview, reviewed)
+			}
+			if ok != tt.wantOK {
+				t.Errorf("want ok %v, got %v (%v)", tt.wantOK, ok, err)
+			}
+			if !tt.wantOK && err == nil {
+				t.Error("want error for rejected token")
+			}
+		})
+	}
+}
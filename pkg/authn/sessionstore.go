@@ -0,0 +1,130 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authn
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Session stores of the browser login.
+const (
+	// SessionStoreCookie keeps the sessions in the encrypted cookie itself.
+	SessionStoreCookie = "cookie"
+	// SessionStoreMemory keeps the sessions in the memory of the proxy.
+	SessionStoreMemory = "memory"
+	// SessionStoreRedis keeps the sessions in Redis, shared by replicas.
+	SessionStoreRedis = "redis"
+)
+
+// SessionStore stores the sessions of the browser login by their ID, such
+// that the cookie only holds the ID. Stores shared by the replicas of the
+// proxy allow to log in once for all replicas.
+type SessionStore interface {
+	// Save stores the session, which expires after the TTL.
+	Save(ctx context.Context, id string, session []byte, ttl time.Duration) error
+	// Load returns the session, false if it doesn't exist or expired.
+	Load(ctx context.Context, id string) ([]byte, bool, error)
+	// Delete removes the session.
+	Delete(ctx context.Context, id string) error
+}
+
+// NewSessionStore returns the session store of the config, nil for the
+// cookie store.
+func NewSessionStore(config *OIDCConfig) (SessionStore, error) {
+	switch config.LoginSessionStore {
+	case "", SessionStoreCookie:
+		return nil, nil
+	case SessionStoreMemory:
+		return NewMemorySessionStore(), nil
+	case SessionStoreRedis:
+		return NewRedisSessionStore(config.LoginRedisAddress, config.LoginRedisPasswordFile, config.LoginRedisCAFile)
+	default:
+		return nil, fmt.Errorf("unknown session store %q", config.LoginSessionStore)
+	}
+}
+
+// sweepInterval is the minimal interval between removals of expired
+// sessions from the memory store.
+const sweepInterval = time.Minute
+
+type memorySession struct {
+	session []byte
+	expiry  time.Time
+}
+
+// MemorySessionStore keeps the sessions in memory. The sessions are lost on
+// restarts and aren't shared by replicas.
+type MemorySessionStore struct {
+	now func() time.Time
+
+	mu        sync.Mutex
+	sessions  map[string]memorySession
+	lastSweep time.Time
+}
+
+var _ SessionStore = (*MemorySessionStore)(nil)
+
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{
+		now:      time.Now,
+		sessions: map[string]memorySession{},
+	}
+}
+
+func (s *MemorySessionStore) Save(_ context.Context, id string, session []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.lastSweep) > sweepInterval {
+		for id, session := range s.sessions {
+			if now.After(session.expiry) {
+				delete(s.sessions, id)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	s.sessions[id] = memorySession{session: session, expiry: now.Add(ttl)}
+	return nil
+}
+
+func (s *MemorySessionStore) Load(_ context.Context, id string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok {
+		return nil, false, nil
+	}
+	if s.now().After(session.expiry) {
+		delete(s.sessions, id)
+		return nil, false, nil
+	}
+	return session.session, true, nil
+}
+
+func (s *MemorySessionStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, id)
+	return nil
+}
@@ -0,0 +1,209 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authn
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testSessionStore(t *testing.T, store SessionStore, expire func()) {
+	ctx := context.Background()
+	if _, ok, err := store.Load(ctx, "unknown"); ok || err != nil {
+		t.Fatalf("want no session, got %v, %v", ok, err)
+	}
+
+	if err := store.Save(ctx, "id", []byte(`{"name":"alice"}`), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	session, ok, err := store.Load(ctx, "id")
+	if err != nil || !ok || string(session) != `{"name":"alice"}` {
+		t.Fatalf("want the saved session, got %q, %v, %v", session, ok, err)
+	}
+
+	if err := store.Delete(ctx, "id"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := store.Load(ctx, "id"); ok {
+		t.Fatal("want deleted session gone")
+	}
+
+	if err := store.Save(ctx, "id", []byte("session"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	expire()
+	if _, ok, _ := store.Load(ctx, "id"); ok {
+		t.Fatal("want expired session gone")
+	}
+}
+
+func TestMemorySessionStore(t *testing.T) {
+	store := NewMemorySessionStore()
+	testSessionStore(t, store, func() {
+		store.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	})
+}
+
+func TestRedisSessionStore(t *testing.T) {
+	redis := newFakeRedis(t, "secret")
+	passwordFile := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(passwordFile, []byte("secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewRedisSessionStore(redis.addr, passwordFile, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testSessionStore(t, store, redis.expireAll)
+
+	for key := range redis.values {
+		if strings.Contains(key, "id") || !strings.HasPrefix(key, redisSessionPrefix) {
+			t.Errorf("want hashed keys, got %q", key)
+		}
+	}
+
+	wrongPassword, err := NewRedisSessionStore(redis.addr, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := wrongPassword.Load(context.Background(), "id"); err == nil {
+		t.Error("want an error for unauthenticated connections")
+	}
+}
+
+// fakeRedis serves AUTH, SET with PX, GET and DEL of the Redis protocol.
+type fakeRedis struct {
+	addr     string
+	password string
+
+	mu     sync.Mutex
+	values map[string]string
+}
+
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	r := &fakeRedis{addr: l.Addr().String(), password: password, values: map[string]string{}}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go r.serve(conn)
+		}
+	}()
+	return r
+}
+
+func (r *fakeRedis) expireAll() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.values = map[string]string{}
+}
+
+func (r *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	br := bufio.NewReader(conn)
+	authenticated := r.password == ""
+
+	for {
+		args, err := readCommand(br)
+		if err != nil {
+			return
+		}
+
+		var reply string
+		switch cmd := strings.ToUpper(args[0]); {
+		case cmd == "AUTH":
+			authenticated = args[1] == r.password
+			reply = "+OK\r\n"
+			if !authenticated {
+				reply = "-WRONGPASS invalid password\r\n"
+			}
+		case !authenticated:
+			reply = "-NOAUTH Authentication required.\r\n"
+		case cmd == "SET" && len(args) == 5 && args[3] == "PX":
+			r.mu.Lock()
+			r.values[args[1]] = args[2]
+			r.mu.Unlock()
+			reply = "+OK\r\n"
+		case cmd == "GET":
+			r.mu.Lock()
+			v, ok := r.values[args[1]]
+			r.mu.Unlock()
+			reply = "$-1\r\n"
+			if ok {
+				reply = fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+			}
+		case cmd == "DEL":
+			r.mu.Lock()
+			delete(r.values, args[1])
+			r.mu.Unlock()
+			reply = ":1\r\n"
+		default:
+			reply = "-ERR unknown command\r\n"
+		}
+		if _, err := io.WriteString(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+func readCommand(br *bufio.Reader) ([]string, error) {
+	line, err := br.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return nil, err
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(br, data); err != nil {
+			return nil, err
+		}
+		args = append(args, string(data[:size]))
+	}
+	return args, nil
+}
@@ -0,0 +1,307 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authn
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// tokenCacheLabel authenticates the content of the cache file.
+	tokenCacheLabel = "kube-rbac-proxy-token-cache"
+	// tokenCacheSaveInterval is the interval changes are written to the
+	// cache file in.
+	tokenCacheSaveInterval = 30 * time.Second
+)
+
+var tokenCacheFallbacks = metrics.NewCounter(
+	&metrics.CounterOpts{
+		Namespace:      "kube_rbac_proxy",
+		Subsystem:      "token_cache",
+		Name:           "fallbacks_total",
+		Help:           "Number of requests authenticated from the persisted token cache, because the TokenReview failed.",
+		StabilityLevel: metrics.ALPHA,
+	},
+)
+
+func init() {
+	legacyregistry.MustRegister(tokenCacheFallbacks)
+}
+
+type tokenCacheEntry struct {
+	Name      string              `json:"name"`
+	UID       string              `json:"uid,omitempty"`
+	Groups    []string            `json:"groups,omitempty"`
+	Extra     map[string][]string `json:"extra,omitempty"`
+	Audiences []string            `json:"audiences,omitempty"`
+	Expiry    time.Time           `json:"expiry"`
+}
+
+// PersistentTokenCache remembers the users of bearer tokens, which were
+// reviewed successfully, in an encrypted file. Only if the review of a token
+// fails, as the API server is unavailable, the remembered
+// user authenticates the request, such that clients aren't locked out by a
+// restart of the proxy during an outage. Rejected tokens are forgotten.
+type PersistentTokenCache struct {
+	file   string
+	sealer *sealer
+	maxAge time.Duration
+	now    func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]tokenCacheEntry
+	dirty   bool
+}
+
+// NewPersistentTokenCache loads the cache file, if it exists. Users are
+// remembered for maxAge after their last successful review. A cache file
+// that can't be decrypted, e.g. after the key changed, is discarded.
+func NewPersistentTokenCache(file, keyFile string, maxAge time.Duration) (*PersistentTokenCache, error) {
+	s, err := newSealer(keyFile, "token cache key")
+	if err != nil {
+		return nil, err
+	}
+
+	c := &PersistentTokenCache{
+		file:    file,
+		sealer:  s,
+		maxAge:  maxAge,
+		now:     time.Now,
+		entries: map[string]tokenCacheEntry{},
+	}
+
+	sealed, err := os.ReadFile(file)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("failed to read token cache: %w", err)
+	default:
+		if err := s.open(tokenCacheLabel, sealed, &c.entries); err != nil {
+			klog.InfoS("Discarding the token cache, which can't be decrypted", "file", file, "err", err)
+			c.entries = map[string]tokenCacheEntry{}
+		}
+	}
+
+	return c, nil
+}
+
+// WithFallback authenticates the requests with bearer tokens from the cache,
+// whose authentication by a fails as the API server is unavailable.
+func (c *PersistentTokenCache) WithFallback(a authenticator.Request) authenticator.Request {
+	return authenticator.RequestFunc(func(req *http.Request) (*authenticator.Response, bool, error) {
+		resp, ok, err := a.AuthenticateRequest(req)
+
+		// Requests with client certificates might have been authenticated
+		// by the certificate rather than the token.
+		token := bearerToken(req)
+		if token == "" || (req.TLS != nil && len(req.TLS.PeerCertificates) > 0) {
+			return resp, ok, err
+		}
+		key := tokenCacheKey(token, req)
+
+		switch {
+		case err == nil && ok:
+			c.remember(key, resp)
+		case err == nil || !isUnavailable(err):
+			// The token was rejected, with or without a reason.
+			c.forget(key)
+		default:
+			if cached, found := c.lookup(key); found {
+				klog.FromContext(req.Context()).V(2).Info("Authenticated from the token cache, as the TokenReview failed", "user", cached.User.GetName(), "err", err)
+				tokenCacheFallbacks.Inc()
+				return cached, true, nil
+			}
+		}
+
+		return resp, ok, err
+	})
+}
+
+// isUnavailable tells whether the error is of the TokenReview request rather
+// than the review, i.e. whether the API server couldn't be reached or failed.
+func isUnavailable(err error) bool {
+	var agg utilerrors.Aggregate
+	if errors.As(err, &agg) {
+		for _, err := range agg.Errors() {
+			if isUnavailable(err) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var (
+		netErr net.Error
+		urlErr *url.Error
+	)
+	return errors.As(err, &netErr) ||
+		errors.As(err, &urlErr) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		apierrors.IsServiceUnavailable(err) ||
+		apierrors.IsInternalError(err) ||
+		apierrors.IsServerTimeout(err) ||
+		apierrors.IsTimeout(err) ||
+		apierrors.IsTooManyRequests(err) ||
+		apierrors.IsUnexpectedServerError(err)
+}
+
+// tokenCacheKey hashes the token and the audiences the token is reviewed
+// for, such that the file doesn't hold tokens.
+func tokenCacheKey(token string, req *http.Request) string {
+	audiences, _ := authenticator.AudiencesFrom(req.Context())
+	sum := sha256.Sum256([]byte(token + "\x00" + strings.Join(audiences, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+func bearerToken(req *http.Request) string {
+	auth := strings.TrimSpace(req.Header.Get("Authorization"))
+	scheme, token, ok := strings.Cut(auth, " ")
+	if !ok || !strings.EqualFold(scheme, "bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+func (c *PersistentTokenCache) remember(key string, resp *authenticator.Response) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = tokenCacheEntry{
+		Name:      resp.User.GetName(),
+		UID:       resp.User.GetUID(),
+		Groups:    resp.User.GetGroups(),
+		Extra:     resp.User.GetExtra(),
+		Audiences: resp.Audiences,
+		Expiry:    c.now().Add(c.maxAge),
+	}
+	c.dirty = true
+}
+
+func (c *PersistentTokenCache) forget(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[key]; ok {
+		delete(c.entries, key)
+		c.dirty = true
+	}
+}
+
+func (c *PersistentTokenCache) lookup(key string) (*authenticator.Response, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || c.now().After(entry.Expiry) {
+		return nil, false
+	}
+
+	return &authenticator.Response{
+		User: &user.DefaultInfo{
+			Name:   entry.Name,
+			UID:    entry.UID,
+			Groups: entry.Groups,
+			Extra:  entry.Extra,
+		},
+		Audiences: entry.Audiences,
+	}, true
+}
+
+// Save writes the cache file, if the cache changed, without the expired
+// entries. The file is replaced atomically.
+func (c *PersistentTokenCache) Save() error {
+	c.mu.Lock()
+	if !c.dirty {
+		c.mu.Unlock()
+		return nil
+	}
+	now := c.now()
+	for key, entry := range c.entries {
+		if now.After(entry.Expiry) {
+			delete(c.entries, key)
+		}
+	}
+	sealed, err := c.sealer.seal(tokenCacheLabel, c.entries)
+	c.dirty = false
+	c.mu.Unlock()
+	if err == nil {
+		err = c.write(sealed)
+	}
+	if err != nil {
+		// The changes are written with the next save.
+		c.mu.Lock()
+		c.dirty = true
+		c.mu.Unlock()
+	}
+	return err
+}
+
+func (c *PersistentTokenCache) write(sealed []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(c.file), filepath.Base(c.file)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(sealed); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.file)
+}
+
+// Run saves the cache periodically and once more when the context is done.
+func (c *PersistentTokenCache) Run(ctx context.Context) {
+	ticker := time.NewTicker(tokenCacheSaveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := c.Save(); err != nil {
+				klog.ErrorS(err, "Failed to save the token cache", "file", c.file)
+			}
+			return
+		case <-ticker.C:
+			if err := c.Save(); err != nil {
+				klog.ErrorS(err, "Failed to save the token cache", "file", c.file)
+			}
+		}
+	}
+}
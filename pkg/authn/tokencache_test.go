@@ -0,0 +1,152 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authn
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/authentication/user"
+)
+
+func TestPersistentTokenCache(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "tokens")
+	keyFile := filepath.Join(dir, "key")
+	if err := os.WriteFile(keyFile, []byte(strings.Repeat("k", 32)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	unavailable := &url.Error{Op: "Post", URL: "https://kubernetes/apis", Err: syscall.ECONNREFUSED}
+	var reviewErr error
+	reviewed := authenticator.RequestFunc(func(req *http.Request) (*authenticator.Response, bool, error) {
+		if reviewErr != nil {
+			return nil, false, reviewErr
+		}
+		if req.Header.Get("Authorization") != "Bearer valid" {
+			return nil, false, nil
+		}
+		return &authenticator.Response{User: &user.DefaultInfo{Name: "alice", Groups: []string{"admins"}}}, true, nil
+	})
+
+	authenticate := func(a authenticator.Request, token string) (string, bool, error) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, ok, err := a.AuthenticateRequest(req)
+		if !ok {
+			return "", false, err
+		}
+		return resp.User.GetName(), true, err
+	}
+
+	cache, err := NewPersistentTokenCache(file, keyFile, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := authenticate(cache.WithFallback(reviewed), "valid"); !ok {
+		t.Fatal("want the valid token authenticated")
+	}
+	if err := cache.Save(); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "alice") || strings.Contains(string(b), "valid") {
+		t.Fatal("want the cache file encrypted")
+	}
+
+	// After a restart during an outage, the reviewed token is authenticated
+	// from the file, but not other tokens.
+	restarted, err := NewPersistentTokenCache(file, keyFile, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := restarted.WithFallback(reviewed)
+	reviewErr = utilerrors.NewAggregate([]error{unavailable})
+	if name, ok, err := authenticate(a, "valid"); !ok || err != nil || name != "alice" {
+		t.Errorf("want alice from the cache, got %q, %v", name, err)
+	}
+	if _, ok, _ := authenticate(a, "other"); ok {
+		t.Error("want unknown tokens not authenticated")
+	}
+
+	// Rejections by the API server aren't outages, and remove the token.
+	reviewErr = errors.New("token has been invalidated")
+	if _, ok, _ := authenticate(a, "valid"); ok {
+		t.Error("want rejected tokens not authenticated")
+	}
+	reviewErr = unavailable
+	if _, ok, _ := authenticate(a, "valid"); ok {
+		t.Error("want rejected tokens removed from the cache")
+	}
+
+	// Entries expire after the maximum age.
+	reviewErr = nil
+	_, _, _ = authenticate(a, "valid")
+	reviewErr = unavailable
+	restarted.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if _, ok, _ := authenticate(a, "valid"); ok {
+		t.Error("want expired entries not authenticated")
+	}
+
+	// A file of another key is discarded.
+	otherKeyFile := filepath.Join(dir, "other-key")
+	if err := os.WriteFile(otherKeyFile, []byte(strings.Repeat("o", 32)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	rekeyed, err := NewPersistentTokenCache(file, otherKeyFile, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := authenticate(rekeyed.WithFallback(reviewed), "valid"); ok {
+		t.Error("want the entries of another key discarded")
+	}
+}
+
+func TestIsUnavailable(t *testing.T) {
+	for _, tt := range []struct {
+		err  error
+		want bool
+	}{
+		{err: &url.Error{Op: "Post", URL: "https://kubernetes", Err: syscall.ECONNREFUSED}, want: true},
+		{err: apierrors.NewServiceUnavailable("overloaded"), want: true},
+		{err: apierrors.NewTooManyRequests("slow down", 1), want: true},
+		{err: fmt.Errorf("review: %w", apierrors.NewInternalError(errors.New("etcd"))), want: true},
+		{err: utilerrors.NewAggregate([]error{errors.New("x509: unknown authority"), apierrors.NewTimeoutError("review", 1)}), want: true},
+		{err: errors.New("token has been invalidated")},
+		{err: apierrors.NewForbidden(schema.GroupResource{Resource: "tokenreviews"}, "", errors.New("forbidden"))},
+	} {
+		if got := isUnavailable(tt.err); got != tt.want {
+			t.Errorf("%v: want %v, got %v", tt.err, tt.want, got)
+		}
+	}
+}
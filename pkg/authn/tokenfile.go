@@ -0,0 +1,130 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authn
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/authentication/token/tokenfile"
+	"k8s.io/klog/v2"
+)
+
+// TokenFileAuthenticator authenticates the static bearer tokens of a CSV
+// file in the format of the --token-auth-file of the API server, i.e.
+// records of token, user name, uid and optionally comma-separated groups.
+// It allows authentication where neither an OpenID issuer nor the
+// TokenReview API is available, e.g. in air-gapped environments.
+//
+// For hot-reloading the Run method must be started explicitly.
+type TokenFileAuthenticator struct {
+	path     string
+	interval time.Duration
+
+	mu     sync.RWMutex // protects the fields below
+	tokens *tokenfile.TokenAuthenticator
+	raw    []byte
+}
+
+// NewTokenFileAuthenticator creates a TokenFileAuthenticator that reloads
+// the file in an interval.
+func NewTokenFileAuthenticator(path string, interval time.Duration) (*TokenFileAuthenticator, error) {
+	a := &TokenFileAuthenticator{
+		path:     path,
+		interval: interval,
+	}
+
+	if err := a.reload(); err != nil {
+		return nil, fmt.Errorf("error loading token file: %w", err)
+	}
+
+	return a, nil
+}
+
+// Run reloads the file until the context is done. If the file can't be
+// reloaded, e.g. while it is being written, the previous tokens are kept.
+func (a *TokenFileAuthenticator) Run(ctx context.Context) {
+	t := time.NewTicker(a.interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return
+		}
+
+		if err := a.reload(); err != nil {
+			klog.ErrorS(err, "Failed to reload token file, keeping the previous tokens", "path", a.path)
+		}
+	}
+}
+
+func (a *TokenFileAuthenticator) reload() error {
+	commit, err := a.PrepareReload()
+	if err != nil {
+		return err
+	}
+	commit()
+	return nil
+}
+
+// PrepareReload reads and parses the file, the returned function applies
+// the tokens.
+func (a *TokenFileAuthenticator) PrepareReload() (func(), error) {
+	raw, err := os.ReadFile(a.path)
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.RLock()
+	equal := a.tokens != nil && bytes.Equal(raw, a.raw)
+	a.mu.RUnlock()
+
+	if equal {
+		return func() {}, nil
+	}
+
+	tokens, err := tokenfile.NewCSV(a.path)
+	if err != nil {
+		return nil, err
+	}
+
+	return func() {
+		klog.V(4).InfoS("Reloading token file", "path", a.path)
+
+		a.mu.Lock()
+		a.tokens = tokens
+		a.raw = raw
+		a.mu.Unlock()
+	}, nil
+}
+
+// AuthenticateToken authenticates the token against the current tokens of
+// the file.
+func (a *TokenFileAuthenticator) AuthenticateToken(ctx context.Context, token string) (*authenticator.Response, bool, error) {
+	a.mu.RLock()
+	tokens := a.tokens
+	a.mu.RUnlock()
+
+	return tokens.AuthenticateToken(ctx, token)
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package authn
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTokenFileAuthenticator(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.csv")
+	if err := os.WriteFile(path, []byte("token1,alice,1001,\"admins,monitoring\"\ntoken2,bob,1002\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := NewTokenFileAuthenticator(path, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, ok, err := a.AuthenticateToken(context.Background(), "token1")
+	if err != nil || !ok {
+		t.Fatalf("want token1 authenticated, got %v, %v", ok, err)
+	}
+	if resp.User.GetName() != "alice" || resp.User.GetUID() != "1001" || !reflect.DeepEqual(resp.User.GetGroups(), []string{"admins", "monitoring"}) {
+		t.Errorf("unexpected user %v", resp.User)
+	}
+
+	if _, ok, _ := a.AuthenticateToken(context.Background(), "unknown"); ok {
+		t.Error("want unknown token not authenticated")
+	}
+
+	// Rotate token2, malformed files keep the previous tokens.
+	if err := os.WriteFile(path, []byte("token3,bob,1002\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.reload(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := a.AuthenticateToken(context.Background(), "token2"); ok {
+		t.Error("want token2 not authenticated after the reload")
+	}
+
+	if err := os.WriteFile(path, []byte("token4\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.reload(); err == nil {
+		t.Error("want error reloading a malformed file")
+	}
+	if _, ok, _ := a.AuthenticateToken(context.Background(), "token3"); !ok {
+		t.Error("want token3 still authenticated after a failed reload")
+	}
+}
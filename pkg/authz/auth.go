@@ -17,23 +17,129 @@ limitations under the License.
 package authz
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/apiserver/pkg/authorization/authorizer"
 	"k8s.io/apiserver/pkg/authorization/authorizerfactory"
 	"k8s.io/apiserver/pkg/server/options"
 	authorizationclient "k8s.io/client-go/kubernetes/typed/authorization/v1"
+	"k8s.io/klog/v2"
 )
 
 // Config holds configuration enabling request authorization
 type Config struct {
-	Rewrites               *SubjectAccessReviewRewrites `json:"rewrites,omitempty"`
-	ResourceAttributes     *ResourceAttributes          `json:"resourceAttributes,omitempty"`
-	ResourceAttributesFile string                       `json:"-"`
-	Static                 []StaticAuthorizationConfig  `json:"static,omitempty"`
+	Rewrites           *SubjectAccessReviewRewrites `json:"rewrites,omitempty"`
+	ResourceAttributes ResourceAttributesList       `json:"resourceAttributes,omitempty"`
+	// MethodResourceAttributes override the ResourceAttributes for
+	// requests of the HTTP method, e.g. for endpoints with mixed semantics.
+	MethodResourceAttributes map[string]ResourceAttributesList `json:"methodResourceAttributes,omitempty"`
+	ResourceAttributesFile   string                            `json:"-"`
+	Static                   []StaticAuthorizationConfig       `json:"static,omitempty"`
+	MethodVerbs              map[string]string                 `json:"methodVerbs,omitempty"`
+	Scopes                   []ScopeAuthorizationConfig        `json:"scopes,omitempty"`
+	// WatchQueryParameters are the query parameters, e.g. "watch", whose
+	// true value turns the verb of requests mapped to "get" into "watch",
+	// such that streaming reads are authorized apart from point reads.
+	WatchQueryParameters []string `json:"watchQueryParameters,omitempty"`
+	// DenyUsers and DenyGroups are denied before any other authorization.
+	DenyUsers  []string `json:"denyUsers,omitempty"`
+	DenyGroups []string `json:"denyGroups,omitempty"`
+	// AttributesExec generates the attributes of requests with an external
+	// program, instead of the resource attributes and rewrites.
+	AttributesExec *AttributesExecConfig `json:"attributesExec,omitempty"`
+	// APIServerPaths derives the attributes of requests from their paths,
+	// like the API server does, instead of the resource attributes and
+	// rewrites, e.g. to guard an aggregated API server.
+	APIServerPaths bool `json:"apiServerPaths,omitempty"`
+	// ConstraintsWebhook additionally reviews authorized requests, which
+	// may narrow them down with constraints passed to the upstream.
+	ConstraintsWebhook *ConstraintsWebhookConfig `json:"constraintsWebhook,omitempty"`
+	// TemplateHeaders are the request headers the templates of the resource
+	// attributes can read. Other headers are hidden from templates.
+	TemplateHeaders []string `json:"templateHeaders,omitempty"`
+}
+
+// AttributesExecConfig configures the program generating the attributes of
+// requests. It reads the request as JSON on stdin and writes the attributes
+// as JSON to stdout.
+type AttributesExecConfig struct {
+	// Command is the program and its arguments.
+	Command []string `json:"command"`
+	// Timeout bounds each run of the program, defaults to 5s.
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+}
+
+// ResourceAttributesFor returns the resource attributes of requests of the
+// HTTP method, or nil if requests are authorized by their path.
+func (c *Config) ResourceAttributesFor(method string) ResourceAttributesList {
+	if attrs, ok := c.MethodResourceAttributes[method]; ok {
+		return attrs
+	}
+	return c.ResourceAttributes
+}
+
+// AllResourceAttributes returns the ResourceAttributes and the
+// MethodResourceAttributes, which are to be completed alike.
+func (c *Config) AllResourceAttributes() []*ResourceAttributes {
+	var all []*ResourceAttributes
+	for _, attrs := range c.ResourceAttributes {
+		if attrs != nil {
+			all = append(all, attrs)
+		}
+	}
+	for _, list := range c.MethodResourceAttributes {
+		for _, attrs := range list {
+			if attrs != nil {
+				all = append(all, attrs)
+			}
+		}
+	}
+	return all
+}
+
+// ResourceAttributesList holds the resource attributes a request must be
+// authorized for, all of them, e.g. services/proxy and pods/metrics. In the
+// config file it is a list or a single object.
+type ResourceAttributesList []*ResourceAttributes
+
+// UnmarshalJSON accepts a list as well as a single object.
+func (l *ResourceAttributesList) UnmarshalJSON(b []byte) error {
+	if trimmed := bytes.TrimSpace(b); len(trimmed) > 0 && trimmed[0] == '{' {
+		attrs := &ResourceAttributes{}
+		if err := json.Unmarshal(trimmed, attrs); err != nil {
+			return err
+		}
+		*l = ResourceAttributesList{attrs}
+		return nil
+	}
+
+	var list []*ResourceAttributes
+	if err := json.Unmarshal(b, &list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// MarshalJSON marshals a single element as object, as it is commonly
+// configured.
+func (l ResourceAttributesList) MarshalJSON() ([]byte, error) {
+	if len(l) == 1 {
+		return json.Marshal(l[0])
+	}
+	return json.Marshal([]*ResourceAttributes(l))
 }
 
 // SubjectAccessReviewRewrites describes how SubjectAccessReview may be
@@ -41,6 +147,31 @@ type Config struct {
 type SubjectAccessReviewRewrites struct {
 	ByQueryParameter *QueryParameterRewriteConfig `json:"byQueryParameter,omitempty"`
 	ByHTTPHeader     *HTTPHeaderRewriteConfig     `json:"byHttpHeader,omitempty"`
+	Audit            *RewriteAuditConfig          `json:"audit,omitempty"`
+	// DenialDetails is the detail of 403 responses about a denied
+	// rewritten request, one of "none", "value" or "attributes". Defaults
+	// to "none".
+	DenialDetails string `json:"denialDetails,omitempty"`
+}
+
+const (
+	// DenialDetailsNone omits which rewritten attributes were denied.
+	DenialDetailsNone = "none"
+	// DenialDetailsValue names the denied parameter value.
+	DenialDetailsValue = "value"
+	// DenialDetailsAttributes names the denied parameter value together
+	// with the namespace and name it was rewritten to.
+	DenialDetailsAttributes = "attributes"
+)
+
+// ValidateDenialDetails checks the configured denial details.
+func (r *SubjectAccessReviewRewrites) ValidateDenialDetails() error {
+	switch r.DenialDetails {
+	case "", DenialDetailsNone, DenialDetailsValue, DenialDetailsAttributes:
+		return nil
+	default:
+		return fmt.Errorf("unknown denialDetails %q, must be one of %s, %s or %s", r.DenialDetails, DenialDetailsNone, DenialDetailsValue, DenialDetailsAttributes)
+	}
 }
 
 // QueryParameterRewriteConfig describes which HTTP URL query parameter is to
@@ -55,14 +186,83 @@ type HTTPHeaderRewriteConfig struct {
 	Name string `json:"name,omitempty"`
 }
 
+// RewriteAuditConfig enables logging the parameter values used to rewrite
+// a SubjectAccessReview, together with the requesting user.
+type RewriteAuditConfig struct {
+	// Redaction is applied to the values before logging, one of "none",
+	// "hash" or "truncate". Defaults to "none".
+	Redaction string `json:"redaction,omitempty"`
+	// TruncateLength is the number of characters kept by the "truncate"
+	// redaction. Defaults to 8.
+	TruncateLength int `json:"truncateLength,omitempty"`
+}
+
 // ResourceAttributes describes attributes available for resource request authorization
 type ResourceAttributes struct {
-	Namespace   string `json:"namespace,omitempty"`
-	APIGroup    string `json:"apiGroup,omitempty"`
-	APIVersion  string `json:"apiVersion,omitempty"`
-	Resource    string `json:"resource,omitempty"`
-	Subresource string `json:"subresource,omitempty"`
-	Name        string `json:"name,omitempty"`
+	Namespace     string           `json:"namespace,omitempty"`
+	NamespaceFrom *NamespaceSource `json:"namespaceFrom,omitempty"`
+	APIGroup      string           `json:"apiGroup,omitempty"`
+	APIVersion    string           `json:"apiVersion,omitempty"`
+	Resource      string           `json:"resource,omitempty"`
+	Subresource   string           `json:"subresource,omitempty"`
+	Name          string           `json:"name,omitempty"`
+}
+
+// NamespaceSource describes where the namespace of the resource attributes
+// is taken from instead of a fixed value, so that one configuration can be
+// reused across namespaces.
+type NamespaceSource struct {
+	// Pod uses the namespace of the pod kube-rbac-proxy is running in. It
+	// is read from the POD_NAMESPACE environment variable, which is
+	// commonly set via the downward API, falling back to the namespace of
+	// the mounted service account.
+	Pod bool `json:"pod,omitempty"`
+	// HTTPHeader uses the value of the given HTTP header of the request,
+	// which must be a single valid namespace name. The request is authorized
+	// for the namespace the client sent and the header is passed on to the
+	// upstream unchanged, so the upstream must scope its response by the
+	// same header. Unless a trusted front proxy sets the header, it must not
+	// be trusted for anything else.
+	HTTPHeader string `json:"httpHeader,omitempty"`
+}
+
+// envReference matches the ${VAR} references of ExpandEnv and the $$ escape.
+var envReference = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// ExpandEnv replaces ${VAR} references to environment variables in the fixed
+// resource attributes with their values. Any other $ is kept, and $$ escapes
+// a literal $, e.g. $${VAR}. Referencing an unset variable is an error, so
+// that a missing variable doesn't silently widen the authorized scope.
+func (r *ResourceAttributes) ExpandEnv() error {
+	missing := map[string]struct{}{}
+	expand := func(s string) string {
+		return envReference.ReplaceAllStringFunc(s, func(ref string) string {
+			if ref == "$$" {
+				return "$"
+			}
+			name := ref[2 : len(ref)-1]
+			value, ok := os.LookupEnv(name)
+			if !ok {
+				missing[name] = struct{}{}
+			}
+			return value
+		})
+	}
+
+	for _, field := range []*string{&r.Namespace, &r.APIGroup, &r.APIVersion, &r.Resource, &r.Subresource, &r.Name} {
+		*field = expand(*field)
+	}
+
+	if len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("resource attributes reference unset environment variables: %s", strings.Join(names, ", "))
+	}
+
+	return nil
 }
 
 // StaticAuthorizationConfig describes what is needed to specify a static
@@ -77,27 +277,77 @@ type StaticAuthorizationConfig struct {
 	Name            string `json:"name,omitempty"`
 	ResourceRequest bool   `json:"resourceRequest,omitempty"`
 	Path            string `json:"path,omitempty"`
+	// Verbs and NonResourceURLs match like in RBAC rules, any of the verbs
+	// or "*", and any of the URLs, which may end with "*" to match any
+	// suffix. They can't be combined with Verb and Path respectively.
+	Verbs           []string `json:"verbs,omitempty"`
+	NonResourceURLs []string `json:"nonResourceURLs,omitempty"`
+	// NotBefore and NotAfter limit the validity of the rule, e.g. for
+	// temporary break-glass access. Both are optional.
+	NotBefore *metav1.Time `json:"notBefore,omitempty"`
+	NotAfter  *metav1.Time `json:"notAfter,omitempty"`
+}
+
+// activeAt reports whether t is within the validity of the rule.
+func (saConfig StaticAuthorizationConfig) activeAt(t time.Time) bool {
+	if saConfig.NotBefore != nil && t.Before(saConfig.NotBefore.Time) {
+		return false
+	}
+	if saConfig.NotAfter != nil && t.After(saConfig.NotAfter.Time) {
+		return false
+	}
+	return true
 }
 
 type UserConfig struct {
-	Name   string   `json:"name,omitempty"`
+	Name string `json:"name,omitempty"`
+	// Groups, if set, require the user to be in one of them.
 	Groups []string `json:"groups,omitempty"`
 }
 
+// matches reports whether the user has the name and is in one of the groups
+// of the config.
+func (uc UserConfig) matches(u user.Info) bool {
+	if uc.Name == "" && len(uc.Groups) == 0 {
+		return true
+	}
+	if u == nil {
+		return false
+	}
+	if uc.Name != "" && uc.Name != u.GetName() {
+		return false
+	}
+	if len(uc.Groups) == 0 {
+		return true
+	}
+	for _, g := range u.GetGroups() {
+		if slices.Contains(uc.Groups, g) {
+			return true
+		}
+	}
+	return false
+}
+
 // NewSarAuthorizer creates an authorizer compatible with the kubelet's needs
-func NewSarAuthorizer(client authorizationclient.AuthorizationV1Interface) (authorizer.Authorizer, error) {
+func NewSarAuthorizer(client authorizationclient.AuthorizationV1Interface) (*CachingAuthorizer, error) {
 	if client == nil {
 		return nil, errors.New("no client provided, cannot use webhook authorization")
 	}
 	authorizerConfig := authorizerfactory.DelegatingAuthorizerConfig{
 		SubjectAccessReviewClient: client,
-		// Defaults are most probably taken from: kubernetes/pkg/kubelet/apis/config/v1beta1/defaults.go
-		// Defaults that are more reasonable: apiserver/pkg/server/options/authorization.go
-		AllowCacheTTL:       5 * time.Minute,
-		DenyCacheTTL:        30 * time.Second,
+		// Decisions are cached by the CachingAuthorizer, which can be flushed.
+		AllowCacheTTL:       0,
+		DenyCacheTTL:        0,
 		WebhookRetryBackoff: options.DefaultAuthWebhookRetryBackoff(),
 	}
-	return authorizerConfig.New()
+	sarAuthorizer, err := authorizerConfig.New()
+	if err != nil {
+		return nil, err
+	}
+
+	// Defaults are most probably taken from: kubernetes/pkg/kubelet/apis/config/v1beta1/defaults.go
+	// Defaults that are more reasonable: apiserver/pkg/server/options/authorization.go
+	return NewCachingAuthorizer(sarAuthorizer, 5*time.Minute, 30*time.Second), nil
 }
 
 type staticAuthorizer struct {
@@ -113,20 +363,18 @@ func (saConfig StaticAuthorizationConfig) Matches(a authorizer.Attributes) bool
 		}
 	}
 
-	userName := ""
-	if a.GetUser() != nil {
-		userName = a.GetUser().GetName()
-	}
-
-	if isAllowed(saConfig.User.Name, userName) &&
+	if saConfig.User.matches(a.GetUser()) &&
 		isAllowed(saConfig.Verb, a.GetVerb()) &&
+		(len(saConfig.Verbs) == 0 || hasOrWildcard(saConfig.Verbs, a.GetVerb())) &&
 		isAllowed(saConfig.Namespace, a.GetNamespace()) &&
 		isAllowed(saConfig.APIGroup, a.GetAPIGroup()) &&
 		isAllowed(saConfig.Resource, a.GetResource()) &&
 		isAllowed(saConfig.Subresource, a.GetSubresource()) &&
 		isAllowed(saConfig.Name, a.GetName()) &&
 		isAllowed(saConfig.Path, a.GetPath()) &&
-		saConfig.ResourceRequest == a.IsResourceRequest() {
+		(len(saConfig.NonResourceURLs) == 0 || nonResourceURLMatches(saConfig.NonResourceURLs, a.GetPath())) &&
+		saConfig.ResourceRequest == a.IsResourceRequest() &&
+		saConfig.activeAt(time.Now()) {
 		return true
 	}
 	return false
@@ -136,6 +384,9 @@ func (sa staticAuthorizer) Authorize(ctx context.Context, a authorizer.Attribute
 	// compare a against the configured static auths
 	for _, saConfig := range sa.config {
 		if saConfig.Matches(a) {
+			if (saConfig.NotBefore != nil || saConfig.NotAfter != nil) && a.GetUser() != nil {
+				klog.FromContext(ctx).Info("Allowing by time-limited static auth config", "user", a.GetUser().GetName(), "notBefore", saConfig.NotBefore, "notAfter", saConfig.NotAfter)
+			}
 			return authorizer.DecisionAllow, "found corresponding static auth config", nil
 		}
 	}
@@ -145,9 +396,23 @@ func (sa staticAuthorizer) Authorize(ctx context.Context, a authorizer.Attribute
 
 func NewStaticAuthorizer(config []StaticAuthorizationConfig) (*staticAuthorizer, error) {
 	for _, c := range config {
-		if c.ResourceRequest != (c.Path == "") {
+		if c.ResourceRequest != (c.Path == "" && len(c.NonResourceURLs) == 0) {
 			return nil, fmt.Errorf("invalid configuration: resource requests must not include a path: %v", config)
 		}
+		if c.Path != "" && len(c.NonResourceURLs) > 0 {
+			return nil, fmt.Errorf("invalid configuration: path and nonResourceURLs must not be set at the same time: %v", config)
+		}
+		if c.Verb != "" && len(c.Verbs) > 0 {
+			return nil, fmt.Errorf("invalid configuration: verb and verbs must not be set at the same time: %v", config)
+		}
+		for _, url := range c.NonResourceURLs {
+			if i := strings.Index(url, "*"); i >= 0 && i != len(url)-1 {
+				return nil, fmt.Errorf("invalid configuration: nonResourceURL %q may only end with *", url)
+			}
+		}
+		if c.NotBefore != nil && c.NotAfter != nil && c.NotAfter.Before(c.NotBefore) {
+			return nil, fmt.Errorf("invalid configuration: notAfter %s is before notBefore %s", c.NotAfter, c.NotBefore)
+		}
 	}
 	return &staticAuthorizer{config}, nil
 }
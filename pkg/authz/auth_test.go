@@ -18,13 +18,20 @@ package authz
 
 import (
 	"context"
+	"encoding/json"
+	"reflect"
 	"testing"
+	"time"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/apiserver/pkg/authorization/authorizer"
 )
 
 func TestStaticAuthorizer(t *testing.T) {
+	past := metav1.NewTime(time.Now().Add(-time.Hour))
+	future := metav1.NewTime(time.Now().Add(time.Hour))
+
 	tests := []struct {
 		name   string
 		config []StaticAuthorizationConfig
@@ -120,6 +127,95 @@ func TestStaticAuthorizer(t *testing.T) {
 				authorizer.AttributesRecord{Verb: "get", Resource: "services", ResourceRequest: true},
 			},
 		},
+		{
+			name: "nonResourceURLs",
+			config: []StaticAuthorizationConfig{
+				{
+					User:            UserConfig{Groups: []string{"monitoring", "admins"}},
+					Verbs:           []string{"get", "list"},
+					NonResourceURLs: []string{"/metrics", "/debug/*"},
+				},
+			},
+			shouldPass: []authorizer.Attributes{
+				authorizer.AttributesRecord{User: &user.DefaultInfo{Name: "system:foo", Groups: []string{"monitoring"}}, Verb: "get", Path: "/metrics"},
+				authorizer.AttributesRecord{User: &user.DefaultInfo{Name: "system:foo", Groups: []string{"admins"}}, Verb: "list", Path: "/debug/pprof/heap"},
+				authorizer.AttributesRecord{User: &user.DefaultInfo{Name: "system:foo", Groups: []string{"monitoring"}}, Verb: "get", Path: "/debug/"},
+			},
+			shouldNoOpinion: []authorizer.Attributes{
+				// wrong group
+				authorizer.AttributesRecord{User: &user.DefaultInfo{Name: "system:foo", Groups: []string{"developers"}}, Verb: "get", Path: "/metrics"},
+				// no user
+				authorizer.AttributesRecord{Verb: "get", Path: "/metrics"},
+				// wrong verb
+				authorizer.AttributesRecord{User: &user.DefaultInfo{Name: "system:foo", Groups: []string{"monitoring"}}, Verb: "update", Path: "/metrics"},
+				// no prefix match without *
+				authorizer.AttributesRecord{User: &user.DefaultInfo{Name: "system:foo", Groups: []string{"monitoring"}}, Verb: "get", Path: "/metrics/foo"},
+				authorizer.AttributesRecord{User: &user.DefaultInfo{Name: "system:foo", Groups: []string{"monitoring"}}, Verb: "get", Path: "/debug"},
+			},
+		},
+		{
+			name: "wildcardVerbs",
+			config: []StaticAuthorizationConfig{
+				{User: UserConfig{Name: "system:foo"}, Verbs: []string{"*"}, NonResourceURLs: []string{"*"}},
+			},
+			shouldPass: []authorizer.Attributes{
+				authorizer.AttributesRecord{User: &user.DefaultInfo{Name: "system:foo"}, Verb: "delete", Path: "/anything"},
+			},
+			shouldNoOpinion: []authorizer.Attributes{
+				authorizer.AttributesRecord{User: &user.DefaultInfo{Name: "system:bar"}, Verb: "delete", Path: "/anything"},
+				authorizer.AttributesRecord{User: &user.DefaultInfo{Name: "system:foo"}, Verb: "get", Resource: "pods", ResourceRequest: true},
+			},
+		},
+		{
+			name: "pathAndNonResourceURLs",
+			config: []StaticAuthorizationConfig{
+				{Path: "/metrics", NonResourceURLs: []string{"/debug/*"}},
+			},
+			shouldFail: true,
+		},
+		{
+			name: "verbAndVerbs",
+			config: []StaticAuthorizationConfig{
+				{Verb: "get", Verbs: []string{"list"}, NonResourceURLs: []string{"/metrics"}},
+			},
+			shouldFail: true,
+		},
+		{
+			name: "innerWildcard",
+			config: []StaticAuthorizationConfig{
+				{NonResourceURLs: []string{"/metrics/*/foo"}},
+			},
+			shouldFail: true,
+		},
+		{
+			name: "nonResourceURLsOfResourceRequest",
+			config: []StaticAuthorizationConfig{
+				{NonResourceURLs: []string{"/metrics"}, ResourceRequest: true},
+			},
+			shouldFail: true,
+		},
+		{
+			name: "validityWindow",
+			config: []StaticAuthorizationConfig{
+				{User: UserConfig{Name: "break-glass"}, Path: "/metrics", NotBefore: &past, NotAfter: &future},
+				{User: UserConfig{Name: "expired"}, Path: "/metrics", NotAfter: &past},
+				{User: UserConfig{Name: "pending"}, Path: "/metrics", NotBefore: &future},
+			},
+			shouldPass: []authorizer.Attributes{
+				authorizer.AttributesRecord{User: &user.DefaultInfo{Name: "break-glass"}, Verb: "get", Path: "/metrics"},
+			},
+			shouldNoOpinion: []authorizer.Attributes{
+				authorizer.AttributesRecord{User: &user.DefaultInfo{Name: "expired"}, Verb: "get", Path: "/metrics"},
+				authorizer.AttributesRecord{User: &user.DefaultInfo{Name: "pending"}, Verb: "get", Path: "/metrics"},
+			},
+		},
+		{
+			name: "invalidValidityWindow",
+			config: []StaticAuthorizationConfig{
+				{Path: "/metrics", NotBefore: &future, NotAfter: &past},
+			},
+			shouldFail: true,
+		},
 		{
 			name: "resourceRequestSpecificUser",
 			config: []StaticAuthorizationConfig{
@@ -157,3 +253,72 @@ func TestStaticAuthorizer(t *testing.T) {
 		})
 	}
 }
+
+func TestResourceAttributesExpandEnv(t *testing.T) {
+	t.Setenv("POD_NAMESPACE", "monitoring")
+	t.Setenv("RESOURCE", "services")
+
+	ra := &ResourceAttributes{
+		Namespace:   "${POD_NAMESPACE}",
+		APIVersion:  "v1",
+		Resource:    "${RESOURCE}",
+		Subresource: "$proxy",
+		Name:        "cost$$${RESOURCE}$",
+	}
+	if err := ra.ExpandEnv(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := ResourceAttributes{
+		Namespace:   "monitoring",
+		APIVersion:  "v1",
+		Resource:    "services",
+		Subresource: "$proxy",
+		Name:        "cost$services$",
+	}
+	if *ra != want {
+		t.Errorf("want %+v, got %+v", want, *ra)
+	}
+
+	ra = &ResourceAttributes{Namespace: "${UNSET_NAMESPACE_VARIABLE}"}
+	if err := ra.ExpandEnv(); err == nil {
+		t.Error("want error for unset environment variable")
+	}
+}
+
+func TestResourceAttributesListJSON(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		json string
+		want ResourceAttributesList
+	}{
+		{
+			name: "single object",
+			json: `{"resourceAttributes":{"resource":"services","subresource":"proxy"}}`,
+			want: ResourceAttributesList{{Resource: "services", Subresource: "proxy"}},
+		},
+		{
+			name: "list",
+			json: `{"resourceAttributes":[{"resource":"services","subresource":"proxy"},{"resource":"pods","subresource":"metrics"}]}`,
+			want: ResourceAttributesList{{Resource: "services", Subresource: "proxy"}, {Resource: "pods", Subresource: "metrics"}},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{}
+			if err := json.Unmarshal([]byte(tt.json), &cfg); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(cfg.ResourceAttributes, tt.want) {
+				t.Errorf("want %v, got %v", tt.want, cfg.ResourceAttributes)
+			}
+
+			b, err := json.Marshal(cfg)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(b) != tt.json {
+				t.Errorf("want %s marshaled, got %s", tt.json, b)
+			}
+		})
+	}
+}
@@ -0,0 +1,314 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authz
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/lru"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/authn"
+)
+
+const (
+	decisionCacheSize = 8192
+
+	cacheEvictionCapacity = "capacity"
+	cacheEvictionExpired  = "expired"
+	cacheEvictionFlush    = "flush"
+)
+
+var (
+	cacheEntries = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Namespace:      "kube_rbac_proxy",
+			Subsystem:      "authorization_cache",
+			Name:           "entries",
+			Help:           "Number of cached authorization decisions by decision.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"decision"},
+	)
+	cacheRequests = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      "kube_rbac_proxy",
+			Subsystem:      "authorization_cache",
+			Name:           "requests_total",
+			Help:           "Number of authorization cache lookups by result, one of hit, miss or bypass.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"result"},
+	)
+	cacheEvictions = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      "kube_rbac_proxy",
+			Subsystem:      "authorization_cache",
+			Name:           "evictions_total",
+			Help:           "Number of authorization decisions evicted from the cache by reason, one of capacity, expired or flush.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"reason"},
+	)
+)
+
+func init() {
+	legacyregistry.MustRegister(cacheEntries, cacheRequests, cacheEvictions)
+}
+
+type cachedDecision struct {
+	decision  authorizer.Decision
+	reason    string
+	expires   time.Time
+	partition authn.CachePartition
+}
+
+// CachingAuthorizer caches the allow and deny decisions of an authorizer for
+// separate TTLs. Errors are not cached. Unlike the cache of the webhook
+// authorizer, it can be flushed, as a whole or by the issuer and audience of
+// the tokens, and reports metrics.
+type CachingAuthorizer struct {
+	authorizer authorizer.Authorizer
+	allowTTL   time.Duration
+	denyTTL    time.Duration
+
+	mu          sync.Mutex // protects the fields below
+	cache       *lru.Cache
+	evictReason string
+	// partitions holds the keys of the cached decisions by partition.
+	partitions map[string]*partitionKeys
+}
+
+type partitionKeys struct {
+	partition authn.CachePartition
+	keys      sets.Set[string]
+}
+
+// NewCachingAuthorizer wraps the authorizer with a decision cache.
+func NewCachingAuthorizer(a authorizer.Authorizer, allowTTL, denyTTL time.Duration) *CachingAuthorizer {
+	c := &CachingAuthorizer{
+		authorizer: a,
+		allowTTL:   allowTTL,
+		denyTTL:    denyTTL,
+		partitions: map[string]*partitionKeys{},
+	}
+	c.cache = lru.NewWithEvictionFunc(decisionCacheSize, c.onEvicted)
+
+	return c
+}
+
+func (c *CachingAuthorizer) Authorize(ctx context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
+	partition := authn.CachePartitionFrom(ctx)
+	key, err := cacheKey(a, partition)
+	if err != nil {
+		return c.authorizer.Authorize(ctx, a)
+	}
+
+	// Bypassing requests refresh the cached decision.
+	if authn.CacheBypassed(ctx) {
+		cacheRequests.WithLabelValues("bypass").Inc()
+	} else if entry, ok := c.lookup(key); ok {
+		cacheRequests.WithLabelValues("hit").Inc()
+		return entry.decision, entry.reason, nil
+	} else {
+		cacheRequests.WithLabelValues("miss").Inc()
+	}
+
+	decision, reason, err := c.authorizer.Authorize(ctx, a)
+	if err != nil {
+		return decision, reason, err
+	}
+
+	ttl := c.denyTTL
+	if decision == authorizer.DecisionAllow {
+		ttl = c.allowTTL
+	}
+	if ttl <= 0 {
+		return decision, reason, nil
+	}
+
+	c.mu.Lock()
+	// Replacing an entry evicts the old one, which is accounted as expired.
+	c.evictReason = cacheEvictionExpired
+	c.cache.Remove(key)
+	c.evictReason = cacheEvictionCapacity
+	c.cache.Add(key, &cachedDecision{decision: decision, reason: reason, expires: time.Now().Add(ttl), partition: partition})
+	cacheEntries.WithLabelValues(decisionLabel(decision)).Inc()
+	id := partitionID(partition)
+	if c.partitions[id] == nil {
+		c.partitions[id] = &partitionKeys{partition: partition, keys: sets.New[string]()}
+	}
+	c.partitions[id].keys.Insert(key)
+	c.mu.Unlock()
+
+	return decision, reason, nil
+}
+
+// lookup returns the unexpired decision cached for the key.
+func (c *CachingAuthorizer) lookup(key string) (*cachedDecision, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	v, ok := c.cache.Get(key)
+	if !ok {
+		return nil, false
+	}
+	entry := v.(*cachedDecision)
+	if time.Now().Before(entry.expires) {
+		return entry, true
+	}
+
+	c.evictReason = cacheEvictionExpired
+	c.cache.Remove(key)
+	return nil, false
+}
+
+// Flush drops all cached decisions, such that permission changes take effect
+// immediately.
+func (c *CachingAuthorizer) Flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.evictReason = cacheEvictionFlush
+	c.cache.Clear()
+}
+
+// FlushPartition drops the cached decisions of tokens of the issuer and for
+// the audience, an empty issuer or audience matching any. Decisions of
+// requests authenticated otherwise, e.g. by client certificates, are in the
+// partition without issuer and audiences. It returns the number of dropped
+// decisions.
+func (c *CachingAuthorizer) FlushPartition(issuer, audience string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var keys []string
+	for _, p := range c.partitions {
+		if p.partition.Matches(issuer, audience) {
+			keys = append(keys, p.keys.UnsortedList()...)
+		}
+	}
+
+	c.evictReason = cacheEvictionFlush
+	for _, key := range keys {
+		c.cache.Remove(key)
+	}
+	return len(keys)
+}
+
+// FlushHandler flushes the cache on POST requests, only the decisions of the
+// partition given by the issuer and audience query parameters, if any.
+func (c *CachingAuthorizer) FlushHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		issuer, audience := req.URL.Query().Get("issuer"), req.URL.Query().Get("audience")
+		if issuer == "" && audience == "" {
+			c.Flush()
+			klog.InfoS("Flushed authorization cache")
+		} else {
+			n := c.FlushPartition(issuer, audience)
+			klog.InfoS("Flushed authorization cache partition", "issuer", issuer, "audience", audience, "decisions", n)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// onEvicted is called with c.mu held.
+func (c *CachingAuthorizer) onEvicted(key lru.Key, v interface{}) {
+	entry := v.(*cachedDecision)
+	cacheEntries.WithLabelValues(decisionLabel(entry.decision)).Dec()
+	cacheEvictions.WithLabelValues(c.evictReason).Inc()
+
+	id := partitionID(entry.partition)
+	if p := c.partitions[id]; p != nil {
+		p.keys.Delete(key.(string))
+		if p.keys.Len() == 0 {
+			delete(c.partitions, id)
+		}
+	}
+}
+
+func partitionID(p authn.CachePartition) string {
+	return p.Issuer + "\x00" + strings.Join(p.Audiences, "\x00")
+}
+
+func decisionLabel(d authorizer.Decision) string {
+	if d == authorizer.DecisionAllow {
+		return "allow"
+	}
+	return "deny"
+}
+
+// cacheKey identifies the attributes including the full user info, as the
+// decision of a SubjectAccessReview depends on all of it, and the partition.
+func cacheKey(a authorizer.Attributes, partition authn.CachePartition) (string, error) {
+	key := struct {
+		Issuer          string              `json:"iss"`
+		Audiences       []string            `json:"aud"`
+		User            string              `json:"u"`
+		UID             string              `json:"uid"`
+		Groups          []string            `json:"g"`
+		Extra           map[string][]string `json:"e"`
+		Verb            string              `json:"v"`
+		Namespace       string              `json:"ns"`
+		APIGroup        string              `json:"ag"`
+		APIVersion      string              `json:"av"`
+		Resource        string              `json:"r"`
+		Subresource     string              `json:"sr"`
+		Name            string              `json:"n"`
+		ResourceRequest bool                `json:"rr"`
+		Path            string              `json:"p"`
+	}{
+		Issuer:          partition.Issuer,
+		Audiences:       partition.Audiences,
+		Verb:            a.GetVerb(),
+		Namespace:       a.GetNamespace(),
+		APIGroup:        a.GetAPIGroup(),
+		APIVersion:      a.GetAPIVersion(),
+		Resource:        a.GetResource(),
+		Subresource:     a.GetSubresource(),
+		Name:            a.GetName(),
+		ResourceRequest: a.IsResourceRequest(),
+		Path:            a.GetPath(),
+	}
+	if u := a.GetUser(); u != nil {
+		key.User = u.GetName()
+		key.UID = u.GetUID()
+		key.Groups = u.GetGroups()
+		key.Extra = u.GetExtra()
+	}
+
+	b, err := json.Marshal(key)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
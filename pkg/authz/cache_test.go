@@ -0,0 +1,152 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authz
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/authn"
+)
+
+type countingAuthorizer struct {
+	calls    int
+	decision authorizer.Decision
+	err      error
+}
+
+func (a *countingAuthorizer) Authorize(context.Context, authorizer.Attributes) (authorizer.Decision, string, error) {
+	a.calls++
+	return a.decision, "", a.err
+}
+
+func TestCachingAuthorizer(t *testing.T) {
+	ctx := context.Background()
+	attrs := func(name string) authorizer.Attributes {
+		return authorizer.AttributesRecord{User: &user.DefaultInfo{Name: name}, Verb: "get", Path: "/metrics"}
+	}
+
+	inner := &countingAuthorizer{decision: authorizer.DecisionAllow}
+	c := NewCachingAuthorizer(inner, time.Minute, 0)
+
+	for i := 0; i < 3; i++ {
+		if d, _, _ := c.Authorize(ctx, attrs("alice")); d != authorizer.DecisionAllow {
+			t.Fatalf("want allow, got %v", d)
+		}
+	}
+	if inner.calls != 1 {
+		t.Errorf("want allow decisions to be cached, got %d calls", inner.calls)
+	}
+
+	_, _, _ = c.Authorize(ctx, attrs("bob"))
+	if inner.calls != 2 {
+		t.Errorf("want cache keyed by user, got %d calls", inner.calls)
+	}
+
+	rec := httptest.NewRecorder()
+	c.FlushHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("want GET to be rejected, got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	c.FlushHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("want flush to succeed, got %d", rec.Code)
+	}
+
+	inner.decision = authorizer.DecisionNoOpinion
+	if d, _, _ := c.Authorize(ctx, attrs("alice")); d != authorizer.DecisionNoOpinion {
+		t.Errorf("want flushed decision to be re-evaluated, got %v", d)
+	}
+
+	calls := inner.calls
+	_, _, _ = c.Authorize(ctx, attrs("alice"))
+	if inner.calls != calls+1 {
+		t.Errorf("want deny decisions not to be cached with a zero TTL")
+	}
+
+	inner.err = errors.New("unavailable")
+	inner.decision = authorizer.DecisionAllow
+	_, _, _ = c.Authorize(ctx, attrs("carol"))
+	inner.err = nil
+	calls = inner.calls
+	_, _, _ = c.Authorize(ctx, attrs("carol"))
+	if inner.calls != calls+1 {
+		t.Errorf("want errors not to be cached")
+	}
+}
+
+func TestCachingAuthorizerBypass(t *testing.T) {
+	attrs := authorizer.AttributesRecord{User: &user.DefaultInfo{Name: "alice"}, Verb: "get", Path: "/metrics"}
+	inner := &countingAuthorizer{decision: authorizer.DecisionAllow}
+	c := NewCachingAuthorizer(inner, time.Minute, time.Minute)
+
+	_, _, _ = c.Authorize(context.Background(), attrs)
+	inner.decision = authorizer.DecisionNoOpinion
+
+	if d, _, _ := c.Authorize(authn.WithCacheBypass(context.Background()), attrs); d != authorizer.DecisionNoOpinion || inner.calls != 2 {
+		t.Errorf("want a fresh decision, got %v after %d calls", d, inner.calls)
+	}
+	if d, _, _ := c.Authorize(context.Background(), attrs); d != authorizer.DecisionNoOpinion || inner.calls != 2 {
+		t.Errorf("want the fresh decision to be cached, got %v after %d calls", d, inner.calls)
+	}
+}
+
+func TestCachingAuthorizerPartitions(t *testing.T) {
+	attrs := authorizer.AttributesRecord{User: &user.DefaultInfo{Name: "alice"}, Verb: "get", Path: "/metrics"}
+	oldIdP := authn.WithCachePartition(context.Background(), authn.CachePartition{Issuer: "https://old.example.com", Audiences: []string{"proxy"}})
+	newIdP := authn.WithCachePartition(context.Background(), authn.CachePartition{Issuer: "https://new.example.com", Audiences: []string{"proxy"}})
+
+	inner := &countingAuthorizer{decision: authorizer.DecisionAllow}
+	c := NewCachingAuthorizer(inner, time.Minute, time.Minute)
+
+	for _, ctx := range []context.Context{oldIdP, newIdP, oldIdP, newIdP} {
+		_, _, _ = c.Authorize(ctx, attrs)
+	}
+	if inner.calls != 2 {
+		t.Fatalf("want decisions cached by partition, got %d calls", inner.calls)
+	}
+
+	rec := httptest.NewRecorder()
+	c.FlushHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/?issuer=https://old.example.com", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("want flush to succeed, got %d", rec.Code)
+	}
+
+	_, _, _ = c.Authorize(newIdP, attrs)
+	if inner.calls != 2 {
+		t.Errorf("want the decision of the other issuer to be kept, got %d calls", inner.calls)
+	}
+	_, _, _ = c.Authorize(oldIdP, attrs)
+	if inner.calls != 3 {
+		t.Errorf("want the decision of the flushed issuer to be re-evaluated, got %d calls", inner.calls)
+	}
+
+	if n := c.FlushPartition("", "proxy"); n != 2 {
+		t.Errorf("want both decisions of the audience to be flushed, got %d", n)
+	}
+	if n := c.FlushPartition("", "other"); n != 0 {
+		t.Errorf("want no decisions of another audience, got %d", n)
+	}
+}
@@ -0,0 +1,229 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authz
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"time"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+)
+
+// maxConstraintsReviewSize bounds the response of the constraints webhook.
+const maxConstraintsReviewSize = 1 << 20
+
+// ConstraintsWebhookConfig configures a webhook reviewing authorized
+// requests like a SubjectAccessReview, which may narrow an allowed request
+// down with constraints, e.g. the namespaces the user may see. The
+// constraints are passed to the upstream in headers or query parameters.
+type ConstraintsWebhookConfig struct {
+	// URL is where the reviews are POSTed to.
+	URL string `json:"url"`
+	// CAFile holds the CA certificates of the webhook, instead of the
+	// system roots.
+	CAFile string `json:"caFile,omitempty"`
+	// Timeout bounds each review, defaults to 5s.
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+	// Headers maps the names of constraints to the request headers their
+	// comma-separated values are set in. The headers are removed from
+	// requests without the constraint, so clients can't set them.
+	Headers map[string]string `json:"headers,omitempty"`
+	// QueryParameters maps the names of constraints to query parameters.
+	// Requested values outside of the constraint are dropped, requests
+	// without the parameter get all values of the constraint. Requests
+	// left without a value are forbidden.
+	QueryParameters map[string]string `json:"queryParameters,omitempty"`
+}
+
+// Validate checks the URL and that the constraints are passed on.
+func (c *ConstraintsWebhookConfig) Validate() error {
+	u, err := url.Parse(c.URL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("constraintsWebhook url %q must be an http or https URL", c.URL)
+	}
+	if c.Timeout.Duration < 0 {
+		return errors.New("constraintsWebhook timeout must not be negative")
+	}
+	if len(c.Headers) == 0 && len(c.QueryParameters) == 0 {
+		return errors.New("constraintsWebhook must map constraints to headers or queryParameters")
+	}
+	for name, header := range c.Headers {
+		if name == "" || header == "" {
+			return errors.New("constraintsWebhook headers must map constraint names to header names")
+		}
+	}
+	for name, param := range c.QueryParameters {
+		if name == "" || param == "" {
+			return errors.New("constraintsWebhook queryParameters must map constraint names to parameter names")
+		}
+	}
+	return nil
+}
+
+// ConstraintsReview is exchanged with the constraints webhook. It is sent
+// as a SubjectAccessReview of authorization.k8s.io/v1, whose status may
+// carry constraints in addition.
+type ConstraintsReview struct {
+	metav1.TypeMeta `json:",inline"`
+	Spec            authorizationv1.SubjectAccessReviewSpec `json:"spec"`
+	Status          ConstraintsReviewStatus                 `json:"status"`
+}
+
+// ConstraintsReviewStatus is the decision of the constraints webhook.
+type ConstraintsReviewStatus struct {
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason,omitempty"`
+	// Constraints are the values the allowed request is narrowed down to,
+	// by constraint name.
+	Constraints map[string][]string `json:"constraints,omitempty"`
+}
+
+// ConstraintsWebhook reviews authorized requests with a webhook.
+type ConstraintsWebhook struct {
+	url    string
+	client *http.Client
+}
+
+// NewConstraintsWebhook creates a ConstraintsWebhook for a validated config.
+func NewConstraintsWebhook(cfg *ConstraintsWebhookConfig) (*ConstraintsWebhook, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read constraintsWebhook CA file: %w", err)
+		}
+		roots := x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in constraintsWebhook CA file %q", cfg.CAFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}
+	}
+
+	timeout := cfg.Timeout.Duration
+	if timeout == 0 {
+		timeout = 5 * time.Second
+	}
+
+	return &ConstraintsWebhook{
+		url:    cfg.URL,
+		client: &http.Client{Transport: transport, Timeout: timeout},
+	}, nil
+}
+
+// Review asks the webhook whether the request of the attributes is allowed
+// and for its constraints.
+func (w *ConstraintsWebhook) Review(ctx context.Context, a authorizer.Attributes) (*ConstraintsReviewStatus, error) {
+	body, err := json.Marshal(&ConstraintsReview{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: authorizationv1.SchemeGroupVersion.String(),
+			Kind:       "SubjectAccessReview",
+		},
+		Spec: reviewSpec(a),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal review: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send review: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	review := &ConstraintsReview{}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxConstraintsReviewSize)).Decode(review); err != nil {
+		return nil, fmt.Errorf("failed to decode review: %w", err)
+	}
+	return &review.Status, nil
+}
+
+// reviewSpec describes the attributes like a SubjectAccessReview.
+func reviewSpec(a authorizer.Attributes) authorizationv1.SubjectAccessReviewSpec {
+	spec := authorizationv1.SubjectAccessReviewSpec{}
+	if u := a.GetUser(); u != nil {
+		spec.User = u.GetName()
+		spec.UID = u.GetUID()
+		spec.Groups = u.GetGroups()
+		if extra := u.GetExtra(); len(extra) > 0 {
+			spec.Extra = make(map[string]authorizationv1.ExtraValue, len(extra))
+			for k, v := range extra {
+				spec.Extra[k] = authorizationv1.ExtraValue(v)
+			}
+		}
+	}
+
+	if a.IsResourceRequest() {
+		spec.ResourceAttributes = &authorizationv1.ResourceAttributes{
+			Namespace:   a.GetNamespace(),
+			Verb:        a.GetVerb(),
+			Group:       a.GetAPIGroup(),
+			Version:     a.GetAPIVersion(),
+			Resource:    a.GetResource(),
+			Subresource: a.GetSubresource(),
+			Name:        a.GetName(),
+		}
+	} else {
+		spec.NonResourceAttributes = &authorizationv1.NonResourceAttributes{
+			Path: a.GetPath(),
+			Verb: a.GetVerb(),
+		}
+	}
+
+	return spec
+}
+
+// IntersectConstraints merges the constraints of the reviews of a request,
+// which has to satisfy all of them. A nil result has no constraints.
+func IntersectConstraints(a, b map[string][]string) map[string][]string {
+	if a == nil {
+		return b
+	}
+	for name, values := range b {
+		current, ok := a[name]
+		if !ok {
+			a[name] = values
+			continue
+		}
+		a[name] = slices.DeleteFunc(slices.Clone(current), func(v string) bool {
+			return !slices.Contains(values, v)
+		})
+	}
+	return a
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authz
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+)
+
+func TestConstraintsWebhook(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		review := &ConstraintsReview{}
+		if err := json.NewDecoder(req.Body).Decode(review); err != nil {
+			t.Errorf("failed to decode review: %v", err)
+		}
+		if review.Kind != "SubjectAccessReview" || review.Spec.ResourceAttributes == nil {
+			t.Errorf("want a SubjectAccessReview of resource attributes, got %+v", review)
+		}
+		review.Status.Allowed = review.Spec.User == "alice"
+		if review.Status.Allowed {
+			review.Status.Constraints = map[string][]string{"namespaces": {"team-a", "team-b"}}
+		}
+		_ = json.NewEncoder(w).Encode(review)
+	}))
+	defer server.Close()
+
+	cfg := &ConstraintsWebhookConfig{URL: server.URL, Headers: map[string]string{"namespaces": "X-Namespaces"}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	webhook, err := NewConstraintsWebhook(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	review := func(name string) *ConstraintsReviewStatus {
+		status, err := webhook.Review(context.Background(), authorizer.AttributesRecord{
+			User:            &user.DefaultInfo{Name: name},
+			Verb:            "list",
+			Resource:        "pods",
+			ResourceRequest: true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return status
+	}
+
+	if status := review("alice"); !status.Allowed || !reflect.DeepEqual(status.Constraints["namespaces"], []string{"team-a", "team-b"}) {
+		t.Errorf("want alice allowed with constraints, got %+v", status)
+	}
+	if status := review("bob"); status.Allowed {
+		t.Errorf("want bob denied, got %+v", status)
+	}
+}
+
+func TestConstraintsWebhookConfigValidate(t *testing.T) {
+	for name, cfg := range map[string]*ConstraintsWebhookConfig{
+		"no URL":           {Headers: map[string]string{"namespaces": "X-Namespaces"}},
+		"relative URL":     {URL: "/review", Headers: map[string]string{"namespaces": "X-Namespaces"}},
+		"no mapping":       {URL: "https://policy.example.com"},
+		"empty header":     {URL: "https://policy.example.com", Headers: map[string]string{"namespaces": ""}},
+		"empty constraint": {URL: "https://policy.example.com", QueryParameters: map[string]string{"": "namespace"}},
+	} {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("%s: want an error", name)
+		}
+	}
+}
+
+func TestIntersectConstraints(t *testing.T) {
+	got := IntersectConstraints(nil, map[string][]string{"namespaces": {"a", "b", "c"}})
+	got = IntersectConstraints(got, map[string][]string{"namespaces": {"b", "c", "d"}, "labels": {"x"}})
+	want := map[string][]string{"namespaces": {"b", "c"}, "labels": {"x"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
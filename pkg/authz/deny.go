@@ -0,0 +1,57 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authz
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+)
+
+type denyAuthorizer struct {
+	users  sets.Set[string]
+	groups sets.Set[string]
+}
+
+// NewDenyAuthorizer denies all requests of the users and of the members of
+// the groups. It must precede all other authorizers of a union, such that
+// compromised identities are blocked regardless of their grants.
+func NewDenyAuthorizer(users, groups []string) authorizer.Authorizer {
+	return &denyAuthorizer{
+		users:  sets.New(users...),
+		groups: sets.New(groups...),
+	}
+}
+
+func (d *denyAuthorizer) Authorize(_ context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
+	u := a.GetUser()
+	if u == nil {
+		return authorizer.DecisionNoOpinion, "", nil
+	}
+
+	if d.users.Has(u.GetName()) {
+		return authorizer.DecisionDeny, "user is on the deny list", nil
+	}
+	for _, group := range u.GetGroups() {
+		if d.groups.Has(group) {
+			return authorizer.DecisionDeny, "group " + group + " is on the deny list", nil
+		}
+	}
+
+	return authorizer.DecisionNoOpinion, "", nil
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authz
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/authorization/union"
+)
+
+func TestDenyAuthorizer(t *testing.T) {
+	static, err := NewStaticAuthorizer([]StaticAuthorizationConfig{{Path: "/metrics"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := union.New(
+		NewDenyAuthorizer([]string{"system:serviceaccount:default:compromised"}, []string{"contractors"}),
+		static,
+	)
+
+	for _, tt := range []struct {
+		user *user.DefaultInfo
+		want authorizer.Decision
+	}{
+		{user: &user.DefaultInfo{Name: "alice"}, want: authorizer.DecisionAllow},
+		{user: &user.DefaultInfo{Name: "system:serviceaccount:default:compromised"}, want: authorizer.DecisionDeny},
+		{user: &user.DefaultInfo{Name: "bob", Groups: []string{"developers", "contractors"}}, want: authorizer.DecisionDeny},
+	} {
+		got, _, err := a.Authorize(context.Background(), authorizer.AttributesRecord{User: tt.user, Verb: "get", Path: "/metrics"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("want %v for %s, got %v", tt.want, tt.user.Name, got)
+		}
+	}
+}
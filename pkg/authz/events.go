@@ -0,0 +1,144 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authz
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/client-go/tools/record"
+)
+
+// DenialEventReason is the reason of the events on repeated denials.
+const DenialEventReason = "RepeatedAuthorizationDenials"
+
+// DenialEventsConfig configures the events on repeated denials.
+type DenialEventsConfig struct {
+	// Threshold is the number of denials of a user within the window, which
+	// emits an event. Events are disabled, if 0.
+	Threshold int
+	// Window is the period the denials are counted in.
+	Window time.Duration
+}
+
+// DenialEvents emits a Warning event on the pod of the proxy, once a user is
+// denied the threshold number of times within a window, such that brute force
+// attempts and misconfigured clients show in kubectl describe. At most one
+// event is emitted per user and window.
+type DenialEvents struct {
+	recorder record.EventRecorder
+	pod      *corev1.ObjectReference
+	config   DenialEventsConfig
+	now      func() time.Time
+
+	mu        sync.Mutex // protects the fields below
+	users     map[string]*userDenials
+	lastPrune time.Time
+}
+
+type userDenials struct {
+	start   time.Time
+	count   int
+	emitted bool
+}
+
+// NewDenialEvents creates DenialEvents recording to the pod.
+func NewDenialEvents(recorder record.EventRecorder, pod *corev1.ObjectReference, config DenialEventsConfig) *DenialEvents {
+	return &DenialEvents{
+		recorder: recorder,
+		pod:      pod,
+		config:   config,
+		now:      time.Now,
+		users:    map[string]*userDenials{},
+	}
+}
+
+type denialEventsAuthorizer struct {
+	authorizer authorizer.Authorizer
+	events     *DenialEvents
+}
+
+// WithDenialEvents counts the denials of the authorizer by user. The
+// DenialEvents can be shared by several authorizers, e.g. of listeners.
+func WithDenialEvents(a authorizer.Authorizer, e *DenialEvents) authorizer.Authorizer {
+	if e == nil {
+		return a
+	}
+	return &denialEventsAuthorizer{authorizer: a, events: e}
+}
+
+func (d *denialEventsAuthorizer) Authorize(ctx context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
+	decision, reason, err := d.authorizer.Authorize(ctx, a)
+	// Errors are no denials, e.g. an unavailable API server.
+	if err == nil && decision != authorizer.DecisionAllow && a.GetUser() != nil {
+		d.events.denied(a)
+	}
+	return decision, reason, err
+}
+
+func (e *DenialEvents) denied(a authorizer.Attributes) {
+	user := a.GetUser().GetName()
+	now := e.now()
+
+	e.mu.Lock()
+	e.prune(now)
+	u, ok := e.users[user]
+	if !ok || now.Sub(u.start) >= e.config.Window {
+		u = &userDenials{start: now}
+		e.users[user] = u
+	}
+	u.count++
+	emit := !u.emitted && u.count >= e.config.Threshold
+	if emit {
+		u.emitted = true
+	}
+	count := u.count
+	e.mu.Unlock()
+
+	if !emit {
+		return
+	}
+
+	target := a.GetPath()
+	if a.IsResourceRequest() {
+		target = a.GetResource()
+		if a.GetSubresource() != "" {
+			target += "/" + a.GetSubresource()
+		}
+		if a.GetNamespace() != "" {
+			target = a.GetNamespace() + "/" + target
+		}
+	}
+	e.recorder.Eventf(e.pod, corev1.EventTypeWarning, DenialEventReason,
+		"User %q was denied %d times within %s, last to %s %s", user, count, e.config.Window, a.GetVerb(), target)
+}
+
+// prune forgets the users whose window passed, at most once per window.
+func (e *DenialEvents) prune(now time.Time) {
+	if now.Sub(e.lastPrune) < e.config.Window {
+		return
+	}
+	e.lastPrune = now
+	for user, u := range e.users {
+		if now.Sub(u.start) >= e.config.Window {
+			delete(e.users, user)
+		}
+	}
+}
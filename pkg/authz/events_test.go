@@ -0,0 +1,92 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authz
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestDenialEvents(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	recorder := record.NewFakeRecorder(10)
+	e := NewDenialEvents(recorder, &corev1.ObjectReference{Kind: "Pod", Namespace: "monitoring", Name: "proxy-0"}, DenialEventsConfig{Threshold: 3, Window: time.Minute})
+	e.now = func() time.Time { return now }
+
+	attrs := func(name string) authorizer.Attributes {
+		return authorizer.AttributesRecord{User: &user.DefaultInfo{Name: name}, Verb: "get", Path: "/metrics"}
+	}
+	allow := WithDenialEvents(&countingAuthorizer{decision: authorizer.DecisionAllow}, e)
+	deny := WithDenialEvents(&countingAuthorizer{decision: authorizer.DecisionNoOpinion}, e)
+	failing := WithDenialEvents(&countingAuthorizer{err: errors.New("sar failed")}, e)
+
+	events := func() []string {
+		var got []string
+		for {
+			select {
+			case ev := <-recorder.Events:
+				got = append(got, ev)
+			default:
+				return got
+			}
+		}
+	}
+
+	for range 5 {
+		_, _, _ = allow.Authorize(ctx, attrs("alice"))
+		_, _, _ = failing.Authorize(ctx, attrs("alice"))
+	}
+	_, _, _ = deny.Authorize(ctx, attrs("alice"))
+	_, _, _ = deny.Authorize(ctx, attrs("alice"))
+	_, _, _ = deny.Authorize(ctx, attrs("bob"))
+	if got := events(); len(got) != 0 {
+		t.Fatalf("want no events below the threshold, got %v", got)
+	}
+
+	_, _, _ = deny.Authorize(ctx, attrs("alice"))
+	got := events()
+	if len(got) != 1 || !strings.HasPrefix(got[0], "Warning "+DenialEventReason) || !strings.Contains(got[0], `"alice" was denied 3 times within 1m0s, last to get /metrics`) {
+		t.Fatalf("want an event for alice, got %v", got)
+	}
+
+	for range 5 {
+		_, _, _ = deny.Authorize(ctx, attrs("alice"))
+	}
+	if got := events(); len(got) != 0 {
+		t.Fatalf("want one event per window, got %v", got)
+	}
+
+	now = now.Add(time.Minute)
+	for range 3 {
+		_, _, _ = deny.Authorize(ctx, attrs("alice"))
+	}
+	if got := events(); len(got) != 1 {
+		t.Errorf("want an event in the next window, got %v", got)
+	}
+	if _, ok := e.users["bob"]; ok {
+		t.Error("want users of passed windows to be pruned")
+	}
+}
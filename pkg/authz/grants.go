@@ -0,0 +1,88 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authz
+
+import (
+	"context"
+	"regexp"
+
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/klog/v2"
+)
+
+// rbacGrantReason matches the reason the RBAC authorizer of the API server
+// gives for allowed SubjectAccessReviews, e.g.
+// RBAC: allowed by RoleBinding "reader/default" of Role "reader" to User "alice".
+var rbacGrantReason = regexp.MustCompile(`allowed by (ClusterRoleBinding|RoleBinding) "([^"]*)" of (ClusterRole|Role) "([^"]*)"`)
+
+// Grant is the binding and role that allowed a request.
+type Grant struct {
+	BindingKind string
+	Binding     string
+	RoleKind    string
+	Role        string
+}
+
+// ParseGrant extracts the binding and role from the reason of an allowed
+// SubjectAccessReview. It returns false for reasons of other authorizers
+// than RBAC.
+func ParseGrant(reason string) (Grant, bool) {
+	m := rbacGrantReason.FindStringSubmatch(reason)
+	if m == nil {
+		return Grant{}, false
+	}
+	return Grant{BindingKind: m[1], Binding: m[2], RoleKind: m[3], Role: m[4]}, true
+}
+
+type grantLoggingAuthorizer struct {
+	authorizer authorizer.Authorizer
+	always     bool
+}
+
+// WithGrantLogging logs which RBAC binding and role allowed a request, as
+// reported by the SubjectAccessReview, to ease tightening permissions to the
+// least privilege. Grants are logged if always is set or at verbosity 4.
+func WithGrantLogging(a authorizer.Authorizer, always bool) authorizer.Authorizer {
+	return &grantLoggingAuthorizer{authorizer: a, always: always}
+}
+
+func (g *grantLoggingAuthorizer) Authorize(ctx context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
+	decision, reason, err := g.authorizer.Authorize(ctx, a)
+	if decision != authorizer.DecisionAllow || !(g.always || klog.V(4).Enabled()) {
+		return decision, reason, err
+	}
+
+	var userName string
+	if u := a.GetUser(); u != nil {
+		userName = u.GetName()
+	}
+	attrs := []interface{}{"user", userName, "verb", a.GetVerb()}
+	if a.IsResourceRequest() {
+		attrs = append(attrs, "namespace", a.GetNamespace(), "resource", a.GetResource(), "subresource", a.GetSubresource(), "name", a.GetName())
+	} else {
+		attrs = append(attrs, "path", a.GetPath())
+	}
+
+	if grant, ok := ParseGrant(reason); ok {
+		attrs = append(attrs, "bindingKind", grant.BindingKind, "binding", grant.Binding, "roleKind", grant.RoleKind, "role", grant.Role)
+	} else {
+		attrs = append(attrs, "reason", reason)
+	}
+	klog.FromContext(ctx).Info("Request allowed", attrs...)
+
+	return decision, reason, err
+}
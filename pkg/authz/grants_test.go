@@ -0,0 +1,49 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authz
+
+import "testing"
+
+func TestParseGrant(t *testing.T) {
+	for _, tt := range []struct {
+		reason string
+		want   Grant
+		ok     bool
+	}{
+		{
+			reason: `RBAC: allowed by ClusterRoleBinding "prometheus" of ClusterRole "metrics-reader" to ServiceAccount "prometheus/monitoring"`,
+			want:   Grant{BindingKind: "ClusterRoleBinding", Binding: "prometheus", RoleKind: "ClusterRole", Role: "metrics-reader"},
+			ok:     true,
+		},
+		{
+			reason: `RBAC: allowed by RoleBinding "reader/default" of Role "reader" to User "alice"`,
+			want:   Grant{BindingKind: "RoleBinding", Binding: "reader/default", RoleKind: "Role", Role: "reader"},
+			ok:     true,
+		},
+		{
+			reason: "",
+		},
+		{
+			reason: "allowed by webhook",
+		},
+	} {
+		got, ok := ParseGrant(tt.reason)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("ParseGrant(%q) = %+v, %v, want %+v, %v", tt.reason, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authz
+
+import (
+	"context"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+// otherLabelValue replaces the label values of the attributes beyond the
+// maximum number of series.
+const otherLabelValue = "other"
+
+var attributeDecisions = metrics.NewCounterVec(
+	&metrics.CounterOpts{
+		Namespace:      "kube_rbac_proxy",
+		Subsystem:      "authorization",
+		Name:           "attribute_decisions_total",
+		Help:           "Number of authorization decisions by namespace, resource, verb and decision, one of allowed, denied or error. Attributes beyond the maximum number of series are counted with the label values \"other\".",
+		StabilityLevel: metrics.ALPHA,
+	},
+	[]string{"namespace", "resource", "verb", "decision"},
+)
+
+func init() {
+	legacyregistry.MustRegister(attributeDecisions)
+}
+
+// DecisionMetrics counts authorization decisions by the namespace, resource
+// and verb of the attributes, e.g. to spot clients hammering denied
+// resources. As the attributes are derived from requests, e.g. by rewrites,
+// the number of distinct attributes is bounded.
+type DecisionMetrics struct {
+	maxSeries int
+
+	mu   sync.Mutex // protects the field below
+	seen sets.Set[string]
+}
+
+// NewDecisionMetrics creates DecisionMetrics counting at most maxSeries
+// distinct attributes, further attributes are counted as "other".
+func NewDecisionMetrics(maxSeries int) *DecisionMetrics {
+	return &DecisionMetrics{
+		maxSeries: maxSeries,
+		seen:      sets.New[string](),
+	}
+}
+
+type decisionMetricsAuthorizer struct {
+	authorizer authorizer.Authorizer
+	metrics    *DecisionMetrics
+}
+
+// WithDecisionMetrics counts the decisions of the authorizer. The
+// DecisionMetrics can be shared by several authorizers, e.g. of listeners.
+func WithDecisionMetrics(a authorizer.Authorizer, m *DecisionMetrics) authorizer.Authorizer {
+	if m == nil {
+		return a
+	}
+	return &decisionMetricsAuthorizer{authorizer: a, metrics: m}
+}
+
+func (d *decisionMetricsAuthorizer) Authorize(ctx context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
+	decision, reason, err := d.authorizer.Authorize(ctx, a)
+
+	label := "denied"
+	switch {
+	case err != nil:
+		label = "error"
+	case decision == authorizer.DecisionAllow:
+		label = "allowed"
+	}
+	namespace, resource, verb := d.metrics.labels(a)
+	attributeDecisions.WithLabelValues(namespace, resource, verb, label).Inc()
+
+	return decision, reason, err
+}
+
+// labels returns the label values of the attributes, or "other" once the
+// maximum number of distinct attributes was seen.
+func (m *DecisionMetrics) labels(a authorizer.Attributes) (string, string, string) {
+	resource := a.GetResource()
+	if a.GetSubresource() != "" {
+		resource += "/" + a.GetSubresource()
+	}
+	namespace, verb := a.GetNamespace(), a.GetVerb()
+	key := namespace + "\x00" + resource + "\x00" + verb
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.seen.Has(key) {
+		if m.seen.Len() >= m.maxSeries {
+			return otherLabelValue, otherLabelValue, otherLabelValue
+		}
+		m.seen.Insert(key)
+	}
+	return namespace, resource, verb
+}
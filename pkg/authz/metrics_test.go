@@ -0,0 +1,66 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authz
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/component-base/metrics/testutil"
+)
+
+func TestDecisionMetrics(t *testing.T) {
+	ctx := context.Background()
+	attrs := func(namespace string) authorizer.Attributes {
+		return authorizer.AttributesRecord{Verb: "get", Namespace: namespace, Resource: "pods", Subresource: "metrics", ResourceRequest: true}
+	}
+	count := func(namespace, resource, verb, decision string) float64 {
+		v, err := testutil.GetCounterMetricValue(attributeDecisions.WithLabelValues(namespace, resource, verb, decision))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	m := NewDecisionMetrics(2)
+	allow := WithDecisionMetrics(&countingAuthorizer{decision: authorizer.DecisionAllow}, m)
+	deny := WithDecisionMetrics(&countingAuthorizer{decision: authorizer.DecisionNoOpinion}, m)
+	failing := WithDecisionMetrics(&countingAuthorizer{err: errors.New("sar failed")}, m)
+
+	_, _, _ = allow.Authorize(ctx, attrs("metrics-a"))
+	_, _, _ = deny.Authorize(ctx, attrs("metrics-a"))
+	_, _, _ = failing.Authorize(ctx, attrs("metrics-b"))
+	// Beyond the maximum of two series.
+	_, _, _ = deny.Authorize(ctx, attrs("metrics-c"))
+
+	for _, tt := range []struct {
+		namespace, resource, verb, decision string
+		want                                float64
+	}{
+		{"metrics-a", "pods/metrics", "get", "allowed", 1},
+		{"metrics-a", "pods/metrics", "get", "denied", 1},
+		{"metrics-b", "pods/metrics", "get", "error", 1},
+		{"metrics-c", "pods/metrics", "get", "denied", 0},
+		{"other", "other", "other", "denied", 1},
+	} {
+		if got := count(tt.namespace, tt.resource, tt.verb, tt.decision); got != tt.want {
+			t.Errorf("want %v decisions for %s %s %s %s, got %v", tt.want, tt.namespace, tt.resource, tt.verb, tt.decision, got)
+		}
+	}
+}
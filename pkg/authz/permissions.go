@@ -0,0 +1,96 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authz
+
+import (
+	"context"
+	"fmt"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	authorizationclient "k8s.io/client-go/kubernetes/typed/authorization/v1"
+	"k8s.io/klog/v2"
+)
+
+// Permission is a permission the proxy requires on the API server.
+type Permission struct {
+	Verb     string
+	Group    string
+	Resource string
+	// Purpose tells what the permission is required for.
+	Purpose string
+}
+
+func (p Permission) String() string {
+	if p.Group == "" {
+		return fmt.Sprintf("%s %s", p.Verb, p.Resource)
+	}
+	return fmt.Sprintf("%s %s.%s", p.Verb, p.Resource, p.Group)
+}
+
+var (
+	// TokenReviewPermission is required to authenticate bearer tokens by
+	// the TokenReview API.
+	TokenReviewPermission = Permission{
+		Verb:     "create",
+		Group:    "authentication.k8s.io",
+		Resource: "tokenreviews",
+		Purpose:  "authenticating bearer tokens",
+	}
+	// SubjectAccessReviewPermission is required to authorize requests by
+	// the SubjectAccessReview API.
+	SubjectAccessReviewPermission = Permission{
+		Verb:     "create",
+		Group:    "authorization.k8s.io",
+		Resource: "subjectaccessreviews",
+		Purpose:  "authorizing requests",
+	}
+	// EventPermissions are required to emit events, which are patched when
+	// repeated events are aggregated.
+	EventPermissions = []Permission{
+		{Verb: "create", Resource: "events", Purpose: "emitting events"},
+		{Verb: "patch", Resource: "events", Purpose: "emitting events"},
+	}
+)
+
+// CheckPermissions confirms with SelfSubjectAccessReviews that the identity
+// of the client has the permissions, such that missing RBAC fails on startup
+// instead of failing each request. Permissions that can't be checked, e.g.
+// as the API server is unavailable, are logged and skipped.
+func CheckPermissions(ctx context.Context, client authorizationclient.SelfSubjectAccessReviewsGetter, permissions []Permission) error {
+	var errs []error
+	for _, p := range permissions {
+		review, err := client.SelfSubjectAccessReviews().Create(ctx, &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Verb:     p.Verb,
+					Group:    p.Group,
+					Resource: p.Resource,
+				},
+			},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			klog.ErrorS(err, "Unable to check permission on startup", "permission", p.String())
+			continue
+		}
+		if !review.Status.Allowed {
+			errs = append(errs, fmt.Errorf("the proxy isn't allowed to %s, which is required for %s; bind a role granting it, e.g. the system:auth-delegator ClusterRole, to the service account of the proxy", p, p.Purpose))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authz
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestCheckPermissions(t *testing.T) {
+	permissions := []Permission{TokenReviewPermission, SubjectAccessReviewPermission}
+
+	for _, tt := range []struct {
+		name    string
+		allowed map[string]bool
+		err     error
+		wantErr []string
+	}{
+		{
+			name:    "allowed",
+			allowed: map[string]bool{"tokenreviews": true, "subjectaccessreviews": true},
+		},
+		{
+			name:    "missing",
+			allowed: map[string]bool{"tokenreviews": true},
+			wantErr: []string{"create subjectaccessreviews.authorization.k8s.io", "authorizing requests", "system:auth-delegator"},
+		},
+		{
+			name: "unavailable",
+			err:  errors.New("connection refused"),
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset()
+			client.PrependReactor("create", "selfsubjectaccessreviews", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				if tt.err != nil {
+					return true, nil, tt.err
+				}
+				review := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+				review.Status.Allowed = tt.allowed[review.Spec.ResourceAttributes.Resource]
+				return true, review, nil
+			})
+
+			err := CheckPermissions(context.Background(), client.AuthorizationV1(), permissions)
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("want an error")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("want error containing %q, got %v", want, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,371 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apiserver/pkg/authentication/serviceaccount"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	rbaclisters "k8s.io/client-go/listers/rbac/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+var localRBACRequests = metrics.NewCounterVec(
+	&metrics.CounterOpts{
+		Namespace:      "kube_rbac_proxy",
+		Subsystem:      "authorization_local_rbac",
+		Name:           "requests_total",
+		Help:           "Number of requests evaluated against the local RBAC objects by result, either allowed or fallback.",
+		StabilityLevel: metrics.ALPHA,
+	},
+	[]string{"result"},
+)
+
+func init() {
+	legacyregistry.MustRegister(localRBACRequests)
+}
+
+// subjectIndex indexes role bindings and cluster role bindings by the user
+// names and groups of their subjects, see subjectIndexKeys.
+const subjectIndex = "subject"
+
+// LocalRBACAuthorizer evaluates requests against the RBAC objects watched
+// from the API server, like the RBAC authorizer of the API server does.
+// Requests that aren't allowed locally, e.g. because the objects aren't
+// synced yet or another authorizer of the API server allows them, are
+// passed on to the fallback authorizer.
+//
+// As local allow decisions aren't sent to the API server, they bypass its
+// other authorizers, e.g. webhook authorizers denying requests RBAC allows.
+type LocalRBACAuthorizer struct {
+	factory  informers.SharedInformerFactory
+	fallback authorizer.Authorizer
+	synced   atomic.Bool
+
+	roles               rbaclisters.RoleLister
+	roleBindings        cache.Indexer
+	clusterRoles        rbaclisters.ClusterRoleLister
+	clusterRoleBindings cache.Indexer
+}
+
+// NewLocalRBACAuthorizer creates a LocalRBACAuthorizer, the Run method must
+// be started explicitly.
+func NewLocalRBACAuthorizer(client kubernetes.Interface, fallback authorizer.Authorizer) *LocalRBACAuthorizer {
+	factory := informers.NewSharedInformerFactory(client, 0)
+	rbac := factory.Rbac().V1()
+
+	roleBindings := rbac.RoleBindings().Informer()
+	clusterRoleBindings := rbac.ClusterRoleBindings().Informer()
+	// Indexers can be added as long as the informers aren't started.
+	_ = roleBindings.AddIndexers(cache.Indexers{subjectIndex: func(obj interface{}) ([]string, error) {
+		binding, ok := obj.(*rbacv1.RoleBinding)
+		if !ok {
+			return nil, nil
+		}
+		return subjectIndexKeys(binding.Namespace, binding.Subjects), nil
+	}})
+	_ = clusterRoleBindings.AddIndexers(cache.Indexers{subjectIndex: func(obj interface{}) ([]string, error) {
+		binding, ok := obj.(*rbacv1.ClusterRoleBinding)
+		if !ok {
+			return nil, nil
+		}
+		return subjectIndexKeys("", binding.Subjects), nil
+	}})
+
+	return &LocalRBACAuthorizer{
+		factory:             factory,
+		fallback:            fallback,
+		roles:               rbac.Roles().Lister(),
+		roleBindings:        roleBindings.GetIndexer(),
+		clusterRoles:        rbac.ClusterRoles().Lister(),
+		clusterRoleBindings: clusterRoleBindings.GetIndexer(),
+	}
+}
+
+// Run watches the RBAC objects of all namespaces until the context is done.
+func (l *LocalRBACAuthorizer) Run(ctx context.Context) error {
+	l.factory.Start(ctx.Done())
+	defer l.factory.Shutdown()
+
+	for informer, ok := range l.factory.WaitForCacheSync(ctx.Done()) {
+		if !ok {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to sync %v", informer)
+		}
+	}
+	l.synced.Store(true)
+
+	<-ctx.Done()
+	return nil
+}
+
+// HasSynced returns whether requests are evaluated locally.
+func (l *LocalRBACAuthorizer) HasSynced() bool {
+	return l.synced.Load()
+}
+
+func (l *LocalRBACAuthorizer) Authorize(ctx context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
+	if l.synced.Load() && a.GetUser() != nil {
+		if reason, ok := l.allowedBy(a); ok {
+			localRBACRequests.WithLabelValues("allowed").Inc()
+			return authorizer.DecisionAllow, reason, nil
+		}
+	}
+
+	localRBACRequests.WithLabelValues("fallback").Inc()
+	return l.fallback.Authorize(ctx, a)
+}
+
+// allowedBy returns the reason of the first binding allowing the request,
+// in the format of the RBAC authorizer of the API server. Only the bindings
+// of the user and its groups are looked up by the subject index.
+func (l *LocalRBACAuthorizer) allowedBy(a authorizer.Attributes) (string, bool) {
+	u := a.GetUser()
+
+	for _, obj := range bindingsOf(l.clusterRoleBindings, "", u) {
+		binding, ok := obj.(*rbacv1.ClusterRoleBinding)
+		if !ok {
+			continue
+		}
+		subject, ok := appliesTo(u, binding.Subjects, "")
+		if !ok || binding.RoleRef.Kind != "ClusterRole" {
+			continue
+		}
+		rules, err := l.rules(binding.RoleRef, "")
+		if err != nil || !rulesAllow(a, rules) {
+			continue
+		}
+		return fmt.Sprintf("RBAC: allowed by ClusterRoleBinding %q of ClusterRole %q to %s", binding.Name, binding.RoleRef.Name, describeSubject(subject, "")), true
+	}
+
+	if namespace := a.GetNamespace(); namespace != "" {
+		for _, obj := range bindingsOf(l.roleBindings, namespace, u) {
+			binding, ok := obj.(*rbacv1.RoleBinding)
+			if !ok {
+				continue
+			}
+			subject, ok := appliesTo(u, binding.Subjects, namespace)
+			if !ok {
+				continue
+			}
+			rules, err := l.rules(binding.RoleRef, namespace)
+			if err != nil || !rulesAllow(a, rules) {
+				continue
+			}
+			return fmt.Sprintf("RBAC: allowed by RoleBinding %q of %s %q to %s", binding.Name+"/"+namespace, binding.RoleRef.Kind, binding.RoleRef.Name, describeSubject(subject, namespace)), true
+		}
+	}
+
+	return "", false
+}
+
+// bindingsOf returns the bindings of the namespace, or the cluster role
+// bindings without namespace, whose subjects include the user or one of
+// its groups.
+func bindingsOf(indexer cache.Indexer, namespace string, u user.Info) []interface{} {
+	keys := []string{subjectKey(namespace, rbacv1.UserKind, u.GetName())}
+	for _, group := range u.GetGroups() {
+		keys = append(keys, subjectKey(namespace, rbacv1.GroupKind, group))
+	}
+
+	var bindings []interface{}
+	seen := map[interface{}]struct{}{}
+	for _, key := range keys {
+		objs, err := indexer.ByIndex(subjectIndex, key)
+		if err != nil {
+			continue
+		}
+		for _, obj := range objs {
+			if _, ok := seen[obj]; !ok {
+				seen[obj] = struct{}{}
+				bindings = append(bindings, obj)
+			}
+		}
+	}
+	return bindings
+}
+
+// subjectIndexKeys returns the keys of the subjects of a binding of the
+// namespace, see subjectKey. Service accounts are keyed by their user name.
+func subjectIndexKeys(bindingNamespace string, subjects []rbacv1.Subject) []string {
+	var keys []string
+	for _, subject := range subjects {
+		switch subject.Kind {
+		case rbacv1.UserKind, rbacv1.GroupKind:
+			keys = append(keys, subjectKey(bindingNamespace, subject.Kind, subject.Name))
+		case rbacv1.ServiceAccountKind:
+			namespace := subject.Namespace
+			if namespace == "" {
+				namespace = bindingNamespace
+			}
+			if namespace != "" {
+				keys = append(keys, subjectKey(bindingNamespace, rbacv1.UserKind, serviceaccount.MakeUsername(namespace, subject.Name)))
+			}
+		}
+	}
+	return keys
+}
+
+// subjectKey is the index key of a user or group bound in the namespace,
+// which is empty for cluster role bindings.
+func subjectKey(namespace, kind, name string) string {
+	return namespace + "/" + kind + "/" + name
+}
+
+func (l *LocalRBACAuthorizer) rules(ref rbacv1.RoleRef, namespace string) ([]rbacv1.PolicyRule, error) {
+	switch ref.Kind {
+	case "ClusterRole":
+		role, err := l.clusterRoles.Get(ref.Name)
+		if err != nil {
+			return nil, err
+		}
+		return role.Rules, nil
+	case "Role":
+		role, err := l.roles.Roles(namespace).Get(ref.Name)
+		if err != nil {
+			return nil, err
+		}
+		return role.Rules, nil
+	default:
+		return nil, errors.New("unknown role kind " + ref.Kind)
+	}
+}
+
+// appliesTo returns the subject matching the user. The namespace of
+// service account subjects of role bindings defaults to the namespace of the
+// binding.
+func appliesTo(u user.Info, subjects []rbacv1.Subject, bindingNamespace string) (*rbacv1.Subject, bool) {
+	for i := range subjects {
+		subject := &subjects[i]
+		switch subject.Kind {
+		case rbacv1.UserKind:
+			if u.GetName() == subject.Name {
+				return subject, true
+			}
+		case rbacv1.GroupKind:
+			for _, group := range u.GetGroups() {
+				if group == subject.Name {
+					return subject, true
+				}
+			}
+		case rbacv1.ServiceAccountKind:
+			namespace := subject.Namespace
+			if namespace == "" {
+				namespace = bindingNamespace
+			}
+			if namespace != "" && u.GetName() == serviceaccount.MakeUsername(namespace, subject.Name) {
+				return subject, true
+			}
+		}
+	}
+	return nil, false
+}
+
+func describeSubject(s *rbacv1.Subject, bindingNamespace string) string {
+	if s.Kind == rbacv1.ServiceAccountKind {
+		namespace := s.Namespace
+		if namespace == "" {
+			namespace = bindingNamespace
+		}
+		return fmt.Sprintf("%s %q", s.Kind, namespace+"/"+s.Name)
+	}
+	return fmt.Sprintf("%s %q", s.Kind, s.Name)
+}
+
+func rulesAllow(a authorizer.Attributes, rules []rbacv1.PolicyRule) bool {
+	for i := range rules {
+		if ruleAllows(a, &rules[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+func ruleAllows(a authorizer.Attributes, rule *rbacv1.PolicyRule) bool {
+	if !hasOrWildcard(rule.Verbs, a.GetVerb()) {
+		return false
+	}
+
+	if !a.IsResourceRequest() {
+		return nonResourceURLMatches(rule.NonResourceURLs, a.GetPath())
+	}
+
+	if !hasOrWildcard(rule.APIGroups, a.GetAPIGroup()) {
+		return false
+	}
+
+	resource := a.GetResource()
+	if a.GetSubresource() != "" {
+		resource += "/" + a.GetSubresource()
+	}
+	resourceMatches := false
+	for _, r := range rule.Resources {
+		if r == rbacv1.ResourceAll || r == resource || (a.GetSubresource() != "" && r == "*/"+a.GetSubresource()) {
+			resourceMatches = true
+			break
+		}
+	}
+	if !resourceMatches {
+		return false
+	}
+
+	if len(rule.ResourceNames) == 0 {
+		return true
+	}
+	for _, name := range rule.ResourceNames {
+		if a.GetName() != "" && name == a.GetName() {
+			return true
+		}
+	}
+	return false
+}
+
+// nonResourceURLMatches reports whether one of the URLs matches the path,
+// with a trailing * matching any suffix as for RBAC.
+func nonResourceURLMatches(urls []string, path string) bool {
+	for _, url := range urls {
+		if url == rbacv1.NonResourceAll || url == path {
+			return true
+		}
+		if strings.HasSuffix(url, "*") && strings.HasPrefix(path, strings.TrimSuffix(url, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasOrWildcard(values []string, value string) bool {
+	for _, v := range values {
+		if v == "*" || v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authz
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestLocalRBACAuthorizer(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "metrics-reader"},
+			Rules: []rbacv1.PolicyRule{
+				{NonResourceURLs: []string{"/metrics", "/debug/*"}, Verbs: []string{"get"}},
+			},
+		},
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "monitoring"},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "metrics-reader"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.GroupKind, Name: "monitoring"}},
+		},
+		&rbacv1.Role{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod-metrics", Namespace: "team-a"},
+			Rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"pods/metrics"}, ResourceNames: []string{"web"}, Verbs: []string{"get"}},
+			},
+		},
+		&rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "prometheus", Namespace: "team-a"},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: "pod-metrics"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "prometheus"}},
+		},
+	)
+	fallback := &countingAuthorizer{decision: authorizer.DecisionDeny}
+	l := NewLocalRBACAuthorizer(client, fallback)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Requests are passed on until the objects are synced.
+	prometheus := &user.DefaultInfo{Name: "system:serviceaccount:team-a:prometheus"}
+	podMetrics := authorizer.AttributesRecord{
+		User: prometheus, Verb: "get", Namespace: "team-a", Resource: "pods", Subresource: "metrics", Name: "web", ResourceRequest: true,
+	}
+	if decision, _, _ := l.Authorize(ctx, podMetrics); decision != authorizer.DecisionDeny || fallback.calls != 1 {
+		t.Fatalf("want fallback before sync, got %v after %d calls", decision, fallback.calls)
+	}
+
+	go func() { _ = l.Run(ctx) }()
+	if err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 5*time.Second, true, func(context.Context) (bool, error) {
+		return l.HasSynced(), nil
+	}); err != nil {
+		t.Fatal("want RBAC objects to be synced")
+	}
+
+	for _, tt := range []struct {
+		name         string
+		attrs        authorizer.AttributesRecord
+		wantDecision authorizer.Decision
+		wantReason   string
+	}{
+		{
+			name:         "cluster role binding to group",
+			attrs:        authorizer.AttributesRecord{User: &user.DefaultInfo{Name: "alice", Groups: []string{"monitoring"}}, Verb: "get", Path: "/debug/pprof"},
+			wantDecision: authorizer.DecisionAllow,
+			wantReason:   `RBAC: allowed by ClusterRoleBinding "monitoring" of ClusterRole "metrics-reader" to Group "monitoring"`,
+		},
+		{
+			name:         "role binding to service account",
+			attrs:        podMetrics,
+			wantDecision: authorizer.DecisionAllow,
+			wantReason:   `RBAC: allowed by RoleBinding "prometheus/team-a" of Role "pod-metrics" to ServiceAccount "team-a/prometheus"`,
+		},
+		{
+			name: "other resource name",
+			attrs: authorizer.AttributesRecord{
+				User: prometheus, Verb: "get", Namespace: "team-a", Resource: "pods", Subresource: "metrics", Name: "db", ResourceRequest: true,
+			},
+			wantDecision: authorizer.DecisionDeny,
+		},
+		{
+			name: "service account of another namespace",
+			attrs: authorizer.AttributesRecord{
+				User: &user.DefaultInfo{Name: "system:serviceaccount:team-b:prometheus"}, Verb: "get", Namespace: "team-a", Resource: "pods", Subresource: "metrics", Name: "web", ResourceRequest: true,
+			},
+			wantDecision: authorizer.DecisionDeny,
+		},
+		{
+			name: "group bound in another namespace",
+			attrs: authorizer.AttributesRecord{
+				User: &user.DefaultInfo{Name: "bob", Groups: []string{"monitoring"}}, Verb: "get", Namespace: "team-b", Resource: "pods", Subresource: "metrics", Name: "web", ResourceRequest: true,
+			},
+			wantDecision: authorizer.DecisionDeny,
+		},
+		{
+			name:         "other verb",
+			attrs:        authorizer.AttributesRecord{User: &user.DefaultInfo{Name: "alice", Groups: []string{"monitoring"}}, Verb: "create", Path: "/metrics"},
+			wantDecision: authorizer.DecisionDeny,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			decision, reason, err := l.Authorize(ctx, tt.attrs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decision != tt.wantDecision {
+				t.Errorf("want %v, got %v", tt.wantDecision, decision)
+			}
+			if tt.wantReason != "" && reason != tt.wantReason {
+				t.Errorf("want reason %q, got %q", tt.wantReason, reason)
+			}
+			if _, ok := ParseGrant(reason); ok != (tt.wantDecision == authorizer.DecisionAllow) {
+				t.Errorf("want the grant of %q to be parsable", reason)
+			}
+		})
+	}
+}
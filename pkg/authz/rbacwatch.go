@@ -0,0 +1,99 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// RBACWatcher flushes the authorization cache whenever RBAC roles or
+// bindings change, such that revoked permissions take effect within seconds
+// instead of the cache TTL.
+type RBACWatcher struct {
+	client kubernetes.Interface
+	cache  *CachingAuthorizer
+}
+
+// NewRBACWatcher creates an RBACWatcher, the Run method must be started
+// explicitly.
+func NewRBACWatcher(client kubernetes.Interface, cache *CachingAuthorizer) *RBACWatcher {
+	return &RBACWatcher{
+		client: client,
+		cache:  cache,
+	}
+}
+
+// Run watches the RBAC objects of all namespaces until the context is done.
+func (w *RBACWatcher) Run(ctx context.Context) error {
+	factory := informers.NewSharedInformerFactory(w.client, 0)
+	rbac := factory.Rbac().V1()
+	watched := []cache.SharedIndexInformer{
+		rbac.Roles().Informer(),
+		rbac.RoleBindings().Informer(),
+		rbac.ClusterRoles().Informer(),
+		rbac.ClusterRoleBindings().Informer(),
+	}
+
+	handler := cache.ResourceEventHandlerDetailedFuncs{
+		AddFunc: func(obj interface{}, isInInitialList bool) {
+			if !isInInitialList {
+				w.flush(obj)
+			}
+		},
+		UpdateFunc: func(_, obj interface{}) { w.flush(obj) },
+		DeleteFunc: w.flush,
+	}
+
+	synced := make([]cache.InformerSynced, 0, len(watched))
+	for _, informer := range watched {
+		if _, err := informer.AddEventHandler(handler); err != nil {
+			return fmt.Errorf("failed to watch RBAC objects: %w", err)
+		}
+		synced = append(synced, informer.HasSynced)
+	}
+
+	factory.Start(ctx.Done())
+	defer factory.Shutdown()
+
+	if !cache.WaitForCacheSync(ctx.Done(), synced...) {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return errors.New("failed to sync RBAC objects")
+	}
+	// Changes between the creation of the cache and the sync are not seen.
+	w.cache.Flush()
+
+	<-ctx.Done()
+	return nil
+}
+
+func (w *RBACWatcher) flush(obj interface{}) {
+	if klog.V(4).Enabled() {
+		if key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj); err == nil {
+			klog.InfoS("Flushing authorization cache", "kind", fmt.Sprintf("%T", obj), "key", key)
+		}
+	}
+	w.cache.Flush()
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authz
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRBACWatcher(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	inner := &countingAuthorizer{decision: authorizer.DecisionAllow}
+	c := NewCachingAuthorizer(inner, time.Hour, time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- NewRBACWatcher(client, c).Run(ctx) }()
+
+	attrs := authorizer.AttributesRecord{User: &user.DefaultInfo{Name: "alice"}, Verb: "get", Path: "/metrics"}
+	// Waits until a decision is cached, which happens after the initial
+	// flush of the watcher.
+	if err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 5*time.Second, true, func(context.Context) (bool, error) {
+		calls := inner.calls
+		_, _, _ = c.Authorize(ctx, attrs)
+		_, _, _ = c.Authorize(ctx, attrs)
+		return inner.calls == calls+1, nil
+	}); err != nil {
+		t.Fatal("want decision to be cached")
+	}
+
+	if _, err := client.RbacV1().ClusterRoleBindings().Create(ctx, &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "metrics-reader"},
+	}, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := inner.calls
+	if err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 5*time.Second, true, func(context.Context) (bool, error) {
+		_, _, _ = c.Authorize(ctx, attrs)
+		return inner.calls > calls, nil
+	}); err != nil {
+		t.Fatal("want cache to be flushed on RBAC change")
+	}
+
+	cancel()
+	if err := <-errCh; err != nil {
+		t.Errorf("want no error on shutdown, got %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authz
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/authn"
+)
+
+// ScopeAuthorizationConfig allows requests authenticated by an OIDC token
+// carrying the scope. Empty verb and path match any value.
+type ScopeAuthorizationConfig struct {
+	Scope string `json:"scope"`
+	Verb  string `json:"verb,omitempty"`
+	Path  string `json:"path,omitempty"`
+}
+
+type scopeAuthorizer struct {
+	config []ScopeAuthorizationConfig
+}
+
+// NewScopeAuthorizer creates an authorizer allowing requests by the OAuth2
+// scopes of the validated token, without asking the API server. It has no
+// opinion on any other request.
+func NewScopeAuthorizer(config []ScopeAuthorizationConfig) (*scopeAuthorizer, error) {
+	for _, c := range config {
+		if c.Scope == "" {
+			return nil, fmt.Errorf("invalid configuration: scope authorization must include a scope: %v", config)
+		}
+	}
+	return &scopeAuthorizer{config}, nil
+}
+
+func (sa scopeAuthorizer) Authorize(ctx context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
+	if a.GetUser() == nil {
+		return authorizer.DecisionNoOpinion, "", nil
+	}
+
+	scopes := map[string]struct{}{}
+	for _, scope := range a.GetUser().GetExtra()[authn.ScopesExtraKey] {
+		scopes[scope] = struct{}{}
+	}
+
+	for _, c := range sa.config {
+		if _, ok := scopes[c.Scope]; !ok {
+			continue
+		}
+		if (c.Verb == "" || c.Verb == a.GetVerb()) && (c.Path == "" || c.Path == a.GetPath()) {
+			return authorizer.DecisionAllow, fmt.Sprintf("allowed by token scope %q", c.Scope), nil
+		}
+	}
+
+	return authorizer.DecisionNoOpinion, "", nil
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authz
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/authn"
+)
+
+func TestScopeAuthorizer(t *testing.T) {
+	sa, err := NewScopeAuthorizer([]ScopeAuthorizationConfig{
+		{Scope: "metrics:read", Verb: "get", Path: "/metrics"},
+		{Scope: "admin"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	withScopes := func(scopes ...string) user.Info {
+		return &user.DefaultInfo{Name: "client", Extra: map[string][]string{authn.ScopesExtraKey: scopes}}
+	}
+
+	for _, tt := range []struct {
+		name  string
+		attrs authorizer.AttributesRecord
+		want  authorizer.Decision
+	}{
+		{
+			name:  "matching scope",
+			attrs: authorizer.AttributesRecord{User: withScopes("openid", "metrics:read"), Verb: "get", Path: "/metrics"},
+			want:  authorizer.DecisionAllow,
+		},
+		{
+			name:  "matching scope with other verb",
+			attrs: authorizer.AttributesRecord{User: withScopes("metrics:read"), Verb: "create", Path: "/metrics"},
+			want:  authorizer.DecisionNoOpinion,
+		},
+		{
+			name:  "matching scope with other path",
+			attrs: authorizer.AttributesRecord{User: withScopes("metrics:read"), Verb: "get", Path: "/debug"},
+			want:  authorizer.DecisionNoOpinion,
+		},
+		{
+			name:  "wildcard scope",
+			attrs: authorizer.AttributesRecord{User: withScopes("admin"), Verb: "delete", Path: "/debug"},
+			want:  authorizer.DecisionAllow,
+		},
+		{
+			name:  "no scopes",
+			attrs: authorizer.AttributesRecord{User: &user.DefaultInfo{Name: "client"}, Verb: "get", Path: "/metrics"},
+			want:  authorizer.DecisionNoOpinion,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := sa.Authorize(context.Background(), tt.attrs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("want decision %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	if _, err := NewScopeAuthorizer([]ScopeAuthorizationConfig{{Verb: "get"}}); err == nil {
+		t.Error("want error for missing scope")
+	}
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package faults injects artificial failures for resilience testing. It must
+// not be used in production.
+package faults
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+)
+
+// Config holds the rates of the injected faults. Rates are between 0 (never)
+// and 1 (always).
+type Config struct {
+	// SARLatency delays SubjectAccessReview decisions at SARLatencyRate.
+	SARLatency     time.Duration
+	SARLatencyRate float64
+	// AuthenticationFailureRate fails authentication with an error.
+	AuthenticationFailureRate float64
+	// UpstreamErrorRate fails requests to the upstream with a transport
+	// error, which results in 502.
+	UpstreamErrorRate float64
+}
+
+// Enabled reports whether any fault is injected.
+func (c *Config) Enabled() bool {
+	return (c.SARLatency > 0 && c.SARLatencyRate > 0) || c.AuthenticationFailureRate > 0 || c.UpstreamErrorRate > 0
+}
+
+// Validate checks that the rates are between 0 and 1.
+func (c *Config) Validate() error {
+	for name, rate := range map[string]float64{
+		"SAR latency":            c.SARLatencyRate,
+		"authentication failure": c.AuthenticationFailureRate,
+		"upstream error":         c.UpstreamErrorRate,
+	} {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("%s rate must be between 0 and 1, got %v", name, rate)
+		}
+	}
+	if c.SARLatency < 0 {
+		return errors.New("SAR latency must not be negative")
+	}
+	return nil
+}
+
+// random is replaced in tests.
+var random = rand.Float64
+
+func inject(rate float64) bool {
+	return rate > 0 && random() < rate
+}
+
+// WithSARLatency delays the decisions of the authorizer, unless the request
+// is canceled meanwhile.
+func WithSARLatency(a authorizer.Authorizer, latency time.Duration, rate float64) authorizer.Authorizer {
+	if latency <= 0 || rate <= 0 {
+		return a
+	}
+
+	return authorizer.AuthorizerFunc(func(ctx context.Context, attrs authorizer.Attributes) (authorizer.Decision, string, error) {
+		if inject(rate) {
+			timer := time.NewTimer(latency)
+			defer timer.Stop()
+
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				return authorizer.DecisionNoOpinion, "", ctx.Err()
+			}
+		}
+
+		return a.Authorize(ctx, attrs)
+	})
+}
+
+// WithAuthenticationFailures fails authentication with an error.
+func WithAuthenticationFailures(auth authenticator.Request, rate float64) authenticator.Request {
+	if rate <= 0 {
+		return auth
+	}
+
+	return authenticator.RequestFunc(func(req *http.Request) (*authenticator.Response, bool, error) {
+		if inject(rate) {
+			return nil, false, errors.New("injected authentication failure")
+		}
+
+		return auth.AuthenticateRequest(req)
+	})
+}
+
+// WithUpstreamErrors fails round trips to the upstream with an error.
+func WithUpstreamErrors(rt http.RoundTripper, rate float64) http.RoundTripper {
+	if rate <= 0 {
+		return rt
+	}
+
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if inject(rate) {
+			return nil, errors.New("injected upstream error")
+		}
+
+		return rt.RoundTrip(req)
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
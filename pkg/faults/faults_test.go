@@ -0,0 +1,105 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package faults
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+)
+
+func withRandom(t *testing.T, value float64) {
+	t.Helper()
+
+	orig := random
+	random = func() float64 { return value }
+	t.Cleanup(func() { random = orig })
+}
+
+func TestWithAuthenticationFailures(t *testing.T) {
+	auth := authenticator.RequestFunc(func(*http.Request) (*authenticator.Response, bool, error) {
+		return &authenticator.Response{User: &user.DefaultInfo{Name: "alice"}}, true, nil
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	withRandom(t, 0.3)
+	if _, ok, err := WithAuthenticationFailures(auth, 0.5).AuthenticateRequest(req); ok || err == nil {
+		t.Error("want injected failure below the rate")
+	}
+	if _, ok, err := WithAuthenticationFailures(auth, 0.2).AuthenticateRequest(req); !ok || err != nil {
+		t.Errorf("want authentication above the rate, got %v", err)
+	}
+}
+
+func TestWithSARLatency(t *testing.T) {
+	a := authorizer.AuthorizerFunc(func(context.Context, authorizer.Attributes) (authorizer.Decision, string, error) {
+		return authorizer.DecisionAllow, "", nil
+	})
+	withRandom(t, 0)
+
+	start := time.Now()
+	if d, _, err := WithSARLatency(a, 50*time.Millisecond, 1).Authorize(context.Background(), authorizer.AttributesRecord{}); d != authorizer.DecisionAllow || err != nil {
+		t.Fatalf("want allow, got %v, %v", d, err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("want latency of 50ms, got %v", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := WithSARLatency(a, time.Hour, 1).Authorize(ctx, authorizer.AttributesRecord{}); err == nil {
+		t.Error("want error for canceled request")
+	}
+}
+
+func TestWithUpstreamErrors(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer upstream.Close()
+	req := httptest.NewRequest(http.MethodGet, upstream.URL, nil)
+	req.RequestURI = ""
+
+	withRandom(t, 0.5)
+	if _, err := WithUpstreamErrors(http.DefaultTransport, 1).RoundTrip(req); err == nil {
+		t.Error("want injected upstream error")
+	}
+	resp, err := WithUpstreamErrors(http.DefaultTransport, 0.1).RoundTrip(req)
+	if err != nil {
+		t.Fatalf("want round trip above the rate, got %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestValidate(t *testing.T) {
+	for _, c := range []Config{
+		{SARLatencyRate: 1.5},
+		{AuthenticationFailureRate: -0.1},
+		{SARLatency: -time.Second},
+	} {
+		if err := c.Validate(); err == nil {
+			t.Errorf("want error for %+v", c)
+		}
+	}
+	if err := (&Config{SARLatency: time.Second, SARLatencyRate: 0.1}).Validate(); err != nil {
+		t.Errorf("want valid config, got %v", err)
+	}
+}
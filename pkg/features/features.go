@@ -0,0 +1,90 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package features holds the feature gates of kube-rbac-proxy. It mirrors
+// the feature gates of Kubernetes components, so that experimental
+// behaviors can ship disabled and be enabled per deployment with
+// --feature-gates.
+package features
+
+import (
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/component-base/featuregate"
+	logsapi "k8s.io/component-base/logs/api/v1"
+)
+
+const (
+	// Every feature gate should add a key here following this template:
+	//
+	// // owner: @username
+	// // alpha: v0.X
+	// //
+	// // Description of the feature.
+	// MyFeature featuregate.Feature = "MyFeature"
+
+	// owner: @ibihim
+	// alpha: v0.19
+	//
+	// Allows --authorization-local-rbac, which evaluates requests against the
+	// RBAC objects watched from the API server. Requests allowed locally
+	// bypass the other authorizers of the API server.
+	LocalRBACAuthorizer featuregate.Feature = "LocalRBACAuthorizer"
+
+	// owner: @ibihim
+	// beta: v0.19
+	//
+	// Allows --upstream-force-h2c, which speaks HTTP/2 in cleartext to the
+	// upstream. The flag predates the feature gates, so the gate is on by
+	// default and only allows to forbid it.
+	UpstreamH2C featuregate.Feature = "UpstreamH2C"
+
+	// owner: @ibihim
+	// alpha: v0.19
+	//
+	// Allows the hidden --fault-* flags, which inject failures for resilience
+	// testing.
+	FaultInjection featuregate.Feature = "FaultInjection"
+)
+
+// DefaultMutableFeatureGate is the mutable feature gate of kube-rbac-proxy.
+// Features must be added before the --feature-gates flag is registered.
+var DefaultMutableFeatureGate featuregate.MutableFeatureGate = featuregate.NewFeatureGate()
+
+// DefaultFeatureGate is the read-only view of DefaultMutableFeatureGate.
+var DefaultFeatureGate featuregate.FeatureGate = DefaultMutableFeatureGate
+
+// defaultFeatureGates consists of all known kube-rbac-proxy feature keys.
+// To add a new feature, define a key for it above and add it here.
+var defaultFeatureGates = map[featuregate.Feature]featuregate.FeatureSpec{
+	LocalRBACAuthorizer: {Default: false, PreRelease: featuregate.Alpha},
+	UpstreamH2C:         {Default: true, PreRelease: featuregate.Beta},
+	FaultInjection:      {Default: false, PreRelease: featuregate.Alpha},
+}
+
+func init() {
+	runtime.Must(DefaultMutableFeatureGate.Add(defaultFeatureGates))
+	// The logging features, e.g. ContextualLogging, are gated alike.
+	runtime.Must(logsapi.AddFeatureGates(DefaultMutableFeatureGate))
+}
+
+// Enabled returns the state of all known features.
+func Enabled() map[string]bool {
+	enabled := map[string]bool{}
+	for feature := range DefaultMutableFeatureGate.GetAll() {
+		enabled[string(feature)] = DefaultFeatureGate.Enabled(feature)
+	}
+	return enabled
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"path"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/proxy"
+)
+
+// AccessLogConfig samples the access log of requests, whose path matches one
+// of the patterns. The patterns use the syntax of --allow-paths. The first
+// matching config applies, requests matching none aren't logged.
+type AccessLogConfig struct {
+	Paths []string `json:"paths,omitempty"`
+	// AllowedRate is the fraction of requests logged, which weren't denied,
+	// between 0 and 1. Defaults to 1.
+	AllowedRate *float64 `json:"allowedRate,omitempty"`
+	// DeniedRate is the fraction of requests logged, which were answered
+	// with 401 or 403, between 0 and 1. Defaults to 1.
+	DeniedRate *float64 `json:"deniedRate,omitempty"`
+}
+
+// ValidateAccessLog checks the path patterns and the rates.
+func ValidateAccessLog(configs []AccessLogConfig) error {
+	for i, config := range configs {
+		if len(config.Paths) == 0 {
+			return fmt.Errorf("accessLog[%d]: at least one path is required", i)
+		}
+		for _, pattern := range config.Paths {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("accessLog[%d]: invalid path %q: %w", i, pattern, err)
+			}
+		}
+		for name, rate := range map[string]*float64{"allowedRate": config.AllowedRate, "deniedRate": config.DeniedRate} {
+			if rate != nil && (*rate < 0 || *rate > 1) {
+				return fmt.Errorf("accessLog[%d]: %s must be between 0 and 1", i, name)
+			}
+		}
+	}
+	return nil
+}
+
+// WithAccessLog logs the handled requests, sampled by the rates of the
+// first config matching the path.
+func WithAccessLog(configs []AccessLogConfig, handler http.HandlerFunc) http.HandlerFunc {
+	if len(configs) == 0 {
+		return handler
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		var config *AccessLogConfig
+		for i := range configs {
+			if _, found := proxy.MatchPath(configs[i].Paths, req.URL.Path); found {
+				config = &configs[i]
+				break
+			}
+		}
+		if config == nil {
+			handler.ServeHTTP(w, req)
+			return
+		}
+
+		start := time.Now()
+		sw := &statusResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		handler.ServeHTTP(sw, req)
+
+		rate := config.AllowedRate
+		if sw.status == http.StatusUnauthorized || sw.status == http.StatusForbidden {
+			rate = config.DeniedRate
+		}
+		if !sampled(rate) {
+			return
+		}
+
+		klog.FromContext(req.Context()).Info("Handled request",
+			"method", req.Method,
+			"path", req.URL.Path,
+			"status", sw.status,
+			"duration", time.Since(start),
+		)
+	}
+}
+
+// sampled decides whether a request is logged at the rate, which defaults
+// to 1.
+func sampled(rate *float64) bool {
+	if rate == nil || *rate >= 1 {
+		return true
+	}
+	return rand.Float64() < *rate
+}
+
+// statusResponseWriter records the status of the response.
+type statusResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusResponseWriter) WriteHeader(code int) {
+	if !w.wroteHeader && code >= http.StatusOK {
+		w.wroteHeader = true
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap allows http.ResponseController to flush streamed responses.
+func (w *statusResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
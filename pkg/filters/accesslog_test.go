@@ -0,0 +1,105 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr/funcr"
+	"k8s.io/klog/v2"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/filters"
+)
+
+func TestWithAccessLog(t *testing.T) {
+	none, all := 0.0, 1.0
+	configs := []filters.AccessLogConfig{
+		{Paths: []string{"/metrics"}, AllowedRate: &none, DeniedRate: &all},
+		{Paths: []string{"/admin/*"}},
+	}
+
+	for _, tt := range []struct {
+		name       string
+		path       string
+		status     int
+		wantLogged bool
+	}{
+		{
+			name:   "allowed scrape",
+			path:   "/metrics",
+			status: http.StatusOK,
+		},
+		{
+			name:       "denied scrape",
+			path:       "/metrics",
+			status:     http.StatusForbidden,
+			wantLogged: true,
+		},
+		{
+			name:       "allowed admin request",
+			path:       "/admin/users",
+			status:     http.StatusOK,
+			wantLogged: true,
+		},
+		{
+			name:   "unmatched path",
+			path:   "/healthz",
+			status: http.StatusUnauthorized,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var logged []string
+			logger := funcr.New(func(prefix, args string) {
+				logged = append(logged, args)
+			}, funcr.Options{})
+
+			handler := filters.WithAccessLog(configs, func(w http.ResponseWriter, req *http.Request) {
+				w.WriteHeader(tt.status)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req = req.WithContext(klog.NewContext(req.Context(), logger))
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("want status %d, got %d", tt.status, rec.Code)
+			}
+			if (len(logged) > 0) != tt.wantLogged {
+				t.Fatalf("want logged %t, got %q", tt.wantLogged, logged)
+			}
+			if tt.wantLogged && !strings.Contains(logged[0], `"path"="`+tt.path+`"`) {
+				t.Errorf("want the path in %s", logged[0])
+			}
+		})
+	}
+}
+
+func TestValidateAccessLog(t *testing.T) {
+	tooHigh := 1.5
+	for _, configs := range [][]filters.AccessLogConfig{
+		{{}},
+		{{Paths: []string{"["}}},
+		{{Paths: []string{"/metrics"}, DeniedRate: &tooHigh}},
+	} {
+		if err := filters.ValidateAccessLog(configs); err == nil {
+			t.Errorf("want error for %+v", configs)
+		}
+	}
+}
@@ -27,13 +27,62 @@ import (
 	"k8s.io/apiserver/pkg/authentication/authenticator"
 	"k8s.io/apiserver/pkg/authorization/authorizer"
 	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
 	"k8s.io/klog/v2"
 )
 
+var (
+	groupsOverflows = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      "kube_rbac_proxy",
+			Subsystem:      "auth_header",
+			Name:           "groups_overflows_total",
+			Help:           "Number of requests whose groups exceeded the maximum size of the groups header by the action taken, reject or truncate.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"action"},
+	)
+	groupsTruncated = metrics.NewCounter(
+		&metrics.CounterOpts{
+			Namespace:      "kube_rbac_proxy",
+			Subsystem:      "auth_header",
+			Name:           "truncated_groups_total",
+			Help:           "Number of groups left out of the groups header, because they exceeded its maximum size.",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+	clientFields = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      "kube_rbac_proxy",
+			Subsystem:      "auth_header",
+			Name:           "client_fields_total",
+			Help:           "Number of requests carrying auth header fields sent by the client by the action taken, strip or reject.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"action"},
+	)
+)
+
+func init() {
+	legacyregistry.MustRegister(groupsOverflows, groupsTruncated, clientFields)
+}
+
 func WithAuthentication(
 	authReq authenticator.Request,
 	audiences []string,
 	handler http.HandlerFunc,
+) http.HandlerFunc {
+	return WithAuthenticationChallenge(authReq, audiences, nil, handler)
+}
+
+// WithAuthenticationChallenge is WithAuthentication, whose 401 responses
+// carry the WWW-Authenticate challenge. A nil challenge is omitted.
+func WithAuthenticationChallenge(
+	authReq authenticator.Request,
+	audiences []string,
+	challenge *BearerChallenge,
+	handler http.HandlerFunc,
 ) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		ctx := req.Context()
@@ -44,16 +93,20 @@ func WithAuthentication(
 
 		res, ok, err := authReq.AuthenticateRequest(req)
 		if err != nil {
-			klog.Errorf("Unable to authenticate the request due to an error: %v", err)
+			klog.FromContext(ctx).Error(err, "Unable to authenticate the request")
+			challenge.set(w, req)
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
 		if !ok {
+			challenge.set(w, req)
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
 
-		req = req.WithContext(request.WithUser(req.Context(), res.User))
+		ctx = klog.NewContext(ctx, klog.FromContext(ctx).WithValues("user", res.User.GetName()))
+		ctx = authn.WithCachePartition(ctx, authn.RequestCachePartition(req, res))
+		req = req.WithContext(request.WithUser(ctx, res.User))
 		handler.ServeHTTP(w, req)
 	}
 }
@@ -78,7 +131,7 @@ func WithAuthorization(
 		allAttrs := getRequestAttributes(u, req)
 		if len(allAttrs) == 0 {
 			msg := "Bad Request. The request or configuration is malformed."
-			klog.V(2).Info(msg)
+			klog.FromContext(req.Context()).V(2).Info(msg)
 			http.Error(w, msg, http.StatusBadRequest)
 			return
 		}
@@ -88,13 +141,13 @@ func WithAuthorization(
 			authorized, reason, err := authz.Authorize(req.Context(), attrs)
 			if err != nil {
 				msg := fmt.Sprintf("Authorization error (user=%s, verb=%s, resource=%s, subresource=%s)", u.GetName(), attrs.GetVerb(), attrs.GetResource(), attrs.GetSubresource())
-				klog.Errorf("%s: %s", msg, err)
+				klog.FromContext(req.Context()).Error(err, "Authorization error", "verb", attrs.GetVerb(), "resource", attrs.GetResource(), "subresource", attrs.GetSubresource())
 				http.Error(w, msg, http.StatusInternalServerError)
 				return
 			}
 			if authorized != authorizer.DecisionAllow {
-				msg := fmt.Sprintf("Forbidden (user=%s, verb=%s, resource=%s, subresource=%s)", u.GetName(), attrs.GetVerb(), attrs.GetResource(), attrs.GetSubresource())
-				klog.V(2).Infof("%s. Reason: %q.", msg, reason)
+				msg := fmt.Sprintf("Forbidden (user=%s, verb=%s, resource=%s, subresource=%s%s)", u.GetName(), attrs.GetVerb(), attrs.GetResource(), attrs.GetSubresource(), denialDetails(cfg, attrs))
+				klog.FromContext(req.Context()).V(2).Info("Forbidden", "verb", attrs.GetVerb(), "namespace", attrs.GetNamespace(), "resource", attrs.GetResource(), "subresource", attrs.GetSubresource(), "name", attrs.GetName(), "path", attrs.GetPath(), "reason", reason)
 				http.Error(w, msg, http.StatusForbidden)
 				return
 			}
@@ -104,6 +157,64 @@ func WithAuthorization(
 	}
 }
 
+// denialDetails describes the denied rewritten attributes with the detail
+// configured for the rewrites, such that clients requesting many values can
+// tell which one was denied.
+func denialDetails(cfg *authz.Config, attrs authorizer.Attributes) string {
+	rewritten, ok := attrs.(proxy.RewrittenAttributes)
+	if !ok || cfg.Rewrites == nil {
+		return ""
+	}
+
+	switch cfg.Rewrites.DenialDetails {
+	case authz.DenialDetailsValue:
+		return fmt.Sprintf(", value=%q", rewritten.Value)
+	case authz.DenialDetailsAttributes:
+		return fmt.Sprintf(", namespace=%s, name=%s, value=%q", rewritten.GetNamespace(), rewritten.GetName(), rewritten.Value)
+	default:
+		return ""
+	}
+}
+
+// WithClientAuthHeaderProtection strips or rejects the auth header fields
+// clients sent themselves, before any other filter. Otherwise they reach
+// the upstream for requests that aren't authenticated, e.g. of
+// --ignore-paths, and an upstream trusting them could be tricked into
+// another identity.
+func WithClientAuthHeaderProtection(cfg *authn.AuthnHeaderConfig, handler http.HandlerFunc) http.HandlerFunc {
+	if !cfg.Enabled || cfg.ClientFields == "" {
+		return handler
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		var found []string
+		for _, name := range []string{cfg.UserFieldName, cfg.GroupsFieldName} {
+			if _, ok := req.Header[http.CanonicalHeaderKey(name)]; ok {
+				found = append(found, name)
+			}
+		}
+		if len(found) == 0 {
+			handler.ServeHTTP(w, req)
+			return
+		}
+
+		clientFields.WithLabelValues(cfg.ClientFields).Inc()
+		if cfg.ClientFields == authn.ClientHeaderFieldsReject {
+			rejectedRequests.WithLabelValues("client_auth_headers").Inc()
+			klog.FromContext(req.Context()).V(2).Info("Rejecting request with auth header fields sent by the client", "headers", found)
+			http.Error(w, fmt.Sprintf("Bad Request. The request must not contain the headers %s.", strings.Join(found, ", ")), http.StatusBadRequest)
+			return
+		}
+
+		klog.FromContext(req.Context()).V(4).Info("Stripping auth header fields sent by the client", "headers", found)
+		req.Header = req.Header.Clone()
+		for _, name := range found {
+			req.Header.Del(name)
+		}
+		handler.ServeHTTP(w, req)
+	}
+}
+
 // WithAuthHeaders adds identity information to the headers.
 // Must not be used, if connection is not encrypted with TLS.
 func WithAuthHeaders(cfg *authn.AuthnHeaderConfig, handler http.HandlerFunc) http.HandlerFunc {
@@ -114,12 +225,70 @@ func WithAuthHeaders(cfg *authn.AuthnHeaderConfig, handler http.HandlerFunc) htt
 	return func(w http.ResponseWriter, req *http.Request) {
 		u, ok := request.UserFrom(req.Context())
 		if ok {
+			groups, ok := groupHeaderValues(cfg, u.GetGroups())
+			if !ok {
+				klog.FromContext(req.Context()).V(2).Info("Rejecting request, the groups exceed the header size", "groups", len(u.GetGroups()))
+				http.Error(w, "The groups of the user exceed the maximum header size.", http.StatusRequestHeaderFieldsTooLarge)
+				return
+			}
+
 			// Seemingly well-known headers to tell the upstream about user's identity
 			// so that the upstream can achieve the original goal of delegating RBAC authn/authz to kube-rbac-proxy
 			req.Header.Set(cfg.UserFieldName, u.GetName())
-			req.Header.Set(cfg.GroupsFieldName, strings.Join(u.GetGroups(), cfg.GroupSeparator))
+			setHeaderValues(req.Header, cfg.GroupsFieldName, groups)
 		}
 
 		handler.ServeHTTP(w, req)
 	}
 }
+
+// groupHeaderValues returns the values of the groups header field, split by
+// the maximum value size and capped by the maximum size. It returns false,
+// if the groups exceed the maximum size and are to be rejected.
+func groupHeaderValues(cfg *authn.AuthnHeaderConfig, groups []string) ([]string, bool) {
+	var values []string
+	var value strings.Builder
+	size := 0
+	for i, group := range groups {
+		sep := len(cfg.GroupSeparator)
+		if value.Len() == 0 {
+			sep = 0
+		}
+		// Splitting doesn't count the separator, each value starts anew.
+		split := cfg.GroupsMaxValueSize > 0 && value.Len() > 0 && value.Len()+sep+len(group) > cfg.GroupsMaxValueSize
+		if split {
+			sep = 0
+		}
+
+		if cfg.GroupsMaxSize > 0 && size+sep+len(group) > cfg.GroupsMaxSize {
+			if cfg.GroupsOverflow != authn.GroupsOverflowTruncate {
+				groupsOverflows.WithLabelValues(authn.GroupsOverflowReject).Inc()
+				return nil, false
+			}
+			groupsOverflows.WithLabelValues(authn.GroupsOverflowTruncate).Inc()
+			groupsTruncated.Add(float64(len(groups) - i))
+			break
+		}
+
+		if split {
+			values = append(values, value.String())
+			value.Reset()
+		}
+		if value.Len() > 0 {
+			value.WriteString(cfg.GroupSeparator)
+		}
+		value.WriteString(group)
+		size += sep + len(group)
+	}
+	values = append(values, value.String())
+
+	return values, true
+}
+
+// setHeaderValues replaces the values of the header field.
+func setHeaderValues(h http.Header, name string, values []string) {
+	h.Del(name)
+	for _, v := range values {
+		h.Add(name, v)
+	}
+}
@@ -33,6 +33,7 @@ import (
 	"k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/apiserver/pkg/authorization/authorizer"
 	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/component-base/metrics/legacyregistry"
 )
 
 func TestWithAuthentication(t *testing.T) {
@@ -146,11 +147,20 @@ func TestWithAuthorization(t *testing.T) {
 			req:   userRequest,
 			authz: nil,
 			cfg: &authz.Config{
-				ResourceAttributes: &authz.ResourceAttributes{},
+				ResourceAttributes: authz.ResourceAttributesList{{}},
 				Rewrites:           &authz.SubjectAccessReviewRewrites{},
 			},
 			status: http.StatusBadRequest,
 		},
+		{
+			name:  "should fail with a template failing for the request",
+			req:   userRequest,
+			authz: nil,
+			cfg: &authz.Config{
+				ResourceAttributes: authz.ResourceAttributesList{{Namespace: "{{ index .Path 5 }}"}},
+			},
+			status: http.StatusBadRequest,
+		},
 		{
 			name: "should fail with error on authorization",
 			req:  userRequest,
@@ -196,6 +206,56 @@ func TestWithAuthorization(t *testing.T) {
 	}
 }
 
+func TestWithAuthorizationDenialDetails(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/metrics?namespace=tenant1&namespace=tenant2", nil)
+	req = req.WithContext(request.WithUser(req.Context(), &user.DefaultInfo{Name: "alice"}))
+
+	onlyTenant1 := authorizerFunc(func(ctx context.Context, attr authorizer.Attributes) (authorizer.Decision, string, error) {
+		if attr.GetNamespace() == "tenant1" {
+			return authorizer.DecisionAllow, "", nil
+		}
+		return authorizer.DecisionNoOpinion, "", nil
+	})
+
+	for _, tt := range []struct {
+		details string
+		want    string
+	}{
+		{
+			details: "",
+			want:    "Forbidden (user=alice, verb=get, resource=pods, subresource=metrics)\n",
+		},
+		{
+			details: authz.DenialDetailsValue,
+			want:    "Forbidden (user=alice, verb=get, resource=pods, subresource=metrics, value=\"tenant2\")\n",
+		},
+		{
+			details: authz.DenialDetailsAttributes,
+			want:    "Forbidden (user=alice, verb=get, resource=pods, subresource=metrics, namespace=tenant2, name=, value=\"tenant2\")\n",
+		},
+	} {
+		t.Run(tt.details, func(t *testing.T) {
+			cfg := &authz.Config{
+				Rewrites: &authz.SubjectAccessReviewRewrites{
+					ByQueryParameter: &authz.QueryParameterRewriteConfig{Name: "namespace"},
+					DenialDetails:    tt.details,
+				},
+				ResourceAttributes: authz.ResourceAttributesList{{Namespace: "{{ .Value }}", Resource: "pods", Subresource: "metrics"}},
+			}
+
+			rec := httptest.NewRecorder()
+			filters.WithAuthorization(onlyTenant1, cfg, func(w http.ResponseWriter, r *http.Request) {}).ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusForbidden {
+				t.Fatalf("want status 403, got %d", rec.Code)
+			}
+			if got := rec.Body.String(); got != tt.want {
+				t.Errorf("want body %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
 type authorizerFunc func(context.Context, authorizer.Attributes) (authorizer.Decision, string, error)
 
 func (a authorizerFunc) Authorize(ctx context.Context, attr authorizer.Attributes) (authorizer.Decision, string, error) {
@@ -268,6 +328,197 @@ func TestWithAuthHeaders(t *testing.T) {
 	}
 }
 
+func TestWithAuthHeadersGroupLimits(t *testing.T) {
+	groups := []string{"aaaa", "bbbb", "cccc", "dddd"}
+
+	for _, tt := range []struct {
+		name       string
+		cfg        authn.AuthnHeaderConfig
+		wantStatus int
+		wantValues []string
+	}{
+		{
+			name:       "unlimited",
+			wantStatus: http.StatusOK,
+			wantValues: []string{"aaaa|bbbb|cccc|dddd"},
+		},
+		{
+			name:       "split",
+			cfg:        authn.AuthnHeaderConfig{GroupsMaxValueSize: 10},
+			wantStatus: http.StatusOK,
+			wantValues: []string{"aaaa|bbbb", "cccc|dddd"},
+		},
+		{
+			name:       "split larger groups",
+			cfg:        authn.AuthnHeaderConfig{GroupsMaxValueSize: 3},
+			wantStatus: http.StatusOK,
+			wantValues: []string{"aaaa", "bbbb", "cccc", "dddd"},
+		},
+		{
+			name:       "fitting",
+			cfg:        authn.AuthnHeaderConfig{GroupsMaxSize: 19},
+			wantStatus: http.StatusOK,
+			wantValues: []string{"aaaa|bbbb|cccc|dddd"},
+		},
+		{
+			name:       "rejected",
+			cfg:        authn.AuthnHeaderConfig{GroupsMaxSize: 18, GroupsOverflow: authn.GroupsOverflowReject},
+			wantStatus: http.StatusRequestHeaderFieldsTooLarge,
+		},
+		{
+			name:       "truncated",
+			cfg:        authn.AuthnHeaderConfig{GroupsMaxSize: 10, GroupsOverflow: authn.GroupsOverflowTruncate},
+			wantStatus: http.StatusOK,
+			wantValues: []string{"aaaa|bbbb"},
+		},
+		{
+			name:       "split and truncated",
+			cfg:        authn.AuthnHeaderConfig{GroupsMaxValueSize: 4, GroupsMaxSize: 12, GroupsOverflow: authn.GroupsOverflowTruncate},
+			wantStatus: http.StatusOK,
+			wantValues: []string{"aaaa", "bbbb", "cccc"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			cfg.Enabled = true
+			cfg.UserFieldName = "X-Remote-User"
+			cfg.GroupsFieldName = "X-Remote-Groups"
+			cfg.GroupSeparator = "|"
+
+			var got []string
+			handler := filters.WithAuthHeaders(&cfg, func(w http.ResponseWriter, req *http.Request) {
+				got = req.Header.Values("X-Remote-Groups")
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req = req.WithContext(request.WithUser(req.Context(), &user.DefaultInfo{Name: "alice", Groups: groups}))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("want status %d, got %d", tt.wantStatus, rec.Code)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.wantValues, ",") {
+				t.Errorf("want groups %q, got %q", tt.wantValues, got)
+			}
+		})
+	}
+}
+
+// clientFieldsCount returns the number of requests with auth header fields
+// sent by the client, that were counted by the action.
+func clientFieldsCount(t *testing.T, action string) float64 {
+	t.Helper()
+
+	families, err := legacyregistry.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != "kube_rbac_proxy_auth_header_client_fields_total" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == "action" && label.GetValue() == action {
+					return m.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	return 0
+}
+
+func TestWithClientAuthHeaderProtection(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		cfg        *authn.AuthnHeaderConfig
+		header     http.Header
+		wantStatus int
+		wantHeader http.Header
+		// wantAction is the action the request is counted by, if any.
+		wantAction string
+	}{
+		{
+			name:       "without mode the headers pass through",
+			cfg:        &authn.AuthnHeaderConfig{Enabled: true, UserFieldName: "X-Remote-User", GroupsFieldName: "X-Remote-Groups"},
+			header:     http.Header{"X-Remote-User": {"admin"}},
+			wantStatus: http.StatusOK,
+			wantHeader: http.Header{"X-Remote-User": {"admin"}},
+		},
+		{
+			name:       "disabled header fields pass through",
+			cfg:        &authn.AuthnHeaderConfig{UserFieldName: "X-Remote-User", ClientFields: authn.ClientHeaderFieldsReject},
+			header:     http.Header{"X-Remote-User": {"admin"}},
+			wantStatus: http.StatusOK,
+			wantHeader: http.Header{"X-Remote-User": {"admin"}},
+		},
+		{
+			name:       "strip removes the header fields",
+			cfg:        &authn.AuthnHeaderConfig{Enabled: true, UserFieldName: "x-remote-user", GroupsFieldName: "x-remote-groups", ClientFields: authn.ClientHeaderFieldsStrip},
+			header:     http.Header{"X-Remote-User": {"admin"}, "X-Remote-Groups": {"system:masters"}, "Accept": {"*/*"}},
+			wantStatus: http.StatusOK,
+			wantHeader: http.Header{"Accept": {"*/*"}},
+			wantAction: "strip",
+		},
+		{
+			name:       "reject fails requests with the header fields",
+			cfg:        &authn.AuthnHeaderConfig{Enabled: true, UserFieldName: "X-Remote-User", GroupsFieldName: "X-Remote-Groups", ClientFields: authn.ClientHeaderFieldsReject},
+			header:     http.Header{"X-Remote-Groups": {""}},
+			wantStatus: http.StatusBadRequest,
+			wantAction: "reject",
+		},
+		{
+			name:       "reject passes requests without the header fields",
+			cfg:        &authn.AuthnHeaderConfig{Enabled: true, UserFieldName: "X-Remote-User", GroupsFieldName: "X-Remote-Groups", ClientFields: authn.ClientHeaderFieldsReject},
+			header:     http.Header{"Accept": {"*/*"}},
+			wantStatus: http.StatusOK,
+			wantHeader: http.Header{"Accept": {"*/*"}},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var got http.Header
+			handler := filters.WithClientAuthHeaderProtection(tt.cfg, func(w http.ResponseWriter, req *http.Request) {
+				got = req.Header
+			})
+
+			before := map[string]float64{"strip": clientFieldsCount(t, "strip"), "reject": clientFieldsCount(t, "reject")}
+
+			req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+			req.Header = tt.header
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("want status %d, have %d", tt.wantStatus, rec.Code)
+			}
+			for action, count := range before {
+				want := count
+				if action == tt.wantAction {
+					want++
+				}
+				if got := clientFieldsCount(t, action); got != want {
+					t.Errorf("want %v requests counted as %s, have %v", want, action, got)
+				}
+			}
+			if tt.wantStatus != http.StatusOK {
+				if got != nil {
+					t.Fatal("rejected request reached the handler")
+				}
+				return
+			}
+			if len(got) != len(tt.wantHeader) {
+				t.Fatalf("want headers %v, have %v", tt.wantHeader, got)
+			}
+			for k, v := range tt.wantHeader {
+				if got.Get(k) != v[0] {
+					t.Errorf("want header %s=%q, have %q", k, v[0], got.Get(k))
+				}
+			}
+		})
+	}
+}
+
 func TestProxyWithOIDCSupport(t *testing.T) {
 	cfg := proxy.Config{
 		Authentication: &authn.AuthnConfig{
@@ -0,0 +1,93 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/authn"
+
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+// Headers carrying the original request in an authentication subrequest.
+// NGINX auth_request needs them to be configured explicitly, Traefik
+// ForwardAuth sets the X-Forwarded-* headers by default.
+const (
+	headerOriginalMethod  = "X-Original-Method"
+	headerOriginalURI     = "X-Original-URI"
+	headerForwardedMethod = "X-Forwarded-Method"
+	headerForwardedURI    = "X-Forwarded-Uri"
+)
+
+// WithOriginalRequest restores the method and URL of the original request
+// from the headers of an authentication subrequest, such that the
+// authorization happens on the original request. Subrequests missing either
+// are rejected, rather than authorizing the subrequest itself.
+func WithOriginalRequest(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		method := firstHeader(req.Header, headerOriginalMethod, headerForwardedMethod)
+		uri := firstHeader(req.Header, headerOriginalURI, headerForwardedURI)
+		if method == "" || uri == "" {
+			http.Error(w, "Bad Request. The original method or URI is missing.", http.StatusBadRequest)
+			return
+		}
+
+		u, err := url.ParseRequestURI(uri)
+		if err != nil {
+			http.Error(w, "Bad Request. The original URI is malformed.", http.StatusBadRequest)
+			return
+		}
+
+		req = req.Clone(req.Context())
+		req.Method = strings.ToUpper(method)
+		req.URL.Path = u.Path
+		req.URL.RawPath = u.RawPath
+		req.URL.RawQuery = u.RawQuery
+
+		handler.ServeHTTP(w, req)
+	}
+}
+
+// AuthRequestIdentity responds to successfully authenticated and authorized
+// subrequests with the user's identity in the response headers, so that the
+// front proxy can pass them on to the upstream.
+func AuthRequestIdentity(cfg *authn.AuthnHeaderConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if u, ok := request.UserFrom(req.Context()); ok {
+			groups, ok := groupHeaderValues(cfg, u.GetGroups())
+			if !ok {
+				http.Error(w, "The groups of the user exceed the maximum header size.", http.StatusRequestHeaderFieldsTooLarge)
+				return
+			}
+			w.Header().Set(cfg.UserFieldName, u.GetName())
+			setHeaderValues(w.Header(), cfg.GroupsFieldName, groups)
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func firstHeader(h http.Header, keys ...string) string {
+	for _, k := range keys {
+		if v := h.Get(k); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/authn"
+	"github.com/brancz/kube-rbac-proxy/pkg/filters"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+func TestWithOriginalRequest(t *testing.T) {
+	for _, tt := range []struct {
+		name           string
+		headers        map[string]string
+		expectedMethod string
+		expectedPath   string
+		expectedQuery  string
+		status         int
+	}{
+		{
+			name:           "nginx auth_request",
+			headers:        map[string]string{"X-Original-Method": "POST", "X-Original-URI": "/api/v1/query?namespace=foo"},
+			expectedMethod: http.MethodPost,
+			expectedPath:   "/api/v1/query",
+			expectedQuery:  "namespace=foo",
+			status:         http.StatusOK,
+		},
+		{
+			name:           "traefik forward auth",
+			headers:        map[string]string{"X-Forwarded-Method": "DELETE", "X-Forwarded-Uri": "/metrics"},
+			expectedMethod: http.MethodDelete,
+			expectedPath:   "/metrics",
+			status:         http.StatusOK,
+		},
+		{
+			name:   "without headers",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:    "without method",
+			headers: map[string]string{"X-Original-URI": "/metrics"},
+			status:  http.StatusBadRequest,
+		},
+		{
+			name:    "without uri",
+			headers: map[string]string{"X-Forwarded-Method": "GET"},
+			status:  http.StatusBadRequest,
+		},
+		{
+			name:    "malformed uri",
+			headers: map[string]string{"X-Original-Method": "GET", "X-Original-URI": "::"},
+			status:  http.StatusBadRequest,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/authz", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+
+			rec := httptest.NewRecorder()
+			filters.WithOriginalRequest(func(w http.ResponseWriter, r *http.Request) {
+				if tt.status != http.StatusOK {
+					t.Error("want the subrequest rejected")
+				}
+				if r.Method != tt.expectedMethod {
+					t.Errorf("want method %q, got %q", tt.expectedMethod, r.Method)
+				}
+				if r.URL.Path != tt.expectedPath {
+					t.Errorf("want path %q, got %q", tt.expectedPath, r.URL.Path)
+				}
+				if r.URL.RawQuery != tt.expectedQuery {
+					t.Errorf("want query %q, got %q", tt.expectedQuery, r.URL.RawQuery)
+				}
+			}).ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("want status %d, got %d", tt.status, rec.Code)
+			}
+		})
+	}
+}
+
+func TestAuthRequestIdentity(t *testing.T) {
+	cfg := &authn.AuthnHeaderConfig{
+		UserFieldName:   "x-remote-user",
+		GroupsFieldName: "x-remote-groups",
+		GroupSeparator:  "|",
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/authz", nil)
+	req = req.WithContext(request.WithUser(req.Context(), &user.DefaultInfo{
+		Name:   "system:serviceaccount:default:default",
+		Groups: []string{"system:authenticated", "system:serviceaccounts"},
+	}))
+
+	rec := httptest.NewRecorder()
+	filters.AuthRequestIdentity(cfg).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("want status %d, got %d", http.StatusOK, rec.Code)
+	}
+	if u := rec.Header().Get("x-remote-user"); u != "system:serviceaccount:default:default" {
+		t.Errorf("unexpected user header %q", u)
+	}
+	if g := rec.Header().Get("x-remote-groups"); g != "system:authenticated|system:serviceaccounts" {
+		t.Errorf("unexpected groups header %q", g)
+	}
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+
+	"k8s.io/klog/v2"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/audit"
+)
+
+// BodyBuffer configures the buffering of request bodies.
+type BodyBuffer struct {
+	// MaxSize is the maximum size of buffered bodies, larger bodies are
+	// streamed to the upstream. Zero disables buffering.
+	MaxSize int64
+	// Hash adds the SHA-256 of buffered bodies to the exported decisions.
+	Hash bool
+}
+
+// WithRequestBodyBuffer reads request bodies up to the maximum size into
+// memory, such that the request can be replayed with GetBody, e.g. to retry
+// it on upstream connection resets.
+//
+// Bodies of requests expecting a 100 Continue are streamed, as reading them
+// would send the 100 Continue before the request is authorized. That way
+// denied clients receive the 403 before they upload the body.
+func WithRequestBodyBuffer(cfg BodyBuffer, handler http.HandlerFunc) http.HandlerFunc {
+	if cfg.MaxSize <= 0 {
+		return handler
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Body == nil || req.Body == http.NoBody || req.ContentLength > cfg.MaxSize || expectsContinue(req) {
+			handler.ServeHTTP(w, req)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(req.Body, cfg.MaxSize+1))
+		if err != nil {
+			klog.FromContext(req.Context()).V(2).Info("Unable to read the request body", "err", err)
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+
+		if int64(len(body)) > cfg.MaxSize {
+			// Bodies of unknown length exceeding the maximum size are
+			// streamed, starting with the bytes read so far.
+			req.Body = readCloser{io.MultiReader(bytes.NewReader(body), req.Body), req.Body}
+			handler.ServeHTTP(w, req)
+			return
+		}
+
+		req.Body.Close()
+		req.ContentLength = int64(len(body))
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+
+		if cfg.Hash {
+			sum := sha256.Sum256(body)
+			req = req.WithContext(audit.WithBodyHash(req.Context(), hex.EncodeToString(sum[:])))
+		}
+
+		handler.ServeHTTP(w, req)
+	}
+}
+
+func expectsContinue(req *http.Request) bool {
+	return strings.EqualFold(req.Header.Get("Expect"), "100-continue")
+}
+
+// readCloser reads from the Reader and closes the Closer.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/filters"
+)
+
+func TestWithRequestBodyBuffer(t *testing.T) {
+	for _, tt := range []struct {
+		name          string
+		maxSize       int64
+		body          string
+		unknownLength bool
+		expect        string
+		wantBuffered  bool
+	}{
+		{
+			name:    "disabled",
+			body:    "query",
+			maxSize: 0,
+		},
+		{
+			name:         "within the maximum size",
+			body:         "query",
+			maxSize:      5,
+			wantBuffered: true,
+		},
+		{
+			name:    "exceeding the maximum size",
+			body:    "query",
+			maxSize: 4,
+		},
+		{
+			name:          "unknown length within the maximum size",
+			body:          "query",
+			unknownLength: true,
+			maxSize:       5,
+			wantBuffered:  true,
+		},
+		{
+			name:          "unknown length exceeding the maximum size",
+			body:          "query",
+			unknownLength: true,
+			maxSize:       4,
+		},
+		{
+			name:    "expecting 100 Continue",
+			body:    "query",
+			expect:  "100-Continue",
+			maxSize: 5,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := filters.WithRequestBodyBuffer(filters.BodyBuffer{MaxSize: tt.maxSize, Hash: true}, func(w http.ResponseWriter, req *http.Request) {
+				if buffered := req.GetBody != nil; buffered != tt.wantBuffered {
+					t.Errorf("want buffered %v, got %v", tt.wantBuffered, buffered)
+				}
+				if tt.wantBuffered {
+					replayed, err := req.GetBody()
+					if err != nil {
+						t.Fatal(err)
+					}
+					if b, _ := io.ReadAll(replayed); string(b) != tt.body {
+						t.Errorf("want replayed body %q, got %q", tt.body, b)
+					}
+				}
+				if b, _ := io.ReadAll(req.Body); string(b) != tt.body {
+					t.Errorf("want body %q, got %q", tt.body, b)
+				}
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/query", strings.NewReader(tt.body))
+			if tt.unknownLength {
+				req.ContentLength = -1
+			}
+			if tt.expect != "" {
+				req.Header.Set("Expect", tt.expect)
+			}
+			handler(httptest.NewRecorder(), req)
+		})
+	}
+}
+
+func TestWithRequestBodyBufferExpectContinue(t *testing.T) {
+	handler := filters.WithRequestBodyBuffer(filters.BodyBuffer{MaxSize: 1 << 20}, func(w http.ResponseWriter, req *http.Request) {
+		// Denied requests are answered without reading the body.
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	body := &countingReader{Reader: strings.NewReader(strings.Repeat("x", 1<<10))}
+	req, err := http.NewRequest(http.MethodPost, server.URL, io.NopCloser(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.ContentLength = 1 << 10
+	req.Header.Set("Expect", "100-continue")
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ExpectContinueTimeout = 10 * time.Second
+	defer transport.CloseIdleConnections()
+
+	resp, err := (&http.Client{Transport: transport}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("want status %d, got %d", http.StatusForbidden, resp.StatusCode)
+	}
+	if body.n > 0 {
+		t.Errorf("want the body not to be uploaded, got %d bytes read", body.n)
+	}
+}
+
+type countingReader struct {
+	io.Reader
+	n int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.n += n
+	return n, err
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters
+
+import (
+	"net/http"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/klog/v2"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/authn"
+)
+
+// CacheBypassHeader is the header, with which members of the cache bypass
+// groups force a fresh TokenReview and SubjectAccessReviews.
+const CacheBypassHeader = "X-KRP-No-Cache"
+
+// WithCacheBypass authenticates requests of members of the groups carrying
+// the CacheBypassHeader again, without the caches, and bypasses the cached
+// authorization decisions, e.g. to debug the propagation of permissions
+// without restarting the proxy. It must be applied after the
+// authentication, whose authenticator is given. The header is ignored for
+// other users and removed before proxying.
+func WithCacheBypass(groups []string, authReq authenticator.Request, handler http.HandlerFunc) http.HandlerFunc {
+	if len(groups) == 0 {
+		return handler
+	}
+	bypassGroups := sets.New(groups...)
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get(CacheBypassHeader) == "" {
+			handler.ServeHTTP(w, req)
+			return
+		}
+		req = req.Clone(req.Context())
+		req.Header.Del(CacheBypassHeader)
+
+		logger := klog.FromContext(req.Context())
+		u, ok := request.UserFrom(req.Context())
+		if !ok || !bypassGroups.HasAny(u.GetGroups()...) {
+			logger.V(2).Info("Ignoring cache bypass of a user not in the cache bypass groups")
+			handler.ServeHTTP(w, req)
+			return
+		}
+
+		// The groups were authenticated from the cache, they are confirmed
+		// by the fresh authentication, which replaces the user.
+		ctx := authn.WithCacheBypass(req.Context())
+		res, ok, err := authReq.AuthenticateRequest(req.WithContext(ctx))
+		if err != nil {
+			logger.Error(err, "Unable to authenticate the request bypassing the cache")
+		}
+		if err != nil || !ok {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		logger.V(2).Info("Bypassing the authentication and authorization caches")
+		handler.ServeHTTP(w, req.WithContext(request.WithUser(ctx, res.User)))
+	}
+}
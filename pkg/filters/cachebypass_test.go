@@ -0,0 +1,95 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/authn"
+	"github.com/brancz/kube-rbac-proxy/pkg/filters"
+)
+
+func TestCacheBypass(t *testing.T) {
+	var fresh int
+	authReq := authenticator.RequestFunc(func(req *http.Request) (*authenticator.Response, bool, error) {
+		if !authn.CacheBypassed(req.Context()) {
+			t.Error("want the authentication to bypass the cache")
+		}
+		fresh++
+		return &authenticator.Response{User: &user.DefaultInfo{Name: "alice", Groups: []string{"admins", "fresh"}}}, true, nil
+	})
+
+	var bypassed bool
+	var groups []string
+	handler := filters.WithAuthenticationChallenge(bypassAuthenticator("alice", "admins"), nil, nil,
+		filters.WithCacheBypass([]string{"admins"}, authReq, func(w http.ResponseWriter, req *http.Request) {
+			if req.Header.Get(filters.CacheBypassHeader) != "" {
+				t.Error("want the header removed")
+			}
+			bypassed = authn.CacheBypassed(req.Context())
+			u, _ := request.UserFrom(req.Context())
+			groups = u.GetGroups()
+		}))
+
+	for _, tt := range []struct {
+		name         string
+		header       bool
+		wantBypassed bool
+	}{
+		{name: "no header"},
+		{name: "header", header: true, wantBypassed: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			bypassed, fresh = false, 0
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tt.header {
+				req.Header.Set(filters.CacheBypassHeader, "1")
+			}
+			handler(httptest.NewRecorder(), req)
+
+			if bypassed != tt.wantBypassed {
+				t.Errorf("want bypassed %v, got %v", tt.wantBypassed, bypassed)
+			}
+			if tt.wantBypassed && (fresh != 1 || len(groups) != 2) {
+				t.Errorf("want the user of the fresh authentication, got groups %v", groups)
+			}
+		})
+	}
+
+	nonAdmin := filters.WithAuthenticationChallenge(bypassAuthenticator("bob", "users"), nil, nil,
+		filters.WithCacheBypass([]string{"admins"}, authReq, func(w http.ResponseWriter, req *http.Request) {
+			bypassed = authn.CacheBypassed(req.Context())
+		}))
+	bypassed, fresh = false, 0
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set(filters.CacheBypassHeader, "1")
+	nonAdmin(httptest.NewRecorder(), req)
+	if bypassed || fresh != 0 {
+		t.Error("want the header ignored for users not in the groups")
+	}
+}
+
+func bypassAuthenticator(name string, groups ...string) authenticator.Request {
+	return authenticator.RequestFunc(func(*http.Request) (*authenticator.Response, bool, error) {
+		return &authenticator.Response{User: &user.DefaultInfo{Name: name, Groups: groups}}, true, nil
+	})
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters
+
+import (
+	"net/http"
+	"strings"
+)
+
+// BearerChallenge configures the WWW-Authenticate challenge of 401
+// responses as of RFC 6750, such that clients can tell that a bearer token
+// is expected and whether the one they sent was rejected.
+type BearerChallenge struct {
+	// Realm is the protection space of the proxy. It is sent as a quoted
+	// string, with quotes and backslashes escaped.
+	Realm string
+	// ErrorCodes adds error="invalid_token" to the challenge of requests,
+	// whose bearer token was rejected.
+	ErrorCodes bool
+}
+
+// realmEscaper escapes the realm as a quoted-string of RFC 7230.
+var realmEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// set adds the challenge to the header of the response. A nil challenge is
+// a no-op.
+func (c *BearerChallenge) set(w http.ResponseWriter, req *http.Request) {
+	if c == nil {
+		return
+	}
+
+	challenge := `Bearer realm="` + realmEscaper.Replace(c.Realm) + `"`
+	scheme, _, _ := strings.Cut(req.Header.Get("Authorization"), " ")
+	if c.ErrorCodes && strings.EqualFold(scheme, "bearer") {
+		challenge += `, error="invalid_token"`
+	}
+	w.Header().Set("WWW-Authenticate", challenge)
+}
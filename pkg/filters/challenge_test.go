@@ -0,0 +1,100 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/authentication/user"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/filters"
+)
+
+func TestWithAuthenticationChallenge(t *testing.T) {
+	authReq := authenticator.RequestFunc(func(req *http.Request) (*authenticator.Response, bool, error) {
+		if req.Header.Get("Authorization") != "Bearer valid" {
+			return nil, false, nil
+		}
+		return &authenticator.Response{User: &user.DefaultInfo{Name: "alice"}}, true, nil
+	})
+	upstream := func(w http.ResponseWriter, req *http.Request) {
+		// 401 responses of the upstream are passed on unchanged.
+		w.WriteHeader(http.StatusUnauthorized)
+	}
+
+	for _, tt := range []struct {
+		name          string
+		challenge     *filters.BearerChallenge
+		authorization string
+		want          string
+	}{
+		{
+			name:      "no credentials",
+			challenge: &filters.BearerChallenge{Realm: "metrics", ErrorCodes: true},
+			want:      `Bearer realm="metrics"`,
+		},
+		{
+			name:          "rejected token",
+			challenge:     &filters.BearerChallenge{Realm: "metrics", ErrorCodes: true},
+			authorization: "Bearer invalid",
+			want:          `Bearer realm="metrics", error="invalid_token"`,
+		},
+		{
+			name:          "rejected token without error codes",
+			challenge:     &filters.BearerChallenge{Realm: "metrics"},
+			authorization: "Bearer invalid",
+			want:          `Bearer realm="metrics"`,
+		},
+		{
+			name:          "other scheme",
+			challenge:     &filters.BearerChallenge{Realm: "metrics", ErrorCodes: true},
+			authorization: "Basic YWxpY2U6c2VjcmV0",
+			want:          `Bearer realm="metrics"`,
+		},
+		{
+			name:      "quoted realm",
+			challenge: &filters.BearerChallenge{Realm: `a "b" \c`},
+			want:      `Bearer realm="a \"b\" \\c"`,
+		},
+		{
+			name:          "authenticated",
+			challenge:     &filters.BearerChallenge{Realm: "metrics", ErrorCodes: true},
+			authorization: "Bearer valid",
+		},
+		{
+			name: "no challenge",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+
+			rec := httptest.NewRecorder()
+			filters.WithAuthenticationChallenge(authReq, nil, tt.challenge, upstream)(rec, req)
+			if rec.Code != http.StatusUnauthorized {
+				t.Fatalf("want status %d, got %d", http.StatusUnauthorized, rec.Code)
+			}
+			if got := rec.Header().Get("WWW-Authenticate"); got != tt.want {
+				t.Errorf("want challenge %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters
+
+import (
+	"net/http"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/audit"
+)
+
+// Keys of the user's extra info holding the client connection.
+const (
+	RemoteIPExtraKey         = "kube-rbac-proxy.io/remote-ip"
+	ServerNameExtraKey       = "kube-rbac-proxy.io/tls-server-name"
+	TLSVersionExtraKey       = "kube-rbac-proxy.io/tls-version"
+	ClientCertSHA256ExtraKey = "kube-rbac-proxy.io/client-cert-sha256"
+)
+
+// WithConnection adds the client connection to the context of the request,
+// so that the exported decisions carry it.
+func WithConnection(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		req = req.WithContext(audit.WithConnection(req.Context(), audit.NewConnection(req)))
+		handler.ServeHTTP(w, req)
+	}
+}
+
+// WithConnectionExtra adds the client connection to the extra info of the
+// authenticated user, so that SubjectAccessReviews can take it into
+// account.
+func WithConnectionExtra(enabled bool, handler http.HandlerFunc) http.HandlerFunc {
+	if !enabled {
+		return handler
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		u, ok := request.UserFrom(req.Context())
+		if !ok {
+			handler.ServeHTTP(w, req)
+			return
+		}
+		c, ok := audit.ConnectionFrom(req.Context())
+		if !ok {
+			c = audit.NewConnection(req)
+		}
+
+		extra := make(map[string][]string, len(u.GetExtra())+4)
+		for k, v := range u.GetExtra() {
+			extra[k] = v
+		}
+		for k, v := range map[string]string{
+			RemoteIPExtraKey:         c.RemoteIP,
+			ServerNameExtraKey:       c.ServerName,
+			TLSVersionExtraKey:       c.TLSVersion,
+			ClientCertSHA256ExtraKey: c.ClientCertSHA256,
+		} {
+			if v != "" {
+				extra[k] = []string{v}
+			}
+		}
+
+		req = req.WithContext(request.WithUser(req.Context(), &user.DefaultInfo{
+			Name:   u.GetName(),
+			UID:    u.GetUID(),
+			Groups: u.GetGroups(),
+			Extra:  extra,
+		}))
+		handler.ServeHTTP(w, req)
+	}
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters_test
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/filters"
+)
+
+func TestWithConnectionExtra(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		enabled   bool
+		wantExtra map[string][]string
+	}{
+		{
+			name:      "disabled",
+			wantExtra: map[string][]string{"scopes": {"read"}},
+		},
+		{
+			name:    "enabled",
+			enabled: true,
+			wantExtra: map[string][]string{
+				"scopes":                   {"read"},
+				filters.RemoteIPExtraKey:   {"10.0.0.1"},
+				filters.ServerNameExtraKey: {"proxy.example.com"},
+				filters.TLSVersionExtraKey: {"TLS 1.2"},
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var got user.Info
+			handler := filters.WithConnection(filters.WithConnectionExtra(tt.enabled, func(w http.ResponseWriter, req *http.Request) {
+				got, _ = request.UserFrom(req.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = "10.0.0.1:41234"
+			req.TLS = &tls.ConnectionState{ServerName: "proxy.example.com", Version: tls.VersionTLS12}
+			req = req.WithContext(request.WithUser(req.Context(), &user.DefaultInfo{
+				Name:  "alice",
+				Extra: map[string][]string{"scopes": {"read"}},
+			}))
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got == nil || got.GetName() != "alice" {
+				t.Fatalf("want user alice, got %v", got)
+			}
+			if !reflect.DeepEqual(got.GetExtra(), tt.wantExtra) {
+				t.Errorf("want extra %v, got %v", tt.wantExtra, got.GetExtra())
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/klog/v2"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/authz"
+	"github.com/brancz/kube-rbac-proxy/pkg/proxy"
+)
+
+// WithAuthorizationConstraints reviews authorized requests with the
+// constraints webhook of the config, and passes the constraints of allowed
+// requests to the upstream. It is meant to be applied after authorization.
+func WithAuthorizationConstraints(cfg *authz.Config, handler http.HandlerFunc) http.HandlerFunc {
+	if cfg == nil || cfg.ConstraintsWebhook == nil {
+		return handler
+	}
+
+	webhookCfg := cfg.ConstraintsWebhook
+	webhook, err := authz.NewConstraintsWebhook(webhookCfg)
+	if err != nil {
+		// The configuration is validated on startup, requests fail closed
+		// for anything that slipped through.
+		klog.ErrorS(err, "Invalid constraints webhook configuration, failing requests")
+		return func(w http.ResponseWriter, req *http.Request) {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+	}
+
+	getRequestAttributes := proxy.
+		NewKubeRBACProxyAuthorizerAttributesGetter(cfg).
+		GetRequestAttributes
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		u, ok := request.UserFrom(req.Context())
+		if !ok {
+			http.Error(w, "user not in context", http.StatusBadRequest)
+			return
+		}
+
+		var constraints map[string][]string
+		for _, attrs := range getRequestAttributes(u, req) {
+			status, err := webhook.Review(req.Context(), attrs)
+			if err != nil {
+				klog.FromContext(req.Context()).Error(err, "Constraints review error", "verb", attrs.GetVerb(), "resource", attrs.GetResource(), "subresource", attrs.GetSubresource())
+				http.Error(w, "Authorization error (constraints review failed)", http.StatusInternalServerError)
+				return
+			}
+			if !status.Allowed {
+				klog.FromContext(req.Context()).V(2).Info("Forbidden by the constraints webhook", "verb", attrs.GetVerb(), "namespace", attrs.GetNamespace(), "resource", attrs.GetResource(), "path", attrs.GetPath(), "reason", status.Reason)
+				http.Error(w, "Forbidden (user="+u.GetName()+")", http.StatusForbidden)
+				return
+			}
+			constraints = authz.IntersectConstraints(constraints, status.Constraints)
+		}
+
+		for name, header := range webhookCfg.Headers {
+			req.Header.Del(header)
+			if values, ok := constraints[name]; ok {
+				req.Header.Set(header, strings.Join(values, ","))
+			}
+		}
+
+		if len(webhookCfg.QueryParameters) > 0 {
+			query := req.URL.Query()
+			for name, param := range webhookCfg.QueryParameters {
+				allowed, ok := constraints[name]
+				if !ok {
+					continue
+				}
+				requested, ok := query[param]
+				if ok {
+					requested = slices.DeleteFunc(requested, func(v string) bool {
+						return !slices.Contains(allowed, v)
+					})
+				} else {
+					requested = slices.Clone(allowed)
+				}
+				// Without a value the parameter would be dropped, leaving
+				// the request unconstrained.
+				if len(requested) == 0 {
+					klog.FromContext(req.Context()).V(2).Info("Forbidden, no requested value is within the constraint", "constraint", name, "parameter", param)
+					http.Error(w, "Forbidden (user="+u.GetName()+", parameter="+param+")", http.StatusForbidden)
+					return
+				}
+				query[param] = requested
+			}
+			req.URL.RawQuery = query.Encode()
+		}
+
+		handler.ServeHTTP(w, req)
+	}
+}
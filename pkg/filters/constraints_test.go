@@ -0,0 +1,126 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/authz"
+	"github.com/brancz/kube-rbac-proxy/pkg/filters"
+)
+
+func TestWithAuthorizationConstraints(t *testing.T) {
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		review := &authz.ConstraintsReview{}
+		_ = json.NewDecoder(req.Body).Decode(review)
+		review.Status.Allowed = review.Spec.User != "mallory"
+		if review.Spec.User == "alice" {
+			review.Status.Constraints = map[string][]string{"namespaces": {"team-a", "team-b"}}
+		}
+		_ = json.NewEncoder(w).Encode(review)
+	}))
+	defer webhook.Close()
+
+	cfg := &authz.Config{
+		ConstraintsWebhook: &authz.ConstraintsWebhookConfig{
+			URL:             webhook.URL,
+			Headers:         map[string]string{"namespaces": "X-Allowed-Namespaces"},
+			QueryParameters: map[string]string{"namespaces": "namespace"},
+		},
+	}
+
+	var upstream *http.Request
+	handler := filters.WithAuthorizationConstraints(cfg, func(w http.ResponseWriter, req *http.Request) {
+		upstream = req
+	})
+
+	for _, tt := range []struct {
+		name       string
+		user       string
+		target     string
+		wantStatus int
+		wantHeader string
+		wantQuery  string
+	}{
+		{
+			name:       "constrained",
+			user:       "alice",
+			target:     "/api/pods",
+			wantStatus: http.StatusOK,
+			wantHeader: "team-a,team-b",
+			wantQuery:  "namespace=team-a&namespace=team-b",
+		},
+		{
+			name:       "narrowed query",
+			user:       "alice",
+			target:     "/api/pods?namespace=team-b&namespace=team-c",
+			wantStatus: http.StatusOK,
+			wantHeader: "team-a,team-b",
+			wantQuery:  "namespace=team-b",
+		},
+		{
+			name:       "query outside the constraint",
+			user:       "alice",
+			target:     "/api/pods?namespace=team-c",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "unconstrained",
+			user:       "bob",
+			target:     "/api/pods?namespace=team-c",
+			wantStatus: http.StatusOK,
+			wantQuery:  "namespace=team-c",
+		},
+		{
+			name:       "denied",
+			user:       "mallory",
+			target:     "/api/pods",
+			wantStatus: http.StatusForbidden,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream = nil
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			// Clients can't set the constraint headers themselves.
+			req.Header.Set("X-Allowed-Namespaces", "kube-system")
+			req = req.WithContext(request.WithUser(req.Context(), &user.DefaultInfo{Name: tt.user}))
+
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("want status %d, got %d", tt.wantStatus, rec.Code)
+			}
+			if tt.wantStatus != http.StatusOK {
+				if upstream != nil {
+					t.Error("want the request not passed on")
+				}
+				return
+			}
+			if got := upstream.Header.Get("X-Allowed-Namespaces"); got != tt.wantHeader {
+				t.Errorf("want header %q, got %q", tt.wantHeader, got)
+			}
+			if got := upstream.URL.RawQuery; got != tt.wantQuery {
+				t.Errorf("want query %q, got %q", tt.wantQuery, got)
+			}
+		})
+	}
+}
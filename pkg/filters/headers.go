@@ -0,0 +1,113 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+
+	"golang.org/x/net/http/httpguts"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/proxy"
+)
+
+// ResponseHeaderConfig sets headers on the responses to requests, whose path
+// matches one of the patterns. The patterns use the syntax of --allow-paths.
+type ResponseHeaderConfig struct {
+	Paths   []string          `json:"paths,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// ValidateResponseHeaders checks the path patterns and the header names and
+// values.
+func ValidateResponseHeaders(configs []ResponseHeaderConfig) error {
+	for i, config := range configs {
+		if len(config.Paths) == 0 {
+			return fmt.Errorf("responseHeaders[%d]: at least one path is required", i)
+		}
+		for _, pattern := range config.Paths {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("responseHeaders[%d]: invalid path %q: %w", i, pattern, err)
+			}
+		}
+		for name, value := range config.Headers {
+			if !httpguts.ValidHeaderFieldName(name) {
+				return fmt.Errorf("responseHeaders[%d]: invalid header name %q", i, name)
+			}
+			if !httpguts.ValidHeaderFieldValue(value) {
+				return fmt.Errorf("responseHeaders[%d]: invalid value of header %q", i, name)
+			}
+		}
+	}
+	return nil
+}
+
+// WithResponseHeaders sets the headers of all matching configs on the
+// response. They replace the headers of the same name set by the upstream,
+// such that e.g. a dashboard can't weaken a Cache-Control: no-store.
+func WithResponseHeaders(configs []ResponseHeaderConfig, handler http.HandlerFunc) http.HandlerFunc {
+	if len(configs) == 0 {
+		return handler
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		headers := http.Header{}
+		for _, config := range configs {
+			if _, found := proxy.MatchPath(config.Paths, req.URL.Path); found {
+				for name, value := range config.Headers {
+					headers.Set(name, value)
+				}
+			}
+		}
+
+		if len(headers) == 0 {
+			handler.ServeHTTP(w, req)
+			return
+		}
+
+		handler.ServeHTTP(&headerResponseWriter{ResponseWriter: w, headers: headers}, req)
+	}
+}
+
+// headerResponseWriter sets the headers right before they are written.
+type headerResponseWriter struct {
+	http.ResponseWriter
+	headers     http.Header
+	wroteHeader bool
+}
+
+func (w *headerResponseWriter) WriteHeader(code int) {
+	if !w.wroteHeader && code >= http.StatusOK {
+		w.wroteHeader = true
+		for name, values := range w.headers {
+			w.ResponseWriter.Header()[name] = values
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *headerResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap allows http.ResponseController to flush streamed responses.
+func (w *headerResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
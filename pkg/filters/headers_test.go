@@ -0,0 +1,95 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/filters"
+)
+
+func TestResponseHeaders(t *testing.T) {
+	configs := []filters.ResponseHeaderConfig{
+		{
+			Paths: []string{"/dashboard", "/dashboard/*"},
+			Headers: map[string]string{
+				"Strict-Transport-Security": "max-age=31536000",
+				"Cache-Control":             "no-store",
+			},
+		},
+		{
+			Paths:   []string{"/*"},
+			Headers: map[string]string{"X-Content-Type-Options": "nosniff"},
+		},
+	}
+	if err := filters.ValidateResponseHeaders(configs); err != nil {
+		t.Fatal(err)
+	}
+
+	upstream := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=3600")
+		_, _ = w.Write([]byte("ok"))
+	}
+	handler := filters.WithResponseHeaders(configs, upstream)
+
+	for _, tt := range []struct {
+		path string
+		want map[string]string
+	}{
+		{
+			path: "/dashboard/index.html",
+			want: map[string]string{
+				"Strict-Transport-Security": "max-age=31536000",
+				"Cache-Control":             "no-store",
+				"X-Content-Type-Options":    "",
+			},
+		},
+		{
+			path: "/metrics",
+			want: map[string]string{
+				"Strict-Transport-Security": "",
+				"Cache-Control":             "max-age=3600",
+				"X-Content-Type-Options":    "nosniff",
+			},
+		},
+	} {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			for name, want := range tt.want {
+				if got := rec.Header().Values(name); (want == "" && len(got) != 0) || (want != "" && (len(got) != 1 || got[0] != want)) {
+					t.Errorf("want %s %q, got %q", name, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateResponseHeaders(t *testing.T) {
+	for _, config := range []filters.ResponseHeaderConfig{
+		{Headers: map[string]string{"Cache-Control": "no-store"}},
+		{Paths: []string{"[]a]"}},
+		{Paths: []string{"/*"}, Headers: map[string]string{"Cache Control": "no-store"}},
+		{Paths: []string{"/*"}, Headers: map[string]string{"Cache-Control": "no-store\r\nX-Injected: 1"}},
+	} {
+		if err := filters.ValidateResponseHeaders([]filters.ResponseHeaderConfig{config}); err == nil {
+			t.Errorf("want error for %+v", config)
+		}
+	}
+}
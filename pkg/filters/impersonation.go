@@ -0,0 +1,172 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apiserver/pkg/authentication/serviceaccount"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/klog/v2"
+)
+
+// WithImpersonation replaces the authenticated user with the one asserted by
+// the Impersonate-User, Impersonate-Group, Impersonate-Uid and
+// Impersonate-Extra-* headers, e.g. of a front proxy. Like the API server,
+// the authenticated user must be allowed to impersonate each of the
+// asserted attributes.
+func WithImpersonation(enabled bool, authz authorizer.Authorizer, handler http.HandlerFunc) http.HandlerFunc {
+	if !enabled {
+		return handler
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		impersonated, checks, err := impersonatedUser(req.Header)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if impersonated == nil {
+			handler.ServeHTTP(w, req)
+			return
+		}
+
+		u, ok := request.UserFrom(req.Context())
+		if !ok {
+			http.Error(w, "user not in context", http.StatusBadRequest)
+			return
+		}
+
+		for _, attrs := range checks {
+			attrs.User = u
+			decision, reason, err := authz.Authorize(req.Context(), attrs)
+			if err != nil {
+				msg := fmt.Sprintf("Impersonation error (user=%s, resource=%s, name=%s)", u.GetName(), attrs.Resource, attrs.Name)
+				klog.FromContext(req.Context()).Error(err, "Impersonation error", "resource", attrs.Resource, "name", attrs.Name)
+				http.Error(w, msg, http.StatusInternalServerError)
+				return
+			}
+			if decision != authorizer.DecisionAllow {
+				msg := fmt.Sprintf("Forbidden (user=%s, verb=impersonate, resource=%s, name=%s)", u.GetName(), attrs.Resource, attrs.Name)
+				klog.FromContext(req.Context()).V(2).Info("Forbidden", "verb", "impersonate", "resource", attrs.Resource, "name", attrs.Name, "reason", reason)
+				http.Error(w, msg, http.StatusForbidden)
+				return
+			}
+		}
+
+		// The upstream sees the impersonated user only.
+		for name := range req.Header {
+			if isImpersonationHeader(name) {
+				req.Header.Del(name)
+			}
+		}
+
+		logger := klog.FromContext(req.Context()).WithValues("impersonatedUser", impersonated.GetName())
+		logger.V(4).Info("Impersonating")
+		req = req.WithContext(request.WithUser(klog.NewContext(req.Context(), logger), impersonated))
+		handler.ServeHTTP(w, req)
+	}
+}
+
+// impersonatedUser returns the user asserted by the headers and the
+// attributes the authenticated user must be allowed, or nil if the request
+// doesn't impersonate.
+func impersonatedUser(header http.Header) (*user.DefaultInfo, []authorizer.AttributesRecord, error) {
+	impersonated := &user.DefaultInfo{}
+	var checks []authorizer.AttributesRecord
+	impersonate := func(apiGroup, resource, subresource, namespace, name string) {
+		checks = append(checks, authorizer.AttributesRecord{
+			Verb:            "impersonate",
+			APIGroup:        apiGroup,
+			Resource:        resource,
+			Subresource:     subresource,
+			Namespace:       namespace,
+			Name:            name,
+			ResourceRequest: true,
+		})
+	}
+
+	impersonated.Name = header.Get(authenticationv1.ImpersonateUserHeader)
+	if namespace, name, err := serviceaccount.SplitUsername(impersonated.Name); err == nil {
+		impersonate("", "serviceaccounts", "", namespace, name)
+		impersonated.Groups = serviceaccount.MakeGroupNames(namespace)
+	} else if impersonated.Name != "" {
+		impersonate("", "users", "", "", impersonated.Name)
+	}
+
+	for _, group := range header.Values(authenticationv1.ImpersonateGroupHeader) {
+		impersonate("", "groups", "", "", group)
+		impersonated.Groups = append(impersonated.Groups, group)
+	}
+
+	if uid := header.Get(authenticationv1.ImpersonateUIDHeader); uid != "" {
+		impersonate(authenticationv1.GroupName, "uids", "", "", uid)
+		impersonated.UID = uid
+	}
+
+	for name, values := range header {
+		if !strings.HasPrefix(name, authenticationv1.ImpersonateUserExtraHeaderPrefix) {
+			continue
+		}
+		key, err := url.PathUnescape(strings.ToLower(strings.TrimPrefix(name, authenticationv1.ImpersonateUserExtraHeaderPrefix)))
+		if err != nil {
+			return nil, nil, fmt.Errorf("malformed %s header %q", authenticationv1.ImpersonateUserExtraHeaderPrefix, name)
+		}
+		if impersonated.Extra == nil {
+			impersonated.Extra = map[string][]string{}
+		}
+		for _, value := range values {
+			impersonate(authenticationv1.GroupName, "userextras", key, "", value)
+			impersonated.Extra[key] = append(impersonated.Extra[key], value)
+		}
+	}
+
+	if len(checks) == 0 {
+		return nil, nil, nil
+	}
+	if impersonated.Name == "" {
+		return nil, nil, fmt.Errorf("impersonating groups, uids or extras requires %s", authenticationv1.ImpersonateUserHeader)
+	}
+
+	if impersonated.Name != user.Anonymous {
+		impersonated.Groups = appendIfMissing(impersonated.Groups, user.AllAuthenticated)
+	}
+
+	return impersonated, checks, nil
+}
+
+func isImpersonationHeader(name string) bool {
+	switch name {
+	case authenticationv1.ImpersonateUserHeader, authenticationv1.ImpersonateGroupHeader, authenticationv1.ImpersonateUIDHeader:
+		return true
+	}
+	return strings.HasPrefix(name, authenticationv1.ImpersonateUserExtraHeaderPrefix)
+}
+
+func appendIfMissing(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/request"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/filters"
+)
+
+func TestWithImpersonation(t *testing.T) {
+	// The front proxy may impersonate alice, the group of developers and
+	// the scopes extra.
+	authz := authorizer.AuthorizerFunc(func(_ context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
+		if a.GetUser().GetName() != "front-proxy" || a.GetVerb() != "impersonate" {
+			return authorizer.DecisionNoOpinion, "", nil
+		}
+		switch a.GetResource() + "/" + a.GetSubresource() + "/" + a.GetName() {
+		case "users//alice", "groups//developers", "userextras/scopes/read":
+			return authorizer.DecisionAllow, "", nil
+		}
+		return authorizer.DecisionNoOpinion, "", nil
+	})
+
+	for _, tt := range []struct {
+		name    string
+		headers map[string][]string
+		status  int
+		want    *user.DefaultInfo
+	}{
+		{
+			name:   "should pass through without impersonation",
+			status: http.StatusOK,
+			want:   &user.DefaultInfo{Name: "front-proxy"},
+		},
+		{
+			name: "should impersonate allowed attributes",
+			headers: map[string][]string{
+				"Impersonate-User":         {"alice"},
+				"Impersonate-Group":        {"developers"},
+				"Impersonate-Extra-Scopes": {"read"},
+			},
+			status: http.StatusOK,
+			want: &user.DefaultInfo{
+				Name:   "alice",
+				Groups: []string{"developers", user.AllAuthenticated},
+				Extra:  map[string][]string{"scopes": {"read"}},
+			},
+		},
+		{
+			name:    "should forbid other users",
+			headers: map[string][]string{"Impersonate-User": {"bob"}},
+			status:  http.StatusForbidden,
+		},
+		{
+			name: "should forbid other groups",
+			headers: map[string][]string{
+				"Impersonate-User":  {"alice"},
+				"Impersonate-Group": {"system:masters"},
+			},
+			status: http.StatusForbidden,
+		},
+		{
+			name:    "should forbid uids",
+			headers: map[string][]string{"Impersonate-User": {"alice"}, "Impersonate-Uid": {"1234"}},
+			status:  http.StatusForbidden,
+		},
+		{
+			name:    "should reject groups without user",
+			headers: map[string][]string{"Impersonate-Group": {"developers"}},
+			status:  http.StatusBadRequest,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var got user.Info
+			var leaked bool
+			handler := filters.WithImpersonation(true, authz, func(w http.ResponseWriter, req *http.Request) {
+				got, _ = request.UserFrom(req.Context())
+				leaked = req.Header.Get("Impersonate-User") != ""
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			req = req.WithContext(request.WithUser(req.Context(), &user.DefaultInfo{Name: "front-proxy"}))
+			for name, values := range tt.headers {
+				req.Header[name] = values
+			}
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("want status %d, got %d: %s", tt.status, rec.Code, rec.Body)
+			}
+			if tt.want != nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("want user %+v, got %+v", tt.want, got)
+			}
+			if leaked {
+				t.Error("want impersonation headers to be removed")
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters
+
+import (
+	"html/template"
+	"net/http"
+	"sort"
+
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/klog/v2"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/authz"
+	"github.com/brancz/kube-rbac-proxy/pkg/proxy"
+)
+
+var landingPageTemplate = template.Must(template.New("landing").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>kube-rbac-proxy</title>
+</head>
+<body>
+<h1>kube-rbac-proxy</h1>
+<p>Authenticated as <strong>{{ .User }}</strong>{{ if .UID }} (uid {{ .UID }}){{ end }}.</p>
+{{- if .Groups }}
+<h2>Groups</h2>
+<ul>
+{{- range .Groups }}
+<li>{{ . }}</li>
+{{- end }}
+</ul>
+{{- end }}
+{{- if .Routes }}
+<h2>Routes</h2>
+<table>
+<tr><th>Path</th><th>Access</th></tr>
+{{- range .Routes }}
+<tr><td>{{ if .Allowed }}<a href="{{ .Path }}">{{ .Path }}</a>{{ else }}{{ .Path }}{{ end }}</td><td>{{ if .Allowed }}allowed{{ else }}denied{{ end }}</td></tr>
+{{- end }}
+</table>
+{{- end }}
+</body>
+</html>
+`))
+
+type landingPageRoute struct {
+	Path    string
+	Allowed bool
+}
+
+type landingPageData struct {
+	User   string
+	UID    string
+	Groups []string
+	Routes []landingPageRoute
+}
+
+// LandingPage shows the authenticated user, its groups and which of the
+// routes it may get, such that users of dashboards can debug their access
+// themselves. The routes are authorized like GET requests to the upstream,
+// including the allowed paths and their restrictions to users or groups.
+func LandingPage(authorizer authorizer.Authorizer, cfg *authz.Config, allowPaths []AllowPath, routes []string) http.HandlerFunc {
+	getRequestAttributes := proxy.
+		NewKubeRBACProxyAuthorizerAttributesGetter(cfg).
+		GetRequestAttributes
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		u, ok := request.UserFrom(req.Context())
+		if !ok {
+			http.Error(w, "user not in context", http.StatusBadRequest)
+			return
+		}
+
+		data := landingPageData{
+			User:   u.GetName(),
+			UID:    u.GetUID(),
+			Groups: append([]string(nil), u.GetGroups()...),
+		}
+		sort.Strings(data.Groups)
+
+		for _, route := range routes {
+			routeReq, err := http.NewRequestWithContext(req.Context(), http.MethodGet, route, nil)
+			if err != nil {
+				klog.FromContext(req.Context()).Error(err, "Invalid landing page route", "route", route)
+				continue
+			}
+			allowed := false
+			if allowPathDenial(allowPaths, u, routeReq.URL.Path) == "" {
+				allowed = authorizeAll(req.Context(), authorizer, getRequestAttributes(u, routeReq)).Allowed
+			}
+			data.Routes = append(data.Routes, landingPageRoute{Path: route, Allowed: allowed})
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		if err := landingPageTemplate.Execute(w, data); err != nil {
+			klog.FromContext(req.Context()).Error(err, "Failed to write the landing page")
+		}
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/authz"
+	"github.com/brancz/kube-rbac-proxy/pkg/filters"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+func TestLandingPage(t *testing.T) {
+	// Alice may get the metrics only.
+	allowed := authorizer.AuthorizerFunc(func(ctx context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
+		if a.GetUser().GetName() == "alice" && a.GetVerb() == "get" && a.GetPath() == "/metrics" {
+			return authorizer.DecisionAllow, "allowed", nil
+		}
+		return authorizer.DecisionNoOpinion, "", nil
+	})
+	handler := filters.LandingPage(allowed, &authz.Config{}, nil, []string{"/metrics", "/debug"})
+
+	t.Run("authenticated", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req = req.WithContext(request.WithUser(req.Context(), &user.DefaultInfo{
+			Name:   "alice",
+			Groups: []string{"system:authenticated", "<admins>"},
+		}))
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("want status 200, got %d", rec.Code)
+		}
+		if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/html") {
+			t.Errorf("want an HTML page, got %q", got)
+		}
+		body := rec.Body.String()
+		for _, want := range []string{
+			"<strong>alice</strong>",
+			"<li>system:authenticated</li>",
+			"<li>&lt;admins&gt;</li>",
+			`<a href="/metrics">/metrics</a></td><td>allowed`,
+			"<td>/debug</td><td>denied",
+		} {
+			if !strings.Contains(body, want) {
+				t.Errorf("want page containing %q, got:\n%s", want, body)
+			}
+		}
+	})
+
+	t.Run("restricted path", func(t *testing.T) {
+		allowPaths, err := filters.ParseAllowPaths([]string{"/metrics@user=bob", "/debug"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req = req.WithContext(request.WithUser(req.Context(), &user.DefaultInfo{Name: "alice"}))
+		rec := httptest.NewRecorder()
+		filters.LandingPage(allowed, &authz.Config{}, allowPaths, []string{"/metrics", "/other"})(rec, req)
+
+		body := rec.Body.String()
+		if !strings.Contains(body, "<td>/metrics</td><td>denied") {
+			t.Errorf("want the restricted route denied, got:\n%s", body)
+		}
+		if !strings.Contains(body, "<td>/other</td><td>denied") {
+			t.Errorf("want the route outside of the allowed paths denied, got:\n%s", body)
+		}
+	})
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("want status 400, got %d", rec.Code)
+		}
+	})
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters
+
+import (
+	"net/http"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+)
+
+var rejectedRequests = metrics.NewCounterVec(
+	&metrics.CounterOpts{
+		Namespace:      "kube_rbac_proxy",
+		Name:           "rejected_requests_total",
+		Help:           "Number of requests rejected before authentication by reason.",
+		StabilityLevel: metrics.ALPHA,
+	},
+	[]string{"reason"},
+)
+
+func init() {
+	legacyregistry.MustRegister(rejectedRequests)
+}
+
+// RequestLimits bounds the size of incoming requests. Zero values disable
+// the respective limit.
+type RequestLimits struct {
+	// MaxHeaders is the maximum number of header values.
+	MaxHeaders int
+	// MaxURLLength is the maximum length of the request URI.
+	MaxURLLength int
+}
+
+// WithRequestLimits rejects requests with too many headers with 431 and
+// requests with too long URLs with 414, before any further work happens.
+func WithRequestLimits(limits RequestLimits, handler http.HandlerFunc) http.HandlerFunc {
+	if limits.MaxHeaders <= 0 && limits.MaxURLLength <= 0 {
+		return handler
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		if limits.MaxURLLength > 0 && len(req.RequestURI) > limits.MaxURLLength {
+			rejectedRequests.WithLabelValues("url_too_long").Inc()
+			klog.FromContext(req.Context()).V(2).Info("Rejecting request with a too long URL", "length", len(req.RequestURI), "limit", limits.MaxURLLength)
+			http.Error(w, http.StatusText(http.StatusRequestURITooLong), http.StatusRequestURITooLong)
+			return
+		}
+
+		if limits.MaxHeaders > 0 {
+			count := 0
+			for _, values := range req.Header {
+				count += len(values)
+			}
+			if count > limits.MaxHeaders {
+				rejectedRequests.WithLabelValues("too_many_headers").Inc()
+				klog.FromContext(req.Context()).V(2).Info("Rejecting request with too many headers", "headers", count, "limit", limits.MaxHeaders)
+				http.Error(w, http.StatusText(http.StatusRequestHeaderFieldsTooLarge), http.StatusRequestHeaderFieldsTooLarge)
+				return
+			}
+		}
+
+		handler.ServeHTTP(w, req)
+	}
+}
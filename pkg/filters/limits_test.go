@@ -0,0 +1,77 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/filters"
+)
+
+func TestWithRequestLimits(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		limits  filters.RequestLimits
+		url     string
+		headers int
+		status  int
+	}{
+		{
+			name:    "no limits",
+			url:     "/" + strings.Repeat("a", 4096),
+			headers: 100,
+			status:  http.StatusOK,
+		},
+		{
+			name:    "within limits",
+			limits:  filters.RequestLimits{MaxHeaders: 10, MaxURLLength: 20},
+			url:     "/metrics",
+			headers: 10,
+			status:  http.StatusOK,
+		},
+		{
+			name:    "too many headers",
+			limits:  filters.RequestLimits{MaxHeaders: 10},
+			url:     "/metrics",
+			headers: 11,
+			status:  http.StatusRequestHeaderFieldsTooLarge,
+		},
+		{
+			name:   "url too long",
+			limits: filters.RequestLimits{MaxURLLength: 20},
+			url:    "/metrics?match[]=" + strings.Repeat("a", 20),
+			status: http.StatusRequestURITooLong,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			for i := 0; i < tt.headers; i++ {
+				req.Header.Add(fmt.Sprintf("X-Header-%d", i), "value")
+			}
+
+			rec := httptest.NewRecorder()
+			filters.WithRequestLimits(tt.limits, func(w http.ResponseWriter, r *http.Request) {}).ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("want status %d, got %d", tt.status, rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"k8s.io/klog/v2"
+)
+
+// RequestIDHeader is the header a request ID is taken from, if the client
+// or a front proxy sets it.
+const RequestIDHeader = "X-Request-Id"
+
+// WithRequestLogger adds a logger to the request context, which logs the
+// request ID along with every message about the request. The ID is taken
+// from the X-Request-Id header or generated.
+func WithRequestLogger(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		id := req.Header.Get(RequestIDHeader)
+		if id == "" {
+			id = newRequestID()
+		}
+
+		logger := klog.FromContext(req.Context()).WithValues("requestID", id)
+		req = req.WithContext(klog.NewContext(req.Context(), logger))
+		handler.ServeHTTP(w, req)
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr/funcr"
+	"k8s.io/klog/v2"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/filters"
+)
+
+func TestWithRequestLogger(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		requestID string
+	}{
+		{
+			name:      "request ID from the header",
+			requestID: "abc123",
+		},
+		{
+			name: "generated request ID",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var logged []string
+			logger := funcr.New(func(prefix, args string) {
+				logged = append(logged, args)
+			}, funcr.Options{})
+
+			handler := filters.WithRequestLogger(func(w http.ResponseWriter, req *http.Request) {
+				klog.FromContext(req.Context()).Info("Handling request")
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tt.requestID != "" {
+				req.Header.Set(filters.RequestIDHeader, tt.requestID)
+			}
+			req = req.WithContext(klog.NewContext(req.Context(), logger))
+			handler(httptest.NewRecorder(), req)
+
+			if len(logged) != 1 {
+				t.Fatalf("want 1 message logged, got %d", len(logged))
+			}
+			want := `"requestID"=`
+			if tt.requestID != "" {
+				want += `"` + tt.requestID + `"`
+			}
+			if !strings.Contains(logged[0], want) {
+				t.Errorf("want %s in %s", want, logged[0])
+			}
+			if tt.requestID == "" && strings.Contains(logged[0], `"requestID"=""`) {
+				t.Errorf("want a generated request ID in %s", logged[0])
+			}
+		})
+	}
+}
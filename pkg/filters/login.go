@@ -0,0 +1,59 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/authn"
+)
+
+// WithLoginRedirect sends unauthenticated browsers to the login of the
+// OpenID issuer. Browsers are told apart by GET and HEAD requests accepting
+// HTML without a bearer token or client certificate. Other requests, and
+// browsers with a valid session, are passed on to be authenticated.
+func WithLoginRedirect(login *authn.OIDCLogin, handler http.HandlerFunc) http.HandlerFunc {
+	if login == nil {
+		return handler
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		if !isBrowserWithoutCredentials(req) {
+			handler.ServeHTTP(w, req)
+			return
+		}
+		if _, ok, _ := login.AuthenticateRequest(req); ok {
+			handler.ServeHTTP(w, req)
+			return
+		}
+
+		login.Redirect(w, req)
+	}
+}
+
+func isBrowserWithoutCredentials(req *http.Request) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+	if !strings.Contains(req.Header.Get("Accept"), "text/html") {
+		return false
+	}
+	if req.Header.Get("Authorization") != "" {
+		return false
+	}
+	return req.TLS == nil || len(req.TLS.PeerCertificates) == 0
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/authn"
+	"github.com/brancz/kube-rbac-proxy/pkg/filters"
+)
+
+func TestWithLoginRedirect(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(secretFile, []byte(strings.Repeat("s", 32)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	issuer := httptest.NewServer(http.NotFoundHandler())
+	defer issuer.Close()
+
+	login, err := authn.NewOIDCLogin(&authn.OIDCConfig{
+		IssuerURL:             issuer.URL,
+		ClientID:              "proxy",
+		LoginRedirectURL:      "https://proxy.example.com/oauth2/callback",
+		LoginClientSecretFile: secretFile,
+		LoginCookieSecretFile: secretFile,
+	}, authenticator.RequestFunc(func(*http.Request) (*authenticator.Response, bool, error) {
+		return nil, false, nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name         string
+		method       string
+		header       http.Header
+		clientCert   bool
+		wantRedirect bool
+	}{
+		{
+			name:         "browser",
+			method:       http.MethodGet,
+			header:       http.Header{"Accept": {"text/html,application/xhtml+xml"}},
+			wantRedirect: true,
+		},
+		{
+			name:   "api client",
+			method: http.MethodGet,
+			header: http.Header{"Accept": {"application/json"}},
+		},
+		{
+			name:   "browser with token",
+			method: http.MethodGet,
+			header: http.Header{"Accept": {"text/html"}, "Authorization": {"Bearer token"}},
+		},
+		{
+			name:       "browser with client certificate",
+			method:     http.MethodGet,
+			header:     http.Header{"Accept": {"text/html"}},
+			clientCert: true,
+		},
+		{
+			name:   "form post",
+			method: http.MethodPost,
+			header: http.Header{"Accept": {"text/html"}},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var passed bool
+			handler := filters.WithLoginRedirect(login, func(w http.ResponseWriter, req *http.Request) {
+				passed = true
+			})
+
+			req := httptest.NewRequest(tt.method, "/dashboard", nil)
+			req.Header = tt.header
+			if tt.clientCert {
+				req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{}}}
+			}
+			handler(httptest.NewRecorder(), req)
+
+			// The issuer can't be discovered, so the redirect fails, but
+			// the request isn't passed on either way.
+			if passed == tt.wantRedirect {
+				t.Errorf("want redirect %v, got passed %v", tt.wantRedirect, passed)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/proxy"
+)
+
+// Maintenance is a runtime toggle answering requests of the maintenance
+// paths with 503 and Retry-After, e.g. during migrations of the upstream.
+type Maintenance struct {
+	paths      []string
+	retryAfter time.Duration
+	enabled    atomic.Bool
+}
+
+// NewMaintenance creates a Maintenance for the path patterns, or for all
+// paths if there are none.
+func NewMaintenance(paths []string, retryAfter time.Duration, enabled bool) *Maintenance {
+	m := &Maintenance{
+		paths:      paths,
+		retryAfter: retryAfter,
+	}
+	m.enabled.Store(enabled)
+
+	return m
+}
+
+// Enabled returns whether maintenance mode is on.
+func (m *Maintenance) Enabled() bool {
+	return m.enabled.Load()
+}
+
+// SetEnabled switches maintenance mode on or off.
+func (m *Maintenance) SetEnabled(enabled bool) {
+	if m.enabled.Swap(enabled) != enabled {
+		klog.Warningf("Switched maintenance mode, enabled=%t", enabled)
+	}
+}
+
+// Toggle switches maintenance mode and returns the new state.
+func (m *Maintenance) Toggle() bool {
+	for {
+		enabled := m.enabled.Load()
+		if m.enabled.CompareAndSwap(enabled, !enabled) {
+			klog.Warningf("Switched maintenance mode, enabled=%t", !enabled)
+			return !enabled
+		}
+	}
+}
+
+// Handler returns the state on GET requests and sets it on PUT requests
+// with the enabled query parameter, e.g. PUT /debug/maintenance?enabled=true.
+func (m *Maintenance) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			enabled, err := strconv.ParseBool(req.URL.Query().Get("enabled"))
+			if err != nil {
+				http.Error(w, "enabled must be true or false", http.StatusBadRequest)
+				return
+			}
+			m.SetEnabled(enabled)
+		default:
+			w.Header().Set("Allow", http.MethodGet+", "+http.MethodPut)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(struct {
+			Enabled bool `json:"enabled"`
+		}{Enabled: m.Enabled()})
+	}
+}
+
+func (m *Maintenance) matches(req *http.Request) bool {
+	if len(m.paths) == 0 {
+		return true
+	}
+
+	_, found := proxy.MatchPath(m.paths, req.URL.Path)
+	return found
+}
+
+// WithMaintenance answers requests of the maintenance paths with 503 while
+// maintenance mode is on, before they are authenticated. A nil Maintenance
+// passes all requests.
+func WithMaintenance(m *Maintenance, handler http.HandlerFunc) http.HandlerFunc {
+	if m == nil {
+		return handler
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		if m.Enabled() && m.matches(req) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(m.retryAfter.Seconds())))
+			http.Error(w, "The upstream is under maintenance", http.StatusServiceUnavailable)
+			return
+		}
+
+		handler.ServeHTTP(w, req)
+	}
+}
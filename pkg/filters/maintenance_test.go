@@ -0,0 +1,79 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/filters"
+)
+
+func TestMaintenance(t *testing.T) {
+	m := filters.NewMaintenance([]string{"/api/*"}, 2*time.Minute, false)
+	upstream := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+	handler := filters.WithMaintenance(m, upstream)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	if rec := get("/api/v1"); rec.Code != http.StatusOK {
+		t.Errorf("want %d before maintenance, got %d", http.StatusOK, rec.Code)
+	}
+
+	rec := httptest.NewRecorder()
+	m.Handler()(rec, httptest.NewRequest(http.MethodPut, "/debug/maintenance?enabled=true", nil))
+	if rec.Code != http.StatusOK || !m.Enabled() {
+		t.Fatalf("want maintenance mode to be enabled, got %d", rec.Code)
+	}
+
+	rec = get("/api/v1")
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("want %d in maintenance, got %d", http.StatusServiceUnavailable, rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "120" {
+		t.Errorf("want Retry-After 120, got %q", got)
+	}
+	if rec := get("/metrics"); rec.Code != http.StatusOK {
+		t.Errorf("want other paths to pass through, got %d", rec.Code)
+	}
+
+	if m.Toggle() {
+		t.Error("want toggle to disable maintenance mode")
+	}
+	if rec := get("/api/v1"); rec.Code != http.StatusOK {
+		t.Errorf("want %d after maintenance, got %d", http.StatusOK, rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	m.Handler()(rec, httptest.NewRequest(http.MethodPut, "/debug/maintenance?enabled=maybe", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("want %d for invalid value, got %d", http.StatusBadRequest, rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	filters.WithMaintenance(nil, upstream)(rec, httptest.NewRequest(http.MethodGet, "/api/v1", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("want %d without maintenance configured, got %d", http.StatusOK, rec.Code)
+	}
+}
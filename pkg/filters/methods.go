@@ -0,0 +1,69 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/audit"
+)
+
+// MethodDenial configures the methods rejected before a request is
+// authenticated or proxied, e.g. CONNECT and TRACE, which would otherwise be
+// authorized with the "*" verb.
+type MethodDenial struct {
+	// Methods are the denied methods.
+	Methods sets.Set[string]
+	// Status is the status code the denied requests are answered with.
+	Status int
+	// Exporter, if set, receives a deny decision for each denied request.
+	Exporter *audit.Exporter
+}
+
+// WithMethodDenial answers requests of the denied methods with the
+// configured status. A nil MethodDenial passes all requests.
+func WithMethodDenial(cfg *MethodDenial, handler http.HandlerFunc) http.HandlerFunc {
+	if cfg == nil || cfg.Methods.Len() == 0 {
+		return handler
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		if !cfg.Methods.Has(req.Method) {
+			handler.ServeHTTP(w, req)
+			return
+		}
+
+		reason := fmt.Sprintf("method %s is denied", req.Method)
+		klog.FromContext(req.Context()).V(2).Info("Denied request", "method", req.Method, "path", req.URL.Path, "host", req.Host)
+		if cfg.Exporter != nil {
+			ev := &audit.Event{
+				Time:       time.Now(),
+				Decision:   audit.DecisionDeny,
+				Reason:     reason,
+				Attributes: audit.Attributes{Path: req.URL.Path},
+			}
+			ev.Connection, _ = audit.ConnectionFrom(req.Context())
+			cfg.Exporter.Export(ev)
+		}
+
+		http.Error(w, http.StatusText(cfg.Status), cfg.Status)
+	}
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/audit"
+	"github.com/brancz/kube-rbac-proxy/pkg/filters"
+)
+
+type chanSink chan *audit.Event
+
+func (s chanSink) Write(_ context.Context, ev *audit.Event) error {
+	s <- ev
+	return nil
+}
+
+func TestMethodDenial(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sink := make(chanSink, 1)
+	exporter := audit.NewExporter(sink, 1)
+	go exporter.Run(ctx)
+
+	cfg := &filters.MethodDenial{
+		Methods:  sets.New(http.MethodConnect, http.MethodTrace),
+		Status:   http.StatusMethodNotAllowed,
+		Exporter: exporter,
+	}
+	upstream := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+	handler := filters.WithConnection(filters.WithMethodDenial(cfg, upstream))
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/api/v1", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("want %d for GET, got %d", http.StatusOK, rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodTrace, "/api/v1", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("want %d for TRACE, got %d", http.StatusMethodNotAllowed, rec.Code)
+	}
+
+	select {
+	case ev := <-sink:
+		if ev.Decision != audit.DecisionDeny || ev.Attributes.Path != "/api/v1" || ev.Connection == nil {
+			t.Errorf("want a deny decision of /api/v1 with the connection, got %+v", ev)
+		}
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("want the denied request exported")
+	}
+
+	rec = httptest.NewRecorder()
+	filters.WithMethodDenial(nil, upstream)(rec, httptest.NewRequest(http.MethodConnect, "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("want %d without denied methods, got %d", http.StatusOK, rec.Code)
+	}
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"golang.org/x/net/http/httpguts"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+)
+
+// DefaultMethodOverrideHeader is the header carrying the overriding method,
+// if none is configured.
+const DefaultMethodOverrideHeader = "X-HTTP-Method-Override"
+
+// MethodOverrideConfig allows clients that can only send GET and POST, e.g.
+// browsers behind restrictive gateways, to send other methods as POST with
+// the method in a header.
+type MethodOverrideConfig struct {
+	// Header carries the method, X-HTTP-Method-Override by default.
+	Header string `json:"header,omitempty"`
+	// Methods are the methods POST requests may be overridden with.
+	Methods []string `json:"methods"`
+}
+
+// ValidateMethodOverride checks the header and the methods.
+func ValidateMethodOverride(config *MethodOverrideConfig) error {
+	if config == nil {
+		return nil
+	}
+	if config.Header != "" && !httpguts.ValidHeaderFieldName(config.Header) {
+		return fmt.Errorf("methodOverride: invalid header %q", config.Header)
+	}
+	if len(config.Methods) == 0 {
+		return errors.New("methodOverride: at least one method is required")
+	}
+	for _, method := range config.Methods {
+		if !httpguts.ValidHeaderFieldName(method) {
+			return fmt.Errorf("methodOverride: invalid method %q", method)
+		}
+		// CONNECT requests have no path to be proxied to.
+		if method == http.MethodConnect {
+			return fmt.Errorf("methodOverride: method %s can't be overridden with", method)
+		}
+	}
+	return nil
+}
+
+// WithMethodOverride replaces the method of POST requests with the one of the
+// header, if it is allowed, such that the verb is derived from it and the
+// upstream receives it. It must be applied before the method is evaluated,
+// i.e. before the method denial and authorization. The header is removed, as
+// an upstream honouring it itself would bypass the authorization of the
+// method. Requests of other methods with the header, and POST requests
+// overridden with methods that aren't allowed, are rejected. The config must
+// be validated upfront.
+func WithMethodOverride(config *MethodOverrideConfig, handler http.HandlerFunc) http.HandlerFunc {
+	if config == nil {
+		return handler
+	}
+
+	header := config.Header
+	if header == "" {
+		header = DefaultMethodOverrideHeader
+	}
+	methods := sets.New(config.Methods...)
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		values, ok := req.Header[http.CanonicalHeaderKey(header)]
+		if !ok {
+			handler.ServeHTTP(w, req)
+			return
+		}
+
+		// Only POST is overridden, an overridden GET would allow cross site
+		// requests from e.g. image tags to modify.
+		if req.Method != http.MethodPost || len(values) != 1 || !methods.Has(values[0]) {
+			klog.FromContext(req.Context()).V(2).Info("Method override not allowed", "method", req.Method, "override", values, "path", req.URL.Path)
+			http.Error(w, fmt.Sprintf("Bad Request (method override of %s is not allowed)", req.Method), http.StatusBadRequest)
+			return
+		}
+
+		req = req.Clone(req.Context())
+		req.Method = values[0]
+		req.Header.Del(header)
+		handler.ServeHTTP(w, req)
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/filters"
+)
+
+func TestMethodOverride(t *testing.T) {
+	config := &filters.MethodOverrideConfig{Methods: []string{http.MethodPut, http.MethodDelete}}
+	if err := filters.ValidateMethodOverride(config); err != nil {
+		t.Fatal(err)
+	}
+
+	var gotMethod, gotHeader string
+	handler := filters.WithMethodOverride(config, func(w http.ResponseWriter, req *http.Request) {
+		gotMethod = req.Method
+		gotHeader = req.Header.Get(filters.DefaultMethodOverrideHeader)
+	})
+
+	for _, tt := range []struct {
+		name       string
+		method     string
+		override   []string
+		wantStatus int
+		wantMethod string
+	}{
+		{name: "no override", method: http.MethodPost, wantStatus: http.StatusOK, wantMethod: http.MethodPost},
+		{name: "allowed override", method: http.MethodPost, override: []string{http.MethodDelete}, wantStatus: http.StatusOK, wantMethod: http.MethodDelete},
+		{name: "method not allowed", method: http.MethodPost, override: []string{http.MethodPatch}, wantStatus: http.StatusBadRequest},
+		{name: "methods are case sensitive", method: http.MethodPost, override: []string{"delete"}, wantStatus: http.StatusBadRequest},
+		{name: "several overrides", method: http.MethodPost, override: []string{http.MethodPut, http.MethodDelete}, wantStatus: http.StatusBadRequest},
+		{name: "GET can't be overridden", method: http.MethodGet, override: []string{http.MethodDelete}, wantStatus: http.StatusBadRequest},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			gotMethod, gotHeader = "", ""
+			req := httptest.NewRequest(tt.method, "/api/items/1", nil)
+			for _, o := range tt.override {
+				req.Header.Add(filters.DefaultMethodOverrideHeader, o)
+			}
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("want status %d, got %d", tt.wantStatus, rec.Code)
+			}
+			if gotMethod != tt.wantMethod {
+				t.Errorf("want method %q, got %q", tt.wantMethod, gotMethod)
+			}
+			if gotHeader != "" {
+				t.Error("want the override header removed")
+			}
+		})
+	}
+}
+
+func TestValidateMethodOverride(t *testing.T) {
+	for _, config := range []*filters.MethodOverrideConfig{
+		{},
+		{Header: "X Method", Methods: []string{http.MethodPut}},
+		{Methods: []string{"PU T"}},
+		{Methods: []string{http.MethodConnect}},
+	} {
+		if err := filters.ValidateMethodOverride(config); err == nil {
+			t.Errorf("want error for %+v", config)
+		}
+	}
+}
@@ -16,10 +16,84 @@ limitations under the License.
 package filters
 
 import (
+	"fmt"
 	"net/http"
 	"path"
+	"slices"
+	"strings"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/klog/v2"
 )
 
+// AllowPath is an entry of the allowed paths, a path pattern which is
+// optionally restricted to a user or the members of a group, in the form
+// "/debug/*@user=alice" or "/debug/*@group=system:masters".
+type AllowPath struct {
+	Pattern string
+	User    string
+	Group   string
+}
+
+// ParseAllowPaths parses the entries of the allowed paths. Only a trailing
+// "@user=<name>" or "@group=<name>" restricts the entry, other "@" are part
+// of the pattern, e.g. of "/api/v1/users/@me".
+func ParseAllowPaths(entries []string) ([]AllowPath, error) {
+	allowPaths := make([]AllowPath, 0, len(entries))
+	for _, entry := range entries {
+		allowPath := AllowPath{Pattern: entry}
+		if i := strings.LastIndex(entry, "@"); i >= 0 {
+			kind, name, _ := strings.Cut(entry[i+1:], "=")
+			switch kind {
+			case "user":
+				allowPath = AllowPath{Pattern: entry[:i], User: name}
+			case "group":
+				allowPath = AllowPath{Pattern: entry[:i], Group: name}
+			}
+			if (kind == "user" || kind == "group") && name == "" {
+				return nil, fmt.Errorf("allow path %q must be restricted by user=<name> or group=<name>", entry)
+			}
+		}
+		if _, err := path.Match(allowPath.Pattern, ""); err != nil {
+			return nil, fmt.Errorf("failed to verify allow path: %s", entry)
+		}
+		allowPaths = append(allowPaths, allowPath)
+	}
+	return allowPaths, nil
+}
+
+// String returns the entry the AllowPath was parsed from.
+func (p AllowPath) String() string {
+	switch {
+	case p.User != "":
+		return p.Pattern + "@user=" + p.User
+	case p.Group != "":
+		return p.Pattern + "@group=" + p.Group
+	default:
+		return p.Pattern
+	}
+}
+
+// AllowPathPatterns returns the patterns of the allowed paths.
+func AllowPathPatterns(allowPaths []AllowPath) []string {
+	var patterns []string
+	for _, p := range allowPaths {
+		patterns = append(patterns, p.Pattern)
+	}
+	return patterns
+}
+
+func (p AllowPath) matchesUser(u user.Info) bool {
+	if p.User != "" {
+		return u.GetName() == p.User
+	}
+	if p.Group != "" {
+		return slices.Contains(u.GetGroups(), p.Group)
+	}
+	return true
+}
+
 func WithAllowPaths(allowPaths []string, handler http.HandlerFunc) http.HandlerFunc {
 	if len(allowPaths) == 0 {
 		return handler
@@ -46,3 +120,69 @@ func WithAllowPaths(allowPaths []string, handler http.HandlerFunc) http.HandlerF
 		http.NotFound(w, req)
 	}
 }
+
+// WithAllowPathIdentities forbids authenticated requests of paths, whose
+// matching allowed paths are all restricted to other users or groups.
+// Requests of paths without a matching allowed path are left to
+// WithAllowPaths.
+func WithAllowPathIdentities(allowPaths []AllowPath, handler http.HandlerFunc) http.HandlerFunc {
+	if !restrictsIdentities(allowPaths) {
+		return handler
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		u, ok := request.UserFrom(req.Context())
+		if !ok || !allowPathIdentity(allowPaths, u, req.URL.Path) {
+			klog.FromContext(req.Context()).V(2).Info("Path is restricted to other users or groups", "path", req.URL.Path)
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		handler.ServeHTTP(w, req)
+	}
+}
+
+func restrictsIdentities(allowPaths []AllowPath) bool {
+	return slices.ContainsFunc(allowPaths, func(p AllowPath) bool { return p.User != "" || p.Group != "" })
+}
+
+// allowPathIdentity tells whether the user may request the path, as one of
+// the allowed paths matching it is not restricted to other users or groups,
+// or none matches.
+func allowPathIdentity(allowPaths []AllowPath, u user.Info, requestPath string) bool {
+	matched := false
+	for _, p := range allowPaths {
+		// The patterns are validated on startup.
+		if found, _ := path.Match(p.Pattern, requestPath); !found {
+			continue
+		}
+		matched = true
+		if p.matchesUser(u) {
+			return true
+		}
+	}
+	return !matched
+}
+
+// allowPathDenial returns why the allowed paths deny the user the path, as
+// WithAllowPaths and WithAllowPathIdentities would, or "" if they don't.
+func allowPathDenial(allowPaths []AllowPath, u user.Info, requestPath string) string {
+	if len(allowPaths) == 0 {
+		return ""
+	}
+
+	matched := false
+	for _, p := range allowPaths {
+		// The patterns are validated on startup.
+		if found, _ := path.Match(p.Pattern, requestPath); !found {
+			continue
+		}
+		matched = true
+		if p.matchesUser(u) {
+			return ""
+		}
+	}
+	if matched {
+		return pathRestrictedReason
+	}
+	return pathNotAllowedReason
+}
@@ -20,6 +20,9 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+
 	"github.com/brancz/kube-rbac-proxy/pkg/filters"
 )
 
@@ -71,3 +74,108 @@ func TestAllowPath(t *testing.T) {
 		})
 	}
 }
+
+func TestAllowPathIdentities(t *testing.T) {
+	allowPaths, err := filters.ParseAllowPaths([]string{
+		"/metrics",
+		"/debug/*@group=system:masters",
+		"/debug/*@user=alice",
+		"/admin@user=bob",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name   string
+		path   string
+		user   user.Info
+		status int
+	}{
+		{
+			name:   "unrestricted path",
+			path:   "/metrics",
+			user:   &user.DefaultInfo{Name: "carol"},
+			status: http.StatusOK,
+		},
+		{
+			name:   "member of the group",
+			path:   "/debug/pprof",
+			user:   &user.DefaultInfo{Name: "carol", Groups: []string{"system:masters"}},
+			status: http.StatusOK,
+		},
+		{
+			name:   "user of another entry",
+			path:   "/debug/pprof",
+			user:   &user.DefaultInfo{Name: "alice"},
+			status: http.StatusOK,
+		},
+		{
+			name:   "other user",
+			path:   "/debug/pprof",
+			user:   &user.DefaultInfo{Name: "carol", Groups: []string{"system:authenticated"}},
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "user of another path",
+			path:   "/debug/pprof",
+			user:   &user.DefaultInfo{Name: "bob"},
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "unauthenticated",
+			path:   "/admin",
+			status: http.StatusForbidden,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.user != nil {
+				req = req.WithContext(request.WithUser(req.Context(), tt.user))
+			}
+			rec := httptest.NewRecorder()
+			filters.WithAllowPathIdentities(allowPaths, emptyHandler).ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("want: %d\nhave: %d\n", tt.status, rec.Code)
+			}
+		})
+	}
+}
+
+func TestParseAllowPaths(t *testing.T) {
+	for _, entry := range []string{"/debug/*@user=", "/debug/*@group", "[]a]@user=alice"} {
+		if _, err := filters.ParseAllowPaths([]string{entry}); err == nil {
+			t.Errorf("want an error for %q", entry)
+		}
+	}
+
+	entries := []string{"/metrics", "/debug/*@group=system:masters", "/admin@user=bob"}
+	allowPaths, err := filters.ParseAllowPaths(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range allowPaths {
+		if p.String() != entries[i] {
+			t.Errorf("want %q, got %q", entries[i], p.String())
+		}
+	}
+
+	// Only a trailing user or group restricts the entry.
+	for entry, want := range map[string]filters.AllowPath{
+		"/api/v1/users/@me":        {Pattern: "/api/v1/users/@me"},
+		"/static/*@2x.png":         {Pattern: "/static/*@2x.png"},
+		"/debug/*@team=a":          {Pattern: "/debug/*@team=a"},
+		"/users/@me@user=alice":    {Pattern: "/users/@me", User: "alice"},
+		"/static/*@2x.png@group=a": {Pattern: "/static/*@2x.png", Group: "a"},
+	} {
+		allowPaths, err := filters.ParseAllowPaths([]string{entry})
+		if err != nil {
+			t.Errorf("unexpected error for %q: %v", entry, err)
+			continue
+		}
+		if allowPaths[0] != want {
+			t.Errorf("want %+v for %q, got %+v", want, entry, allowPaths[0])
+		}
+	}
+}
@@ -0,0 +1,250 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/proxy"
+)
+
+// defaultQueueTimeout is the time queued requests wait for a seat, unless
+// configured otherwise.
+const defaultQueueTimeout = 5 * time.Second
+
+var (
+	priorityLevelInFlight = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Namespace:      "kube_rbac_proxy",
+			Subsystem:      "priority_level",
+			Name:           "requests_in_flight",
+			Help:           "Number of requests executing by priority level.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"priority_level"},
+	)
+	priorityLevelQueued = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Namespace:      "kube_rbac_proxy",
+			Subsystem:      "priority_level",
+			Name:           "queued_requests",
+			Help:           "Number of requests waiting for a seat by priority level.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"priority_level"},
+	)
+	priorityLevelRejected = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      "kube_rbac_proxy",
+			Subsystem:      "priority_level",
+			Name:           "rejected_requests_total",
+			Help:           "Number of requests rejected with 429 by priority level and reason, queue_full or timeout.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"priority_level", "reason"},
+	)
+)
+
+func init() {
+	legacyregistry.MustRegister(priorityLevelInFlight, priorityLevelQueued, priorityLevelRejected)
+}
+
+// PriorityLevelConfig classifies requests into a priority level with its
+// own concurrency limit, such that the requests of one level can't starve
+// the others. A request is of the level, if its user is one of the users or
+// a member of one of the groups, and its path matches one of the paths.
+// Levels without users and groups match all users, levels without paths
+// all paths. The paths use the syntax of --allow-paths.
+type PriorityLevelConfig struct {
+	Name   string   `json:"name"`
+	Users  []string `json:"users,omitempty"`
+	Groups []string `json:"groups,omitempty"`
+	Paths  []string `json:"paths,omitempty"`
+	// Concurrency is the number of requests of the level executing at the
+	// same time.
+	Concurrency int `json:"concurrency"`
+	// QueueLength is the number of requests waiting for a seat, further
+	// requests are rejected with 429.
+	QueueLength int `json:"queueLength,omitempty"`
+	// QueueTimeout is the time requests wait for a seat before they are
+	// rejected with 429, defaults to 5s.
+	QueueTimeout metav1.Duration `json:"queueTimeout,omitempty"`
+}
+
+// ValidatePriorityLevels checks the names, the limits and the path patterns.
+func ValidatePriorityLevels(configs []PriorityLevelConfig) error {
+	names := sets.New[string]()
+	for i, config := range configs {
+		if config.Name == "" {
+			return fmt.Errorf("priorityLevels[%d]: name is required", i)
+		}
+		if names.Has(config.Name) {
+			return fmt.Errorf("priorityLevels[%d]: duplicate name %q", i, config.Name)
+		}
+		names.Insert(config.Name)
+		if config.Concurrency <= 0 {
+			return fmt.Errorf("priorityLevels[%d]: concurrency must be positive", i)
+		}
+		if config.QueueLength < 0 || config.QueueTimeout.Duration < 0 {
+			return fmt.Errorf("priorityLevels[%d]: queueLength and queueTimeout must not be negative", i)
+		}
+		for _, pattern := range config.Paths {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("priorityLevels[%d]: invalid path %q: %w", i, pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+type priorityLevel struct {
+	config       PriorityLevelConfig
+	queueTimeout time.Duration
+	seats        chan struct{}
+	queued       atomic.Int32
+}
+
+// PriorityLevels limits the concurrency of the requests of each level.
+// Requests of no level aren't limited.
+type PriorityLevels struct {
+	levels []*priorityLevel
+}
+
+// NewPriorityLevels creates PriorityLevels of validated configs.
+func NewPriorityLevels(configs []PriorityLevelConfig) *PriorityLevels {
+	p := &PriorityLevels{}
+	for _, config := range configs {
+		queueTimeout := config.QueueTimeout.Duration
+		if queueTimeout == 0 {
+			queueTimeout = defaultQueueTimeout
+		}
+		p.levels = append(p.levels, &priorityLevel{
+			config:       config,
+			queueTimeout: queueTimeout,
+			seats:        make(chan struct{}, config.Concurrency),
+		})
+	}
+	return p
+}
+
+// classify returns the first level of the request, or nil.
+func (p *PriorityLevels) classify(req *http.Request) *priorityLevel {
+	u, ok := request.UserFrom(req.Context())
+	for _, l := range p.levels {
+		if len(l.config.Users) > 0 || len(l.config.Groups) > 0 {
+			if !ok {
+				continue
+			}
+			if !slices.Contains(l.config.Users, u.GetName()) && !slices.ContainsFunc(u.GetGroups(), func(g string) bool {
+				return slices.Contains(l.config.Groups, g)
+			}) {
+				continue
+			}
+		}
+		if len(l.config.Paths) > 0 {
+			if _, found := proxy.MatchPath(l.config.Paths, req.URL.Path); !found {
+				continue
+			}
+		}
+		return l
+	}
+	return nil
+}
+
+var (
+	errQueueFull    = errors.New("queue_full")
+	errQueueTimeout = errors.New("timeout")
+)
+
+// acquire takes a seat of the level, waiting in its queue if all seats are
+// taken.
+func (l *priorityLevel) acquire(req *http.Request) error {
+	select {
+	case l.seats <- struct{}{}:
+		return nil
+	default:
+	}
+
+	if int(l.queued.Add(1)) > l.config.QueueLength {
+		l.queued.Add(-1)
+		return errQueueFull
+	}
+	priorityLevelQueued.WithLabelValues(l.config.Name).Inc()
+	defer func() {
+		l.queued.Add(-1)
+		priorityLevelQueued.WithLabelValues(l.config.Name).Dec()
+	}()
+
+	timer := time.NewTimer(l.queueTimeout)
+	defer timer.Stop()
+	select {
+	case l.seats <- struct{}{}:
+		return nil
+	case <-timer.C:
+		return errQueueTimeout
+	case <-req.Context().Done():
+		return req.Context().Err()
+	}
+}
+
+// WithPriorityLevels classifies authenticated requests into their priority
+// level and executes them within its concurrency limit. Requests exceeding
+// the limit and the queue of their level are rejected with 429. A nil
+// PriorityLevels passes all requests.
+func WithPriorityLevels(p *PriorityLevels, handler http.HandlerFunc) http.HandlerFunc {
+	if p == nil || len(p.levels) == 0 {
+		return handler
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		l := p.classify(req)
+		if l == nil {
+			handler.ServeHTTP(w, req)
+			return
+		}
+
+		if err := l.acquire(req); err != nil {
+			if req.Context().Err() != nil {
+				return
+			}
+			priorityLevelRejected.WithLabelValues(l.config.Name, err.Error()).Inc()
+			klog.FromContext(req.Context()).V(2).Info("Rejecting request of an exhausted priority level", "priorityLevel", l.config.Name, "reason", err.Error())
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+			return
+		}
+		priorityLevelInFlight.WithLabelValues(l.config.Name).Inc()
+		defer func() {
+			<-l.seats
+			priorityLevelInFlight.WithLabelValues(l.config.Name).Dec()
+		}()
+
+		handler.ServeHTTP(w, req)
+	}
+}
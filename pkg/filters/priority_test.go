@@ -0,0 +1,114 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/filters"
+)
+
+func TestWithPriorityLevels(t *testing.T) {
+	configs := []filters.PriorityLevelConfig{
+		{
+			Name:        "system",
+			Groups:      []string{"system:monitoring"},
+			Paths:       []string{"/metrics"},
+			Concurrency: 1,
+		},
+		{
+			Name:         "best-effort",
+			Concurrency:  1,
+			QueueLength:  1,
+			QueueTimeout: metav1.Duration{Duration: 50 * time.Millisecond},
+		},
+	}
+	if err := filters.ValidatePriorityLevels(configs); err != nil {
+		t.Fatal(err)
+	}
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 10)
+	handler := filters.WithPriorityLevels(filters.NewPriorityLevels(configs), func(w http.ResponseWriter, req *http.Request) {
+		started <- struct{}{}
+		if req.URL.Query().Has("block") {
+			<-release
+		}
+	})
+
+	serve := func(u *user.DefaultInfo, target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req = req.WithContext(request.WithUser(req.Context(), u))
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec
+	}
+	tenant := &user.DefaultInfo{Name: "tenant"}
+	scraper := &user.DefaultInfo{Name: "prometheus", Groups: []string{"system:monitoring"}}
+
+	// A tenant request takes the only best-effort seat, a second one waits
+	// in the queue until it times out and a third one finds the queue full.
+	blocked := make(chan int)
+	go func() { blocked <- serve(tenant, "/api?block").Code }()
+	<-started
+	queued := make(chan int)
+	go func() { queued <- serve(tenant, "/api").Code }()
+	time.Sleep(10 * time.Millisecond)
+	if rec := serve(tenant, "/api"); rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("want %d with Retry-After for a full queue, got %d", http.StatusTooManyRequests, rec.Code)
+	}
+	if code := <-queued; code != http.StatusTooManyRequests {
+		t.Errorf("want %d after the queue timeout, got %d", http.StatusTooManyRequests, code)
+	}
+
+	// The scraper isn't starved by tenants.
+	if rec := serve(scraper, "/metrics"); rec.Code != http.StatusOK {
+		t.Errorf("want %d for the scraper, got %d", http.StatusOK, rec.Code)
+	}
+	<-started
+
+	// Queued requests get the seat once it is released.
+	go func() { queued <- serve(tenant, "/api").Code }()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	if code := <-blocked; code != http.StatusOK {
+		t.Errorf("want %d for the blocking request, got %d", http.StatusOK, code)
+	}
+	if code := <-queued; code != http.StatusOK {
+		t.Errorf("want %d for the queued request, got %d", http.StatusOK, code)
+	}
+}
+
+func TestValidatePriorityLevels(t *testing.T) {
+	for name, configs := range map[string][]filters.PriorityLevelConfig{
+		"no name":        {{Concurrency: 1}},
+		"duplicate name": {{Name: "a", Concurrency: 1}, {Name: "a", Concurrency: 1}},
+		"no concurrency": {{Name: "a"}},
+		"negative queue": {{Name: "a", Concurrency: 1, QueueLength: -1}},
+		"invalid path":   {{Name: "a", Concurrency: 1, Paths: []string{"[/metrics"}}},
+	} {
+		if err := filters.ValidatePriorityLevels(configs); err == nil {
+			t.Errorf("%s: want an error", name)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/proxy"
+)
+
+// ProbeConfig identifies the kubelet probes answered by the proxy itself.
+type ProbeConfig struct {
+	// Paths are patterns of the probed paths.
+	Paths []string
+	// UserAgentPrefix is the prefix of the User-Agent of the probes, the
+	// kubelet sends kube-probe/<version>.
+	UserAgentPrefix string
+}
+
+// WithProbes answers GET and HEAD requests of probes with 200 and "ok",
+// without authentication and without contacting the upstream. The answer
+// reveals nothing but the liveness of the proxy, so a spoofed User-Agent
+// gains nothing.
+func WithProbes(config ProbeConfig, handler http.HandlerFunc) http.HandlerFunc {
+	if len(config.Paths) == 0 {
+		return handler
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		if isProbe(config, req) {
+			_, _ = w.Write([]byte("ok"))
+			return
+		}
+
+		handler.ServeHTTP(w, req)
+	}
+}
+
+func isProbe(config ProbeConfig, req *http.Request) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+	if !strings.HasPrefix(req.UserAgent(), config.UserAgentPrefix) {
+		return false
+	}
+
+	_, found := proxy.MatchPath(config.Paths, req.URL.Path)
+	return found
+}
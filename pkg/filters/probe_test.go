@@ -0,0 +1,91 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/filters"
+)
+
+func TestProbes(t *testing.T) {
+	config := filters.ProbeConfig{
+		Paths:           []string{"/healthz", "/ready*"},
+		UserAgentPrefix: "kube-probe/",
+	}
+	upstream := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}
+	handler := filters.WithProbes(config, upstream)
+
+	for _, tt := range []struct {
+		name      string
+		method    string
+		path      string
+		userAgent string
+		status    int
+	}{
+		{
+			name:      "should answer kubelet probe",
+			method:    http.MethodGet,
+			path:      "/healthz",
+			userAgent: "kube-probe/1.30",
+			status:    http.StatusOK,
+		},
+		{
+			name:      "should answer kubelet probe matching pattern",
+			method:    http.MethodHead,
+			path:      "/readyz",
+			userAgent: "kube-probe/1.30",
+			status:    http.StatusOK,
+		},
+		{
+			name:      "should pass through other user agents",
+			method:    http.MethodGet,
+			path:      "/healthz",
+			userAgent: "curl/8.0",
+			status:    http.StatusUnauthorized,
+		},
+		{
+			name:      "should pass through other paths",
+			method:    http.MethodGet,
+			path:      "/metrics",
+			userAgent: "kube-probe/1.30",
+			status:    http.StatusUnauthorized,
+		},
+		{
+			name:      "should pass through other methods",
+			method:    http.MethodPost,
+			path:      "/healthz",
+			userAgent: "kube-probe/1.30",
+			status:    http.StatusUnauthorized,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("User-Agent", tt.userAgent)
+
+			handler(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("want status %d, got %d", tt.status, rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"regexp"
+	"sort"
+
+	"k8s.io/klog/v2"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/proxy"
+)
+
+// QueryParameterConfig restricts the query parameters of authorized
+// requests, whose path matches one of the patterns. The patterns use the
+// syntax of --allow-paths.
+type QueryParameterConfig struct {
+	Paths      []string             `json:"paths,omitempty"`
+	Parameters []QueryParameterRule `json:"parameters,omitempty"`
+	// DenyUnlisted rejects requests with parameters that aren't listed in
+	// the Parameters.
+	DenyUnlisted bool `json:"denyUnlisted,omitempty"`
+}
+
+// QueryParameterRule restricts the values of a query parameter. The
+// expressions must match the whole value.
+type QueryParameterRule struct {
+	Name string `json:"name"`
+	// Deny rejects requests with the parameter, regardless of its values.
+	Deny bool `json:"deny,omitempty"`
+	// AllowedValues is a regular expression all values of the parameter
+	// must match.
+	AllowedValues string `json:"allowedValues,omitempty"`
+	// DeniedValues is a regular expression none of the values of the
+	// parameter may match.
+	DeniedValues string `json:"deniedValues,omitempty"`
+}
+
+// ValidateQueryParameters checks the path patterns and the expressions.
+func ValidateQueryParameters(configs []QueryParameterConfig) error {
+	for i, config := range configs {
+		if len(config.Paths) == 0 {
+			return fmt.Errorf("queryParameters[%d]: at least one path is required", i)
+		}
+		for _, pattern := range config.Paths {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("queryParameters[%d]: invalid path %q: %w", i, pattern, err)
+			}
+		}
+		for _, rule := range config.Parameters {
+			if rule.Name == "" {
+				return fmt.Errorf("queryParameters[%d]: parameter name is required", i)
+			}
+			if _, err := compileValues(rule.AllowedValues); err != nil {
+				return fmt.Errorf("queryParameters[%d]: invalid allowedValues of %q: %w", i, rule.Name, err)
+			}
+			if _, err := compileValues(rule.DeniedValues); err != nil {
+				return fmt.Errorf("queryParameters[%d]: invalid deniedValues of %q: %w", i, rule.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// WithQueryParameters rejects requests whose query parameters violate one of
+// the matching configs. The configs must be validated upfront. It is meant
+// to be applied after authorization, such that tenants can't use exotic
+// query options on shared upstreams, e.g. arbitrary match[] selectors.
+func WithQueryParameters(configs []QueryParameterConfig, handler http.HandlerFunc) http.HandlerFunc {
+	if len(configs) == 0 {
+		return handler
+	}
+
+	policies := make([]queryParameterPolicy, 0, len(configs))
+	for _, config := range configs {
+		policy := queryParameterPolicy{
+			paths:        config.Paths,
+			denyUnlisted: config.DenyUnlisted,
+			rules:        map[string]queryParameterMatcher{},
+		}
+		for _, rule := range config.Parameters {
+			allowed, _ := compileValues(rule.AllowedValues)
+			denied, _ := compileValues(rule.DeniedValues)
+			policy.rules[rule.Name] = queryParameterMatcher{deny: rule.Deny, allowed: allowed, denied: denied}
+		}
+		policies = append(policies, policy)
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query()
+		for _, policy := range policies {
+			if _, found := proxy.MatchPath(policy.paths, req.URL.Path); !found {
+				continue
+			}
+			if name, ok := policy.allows(query); !ok {
+				klog.FromContext(req.Context()).V(2).Info("Query parameter not allowed", "path", req.URL.Path, "parameter", name)
+				http.Error(w, fmt.Sprintf("Forbidden (query parameter %q is not allowed)", name), http.StatusForbidden)
+				return
+			}
+		}
+
+		handler.ServeHTTP(w, req)
+	}
+}
+
+type queryParameterPolicy struct {
+	paths        []string
+	denyUnlisted bool
+	rules        map[string]queryParameterMatcher
+}
+
+type queryParameterMatcher struct {
+	deny    bool
+	allowed *regexp.Regexp
+	denied  *regexp.Regexp
+}
+
+// allows returns whether the query complies with the policy, and otherwise
+// the name of the first parameter violating it.
+func (p queryParameterPolicy) allows(query map[string][]string) (string, bool) {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		values := query[name]
+		rule, ok := p.rules[name]
+		if !ok {
+			if p.denyUnlisted {
+				return name, false
+			}
+			continue
+		}
+		if rule.deny {
+			return name, false
+		}
+		for _, value := range values {
+			if rule.allowed != nil && !rule.allowed.MatchString(value) {
+				return name, false
+			}
+			if rule.denied != nil && rule.denied.MatchString(value) {
+				return name, false
+			}
+		}
+	}
+	return "", true
+}
+
+// compileValues compiles an expression matching whole values, or returns nil
+// for an empty expression.
+func compileValues(expr string) (*regexp.Regexp, error) {
+	if expr == "" {
+		return nil, nil
+	}
+	return regexp.Compile("^(?:" + expr + ")$")
+}
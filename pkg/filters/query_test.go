@@ -0,0 +1,136 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/filters"
+)
+
+func TestQueryParameters(t *testing.T) {
+	configs := []filters.QueryParameterConfig{
+		{
+			Paths: []string{"/federate"},
+			Parameters: []filters.QueryParameterRule{
+				{Name: "match[]", AllowedValues: `\{namespace="[a-z0-9-]+"\}`},
+				{Name: "debug", DeniedValues: "true|1"},
+				{Name: "trace", Deny: true},
+			},
+		},
+		{
+			Paths:        []string{"/strict"},
+			Parameters:   []filters.QueryParameterRule{{Name: "page"}},
+			DenyUnlisted: true,
+		},
+	}
+	if err := filters.ValidateQueryParameters(configs); err != nil {
+		t.Fatal(err)
+	}
+
+	upstream := func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}
+	handler := filters.WithQueryParameters(configs, upstream)
+
+	for _, tt := range []struct {
+		name string
+		uri  string
+		want int
+	}{
+		{
+			name: "allowed match",
+			uri:  `/federate?match[]={namespace="foo"}&debug=false`,
+			want: http.StatusOK,
+		},
+		{
+			name: "one of several matches not allowed",
+			uri:  `/federate?match[]={namespace="foo"}&match[]={__name__=~".%2B"}`,
+			want: http.StatusForbidden,
+		},
+		{
+			name: "allowed values match whole values",
+			uri:  `/federate?match[]={namespace="foo"}x`,
+			want: http.StatusForbidden,
+		},
+		{
+			name: "denied value",
+			uri:  "/federate?debug=true",
+			want: http.StatusForbidden,
+		},
+		{
+			name: "denied parameter",
+			uri:  "/federate?trace",
+			want: http.StatusForbidden,
+		},
+		{
+			name: "unlisted parameter",
+			uri:  "/federate?foo=bar",
+			want: http.StatusOK,
+		},
+		{
+			name: "unlisted parameter denied",
+			uri:  "/strict?page=1&foo=bar",
+			want: http.StatusForbidden,
+		},
+		{
+			name: "listed parameter",
+			uri:  "/strict?page=1",
+			want: http.StatusOK,
+		},
+		{
+			name: "other path",
+			uri:  "/metrics?debug=true",
+			want: http.StatusOK,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler(rec, httptest.NewRequest(http.MethodGet, tt.uri, nil))
+			if rec.Code != tt.want {
+				t.Errorf("want status %d, got %d: %s", tt.want, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestValidateQueryParameters(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		configs []filters.QueryParameterConfig
+	}{
+		{
+			name:    "no paths",
+			configs: []filters.QueryParameterConfig{{Parameters: []filters.QueryParameterRule{{Name: "debug", Deny: true}}}},
+		},
+		{
+			name:    "no name",
+			configs: []filters.QueryParameterConfig{{Paths: []string{"/"}, Parameters: []filters.QueryParameterRule{{Deny: true}}}},
+		},
+		{
+			name:    "invalid expression",
+			configs: []filters.QueryParameterConfig{{Paths: []string{"/"}, Parameters: []filters.QueryParameterRule{{Name: "match[]", AllowedValues: "("}}}},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := filters.ValidateQueryParameters(tt.configs); err == nil {
+				t.Error("want error, got none")
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+)
+
+// Readiness tells whether the proxy is ready to serve. It isn't ready until
+// all warm-up checks, e.g. of the authenticator and the authorizer, passed
+// once, and it isn't ready anymore once it drains on shutdown, while it
+// keeps serving. Service meshes and endpoints controllers thereby route
+// traffic to the proxy only while it can serve it.
+type Readiness struct {
+	mu      sync.Mutex
+	pending map[string]*warmUpCheck
+
+	draining atomic.Bool
+}
+
+type warmUpCheck struct {
+	check func(context.Context) error
+	err   error
+}
+
+// NewReadiness creates a Readiness without warm-up checks.
+func NewReadiness() *Readiness {
+	return &Readiness{pending: map[string]*warmUpCheck{}}
+}
+
+// AddWarmUpCheck adds a check, which must pass once before the proxy is
+// ready.
+func (r *Readiness) AddWarmUpCheck(name string, check func(context.Context) error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.pending[name] = &warmUpCheck{
+		check: check,
+		err:   errors.New("not run yet"),
+	}
+}
+
+// Run runs the pending warm-up checks in the interval, until all passed or
+// the context is done.
+func (r *Readiness) Run(ctx context.Context, interval time.Duration) {
+	_ = wait.PollUntilContextCancel(ctx, interval, true, func(ctx context.Context) (bool, error) {
+		return r.runChecks(ctx), nil
+	})
+}
+
+// runChecks runs the pending checks and returns whether all passed.
+func (r *Readiness) runChecks(ctx context.Context) bool {
+	r.mu.Lock()
+	pending := make(map[string]*warmUpCheck, len(r.pending))
+	for name, c := range r.pending {
+		pending[name] = c
+	}
+	r.mu.Unlock()
+
+	for name, c := range pending {
+		err := c.check(ctx)
+
+		r.mu.Lock()
+		if err == nil {
+			delete(r.pending, name)
+			klog.InfoS("Warm-up check passed", "check", name)
+		} else {
+			c.err = err
+			klog.V(2).InfoS("Warm-up check failed", "check", name, "err", err)
+		}
+		r.mu.Unlock()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.pending) == 0
+}
+
+// Drain makes the proxy unready for good.
+func (r *Readiness) Drain() {
+	r.draining.Store(true)
+}
+
+// Ready returns nil if the proxy is ready, the reason otherwise.
+func (r *Readiness) Ready() error {
+	if r.draining.Load() {
+		return errors.New("draining for shutdown")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.pending) == 0 {
+		return nil
+	}
+
+	// The first pending check by name is reported.
+	names := make([]string, 0, len(r.pending))
+	for name := range r.pending {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return fmt.Errorf("warm-up check %s hasn't passed: %w", names[0], r.pending[names[0]].err)
+}
+
+// Handler answers with 200 and "ok" while the proxy is ready, with 503 and
+// the reason otherwise.
+func (r *Readiness) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if err := r.Ready(); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/filters"
+)
+
+func TestReadiness(t *testing.T) {
+	r := filters.NewReadiness()
+
+	discovered := false
+	r.AddWarmUpCheck("oidc-discovery", func(context.Context) error {
+		if !discovered {
+			return errors.New("issuer unreachable")
+		}
+		return nil
+	})
+	r.AddWarmUpCheck("subjectaccessreview", func(context.Context) error { return nil })
+
+	probe := func() int {
+		rec := httptest.NewRecorder()
+		r.Handler()(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		return rec.Code
+	}
+
+	if got := probe(); got != http.StatusServiceUnavailable {
+		t.Errorf("want %d before the warm-up, got %d", http.StatusServiceUnavailable, got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r.Run(ctx, 0)
+	if err := r.Ready(); err == nil || err.Error() != "warm-up check oidc-discovery hasn't passed: issuer unreachable" {
+		t.Errorf("want the failed warm-up check, got %v", err)
+	}
+
+	discovered = true
+	r.Run(context.Background(), 0)
+	if got := probe(); got != http.StatusOK {
+		t.Errorf("want %d after the warm-up, got %d", http.StatusOK, got)
+	}
+
+	r.Drain()
+	if got := probe(); got != http.StatusServiceUnavailable {
+		t.Errorf("want %d while draining, got %d", http.StatusServiceUnavailable, got)
+	}
+}
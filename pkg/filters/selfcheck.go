@@ -0,0 +1,147 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/klog/v2"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/audit"
+	"github.com/brancz/kube-rbac-proxy/pkg/authz"
+	"github.com/brancz/kube-rbac-proxy/pkg/proxy"
+)
+
+const (
+	// pathRestrictedReason is the reason of requests forbidden by the
+	// identities of the allowed paths.
+	pathRestrictedReason = "the path is restricted to other users or groups"
+	// pathNotAllowedReason is the reason of requests of paths outside of the
+	// allowed paths.
+	pathNotAllowedReason = "the path is not allowed"
+)
+
+// SelfCheckResult is the response of the self-check endpoint.
+type SelfCheckResult struct {
+	// Allowed tells whether the hypothetical request would be authorized.
+	Allowed bool `json:"allowed"`
+	// Reason tells why the request would be forbidden without authorizing
+	// it, e.g. as its path is restricted to other users or groups.
+	Reason string `json:"reason,omitempty"`
+	// Decisions holds the decision for each of the attributes of the
+	// request, all of which must be allowed.
+	Decisions []*audit.Event `json:"decisions"`
+}
+
+// SelfCheck answers whether the authenticated user would be authorized to
+// send a hypothetical request, given by the method and uri query parameters
+// and by header parameters in the form "Name: value". The attributes are
+// generated as for requests to the upstream, so users can debug their own
+// permissions. Paths outside of the allowed paths, or restricted to other
+// users or groups by them, are forbidden.
+func SelfCheck(authorizer authorizer.Authorizer, cfg *authz.Config, allowPaths []AllowPath) http.HandlerFunc {
+	getRequestAttributes := proxy.
+		NewKubeRBACProxyAuthorizerAttributesGetter(cfg).
+		GetRequestAttributes
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		u, ok := request.UserFrom(req.Context())
+		if !ok {
+			http.Error(w, "user not in context", http.StatusBadRequest)
+			return
+		}
+
+		hypothetical, err := hypotheticalRequest(req)
+		if err != nil {
+			http.Error(w, "Bad Request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		result := SelfCheckResult{Reason: allowPathDenial(allowPaths, u, hypothetical.URL.Path)}
+		if result.Reason == "" {
+			allAttrs := getRequestAttributes(u, hypothetical)
+			if len(allAttrs) == 0 {
+				http.Error(w, "Bad Request. The request or configuration is malformed.", http.StatusBadRequest)
+				return
+			}
+			result = authorizeAll(req.Context(), authorizer, allAttrs)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			klog.FromContext(req.Context()).Error(err, "Failed to write self-check result")
+		}
+	}
+}
+
+// authorizeAll authorizes the attributes of a request, all of which must be
+// allowed.
+func authorizeAll(ctx context.Context, authorizer authorizer.Authorizer, allAttrs []authorizer.Attributes) SelfCheckResult {
+	result := SelfCheckResult{Allowed: len(allAttrs) > 0}
+	for _, attrs := range allAttrs {
+		decision, reason, err := authorizer.Authorize(ctx, attrs)
+		ev := audit.NewEvent(attrs, decision, reason, err)
+		result.Decisions = append(result.Decisions, ev)
+		if ev.Decision != audit.DecisionAllow {
+			result.Allowed = false
+		}
+	}
+	return result
+}
+
+// hypotheticalRequest builds the request described by the query parameters.
+func hypotheticalRequest(req *http.Request) (*http.Request, error) {
+	query := req.URL.Query()
+
+	uri := query.Get("uri")
+	if uri == "" {
+		return nil, errors.New("the uri parameter is missing")
+	}
+	u, err := url.ParseRequestURI(uri)
+	if err != nil {
+		return nil, errors.New("the uri parameter is malformed")
+	}
+
+	method := http.MethodGet
+	if m := query.Get("method"); m != "" {
+		method = strings.ToUpper(m)
+	}
+
+	hypothetical := req.Clone(req.Context())
+	hypothetical.Method = method
+	hypothetical.URL = u
+	hypothetical.Header = http.Header{}
+	for _, header := range query["header"] {
+		name, value, ok := strings.Cut(header, ":")
+		if !ok {
+			return nil, errors.New("the header parameters must have the form \"Name: value\"")
+		}
+		hypothetical.Header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+
+	return hypothetical, nil
+}
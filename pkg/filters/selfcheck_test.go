@@ -0,0 +1,137 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/authz"
+	"github.com/brancz/kube-rbac-proxy/pkg/filters"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+func TestSelfCheck(t *testing.T) {
+	// Alice may get the metrics of namespace foo.
+	allowed := authorizer.AuthorizerFunc(func(ctx context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
+		if a.GetUser().GetName() == "alice" && a.GetVerb() == "get" && a.GetNamespace() == "foo" {
+			return authorizer.DecisionAllow, "allowed", nil
+		}
+		return authorizer.DecisionNoOpinion, "", nil
+	})
+	cfg := &authz.Config{
+		ResourceAttributes: authz.ResourceAttributesList{{
+			NamespaceFrom: &authz.NamespaceSource{HTTPHeader: "X-Namespace"},
+			Resource:      "namespaces",
+			Subresource:   "metrics",
+		}},
+	}
+
+	for _, tt := range []struct {
+		name        string
+		query       url.Values
+		status      int
+		wantAllowed bool
+	}{
+		{
+			name:        "allowed",
+			query:       url.Values{"uri": {"/metrics"}, "header": {"X-Namespace: foo"}},
+			status:      http.StatusOK,
+			wantAllowed: true,
+		},
+		{
+			name:   "denied method",
+			query:  url.Values{"method": {"post"}, "uri": {"/metrics"}, "header": {"X-Namespace: foo"}},
+			status: http.StatusOK,
+		},
+		{
+			name:   "denied namespace",
+			query:  url.Values{"uri": {"/metrics"}, "header": {"X-Namespace: bar"}},
+			status: http.StatusOK,
+		},
+		{
+			name:   "without uri",
+			query:  url.Values{"header": {"X-Namespace: foo"}},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "malformed header",
+			query:  url.Values{"uri": {"/metrics"}, "header": {"X-Namespace"}},
+			status: http.StatusBadRequest,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/apis/authorization/self?"+tt.query.Encode(), nil)
+			req = req.WithContext(request.WithUser(req.Context(), &user.DefaultInfo{Name: "alice"}))
+
+			rec := httptest.NewRecorder()
+			filters.SelfCheck(allowed, cfg, nil)(rec, req)
+			if rec.Code != tt.status {
+				t.Fatalf("want status %d, got %d: %s", tt.status, rec.Code, rec.Body.String())
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var result filters.SelfCheckResult
+			if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+				t.Fatal(err)
+			}
+			if result.Allowed != tt.wantAllowed {
+				t.Errorf("want allowed %v, got %v", tt.wantAllowed, result.Allowed)
+			}
+			if len(result.Decisions) != 1 || result.Decisions[0].User != "alice" {
+				t.Errorf("want a single decision for alice, got %+v", result.Decisions)
+			}
+		})
+	}
+}
+
+func TestSelfCheckAllowPathIdentities(t *testing.T) {
+	allowPaths, err := filters.ParseAllowPaths([]string{"/debug/*@user=bob", "/metrics"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	allowAll := authorizer.AuthorizerFunc(func(context.Context, authorizer.Attributes) (authorizer.Decision, string, error) {
+		return authorizer.DecisionAllow, "", nil
+	})
+
+	for uri, want := range map[string]filters.SelfCheckResult{
+		"/debug/pprof": {Reason: "the path is restricted to other users or groups"},
+		"/metrics":     {Allowed: true},
+		"/other":       {Reason: "the path is not allowed"},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/apis/authorization/self?"+url.Values{"uri": {uri}}.Encode(), nil)
+		req = req.WithContext(request.WithUser(req.Context(), &user.DefaultInfo{Name: "alice"}))
+		rec := httptest.NewRecorder()
+		filters.SelfCheck(allowAll, &authz.Config{}, allowPaths)(rec, req)
+
+		var result filters.SelfCheckResult
+		if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		if result.Allowed != want.Allowed || result.Reason != want.Reason {
+			t.Errorf("want %+v for %s, got %+v", want, uri, result)
+		}
+	}
+}
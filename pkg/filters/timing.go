@@ -0,0 +1,157 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+// Phases of requests measured by WithPhaseTiming.
+const (
+	PhaseAuthentication = "authn"
+	PhaseAuthorization  = "authz"
+	PhaseUpstream       = "upstream"
+)
+
+var requestPhaseDuration = metrics.NewHistogramVec(
+	&metrics.HistogramOpts{
+		Namespace:      "kube_rbac_proxy",
+		Name:           "request_phase_duration_seconds",
+		Help:           "Time spent per phase of requests, one of authn, authz or upstream. The upstream phase lasts until the response was sent.",
+		Buckets:        metrics.ExponentialBuckets(0.001, 2, 15),
+		StabilityLevel: metrics.ALPHA,
+	},
+	[]string{"phase"},
+)
+
+func init() {
+	legacyregistry.MustRegister(requestPhaseDuration)
+}
+
+type phaseTimerKey struct{}
+
+// phaseTimer records the durations of the phases of a request, which follow
+// each other.
+type phaseTimer struct {
+	mu        sync.Mutex // protects the fields below
+	phase     string
+	start     time.Time
+	durations []phaseDuration
+}
+
+type phaseDuration struct {
+	phase    string
+	duration time.Duration
+}
+
+// next ends the current phase, if any, and starts the given one.
+func (t *phaseTimer) next(phase string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if t.phase != "" {
+		t.durations = append(t.durations, phaseDuration{phase: t.phase, duration: now.Sub(t.start)})
+	}
+	t.phase, t.start = phase, now
+}
+
+// snapshot returns the durations of the ended phases and of the current one
+// so far.
+func (t *phaseTimer) snapshot() []phaseDuration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	durations := append([]phaseDuration(nil), t.durations...)
+	if t.phase != "" {
+		durations = append(durations, phaseDuration{phase: t.phase, duration: time.Since(t.start)})
+	}
+	return durations
+}
+
+// WithPhaseTiming measures the time spent per phase of requests, which are
+// started by WithPhase, and observes it in a histogram. With serverTiming,
+// the durations are sent in the Server-Timing header of the response, the
+// one of the upstream phase until the upstream responded.
+func WithPhaseTiming(serverTiming bool, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		timer := &phaseTimer{}
+		req = req.WithContext(context.WithValue(req.Context(), phaseTimerKey{}, timer))
+
+		if serverTiming {
+			w = &serverTimingResponseWriter{ResponseWriter: w, timer: timer}
+		}
+		handler.ServeHTTP(w, req)
+
+		timer.next("")
+		for _, d := range timer.snapshot() {
+			requestPhaseDuration.WithLabelValues(d.phase).Observe(d.duration.Seconds())
+		}
+	}
+}
+
+// WithPhase starts the phase of the request, ending the previous one.
+func WithPhase(phase string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if timer, ok := req.Context().Value(phaseTimerKey{}).(*phaseTimer); ok {
+			timer.next(phase)
+		}
+		handler.ServeHTTP(w, req)
+	}
+}
+
+// serverTimingResponseWriter sets the Server-Timing header right before the
+// header is written.
+type serverTimingResponseWriter struct {
+	http.ResponseWriter
+	timer       *phaseTimer
+	wroteHeader bool
+}
+
+func (w *serverTimingResponseWriter) WriteHeader(code int) {
+	if !w.wroteHeader && code >= http.StatusOK {
+		w.wroteHeader = true
+
+		timings := []string{}
+		for _, d := range w.timer.snapshot() {
+			timings = append(timings, fmt.Sprintf("%s;dur=%.3f", d.phase, float64(d.duration.Microseconds())/1000))
+		}
+		if len(timings) > 0 {
+			w.ResponseWriter.Header().Add("Server-Timing", strings.Join(timings, ", "))
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *serverTimingResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap allows http.ResponseController to flush streamed responses.
+func (w *serverTimingResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
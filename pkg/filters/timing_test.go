@@ -0,0 +1,73 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/filters"
+)
+
+func TestWithPhaseTiming(t *testing.T) {
+	upstream := func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}
+	deny := func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+	}
+	chain := func(authz http.HandlerFunc, serverTiming bool) http.HandlerFunc {
+		handler := filters.WithPhase(filters.PhaseAuthorization, authz)
+		handler = filters.WithPhase(filters.PhaseAuthentication, handler)
+		return filters.WithPhaseTiming(serverTiming, handler)
+	}
+	allow := func(w http.ResponseWriter, r *http.Request) {
+		filters.WithPhase(filters.PhaseUpstream, upstream)(w, r)
+	}
+
+	for _, tt := range []struct {
+		name    string
+		handler http.HandlerFunc
+		want    string
+	}{
+		{
+			name:    "allowed",
+			handler: chain(allow, true),
+			want:    `^authn;dur=[0-9.]+, authz;dur=[0-9.]+, upstream;dur=[0-9.]+$`,
+		},
+		{
+			name:    "denied",
+			handler: chain(deny, true),
+			want:    `^authn;dur=[0-9.]+, authz;dur=[0-9.]+$`,
+		},
+		{
+			name:    "disabled",
+			handler: chain(allow, false),
+			want:    `^$`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tt.handler(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+			if got := rec.Header().Get("Server-Timing"); !regexp.MustCompile(tt.want).MatchString(got) {
+				t.Errorf("want Server-Timing matching %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
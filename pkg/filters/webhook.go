@@ -0,0 +1,131 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters
+
+import (
+	"encoding/json"
+	"net/http"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/klog/v2"
+)
+
+// maxReviewSize is the maximum size of the SubjectAccessReviews of the
+// webhook.
+const maxReviewSize = 1 << 20
+
+// AuthorizationWebhook serves the SubjectAccessReview webhook API of the
+// authorization.k8s.io/v1 group, answering the reviews with the decision of
+// the review authorizer, such that other components can delegate to the
+// policy of the proxy. Callers must be allowed by the authorizer to create
+// the path of the webhook as a non-resource URL.
+func AuthorizationWebhook(a, reviewAuthorizer authorizer.Authorizer) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		caller, ok := request.UserFrom(req.Context())
+		if !ok {
+			http.Error(w, "user not in context", http.StatusBadRequest)
+			return
+		}
+
+		decision, reason, err := a.Authorize(req.Context(), authorizer.AttributesRecord{
+			User: caller,
+			Verb: "create",
+			Path: req.URL.Path,
+		})
+		if err != nil || decision != authorizer.DecisionAllow {
+			klog.FromContext(req.Context()).V(2).Info("Forbidden to review access", "reason", reason, "err", err)
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		req.Body = http.MaxBytesReader(w, req.Body, maxReviewSize)
+		review := &authorizationv1.SubjectAccessReview{}
+		if err := json.NewDecoder(req.Body).Decode(review); err != nil {
+			http.Error(w, "Bad Request. The SubjectAccessReview is malformed.", http.StatusBadRequest)
+			return
+		}
+		if review.APIVersion != authorizationv1.SchemeGroupVersion.String() || review.Kind != "SubjectAccessReview" {
+			http.Error(w, "Bad Request. Only SubjectAccessReviews of authorization.k8s.io/v1 are supported.", http.StatusBadRequest)
+			return
+		}
+
+		attrs, ok := reviewAttributes(&review.Spec)
+		if !ok {
+			http.Error(w, "Bad Request. The SubjectAccessReview has neither resource nor non-resource attributes.", http.StatusBadRequest)
+			return
+		}
+
+		decision, reason, err = reviewAuthorizer.Authorize(req.Context(), attrs)
+		review.Status = authorizationv1.SubjectAccessReviewStatus{
+			Allowed: decision == authorizer.DecisionAllow,
+			Denied:  decision == authorizer.DecisionDeny,
+			Reason:  reason,
+		}
+		if err != nil {
+			review.Status.EvaluationError = err.Error()
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(review); err != nil {
+			klog.FromContext(req.Context()).Error(err, "Failed to write SubjectAccessReview")
+		}
+	}
+}
+
+// reviewAttributes returns the attributes of the review spec, the selectors
+// of resource attributes are ignored.
+func reviewAttributes(spec *authorizationv1.SubjectAccessReviewSpec) (authorizer.Attributes, bool) {
+	extra := make(map[string][]string, len(spec.Extra))
+	for k, v := range spec.Extra {
+		extra[k] = v
+	}
+	attrs := authorizer.AttributesRecord{
+		User: &user.DefaultInfo{
+			Name:   spec.User,
+			UID:    spec.UID,
+			Groups: spec.Groups,
+			Extra:  extra,
+		},
+	}
+
+	switch {
+	case spec.ResourceAttributes != nil:
+		ra := spec.ResourceAttributes
+		attrs.ResourceRequest = true
+		attrs.Verb = ra.Verb
+		attrs.Namespace = ra.Namespace
+		attrs.APIGroup = ra.Group
+		attrs.APIVersion = ra.Version
+		attrs.Resource = ra.Resource
+		attrs.Subresource = ra.Subresource
+		attrs.Name = ra.Name
+	case spec.NonResourceAttributes != nil:
+		attrs.Verb = spec.NonResourceAttributes.Verb
+		attrs.Path = spec.NonResourceAttributes.Path
+	default:
+		return nil, false
+	}
+
+	return attrs, true
+}
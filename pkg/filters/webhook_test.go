@@ -0,0 +1,173 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filters_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/filters"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+func TestAuthorizationWebhook(t *testing.T) {
+	// The apiserver may review access and carol may do everything, but only
+	// the review authorizer decides the reviews.
+	a := authorizer.AuthorizerFunc(func(ctx context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
+		switch {
+		case a.GetUser().GetName() == "apiserver" && a.GetVerb() == "create" && a.GetPath() == "/review":
+			return authorizer.DecisionAllow, "", nil
+		case a.GetUser().GetName() == "carol":
+			return authorizer.DecisionAllow, "carol may do everything", nil
+		}
+		return authorizer.DecisionNoOpinion, "", nil
+	})
+	// Alice may get pods in namespace foo and bob is denied everything.
+	reviewAuthorizer := authorizer.AuthorizerFunc(func(ctx context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
+		switch {
+		case a.GetUser().GetName() == "alice" && a.IsResourceRequest() && a.GetVerb() == "get" && a.GetResource() == "pods" && a.GetNamespace() == "foo":
+			return authorizer.DecisionAllow, "alice may get pods", nil
+		case a.GetUser().GetName() == "bob":
+			return authorizer.DecisionDeny, "bob is denied", nil
+		}
+		return authorizer.DecisionNoOpinion, "", nil
+	})
+
+	review := func(u string, ra *authorizationv1.ResourceAttributes, nra *authorizationv1.NonResourceAttributes) string {
+		b, err := json.Marshal(authorizationv1.SubjectAccessReview{
+			TypeMeta: metav1.TypeMeta{APIVersion: "authorization.k8s.io/v1", Kind: "SubjectAccessReview"},
+			Spec: authorizationv1.SubjectAccessReviewSpec{
+				User:                  u,
+				ResourceAttributes:    ra,
+				NonResourceAttributes: nra,
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	pods := &authorizationv1.ResourceAttributes{Verb: "get", Namespace: "foo", Resource: "pods"}
+
+	for _, tt := range []struct {
+		name        string
+		caller      string
+		method      string
+		body        string
+		status      int
+		wantAllowed bool
+		wantDenied  bool
+	}{
+		{
+			name:        "allowed",
+			caller:      "apiserver",
+			body:        review("alice", pods, nil),
+			status:      http.StatusOK,
+			wantAllowed: true,
+		},
+		{
+			name:   "no opinion",
+			caller: "apiserver",
+			body:   review("alice", nil, &authorizationv1.NonResourceAttributes{Verb: "get", Path: "/metrics"}),
+			status: http.StatusOK,
+		},
+		{
+			name:       "denied",
+			caller:     "apiserver",
+			body:       review("bob", pods, nil),
+			status:     http.StatusOK,
+			wantDenied: true,
+		},
+		{
+			name:   "not reviewed by the authorizer",
+			caller: "apiserver",
+			body:   review("carol", pods, nil),
+			status: http.StatusOK,
+		},
+		{
+			name:   "caller not allowed",
+			caller: "alice",
+			body:   review("alice", pods, nil),
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "without attributes",
+			caller: "apiserver",
+			body:   review("alice", nil, nil),
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "unsupported version",
+			caller: "apiserver",
+			body:   `{"apiVersion":"authorization.k8s.io/v1beta1","kind":"SubjectAccessReview","spec":{"user":"alice"}}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "unsupported kind",
+			caller: "apiserver",
+			body:   `{"apiVersion":"authorization.k8s.io/v1","kind":"LocalSubjectAccessReview","spec":{"user":"alice"}}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "too large",
+			caller: "apiserver",
+			body:   `{"apiVersion":"authorization.k8s.io/v1","kind":"SubjectAccessReview","spec":{"user":"` + strings.Repeat("a", 1<<20) + `"}}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "wrong method",
+			caller: "apiserver",
+			method: http.MethodGet,
+			status: http.StatusMethodNotAllowed,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			req := httptest.NewRequest(method, "/review", strings.NewReader(tt.body))
+			req = req.WithContext(request.WithUser(req.Context(), &user.DefaultInfo{Name: tt.caller}))
+			rec := httptest.NewRecorder()
+
+			filters.AuthorizationWebhook(a, reviewAuthorizer).ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("want status %d, got %d: %s", tt.status, rec.Code, rec.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var got authorizationv1.SubjectAccessReview
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Status.Allowed != tt.wantAllowed || got.Status.Denied != tt.wantDenied {
+				t.Errorf("want allowed %t and denied %t, got %+v", tt.wantAllowed, tt.wantDenied, got.Status)
+			}
+		})
+	}
+}
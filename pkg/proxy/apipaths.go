@@ -0,0 +1,65 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"net/http"
+
+	"k8s.io/apimachinery/pkg/api/validation/path"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+// apiPathsFactory parses paths like the API server, e.g. of an aggregated
+// API server behind the proxy.
+var apiPathsFactory = &request.RequestInfoFactory{
+	APIPrefixes:          sets.NewString("api", "apis"),
+	GrouplessAPIPrefixes: sets.NewString("api"),
+}
+
+// apiPathsAttributes returns the attributes the API server would authorize
+// the request for, e.g. get of pods/log named web in the namespace default
+// for GET /api/v1/namespaces/default/pods/web/log. The verb follows the API
+// server too, e.g. list, watch or deletecollection. Paths outside of the API
+// are non-resource requests. It returns false for invalid namespaces and
+// names.
+func apiPathsAttributes(u user.Info, r *http.Request) (authorizer.Attributes, bool) {
+	info, err := apiPathsFactory.NewRequestInfo(r)
+	if err != nil {
+		return nil, false
+	}
+	for _, segment := range []string{info.Namespace, info.Name} {
+		if segment != "" && len(path.IsValidPathSegmentName(segment)) > 0 {
+			return nil, false
+		}
+	}
+
+	return authorizer.AttributesRecord{
+		User:            u,
+		Verb:            info.Verb,
+		Namespace:       info.Namespace,
+		APIGroup:        info.APIGroup,
+		APIVersion:      info.APIVersion,
+		Resource:        info.Resource,
+		Subresource:     info.Subresource,
+		Name:            info.Name,
+		ResourceRequest: info.IsResourceRequest,
+		Path:            info.Path,
+	}, true
+}
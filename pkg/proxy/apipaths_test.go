@@ -0,0 +1,109 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/authz"
+)
+
+func TestAPIServerPathsAttributes(t *testing.T) {
+	n := krpAuthorizerAttributesGetter{authzConfig: &authz.Config{APIServerPaths: true}}
+
+	for _, tt := range []struct {
+		method, target string
+		want           []authorizer.Attributes
+	}{
+		{
+			method: "GET",
+			target: "/apis/metrics.k8s.io/v1beta1/namespaces/default/pods/web",
+			want: []authorizer.Attributes{authorizer.AttributesRecord{
+				Verb:            "get",
+				Namespace:       "default",
+				APIGroup:        "metrics.k8s.io",
+				APIVersion:      "v1beta1",
+				Resource:        "pods",
+				Name:            "web",
+				ResourceRequest: true,
+				Path:            "/apis/metrics.k8s.io/v1beta1/namespaces/default/pods/web",
+			}},
+		},
+		{
+			method: "GET",
+			target: "/api/v1/namespaces/default/pods/web/log",
+			want: []authorizer.Attributes{authorizer.AttributesRecord{
+				Verb:            "get",
+				Namespace:       "default",
+				APIVersion:      "v1",
+				Resource:        "pods",
+				Subresource:     "log",
+				Name:            "web",
+				ResourceRequest: true,
+				Path:            "/api/v1/namespaces/default/pods/web/log",
+			}},
+		},
+		{
+			method: "GET",
+			target: "/apis/metrics.k8s.io/v1beta1/nodes?watch=true",
+			want: []authorizer.Attributes{authorizer.AttributesRecord{
+				Verb:            "watch",
+				APIGroup:        "metrics.k8s.io",
+				APIVersion:      "v1beta1",
+				Resource:        "nodes",
+				ResourceRequest: true,
+				Path:            "/apis/metrics.k8s.io/v1beta1/nodes",
+			}},
+		},
+		{
+			method: "DELETE",
+			target: "/apis/example.com/v1/namespaces/default/widgets",
+			want: []authorizer.Attributes{authorizer.AttributesRecord{
+				Verb:            "deletecollection",
+				Namespace:       "default",
+				APIGroup:        "example.com",
+				APIVersion:      "v1",
+				Resource:        "widgets",
+				ResourceRequest: true,
+				Path:            "/apis/example.com/v1/namespaces/default/widgets",
+			}},
+		},
+		{
+			method: "GET",
+			target: "/openapi/v2",
+			want: []authorizer.Attributes{authorizer.AttributesRecord{
+				Verb: "get",
+				Path: "/openapi/v2",
+			}},
+		},
+		{
+			method: "GET",
+			target: "/apis/example.com/v1/namespaces/default/widgets/..",
+		},
+	} {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			got := n.GetRequestAttributes(nil, httptest.NewRequest(tt.method, tt.target, nil))
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("want %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+const (
+	AffinityNone   = "none"
+	AffinityCookie = "cookie"
+	AffinityUser   = "user"
+
+	// AffinityCookieName is the name of the cookie pinning a client to an
+	// upstream with the cookie affinity.
+	AffinityCookieName = "kube-rbac-proxy-upstream"
+)
+
+// pinnedKey is the context key of the upstream id of the affinity cookie.
+type pinnedKey struct{}
+
+type upstream struct {
+	url *url.URL
+	// id identifies the upstream in the affinity cookie without exposing
+	// its address.
+	id       string
+	director func(*http.Request)
+}
+
+// Balancer distributes requests across a pool of equivalent upstreams.
+// Without affinity requests are distributed round-robin. The cookie affinity
+// pins clients by a cookie, the user affinity pins authenticated users by
+// the hash of their name.
+//
+// The Director and ModifyResponse signatures are compatible with
+// https://golang.org/pkg/net/http/httputil/#ReverseProxy.
+type Balancer struct {
+	affinity string
+	next     atomic.Uint64
+
+	mu        sync.RWMutex // protects the fields below
+	upstreams []*upstream
+}
+
+// NewBalancer creates a Balancer for the given upstreams and affinity.
+func NewBalancer(upstreams []*url.URL, affinity string) (*Balancer, error) {
+	switch affinity {
+	case "", AffinityNone, AffinityCookie, AffinityUser:
+	default:
+		return nil, fmt.Errorf("unknown upstream affinity %q, must be one of %s, %s or %s", affinity, AffinityNone, AffinityCookie, AffinityUser)
+	}
+
+	b := &Balancer{affinity: affinity}
+	b.SetUpstreams(upstreams)
+
+	return b, nil
+}
+
+// SetUpstreams replaces the pool of upstreams.
+func (b *Balancer) SetUpstreams(urls []*url.URL) {
+	upstreams := make([]*upstream, 0, len(urls))
+	for _, u := range urls {
+		sum := sha256.Sum256([]byte(u.Scheme + "://" + u.Host))
+		upstreams = append(upstreams, &upstream{
+			url:      u,
+			id:       hex.EncodeToString(sum[:8]),
+			director: httputil.NewSingleHostReverseProxy(u).Director,
+		})
+	}
+
+	b.mu.Lock()
+	b.upstreams = upstreams
+	b.mu.Unlock()
+}
+
+// Director rewrites the request to the upstream picked for it. The affinity
+// cookie is owned by the proxy and removed from the request.
+func (b *Balancer) Director(req *http.Request) {
+	b.mu.RLock()
+	upstreams := b.upstreams
+	b.mu.RUnlock()
+
+	if len(upstreams) == 0 {
+		// Leaving the URL without host fails the request with a 502.
+		req.URL.Host = ""
+		return
+	}
+
+	u := b.pick(req, upstreams)
+	if c, err := req.Cookie(AffinityCookieName); err == nil {
+		*req = *req.WithContext(context.WithValue(req.Context(), pinnedKey{}, c.Value))
+		removeCookie(req, AffinityCookieName)
+	}
+	u.director(req)
+}
+
+// WithoutCookie returns a director removing the named cookie owned by the
+// proxy from requests, before they are rewritten by next.
+func WithoutCookie(next func(*http.Request), name string) func(*http.Request) {
+	return func(req *http.Request) {
+		if _, err := req.Cookie(name); err == nil {
+			removeCookie(req, name)
+		}
+		next(req)
+	}
+}
+
+// removeCookie removes the named cookie from the request.
+func removeCookie(req *http.Request, name string) {
+	cookies := req.Cookies()
+	kept := make([]string, 0, len(cookies))
+	for _, c := range cookies {
+		if c.Name != name {
+			kept = append(kept, c.String())
+		}
+	}
+
+	req.Header.Del("Cookie")
+	if len(kept) > 0 {
+		req.Header.Set("Cookie", strings.Join(kept, "; "))
+	}
+}
+
+func (b *Balancer) pick(req *http.Request, upstreams []*upstream) *upstream {
+	switch b.affinity {
+	case AffinityCookie:
+		if c, err := req.Cookie(AffinityCookieName); err == nil {
+			for _, u := range upstreams {
+				if u.id == c.Value {
+					return u
+				}
+			}
+		}
+	case AffinityUser:
+		if u, ok := request.UserFrom(req.Context()); ok {
+			return rendezvous(u.GetName(), upstreams)
+		}
+	}
+
+	return upstreams[(b.next.Add(1)-1)%uint64(len(upstreams))]
+}
+
+// rendezvous picks the upstream with the highest hash for the key, such that
+// changes of the pool only move the keys of the changed upstreams.
+func rendezvous(key string, upstreams []*upstream) *upstream {
+	var (
+		best      *upstream
+		bestScore uint64
+	)
+	for _, u := range upstreams {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(key))
+		_, _ = h.Write([]byte(u.id))
+		if score := h.Sum64(); best == nil || score > bestScore {
+			best, bestScore = u, score
+		}
+	}
+	return best
+}
+
+// ModifyResponse sets the affinity cookie for the upstream that served the
+// request, if the client isn't pinned to it yet.
+func (b *Balancer) ModifyResponse(resp *http.Response) error {
+	if b.affinity != AffinityCookie || resp.Request == nil {
+		return nil
+	}
+
+	b.mu.RLock()
+	upstreams := b.upstreams
+	b.mu.RUnlock()
+
+	for _, u := range upstreams {
+		if u.url.Scheme != resp.Request.URL.Scheme || u.url.Host != resp.Request.URL.Host {
+			continue
+		}
+
+		if pinned, _ := resp.Request.Context().Value(pinnedKey{}).(string); pinned == u.id {
+			return nil
+		}
+
+		cookie := &http.Cookie{
+			Name:     AffinityCookieName,
+			Value:    u.id,
+			Path:     "/",
+			HttpOnly: true,
+			Secure:   resp.Request.TLS != nil,
+			SameSite: http.SameSiteLaxMode,
+		}
+		resp.Header.Add("Set-Cookie", cookie.String())
+		return nil
+	}
+
+	return nil
+}
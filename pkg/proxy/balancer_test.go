@@ -0,0 +1,148 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+func testUpstreams(t *testing.T, rawURLs ...string) []*url.URL {
+	t.Helper()
+
+	var urls []*url.URL
+	for _, raw := range rawURLs {
+		u, err := url.Parse(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		urls = append(urls, u)
+	}
+	return urls
+}
+
+func direct(b *Balancer, req *http.Request) string {
+	b.Director(req)
+	return req.URL.Host
+}
+
+func TestBalancerRoundRobin(t *testing.T) {
+	b, err := NewBalancer(testUpstreams(t, "http://a:8080", "http://b:8080"), AffinityNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for i := 0; i < 4; i++ {
+		got = append(got, direct(b, httptest.NewRequest(http.MethodGet, "/metrics", nil)))
+	}
+
+	want := []string{"a:8080", "b:8080", "a:8080", "b:8080"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("want %v, got %v", want, got)
+		}
+	}
+}
+
+func TestBalancerCookieAffinity(t *testing.T) {
+	b, err := NewBalancer(testUpstreams(t, "http://a:8080", "http://b:8080"), AffinityCookie)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	host := direct(b, req)
+
+	resp := &http.Response{Header: http.Header{}, Request: req}
+	if err := b.ModifyResponse(resp); err != nil {
+		t.Fatal(err)
+	}
+	cookies := resp.Cookies()
+	if len(cookies) != 1 || cookies[0].Name != AffinityCookieName {
+		t.Fatalf("want affinity cookie, got %v", cookies)
+	}
+
+	for i := 0; i < 4; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(&http.Cookie{Name: "session", Value: "s"})
+		req.AddCookie(cookies[0])
+		if got := direct(b, req); got != host {
+			t.Fatalf("want pinned upstream %s, got %s", host, got)
+		}
+		if _, err := req.Cookie(AffinityCookieName); err == nil {
+			t.Error("want affinity cookie removed from the upstream request")
+		}
+		if c, err := req.Cookie("session"); err != nil || c.Value != "s" {
+			t.Errorf("want other cookies passed on, got %v", req.Header.Values("Cookie"))
+		}
+
+		resp := &http.Response{Header: http.Header{}, Request: req}
+		if err := b.ModifyResponse(resp); err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Cookies()) != 0 {
+			t.Errorf("want no cookie for pinned client, got %v", resp.Cookies())
+		}
+	}
+}
+
+func TestBalancerUserAffinity(t *testing.T) {
+	b, err := NewBalancer(testUpstreams(t, "http://a:8080", "http://b:8080", "http://c:8080"), AffinityUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newRequest := func(name string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		return req.WithContext(request.WithUser(req.Context(), &user.DefaultInfo{Name: name}))
+	}
+
+	hosts := map[string]string{}
+	for _, name := range []string{"alice", "bob", "carol", "dave"} {
+		hosts[name] = direct(b, newRequest(name))
+	}
+	for i := 0; i < 3; i++ {
+		for name, host := range hosts {
+			if got := direct(b, newRequest(name)); got != host {
+				t.Fatalf("want %s pinned to %s, got %s", name, host, got)
+			}
+		}
+	}
+
+	// Removing an upstream only moves the users of the removed upstream.
+	b.SetUpstreams(testUpstreams(t, "http://a:8080", "http://b:8080"))
+	for name, host := range hosts {
+		if host == "c:8080" {
+			continue
+		}
+		if got := direct(b, newRequest(name)); got != host {
+			t.Errorf("want %s to stay on %s, got %s", name, host, got)
+		}
+	}
+}
+
+func TestNewBalancerUnknownAffinity(t *testing.T) {
+	if _, err := NewBalancer(nil, "ip"); err == nil {
+		t.Error("want error for unknown affinity")
+	}
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// BandwidthLimiter limits the rate at which upstream response bodies are
+// copied to clients, per client connection and in total, with token buckets
+// holding a second worth of bytes. As the upstream body isn't read while
+// waiting for tokens, the upstream connection is throttled as well.
+//
+// The ModifyResponse signature is compatible with
+// https://golang.org/pkg/net/http/httputil/#ReverseProxy.
+type BandwidthLimiter struct {
+	connectionLimit int
+	total           *rate.Limiter
+
+	mu          sync.Mutex
+	connections map[string]*connectionLimiter
+}
+
+// connectionLimiter is shared by the concurrent responses of a client
+// connection, e.g. HTTP/2 streams.
+type connectionLimiter struct {
+	*rate.Limiter
+	responses int
+}
+
+// NewBandwidthLimiter creates a BandwidthLimiter with limits in bytes per
+// second. A limit of zero disables the respective limit.
+func NewBandwidthLimiter(connectionLimit, totalLimit int) *BandwidthLimiter {
+	l := &BandwidthLimiter{
+		connectionLimit: connectionLimit,
+		connections:     map[string]*connectionLimiter{},
+	}
+	if totalLimit > 0 {
+		l.total = rate.NewLimiter(rate.Limit(totalLimit), totalLimit)
+	}
+	return l
+}
+
+// ModifyResponse replaces the body of the response with a rate limited one.
+func (l *BandwidthLimiter) ModifyResponse(resp *http.Response) error {
+	if resp.Request == nil || resp.Body == nil || resp.Body == http.NoBody {
+		return nil
+	}
+	if l.connectionLimit <= 0 && l.total == nil {
+		return nil
+	}
+
+	body := &limitedBody{
+		ReadCloser: resp.Body,
+		ctx:        resp.Request.Context(),
+		maxRead:    -1,
+	}
+	if l.connectionLimit > 0 {
+		// The remote address identifies the client connection.
+		addr := resp.Request.RemoteAddr
+		body.limiters = append(body.limiters, l.acquire(addr))
+		body.release = func() { l.release(addr) }
+		body.maxRead = l.connectionLimit
+	}
+	if l.total != nil {
+		body.limiters = append(body.limiters, l.total)
+		if body.maxRead < 0 || l.total.Burst() < body.maxRead {
+			body.maxRead = l.total.Burst()
+		}
+	}
+
+	resp.Body = body
+	return nil
+}
+
+func (l *BandwidthLimiter) acquire(addr string) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	c, ok := l.connections[addr]
+	if !ok {
+		c = &connectionLimiter{Limiter: rate.NewLimiter(rate.Limit(l.connectionLimit), l.connectionLimit)}
+		l.connections[addr] = c
+	}
+	c.responses++
+	return c.Limiter
+}
+
+func (l *BandwidthLimiter) release(addr string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if c, ok := l.connections[addr]; ok {
+		c.responses--
+		if c.responses <= 0 {
+			delete(l.connections, addr)
+		}
+	}
+}
+
+// limitedBody waits for the tokens of the bytes read, reads never exceed the
+// burst of the limiters.
+type limitedBody struct {
+	io.ReadCloser
+	ctx      context.Context
+	limiters []*rate.Limiter
+	maxRead  int
+
+	release   func()
+	closeOnce sync.Once
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if len(p) > b.maxRead {
+		p = p[:b.maxRead]
+	}
+
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		for _, limiter := range b.limiters {
+			if waitErr := limiter.WaitN(b.ctx, n); waitErr != nil {
+				return n, waitErr
+			}
+		}
+	}
+	return n, err
+}
+
+func (b *limitedBody) Close() error {
+	b.closeOnce.Do(func() {
+		if b.release != nil {
+			b.release()
+		}
+	})
+	return b.ReadCloser.Close()
+}
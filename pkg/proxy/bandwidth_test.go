@@ -0,0 +1,116 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBandwidthLimiter(t *testing.T) {
+	for _, tt := range []struct {
+		name            string
+		connectionLimit int
+		totalLimit      int
+		minDuration     time.Duration
+	}{
+		{
+			name: "unlimited",
+		},
+		{
+			name:            "connection limit",
+			connectionLimit: 1000,
+			minDuration:     400 * time.Millisecond,
+		},
+		{
+			name:        "total limit",
+			totalLimit:  1000,
+			minDuration: 400 * time.Millisecond,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewBandwidthLimiter(tt.connectionLimit, tt.totalLimit)
+
+			req := httptest.NewRequest(http.MethodGet, "/download", nil)
+			req.RemoteAddr = "10.0.0.1:34567"
+			resp := &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(make([]byte, 1500))),
+				Request:    req,
+			}
+			if err := l.ModifyResponse(resp); err != nil {
+				t.Fatal(err)
+			}
+
+			// The burst of a second worth of bytes is consumed at once, the
+			// remaining 500 bytes take half a second.
+			start := time.Now()
+			n, err := io.Copy(io.Discard, resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != 1500 {
+				t.Errorf("want 1500 bytes, got %d", n)
+			}
+			if elapsed := time.Since(start); elapsed < tt.minDuration {
+				t.Errorf("want copying to take at least %v, took %v", tt.minDuration, elapsed)
+			}
+
+			if err := resp.Body.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if len(l.connections) != 0 {
+				t.Errorf("want connection limiters released, got %d", len(l.connections))
+			}
+		})
+	}
+}
+
+func TestBandwidthLimiterSharedConnection(t *testing.T) {
+	l := NewBandwidthLimiter(1000, 0)
+
+	var bodies []io.ReadCloser
+	for _, addr := range []string{"10.0.0.1:34567", "10.0.0.1:34567", "10.0.0.2:34567"} {
+		req := httptest.NewRequest(http.MethodGet, "/download", nil)
+		req.RemoteAddr = addr
+		resp := &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(nil)), Request: req}
+		if err := l.ModifyResponse(resp); err != nil {
+			t.Fatal(err)
+		}
+		bodies = append(bodies, resp.Body)
+	}
+
+	if len(l.connections) != 2 {
+		t.Fatalf("want a limiter per connection, got %d", len(l.connections))
+	}
+
+	bodies[0].Close()
+	bodies[0].Close()
+	if c := l.connections["10.0.0.1:34567"]; c == nil || c.responses != 1 {
+		t.Errorf("want the limiter kept for the remaining response of the connection, got %+v", c)
+	}
+
+	bodies[1].Close()
+	bodies[2].Close()
+	if len(l.connections) != 0 {
+		t.Errorf("want connection limiters released, got %d", len(l.connections))
+	}
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"slices"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+var canaryRequests = metrics.NewCounterVec(
+	&metrics.CounterOpts{
+		Namespace:      "kube_rbac_proxy",
+		Subsystem:      "upstream",
+		Name:           "canary_requests_total",
+		Help:           "Number of requests routed to canary upstreams by upstream.",
+		StabilityLevel: metrics.ALPHA,
+	},
+	[]string{"upstream"},
+)
+
+func init() {
+	legacyregistry.MustRegister(canaryRequests)
+}
+
+// CanaryConfig routes the requests of the users and the members of the
+// groups to an alternate upstream, and additionally a share of all other
+// authenticated users given by the weight between 0 and 1. The share is
+// picked by the hash of the user name, such that users stay on the same
+// upstream.
+type CanaryConfig struct {
+	Upstream string   `json:"upstream"`
+	Users    []string `json:"users,omitempty"`
+	Groups   []string `json:"groups,omitempty"`
+	Weight   float64  `json:"weight,omitempty"`
+}
+
+// ValidateCanaries checks the upstream URLs and weights.
+func ValidateCanaries(configs []CanaryConfig) error {
+	for i, config := range configs {
+		if _, err := parseCanaryUpstream(config.Upstream); err != nil {
+			return fmt.Errorf("canaries[%d]: %w", i, err)
+		}
+		if config.Weight < 0 || config.Weight > 1 {
+			return fmt.Errorf("canaries[%d]: weight must be between 0 and 1", i)
+		}
+		if len(config.Users) == 0 && len(config.Groups) == 0 && config.Weight == 0 {
+			return fmt.Errorf("canaries[%d]: at least one of users, groups or weight is required", i)
+		}
+	}
+	return nil
+}
+
+func parseCanaryUpstream(upstream string) (*url.URL, error) {
+	u, err := url.Parse(upstream)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse upstream URL: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("upstream URL %q must be an http or https URL with a host", upstream)
+	}
+	return u, nil
+}
+
+type canary struct {
+	config   CanaryConfig
+	host     string
+	director func(*http.Request)
+}
+
+// CanaryRouter routes requests of authenticated users to the upstream of the
+// first matching canary config.
+type CanaryRouter struct {
+	canaries []*canary
+}
+
+// NewCanaryRouter creates a CanaryRouter for validated configs.
+func NewCanaryRouter(configs []CanaryConfig) (*CanaryRouter, error) {
+	r := &CanaryRouter{}
+	for _, config := range configs {
+		u, err := parseCanaryUpstream(config.Upstream)
+		if err != nil {
+			return nil, err
+		}
+		r.canaries = append(r.canaries, &canary{
+			config:   config,
+			host:     u.Host,
+			director: httputil.NewSingleHostReverseProxy(u).Director,
+		})
+	}
+	return r, nil
+}
+
+// Director returns a director rewriting requests of matching users to their
+// canary upstream, other requests are rewritten by next. The director is
+// compatible with https://golang.org/pkg/net/http/httputil/#ReverseProxy.
+func (r *CanaryRouter) Director(next func(*http.Request)) func(*http.Request) {
+	return func(req *http.Request) {
+		u, ok := request.UserFrom(req.Context())
+		if !ok {
+			next(req)
+			return
+		}
+
+		for _, c := range r.canaries {
+			if c.matches(u) {
+				// The affinity cookie only pins clients to the balanced
+				// upstreams, it isn't passed on to the canary.
+				if _, err := req.Cookie(AffinityCookieName); err == nil {
+					removeCookie(req, AffinityCookieName)
+				}
+				c.director(req)
+				canaryRequests.WithLabelValues(c.host).Inc()
+				setUpstreamLabel(req.Context(), c.host)
+				return
+			}
+		}
+
+		next(req)
+	}
+}
+
+func (c *canary) matches(u user.Info) bool {
+	if slices.Contains(c.config.Users, u.GetName()) {
+		return true
+	}
+	for _, group := range u.GetGroups() {
+		if slices.Contains(c.config.Groups, group) {
+			return true
+		}
+	}
+	return c.config.Weight > 0 && userShare(u.GetName(), c.config.Upstream) < c.config.Weight
+}
+
+// userShare maps the user name to [0, 1). The upstream is hashed as well,
+// such that the shares of several canaries are independent.
+func userShare(name, upstream string) float64 {
+	sum := sha256.Sum256([]byte(upstream + "\x00" + name))
+	return float64(binary.BigEndian.Uint64(sum[:8])>>11) / (1 << 53)
+}
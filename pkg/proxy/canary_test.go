@@ -0,0 +1,118 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"testing"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+func TestCanaryRouter(t *testing.T) {
+	router, err := NewCanaryRouter([]CanaryConfig{
+		{Upstream: "http://users:8080", Users: []string{"alice"}, Groups: []string{"canary-testers"}},
+		{Upstream: "https://everyone:8443/base", Weight: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	upstream, err := url.Parse("http://upstream:8080")
+	if err != nil {
+		t.Fatal(err)
+	}
+	director := router.Director(httputil.NewSingleHostReverseProxy(upstream).Director)
+
+	for _, tt := range []struct {
+		name    string
+		user    user.Info
+		wantURL string
+	}{
+		{
+			name:    "user",
+			user:    &user.DefaultInfo{Name: "alice"},
+			wantURL: "http://users:8080/metrics",
+		},
+		{
+			name:    "group",
+			user:    &user.DefaultInfo{Name: "bob", Groups: []string{"system:authenticated", "canary-testers"}},
+			wantURL: "http://users:8080/metrics",
+		},
+		{
+			name:    "weight",
+			user:    &user.DefaultInfo{Name: "bob"},
+			wantURL: "https://everyone:8443/base/metrics",
+		},
+		{
+			name:    "unauthenticated",
+			wantURL: "http://upstream:8080/metrics",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tt.user != nil {
+				req = req.WithContext(request.WithUser(req.Context(), tt.user))
+			}
+			director(req)
+			if got := req.URL.String(); got != tt.wantURL {
+				t.Errorf("want %s, got %s", tt.wantURL, got)
+			}
+		})
+	}
+}
+
+func TestCanaryWeight(t *testing.T) {
+	c := &canary{config: CanaryConfig{Upstream: "http://canary", Weight: 0.2}}
+
+	var routed int
+	for i := 0; i < 10000; i++ {
+		u := &user.DefaultInfo{Name: fmt.Sprintf("user-%d", i)}
+		if c.matches(u) {
+			routed++
+		}
+		if c.matches(u) != c.matches(u) {
+			t.Fatalf("user %s isn't routed consistently", u.Name)
+		}
+	}
+	if routed < 1800 || routed > 2200 {
+		t.Errorf("want about 2000 of 10000 users routed, got %d", routed)
+	}
+}
+
+func TestValidateCanaries(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		config  CanaryConfig
+		wantErr bool
+	}{
+		{name: "valid", config: CanaryConfig{Upstream: "http://canary", Weight: 0.1}},
+		{name: "no host", config: CanaryConfig{Upstream: "canary", Users: []string{"alice"}}, wantErr: true},
+		{name: "weight too large", config: CanaryConfig{Upstream: "http://canary", Weight: 2}, wantErr: true},
+		{name: "matches nobody", config: CanaryConfig{Upstream: "http://canary"}, wantErr: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateCanaries([]CanaryConfig{tt.config}); (err != nil) != tt.wantErr {
+				t.Errorf("want error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Compressor gzips uncompressed upstream responses for clients accepting
+// gzip. Responses compressed by the upstream are passed through unchanged,
+// as are 304 responses and the conditional headers of requests.
+//
+// The ModifyResponse signature is compatible with
+// https://golang.org/pkg/net/http/httputil/#ReverseProxy.
+type Compressor struct {
+	minSize int64
+	writers sync.Pool
+}
+
+// NewCompressor creates a Compressor with a gzip level between 1 (fastest)
+// and 9 (smallest). Responses with a known length below minSize are not
+// compressed.
+func NewCompressor(level int, minSize int64) (*Compressor, error) {
+	if level < gzip.BestSpeed || level > gzip.BestCompression {
+		return nil, fmt.Errorf("compression level must be between %d and %d, got %d", gzip.BestSpeed, gzip.BestCompression, level)
+	}
+
+	return &Compressor{
+		minSize: minSize,
+		writers: sync.Pool{
+			New: func() interface{} {
+				w, _ := gzip.NewWriterLevel(nil, level)
+				return w
+			},
+		},
+	}, nil
+}
+
+// ModifyResponse replaces the body of the response with a gzip stream.
+func (c *Compressor) ModifyResponse(resp *http.Response) error {
+	if !c.shouldCompress(resp) {
+		return nil
+	}
+
+	pr, pw := io.Pipe()
+	body := resp.Body
+	go func() {
+		gw := c.writers.Get().(*gzip.Writer)
+		defer c.writers.Put(gw)
+		gw.Reset(pw)
+
+		_, err := io.Copy(gw, body)
+		if err == nil {
+			err = gw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	resp.Body = &compressedBody{PipeReader: pr, body: body}
+	resp.Header.Set("Content-Encoding", "gzip")
+	// The compressed representation differs byte by byte, so a strong
+	// ETag of the upstream only holds as a weak one.
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		resp.Header.Set("ETag", weakETag(etag))
+	}
+	resp.Header.Del("Content-Length")
+	resp.Header.Add("Vary", "Accept-Encoding")
+	resp.ContentLength = -1
+	resp.Uncompressed = false
+
+	return nil
+}
+
+func (c *Compressor) shouldCompress(resp *http.Response) bool {
+	if resp.Request == nil || resp.Request.Method == http.MethodHead {
+		return false
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusNotModified {
+		return false
+	}
+	if resp.Header.Get("Content-Encoding") != "" || resp.Header.Get("Content-Range") != "" {
+		return false
+	}
+	if resp.ContentLength >= 0 && resp.ContentLength < c.minSize {
+		return false
+	}
+	// Streams are compressed in blocks, which would delay the events.
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType == "text/event-stream" {
+		return false
+	}
+
+	return acceptsGzip(resp.Request.Header)
+}
+
+// acceptsGzip reports whether the Accept-Encoding header allows gzip, which
+// is the case if it lists gzip, or else *, without a zero quality.
+func acceptsGzip(h http.Header) bool {
+	accepted := map[string]bool{}
+	for _, value := range h.Values("Accept-Encoding") {
+		for _, coding := range strings.Split(value, ",") {
+			name, params, _ := strings.Cut(coding, ";")
+			name = strings.TrimSpace(name)
+
+			q := 1.0
+			if key, value, _ := strings.Cut(params, "="); strings.TrimSpace(key) == "q" {
+				var err error
+				if q, err = strconv.ParseFloat(strings.TrimSpace(value), 64); err != nil {
+					q = 0
+				}
+			}
+			accepted[name] = q > 0
+		}
+	}
+
+	if ok, listed := accepted["gzip"]; listed {
+		return ok
+	}
+	return accepted["*"]
+}
+
+// compressedBody closes the upstream body along with the pipe, which stops
+// the compression of an abandoned response.
+type compressedBody struct {
+	*io.PipeReader
+	body io.ReadCloser
+}
+
+func (b *compressedBody) Close() error {
+	b.PipeReader.Close()
+	return b.body.Close()
+}
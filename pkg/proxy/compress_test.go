@@ -0,0 +1,118 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestCompressor(t *testing.T) {
+	metrics := strings.Repeat("http_requests_total{code=\"200\"} 1\n", 1000)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/small":
+			_, _ = w.Write([]byte("ok"))
+		case "/compressed":
+			w.Header().Set("Content-Encoding", "gzip")
+			gw := gzip.NewWriter(w)
+			_, _ = gw.Write([]byte(metrics))
+			_ = gw.Close()
+		case "/events":
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte(metrics))
+		default:
+			_, _ = w.Write([]byte(metrics))
+		}
+	}))
+	defer upstream.Close()
+
+	u, err := url.Parse(upstream.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewCompressor(gzip.BestSpeed, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rp := httputil.NewSingleHostReverseProxy(u)
+	rp.ModifyResponse = c.ModifyResponse
+	proxy := httptest.NewServer(rp)
+	defer proxy.Close()
+
+	for _, tt := range []struct {
+		path           string
+		acceptEncoding string
+		wantGzip       bool
+	}{
+		{path: "/metrics", acceptEncoding: "gzip", wantGzip: true},
+		{path: "/metrics", acceptEncoding: "br, *;q=0.5", wantGzip: true},
+		{path: "/metrics", acceptEncoding: "*, gzip;q=0"},
+		{path: "/metrics", acceptEncoding: "identity"},
+		{path: "/small", acceptEncoding: "gzip"},
+		{path: "/events", acceptEncoding: "gzip"},
+		{path: "/compressed", acceptEncoding: "gzip", wantGzip: true},
+	} {
+		t.Run(tt.path+" "+tt.acceptEncoding, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, proxy.URL+tt.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+
+			resp, err := http.DefaultTransport.RoundTrip(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if gotGzip := resp.Header.Get("Content-Encoding") == "gzip"; gotGzip != tt.wantGzip {
+				t.Fatalf("want gzip %t, got Content-Encoding %q", tt.wantGzip, resp.Header.Get("Content-Encoding"))
+			}
+
+			body := resp.Body
+			if tt.wantGzip {
+				if body, err = gzip.NewReader(resp.Body); err != nil {
+					t.Fatal(err)
+				}
+			}
+			b, err := io.ReadAll(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := metrics
+			if tt.path == "/small" {
+				want = "ok"
+			}
+			if string(b) != want {
+				t.Errorf("want body of %d bytes, got %d bytes", len(want), len(b))
+			}
+		})
+	}
+}
+
+func TestNewCompressorLevel(t *testing.T) {
+	if _, err := NewCompressor(10, 0); err == nil {
+		t.Error("want error for level 10")
+	}
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	discoverylisters "k8s.io/client-go/listers/discovery/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// EndpointSliceDiscovery keeps the upstreams of a Balancer in sync with the
+// ready endpoints of a Service, by watching its EndpointSlices.
+type EndpointSliceDiscovery struct {
+	client    kubernetes.Interface
+	namespace string
+	service   string
+	portName  string
+	// template provides the scheme and the path of the upstream URLs.
+	template *url.URL
+	balancer *Balancer
+}
+
+// NewEndpointSliceDiscovery creates an EndpointSliceDiscovery, the Run method
+// must be started explicitly. The port of the endpoints is selected by name,
+// an empty name selects the only port of the EndpointSlices.
+func NewEndpointSliceDiscovery(client kubernetes.Interface, namespace, service, portName string, template *url.URL, balancer *Balancer) *EndpointSliceDiscovery {
+	return &EndpointSliceDiscovery{
+		client:    client,
+		namespace: namespace,
+		service:   service,
+		portName:  portName,
+		template:  template,
+		balancer:  balancer,
+	}
+}
+
+// Run watches the EndpointSlices until the context is done.
+func (d *EndpointSliceDiscovery) Run(ctx context.Context) error {
+	selector := labels.SelectorFromSet(labels.Set{discoveryv1.LabelServiceName: d.service})
+
+	factory := informers.NewSharedInformerFactoryWithOptions(d.client, 0,
+		informers.WithNamespace(d.namespace),
+		informers.WithTweakListOptions(func(o *metav1.ListOptions) {
+			o.LabelSelector = selector.String()
+		}),
+	)
+	informer := factory.Discovery().V1().EndpointSlices()
+	lister := informer.Lister().EndpointSlices(d.namespace)
+
+	sync := func() {
+		if err := d.sync(lister, selector); err != nil {
+			klog.ErrorS(err, "Failed to sync upstreams", "service", klog.KRef(d.namespace, d.service))
+		}
+	}
+	if _, err := informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(interface{}) { sync() },
+		UpdateFunc: func(interface{}, interface{}) { sync() },
+		DeleteFunc: func(interface{}) { sync() },
+	}); err != nil {
+		return fmt.Errorf("failed to watch EndpointSlices: %w", err)
+	}
+
+	factory.Start(ctx.Done())
+	defer factory.Shutdown()
+
+	if !cache.WaitForCacheSync(ctx.Done(), informer.Informer().HasSynced) {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return errors.New("failed to sync EndpointSlices")
+	}
+	sync()
+
+	<-ctx.Done()
+	return nil
+}
+
+func (d *EndpointSliceDiscovery) sync(lister discoverylisters.EndpointSliceNamespaceLister, selector labels.Selector) error {
+	slices, err := lister.List(selector)
+	if err != nil {
+		return err
+	}
+
+	upstreams, err := d.upstreams(slices)
+	if err != nil {
+		return err
+	}
+
+	klog.V(2).InfoS("Discovered upstreams", "service", klog.KRef(d.namespace, d.service), "upstreams", len(upstreams))
+	d.balancer.SetUpstreams(upstreams)
+
+	return nil
+}
+
+// upstreams returns the URLs of the ready endpoints, sorted and without
+// duplicates, as dual-stack services list endpoints in several slices.
+func (d *EndpointSliceDiscovery) upstreams(slices []*discoveryv1.EndpointSlice) ([]*url.URL, error) {
+	hosts := map[string]struct{}{}
+
+	for _, slice := range slices {
+		port, err := d.port(slice)
+		if err != nil {
+			return nil, err
+		}
+		if port == 0 {
+			continue
+		}
+
+		for _, ep := range slice.Endpoints {
+			if ep.Conditions.Ready != nil && !*ep.Conditions.Ready {
+				continue
+			}
+			for _, address := range ep.Addresses {
+				hosts[net.JoinHostPort(address, strconv.Itoa(int(port)))] = struct{}{}
+			}
+		}
+	}
+
+	sorted := make([]string, 0, len(hosts))
+	for host := range hosts {
+		sorted = append(sorted, host)
+	}
+	sort.Strings(sorted)
+
+	upstreams := make([]*url.URL, 0, len(sorted))
+	for _, host := range sorted {
+		u := *d.template
+		u.Host = host
+		upstreams = append(upstreams, &u)
+	}
+
+	return upstreams, nil
+}
+
+// port returns the selected port of the slice, or 0 if the slice has no
+// ports yet.
+func (d *EndpointSliceDiscovery) port(slice *discoveryv1.EndpointSlice) (int32, error) {
+	if d.portName == "" && len(slice.Ports) > 1 {
+		return 0, fmt.Errorf("EndpointSlice %s has several ports, the port name must be set", slice.Name)
+	}
+
+	for _, p := range slice.Ports {
+		if p.Port == nil {
+			continue
+		}
+		if d.portName == "" || (p.Name != nil && *p.Name == d.portName) {
+			return *p.Port, nil
+		}
+	}
+
+	return 0, nil
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"context"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/ptr"
+)
+
+func newEndpointSlice(name, service string, ports map[string]int32, ready map[string]bool) *discoveryv1.EndpointSlice {
+	slice := &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "monitoring",
+			Labels:    map[string]string{discoveryv1.LabelServiceName: service},
+		},
+		AddressType: discoveryv1.AddressTypeIPv4,
+	}
+	for portName, port := range ports {
+		slice.Ports = append(slice.Ports, discoveryv1.EndpointPort{Name: ptr.To(portName), Port: ptr.To(port)})
+	}
+	for address, isReady := range ready {
+		slice.Endpoints = append(slice.Endpoints, discoveryv1.Endpoint{
+			Addresses:  []string{address},
+			Conditions: discoveryv1.EndpointConditions{Ready: ptr.To(isReady)},
+		})
+	}
+	return slice
+}
+
+func balancerHosts(b *Balancer) []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	hosts := []string{}
+	for _, u := range b.upstreams {
+		hosts = append(hosts, u.url.Scheme+"://"+u.url.Host+u.url.Path)
+	}
+	return hosts
+}
+
+func TestEndpointSliceDiscovery(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		newEndpointSlice("dashboard-a", "dashboard", map[string]int32{"http": 8080}, map[string]bool{"10.0.0.2": true, "10.0.0.1": true, "10.0.0.3": false}),
+		newEndpointSlice("other-a", "other", map[string]int32{"http": 9090}, map[string]bool{"10.0.1.1": true}),
+	)
+
+	template := testUpstreams(t, "https://dashboard.monitoring.svc:8443/ui")[0]
+	b, err := NewBalancer([]*url.URL{template}, AffinityNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	d := NewEndpointSliceDiscovery(client, "monitoring", "dashboard", "http", template, b)
+	errCh := make(chan error, 1)
+	go func() { errCh <- d.Run(ctx) }()
+
+	waitForHosts := func(want []string) {
+		t.Helper()
+		if err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 5*time.Second, true, func(context.Context) (bool, error) {
+			return reflect.DeepEqual(balancerHosts(b), want), nil
+		}); err != nil {
+			t.Fatalf("want upstreams %v, got %v", want, balancerHosts(b))
+		}
+	}
+
+	waitForHosts([]string{"https://10.0.0.1:8080/ui", "https://10.0.0.2:8080/ui"})
+
+	if _, err := client.DiscoveryV1().EndpointSlices("monitoring").Update(ctx,
+		newEndpointSlice("dashboard-a", "dashboard", map[string]int32{"http": 8080}, map[string]bool{"10.0.0.3": true}),
+		metav1.UpdateOptions{},
+	); err != nil {
+		t.Fatal(err)
+	}
+	waitForHosts([]string{"https://10.0.0.3:8080/ui"})
+
+	if err := client.DiscoveryV1().EndpointSlices("monitoring").Delete(ctx, "dashboard-a", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	waitForHosts([]string{})
+
+	cancel()
+	if err := <-errCh; err != nil {
+		t.Errorf("want no error on shutdown, got %v", err)
+	}
+}
+
+func TestEndpointSliceDiscoveryPort(t *testing.T) {
+	d := &EndpointSliceDiscovery{}
+	if _, err := d.port(newEndpointSlice("a", "svc", map[string]int32{"http": 80, "metrics": 9090}, nil)); err == nil {
+		t.Error("want error for several ports without port name")
+	}
+
+	d.portName = "metrics"
+	port, err := d.port(newEndpointSlice("a", "svc", map[string]int32{"http": 80, "metrics": 9090}, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if port != 9090 {
+		t.Errorf("want port 9090, got %d", port)
+	}
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+
+	"golang.org/x/net/http2"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+)
+
+// Reasons an upstream request failed.
+const (
+	UpstreamErrorDNS               = "dns"
+	UpstreamErrorTLS               = "tls_handshake"
+	UpstreamErrorConnectionRefused = "connection_refused"
+	UpstreamErrorConnectionReset   = "connection_reset"
+	UpstreamErrorTimeout           = "timeout"
+	UpstreamErrorProtocol          = "protocol"
+	UpstreamErrorCanceled          = "canceled"
+	UpstreamErrorUnknown           = "unknown"
+)
+
+var upstreamErrors = metrics.NewCounterVec(
+	&metrics.CounterOpts{
+		Namespace:      "kube_rbac_proxy",
+		Subsystem:      "upstream",
+		Name:           "errors_total",
+		Help:           "Number of failed upstream requests by reason.",
+		StabilityLevel: metrics.ALPHA,
+	},
+	[]string{"reason"},
+)
+
+func init() {
+	legacyregistry.MustRegister(upstreamErrors)
+}
+
+// ClassifyUpstreamError returns the reason an upstream request failed.
+func ClassifyUpstreamError(err error) string {
+	var (
+		dnsErr       *net.DNSError
+		recordErr    tls.RecordHeaderError
+		alertErr     tls.AlertError
+		verifyErr    *tls.CertificateVerificationError
+		authorityErr x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+		streamErr    http2.StreamError
+		connErr      http2.ConnectionError
+		goAwayErr    http2.GoAwayError
+		netErr       net.Error
+	)
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return UpstreamErrorCanceled
+	case errors.As(err, &dnsErr):
+		return UpstreamErrorDNS
+	case errors.As(err, &recordErr),
+		errors.As(err, &alertErr),
+		errors.As(err, &verifyErr),
+		errors.As(err, &authorityErr),
+		errors.As(err, &hostnameErr),
+		errors.As(err, &invalidErr):
+		return UpstreamErrorTLS
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return UpstreamErrorConnectionRefused
+	case errors.Is(err, syscall.ECONNRESET):
+		return UpstreamErrorConnectionReset
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.As(err, &netErr) && netErr.Timeout():
+		return UpstreamErrorTimeout
+	case errors.As(err, &streamErr),
+		errors.As(err, &connErr),
+		errors.As(err, &goAwayErr),
+		strings.Contains(err.Error(), "malformed HTTP"):
+		return UpstreamErrorProtocol
+	}
+
+	return UpstreamErrorUnknown
+}
+
+// NewUpstreamErrorHandler returns an error handler for httputil.ReverseProxy
+// that classifies, logs and counts upstream errors. If diagnostics is true,
+// the classification and error are written into the 502 response body.
+func NewUpstreamErrorHandler(diagnostics bool) func(http.ResponseWriter, *http.Request, error) {
+	return func(w http.ResponseWriter, req *http.Request, err error) {
+		reason := ClassifyUpstreamError(err)
+		upstreamErrors.WithLabelValues(reason).Inc()
+		klog.FromContext(req.Context()).Error(err, "Upstream request failed", "method", req.Method, "path", req.URL.Path, "reason", reason)
+
+		if !diagnostics {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		http.Error(w, fmt.Sprintf("Bad Gateway (reason=%s): %v", reason, err), http.StatusBadGateway)
+	}
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+
+	"golang.org/x/net/http2"
+)
+
+func TestClassifyUpstreamError(t *testing.T) {
+	cases := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{"dns", &url.Error{Op: "Get", Err: &net.OpError{Op: "dial", Err: &net.DNSError{Err: "no such host", Name: "upstream"}}}, UpstreamErrorDNS},
+		{"tls", &url.Error{Op: "Get", Err: x509.UnknownAuthorityError{}}, UpstreamErrorTLS},
+		{"connection refused", &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, UpstreamErrorConnectionRefused},
+		{"connection reset", &net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, UpstreamErrorConnectionReset},
+		{"timeout", fmt.Errorf("wrapped: %w", context.DeadlineExceeded), UpstreamErrorTimeout},
+		{"canceled", context.Canceled, UpstreamErrorCanceled},
+		{"http2 protocol", http2.StreamError{Code: http2.ErrCodeProtocol}, UpstreamErrorProtocol},
+		{"http1 protocol", errors.New(`net/http: HTTP/1.x transport connection broken: malformed HTTP response "foo"`), UpstreamErrorProtocol},
+		{"unknown", errors.New("something else"), UpstreamErrorUnknown},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if reason := ClassifyUpstreamError(c.err); reason != c.expected {
+				t.Errorf("want reason %q, got %q", c.expected, reason)
+			}
+		})
+	}
+}
+
+func TestUpstreamErrorHandler(t *testing.T) {
+	err := &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}
+
+	for _, diagnostics := range []bool{false, true} {
+		rec := httptest.NewRecorder()
+		NewUpstreamErrorHandler(diagnostics)(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil), err)
+
+		if rec.Code != http.StatusBadGateway {
+			t.Errorf("diagnostics=%t: want status %d, got %d", diagnostics, http.StatusBadGateway, rec.Code)
+		}
+
+		hasReason := strings.Contains(rec.Body.String(), UpstreamErrorConnectionRefused)
+		if hasReason != diagnostics {
+			t.Errorf("diagnostics=%t: unexpected body %q", diagnostics, rec.Body.String())
+		}
+	}
+}
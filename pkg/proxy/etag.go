@@ -0,0 +1,126 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ETagger adds weak ETags to cacheable upstream responses without one, and
+// answers conditional requests matching them with 304, saving the bandwidth
+// of e.g. dashboard assets whose upstream doesn't support conditional
+// requests. The upstream still serves the full response.
+//
+// The ModifyResponse signature is compatible with
+// https://golang.org/pkg/net/http/httputil/#ReverseProxy.
+type ETagger struct {
+	maxSize int64
+}
+
+// NewETagger creates an ETagger for responses up to maxSize bytes, which
+// are buffered to hash them.
+func NewETagger(maxSize int64) *ETagger {
+	return &ETagger{maxSize: maxSize}
+}
+
+// ModifyResponse sets the ETag of the response, and replaces it with a 304
+// response if the request's If-None-Match matches.
+func (e *ETagger) ModifyResponse(resp *http.Response) error {
+	if !e.shouldTag(resp) {
+		return nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, e.maxSize+1))
+	if err != nil {
+		return err
+	}
+	if int64(len(body)) > e.maxSize {
+		// Bodies of unknown length exceeding the maximum size are passed
+		// on untagged, starting with the bytes read so far.
+		resp.Body = readCloser{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return nil
+	}
+	resp.Body.Close()
+
+	sum := sha256.Sum256(body)
+	etag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+	resp.Header.Set("ETag", etag)
+
+	if etagMatches(resp.Request.Header.Get("If-None-Match"), etag) {
+		resp.StatusCode = http.StatusNotModified
+		resp.Status = http.StatusText(http.StatusNotModified)
+		resp.Header.Del("Content-Length")
+		resp.Header.Del("Content-Type")
+		resp.ContentLength = 0
+		resp.Body = http.NoBody
+		return nil
+	}
+
+	resp.ContentLength = int64(len(body))
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return nil
+}
+
+func (e *ETagger) shouldTag(resp *http.Response) bool {
+	if resp.Request == nil || resp.Request.Method != http.MethodGet || resp.StatusCode != http.StatusOK {
+		return false
+	}
+	if resp.Header.Get("ETag") != "" || resp.Header.Get("Content-Encoding") != "" {
+		return false
+	}
+	if resp.ContentLength > e.maxSize {
+		return false
+	}
+	cacheControl := strings.ToLower(resp.Header.Get("Cache-Control"))
+	return !strings.Contains(cacheControl, "no-store")
+}
+
+// etagMatches compares the If-None-Match header to the ETag with the weak
+// comparison of RFC 9110.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// weakETag returns the ETag as a weak one.
+func weakETag(etag string) string {
+	if etag == "" || strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return "W/" + etag
+}
+
+// readCloser reads from the Reader and closes the Closer.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
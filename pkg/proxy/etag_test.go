@@ -0,0 +1,160 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConditionalRequests(t *testing.T) {
+	modified := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	asset := strings.Repeat("body { color: black; }\n", 100)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tagged.css":
+			w.Header().Set("ETag", `"v1"`)
+			http.ServeContent(w, r, "tagged.css", modified, strings.NewReader(asset))
+		case "/untagged.css":
+			w.Header().Set("Content-Type", "text/css")
+			_, _ = w.Write([]byte(asset))
+		case "/private.css":
+			w.Header().Set("Cache-Control", "no-store")
+			_, _ = w.Write([]byte(asset))
+		}
+	}))
+	defer upstream.Close()
+
+	u, err := url.Parse(upstream.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compressor, err := NewCompressor(1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	etagger := NewETagger(1 << 20)
+	rp := httputil.NewSingleHostReverseProxy(u)
+	rp.ModifyResponse = func(resp *http.Response) error {
+		if err := etagger.ModifyResponse(resp); err != nil {
+			return err
+		}
+		return compressor.ModifyResponse(resp)
+	}
+	proxy := httptest.NewServer(rp)
+	defer proxy.Close()
+
+	get := func(path string, header http.Header) (*http.Response, string) {
+		req, err := http.NewRequest(http.MethodGet, proxy.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header = header
+		resp, err := http.DefaultTransport.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp, string(body)
+	}
+
+	t.Run("upstream 304 passes through", func(t *testing.T) {
+		for _, header := range []http.Header{
+			{"If-None-Match": {`"v1"`}},
+			{"If-Modified-Since": {modified.Format(http.TimeFormat)}},
+			{"If-None-Match": {`"v1"`}, "Accept-Encoding": {"gzip"}},
+		} {
+			resp, body := get("/tagged.css", header)
+			if resp.StatusCode != http.StatusNotModified || body != "" {
+				t.Errorf("%v: want 304 without body, got %d with %d bytes", header, resp.StatusCode, len(body))
+			}
+			if resp.Header.Get("ETag") != `"v1"` {
+				t.Errorf("%v: want the upstream ETag, got %q", header, resp.Header.Get("ETag"))
+			}
+		}
+	})
+
+	t.Run("compression weakens the upstream ETag", func(t *testing.T) {
+		resp, _ := get("/tagged.css", http.Header{"Accept-Encoding": {"gzip"}})
+		if resp.Header.Get("Content-Encoding") != "gzip" || resp.Header.Get("ETag") != `W/"v1"` {
+			t.Errorf("want a gzipped response with a weak ETag, got %q and %q", resp.Header.Get("Content-Encoding"), resp.Header.Get("ETag"))
+		}
+
+		// The weak ETag still matches at the upstream.
+		resp, _ = get("/tagged.css", http.Header{"Accept-Encoding": {"gzip"}, "If-None-Match": {`W/"v1"`}})
+		if resp.StatusCode != http.StatusNotModified {
+			t.Errorf("want 304, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("generated weak ETag", func(t *testing.T) {
+		resp, body := get("/untagged.css", http.Header{})
+		etag := resp.Header.Get("ETag")
+		if resp.StatusCode != http.StatusOK || body != asset || !strings.HasPrefix(etag, `W/"`) {
+			t.Fatalf("want the asset with a weak ETag, got %d and %q", resp.StatusCode, etag)
+		}
+
+		resp, body = get("/untagged.css", http.Header{"If-None-Match": {etag}})
+		if resp.StatusCode != http.StatusNotModified || body != "" || resp.Header.Get("ETag") != etag {
+			t.Errorf("want 304 with ETag %s, got %d with %q", etag, resp.StatusCode, resp.Header.Get("ETag"))
+		}
+
+		resp, body = get("/untagged.css", http.Header{"If-None-Match": {`W/"other"`}})
+		if resp.StatusCode != http.StatusOK || body != asset {
+			t.Errorf("want the asset for another ETag, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("no-store responses aren't tagged", func(t *testing.T) {
+		resp, _ := get("/private.css", http.Header{})
+		if resp.Header.Get("ETag") != "" {
+			t.Errorf("want no ETag, got %q", resp.Header.Get("ETag"))
+		}
+	})
+}
+
+func TestETaggerMaxSize(t *testing.T) {
+	body := strings.Repeat("x", 100)
+	for _, contentLength := range []int64{100, -1} {
+		resp := &http.Response{
+			StatusCode:    http.StatusOK,
+			Header:        http.Header{},
+			Body:          io.NopCloser(strings.NewReader(body)),
+			ContentLength: contentLength,
+			Request:       httptest.NewRequest(http.MethodGet, "/", nil),
+		}
+		if err := NewETagger(10).ModifyResponse(resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Header.Get("ETag") != "" {
+			t.Errorf("length %d: want no ETag for large responses", contentLength)
+		}
+		if b, _ := io.ReadAll(resp.Body); string(b) != body {
+			t.Errorf("length %d: want the whole body, got %d bytes", contentLength, len(b))
+		}
+	}
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strings"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/authz"
+)
+
+// credentialHeaders aren't passed to the attributes program, the identity
+// of the request is passed as user instead.
+var credentialHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie"}
+
+// AttributesExecRequest is written to the stdin of the attributes program.
+type AttributesExecRequest struct {
+	Method string             `json:"method"`
+	Path   string             `json:"path"`
+	Query  url.Values         `json:"query,omitempty"`
+	Header http.Header        `json:"header,omitempty"`
+	User   AttributesExecUser `json:"user"`
+}
+
+// AttributesExecUser is the authenticated user of the request.
+type AttributesExecUser struct {
+	Name   string              `json:"name"`
+	UID    string              `json:"uid,omitempty"`
+	Groups []string            `json:"groups,omitempty"`
+	Extra  map[string][]string `json:"extra,omitempty"`
+}
+
+// AttributesExecResponse is read from the stdout of the attributes program.
+// The request must be authorized for all of the attributes.
+type AttributesExecResponse struct {
+	Attributes []AttributesExecRecord `json:"attributes"`
+}
+
+// AttributesExecRecord holds the attributes of a resource request, or of a
+// non-resource request with a path. The verb defaults to the verb of the
+// HTTP method.
+type AttributesExecRecord struct {
+	Verb            string `json:"verb,omitempty"`
+	Namespace       string `json:"namespace,omitempty"`
+	APIGroup        string `json:"apiGroup,omitempty"`
+	APIVersion      string `json:"apiVersion,omitempty"`
+	Resource        string `json:"resource,omitempty"`
+	Subresource     string `json:"subresource,omitempty"`
+	Name            string `json:"name,omitempty"`
+	ResourceRequest bool   `json:"resourceRequest,omitempty"`
+	Path            string `json:"path,omitempty"`
+}
+
+// execAttributes runs the attributes program for the request.
+func execAttributes(ctx context.Context, cfg *authz.AttributesExecConfig, u user.Info, r *http.Request, apiVerb string) ([]authorizer.Attributes, error) {
+	header := r.Header.Clone()
+	for _, name := range credentialHeaders {
+		header.Del(name)
+	}
+	in := AttributesExecRequest{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.Query(),
+		Header: header,
+	}
+	if u != nil {
+		in.User = AttributesExecUser{
+			Name:   u.GetName(),
+			UID:    u.GetUID(),
+			Groups: u.GetGroups(),
+			Extra:  u.GetExtra(),
+		}
+	}
+	stdin, err := json.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Timeout.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Timeout.Duration)
+		defer cancel()
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, cfg.Command[0], cfg.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("attributes program failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var out AttributesExecResponse
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		return nil, fmt.Errorf("failed to parse the output of the attributes program: %w", err)
+	}
+	if len(out.Attributes) == 0 {
+		return nil, errors.New("attributes program returned no attributes")
+	}
+
+	allAttrs := make([]authorizer.Attributes, 0, len(out.Attributes))
+	for _, rec := range out.Attributes {
+		if rec.ResourceRequest != (rec.Path == "") {
+			return nil, errors.New("attributes program returned attributes with both or neither of resourceRequest and path")
+		}
+		verb := rec.Verb
+		if verb == "" {
+			verb = apiVerb
+		}
+		allAttrs = append(allAttrs, authorizer.AttributesRecord{
+			User:            u,
+			Verb:            verb,
+			Namespace:       rec.Namespace,
+			APIGroup:        rec.APIGroup,
+			APIVersion:      rec.APIVersion,
+			Resource:        rec.Resource,
+			Subresource:     rec.Subresource,
+			Name:            rec.Name,
+			ResourceRequest: rec.ResourceRequest,
+			Path:            rec.Path,
+		})
+	}
+	return allAttrs, nil
+}
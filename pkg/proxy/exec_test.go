@@ -0,0 +1,118 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/authz"
+)
+
+func TestExecAttributes(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.json")
+
+	for _, tt := range []struct {
+		name      string
+		output    string
+		wantAttrs int
+	}{
+		{
+			name:      "resource and non-resource attributes",
+			output:    `{"attributes":[{"namespace":"team-a","resource":"pods","resourceRequest":true},{"verb":"list","path":"/metrics"}]}`,
+			wantAttrs: 2,
+		},
+		{
+			name:   "no attributes",
+			output: `{"attributes":[]}`,
+		},
+		{
+			name:   "attributes without path",
+			output: `{"attributes":[{"verb":"get"}]}`,
+		},
+		{
+			name:   "malformed output",
+			output: `attributes`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			script := filepath.Join(dir, "attributes.sh")
+			if err := os.WriteFile(script, []byte("#!/bin/sh\ncat > "+input+"\necho '"+tt.output+"'\n"), 0o700); err != nil {
+				t.Fatal(err)
+			}
+			getter := NewKubeRBACProxyAuthorizerAttributesGetter(&authz.Config{
+				AttributesExec: &authz.AttributesExecConfig{
+					Command: []string{script},
+					Timeout: metav1.Duration{Duration: 5 * time.Second},
+				},
+			})
+
+			req := httptest.NewRequest("GET", "/metrics?tenant=a", nil)
+			req.Header.Set("Authorization", "Bearer secret")
+			req.Header.Set("X-Tenant", "a")
+			attrs := getter.GetRequestAttributes(&user.DefaultInfo{Name: "alice", Groups: []string{"dev"}}, req)
+
+			if len(attrs) != tt.wantAttrs {
+				t.Fatalf("want %d attributes, got %v", tt.wantAttrs, attrs)
+			}
+			if tt.wantAttrs == 0 {
+				return
+			}
+			if attrs[0].GetVerb() != "get" || attrs[0].GetNamespace() != "team-a" || !attrs[0].IsResourceRequest() {
+				t.Errorf("unexpected resource attributes %+v", attrs[0])
+			}
+			if attrs[1].GetVerb() != "list" || attrs[1].GetPath() != "/metrics" || attrs[1].GetUser().GetName() != "alice" {
+				t.Errorf("unexpected non-resource attributes %+v", attrs[1])
+			}
+
+			b, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var in AttributesExecRequest
+			if err := json.Unmarshal(b, &in); err != nil {
+				t.Fatal(err)
+			}
+			if in.Method != "GET" || in.Path != "/metrics" || in.Query.Get("tenant") != "a" || in.User.Name != "alice" {
+				t.Errorf("unexpected input %+v", in)
+			}
+			if in.Header.Get("X-Tenant") != "a" || in.Header.Get("Authorization") != "" {
+				t.Errorf("want credentials to be removed from the headers, got %v", in.Header)
+			}
+		})
+	}
+
+	t.Run("timeout", func(t *testing.T) {
+		getter := NewKubeRBACProxyAuthorizerAttributesGetter(&authz.Config{
+			AttributesExec: &authz.AttributesExecConfig{
+				Command: []string{"sleep", "10"},
+				Timeout: metav1.Duration{Duration: 100 * time.Millisecond},
+			},
+		})
+		if attrs := getter.GetRequestAttributes(&user.DefaultInfo{Name: "alice"}, httptest.NewRequest("GET", "/", nil)); attrs != nil {
+			t.Errorf("want no attributes, got %v", attrs)
+		}
+	})
+}
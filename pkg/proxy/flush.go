@@ -0,0 +1,76 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"path"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// FlushIntervalConfig sets the flush interval of the reverse proxy for
+// requests, whose path matches one of the patterns. A negative interval
+// flushes immediately after each write, zero disables periodic flushing.
+type FlushIntervalConfig struct {
+	Paths    []string        `json:"paths,omitempty"`
+	Interval metav1.Duration `json:"interval"`
+}
+
+// ValidateFlushIntervals checks the path patterns.
+func ValidateFlushIntervals(configs []FlushIntervalConfig) error {
+	for i, config := range configs {
+		if len(config.Paths) == 0 {
+			return fmt.Errorf("flushIntervals[%d]: at least one path is required", i)
+		}
+		for _, pattern := range config.Paths {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("flushIntervals[%d]: invalid path %q: %w", i, pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+// WithFlushIntervals serves requests with a copy of the reverse proxy using
+// the flush interval of the first matching config, other requests with the
+// reverse proxy itself. The reverse proxy must not be modified afterwards.
+func WithFlushIntervals(rp *httputil.ReverseProxy, configs []FlushIntervalConfig) http.HandlerFunc {
+	if len(configs) == 0 {
+		return rp.ServeHTTP
+	}
+
+	proxies := make([]*httputil.ReverseProxy, 0, len(configs))
+	for _, config := range configs {
+		p := *rp
+		p.FlushInterval = config.Interval.Duration
+		proxies = append(proxies, &p)
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		for i, config := range configs {
+			if _, found := MatchPath(config.Paths, req.URL.Path); found {
+				proxies[i].ServeHTTP(w, req)
+				return
+			}
+		}
+
+		rp.ServeHTTP(w, req)
+	}
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestWithFlushIntervals(t *testing.T) {
+	release := make(chan struct{})
+
+	// The upstream announces the length, such that the reverse proxy
+	// doesn't flush immediately on its own.
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "12")
+		_, _ = w.Write([]byte("first line\n"))
+		w.(http.Flusher).Flush()
+		<-release
+		_, _ = w.Write([]byte("\n"))
+	}))
+	defer upstream.Close()
+
+	configs := []FlushIntervalConfig{
+		{Paths: []string{"/stream/*"}, Interval: metav1.Duration{Duration: -1}},
+	}
+	if err := ValidateFlushIntervals(configs); err != nil {
+		t.Fatal(err)
+	}
+	rp := httputil.NewSingleHostReverseProxy(testUpstreams(t, upstream.URL)[0])
+	proxy := httptest.NewServer(WithFlushIntervals(rp, configs))
+	defer proxy.Close()
+	// Unblocks the upstream before the servers wait for their requests.
+	defer close(release)
+
+	firstLine := func(path string) <-chan string {
+		lines := make(chan string, 1)
+		go func() {
+			resp, err := http.Get(proxy.URL + path)
+			if err != nil {
+				close(lines)
+				return
+			}
+			defer resp.Body.Close()
+			line, _ := bufio.NewReader(resp.Body).ReadString('\n')
+			lines <- line
+		}()
+		return lines
+	}
+
+	select {
+	case line := <-firstLine("/stream/logs"):
+		if line != "first line\n" {
+			t.Errorf("want first line, got %q", line)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("want first line to be flushed immediately")
+	}
+
+	select {
+	case line := <-firstLine("/metrics"):
+		t.Errorf("want response to be buffered, got %q", line)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestValidateFlushIntervals(t *testing.T) {
+	for _, config := range []FlushIntervalConfig{
+		{},
+		{Paths: []string{"[]a]"}},
+	} {
+		if err := ValidateFlushIntervals([]FlushIntervalConfig{config}); err == nil {
+			t.Errorf("want error for %+v", config)
+		}
+	}
+}
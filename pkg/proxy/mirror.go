@@ -0,0 +1,149 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"time"
+
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+)
+
+// Results of mirrored requests.
+const (
+	MirrorResultSent    = "sent"
+	MirrorResultFailed  = "failed"
+	MirrorResultDropped = "dropped"
+)
+
+// maxMirroredRequests limits the mirrored requests in flight, such that a
+// slow mirror doesn't pile up goroutines and connections.
+const maxMirroredRequests = 100
+
+var mirroredRequests = metrics.NewCounterVec(
+	&metrics.CounterOpts{
+		Namespace:      "kube_rbac_proxy",
+		Subsystem:      "upstream",
+		Name:           "mirrored_requests_total",
+		Help:           "Number of requests mirrored to the secondary upstream by result.",
+		StabilityLevel: metrics.ALPHA,
+	},
+	[]string{"result"},
+)
+
+func init() {
+	legacyregistry.MustRegister(mirroredRequests)
+}
+
+// Mirror sends copies of a share of the authenticated upstream requests to a
+// secondary upstream, e.g. to validate a new version of the upstream with
+// production traffic. The copies are sent in the background and their
+// responses are discarded, they never affect the response to the client.
+type Mirror struct {
+	target    *url.URL
+	rate      float64
+	timeout   time.Duration
+	transport http.RoundTripper
+	inFlight  chan struct{}
+	random    func() float64
+}
+
+// NewMirror creates a Mirror sending a rate between 0 and 1 of the requests
+// to the scheme and host of target with transport, each within the timeout.
+func NewMirror(target *url.URL, rate float64, timeout time.Duration, transport http.RoundTripper) *Mirror {
+	return &Mirror{
+		target:    target,
+		rate:      rate,
+		timeout:   timeout,
+		transport: transport,
+		inFlight:  make(chan struct{}, maxMirroredRequests),
+		random:    rand.Float64,
+	}
+}
+
+// RoundTripper mirrors requests before passing them to rt. Requests for
+// ignored paths, upgrade requests and requests whose body can't be replayed
+// with GetBody aren't mirrored.
+func (m *Mirror) RoundTripper(rt http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if m.rate > 0 && m.random() < m.rate {
+			m.mirror(req)
+		}
+		return rt.RoundTrip(req)
+	})
+}
+
+func (m *Mirror) mirror(req *http.Request) {
+	if _, ok := request.UserFrom(req.Context()); !ok {
+		return
+	}
+	if req.Header.Get("Upgrade") != "" {
+		return
+	}
+
+	// The copy outlives the request, but not the timeout.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(req.Context()), m.timeout)
+	mirrored := req.Clone(ctx)
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			cancel()
+			return
+		}
+		body, err := req.GetBody()
+		if err != nil {
+			cancel()
+			return
+		}
+		mirrored.Body = body
+	}
+	mirrored.URL.Scheme = m.target.Scheme
+	mirrored.URL.Host = m.target.Host
+	mirrored.Host = m.target.Host
+
+	select {
+	case m.inFlight <- struct{}{}:
+	default:
+		cancel()
+		if mirrored.Body != nil {
+			mirrored.Body.Close()
+		}
+		mirroredRequests.WithLabelValues(MirrorResultDropped).Inc()
+		return
+	}
+
+	go func() {
+		defer func() { <-m.inFlight }()
+		defer cancel()
+
+		resp, err := m.transport.RoundTrip(mirrored)
+		if err != nil {
+			klog.FromContext(ctx).V(4).Info("Mirrored request failed", "path", mirrored.URL.Path, "err", err)
+			mirroredRequests.WithLabelValues(MirrorResultFailed).Inc()
+			return
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		mirroredRequests.WithLabelValues(MirrorResultSent).Inc()
+	}()
+}
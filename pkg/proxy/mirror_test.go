@@ -0,0 +1,152 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+func TestMirror(t *testing.T) {
+	type mirrored struct {
+		host, path, body string
+	}
+	received := make(chan mirrored, 1)
+	mirrorServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		b, _ := io.ReadAll(req.Body)
+		received <- mirrored{host: req.Host, path: req.URL.Path, body: string(b)}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer mirrorServer.Close()
+	mirrorURL, err := url.Parse(mirrorServer.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name       string
+		sampled    bool
+		anonymous  bool
+		body       string
+		buffered   bool
+		upgrade    bool
+		wantMirror bool
+	}{
+		{
+			name:       "sampled",
+			sampled:    true,
+			wantMirror: true,
+		},
+		{
+			name: "not sampled",
+		},
+		{
+			name:       "buffered body",
+			sampled:    true,
+			body:       "query",
+			buffered:   true,
+			wantMirror: true,
+		},
+		{
+			name:    "streamed body",
+			sampled: true,
+			body:    "query",
+		},
+		{
+			name:      "ignored path",
+			sampled:   true,
+			anonymous: true,
+		},
+		{
+			name:    "upgrade",
+			sampled: true,
+			upgrade: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMirror(mirrorURL, 0.5, time.Minute, http.DefaultTransport)
+			m.random = func() float64 {
+				if tt.sampled {
+					return 0.1
+				}
+				return 0.9
+			}
+
+			var upstreamBody string
+			rt := m.RoundTripper(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				if req.Body != nil {
+					b, _ := io.ReadAll(req.Body)
+					upstreamBody = string(b)
+				}
+				return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+			}))
+
+			var body io.Reader
+			if tt.body != "" {
+				body = io.NopCloser(strings.NewReader(tt.body))
+			}
+			req, err := http.NewRequest(http.MethodPost, "http://upstream/api/query", body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.buffered {
+				req.GetBody = func() (io.ReadCloser, error) {
+					return io.NopCloser(bytes.NewReader([]byte(tt.body))), nil
+				}
+			}
+			if tt.upgrade {
+				req.Header.Set("Connection", "Upgrade")
+				req.Header.Set("Upgrade", "websocket")
+			}
+			if !tt.anonymous {
+				req = req.WithContext(request.WithUser(req.Context(), &user.DefaultInfo{Name: "alice"}))
+			}
+
+			resp, err := rt.RoundTrip(req)
+			if err != nil || resp.StatusCode != http.StatusOK {
+				t.Fatalf("want the upstream response, got %v", err)
+			}
+			if upstreamBody != tt.body {
+				t.Errorf("want upstream body %q, got %q", tt.body, upstreamBody)
+			}
+
+			select {
+			case got := <-received:
+				if !tt.wantMirror {
+					t.Fatalf("want no mirrored request, got %+v", got)
+				}
+				want := mirrored{host: mirrorURL.Host, path: "/api/query", body: tt.body}
+				if got != want {
+					t.Errorf("want mirrored request %+v, got %+v", want, got)
+				}
+			case <-time.After(100 * time.Millisecond):
+				if tt.wantMirror {
+					t.Fatal("want a mirrored request, got none")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import "path"
+
+// MatchPath returns the first of the patterns matching the path. The
+// patterns use the syntax of path.Match and must be validated upfront,
+// malformed patterns never match.
+func MatchPath(patterns []string, p string) (string, bool) {
+	for _, pattern := range patterns {
+		if found, _ := path.Match(pattern, p); found {
+			return pattern, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import "testing"
+
+func TestMatchPath(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		patterns    []string
+		path        string
+		wantPattern string
+		wantFound   bool
+	}{
+		{
+			name: "no patterns",
+			path: "/metrics",
+		},
+		{
+			name:        "first match",
+			patterns:    []string{"/healthz", "/api/*", "/api/v1"},
+			path:        "/api/v1",
+			wantPattern: "/api/*",
+			wantFound:   true,
+		},
+		{
+			name:     "no match",
+			patterns: []string{"/api/*"},
+			path:     "/api/v1/pods",
+		},
+		{
+			name:     "malformed pattern",
+			patterns: []string{"/api/["},
+			path:     "/api/[",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			pattern, found := MatchPath(tt.patterns, tt.path)
+			if pattern != tt.wantPattern || found != tt.wantFound {
+				t.Errorf("want %q, %t, got %q, %t", tt.wantPattern, tt.wantFound, pattern, found)
+			}
+		})
+	}
+}
@@ -18,12 +18,17 @@ package proxy
 
 import (
 	"bytes"
+	"fmt"
 	"net/http"
 	"net/textproto"
+	"strconv"
+	"strings"
 	"text/template"
 
+	"github.com/brancz/kube-rbac-proxy/pkg/audit"
 	"github.com/brancz/kube-rbac-proxy/pkg/authn"
 	"github.com/brancz/kube-rbac-proxy/pkg/authz"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/apiserver/pkg/authorization/authorizer"
 	"k8s.io/klog/v2"
@@ -36,40 +41,71 @@ type Config struct {
 }
 
 func NewKubeRBACProxyAuthorizerAttributesGetter(authzConfig *authz.Config) *krpAuthorizerAttributesGetter {
-	return &krpAuthorizerAttributesGetter{authzConfig}
+	getter := &krpAuthorizerAttributesGetter{authzConfig: authzConfig}
+
+	if authzConfig.Rewrites != nil && authzConfig.Rewrites.Audit != nil {
+		redactor, err := audit.NewRedactor(authzConfig.Rewrites.Audit.Redaction, authzConfig.Rewrites.Audit.TruncateLength)
+		if err != nil {
+			// The configuration is validated on startup, hashing is the
+			// safe choice for anything that slipped through.
+			klog.ErrorS(err, "Invalid rewrite audit configuration, hashing values")
+			redactor, _ = audit.NewRedactor(audit.RedactionHash, 0)
+		}
+		getter.rewriteRedactor = redactor
+	}
+
+	getter.templates = parseTemplates(authzConfig)
+
+	return getter
 }
 
 type krpAuthorizerAttributesGetter struct {
 	authzConfig *authz.Config
+
+	// rewriteRedactor is set, if rewrite parameter values are audited.
+	rewriteRedactor *audit.Redactor
+	// templates holds the parsed templates of the resource attributes by
+	// their source, such that they are parsed once.
+	templates map[string]*template.Template
 }
 
 // GetRequestAttributes populates authorizer attributes for the requests to kube-rbac-proxy.
+// Requests must be authorized for all of the returned attributes, none are
+// returned for malformed requests.
 func (n krpAuthorizerAttributesGetter) GetRequestAttributes(u user.Info, r *http.Request) []authorizer.Attributes {
-	apiVerb := "*"
-	switch r.Method {
-	case "POST":
-		apiVerb = "create"
-	case "GET":
-		apiVerb = "get"
-	case "PUT":
-		apiVerb = "update"
-	case "PATCH":
-		apiVerb = "patch"
-	case "DELETE":
-		apiVerb = "delete"
-	}
+	apiVerb := VerbForRequest(r, n.authzConfig)
+	resourceAttributesList := n.authzConfig.ResourceAttributesFor(r.Method)
 
 	var allAttrs []authorizer.Attributes
 
 	defer func() {
-		for attrs := range allAttrs {
-			klog.V(5).Infof("kube-rbac-proxy request attributes: attrs=%#+v", attrs)
+		for _, attrs := range allAttrs {
+			klog.FromContext(r.Context()).V(5).Info("Request attributes", "attrs", attrs)
 		}
 	}()
 
-	if n.authzConfig.ResourceAttributes == nil {
+	if execCfg := n.authzConfig.AttributesExec; execCfg != nil {
+		attrs, err := execAttributes(r.Context(), execCfg, u, r, apiVerb)
+		if err != nil {
+			klog.FromContext(r.Context()).Error(err, "Failed to generate the request attributes")
+			return nil
+		}
+		allAttrs = attrs
+		return allAttrs
+	}
+
+	if n.authzConfig.APIServerPaths {
+		attrs, ok := apiPathsAttributes(u, r)
+		if !ok {
+			return nil
+		}
+		allAttrs = append(allAttrs, attrs)
+		return allAttrs
+	}
+
+	if len(resourceAttributesList) == 0 {
 		// Default attributes mirror the API attributes that would allow this access to kube-rbac-proxy
-		allAttrs := append(allAttrs, authorizer.AttributesRecord{
+		allAttrs = append(allAttrs, authorizer.AttributesRecord{
 			User:            u,
 			Verb:            apiVerb,
 			Namespace:       "",
@@ -84,62 +120,259 @@ func (n krpAuthorizerAttributesGetter) GetRequestAttributes(u user.Info, r *http
 		return allAttrs
 	}
 
-	if n.authzConfig.Rewrites == nil {
-		allAttrs := append(allAttrs, authorizer.AttributesRecord{
-			User:            u,
-			Verb:            apiVerb,
-			Namespace:       n.authzConfig.ResourceAttributes.Namespace,
-			APIGroup:        n.authzConfig.ResourceAttributes.APIGroup,
-			APIVersion:      n.authzConfig.ResourceAttributes.APIVersion,
-			Resource:        n.authzConfig.ResourceAttributes.Resource,
-			Subresource:     n.authzConfig.ResourceAttributes.Subresource,
-			Name:            n.authzConfig.ResourceAttributes.Name,
-			ResourceRequest: true,
-		})
-		return allAttrs
+	var params []string
+	if n.authzConfig.Rewrites != nil {
+		params = n.rewriteParams(r)
+		if len(params) == 0 {
+			return nil
+		}
+	}
+
+	data := newTemplateData(u, r, n.authzConfig.TemplateHeaders)
+	for _, resourceAttributes := range resourceAttributesList {
+		attrs, ok := n.resourceAttributes(u, r, apiVerb, resourceAttributes, data, params)
+		if !ok {
+			allAttrs = nil
+			return nil
+		}
+		allAttrs = append(allAttrs, attrs...)
+	}
+	return allAttrs
+}
+
+// rewriteParams returns the rewrite values of the request, which are logged
+// if rewrites are audited.
+func (n krpAuthorizerAttributesGetter) rewriteParams(r *http.Request) []string {
+	params := rewriteValues(n.authzConfig.Rewrites, r)
+
+	if len(params) > 0 && n.rewriteRedactor != nil {
+		values := make([]string, 0, len(params))
+		for _, param := range params {
+			values = append(values, n.rewriteRedactor.Redact(param))
+		}
+		klog.FromContext(r.Context()).Info("Rewriting SubjectAccessReview", "method", r.Method, "path", r.URL.Path, "values", values)
 	}
 
+	return params
+}
+
+// rewriteValues returns the values of the rewrite query parameter and HTTP
+// header of the request.
+func rewriteValues(rewrites *authz.SubjectAccessReviewRewrites, r *http.Request) []string {
 	params := []string{}
-	if n.authzConfig.Rewrites.ByQueryParameter != nil && n.authzConfig.Rewrites.ByQueryParameter.Name != "" {
-		if ps, ok := r.URL.Query()[n.authzConfig.Rewrites.ByQueryParameter.Name]; ok {
+	if rewrites.ByQueryParameter != nil && rewrites.ByQueryParameter.Name != "" {
+		if ps, ok := r.URL.Query()[rewrites.ByQueryParameter.Name]; ok {
 			params = append(params, ps...)
 		}
 	}
-	if n.authzConfig.Rewrites.ByHTTPHeader != nil && n.authzConfig.Rewrites.ByHTTPHeader.Name != "" {
+	if rewrites.ByHTTPHeader != nil && rewrites.ByHTTPHeader.Name != "" {
 		mimeHeader := textproto.MIMEHeader(r.Header)
-		mimeKey := textproto.CanonicalMIMEHeaderKey(n.authzConfig.Rewrites.ByHTTPHeader.Name)
+		mimeKey := textproto.CanonicalMIMEHeaderKey(rewrites.ByHTTPHeader.Name)
 		if ps, ok := mimeHeader[mimeKey]; ok {
 			params = append(params, ps...)
 		}
 	}
+	return params
+}
 
-	if len(params) == 0 {
-		return allAttrs
+// resourceAttributes returns the attributes of one of the configured resource
+// attributes, once per rewrite parameter if rewrites are configured. It
+// returns false, if the request lacks a valid namespace header or a template
+// fails to execute for the request.
+func (n krpAuthorizerAttributesGetter) resourceAttributes(u user.Info, r *http.Request, apiVerb string, resourceAttributes *authz.ResourceAttributes, data TemplateData, params []string) ([]authorizer.Attributes, bool) {
+	namespace := resourceAttributes.Namespace
+	if nsFrom := resourceAttributes.NamespaceFrom; nsFrom != nil && nsFrom.HTTPHeader != "" {
+		// The header is passed on to the upstream as it is authorized,
+		// several values could be read differently by the upstream.
+		values := r.Header.Values(nsFrom.HTTPHeader)
+		if len(values) != 1 || len(validation.IsDNS1123Label(values[0])) > 0 {
+			klog.FromContext(r.Context()).V(2).Info("Namespace header missing or invalid", "header", nsFrom.HTTPHeader)
+			return nil, false
+		}
+		namespace = values[0]
 	}
 
-	for _, param := range params {
+	expanded := func(data TemplateData) (authorizer.AttributesRecord, error) {
 		attrs := authorizer.AttributesRecord{
 			User:            u,
 			Verb:            apiVerb,
-			Namespace:       templateWithValue(n.authzConfig.ResourceAttributes.Namespace, param),
-			APIGroup:        templateWithValue(n.authzConfig.ResourceAttributes.APIGroup, param),
-			APIVersion:      templateWithValue(n.authzConfig.ResourceAttributes.APIVersion, param),
-			Resource:        templateWithValue(n.authzConfig.ResourceAttributes.Resource, param),
-			Subresource:     templateWithValue(n.authzConfig.ResourceAttributes.Subresource, param),
-			Name:            templateWithValue(n.authzConfig.ResourceAttributes.Name, param),
 			ResourceRequest: true,
 		}
-		allAttrs = append(allAttrs, attrs)
+		for _, field := range []struct {
+			value string
+			into  *string
+		}{
+			{namespace, &attrs.Namespace},
+			{resourceAttributes.APIGroup, &attrs.APIGroup},
+			{resourceAttributes.APIVersion, &attrs.APIVersion},
+			{resourceAttributes.Resource, &attrs.Resource},
+			{resourceAttributes.Subresource, &attrs.Subresource},
+			{resourceAttributes.Name, &attrs.Name},
+		} {
+			value, err := n.expandTemplate(field.value, data)
+			if err != nil {
+				return attrs, err
+			}
+			*field.into = value
+		}
+		return attrs, nil
 	}
-	return allAttrs
+
+	if n.authzConfig.Rewrites == nil {
+		attrs, err := expanded(data)
+		if err != nil {
+			klog.FromContext(r.Context()).V(2).Info("Resource attributes template failed", "err", err)
+			return nil, false
+		}
+		return []authorizer.Attributes{attrs}, true
+	}
+
+	allAttrs := make([]authorizer.Attributes, 0, len(params))
+	for _, param := range params {
+		data.Value = param
+		attrs, err := expanded(data)
+		if err != nil {
+			klog.FromContext(r.Context()).V(2).Info("Resource attributes template failed", "err", err)
+			return nil, false
+		}
+		allAttrs = append(allAttrs, RewrittenAttributes{AttributesRecord: attrs, Value: param})
+	}
+	return allAttrs, true
+}
+
+// TemplateData is the data the templates of the resource attributes are
+// executed with, e.g. {{.Value}} or {{index .Path 0}}.
+type TemplateData struct {
+	// Value is the rewrite value, empty without rewrites.
+	Value string
+	User  TemplateUser
+	// Path holds the segments of the request path, e.g. [api v1 pods] for
+	// /api/v1/pods.
+	Path []string
+	// Header holds the headers of the templateHeaders by canonical name,
+	// e.g. {{index .Header "X-Tenant"}}. Headers with several values are
+	// omitted, as the upstream could read another one.
+	Header map[string]string
+}
+
+// TemplateUser is the authenticated user of the request. Groups are a set,
+// such that templates can test for a group, e.g. {{if .User.Groups.admins}}.
+type TemplateUser struct {
+	Name   string
+	Groups map[string]bool
+}
+
+func newTemplateData(u user.Info, r *http.Request, headers []string) TemplateData {
+	data := TemplateData{
+		User:   TemplateUser{Groups: map[string]bool{}},
+		Path:   strings.Split(strings.Trim(r.URL.Path, "/"), "/"),
+		Header: map[string]string{},
+	}
+	if u != nil {
+		data.User.Name = u.GetName()
+		for _, g := range u.GetGroups() {
+			data.User.Groups[g] = true
+		}
+	}
+	for _, name := range headers {
+		if values := r.Header.Values(name); len(values) == 1 {
+			data.Header[textproto.CanonicalMIMEHeaderKey(name)] = values[0]
+		}
+	}
+	return data
+}
+
+// RewrittenAttributes are attributes rewritten with the value of a query
+// parameter or an HTTP header.
+type RewrittenAttributes struct {
+	authorizer.AttributesRecord
+	// Value is the parameter value the attributes were rewritten with.
+	Value string
+}
+
+// VerbForMethod maps the HTTP method of a request to the verb used for
+// authorization. Custom mappings take precedence over the built-in ones.
+// Methods without any mapping fall back to the "*" verb.
+func VerbForMethod(method string, custom map[string]string) string {
+	if verb, ok := custom[method]; ok {
+		return verb
+	}
+
+	switch method {
+	case "POST", "MKCOL":
+		return "create"
+	case "GET", "PROPFIND", "REPORT":
+		return "get"
+	case "PUT":
+		return "update"
+	case "PATCH":
+		return "patch"
+	case "DELETE":
+		return "delete"
+	}
+
+	return "*"
+}
+
+// VerbForRequest maps the request to the verb used for authorization, which
+// is "watch" for requests mapped to "get" with a true watch query parameter.
+func VerbForRequest(r *http.Request, cfg *authz.Config) string {
+	verb := VerbForMethod(r.Method, cfg.MethodVerbs)
+	if verb != "get" || len(cfg.WatchQueryParameters) == 0 {
+		return verb
+	}
+
+	query := r.URL.Query()
+	for _, name := range cfg.WatchQueryParameters {
+		for _, value := range query[name] {
+			if watch, err := strconv.ParseBool(value); err == nil && watch {
+				return "watch"
+			}
+		}
+	}
+	return verb
 }
 
-func templateWithValue(templateString, value string) string {
-	tmpl, _ := template.New("valueTemplate").Parse(templateString)
+// ParseTemplate parses an attribute template.
+func ParseTemplate(s string) (*template.Template, error) {
+	return template.New("valueTemplate").Parse(s)
+}
+
+// parseTemplates parses the templates of all resource attributes. The
+// configuration is validated on startup, templates failing to parse are left
+// out, such that the requests using them are rejected.
+func parseTemplates(cfg *authz.Config) map[string]*template.Template {
+	templates := map[string]*template.Template{}
+	for _, attrs := range cfg.AllResourceAttributes() {
+		for _, s := range []string{attrs.Namespace, attrs.APIGroup, attrs.APIVersion, attrs.Resource, attrs.Subresource, attrs.Name} {
+			if _, ok := templates[s]; ok || !strings.Contains(s, "{{") {
+				continue
+			}
+			tmpl, err := ParseTemplate(s)
+			if err != nil {
+				klog.ErrorS(err, "Invalid resource attributes template, failing requests", "template", s)
+				continue
+			}
+			templates[s] = tmpl
+		}
+	}
+	return templates
+}
+
+// expandTemplate executes the attribute template. Templates failing to
+// execute, e.g. indexing beyond the path, fail the request rather than
+// expanding to a value that was never configured.
+func (n krpAuthorizerAttributesGetter) expandTemplate(s string, data TemplateData) (string, error) {
+	if !strings.Contains(s, "{{") {
+		return s, nil
+	}
+	tmpl, ok := n.templates[s]
+	if !ok {
+		return "", fmt.Errorf("template %q was not parsed", s)
+	}
 	out := bytes.NewBuffer(nil)
-	err := tmpl.Execute(out, struct{ Value string }{Value: value})
-	if err != nil {
-		return ""
+	if err := tmpl.Execute(out, data); err != nil {
+		return "", err
 	}
-	return out.String()
+	return out.String(), nil
 }
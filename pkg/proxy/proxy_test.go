@@ -23,6 +23,7 @@ import (
 
 	"github.com/brancz/kube-rbac-proxy/pkg/authz"
 	"github.com/google/go-cmp/cmp"
+	"k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/apiserver/pkg/authorization/authorizer"
 )
 
@@ -54,7 +55,7 @@ func TestGeneratingAuthorizerAttributes(t *testing.T) {
 		},
 		{
 			"without rewrites config",
-			&authz.Config{ResourceAttributes: &authz.ResourceAttributes{Namespace: "tenant1", APIVersion: "v1", Resource: "namespace", Subresource: "metrics"}},
+			&authz.Config{ResourceAttributes: authz.ResourceAttributesList{{Namespace: "tenant1", APIVersion: "v1", Resource: "namespace", Subresource: "metrics"}}},
 			createRequest(nil, nil),
 			[]authorizer.Attributes{
 				authorizer.AttributesRecord{
@@ -71,12 +72,15 @@ func TestGeneratingAuthorizerAttributes(t *testing.T) {
 			},
 		},
 		{
-			"with query param rewrites config",
+			"with method resource attributes",
 			&authz.Config{
-				Rewrites:           &authz.SubjectAccessReviewRewrites{ByQueryParameter: &authz.QueryParameterRewriteConfig{Name: "namespace"}},
-				ResourceAttributes: &authz.ResourceAttributes{Namespace: "{{ .Value }}", APIVersion: "v1", Resource: "namespace", Subresource: "metrics"},
+				ResourceAttributes: authz.ResourceAttributesList{{Namespace: "tenant1", APIVersion: "v1", Resource: "services", Subresource: "proxy"}},
+				MethodResourceAttributes: map[string]authz.ResourceAttributesList{
+					"GET":  {{Namespace: "tenant1", APIVersion: "v1", Resource: "pods", Subresource: "metrics"}},
+					"POST": {{Namespace: "tenant1", APIVersion: "v1", Resource: "pods", Subresource: "debug"}},
+				},
 			},
-			createRequest(map[string][]string{"namespace": {"tenant1"}}, nil),
+			createRequest(nil, nil),
 			[]authorizer.Attributes{
 				authorizer.AttributesRecord{
 					User:            nil,
@@ -84,18 +88,65 @@ func TestGeneratingAuthorizerAttributes(t *testing.T) {
 					Namespace:       "tenant1",
 					APIGroup:        "",
 					APIVersion:      "v1",
-					Resource:        "namespace",
+					Resource:        "pods",
 					Subresource:     "metrics",
 					Name:            "",
 					ResourceRequest: true,
 				},
 			},
 		},
+		{
+			"with resource attributes of other methods",
+			&authz.Config{
+				ResourceAttributes: authz.ResourceAttributesList{{Namespace: "tenant1", APIVersion: "v1", Resource: "services", Subresource: "proxy"}},
+				MethodResourceAttributes: map[string]authz.ResourceAttributesList{
+					"POST": {{Namespace: "tenant1", APIVersion: "v1", Resource: "pods", Subresource: "debug"}},
+				},
+			},
+			createRequest(nil, nil),
+			[]authorizer.Attributes{
+				authorizer.AttributesRecord{
+					User:            nil,
+					Verb:            "get",
+					Namespace:       "tenant1",
+					APIGroup:        "",
+					APIVersion:      "v1",
+					Resource:        "services",
+					Subresource:     "proxy",
+					Name:            "",
+					ResourceRequest: true,
+				},
+			},
+		},
+		{
+			"with query param rewrites config",
+			&authz.Config{
+				Rewrites:           &authz.SubjectAccessReviewRewrites{ByQueryParameter: &authz.QueryParameterRewriteConfig{Name: "namespace"}},
+				ResourceAttributes: authz.ResourceAttributesList{{Namespace: "{{ .Value }}", APIVersion: "v1", Resource: "namespace", Subresource: "metrics"}},
+			},
+			createRequest(map[string][]string{"namespace": {"tenant1"}}, nil),
+			[]authorizer.Attributes{
+				RewrittenAttributes{
+					AttributesRecord: authorizer.AttributesRecord{
+						User:            nil,
+						Verb:            "get",
+						Namespace:       "tenant1",
+						APIGroup:        "",
+						APIVersion:      "v1",
+						Resource:        "namespace",
+						Subresource:     "metrics",
+						Name:            "",
+						ResourceRequest: true,
+					},
+					Value: "tenant1",
+				},
+			},
+		},
 		{
 			"with query param rewrites config but missing URL query",
 			&authz.Config{
 				Rewrites:           &authz.SubjectAccessReviewRewrites{ByQueryParameter: &authz.QueryParameterRewriteConfig{Name: "namespace"}},
-				ResourceAttributes: &authz.ResourceAttributes{Namespace: "{{ .Value }}", APIVersion: "v1", Resource: "namespace", Subresource: "metrics"},
+				ResourceAttributes: authz.ResourceAttributesList{{Namespace: "{{ .Value }}", APIVersion: "v1", Resource: "namespace", Subresource: "metrics"}},
 			},
 			createRequest(nil, nil),
 			nil,
@@ -104,20 +155,23 @@ func TestGeneratingAuthorizerAttributes(t *testing.T) {
 			"with http header rewrites config",
 			&authz.Config{
 				Rewrites:           &authz.SubjectAccessReviewRewrites{ByHTTPHeader: &authz.HTTPHeaderRewriteConfig{Name: "namespace"}},
-				ResourceAttributes: &authz.ResourceAttributes{Namespace: "{{ .Value }}", APIVersion: "v1", Resource: "namespace", Subresource: "metrics"},
+				ResourceAttributes: authz.ResourceAttributesList{{Namespace: "{{ .Value }}", APIVersion: "v1", Resource: "namespace", Subresource: "metrics"}},
 			},
 			createRequest(nil, map[string][]string{"namespace": {"tenant1"}}),
 			[]authorizer.Attributes{
-				authorizer.AttributesRecord{
-					User:            nil,
-					Verb:            "get",
-					Namespace:       "tenant1",
-					APIGroup:        "",
-					APIVersion:      "v1",
-					Resource:        "namespace",
-					Subresource:     "metrics",
-					Name:            "",
-					ResourceRequest: true,
+				RewrittenAttributes{
+					AttributesRecord: authorizer.AttributesRecord{
+						User:            nil,
+						Verb:            "get",
+						Namespace:       "tenant1",
+						APIGroup:        "",
+						APIVersion:      "v1",
+						Resource:        "namespace",
+						Subresource:     "metrics",
+						Name:            "",
+						ResourceRequest: true,
+					},
+					Value: "tenant1",
 				},
 			},
 		},
@@ -125,31 +179,37 @@ func TestGeneratingAuthorizerAttributes(t *testing.T) {
 			"with http header rewrites config and additional header",
 			&authz.Config{
 				Rewrites:           &authz.SubjectAccessReviewRewrites{ByHTTPHeader: &authz.HTTPHeaderRewriteConfig{Name: "namespace"}},
-				ResourceAttributes: &authz.ResourceAttributes{Namespace: "{{ .Value }}", APIVersion: "v1", Resource: "namespace", Subresource: "metrics"},
+				ResourceAttributes: authz.ResourceAttributesList{{Namespace: "{{ .Value }}", APIVersion: "v1", Resource: "namespace", Subresource: "metrics"}},
 			},
 			createRequest(nil, map[string][]string{"namespace": {"tenant1", "tenant2"}}),
 			[]authorizer.Attributes{
-				authorizer.AttributesRecord{
-					User:            nil,
-					Verb:            "get",
-					Namespace:       "tenant1",
-					APIGroup:        "",
-					APIVersion:      "v1",
-					Resource:        "namespace",
-					Subresource:     "metrics",
-					Name:            "",
-					ResourceRequest: true,
+				RewrittenAttributes{
+					AttributesRecord: authorizer.AttributesRecord{
+						User:            nil,
+						Verb:            "get",
+						Namespace:       "tenant1",
+						APIGroup:        "",
+						APIVersion:      "v1",
+						Resource:        "namespace",
+						Subresource:     "metrics",
+						Name:            "",
+						ResourceRequest: true,
+					},
+					Value: "tenant1",
 				},
-				authorizer.AttributesRecord{
-					User:            nil,
-					Verb:            "get",
-					Namespace:       "tenant2",
-					APIGroup:        "",
-					APIVersion:      "v1",
-					Resource:        "namespace",
-					Subresource:     "metrics",
-					Name:            "",
-					ResourceRequest: true,
+				RewrittenAttributes{
+					AttributesRecord: authorizer.AttributesRecord{
+						User:            nil,
+						Verb:            "get",
+						Namespace:       "tenant2",
+						APIGroup:        "",
+						APIVersion:      "v1",
+						Resource:        "namespace",
+						Subresource:     "metrics",
+						Name:            "",
+						ResourceRequest: true,
+					},
+					Value: "tenant2",
 				},
 			},
 		},
@@ -157,7 +217,7 @@ func TestGeneratingAuthorizerAttributes(t *testing.T) {
 			"with http header rewrites config but missing header",
 			&authz.Config{
 				Rewrites:           &authz.SubjectAccessReviewRewrites{ByQueryParameter: &authz.QueryParameterRewriteConfig{Name: "namespace"}},
-				ResourceAttributes: &authz.ResourceAttributes{Namespace: "{{ .Value }}", APIVersion: "v1", Resource: "namespace", Subresource: "metrics"},
+				ResourceAttributes: authz.ResourceAttributesList{{Namespace: "{{ .Value }}", APIVersion: "v1", Resource: "namespace", Subresource: "metrics"}},
 			},
 			createRequest(nil, nil),
 			nil,
@@ -169,12 +229,54 @@ func TestGeneratingAuthorizerAttributes(t *testing.T) {
 					ByHTTPHeader:     &authz.HTTPHeaderRewriteConfig{Name: "namespace"},
 					ByQueryParameter: &authz.QueryParameterRewriteConfig{Name: "namespace"},
 				},
-				ResourceAttributes: &authz.ResourceAttributes{Namespace: "{{ .Value }}", APIVersion: "v1", Resource: "namespace", Subresource: "metrics"},
+				ResourceAttributes: authz.ResourceAttributesList{{Namespace: "{{ .Value }}", APIVersion: "v1", Resource: "namespace", Subresource: "metrics"}},
 			},
 			createRequest(
 				map[string][]string{"namespace": {"tenant1"}},
 				map[string][]string{"namespace": {"tenant2"}},
 			),
+			[]authorizer.Attributes{
+				RewrittenAttributes{
+					AttributesRecord: authorizer.AttributesRecord{
+						User:            nil,
+						Verb:            "get",
+						Namespace:       "tenant1",
+						APIGroup:        "",
+						APIVersion:      "v1",
+						Resource:        "namespace",
+						Subresource:     "metrics",
+						Name:            "",
+						ResourceRequest: true,
+					},
+					Value: "tenant1",
+				},
+				RewrittenAttributes{
+					AttributesRecord: authorizer.AttributesRecord{
+						User:            nil,
+						Verb:            "get",
+						Namespace:       "tenant2",
+						APIGroup:        "",
+						APIVersion:      "v1",
+						Resource:        "namespace",
+						Subresource:     "metrics",
+						Name:            "",
+						ResourceRequest: true,
+					},
+					Value: "tenant2",
+				},
+			},
+		},
+		{
+			"with namespace from http header",
+			&authz.Config{
+				ResourceAttributes: authz.ResourceAttributesList{{
+					NamespaceFrom: &authz.NamespaceSource{HTTPHeader: "x-namespace"},
+					APIVersion:    "v1",
+					Resource:      "namespace",
+					Subresource:   "metrics",
+				}},
+			},
+			createRequest(nil, map[string][]string{"x-namespace": {"tenant1"}}),
 			[]authorizer.Attributes{
 				authorizer.AttributesRecord{
 					User:            nil,
@@ -187,25 +289,92 @@ func TestGeneratingAuthorizerAttributes(t *testing.T) {
 					Name:            "",
 					ResourceRequest: true,
 				},
+			},
+		},
+		{
+			"with namespace from http header but missing header",
+			&authz.Config{
+				ResourceAttributes: authz.ResourceAttributesList{{
+					NamespaceFrom: &authz.NamespaceSource{HTTPHeader: "x-namespace"},
+					APIVersion:    "v1",
+					Resource:      "namespace",
+					Subresource:   "metrics",
+				}},
+			},
+			createRequest(nil, nil),
+			nil,
+		},
+		{
+			"with namespace from http header but several values",
+			&authz.Config{
+				ResourceAttributes: authz.ResourceAttributesList{{
+					NamespaceFrom: &authz.NamespaceSource{HTTPHeader: "x-namespace"},
+					APIVersion:    "v1",
+					Resource:      "namespace",
+					Subresource:   "metrics",
+				}},
+			},
+			createRequest(nil, map[string][]string{"x-namespace": {"tenant1", "tenant2"}}),
+			nil,
+		},
+		{
+			"with namespace from http header but invalid namespace",
+			&authz.Config{
+				ResourceAttributes: authz.ResourceAttributesList{{
+					NamespaceFrom: &authz.NamespaceSource{HTTPHeader: "x-namespace"},
+					APIVersion:    "v1",
+					Resource:      "namespace",
+					Subresource:   "metrics",
+				}},
+			},
+			createRequest(nil, map[string][]string{"x-namespace": {"tenant1/../kube-system"}}),
+			nil,
+		},
+		{
+			"with several resource attributes",
+			&authz.Config{
+				ResourceAttributes: authz.ResourceAttributesList{
+					{Namespace: "tenant1", APIVersion: "v1", Resource: "services", Subresource: "proxy"},
+					{Namespace: "tenant1", APIVersion: "v1", Resource: "pods", Subresource: "metrics"},
+				},
+			},
+			createRequest(nil, nil),
+			[]authorizer.Attributes{
 				authorizer.AttributesRecord{
-					User:            nil,
 					Verb:            "get",
-					Namespace:       "tenant2",
-					APIGroup:        "",
+					Namespace:       "tenant1",
 					APIVersion:      "v1",
-					Resource:        "namespace",
+					Resource:        "services",
+					Subresource:     "proxy",
+					ResourceRequest: true,
+				},
+				authorizer.AttributesRecord{
+					Verb:            "get",
+					Namespace:       "tenant1",
+					APIVersion:      "v1",
+					Resource:        "pods",
 					Subresource:     "metrics",
-					Name:            "",
 					ResourceRequest: true,
 				},
 			},
 		},
+		{
+			"with several resource attributes but one missing its namespace header",
+			&authz.Config{
+				ResourceAttributes: authz.ResourceAttributesList{
+					{Namespace: "tenant1", APIVersion: "v1", Resource: "services", Subresource: "proxy"},
+					{NamespaceFrom: &authz.NamespaceSource{HTTPHeader: "x-namespace"}, APIVersion: "v1", Resource: "pods", Subresource: "metrics"},
+				},
+			},
+			createRequest(nil, nil),
+			nil,
+		},
 	}
 
 	for _, c := range cases {
 		t.Run(c.desc, func(t *testing.T) {
 			t.Log(c.req.URL.Query())
-			n := krpAuthorizerAttributesGetter{authzConfig: c.authzCfg}
+			n := NewKubeRBACProxyAuthorizerAttributesGetter(c.authzCfg)
 			res := n.GetRequestAttributes(nil, c.req)
 			if !cmp.Equal(res, c.expected) {
 				t.Errorf("Generated authorizer attributes are not correct. Expected %v, recieved %v", c.expected, res)
@@ -214,6 +383,88 @@ func TestGeneratingAuthorizerAttributes(t *testing.T) {
 	}
 }
 
+func TestTemplatesOverRequest(t *testing.T) {
+	u := &user.DefaultInfo{Name: "alice", Groups: []string{"admins"}}
+	cfg := &authz.Config{
+		TemplateHeaders: []string{"x-tenant"},
+		ResourceAttributes: authz.ResourceAttributesList{{
+			Namespace:   "{{ index .Path 0 }}",
+			APIVersion:  "v1",
+			Resource:    `{{ if .User.Groups.admins }}services{{ else }}pods{{ end }}`,
+			Subresource: `{{ index .Header "X-Tenant" }}`,
+			Name:        "{{ .User.Name }}",
+		}},
+	}
+	n := NewKubeRBACProxyAuthorizerAttributesGetter(cfg)
+
+	r := httptest.NewRequest("GET", "/tenant1/metrics", nil)
+	r.Header.Set("X-Tenant", "team-a")
+	r.Header.Set("X-Other", "hidden")
+	want := []authorizer.Attributes{authorizer.AttributesRecord{
+		User:            u,
+		Verb:            "get",
+		Namespace:       "tenant1",
+		APIVersion:      "v1",
+		Resource:        "services",
+		Subresource:     "team-a",
+		Name:            "alice",
+		ResourceRequest: true,
+	}}
+	if got := n.GetRequestAttributes(u, r); !cmp.Equal(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	// Headers outside of the allowlist and with several values are hidden.
+	cfg.ResourceAttributes[0].Subresource = `{{ index .Header "X-Other" }}`
+	n = NewKubeRBACProxyAuthorizerAttributesGetter(cfg)
+	r.Header.Add("X-Tenant", "team-b")
+	want[0] = authorizer.AttributesRecord{
+		User:            u,
+		Verb:            "get",
+		Namespace:       "tenant1",
+		APIVersion:      "v1",
+		Resource:        "services",
+		Name:            "alice",
+		ResourceRequest: true,
+	}
+	if got := n.GetRequestAttributes(u, r); !cmp.Equal(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	// Templates failing to execute fail the request.
+	cfg.ResourceAttributes[0].Namespace = "{{ index .Path 5 }}"
+	n = NewKubeRBACProxyAuthorizerAttributesGetter(cfg)
+	if got := n.GetRequestAttributes(u, r); got != nil {
+		t.Errorf("want no attributes, got %v", got)
+	}
+}
+
+func TestVerbForMethod(t *testing.T) {
+	cases := []struct {
+		method   string
+		custom   map[string]string
+		expected string
+	}{
+		{method: "GET", expected: "get"},
+		{method: "POST", expected: "create"},
+		{method: "PUT", expected: "update"},
+		{method: "PATCH", expected: "patch"},
+		{method: "DELETE", expected: "delete"},
+		{method: "PROPFIND", expected: "get"},
+		{method: "REPORT", expected: "get"},
+		{method: "MKCOL", expected: "create"},
+		{method: "LOCK", expected: "*"},
+		{method: "LOCK", custom: map[string]string{"LOCK": "update"}, expected: "update"},
+		{method: "PROPFIND", custom: map[string]string{"PROPFIND": "list"}, expected: "list"},
+	}
+
+	for _, c := range cases {
+		if verb := VerbForMethod(c.method, c.custom); verb != c.expected {
+			t.Errorf("method %s with custom mapping %v: expected verb %q, got %q", c.method, c.custom, c.expected, verb)
+		}
+	}
+}
+
 func createRequest(queryParams, headers map[string][]string) *http.Request {
 	r := httptest.NewRequest("GET", "/accounts", nil)
 	if queryParams != nil {
@@ -232,3 +483,28 @@ func createRequest(queryParams, headers map[string][]string) *http.Request {
 	}
 	return r
 }
+
+func TestVerbForRequest(t *testing.T) {
+	cfg := &authz.Config{WatchQueryParameters: []string{"watch", "follow"}}
+
+	for _, c := range []struct {
+		method   string
+		target   string
+		cfg      *authz.Config
+		expected string
+	}{
+		{method: "GET", target: "/pods", cfg: cfg, expected: "get"},
+		{method: "GET", target: "/pods?watch=true", cfg: cfg, expected: "watch"},
+		{method: "GET", target: "/pods?watch=1", cfg: cfg, expected: "watch"},
+		{method: "GET", target: "/logs?follow=true", cfg: cfg, expected: "watch"},
+		{method: "GET", target: "/pods?watch=false", cfg: cfg, expected: "get"},
+		{method: "GET", target: "/pods?watch=yes", cfg: cfg, expected: "get"},
+		{method: "POST", target: "/pods?watch=true", cfg: cfg, expected: "create"},
+		{method: "GET", target: "/pods?watch=true", cfg: &authz.Config{}, expected: "get"},
+	} {
+		r := httptest.NewRequest(c.method, c.target, nil)
+		if verb := VerbForRequest(r, c.cfg); verb != c.expected {
+			t.Errorf("%s %s: expected verb %q, got %q", c.method, c.target, c.expected, verb)
+		}
+	}
+}
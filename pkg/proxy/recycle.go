@@ -0,0 +1,124 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"time"
+)
+
+// ConnectionRecycler closes upstream connections once they reached a maximum
+// age, such that they are dialed again and the upstream hostname is resolved
+// again. Otherwise keep-alive connections stay pinned to the addresses the
+// hostname resolved to when they were dialed, e.g. after the IP of the
+// upstream Service changed or during rolling updates.
+//
+// For closing idle connections the Run method must be started explicitly.
+type ConnectionRecycler struct {
+	maxAge time.Duration
+	now    func() time.Time
+	// transport is the transport passed to RoundTripper.
+	transport http.RoundTripper
+}
+
+// NewConnectionRecycler creates a ConnectionRecycler for connections of the
+// maximum age.
+func NewConnectionRecycler(maxAge time.Duration) *ConnectionRecycler {
+	return &ConnectionRecycler{
+		maxAge: maxAge,
+		now:    time.Now,
+	}
+}
+
+// DialContext records when the connections of the dial function were dialed.
+func (r *ConnectionRecycler) DialContext(dial func(ctx context.Context, network, addr string) (net.Conn, error)) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		return &datedConn{Conn: conn, dialed: r.now()}, nil
+	}
+}
+
+// RoundTripper sends requests on connections beyond the maximum age with
+// Connection: close, such that HTTP/1 connections are closed after the
+// response, even if they never become idle. The connections of the transport
+// must be dialed by DialContext.
+func (r *ConnectionRecycler) RoundTripper(rt http.RoundTripper) http.RoundTripper {
+	r.transport = rt
+
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		out := req.Clone(req.Context())
+		trace := &httptrace.ClientTrace{
+			// The request is written after the connection was obtained.
+			GotConn: func(info httptrace.GotConnInfo) {
+				if r.expired(info.Conn) {
+					out.Close = true
+				}
+			},
+		}
+		out = out.WithContext(httptrace.WithClientTrace(out.Context(), trace))
+		return rt.RoundTrip(out)
+	})
+}
+
+// Run closes the idle connections of the transport in the interval of the
+// maximum age, until the context is done. Connections in use are closed once
+// they become idle.
+func (r *ConnectionRecycler) Run(ctx context.Context) {
+	closer, ok := r.transport.(interface{ CloseIdleConnections() })
+	if !ok {
+		<-ctx.Done()
+		return
+	}
+
+	t := time.NewTicker(r.maxAge)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			closer.CloseIdleConnections()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (r *ConnectionRecycler) expired(conn net.Conn) bool {
+	// TLS connections wrap the dialed connection.
+	for {
+		switch c := conn.(type) {
+		case *datedConn:
+			return r.now().Sub(c.dialed) >= r.maxAge
+		case interface{ NetConn() net.Conn }:
+			conn = c.NetConn()
+		default:
+			return false
+		}
+	}
+}
+
+// datedConn is a connection that knows when it was dialed.
+type datedConn struct {
+	net.Conn
+	dialed time.Time
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConnectionRecycler(t *testing.T) {
+	var dials atomic.Int32
+	upstream := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	upstream.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			dials.Add(1)
+		}
+	}
+	upstream.Start()
+	defer upstream.Close()
+
+	now := time.Now()
+	r := NewConnectionRecycler(time.Minute)
+	r.now = func() time.Time { return now }
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = r.DialContext((&net.Dialer{}).DialContext)
+	defer transport.CloseIdleConnections()
+	client := &http.Client{Transport: r.RoundTripper(transport)}
+
+	get := func() {
+		t.Helper()
+		resp, err := client.Get(upstream.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	get()
+	get()
+	if got := dials.Load(); got != 1 {
+		t.Fatalf("want the connection to be reused, got %d dials", got)
+	}
+
+	// The request on the expired connection closes it.
+	now = now.Add(time.Minute)
+	get()
+	get()
+	if got := dials.Load(); got != 2 {
+		t.Errorf("want the expired connection to be dialed again, got %d dials", got)
+	}
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const (
+	// OtherRoute labels requests matching no route.
+	OtherRoute = "other"
+	// DefaultUpstream labels requests to the --upstream.
+	DefaultUpstream = "default"
+	// RoutedUpstream labels requests routed by the upstream routing, whose
+	// hosts are too many to label them.
+	RoutedUpstream = "routed"
+)
+
+var requests = metrics.NewCounterVec(
+	&metrics.CounterOpts{
+		Namespace:      "kube_rbac_proxy",
+		Name:           "requests_total",
+		Help:           "Number of requests by route, upstream and status code. The upstream is default, the host of a canary or routed.",
+		StabilityLevel: metrics.ALPHA,
+	},
+	[]string{"route", "upstream", "code"},
+)
+
+func init() {
+	legacyregistry.MustRegister(requests)
+}
+
+// RouteConfig names the requests, whose path matches one of the patterns, in
+// the requests metric, e.g. to derive per-route service level objectives.
+type RouteConfig struct {
+	Name  string   `json:"name"`
+	Paths []string `json:"paths"`
+	// Objective is the share of requests, which must not fail with a 5xx
+	// status, e.g. 0.999. It is read by gen-monitoring only.
+	Objective float64 `json:"objective,omitempty"`
+}
+
+// ValidateRoutes checks the names, path patterns and objectives.
+func ValidateRoutes(configs []RouteConfig) error {
+	names := sets.New(OtherRoute)
+	for i, config := range configs {
+		if errs := validation.IsDNS1123Label(config.Name); len(errs) > 0 {
+			return fmt.Errorf("routes[%d]: invalid name %q: %v", i, config.Name, errs)
+		}
+		if names.Has(config.Name) {
+			return fmt.Errorf("routes[%d]: name %q is not unique", i, config.Name)
+		}
+		names.Insert(config.Name)
+
+		if len(config.Paths) == 0 {
+			return fmt.Errorf("routes[%d]: at least one path is required", i)
+		}
+		for _, pattern := range config.Paths {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("routes[%d]: invalid path %q: %w", i, pattern, err)
+			}
+		}
+
+		if config.Objective < 0 || config.Objective >= 1 {
+			return fmt.Errorf("routes[%d]: objective must be between 0 and 1", i)
+		}
+	}
+	return nil
+}
+
+type upstreamLabelKey struct{}
+
+// setUpstreamLabel sets the upstream label of the request in the requests
+// metric. The directors of the upstreams call it, as they see the outgoing
+// request only, which shares the context of the incoming request.
+func setUpstreamLabel(ctx context.Context, upstream string) {
+	if label, ok := ctx.Value(upstreamLabelKey{}).(*string); ok {
+		*label = upstream
+	}
+}
+
+// WithRequestMetrics counts the requests by the first route matching their
+// path, their upstream and the status code of the response.
+func WithRequestMetrics(routes []RouteConfig, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		route := OtherRoute
+	match:
+		for _, r := range routes {
+			for _, pattern := range r.Paths {
+				if ok, _ := path.Match(pattern, req.URL.Path); ok {
+					route = r.Name
+					break match
+				}
+			}
+		}
+
+		upstream := DefaultUpstream
+		req = req.WithContext(context.WithValue(req.Context(), upstreamLabelKey{}, &upstream))
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			requests.WithLabelValues(route, upstream, strconv.Itoa(sw.status)).Inc()
+		}()
+
+		handler.ServeHTTP(sw, req)
+	}
+}
+
+// statusWriter records the status of the response.
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if !w.wroteHeader && code >= http.StatusOK {
+		w.wroteHeader = true
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap allows http.ResponseController to flush streamed responses.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"testing"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/component-base/metrics/testutil"
+)
+
+func TestWithRequestMetrics(t *testing.T) {
+	router, err := NewCanaryRouter([]CanaryConfig{{Upstream: "http://canary:8080", Users: []string{"alice"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	upstream, err := url.Parse("http://upstream:8080")
+	if err != nil {
+		t.Fatal(err)
+	}
+	director := router.Director(httputil.NewSingleHostReverseProxy(upstream).Director)
+
+	handler := WithRequestMetrics([]RouteConfig{
+		{Name: "query", Paths: []string{"/api/v1/query", "/api/v1/query_range"}},
+		{Name: "api", Paths: []string{"/api/*/*"}},
+	}, func(w http.ResponseWriter, req *http.Request) {
+		director(req)
+		if req.URL.Path == "/api/v1/query_range" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	})
+
+	for _, tt := range []struct {
+		name         string
+		path         string
+		user         user.Info
+		wantRoute    string
+		wantUpstream string
+		wantCode     string
+	}{
+		{name: "first matching route", path: "/api/v1/query", wantRoute: "query", wantUpstream: DefaultUpstream, wantCode: "200"},
+		{name: "status code", path: "/api/v1/query_range", wantRoute: "query", wantUpstream: DefaultUpstream, wantCode: "502"},
+		{name: "pattern", path: "/api/v1/labels", wantRoute: "api", wantUpstream: DefaultUpstream, wantCode: "200"},
+		{name: "no route", path: "/metrics", wantRoute: OtherRoute, wantUpstream: DefaultUpstream, wantCode: "200"},
+		{name: "canary", path: "/metrics", user: &user.DefaultInfo{Name: "alice"}, wantRoute: OtherRoute, wantUpstream: "canary:8080", wantCode: "200"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			counter := requests.WithLabelValues(tt.wantRoute, tt.wantUpstream, tt.wantCode)
+			before, err := testutil.GetCounterMetricValue(counter)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.user != nil {
+				req = req.WithContext(request.WithUser(req.Context(), tt.user))
+			}
+			handler(httptest.NewRecorder(), req)
+
+			after, err := testutil.GetCounterMetricValue(counter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if after-before != 1 {
+				t.Errorf("want the request counted as %s %s %s", tt.wantRoute, tt.wantUpstream, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestValidateRoutes(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		routes  []RouteConfig
+		wantErr bool
+	}{
+		{name: "valid", routes: []RouteConfig{{Name: "api", Paths: []string{"/api/*"}, Objective: 0.999}}},
+		{name: "invalid name", routes: []RouteConfig{{Name: "API", Paths: []string{"/api"}}}, wantErr: true},
+		{name: "reserved name", routes: []RouteConfig{{Name: OtherRoute, Paths: []string{"/api"}}}, wantErr: true},
+		{name: "duplicate name", routes: []RouteConfig{{Name: "api", Paths: []string{"/a"}}, {Name: "api", Paths: []string{"/b"}}}, wantErr: true},
+		{name: "no paths", routes: []RouteConfig{{Name: "api"}}, wantErr: true},
+		{name: "invalid pattern", routes: []RouteConfig{{Name: "api", Paths: []string{"/api/["}}}, wantErr: true},
+		{name: "objective of 1", routes: []RouteConfig{{Name: "api", Paths: []string{"/api"}, Objective: 1}}, wantErr: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateRoutes(tt.routes); (err != nil) != tt.wantErr {
+				t.Errorf("want error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
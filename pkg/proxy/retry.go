@@ -0,0 +1,59 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"syscall"
+
+	"k8s.io/klog/v2"
+)
+
+// WithConnectionResetRetry retries requests once, whose connection to the
+// upstream was reset before a response was received. Only requests that can
+// be replayed with GetBody are retried, i.e. requests without a body or with
+// a buffered body. As the upstream might have processed the request before
+// the reset, non-idempotent requests may be processed twice.
+func WithConnectionResetRetry(rt http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		resp, err := rt.RoundTrip(req)
+		if err == nil || !isConnectionReset(err) {
+			return resp, err
+		}
+
+		retry := req.Clone(req.Context())
+		if req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				return resp, err
+			}
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return resp, err
+			}
+			retry.Body = body
+		}
+
+		klog.FromContext(req.Context()).V(4).Info("Retrying after the upstream connection was reset", "method", req.Method, "path", req.URL.Path, "err", err)
+		return rt.RoundTrip(retry)
+	})
+}
+
+func isConnectionReset(err error) bool {
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
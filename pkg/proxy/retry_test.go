@@ -0,0 +1,87 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestWithConnectionResetRetry(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		err       error
+		buffered  bool
+		wantTries int
+	}{
+		{
+			name:      "buffered body",
+			err:       fmt.Errorf("read: %w", syscall.ECONNRESET),
+			buffered:  true,
+			wantTries: 2,
+		},
+		{
+			name:      "streamed body",
+			err:       fmt.Errorf("read: %w", syscall.ECONNRESET),
+			wantTries: 1,
+		},
+		{
+			name:      "other error",
+			err:       errors.New("connection refused"),
+			buffered:  true,
+			wantTries: 1,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var bodies []string
+			rt := WithConnectionResetRetry(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				b, _ := io.ReadAll(req.Body)
+				bodies = append(bodies, string(b))
+				if len(bodies) == 1 {
+					return nil, tt.err
+				}
+				return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+			}))
+
+			req, err := http.NewRequest(http.MethodPost, "http://upstream/query", io.NopCloser(strings.NewReader("query")))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.buffered {
+				req.GetBody = func() (io.ReadCloser, error) {
+					return io.NopCloser(bytes.NewReader([]byte("query"))), nil
+				}
+			}
+
+			_, _ = rt.RoundTrip(req)
+			if len(bodies) != tt.wantTries {
+				t.Fatalf("want %d tries, got %d", tt.wantTries, len(bodies))
+			}
+			for i, body := range bodies {
+				if body != "query" {
+					t.Errorf("try %d: want body %q, got %q", i, "query", body)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"text/template"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/klog/v2"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/authz"
+)
+
+// UpstreamRoutingConfig selects the upstream of each request by a template
+// over the authenticated user and the value of the rewrite parameter, e.g.
+// "http://{{ .Value }}-svc.{{ .Value }}.svc:8080" routes the requests of
+// tenant-a to its own upstream. The template data are .User with the Name,
+// UID, Groups and Extra of the user, and .Value.
+type UpstreamRoutingConfig struct {
+	Upstream string `json:"upstream"`
+}
+
+// Validate checks that the template parses.
+func (c *UpstreamRoutingConfig) Validate() error {
+	if c.Upstream == "" {
+		return errors.New("upstreamRouting: upstream is required")
+	}
+	if _, err := parseRoutingTemplate(c.Upstream); err != nil {
+		return fmt.Errorf("upstreamRouting: %w", err)
+	}
+	return nil
+}
+
+func parseRoutingTemplate(upstream string) (*template.Template, error) {
+	tmpl, err := template.New("upstream").Option("missingkey=error").Parse(upstream)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse upstream template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// routedKey is the context key of the upstream URL selected for a request.
+type routedKey struct{}
+
+type routingUser struct {
+	Name   string
+	UID    string
+	Groups []string
+	Extra  map[string][]string
+}
+
+type routingData struct {
+	User  routingUser
+	Value string
+}
+
+// UpstreamRouter routes authenticated requests to the upstream selected by
+// the template. Requests whose upstream can't be selected are rejected, they
+// never fall back to another upstream, such that tenants stay isolated.
+type UpstreamRouter struct {
+	tmpl *template.Template
+}
+
+// NewUpstreamRouter creates an UpstreamRouter for a validated config.
+func NewUpstreamRouter(config *UpstreamRoutingConfig) (*UpstreamRouter, error) {
+	tmpl, err := parseRoutingTemplate(config.Upstream)
+	if err != nil {
+		return nil, err
+	}
+	return &UpstreamRouter{tmpl: tmpl}, nil
+}
+
+// WithUpstream selects the upstream of authenticated requests before they are
+// passed on to the handler, which must proxy with the Director of the router.
+// The value is taken from the rewrite parameter of the rewrites.
+func (r *UpstreamRouter) WithUpstream(rewrites *authz.SubjectAccessReviewRewrites, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		u, err := r.upstream(rewrites, req)
+		if err != nil {
+			klog.FromContext(req.Context()).V(2).Info("Unable to select the upstream", "err", err)
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		handler.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), routedKey{}, u)))
+	}
+}
+
+// Director returns a director rewriting requests to the upstream selected by
+// WithUpstream, requests without a selected upstream are rewritten by next. The
+// director is compatible with https://golang.org/pkg/net/http/httputil/#ReverseProxy.
+func (r *UpstreamRouter) Director(next func(*http.Request)) func(*http.Request) {
+	return func(req *http.Request) {
+		u, ok := req.Context().Value(routedKey{}).(*url.URL)
+		if !ok {
+			next(req)
+			return
+		}
+		// The affinity cookie only pins clients to the balanced upstreams,
+		// it isn't passed on to the selected upstream.
+		if _, err := req.Cookie(AffinityCookieName); err == nil {
+			removeCookie(req, AffinityCookieName)
+		}
+		httputil.NewSingleHostReverseProxy(u).Director(req)
+		setUpstreamLabel(req.Context(), RoutedUpstream)
+	}
+}
+
+func (r *UpstreamRouter) upstream(rewrites *authz.SubjectAccessReviewRewrites, req *http.Request) (*url.URL, error) {
+	u, ok := request.UserFrom(req.Context())
+	if !ok {
+		return nil, errors.New("user not in context")
+	}
+
+	data := routingData{
+		User: routingUser{
+			Name:   u.GetName(),
+			UID:    u.GetUID(),
+			Groups: u.GetGroups(),
+			Extra:  u.GetExtra(),
+		},
+	}
+	if rewrites != nil {
+		// Several values would select several upstreams.
+		values := sets.New(rewriteValues(rewrites, req)...)
+		if values.Len() > 1 {
+			return nil, errors.New("the rewrite parameter has several values")
+		}
+		if values.Len() == 1 {
+			data.Value = values.UnsortedList()[0]
+			if errs := validation.IsDNS1123Label(data.Value); len(errs) > 0 {
+				return nil, fmt.Errorf("the rewrite parameter value is invalid: %s", strings.Join(errs, ", "))
+			}
+		}
+	}
+
+	var b strings.Builder
+	if err := r.tmpl.Execute(&b, data); err != nil {
+		return nil, fmt.Errorf("failed to execute upstream template: %w", err)
+	}
+	upstream, err := url.Parse(b.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse upstream URL: %w", err)
+	}
+	if (upstream.Scheme != "http" && upstream.Scheme != "https") || upstream.Hostname() == "" || upstream.User != nil {
+		return nil, fmt.Errorf("upstream URL %q must be an http or https URL with a host", upstream.Redacted())
+	}
+	if errs := validation.IsDNS1123Subdomain(upstream.Hostname()); len(errs) > 0 && net.ParseIP(upstream.Hostname()) == nil {
+		return nil, fmt.Errorf("upstream host %q is invalid", upstream.Hostname())
+	}
+	return upstream, nil
+}
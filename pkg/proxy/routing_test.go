@@ -0,0 +1,164 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"testing"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/authz"
+)
+
+func TestUpstreamRoutingConfigValidate(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		config  UpstreamRoutingConfig
+		wantErr bool
+	}{
+		{name: "valid", config: UpstreamRoutingConfig{Upstream: "http://{{ .Value }}-svc:8080"}},
+		{name: "missing", config: UpstreamRoutingConfig{}, wantErr: true},
+		{name: "malformed", config: UpstreamRoutingConfig{Upstream: "http://{{ .Value -svc"}, wantErr: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("want error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestUpstreamRouter(t *testing.T) {
+	rewrites := &authz.SubjectAccessReviewRewrites{
+		ByQueryParameter: &authz.QueryParameterRewriteConfig{Name: "namespace"},
+	}
+	upstream, err := url.Parse("http://upstream:8080")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name     string
+		template string
+		user     user.Info
+		target   string
+		wantCode int
+		wantURL  string
+	}{
+		{
+			name:     "rewrite value",
+			template: "http://{{ .Value }}-svc.{{ .Value }}.svc:8080",
+			user:     &user.DefaultInfo{Name: "alice"},
+			target:   "/metrics?namespace=tenant-a",
+			wantCode: http.StatusOK,
+			wantURL:  "http://tenant-a-svc.tenant-a.svc:8080/metrics?namespace=tenant-a",
+		},
+		{
+			name:     "identity",
+			template: `https://{{ index .User.Extra "tenant" 0 }}.tenants:8443/base`,
+			user:     &user.DefaultInfo{Name: "alice", Extra: map[string][]string{"tenant": {"tenant-b"}}},
+			target:   "/metrics",
+			wantCode: http.StatusOK,
+			wantURL:  "https://tenant-b.tenants:8443/base/metrics",
+		},
+		{
+			name:     "several values",
+			template: "http://{{ .Value }}-svc:8080",
+			user:     &user.DefaultInfo{Name: "alice"},
+			target:   "/metrics?namespace=tenant-a&namespace=tenant-b",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "invalid value",
+			template: "http://{{ .Value }}-svc:8080",
+			user:     &user.DefaultInfo{Name: "alice"},
+			target:   "/metrics?namespace=evil.com%2F",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "missing claim",
+			template: `http://{{ index .User.Extra "tenant" 0 }}-svc:8080`,
+			user:     &user.DefaultInfo{Name: "alice"},
+			target:   "/metrics",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "invalid host",
+			template: "http://{{ .User.Name }}:8080",
+			user:     &user.DefaultInfo{Name: "Alice Smith"},
+			target:   "/metrics",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "unauthenticated",
+			template: "http://{{ .Value }}-svc:8080",
+			target:   "/metrics?namespace=tenant-a",
+			wantCode: http.StatusForbidden,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			router, err := NewUpstreamRouter(&UpstreamRoutingConfig{Upstream: tt.template})
+			if err != nil {
+				t.Fatal(err)
+			}
+			director := router.Director(httputil.NewSingleHostReverseProxy(upstream).Director)
+
+			var gotURL string
+			handler := router.WithUpstream(rewrites, func(w http.ResponseWriter, req *http.Request) {
+				director(req)
+				gotURL = req.URL.String()
+			})
+
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.user != nil {
+				req = req.WithContext(request.WithUser(req.Context(), tt.user))
+			}
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("want status %d, got %d", tt.wantCode, rec.Code)
+			}
+			if gotURL != tt.wantURL {
+				t.Errorf("want %q, got %q", tt.wantURL, gotURL)
+			}
+		})
+	}
+}
+
+func TestUpstreamRouterDirectorFallback(t *testing.T) {
+	router, err := NewUpstreamRouter(&UpstreamRoutingConfig{Upstream: "http://{{ .Value }}-svc:8080"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	upstream, err := url.Parse("http://upstream:8080")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Requests of ignored paths aren't routed.
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	router.Director(httputil.NewSingleHostReverseProxy(upstream).Director)(req)
+	if got, want := req.URL.String(), "http://upstream:8080/healthz"; got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
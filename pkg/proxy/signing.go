@@ -0,0 +1,130 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apiserver/pkg/endpoints/request"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/authn"
+)
+
+const (
+	// SignatureHeader holds the hex encoded HMAC-SHA256 of the request,
+	// prefixed by "sha256=".
+	SignatureHeader = "X-Kube-Rbac-Proxy-Signature"
+	// SignatureTimestampHeader holds the signing time in Unix seconds.
+	SignatureTimestampHeader = "X-Kube-Rbac-Proxy-Signature-Timestamp"
+
+	minSigningKeyLength = 32
+)
+
+// RequestSigner signs the requests forwarded to the upstream with an HMAC
+// over the method, the request URI, the timestamp and the identity headers,
+// such that the upstream can verify that a request traversed kube-rbac-proxy.
+type RequestSigner struct {
+	key    []byte
+	header *authn.AuthnHeaderConfig
+	now    func() time.Time
+}
+
+// NewRequestSigner reads the shared secret from the key file.
+func NewRequestSigner(keyFile string, header *authn.AuthnHeaderConfig) (*RequestSigner, error) {
+	key, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+	key = bytes.TrimSpace(key)
+	if len(key) < minSigningKeyLength {
+		return nil, fmt.Errorf("signing key must have at least %d bytes", minSigningKeyLength)
+	}
+
+	return &RequestSigner{key: key, header: header, now: time.Now}, nil
+}
+
+// RoundTripper signs requests before passing them to rt.
+func (s *RequestSigner) RoundTripper(rt http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		// RoundTrippers must not modify the request.
+		req = req.Clone(req.Context())
+		s.sign(req)
+		return rt.RoundTrip(req)
+	})
+}
+
+// sign signs the identity of the authenticated user instead of the identity
+// headers of the request, which clients can set on ignored paths. Hence,
+// identity headers not set by kube-rbac-proxy fail the verification.
+func (s *RequestSigner) sign(req *http.Request) {
+	var name, groups string
+	if u, ok := request.UserFrom(req.Context()); ok && s.header.Enabled {
+		name = u.GetName()
+		groups = strings.Join(u.GetGroups(), s.header.GroupSeparator)
+	}
+
+	timestamp := strconv.FormatInt(s.now().Unix(), 10)
+	req.Header.Set(SignatureTimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, signature(s.key, req.Method, req.URL.RequestURI(), timestamp, name, groups))
+}
+
+// VerifyRequest verifies the signature of a request received from
+// kube-rbac-proxy, with the identity header names of the proxy. Signatures
+// older than maxAge are rejected to limit replays.
+func VerifyRequest(req *http.Request, key []byte, userHeader, groupsHeader string, maxAge time.Duration) error {
+	timestamp := req.Header.Get(SignatureTimestampHeader)
+	signed, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.New("missing or invalid signature timestamp")
+	}
+	if age := time.Since(time.Unix(signed, 0)); age > maxAge || age < -maxAge {
+		return fmt.Errorf("signature timestamp is off by %v", age)
+	}
+
+	want := signature(key, req.Method, req.URL.RequestURI(), timestamp, req.Header.Get(userHeader), req.Header.Get(groupsHeader))
+	if !hmac.Equal([]byte(req.Header.Get(SignatureHeader)), []byte(want)) {
+		return errors.New("invalid signature")
+	}
+
+	return nil
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// signature computes the HMAC over the newline separated fields.
+func signature(key []byte, method, requestURI, timestamp, user, groups string) string {
+	mac := hmac.New(sha256.New, key)
+	for _, field := range []string{method, requestURI, timestamp, user, groups} {
+		mac.Write([]byte(field))
+		mac.Write([]byte{'\n'})
+	}
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/authn"
+)
+
+func TestRequestSigner(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	keyFile := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(keyFile, append(key, '\n'), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	header := &authn.AuthnHeaderConfig{Enabled: true, UserFieldName: "x-remote-user", GroupsFieldName: "x-remote-groups", GroupSeparator: "|"}
+	signer, err := NewRequestSigner(keyFile, header)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var verifyErr error
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		verifyErr = VerifyRequest(r, key, "x-remote-user", "x-remote-groups", time.Minute)
+	}))
+	defer upstream.Close()
+
+	rp := httputil.NewSingleHostReverseProxy(testUpstreams(t, upstream.URL+"/base")[0])
+	rp.Transport = signer.RoundTripper(http.DefaultTransport)
+
+	newRequest := func(u user.Info, headers map[string]string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/metrics?x=1", nil)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		if u != nil {
+			req = req.WithContext(request.WithUser(req.Context(), u))
+		}
+		return req
+	}
+
+	for _, tt := range []struct {
+		name    string
+		req     *http.Request
+		wantErr bool
+	}{
+		{
+			name: "authenticated user",
+			req: newRequest(
+				&user.DefaultInfo{Name: "alice", Groups: []string{"a", "b"}},
+				map[string]string{"x-remote-user": "alice", "x-remote-groups": "a|b"},
+			),
+		},
+		{
+			name: "unauthenticated request",
+			req:  newRequest(nil, nil),
+		},
+		{
+			name:    "spoofed identity on ignored path",
+			req:     newRequest(nil, map[string]string{"x-remote-user": "admin"}),
+			wantErr: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rp.ServeHTTP(httptest.NewRecorder(), tt.req)
+			if (verifyErr != nil) != tt.wantErr {
+				t.Errorf("want error %t, got %v", tt.wantErr, verifyErr)
+			}
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	signer.now = func() time.Time { return time.Now().Add(-time.Hour) }
+	signer.sign(req)
+	if err := VerifyRequest(req, key, "x-remote-user", "x-remote-groups", time.Minute); err == nil {
+		t.Error("want error for outdated signature")
+	}
+}
+
+func TestNewRequestSignerShortKey(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(keyFile, []byte("secret"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewRequestSigner(keyFile, &authn.AuthnHeaderConfig{}); err == nil {
+		t.Error("want error for short key")
+	}
+}
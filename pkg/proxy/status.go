@@ -0,0 +1,117 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+var rewrittenStatuses = metrics.NewCounterVec(
+	&metrics.CounterOpts{
+		Namespace:      "kube_rbac_proxy",
+		Subsystem:      "upstream",
+		Name:           "rewritten_statuses_total",
+		Help:           "Number of upstream responses whose status was rewritten by the upstream status and the status sent to the client.",
+		StabilityLevel: metrics.ALPHA,
+	},
+	[]string{"from", "to"},
+)
+
+func init() {
+	legacyregistry.MustRegister(rewrittenStatuses)
+}
+
+// StatusRewriteConfig rewrites the statuses of upstream responses to
+// requests, whose path as sent to the upstream matches one of the patterns,
+// or of all requests without patterns. E.g. 401: 502 keeps clients from confusing an
+// authentication failure of the upstream with one of the proxy.
+type StatusRewriteConfig struct {
+	Paths    []string    `json:"paths,omitempty"`
+	Statuses map[int]int `json:"statuses"`
+}
+
+// ValidateStatusRewrites checks the path patterns and the statuses.
+func ValidateStatusRewrites(configs []StatusRewriteConfig) error {
+	for i, config := range configs {
+		for _, pattern := range config.Paths {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("upstreamStatusRewrites[%d]: invalid path %q: %w", i, pattern, err)
+			}
+		}
+		if len(config.Statuses) == 0 {
+			return fmt.Errorf("upstreamStatusRewrites[%d]: at least one status is required", i)
+		}
+		for from, to := range config.Statuses {
+			if from < 100 || from > 599 || to < 200 || to > 599 {
+				return fmt.Errorf("upstreamStatusRewrites[%d]: invalid rewrite of status %d to %d", i, from, to)
+			}
+		}
+	}
+	return nil
+}
+
+// StatusRewriter rewrites the statuses of upstream responses. Responses of
+// the proxy itself, e.g. its 401 and 403, are never rewritten.
+//
+// The ModifyResponse signature is compatible with
+// https://golang.org/pkg/net/http/httputil/#ReverseProxy.
+type StatusRewriter struct {
+	configs []StatusRewriteConfig
+}
+
+// NewStatusRewriter creates a StatusRewriter for validated configs.
+func NewStatusRewriter(configs []StatusRewriteConfig) *StatusRewriter {
+	return &StatusRewriter{configs: configs}
+}
+
+// ModifyResponse rewrites the status of the response with the first
+// matching config having a rewrite of the status.
+func (r *StatusRewriter) ModifyResponse(resp *http.Response) error {
+	if resp.Request == nil {
+		return nil
+	}
+
+	for _, config := range r.configs {
+		if len(config.Paths) > 0 {
+			if _, found := MatchPath(config.Paths, resp.Request.URL.Path); !found {
+				continue
+			}
+		}
+		to, ok := config.Statuses[resp.StatusCode]
+		if !ok {
+			continue
+		}
+
+		from := resp.StatusCode
+		if from == http.StatusUnauthorized && to != http.StatusUnauthorized {
+			// The challenge of the upstream would be taken as one of the
+			// proxy.
+			resp.Header.Del("WWW-Authenticate")
+		}
+		resp.StatusCode = to
+		resp.Status = fmt.Sprintf("%d %s", to, http.StatusText(to))
+		rewrittenStatuses.WithLabelValues(strconv.Itoa(from), strconv.Itoa(to)).Inc()
+		return nil
+	}
+	return nil
+}
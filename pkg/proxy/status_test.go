@@ -0,0 +1,82 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestValidateStatusRewrites(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		configs []StatusRewriteConfig
+		wantErr bool
+	}{
+		{name: "valid", configs: []StatusRewriteConfig{{Paths: []string{"/api/*"}, Statuses: map[int]int{401: 502}}}},
+		{name: "all paths", configs: []StatusRewriteConfig{{Statuses: map[int]int{403: 502}}}},
+		{name: "no statuses", configs: []StatusRewriteConfig{{Paths: []string{"/api/*"}}}, wantErr: true},
+		{name: "invalid path", configs: []StatusRewriteConfig{{Paths: []string{"[]a]"}, Statuses: map[int]int{401: 502}}}, wantErr: true},
+		{name: "invalid status", configs: []StatusRewriteConfig{{Statuses: map[int]int{401: 1000}}}, wantErr: true},
+		{name: "informational status", configs: []StatusRewriteConfig{{Statuses: map[int]int{401: 101}}}, wantErr: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateStatusRewrites(tt.configs); (err != nil) != tt.wantErr {
+				t.Errorf("want error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestStatusRewriter(t *testing.T) {
+	rewriter := NewStatusRewriter([]StatusRewriteConfig{
+		{Paths: []string{"/api/*"}, Statuses: map[int]int{401: 502, 403: 502}},
+		{Statuses: map[int]int{401: 503}},
+	})
+
+	for _, tt := range []struct {
+		name       string
+		path       string
+		status     int
+		wantStatus int
+	}{
+		{name: "matching path", path: "/api/users", status: http.StatusUnauthorized, wantStatus: http.StatusBadGateway},
+		{name: "other status", path: "/api/users", status: http.StatusNotFound, wantStatus: http.StatusNotFound},
+		{name: "fallback config", path: "/metrics", status: http.StatusUnauthorized, wantStatus: http.StatusServiceUnavailable},
+		{name: "unmatched", path: "/metrics", status: http.StatusForbidden, wantStatus: http.StatusForbidden},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: tt.status,
+				Header:     http.Header{"Www-Authenticate": {`Basic realm="upstream"`}},
+				Request:    httptest.NewRequest(http.MethodGet, tt.path, nil),
+			}
+			if err := rewriter.ModifyResponse(resp); err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("want status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+			rewritten := tt.status != tt.wantStatus
+			if got := resp.Header.Get("WWW-Authenticate") != ""; got == rewritten {
+				t.Errorf("want the WWW-Authenticate header removed only from rewritten 401s, got %q", resp.Header.Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
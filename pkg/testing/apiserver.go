@@ -0,0 +1,146 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package testing provides an in-process kube-rbac-proxy backed by a fake
+// API server, such that proxy configurations can be tested without a
+// cluster.
+package testing
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	stdtesting "testing"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	tokenReviewPath          = "/apis/authentication.k8s.io/v1/tokenreviews"
+	subjectAccessReviewPath  = "/apis/authorization.k8s.io/v1/subjectaccessreviews"
+	subjectAccessReviewAllow = "allowed by fake API server"
+)
+
+// AuthorizeFunc decides a SubjectAccessReview of the fake API server.
+type AuthorizeFunc func(spec authorizationv1.SubjectAccessReviewSpec) bool
+
+// FakeAPIServer answers TokenReviews and SubjectAccessReviews with
+// programmable responses. All other requests fail with 404.
+type FakeAPIServer struct {
+	server *httptest.Server
+
+	mu        sync.Mutex
+	tokens    map[string]authenticationv1.UserInfo
+	authorize AuthorizeFunc
+	reviews   []authorizationv1.SubjectAccessReviewSpec
+}
+
+// NewFakeAPIServer starts a FakeAPIServer, which denies everything until
+// tokens and an authorizer are set. It is stopped when the test ends.
+func NewFakeAPIServer(t stdtesting.TB) *FakeAPIServer {
+	t.Helper()
+
+	s := &FakeAPIServer{tokens: map[string]authenticationv1.UserInfo{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+tokenReviewPath, s.serveTokenReview)
+	mux.HandleFunc("POST "+subjectAccessReviewPath, s.serveSubjectAccessReview)
+	s.server = httptest.NewServer(mux)
+	t.Cleanup(s.server.Close)
+
+	return s
+}
+
+// URL returns the base URL of the fake API server.
+func (s *FakeAPIServer) URL() string {
+	return s.server.URL
+}
+
+// Client returns a client of the fake API server.
+func (s *FakeAPIServer) Client() kubernetes.Interface {
+	return kubernetes.NewForConfigOrDie(&rest.Config{Host: s.server.URL})
+}
+
+// SetToken authenticates the bearer token as the user.
+func (s *FakeAPIServer) SetToken(token string, user authenticationv1.UserInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokens[token] = user
+}
+
+// SetAuthorizer sets the function deciding SubjectAccessReviews.
+func (s *FakeAPIServer) SetAuthorizer(authorize AuthorizeFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.authorize = authorize
+}
+
+// SubjectAccessReviews returns the specs of all reviewed SubjectAccessReviews
+// in order.
+func (s *FakeAPIServer) SubjectAccessReviews() []authorizationv1.SubjectAccessReviewSpec {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]authorizationv1.SubjectAccessReviewSpec{}, s.reviews...)
+}
+
+func (s *FakeAPIServer) serveTokenReview(w http.ResponseWriter, req *http.Request) {
+	review := &authenticationv1.TokenReview{}
+	if err := json.NewDecoder(req.Body).Decode(review); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	user, ok := s.tokens[review.Spec.Token]
+	s.mu.Unlock()
+
+	review.Status = authenticationv1.TokenReviewStatus{
+		Authenticated: ok,
+		User:          user,
+		Audiences:     review.Spec.Audiences,
+	}
+	writeJSON(w, review)
+}
+
+func (s *FakeAPIServer) serveSubjectAccessReview(w http.ResponseWriter, req *http.Request) {
+	review := &authorizationv1.SubjectAccessReview{}
+	if err := json.NewDecoder(req.Body).Decode(review); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.reviews = append(s.reviews, review.Spec)
+	authorize := s.authorize
+	s.mu.Unlock()
+
+	if authorize != nil && authorize(review.Spec) {
+		review.Status = authorizationv1.SubjectAccessReviewStatus{Allowed: true, Reason: subjectAccessReviewAllow}
+	}
+	writeJSON(w, review)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	stdtesting "testing"
+
+	"k8s.io/apiserver/pkg/authorization/union"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/authn"
+	"github.com/brancz/kube-rbac-proxy/pkg/authz"
+	"github.com/brancz/kube-rbac-proxy/pkg/filters"
+	"github.com/brancz/kube-rbac-proxy/pkg/proxy"
+)
+
+// Proxy is an in-process kube-rbac-proxy serving plain HTTP. It
+// authenticates bearer tokens and authorizes requests like the
+// kube-rbac-proxy binary, using the fake API server.
+type Proxy struct {
+	server        *httptest.Server
+	sarAuthorizer *authz.CachingAuthorizer
+}
+
+// NewProxy starts a Proxy in front of the upstream. Nil parts of the config
+// default to the defaults of the flags. The proxy is stopped when the test
+// ends.
+func NewProxy(t stdtesting.TB, apiServer *FakeAPIServer, upstream string, config *proxy.Config) *Proxy {
+	t.Helper()
+
+	upstreamURL, err := url.Parse(upstream)
+	if err != nil {
+		t.Fatalf("failed to parse upstream URL: %v", err)
+	}
+
+	config = withDefaults(config)
+	client := apiServer.Client()
+
+	authenticator, err := authn.NewDelegatingAuthenticator(client.AuthenticationV1(), config.Authentication)
+	if err != nil {
+		t.Fatalf("failed to create authenticator: %v", err)
+	}
+
+	sarAuthorizer, err := authz.NewSarAuthorizer(client.AuthorizationV1())
+	if err != nil {
+		t.Fatalf("failed to create sar authorizer: %v", err)
+	}
+	staticAuthorizer, err := authz.NewStaticAuthorizer(config.Authorization.Static)
+	if err != nil {
+		t.Fatalf("failed to create static authorizer: %v", err)
+	}
+	scopeAuthorizer, err := authz.NewScopeAuthorizer(config.Authorization.Scopes)
+	if err != nil {
+		t.Fatalf("failed to create scope authorizer: %v", err)
+	}
+	authorizer := union.New(
+		authz.NewDenyAuthorizer(config.Authorization.DenyUsers, config.Authorization.DenyGroups),
+		staticAuthorizer,
+		scopeAuthorizer,
+		sarAuthorizer,
+	)
+
+	handler := httputil.NewSingleHostReverseProxy(upstreamURL).ServeHTTP
+	handler = filters.WithAuthHeaders(config.Authentication.Header, handler)
+	handler = filters.WithAuthorization(authorizer, config.Authorization, handler)
+	handler = filters.WithImpersonation(config.Authentication.Impersonation, authorizer, handler)
+	handler = filters.WithAuthentication(authenticator, config.Authentication.Token.Audiences, handler)
+
+	p := &Proxy{
+		server:        httptest.NewServer(http.HandlerFunc(handler)),
+		sarAuthorizer: sarAuthorizer,
+	}
+	t.Cleanup(p.server.Close)
+
+	return p
+}
+
+// URL returns the base URL of the proxy.
+func (p *Proxy) URL() string {
+	return p.server.URL
+}
+
+// Get requests the path with the bearer token, an empty token sends no
+// Authorization header.
+func (p *Proxy) Get(t stdtesting.TB, path, token string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, p.server.URL+path, nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := p.server.Client().Do(req)
+	if err != nil {
+		t.Fatalf("failed to request %s: %v", path, err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+
+	return resp
+}
+
+// FlushAuthorizationCache drops the cached authorization decisions, which
+// is needed for changes of the authorizer of the fake API server to take
+// effect. Authenticated tokens are cached for two minutes.
+func (p *Proxy) FlushAuthorizationCache() {
+	p.sarAuthorizer.Flush()
+}
+
+func withDefaults(config *proxy.Config) *proxy.Config {
+	c := proxy.Config{}
+	if config != nil {
+		c = *config
+	}
+
+	if c.Authentication == nil {
+		c.Authentication = &authn.AuthnConfig{}
+	} else {
+		authnConfig := *c.Authentication
+		c.Authentication = &authnConfig
+	}
+	if c.Authentication.X509 == nil {
+		c.Authentication.X509 = &authn.X509Config{}
+	}
+	if c.Authentication.Header == nil {
+		c.Authentication.Header = &authn.AuthnHeaderConfig{
+			UserFieldName:   "x-remote-user",
+			GroupsFieldName: "x-remote-groups",
+			GroupSeparator:  "|",
+		}
+	}
+	if c.Authentication.Token == nil {
+		c.Authentication.Token = &authn.TokenConfig{}
+	}
+	if c.Authorization == nil {
+		c.Authorization = &authz.Config{}
+	}
+
+	return &c
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testing_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+
+	"github.com/brancz/kube-rbac-proxy/pkg/authn"
+	"github.com/brancz/kube-rbac-proxy/pkg/authz"
+	"github.com/brancz/kube-rbac-proxy/pkg/proxy"
+	krptesting "github.com/brancz/kube-rbac-proxy/pkg/testing"
+)
+
+func TestProxy(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("x-remote-user")))
+	}))
+	defer upstream.Close()
+
+	apiServer := krptesting.NewFakeAPIServer(t)
+	apiServer.SetToken("alice-token", authenticationv1.UserInfo{Username: "alice"})
+	apiServer.SetToken("bob-token", authenticationv1.UserInfo{Username: "bob"})
+	apiServer.SetAuthorizer(func(spec authorizationv1.SubjectAccessReviewSpec) bool {
+		return spec.User == "alice" && spec.ResourceAttributes != nil && spec.ResourceAttributes.Subresource == "metrics"
+	})
+
+	p := krptesting.NewProxy(t, apiServer, upstream.URL, &proxy.Config{
+		Authentication: &authn.AuthnConfig{
+			Header: &authn.AuthnHeaderConfig{Enabled: true, UserFieldName: "x-remote-user", GroupsFieldName: "x-remote-groups"},
+		},
+		Authorization: &authz.Config{
+			ResourceAttributes: authz.ResourceAttributesList{{
+				Namespace:   "monitoring",
+				Resource:    "services",
+				Subresource: "metrics",
+			}},
+		},
+	})
+
+	for _, tt := range []struct {
+		name   string
+		token  string
+		status int
+	}{
+		{name: "unauthenticated", token: "", status: http.StatusUnauthorized},
+		{name: "unknown token", token: "unknown", status: http.StatusUnauthorized},
+		{name: "unauthorized", token: "bob-token", status: http.StatusForbidden},
+		{name: "authorized", token: "alice-token", status: http.StatusOK},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := p.Get(t, "/metrics", tt.token)
+			if resp.StatusCode != tt.status {
+				t.Fatalf("want status %d, got %d", tt.status, resp.StatusCode)
+			}
+
+			if tt.status == http.StatusOK {
+				body, err := io.ReadAll(resp.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(body) != "alice" {
+					t.Errorf("want upstream to see user alice, got %q", body)
+				}
+			}
+		})
+	}
+
+	reviews := apiServer.SubjectAccessReviews()
+	if len(reviews) != 2 {
+		t.Fatalf("want 2 SubjectAccessReviews, got %d", len(reviews))
+	}
+	if attrs := reviews[1].ResourceAttributes; attrs.Namespace != "monitoring" || attrs.Verb != "get" {
+		t.Errorf("want get in monitoring, got %+v", attrs)
+	}
+
+	apiServer.SetAuthorizer(nil)
+	p.FlushAuthorizationCache()
+	if resp := p.Get(t, "/metrics", "alice-token"); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("want revoked access to be forbidden, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tls
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// extKeyUsages maps the names of extended key usages to their OIDs, as of
+// RFC 5280.
+var extKeyUsages = map[string]asn1.ObjectIdentifier{
+	"serverAuth":      {1, 3, 6, 1, 5, 5, 7, 3, 1},
+	"clientAuth":      {1, 3, 6, 1, 5, 5, 7, 3, 2},
+	"codeSigning":     {1, 3, 6, 1, 5, 5, 7, 3, 3},
+	"emailProtection": {1, 3, 6, 1, 5, 5, 7, 3, 4},
+	"timeStamping":    {1, 3, 6, 1, 5, 5, 7, 3, 8},
+	"OCSPSigning":     {1, 3, 6, 1, 5, 5, 7, 3, 9},
+}
+
+// usageNames maps the extended key usages parsed by the x509 package to their
+// names.
+var usageNames = map[x509.ExtKeyUsage]string{
+	x509.ExtKeyUsageServerAuth:      "serverAuth",
+	x509.ExtKeyUsageClientAuth:      "clientAuth",
+	x509.ExtKeyUsageCodeSigning:     "codeSigning",
+	x509.ExtKeyUsageEmailProtection: "emailProtection",
+	x509.ExtKeyUsageTimeStamping:    "timeStamping",
+	x509.ExtKeyUsageOCSPSigning:     "OCSPSigning",
+}
+
+// CertificatePolicy restricts client certificates beyond a valid chain to a
+// client CA.
+type CertificatePolicy struct {
+	// RequiredEKUs must all be listed in the extended key usages of the
+	// client certificate. Certificates without the extension are rejected.
+	RequiredEKUs []asn1.ObjectIdentifier
+	// SignatureAlgorithms are the accepted algorithms of the signatures of
+	// the chain, except the self-signature of the client CA. Any algorithm
+	// is accepted, if empty.
+	SignatureAlgorithms map[x509.SignatureAlgorithm]struct{}
+}
+
+// ParseCertificatePolicy parses the required EKUs, given by name, e.g.
+// clientAuth, or as dotted OID, and the accepted signature algorithms, given
+// by name as of x509.SignatureAlgorithm, e.g. ECDSA-SHA256.
+func ParseCertificatePolicy(ekus, signatureAlgorithms []string) (*CertificatePolicy, error) {
+	p := &CertificatePolicy{}
+
+	for _, eku := range ekus {
+		oid, err := parseEKU(eku)
+		if err != nil {
+			return nil, err
+		}
+		p.RequiredEKUs = append(p.RequiredEKUs, oid)
+	}
+
+	if len(signatureAlgorithms) > 0 {
+		known := map[string]x509.SignatureAlgorithm{}
+		for alg := x509.MD2WithRSA; alg <= x509.PureEd25519; alg++ {
+			known[alg.String()] = alg
+		}
+
+		p.SignatureAlgorithms = map[x509.SignatureAlgorithm]struct{}{}
+		for _, name := range signatureAlgorithms {
+			alg, ok := known[name]
+			if !ok {
+				names := make([]string, 0, len(known))
+				for n := range known {
+					names = append(names, n)
+				}
+				sort.Strings(names)
+				return nil, fmt.Errorf("unknown signature algorithm %q, must be one of %s", name, strings.Join(names, ", "))
+			}
+			p.SignatureAlgorithms[alg] = struct{}{}
+		}
+	}
+
+	return p, nil
+}
+
+func parseEKU(eku string) (asn1.ObjectIdentifier, error) {
+	if oid, ok := extKeyUsages[eku]; ok {
+		return oid, nil
+	}
+
+	parts := strings.Split(eku, ".")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("unknown extended key usage %q, must be a dotted OID or one of serverAuth, clientAuth, codeSigning, emailProtection, timeStamping or OCSPSigning", eku)
+	}
+	oid := make(asn1.ObjectIdentifier, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid extended key usage OID %q", eku)
+		}
+		oid[i] = n
+	}
+	return oid, nil
+}
+
+// check returns the reason, if the verified chain from the leaf to the client
+// CA violates the policy.
+func (p *CertificatePolicy) check(chain []*x509.Certificate) (string, error) {
+	if p == nil {
+		return "", nil
+	}
+
+	leaf := chain[0]
+	ekus := leafEKUs(leaf)
+	for _, required := range p.RequiredEKUs {
+		if !containsOID(ekus, required) {
+			return ChainReasonWrongEKU, fmt.Errorf("client certificate lacks the extended key usage %s", required)
+		}
+	}
+
+	if len(p.SignatureAlgorithms) > 0 {
+		// The root is trusted by configuration, its self-signature is moot.
+		for _, cert := range chain[:len(chain)-1] {
+			if _, ok := p.SignatureAlgorithms[cert.SignatureAlgorithm]; !ok {
+				return ChainReasonSignatureAlgorithm, fmt.Errorf("certificate %q is signed with %s, which is not accepted", cert.Subject.CommonName, cert.SignatureAlgorithm)
+			}
+		}
+	}
+
+	return "", nil
+}
+
+// leafEKUs returns the OIDs of the extended key usages of the certificate,
+// known usages are parsed into ExtKeyUsage by the x509 package.
+func leafEKUs(cert *x509.Certificate) []asn1.ObjectIdentifier {
+	oids := append([]asn1.ObjectIdentifier{}, cert.UnknownExtKeyUsage...)
+	for _, usage := range cert.ExtKeyUsage {
+		if name, ok := usageNames[usage]; ok {
+			oids = append(oids, extKeyUsages[name])
+		}
+	}
+	return oids
+}
+
+func containsOID(oids []asn1.ObjectIdentifier, oid asn1.ObjectIdentifier) bool {
+	for _, o := range oids {
+		if o.Equal(oid) {
+			return true
+		}
+	}
+	return false
+}
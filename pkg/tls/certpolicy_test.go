@@ -0,0 +1,140 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseCertificatePolicy(t *testing.T) {
+	p, err := ParseCertificatePolicy([]string{"clientAuth", "1.3.6.1.4.1.99999.1"}, []string{"ECDSA-SHA256", "SHA256-RSA"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.RequiredEKUs) != 2 || !p.RequiredEKUs[1].Equal(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 99999, 1}) {
+		t.Errorf("unexpected EKUs %v", p.RequiredEKUs)
+	}
+	if _, ok := p.SignatureAlgorithms[x509.ECDSAWithSHA256]; !ok || len(p.SignatureAlgorithms) != 2 {
+		t.Errorf("unexpected signature algorithms %v", p.SignatureAlgorithms)
+	}
+
+	for _, tt := range []struct {
+		ekus, algorithms []string
+	}{
+		{ekus: []string{"clientauth"}},
+		{ekus: []string{"1.3.x"}},
+		{algorithms: []string{"SHA256"}},
+	} {
+		if _, err := ParseCertificatePolicy(tt.ekus, tt.algorithms); err == nil {
+			t.Errorf("want %v and %v to be invalid", tt.ekus, tt.algorithms)
+		}
+	}
+}
+
+func TestCertificatePolicy(t *testing.T) {
+	root := newTestCA(t)
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	root.writeCert(t, caPath)
+
+	customEKU := asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 99999, 1}
+	leaf := func(serial int64, tmpl *x509.Certificate) *x509.Certificate {
+		tmpl.SerialNumber = big.NewInt(serial)
+		tmpl.Subject = pkix.Name{CommonName: "client"}
+		return root.sign(t, tmpl).cert
+	}
+	withoutEKU := leaf(2, &x509.Certificate{})
+	clientAuth := leaf(3, &x509.Certificate{ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}})
+	custom := leaf(4, &x509.Certificate{
+		ExtKeyUsage:        []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		UnknownExtKeyUsage: []asn1.ObjectIdentifier{customEKU},
+	})
+
+	for _, tt := range []struct {
+		name       string
+		ekus       []string
+		algorithms []string
+		cert       *x509.Certificate
+		wantReason string
+	}{
+		{
+			name: "no policy accepts certificates without EKU",
+			cert: withoutEKU,
+		},
+		{
+			name:       "required EKU missing",
+			ekus:       []string{"clientAuth"},
+			cert:       withoutEKU,
+			wantReason: ChainReasonWrongEKU,
+		},
+		{
+			name: "required EKU present",
+			ekus: []string{"clientAuth"},
+			cert: clientAuth,
+		},
+		{
+			name:       "custom EKU missing",
+			ekus:       []string{"clientAuth", customEKU.String()},
+			cert:       clientAuth,
+			wantReason: ChainReasonWrongEKU,
+		},
+		{
+			name: "custom EKU present",
+			ekus: []string{"clientAuth", customEKU.String()},
+			cert: custom,
+		},
+		{
+			name:       "signature algorithm accepted",
+			algorithms: []string{"ECDSA-SHA256"},
+			cert:       clientAuth,
+		},
+		{
+			name:       "signature algorithm not accepted",
+			algorithms: []string{"SHA256-RSA", "Ed25519"},
+			cert:       clientAuth,
+			wantReason: ChainReasonSignatureAlgorithm,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := ParseCertificatePolicy(tt.ekus, tt.algorithms)
+			if err != nil {
+				t.Fatal(err)
+			}
+			v, err := NewChainVerifier(caPath, "", nil, policy)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = v.VerifyConnection(tls.ConnectionState{PeerCertificates: []*x509.Certificate{tt.cert}})
+			var chainErr *ChainError
+			switch {
+			case tt.wantReason == "" && err != nil:
+				t.Errorf("want verification to succeed, got %v", err)
+			case tt.wantReason != "" && !errors.As(err, &chainErr):
+				t.Errorf("want chain error %q, got %v", tt.wantReason, err)
+			case tt.wantReason != "" && chainErr.Reason != tt.wantReason:
+				t.Errorf("want reason %q, got %q", tt.wantReason, chainErr.Reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,440 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tls
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/cache"
+	"k8s.io/apimachinery/pkg/util/sets"
+	certutil "k8s.io/client-go/util/cert"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+)
+
+const (
+	chainCacheSize = 4096
+	chainCacheTTL  = 5 * time.Minute
+	aiaFailureTTL  = time.Minute
+	maxChainDepth  = 8
+)
+
+// Reasons of failed client certificate verifications.
+const (
+	ChainReasonMissingIntermediate = "missing_intermediate"
+	ChainReasonUnknownAuthority    = "unknown_authority"
+	ChainReasonExpired             = "expired"
+	ChainReasonWrongEKU            = "wrong_eku"
+	ChainReasonSignatureAlgorithm  = "signature_algorithm"
+	ChainReasonOther               = "other"
+)
+
+var verificationFailures = metrics.NewCounterVec(
+	&metrics.CounterOpts{
+		Namespace:      "kube_rbac_proxy",
+		Name:           "client_certificate_verification_failures_total",
+		Help:           "Number of TLS handshakes failed, because the client certificate could not be verified, by reason.",
+		StabilityLevel: metrics.ALPHA,
+	},
+	[]string{"reason"},
+)
+
+func init() {
+	legacyregistry.MustRegister(verificationFailures)
+}
+
+// ChainError is returned, if no chain from a client certificate to a client
+// CA could be built.
+type ChainError struct {
+	// Reason classifies the failure, it is one of the ChainReason constants.
+	Reason string
+	Err    error
+}
+
+func (e *ChainError) Error() string {
+	return fmt.Sprintf("client certificate verification failed (%s): %v", e.Reason, e.Err)
+}
+
+func (e *ChainError) Unwrap() error {
+	return e.Err
+}
+
+// ChainVerifier verifies client certificates against the client CAs. The
+// chains presented by clients are completed with the intermediates of a file
+// and optionally with intermediates fetched from the Authority Information
+// Access (AIA) URLs of the certificates. Failed verifications are counted by
+// reason and logged, instead of surfacing as opaque TLS alerts only. Valid
+// chains may further be restricted by a CertificatePolicy.
+//
+// The VerifyConnection signature is compatible with https://golang.org/pkg/crypto/tls/#Config.VerifyConnection.
+type ChainVerifier struct {
+	caPath            string
+	intermediatesPath string
+	policy            *CertificatePolicy
+
+	// AIA fetching is disabled, if aiaClient is nil. Only the allowed hosts
+	// are queried, as the URLs come from unverified certificates.
+	aiaHosts  sets.Set[string]
+	aiaClient *http.Client
+	aiaCache  *cache.LRUExpireCache
+
+	chainCache *cache.LRUExpireCache
+	// connStates caches the completed connection states by the presented
+	// one, which net/http shares between the requests of a connection, such
+	// that the completed state is stable for the connection, too.
+	connStates *cache.LRUExpireCache
+
+	mu            sync.RWMutex // protects the fields below
+	roots         *x509.CertPool
+	rootSubjects  sets.Set[string]
+	intermediates []*x509.Certificate
+}
+
+// NewChainVerifier creates a ChainVerifier for the CA bundle at caPath. If
+// intermediatesPath is set, the intermediates are loaded from it. If aiaHosts
+// are given, missing intermediates are fetched from AIA URLs of these hosts.
+// The policy is optional.
+func NewChainVerifier(caPath, intermediatesPath string, aiaHosts []string, policy *CertificatePolicy) (*ChainVerifier, error) {
+	v := &ChainVerifier{
+		caPath:            caPath,
+		intermediatesPath: intermediatesPath,
+		policy:            policy,
+		chainCache:        cache.NewLRUExpireCache(chainCacheSize),
+		connStates:        cache.NewLRUExpireCache(chainCacheSize),
+	}
+
+	commit, err := v.PrepareReload()
+	if err != nil {
+		return nil, err
+	}
+	commit()
+
+	if len(aiaHosts) > 0 {
+		v.aiaHosts = sets.New(aiaHosts...)
+		v.aiaClient = &http.Client{
+			Timeout: 5 * time.Second,
+			// Redirects could lead to hosts that aren't allowed.
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+		v.aiaCache = cache.NewLRUExpireCache(chainCacheSize)
+	}
+
+	return v, nil
+}
+
+// PrepareReload reads the CA bundle and the intermediates again. The
+// returned function applies them.
+func (v *ChainVerifier) PrepareReload() (func(), error) {
+	roots, err := certutil.CertsFromFile(v.caPath)
+	if err != nil {
+		return nil, fmt.Errorf("error loading client CA: %v", err)
+	}
+
+	var intermediates []*x509.Certificate
+	if v.intermediatesPath != "" {
+		intermediates, err = certutil.CertsFromFile(v.intermediatesPath)
+		if err != nil {
+			return nil, fmt.Errorf("error loading client CA intermediates: %v", err)
+		}
+	}
+
+	pool := x509.NewCertPool()
+	subjects := sets.New[string]()
+	for _, root := range roots {
+		pool.AddCert(root)
+		subjects.Insert(string(root.RawSubject))
+	}
+
+	return func() {
+		v.mu.Lock()
+		v.roots = pool
+		v.rootSubjects = subjects
+		v.intermediates = intermediates
+		v.mu.Unlock()
+		v.chainCache.RemoveAll(func(any) bool { return true })
+		v.connStates.RemoveAll(func(any) bool { return true })
+	}, nil
+}
+
+// VerifyConnection fails the handshake, if the presented client certificate
+// can't be verified. The failure is counted by reason and logged with the
+// subject and issuer of the certificate.
+func (v *ChainVerifier) VerifyConnection(cs tls.ConnectionState) error {
+	if len(cs.PeerCertificates) == 0 {
+		verificationFailures.WithLabelValues(ChainReasonOther).Inc()
+		return errors.New("client certificate required")
+	}
+
+	if _, err := v.Chain(cs.PeerCertificates); err != nil {
+		reason := ChainReasonOther
+		var chainErr *ChainError
+		if errors.As(err, &chainErr) {
+			reason = chainErr.Reason
+		}
+		verificationFailures.WithLabelValues(reason).Inc()
+
+		leaf := cs.PeerCertificates[0]
+		klog.V(2).InfoS("Client certificate verification failed",
+			"reason", reason,
+			"subject", leaf.Subject.String(),
+			"issuer", leaf.Issuer.String(),
+			"presentedCertificates", len(cs.PeerCertificates),
+			"serverName", cs.ServerName,
+			"err", err,
+		)
+		return err
+	}
+
+	return nil
+}
+
+// Chain verifies the leaf of the presented certificates and returns the
+// chain from the leaf to a client CA, if it meets the policy. Verified chains
+// are cached by leaf.
+func (v *ChainVerifier) Chain(presented []*x509.Certificate) ([]*x509.Certificate, error) {
+	leaf := presented[0]
+	if chain, ok := v.chainCache.Get(string(leaf.Raw)); ok {
+		return chain.([]*x509.Certificate), nil
+	}
+
+	v.mu.RLock()
+	roots, rootSubjects := v.roots, v.rootSubjects
+	candidates := append(append([]*x509.Certificate{}, presented[1:]...), v.intermediates...)
+	v.mu.RUnlock()
+
+	chain, err := verifyChain(leaf, roots, candidates)
+	if err != nil {
+		var unknownAuthority x509.UnknownAuthorityError
+		if !errors.As(err, &unknownAuthority) {
+			return nil, &ChainError{Reason: classify(err), Err: err}
+		}
+
+		// Walk up as far as the known certificates reach, fetching missing
+		// issuers, to tell missing intermediates from untrusted roots.
+		last, fetched := v.walk(leaf, rootSubjects, candidates)
+		if len(fetched) > 0 {
+			chain, err = verifyChain(leaf, roots, append(candidates, fetched...))
+		}
+		if err != nil {
+			reason := classify(err)
+			if errors.As(err, &unknownAuthority) {
+				reason = ChainReasonMissingIntermediate
+				// Either the chain ends in an untrusted root, or in a
+				// certificate named after a client CA, but not signed by it.
+				if bytes.Equal(last.RawIssuer, last.RawSubject) || rootSubjects.Has(string(last.RawIssuer)) {
+					reason = ChainReasonUnknownAuthority
+				}
+			}
+			return nil, &ChainError{Reason: reason, Err: err}
+		}
+	}
+
+	if reason, err := v.policy.check(chain); err != nil {
+		return nil, &ChainError{Reason: reason, Err: err}
+	}
+
+	ttl := chainCacheTTL
+	for _, cert := range chain {
+		if expiry := time.Until(cert.NotAfter); expiry < ttl {
+			ttl = expiry
+		}
+	}
+	v.chainCache.Add(string(leaf.Raw), chain, ttl)
+	return chain, nil
+}
+
+// WithCompletedChains replaces the presented client certificates of requests
+// with the verified chain, such that the request authentication verifies
+// certificates whose intermediates weren't presented by the client.
+// Certificates that can't be verified are passed on as presented. All
+// requests of a connection share the completed connection state, such that
+// the users cached per connection are found.
+func (v *ChainVerifier) WithCompletedChains(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.TLS == nil || len(req.TLS.PeerCertificates) == 0 {
+			handler.ServeHTTP(w, req)
+			return
+		}
+
+		if state := v.completedState(req.TLS); state != req.TLS {
+			req = req.Clone(req.Context())
+			req.TLS = state
+		}
+
+		handler.ServeHTTP(w, req)
+	})
+}
+
+// completedState returns the connection state with the verified chain, or
+// the presented state, if the chain is complete or can't be verified.
+func (v *ChainVerifier) completedState(presented *tls.ConnectionState) *tls.ConnectionState {
+	if state, ok := v.connStates.Get(presented); ok {
+		return state.(*tls.ConnectionState)
+	}
+
+	chain, err := v.Chain(presented.PeerCertificates)
+	if err != nil || len(chain) <= len(presented.PeerCertificates) {
+		return presented
+	}
+
+	state := *presented
+	// The root is excluded, it is known to the authenticator.
+	state.PeerCertificates = chain[:len(chain)-1]
+	ttl := chainCacheTTL
+	if expiry := time.Until(presented.PeerCertificates[0].NotAfter); expiry < ttl {
+		ttl = expiry
+	}
+	v.connStates.Add(presented, &state, ttl)
+	return &state
+}
+
+// walk follows the issuers of the leaf among the candidates and the fetched
+// intermediates, until a client CA or a self-issued certificate is reached.
+// It returns the last certificate found and the fetched intermediates.
+func (v *ChainVerifier) walk(leaf *x509.Certificate, rootSubjects sets.Set[string], candidates []*x509.Certificate) (*x509.Certificate, []*x509.Certificate) {
+	var fetched []*x509.Certificate
+	cur := leaf
+	for range maxChainDepth {
+		if rootSubjects.Has(string(cur.RawIssuer)) || bytes.Equal(cur.RawIssuer, cur.RawSubject) {
+			break
+		}
+
+		issuer := findIssuer(cur, candidates)
+		if issuer == nil && v.aiaClient != nil {
+			if issuer = v.fetchIssuer(cur); issuer != nil {
+				candidates = append(candidates, issuer)
+				fetched = append(fetched, issuer)
+			}
+		}
+		if issuer == nil {
+			break
+		}
+		cur = issuer
+	}
+	return cur, fetched
+}
+
+// fetchIssuer fetches the issuer of the certificate from its AIA URLs of the
+// allowed hosts. Results are cached by URL, failures for a shorter time.
+func (v *ChainVerifier) fetchIssuer(cert *x509.Certificate) *x509.Certificate {
+	for _, rawURL := range cert.IssuingCertificateURL {
+		u, err := url.Parse(rawURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || !v.aiaHosts.Has(u.Hostname()) {
+			klog.V(4).InfoS("Skipping AIA URL of client certificate", "url", rawURL)
+			continue
+		}
+
+		if cached, ok := v.aiaCache.Get(rawURL); ok {
+			if issuer, _ := cached.(*x509.Certificate); issuer != nil && cert.CheckSignatureFrom(issuer) == nil {
+				return issuer
+			}
+			continue
+		}
+
+		issuer, err := v.queryAIA(rawURL)
+		if err == nil && !issuer.IsCA {
+			err = errors.New("fetched certificate is not a CA")
+		}
+		if err == nil {
+			err = cert.CheckSignatureFrom(issuer)
+		}
+		if err != nil {
+			klog.V(2).InfoS("Fetching intermediate of client certificate failed", "url", rawURL, "err", err)
+			v.aiaCache.Add(rawURL, (*x509.Certificate)(nil), aiaFailureTTL)
+			continue
+		}
+
+		v.aiaCache.Add(rawURL, issuer, chainCacheTTL)
+		return issuer
+	}
+	return nil
+}
+
+func (v *ChainVerifier) queryAIA(rawURL string) (*x509.Certificate, error) {
+	resp, err := v.aiaClient.Get(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query AIA URL: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected AIA status code %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read AIA response: %w", err)
+	}
+
+	der := body
+	if block, _ := pem.Decode(body); block != nil {
+		der = block.Bytes
+	}
+	return x509.ParseCertificate(der)
+}
+
+func verifyChain(leaf *x509.Certificate, roots *x509.CertPool, candidates []*x509.Certificate) ([]*x509.Certificate, error) {
+	intermediates := x509.NewCertPool()
+	for _, cert := range candidates {
+		intermediates.AddCert(cert)
+	}
+
+	chains, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return chains[0], nil
+}
+
+func findIssuer(cert *x509.Certificate, candidates []*x509.Certificate) *x509.Certificate {
+	for _, candidate := range candidates {
+		if bytes.Equal(candidate.RawSubject, cert.RawIssuer) && cert.CheckSignatureFrom(candidate) == nil {
+			return candidate
+		}
+	}
+	return nil
+}
+
+func classify(err error) string {
+	var invalid x509.CertificateInvalidError
+	if errors.As(err, &invalid) {
+		switch invalid.Reason {
+		case x509.Expired:
+			return ChainReasonExpired
+		case x509.IncompatibleUsage:
+			return ChainReasonWrongEKU
+		}
+	}
+	return ChainReasonOther
+}
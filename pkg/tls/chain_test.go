@@ -0,0 +1,247 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// sign issues a certificate for the template, returning a testCA, such that
+// intermediates can issue certificates themselves.
+func (ca *testCA) sign(t *testing.T, tmpl *x509.Certificate) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tmpl.NotBefore.IsZero() {
+		tmpl.NotBefore = time.Now().Add(-time.Hour)
+	}
+	if tmpl.NotAfter.IsZero() {
+		tmpl.NotAfter = time.Now().Add(time.Hour)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, key.Public(), ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testCA{cert: cert, key: key}
+}
+
+func TestChainVerifier(t *testing.T) {
+	aiaRequests := 0
+	var aiaCert []byte
+	aia := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		aiaRequests++
+		_, _ = w.Write(aiaCert)
+	}))
+	defer aia.Close()
+	aiaURL, err := url.Parse(aia.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root := newTestCA(t)
+	intermediate := root.sign(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "intermediate"},
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	})
+	aiaCert = intermediate.cert.Raw
+	leaf := func(serial int64, tmpl *x509.Certificate) *x509.Certificate {
+		tmpl.SerialNumber = big.NewInt(serial)
+		tmpl.Subject = pkix.Name{CommonName: "client"}
+		if tmpl.ExtKeyUsage == nil {
+			tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+		}
+		return intermediate.sign(t, tmpl).cert
+	}
+	client := leaf(3, &x509.Certificate{IssuingCertificateURL: []string{aia.URL + "/intermediate.der"}})
+
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	root.writeCert(t, caPath)
+	intermediatesPath := filepath.Join(t.TempDir(), "intermediates.pem")
+	intermediate.writeCert(t, intermediatesPath)
+
+	other := newTestCA(t)
+	otherClient := other.sign(t, &x509.Certificate{
+		SerialNumber: big.NewInt(4),
+		Subject:      pkix.Name{CommonName: "client"},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}).cert
+
+	for _, tt := range []struct {
+		name              string
+		intermediatesPath string
+		aiaHosts          []string
+		presented         []*x509.Certificate
+		wantReason        string
+		wantAIARequests   int
+	}{
+		{
+			name:      "presented chain",
+			presented: []*x509.Certificate{client, intermediate.cert},
+		},
+		{
+			name:       "missing intermediate",
+			presented:  []*x509.Certificate{client},
+			wantReason: ChainReasonMissingIntermediate,
+		},
+		{
+			name:              "intermediate from file",
+			intermediatesPath: intermediatesPath,
+			presented:         []*x509.Certificate{client},
+		},
+		{
+			name:            "intermediate from AIA",
+			aiaHosts:        []string{aiaURL.Hostname()},
+			presented:       []*x509.Certificate{client},
+			wantAIARequests: 1,
+		},
+		{
+			name:       "AIA host not allowed",
+			aiaHosts:   []string{"ca.example.com"},
+			presented:  []*x509.Certificate{client},
+			wantReason: ChainReasonMissingIntermediate,
+		},
+		{
+			name:       "unknown authority",
+			presented:  []*x509.Certificate{otherClient, other.cert},
+			wantReason: ChainReasonUnknownAuthority,
+		},
+		{
+			name: "expired",
+			presented: []*x509.Certificate{leaf(5, &x509.Certificate{
+				NotBefore: time.Now().Add(-2 * time.Hour),
+				NotAfter:  time.Now().Add(-time.Hour),
+			}), intermediate.cert},
+			wantReason: ChainReasonExpired,
+		},
+		{
+			name: "wrong EKU",
+			presented: []*x509.Certificate{leaf(6, &x509.Certificate{
+				ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+			}), intermediate.cert},
+			wantReason: ChainReasonWrongEKU,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			aiaRequests = 0
+			v, err := NewChainVerifier(caPath, tt.intermediatesPath, tt.aiaHosts, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for range 2 {
+				err = v.VerifyConnection(tls.ConnectionState{PeerCertificates: tt.presented})
+			}
+			var chainErr *ChainError
+			switch {
+			case tt.wantReason == "" && err != nil:
+				t.Errorf("want verification to succeed, got %v", err)
+			case tt.wantReason != "" && !errors.As(err, &chainErr):
+				t.Errorf("want chain error %q, got %v", tt.wantReason, err)
+			case tt.wantReason != "" && chainErr.Reason != tt.wantReason:
+				t.Errorf("want reason %q, got %q", tt.wantReason, chainErr.Reason)
+			}
+			if aiaRequests != tt.wantAIARequests {
+				t.Errorf("want %d AIA requests, got %d", tt.wantAIARequests, aiaRequests)
+			}
+		})
+	}
+
+	if err := (&ChainVerifier{}).VerifyConnection(tls.ConnectionState{}); err == nil {
+		t.Error("want connections without client certificate to fail")
+	}
+}
+
+func TestChainVerifierWithCompletedChains(t *testing.T) {
+	root := newTestCA(t)
+	intermediate := root.sign(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "intermediate"},
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	})
+	client := intermediate.sign(t, &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "client"},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}).cert
+
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	root.writeCert(t, caPath)
+	intermediatesPath := filepath.Join(t.TempDir(), "intermediates.pem")
+	intermediate.writeCert(t, intermediatesPath)
+
+	v, err := NewChainVerifier(caPath, intermediatesPath, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []*x509.Certificate
+	handler := v.WithCompletedChains(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+		got = req.TLS.PeerCertificates
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "https://localhost/", nil)
+	req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{client}}
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(got) != 2 || !got[0].Equal(client) || !got[1].Equal(intermediate.cert) {
+		t.Errorf("want the chain completed with the intermediate, got %d certificates", len(got))
+	}
+	if len(req.TLS.PeerCertificates) != 1 {
+		t.Error("want the connection state of the original request to be untouched")
+	}
+
+	// Requests of a connection share its connection state, they must share
+	// the completed one, too.
+	var states []*tls.ConnectionState
+	handler = v.WithCompletedChains(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+		states = append(states, req.TLS)
+	}))
+	for range 2 {
+		next := httptest.NewRequest(http.MethodGet, "https://localhost/", nil)
+		next.TLS = req.TLS
+		handler.ServeHTTP(httptest.NewRecorder(), next)
+	}
+	if states[0] != states[1] {
+		t.Error("want the requests of a connection to share the completed connection state")
+	}
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tls
+
+import (
+	"crypto/tls"
+	"fmt"
+)
+
+const (
+	// ClientAuthNone doesn't ask clients for a certificate.
+	ClientAuthNone = "none"
+	// ClientAuthRequest asks clients for a certificate, which is verified
+	// by the authentication. Clients without one may use tokens.
+	ClientAuthRequest = "request"
+	// ClientAuthRequireVerify fails handshakes without a client certificate
+	// signed by the client CA.
+	ClientAuthRequireVerify = "require-verify"
+)
+
+// ClientAuthType maps a client auth policy to the TLS client auth type.
+func ClientAuthType(policy string) (tls.ClientAuthType, error) {
+	switch policy {
+	case ClientAuthNone:
+		return tls.NoClientCert, nil
+	case ClientAuthRequest:
+		return tls.RequestClientCert, nil
+	case ClientAuthRequireVerify:
+		return tls.RequireAndVerifyClientCert, nil
+	default:
+		return tls.NoClientCert, fmt.Errorf("unknown client auth policy %q, must be one of %s, %s or %s", policy, ClientAuthNone, ClientAuthRequest, ClientAuthRequireVerify)
+	}
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tls
+
+import (
+	"crypto/tls"
+	"testing"
+)
+
+func TestClientAuthType(t *testing.T) {
+	for policy, want := range map[string]tls.ClientAuthType{
+		ClientAuthNone:          tls.NoClientCert,
+		ClientAuthRequest:       tls.RequestClientCert,
+		ClientAuthRequireVerify: tls.RequireAndVerifyClientCert,
+	} {
+		got, err := ClientAuthType(policy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("want %v for %s, got %v", want, policy, got)
+		}
+	}
+
+	if _, err := ClientAuthType("require"); err == nil {
+		t.Error("want error for unknown policy")
+	}
+}
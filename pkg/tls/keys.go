@@ -0,0 +1,257 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tls
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+var (
+	oidPBES2  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+
+	// pbkdf2PRFs are the pseudorandom functions of PBKDF2 by OID.
+	pbkdf2PRFs = map[string]func() hash.Hash{
+		"1.2.840.113549.2.7":  sha1.New,
+		"1.2.840.113549.2.8":  sha256.New224,
+		"1.2.840.113549.2.9":  sha256.New,
+		"1.2.840.113549.2.10": sha512.New384,
+		"1.2.840.113549.2.11": sha512.New,
+	}
+	// pbes2Ciphers are the CBC ciphers of PBES2 by OID. Triple DES is
+	// still the default of openssl req.
+	pbes2Ciphers = map[string]pbes2Cipher{
+		"2.16.840.1.101.3.4.1.2":  {keySize: 16, newCipher: aes.NewCipher},
+		"2.16.840.1.101.3.4.1.22": {keySize: 24, newCipher: aes.NewCipher},
+		"2.16.840.1.101.3.4.1.42": {keySize: 32, newCipher: aes.NewCipher},
+		"1.2.840.113549.3.7":      {keySize: 24, newCipher: des.NewTripleDESCipher},
+	}
+)
+
+type pbes2Cipher struct {
+	keySize   int
+	newCipher func(key []byte) (cipher.Block, error)
+}
+
+// Passphrase is the source of the passphrase of an encrypted private key,
+// either a file or an environment variable. The zero value is no passphrase.
+type Passphrase struct {
+	File string
+	Env  string
+}
+
+// IsSet returns whether a source is configured.
+func (p Passphrase) IsSet() bool {
+	return p.File != "" || p.Env != ""
+}
+
+// Validate checks that at most one source is configured.
+func (p Passphrase) Validate() error {
+	if p.File != "" && p.Env != "" {
+		return errors.New("the passphrase can be read from either a file or an environment variable")
+	}
+	return nil
+}
+
+// read returns the passphrase, nil if no source is configured. A trailing
+// newline of the file is stripped.
+func (p Passphrase) read() ([]byte, error) {
+	switch {
+	case p.File != "":
+		b, err := os.ReadFile(p.File)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read passphrase file: %w", err)
+		}
+		return bytes.TrimRight(b, "\r\n"), nil
+	case p.Env != "":
+		v, ok := os.LookupEnv(p.Env)
+		if !ok || v == "" {
+			return nil, fmt.Errorf("passphrase environment variable %s is not set", p.Env)
+		}
+		return []byte(v), nil
+	}
+	return nil, nil
+}
+
+// LoadKeyPair reads a certificate and private key pair from the files, like
+// tls.LoadX509KeyPair, decrypting the key with the passphrase if it is
+// encrypted.
+func LoadKeyPair(certPath, keyPath string, passphrase Passphrase) (tls.Certificate, error) {
+	certPEM, err := os.ReadFile(certPath)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	keyPEM, err := os.ReadFile(keyPath)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	pass, err := passphrase.read()
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return parseKeyPair(certPEM, keyPEM, pass)
+}
+
+// parseKeyPair parses the pair like tls.X509KeyPair, after decrypting an
+// encrypted PKCS #8 key.
+func parseKeyPair(certPEM, keyPEM, passphrase []byte) (tls.Certificate, error) {
+	keyPEM, err := decryptKeyPEM(keyPEM, passphrase)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.X509KeyPair(certPEM, keyPEM)
+}
+
+// decryptKeyPEM returns the key PEM unchanged, unless it holds an encrypted
+// PKCS #8 key, which is returned decrypted.
+func decryptKeyPEM(keyPEM, passphrase []byte) ([]byte, error) {
+	rest := keyPEM
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return keyPEM, nil
+		}
+
+		// The legacy encryption of OpenSSL derives the key with MD5 from
+		// a single round, it is insecure and thus not supported.
+		if strings.Contains(block.Headers["Proc-Type"], "ENCRYPTED") {
+			return nil, errors.New("legacy PEM encryption of private keys is not supported, convert the key to encrypted PKCS #8, e.g. with openssl pkcs8 -topk8 -v2 aes-256-cbc")
+		}
+		if block.Type != "ENCRYPTED PRIVATE KEY" {
+			continue
+		}
+
+		if len(passphrase) == 0 {
+			return nil, errors.New("private key is encrypted, but no passphrase is configured")
+		}
+		der, err := decryptPKCS8(block.Bytes, passphrase)
+		if err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+	}
+}
+
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// decryptPKCS8 decrypts an EncryptedPrivateKeyInfo of RFC 5208 encrypted with
+// PBES2 of RFC 8018, using PBKDF2 and AES-CBC or DES-EDE3-CBC, as generated
+// by OpenSSL by default, and returns the PKCS #8 PrivateKeyInfo.
+func decryptPKCS8(der, passphrase []byte) ([]byte, error) {
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse encrypted private key: %w", err)
+	}
+	if !info.Algorithm.Algorithm.Equal(oidPBES2) {
+		return nil, fmt.Errorf("unsupported encryption %s of private key, only PBES2 is supported", info.Algorithm.Algorithm)
+	}
+
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, fmt.Errorf("failed to parse PBES2 parameters: %w", err)
+	}
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) {
+		return nil, fmt.Errorf("unsupported key derivation %s of private key, only PBKDF2 is supported", params.KeyDerivationFunc.Algorithm)
+	}
+	c, ok := pbes2Ciphers[params.EncryptionScheme.Algorithm.String()]
+	if !ok {
+		return nil, fmt.Errorf("unsupported cipher %s of private key, only AES-CBC and DES-EDE3-CBC are supported", params.EncryptionScheme.Algorithm)
+	}
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, errors.New("invalid IV of private key cipher")
+	}
+
+	var kdf pbkdf2Params
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdf); err != nil {
+		return nil, fmt.Errorf("failed to parse PBKDF2 parameters: %w", err)
+	}
+	if kdf.IterationCount <= 0 || (kdf.KeyLength != 0 && kdf.KeyLength != c.keySize) {
+		return nil, errors.New("invalid PBKDF2 parameters of private key")
+	}
+	prf := sha1.New
+	if len(kdf.PRF.Algorithm) > 0 {
+		if prf, ok = pbkdf2PRFs[kdf.PRF.Algorithm.String()]; !ok {
+			return nil, fmt.Errorf("unsupported PBKDF2 function %s of private key", kdf.PRF.Algorithm)
+		}
+	}
+
+	block, err := c.newCipher(pbkdf2.Key(passphrase, kdf.Salt, kdf.IterationCount, c.keySize, prf))
+	if err != nil {
+		return nil, err
+	}
+	data := info.EncryptedData
+	if len(iv) != block.BlockSize() {
+		return nil, errors.New("invalid IV of private key cipher")
+	}
+	if len(data) == 0 || len(data)%block.BlockSize() != 0 {
+		return nil, errors.New("invalid length of encrypted private key")
+	}
+	plain := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, data)
+
+	// A wrong passphrase shows as invalid padding or an unparsable key.
+	errWrongPassphrase := errors.New("failed to decrypt private key, the passphrase is wrong")
+	pad := int(plain[len(plain)-1])
+	if pad == 0 || pad > block.BlockSize() {
+		return nil, errWrongPassphrase
+	}
+	for _, b := range plain[len(plain)-pad:] {
+		if int(b) != pad {
+			return nil, errWrongPassphrase
+		}
+	}
+	plain = plain[:len(plain)-pad]
+	if _, err := x509.ParsePKCS8PrivateKey(plain); err != nil {
+		return nil, errWrongPassphrase
+	}
+	return plain, nil
+}
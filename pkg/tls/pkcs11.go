@@ -0,0 +1,255 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tls
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// PKCS #11 mechanisms, as of the specification, of the signatures of TLS.
+const (
+	ckmRSAPKCS    = 0x1
+	ckmRSAPKCSPSS = 0xd
+	ckmECDSA      = 0x1041
+)
+
+// pkcs1Prefixes are the DigestInfo prefixes of RSA PKCS #1 v1.5 signatures,
+// which CKM_RSA_PKCS expects along with the digest.
+var pkcs1Prefixes = map[crypto.Hash][]byte{
+	crypto.MD5SHA1: {},
+	crypto.SHA1:    {0x30, 0x21, 0x30, 0x09, 0x06, 0x05, 0x2b, 0x0e, 0x03, 0x02, 0x1a, 0x05, 0x00, 0x04, 0x14},
+	crypto.SHA256:  {0x30, 0x31, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x01, 0x05, 0x00, 0x04, 0x20},
+	crypto.SHA384:  {0x30, 0x41, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x02, 0x05, 0x00, 0x04, 0x30},
+	crypto.SHA512:  {0x30, 0x51, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x03, 0x05, 0x00, 0x04, 0x40},
+}
+
+// PKCS11URI identifies a private key on a PKCS #11 token, e.g. of an HSM or
+// a TPM, as of RFC 7512, e.g.
+// pkcs11:token=proxy;object=serving?module-path=/usr/lib/softhsm/libsofthsm2.so&pin-source=/etc/pin.
+type PKCS11URI struct {
+	// ModulePath is the PKCS #11 module of the token, a shared library.
+	ModulePath string
+	// Token is the label of the token.
+	Token string
+	// Object and ID are the label and the ID of the key, at least one of
+	// them is set.
+	Object string
+	ID     []byte
+	// PinSource is the file of the user PIN, the session isn't logged in
+	// without it.
+	PinSource string
+}
+
+// ParsePKCS11URI parses the URI of a private key. Attributes that aren't
+// supported are rejected, rather than selecting another key than intended.
+func ParsePKCS11URI(s string) (*PKCS11URI, error) {
+	rest, ok := strings.CutPrefix(s, "pkcs11:")
+	if !ok {
+		return nil, errors.New("PKCS #11 URI must start with pkcs11:")
+	}
+	path, query, _ := strings.Cut(rest, "?")
+
+	uri := &PKCS11URI{}
+	attrs := map[string]string{}
+	for _, part := range append(splitNonEmpty(path, ";"), splitNonEmpty(query, "&")...) {
+		name, value, _ := strings.Cut(part, "=")
+		unescaped, err := url.PathUnescape(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PKCS #11 URI attribute %s: %w", name, err)
+		}
+		if _, ok := attrs[name]; ok {
+			return nil, fmt.Errorf("duplicate PKCS #11 URI attribute %s", name)
+		}
+		attrs[name] = unescaped
+	}
+
+	for name, value := range attrs {
+		switch name {
+		case "module-path":
+			uri.ModulePath = value
+		case "token":
+			uri.Token = value
+		case "object":
+			uri.Object = value
+		case "id":
+			uri.ID = []byte(value)
+		case "pin-source":
+			uri.PinSource = strings.TrimPrefix(value, "file:")
+		case "type":
+			if value != "private" {
+				return nil, fmt.Errorf("PKCS #11 URI must select a private key, got type %s", value)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported PKCS #11 URI attribute %s", name)
+		}
+	}
+
+	if uri.ModulePath == "" {
+		return nil, errors.New("PKCS #11 URI must set the module-path")
+	}
+	if uri.Token == "" {
+		return nil, errors.New("PKCS #11 URI must set the token")
+	}
+	if uri.Object == "" && len(uri.ID) == 0 {
+		return nil, errors.New("PKCS #11 URI must set the object or the id of the key")
+	}
+	return uri, nil
+}
+
+func splitNonEmpty(s, sep string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, sep)
+}
+
+// pin returns the user PIN, nil if no pin-source is set. A trailing newline
+// of the file is stripped.
+func (u *PKCS11URI) pin() ([]byte, error) {
+	if u.PinSource == "" {
+		return nil, nil
+	}
+	b, err := os.ReadFile(u.PinSource)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PIN file: %w", err)
+	}
+	return bytes.TrimRight(b, "\r\n"), nil
+}
+
+// pkcs11Key is a private key on a token, which signs the input with the
+// mechanism.
+type pkcs11Key interface {
+	sign(mechanism pkcs11Mechanism, input []byte) ([]byte, error)
+}
+
+type pkcs11Mechanism struct {
+	typ uint
+	// hash and saltLength are the parameters of CKM_RSA_PKCS_PSS.
+	hash       crypto.Hash
+	saltLength int
+}
+
+// pkcs11Signer is the private key of a certificate whose key is on a token.
+// The public key is the one of the certificate, the key pair is checked by a
+// signature when the certificate is loaded.
+type pkcs11Signer struct {
+	key    pkcs11Key
+	public crypto.PublicKey
+}
+
+func (s *pkcs11Signer) Public() crypto.PublicKey {
+	return s.public
+}
+
+// Sign signs the digest, encoding the signature like the keys of the
+// standard library do, ASN.1 for ECDSA.
+func (s *pkcs11Signer) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	switch public := s.public.(type) {
+	case *ecdsa.PublicKey:
+		raw, err := s.key.sign(pkcs11Mechanism{typ: ckmECDSA}, digest)
+		if err != nil {
+			return nil, err
+		}
+		if len(raw) == 0 || len(raw)%2 != 0 {
+			return nil, fmt.Errorf("invalid ECDSA signature length %d", len(raw))
+		}
+		return asn1.Marshal(struct{ R, S *big.Int }{
+			R: new(big.Int).SetBytes(raw[:len(raw)/2]),
+			S: new(big.Int).SetBytes(raw[len(raw)/2:]),
+		})
+	case *rsa.PublicKey:
+		if pss, ok := opts.(*rsa.PSSOptions); ok {
+			saltLength := pss.SaltLength
+			switch saltLength {
+			case rsa.PSSSaltLengthEqualsHash:
+				saltLength = pss.Hash.Size()
+			case rsa.PSSSaltLengthAuto:
+				saltLength = (public.N.BitLen()-1+7)/8 - 2 - pss.Hash.Size()
+			}
+			return s.key.sign(pkcs11Mechanism{typ: ckmRSAPKCSPSS, hash: pss.Hash, saltLength: saltLength}, digest)
+		}
+		prefix, ok := pkcs1Prefixes[opts.HashFunc()]
+		if !ok {
+			return nil, fmt.Errorf("unsupported hash %v of RSA PKCS #1 v1.5 signatures", opts.HashFunc())
+		}
+		input := make([]byte, 0, len(prefix)+len(digest))
+		input = append(append(input, prefix...), digest...)
+		return s.key.sign(pkcs11Mechanism{typ: ckmRSAPKCS}, input)
+	}
+	return nil, fmt.Errorf("unsupported PKCS #11 key type %T", s.public)
+}
+
+// parsePKCS11KeyPair parses the certificate chain whose key is on the token.
+// Like tls.X509KeyPair for keys in files, it fails if the certificate
+// doesn't match the key.
+func parsePKCS11KeyPair(certPEM []byte, key pkcs11Key) (tls.Certificate, error) {
+	var cert tls.Certificate
+	for {
+		var block *pem.Block
+		block, certPEM = pem.Decode(certPEM)
+		if block == nil {
+			break
+		}
+		if block.Type == "CERTIFICATE" {
+			cert.Certificate = append(cert.Certificate, block.Bytes)
+		}
+	}
+	if len(cert.Certificate) == 0 {
+		return tls.Certificate{}, errors.New("failed to find any PEM data in certificate input")
+	}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	signer := &pkcs11Signer{key: key, public: leaf.PublicKey}
+
+	digest := sha256.Sum256(leaf.Raw)
+	sig, err := signer.Sign(nil, digest[:], crypto.SHA256)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to sign with the PKCS #11 key: %w", err)
+	}
+	switch public := leaf.PublicKey.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(public, digest[:], sig) {
+			return tls.Certificate{}, errors.New("private key does not match public key")
+		}
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(public, crypto.SHA256, digest[:], sig); err != nil {
+			return tls.Certificate{}, errors.New("private key does not match public key")
+		}
+	}
+
+	cert.PrivateKey = signer
+	cert.Leaf = leaf
+	return cert, nil
+}
@@ -0,0 +1,393 @@
+//go:build pkcs11 && cgo
+
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tls
+
+/*
+#cgo LDFLAGS: -ldl
+
+#include <dlfcn.h>
+#include <stdlib.h>
+#include <string.h>
+
+// The subset of the PKCS #11 v2.40 types the key needs, the function list
+// is declared up to C_Sign, the entries are in the order of the
+// specification.
+typedef unsigned char CK_BYTE;
+typedef unsigned char CK_BBOOL;
+typedef unsigned long CK_ULONG;
+typedef CK_ULONG CK_RV;
+typedef CK_ULONG CK_SLOT_ID;
+typedef CK_ULONG CK_SESSION_HANDLE;
+typedef CK_ULONG CK_OBJECT_HANDLE;
+
+typedef struct { CK_BYTE major; CK_BYTE minor; } CK_VERSION;
+
+typedef struct {
+	CK_ULONG type;
+	void *pValue;
+	CK_ULONG ulValueLen;
+} CK_ATTRIBUTE;
+
+typedef struct {
+	CK_ULONG mechanism;
+	void *pParameter;
+	CK_ULONG ulParameterLen;
+} CK_MECHANISM;
+
+typedef struct {
+	CK_ULONG hashAlg;
+	CK_ULONG mgf;
+	CK_ULONG sLen;
+} CK_RSA_PKCS_PSS_PARAMS;
+
+typedef struct {
+	void *CreateMutex;
+	void *DestroyMutex;
+	void *LockMutex;
+	void *UnlockMutex;
+	CK_ULONG flags;
+	void *pReserved;
+} CK_C_INITIALIZE_ARGS;
+
+typedef struct {
+	CK_BYTE label[32];
+	CK_BYTE manufacturerID[32];
+	CK_BYTE model[16];
+	CK_BYTE serialNumber[16];
+	CK_ULONG flags;
+	// The remaining fields aren't read.
+} CK_TOKEN_INFO_PREFIX;
+
+typedef void *CK_FN;
+
+typedef struct {
+	CK_VERSION version;
+	CK_RV (*C_Initialize)(void *);
+	CK_FN C_Finalize;
+	CK_FN C_GetInfo;
+	CK_FN C_GetFunctionList;
+	CK_RV (*C_GetSlotList)(CK_BBOOL, CK_SLOT_ID *, CK_ULONG *);
+	CK_FN C_GetSlotInfo;
+	CK_RV (*C_GetTokenInfo)(CK_SLOT_ID, void *);
+	CK_FN C_GetMechanismList;
+	CK_FN C_GetMechanismInfo;
+	CK_FN C_InitToken;
+	CK_FN C_InitPIN;
+	CK_FN C_SetPIN;
+	CK_RV (*C_OpenSession)(CK_SLOT_ID, CK_ULONG, void *, void *, CK_SESSION_HANDLE *);
+	CK_FN C_CloseSession;
+	CK_FN C_CloseAllSessions;
+	CK_FN C_GetSessionInfo;
+	CK_FN C_GetOperationState;
+	CK_FN C_SetOperationState;
+	CK_RV (*C_Login)(CK_SESSION_HANDLE, CK_ULONG, CK_BYTE *, CK_ULONG);
+	CK_FN C_Logout;
+	CK_FN C_CreateObject;
+	CK_FN C_CopyObject;
+	CK_FN C_DestroyObject;
+	CK_FN C_GetObjectSize;
+	CK_FN C_GetAttributeValue;
+	CK_FN C_SetAttributeValue;
+	CK_RV (*C_FindObjectsInit)(CK_SESSION_HANDLE, CK_ATTRIBUTE *, CK_ULONG);
+	CK_RV (*C_FindObjects)(CK_SESSION_HANDLE, CK_OBJECT_HANDLE *, CK_ULONG, CK_ULONG *);
+	CK_RV (*C_FindObjectsFinal)(CK_SESSION_HANDLE);
+	CK_FN C_EncryptInit;
+	CK_FN C_Encrypt;
+	CK_FN C_EncryptUpdate;
+	CK_FN C_EncryptFinal;
+	CK_FN C_DecryptInit;
+	CK_FN C_Decrypt;
+	CK_FN C_DecryptUpdate;
+	CK_FN C_DecryptFinal;
+	CK_FN C_DigestInit;
+	CK_FN C_Digest;
+	CK_FN C_DigestUpdate;
+	CK_FN C_DigestKey;
+	CK_FN C_DigestFinal;
+	CK_RV (*C_SignInit)(CK_SESSION_HANDLE, CK_MECHANISM *, CK_OBJECT_HANDLE);
+	CK_RV (*C_Sign)(CK_SESSION_HANDLE, CK_BYTE *, CK_ULONG, CK_BYTE *, CK_ULONG *);
+} CK_FUNCTION_LIST_PREFIX;
+
+typedef CK_RV (*CK_C_GetFunctionList)(CK_FUNCTION_LIST_PREFIX **);
+
+static CK_RV load_module(const char *path, CK_FUNCTION_LIST_PREFIX **fl, char **err) {
+	void *module = dlopen(path, RTLD_NOW | RTLD_LOCAL);
+	if (module == NULL) {
+		*err = strdup(dlerror());
+		return (CK_RV)-1;
+	}
+	CK_C_GetFunctionList getFunctionList = (CK_C_GetFunctionList)dlsym(module, "C_GetFunctionList");
+	if (getFunctionList == NULL) {
+		*err = strdup(dlerror());
+		dlclose(module);
+		return (CK_RV)-1;
+	}
+	return getFunctionList(fl);
+}
+
+static CK_RV initialize(CK_FUNCTION_LIST_PREFIX *fl) {
+	// The module locks with the native mutexes of the OS, the key is used
+	// from several goroutines.
+	CK_C_INITIALIZE_ARGS args = {0};
+	args.flags = 0x2; // CKF_OS_LOCKING_OK
+	return fl->C_Initialize(&args);
+}
+
+static CK_RV get_slot_list(CK_FUNCTION_LIST_PREFIX *fl, CK_SLOT_ID *slots, CK_ULONG *count) {
+	return fl->C_GetSlotList(1, slots, count);
+}
+
+static CK_RV get_token_label(CK_FUNCTION_LIST_PREFIX *fl, CK_SLOT_ID slot, CK_BYTE *label) {
+	// Modules write the whole CK_TOKEN_INFO, which is reserved generously.
+	CK_BYTE info[1024];
+	CK_RV rv = fl->C_GetTokenInfo(slot, info);
+	if (rv == 0) {
+		memcpy(label, ((CK_TOKEN_INFO_PREFIX *)info)->label, 32);
+	}
+	return rv;
+}
+
+static CK_RV open_session(CK_FUNCTION_LIST_PREFIX *fl, CK_SLOT_ID slot, CK_SESSION_HANDLE *session) {
+	return fl->C_OpenSession(slot, 0x4, NULL, NULL, session); // CKF_SERIAL_SESSION
+}
+
+static CK_RV login(CK_FUNCTION_LIST_PREFIX *fl, CK_SESSION_HANDLE session, CK_BYTE *pin, CK_ULONG pinLen) {
+	return fl->C_Login(session, 1, pin, pinLen); // CKU_USER
+}
+
+static CK_RV find_objects(CK_FUNCTION_LIST_PREFIX *fl, CK_SESSION_HANDLE session, CK_ATTRIBUTE *tmpl, CK_ULONG tmplLen, CK_OBJECT_HANDLE *objects, CK_ULONG max, CK_ULONG *count) {
+	CK_RV rv = fl->C_FindObjectsInit(session, tmpl, tmplLen);
+	if (rv != 0) {
+		return rv;
+	}
+	rv = fl->C_FindObjects(session, objects, max, count);
+	CK_RV final = fl->C_FindObjectsFinal(session);
+	return rv != 0 ? rv : final;
+}
+
+static CK_RV sign(CK_FUNCTION_LIST_PREFIX *fl, CK_SESSION_HANDLE session, CK_OBJECT_HANDLE key, CK_MECHANISM *mechanism, CK_BYTE *input, CK_ULONG inputLen, CK_BYTE *sig, CK_ULONG *sigLen) {
+	CK_RV rv = fl->C_SignInit(session, mechanism, key);
+	if (rv != 0) {
+		return rv;
+	}
+	return fl->C_Sign(session, input, inputLen, sig, sigLen);
+}
+*/
+import "C"
+
+import (
+	"bytes"
+	"crypto"
+	"errors"
+	"fmt"
+	"sync"
+	"unsafe"
+)
+
+const (
+	ckrCryptokiAlreadyInitialized = 0x191
+	ckrUserAlreadyLoggedIn        = 0x100
+
+	ckaClass      = 0x0
+	ckaLabel      = 0x3
+	ckaID         = 0x102
+	ckoPrivateKey = 0x3
+
+	// maxPKCS11SignatureSize is large enough for RSA keys of 16384 bits.
+	maxPKCS11SignatureSize = 2048
+)
+
+// pkcs11Hashes are the PKCS #11 mechanisms and mask generation functions
+// of the hashes of RSA-PSS signatures.
+var pkcs11Hashes = map[crypto.Hash]struct{ mechanism, mgf C.CK_ULONG }{
+	crypto.SHA1:   {0x220, 0x1},
+	crypto.SHA256: {0x250, 0x2},
+	crypto.SHA384: {0x260, 0x3},
+	crypto.SHA512: {0x270, 0x4},
+}
+
+// pkcs11Token is a private key on a token of a module, which is loaded
+// once for the lifetime of the process. The key is used through a single
+// session, whose operations are serialized.
+type pkcs11Token struct {
+	fl *C.CK_FUNCTION_LIST_PREFIX
+
+	mu      sync.Mutex // serializes the operations of the session
+	session C.CK_SESSION_HANDLE
+	object  C.CK_OBJECT_HANDLE
+}
+
+type pkcs11Error struct {
+	op string
+	rv C.CK_RV
+}
+
+func (e pkcs11Error) Error() string {
+	return fmt.Sprintf("PKCS #11 %s failed: CKR 0x%x", e.op, uint64(e.rv))
+}
+
+func openPKCS11Key(uri *PKCS11URI) (pkcs11Key, error) {
+	t := &pkcs11Token{}
+
+	modulePath := C.CString(uri.ModulePath)
+	defer C.free(unsafe.Pointer(modulePath))
+	var cerr *C.char
+	if rv := C.load_module(modulePath, &t.fl, &cerr); rv != 0 {
+		if cerr != nil {
+			defer C.free(unsafe.Pointer(cerr))
+			return nil, fmt.Errorf("failed to load PKCS #11 module: %s", C.GoString(cerr))
+		}
+		return nil, pkcs11Error{"C_GetFunctionList", rv}
+	}
+	if rv := C.initialize(t.fl); rv != 0 && rv != ckrCryptokiAlreadyInitialized {
+		return nil, pkcs11Error{"C_Initialize", rv}
+	}
+
+	slot, err := t.findSlot(uri.Token)
+	if err != nil {
+		return nil, err
+	}
+	if rv := C.open_session(t.fl, slot, &t.session); rv != 0 {
+		return nil, pkcs11Error{"C_OpenSession", rv}
+	}
+
+	pin, err := uri.pin()
+	if err != nil {
+		return nil, err
+	}
+	if len(pin) > 0 {
+		cpin := C.CBytes(pin)
+		defer C.free(cpin)
+		if rv := C.login(t.fl, t.session, (*C.CK_BYTE)(cpin), C.CK_ULONG(len(pin))); rv != 0 && rv != ckrUserAlreadyLoggedIn {
+			return nil, pkcs11Error{"C_Login", rv}
+		}
+	}
+
+	if t.object, err = t.findKey(uri.Object, uri.ID); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// findSlot returns the slot of the token with the label.
+func (t *pkcs11Token) findSlot(label string) (C.CK_SLOT_ID, error) {
+	var count C.CK_ULONG
+	if rv := C.get_slot_list(t.fl, nil, &count); rv != 0 {
+		return 0, pkcs11Error{"C_GetSlotList", rv}
+	}
+	if count == 0 {
+		return 0, errors.New("no PKCS #11 token present")
+	}
+	slots := (*C.CK_SLOT_ID)(C.malloc(C.size_t(count) * C.size_t(unsafe.Sizeof(C.CK_SLOT_ID(0)))))
+	defer C.free(unsafe.Pointer(slots))
+	if rv := C.get_slot_list(t.fl, slots, &count); rv != 0 {
+		return 0, pkcs11Error{"C_GetSlotList", rv}
+	}
+
+	labelBuf := (*C.CK_BYTE)(C.malloc(32))
+	defer C.free(unsafe.Pointer(labelBuf))
+	for _, slot := range unsafe.Slice(slots, count) {
+		if rv := C.get_token_label(t.fl, slot, labelBuf); rv != 0 {
+			return 0, pkcs11Error{"C_GetTokenInfo", rv}
+		}
+		// Labels are padded with blanks.
+		if string(bytes.TrimRight(C.GoBytes(unsafe.Pointer(labelBuf), 32), " ")) == label {
+			return slot, nil
+		}
+	}
+	return 0, fmt.Errorf("no PKCS #11 token with the label %q", label)
+}
+
+type pkcs11Attribute struct {
+	typ   C.CK_ULONG
+	value []byte
+}
+
+// findKey returns the private key with the label and ID, either may be
+// empty. Exactly one key must match.
+func (t *pkcs11Token) findKey(label string, id []byte) (C.CK_OBJECT_HANDLE, error) {
+	attrs := []pkcs11Attribute{{ckaClass, ulongBytes(ckoPrivateKey)}}
+	if label != "" {
+		attrs = append(attrs, pkcs11Attribute{ckaLabel, []byte(label)})
+	}
+	if len(id) > 0 {
+		attrs = append(attrs, pkcs11Attribute{ckaID, id})
+	}
+
+	// The template holds pointers, it is allocated in C.
+	tmpl := (*C.CK_ATTRIBUTE)(C.malloc(C.size_t(len(attrs)) * C.size_t(unsafe.Sizeof(C.CK_ATTRIBUTE{}))))
+	defer C.free(unsafe.Pointer(tmpl))
+	for i, attr := range unsafe.Slice(tmpl, len(attrs)) {
+		value := C.CBytes(attrs[i].value)
+		defer C.free(value)
+		attr._type = attrs[i].typ
+		attr.pValue = value
+		attr.ulValueLen = C.CK_ULONG(len(attrs[i].value))
+		unsafe.Slice(tmpl, len(attrs))[i] = attr
+	}
+
+	objects := (*C.CK_OBJECT_HANDLE)(C.malloc(2 * C.size_t(unsafe.Sizeof(C.CK_OBJECT_HANDLE(0)))))
+	defer C.free(unsafe.Pointer(objects))
+	var count C.CK_ULONG
+	if rv := C.find_objects(t.fl, t.session, tmpl, C.CK_ULONG(len(attrs)), objects, 2, &count); rv != 0 {
+		return 0, pkcs11Error{"C_FindObjects", rv}
+	}
+	switch count {
+	case 0:
+		return 0, errors.New("no PKCS #11 private key matches the object and id")
+	case 1:
+		return *objects, nil
+	}
+	return 0, errors.New("several PKCS #11 private keys match the object and id")
+}
+
+func (t *pkcs11Token) sign(mechanism pkcs11Mechanism, input []byte) ([]byte, error) {
+	m := (*C.CK_MECHANISM)(C.calloc(1, C.size_t(unsafe.Sizeof(C.CK_MECHANISM{}))))
+	defer C.free(unsafe.Pointer(m))
+	m.mechanism = C.CK_ULONG(mechanism.typ)
+	if mechanism.typ == ckmRSAPKCSPSS {
+		hash, ok := pkcs11Hashes[mechanism.hash]
+		if !ok {
+			return nil, fmt.Errorf("unsupported hash %v of RSA-PSS signatures", mechanism.hash)
+		}
+		params := (*C.CK_RSA_PKCS_PSS_PARAMS)(C.malloc(C.size_t(unsafe.Sizeof(C.CK_RSA_PKCS_PSS_PARAMS{}))))
+		defer C.free(unsafe.Pointer(params))
+		*params = C.CK_RSA_PKCS_PSS_PARAMS{hashAlg: hash.mechanism, mgf: hash.mgf, sLen: C.CK_ULONG(mechanism.saltLength)}
+		m.pParameter = unsafe.Pointer(params)
+		m.ulParameterLen = C.CK_ULONG(unsafe.Sizeof(*params))
+	}
+
+	cinput := C.CBytes(input)
+	defer C.free(cinput)
+	sig := C.malloc(maxPKCS11SignatureSize)
+	defer C.free(sig)
+	sigLen := C.CK_ULONG(maxPKCS11SignatureSize)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if rv := C.sign(t.fl, t.session, t.object, m, (*C.CK_BYTE)(cinput), C.CK_ULONG(len(input)), (*C.CK_BYTE)(sig), &sigLen); rv != 0 {
+		return nil, pkcs11Error{"C_Sign", rv}
+	}
+	return C.GoBytes(sig, C.int(sigLen)), nil
+}
+
+// ulongBytes returns the native encoding of a CK_ULONG attribute value.
+func ulongBytes(v C.CK_ULONG) []byte {
+	return C.GoBytes(unsafe.Pointer(&v), C.int(unsafe.Sizeof(v)))
+}
@@ -0,0 +1,27 @@
+//go:build !pkcs11 || !cgo
+
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tls
+
+import "errors"
+
+// openPKCS11Key fails, PKCS #11 modules are shared libraries, which the
+// statically linked proxy can't load.
+func openPKCS11Key(*PKCS11URI) (pkcs11Key, error) {
+	return nil, errors.New("PKCS #11 keys require a build with cgo and the pkcs11 build tag, e.g. CGO_ENABLED=1 go build -tags pkcs11")
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tls
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParsePKCS11URI(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		uri     string
+		want    *PKCS11URI
+		wantErr bool
+	}{
+		{
+			name: "token and object",
+			uri:  "pkcs11:token=proxy;object=serving;type=private?module-path=/usr/lib/softhsm/libsofthsm2.so&pin-source=file:/etc/pin",
+			want: &PKCS11URI{ModulePath: "/usr/lib/softhsm/libsofthsm2.so", Token: "proxy", Object: "serving", PinSource: "/etc/pin"},
+		},
+		{
+			name: "percent-encoded id",
+			uri:  "pkcs11:token=my%20token;id=%01%02?module-path=/lib/p11.so",
+			want: &PKCS11URI{ModulePath: "/lib/p11.so", Token: "my token", ID: []byte{1, 2}},
+		},
+		{name: "other scheme", uri: "file:/etc/key.pem", wantErr: true},
+		{name: "without module", uri: "pkcs11:token=proxy;object=serving", wantErr: true},
+		{name: "without token", uri: "pkcs11:object=serving?module-path=/lib/p11.so", wantErr: true},
+		{name: "without key", uri: "pkcs11:token=proxy?module-path=/lib/p11.so", wantErr: true},
+		{name: "public key", uri: "pkcs11:token=proxy;object=serving;type=public?module-path=/lib/p11.so", wantErr: true},
+		{name: "unsupported attribute", uri: "pkcs11:token=proxy;object=serving;slot-id=1?module-path=/lib/p11.so", wantErr: true},
+		{name: "duplicate attribute", uri: "pkcs11:token=proxy;object=serving;object=other?module-path=/lib/p11.so", wantErr: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePKCS11URI(tt.uri)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("want error %t, got %v", tt.wantErr, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("want %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPKCS11CertReloader(t *testing.T) {
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name       string
+		key        crypto.Signer
+		maxVersion uint16
+	}{
+		{name: "ECDSA", key: ecdsaKey, maxVersion: tls.VersionTLS13},
+		{name: "ECDSA TLS 1.2", key: ecdsaKey, maxVersion: tls.VersionTLS12},
+		{name: "RSA", key: rsaKey, maxVersion: tls.VersionTLS13},
+		{name: "RSA TLS 1.2", key: rsaKey, maxVersion: tls.VersionTLS12},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			certPath, cert := writePKCS11TestCert(t, tt.key)
+			r := &CertReloader{certPath: certPath, keyPath: "pkcs11:token=test;object=test", pkcs11Key: softPKCS11Key{tt.key}}
+			if err := r.reload(); err != nil {
+				t.Fatal(err)
+			}
+
+			roots := x509.NewCertPool()
+			roots.AddCert(cert)
+			serverConn, clientConn := net.Pipe()
+			defer clientConn.Close()
+			go func() {
+				defer serverConn.Close()
+				_ = tls.Server(serverConn, &tls.Config{GetCertificate: r.GetCertificate, MaxVersion: tt.maxVersion}).Handshake()
+			}()
+			if err := tls.Client(clientConn, &tls.Config{RootCAs: roots, ServerName: "localhost"}).Handshake(); err != nil {
+				t.Errorf("want handshake signed by the token, got %v", err)
+			}
+		})
+	}
+
+	t.Run("mismatching key", func(t *testing.T) {
+		certPath, _ := writePKCS11TestCert(t, ecdsaKey)
+		otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := &CertReloader{certPath: certPath, keyPath: "pkcs11:token=test;object=test", pkcs11Key: softPKCS11Key{otherKey}}
+		if err := r.reload(); err == nil {
+			t.Error("want certificate of another key to be rejected")
+		}
+	})
+}
+
+// softPKCS11Key implements the mechanisms of a token in software.
+type softPKCS11Key struct {
+	key crypto.Signer
+}
+
+func (k softPKCS11Key) sign(mechanism pkcs11Mechanism, input []byte) ([]byte, error) {
+	switch key := k.key.(type) {
+	case *ecdsa.PrivateKey:
+		if mechanism.typ != ckmECDSA {
+			break
+		}
+		r, s, err := ecdsa.Sign(rand.Reader, key, input)
+		if err != nil {
+			return nil, err
+		}
+		// Tokens return r and s concatenated, padded to the size of the
+		// curve.
+		size := (key.Curve.Params().BitSize + 7) / 8
+		raw := make([]byte, 2*size)
+		r.FillBytes(raw[:size])
+		s.FillBytes(raw[size:])
+		return raw, nil
+	case *rsa.PrivateKey:
+		switch mechanism.typ {
+		case ckmRSAPKCS:
+			// The input is the DigestInfo, which is signed as is.
+			return rsa.SignPKCS1v15(rand.Reader, key, 0, input)
+		case ckmRSAPKCSPSS:
+			return rsa.SignPSS(rand.Reader, key, mechanism.hash, input, &rsa.PSSOptions{SaltLength: mechanism.saltLength})
+		}
+	}
+	return nil, errors.New("mechanism not supported by the key")
+}
+
+// writePKCS11TestCert writes a self-signed certificate of the key for
+// localhost.
+func writePKCS11TestCert(t *testing.T, key crypto.Signer) (string, *x509.Certificate) {
+	t.Helper()
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "cert.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path, cert
+}
@@ -37,7 +37,11 @@ import (
 // For hot-reloading the Watch method must be started explicitly.
 type CertReloader struct {
 	certPath, keyPath string
-	interval          time.Duration
+	passphrase        Passphrase
+	// pkcs11Key is set, if the key is on a PKCS #11 token, keyPath is the
+	// URI of the key then.
+	pkcs11Key pkcs11Key
+	interval  time.Duration
 
 	mu              sync.RWMutex // protects the fields below
 	cert            *tls.Certificate
@@ -45,11 +49,41 @@ type CertReloader struct {
 }
 
 // NewCertReloader creates a new CertReloader that loads certs in an interval.
-func NewCertReloader(certPath, keyPath string, interval time.Duration) (*CertReloader, error) {
+// An encrypted key is decrypted with the passphrase, which is read again on
+// each reload of a changed key.
+func NewCertReloader(certPath, keyPath string, passphrase Passphrase, interval time.Duration) (*CertReloader, error) {
 	r := &CertReloader{
-		certPath: certPath,
-		keyPath:  keyPath,
-		interval: interval,
+		certPath:   certPath,
+		keyPath:    keyPath,
+		passphrase: passphrase,
+		interval:   interval,
+	}
+
+	if err := r.reload(); err != nil {
+		return nil, fmt.Errorf("error loading certificates: %v", err)
+	}
+
+	return r, nil
+}
+
+// NewPKCS11CertReloader creates a new CertReloader that loads the
+// certificate in an interval, whose key is on a PKCS #11 token, e.g. of an
+// HSM or a TPM. The token is opened once, the key never leaves it.
+func NewPKCS11CertReloader(certPath, keyURI string, interval time.Duration) (*CertReloader, error) {
+	uri, err := ParsePKCS11URI(keyURI)
+	if err != nil {
+		return nil, err
+	}
+	key, err := openPKCS11Key(uri)
+	if err != nil {
+		return nil, fmt.Errorf("error opening PKCS #11 key: %w", err)
+	}
+
+	r := &CertReloader{
+		certPath:  certPath,
+		keyPath:   keyURI,
+		pkcs11Key: key,
+		interval:  interval,
 	}
 
 	if err := r.reload(); err != nil {
@@ -78,14 +112,28 @@ func (r *CertReloader) Watch(ctx context.Context) error {
 }
 
 func (r *CertReloader) reload() error {
-	certRaw, err := os.ReadFile(r.certPath)
+	commit, err := r.PrepareReload()
 	if err != nil {
-		return fmt.Errorf("error loading certificate: %v", err)
+		return err
 	}
+	commit()
+	return nil
+}
 
-	keyRaw, err := os.ReadFile(r.keyPath)
+// PrepareReload reads and parses the certificate and key, the returned
+// function applies them. It allows reloading several inputs all or nothing.
+func (r *CertReloader) PrepareReload() (func(), error) {
+	certRaw, err := os.ReadFile(r.certPath)
 	if err != nil {
-		return fmt.Errorf("error loading key: %v", err)
+		return nil, fmt.Errorf("error loading certificate: %v", err)
+	}
+
+	var keyRaw []byte
+	if r.pkcs11Key == nil {
+		keyRaw, err = os.ReadFile(r.keyPath)
+		if err != nil {
+			return nil, fmt.Errorf("error loading key: %v", err)
+		}
 	}
 
 	r.mu.RLock()
@@ -93,23 +141,35 @@ func (r *CertReloader) reload() error {
 	r.mu.RUnlock()
 
 	if equal {
-		return nil
+		return func() {}, nil
 	}
 
-	klog.V(4).Info("reloading key ", r.keyPath, " certificate ", r.certPath)
-
-	cert, err := tls.X509KeyPair(certRaw, keyRaw)
+	cert, err := r.parse(certRaw, keyRaw)
 	if err != nil {
-		return fmt.Errorf("error parsing certificate: %v", err)
+		return nil, fmt.Errorf("error parsing certificate: %v", err)
 	}
 
-	r.mu.Lock()
-	r.cert = &cert
-	r.certRaw = certRaw
-	r.keyRaw = keyRaw
-	r.mu.Unlock()
+	return func() {
+		klog.V(4).InfoS("Reloading certificate", "key", r.keyPath, "certificate", r.certPath)
 
-	return nil
+		r.mu.Lock()
+		r.cert = &cert
+		r.certRaw = certRaw
+		r.keyRaw = keyRaw
+		r.mu.Unlock()
+	}, nil
+}
+
+func (r *CertReloader) parse(certRaw, keyRaw []byte) (tls.Certificate, error) {
+	if r.pkcs11Key != nil {
+		return parsePKCS11KeyPair(certRaw, r.pkcs11Key)
+	}
+
+	passphrase, err := r.passphrase.read()
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return parseKeyPair(certRaw, keyRaw, passphrase)
 }
 
 // GetCertificate returns the current valid certificate.
@@ -157,7 +157,7 @@ func commonNameIs(want string) checkFunc {
 }
 
 func newCertReloader(t *testing.T, s *scenario) {
-	r, err := NewCertReloader(s.certPath, s.keyPath, 10*time.Millisecond)
+	r, err := NewCertReloader(s.certPath, s.keyPath, Passphrase{}, 10*time.Millisecond)
 	if err != nil {
 		t.Fatalf("error creating cert reloader: %v", err)
 	}
@@ -0,0 +1,334 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tls
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+	"k8s.io/apimachinery/pkg/util/cache"
+	certutil "k8s.io/client-go/util/cert"
+	"k8s.io/klog/v2"
+)
+
+const (
+	ocspCacheSize  = 4096
+	ocspDefaultTTL = 5 * time.Minute
+)
+
+// RevocationChecker rejects client certificates that are revoked, either by a
+// CRL file or by the OCSP responder named in the certificate. CRLs must be
+// signed by a client CA, and client certificates are rejected while the CRL is
+// past its next update.
+//
+// The VerifyConnection signature is compatible with https://golang.org/pkg/crypto/tls/#Config.VerifyConnection.
+//
+// For hot-reloading the CRL the Watch method must be started explicitly.
+type RevocationChecker struct {
+	crlPath  string
+	caPath   string
+	interval time.Duration
+
+	// OCSP checking is disabled, if ocspClient is nil.
+	ocspClient *http.Client
+	ocspCache  *cache.LRUExpireCache
+
+	mu         sync.RWMutex // protects the fields below
+	issuers    []*x509.Certificate
+	crlRaw     []byte
+	revoked    map[string]struct{}
+	nextUpdate time.Time
+}
+
+// NewRevocationChecker creates a RevocationChecker. If crlPath is set, the
+// CRL is loaded from it in the given interval and verified against the CA
+// bundle at caPath. If checkOCSP is set, the OCSP responder of the client
+// certificate is queried. The issuer for the OCSP request is looked up in the
+// CA bundle at caPath only, such that unverified certificates can't make the
+// proxy query arbitrary responders.
+func NewRevocationChecker(crlPath string, interval time.Duration, checkOCSP bool, caPath string) (*RevocationChecker, error) {
+	c := &RevocationChecker{
+		crlPath:  crlPath,
+		caPath:   caPath,
+		interval: interval,
+		revoked:  map[string]struct{}{},
+	}
+
+	if caPath != "" {
+		issuers, err := certutil.CertsFromFile(caPath)
+		if err != nil {
+			return nil, fmt.Errorf("error loading client CA: %v", err)
+		}
+		c.issuers = issuers
+	}
+
+	if checkOCSP {
+		c.ocspClient = &http.Client{Timeout: 5 * time.Second}
+		c.ocspCache = cache.NewLRUExpireCache(ocspCacheSize)
+	}
+
+	if crlPath != "" {
+		if err := c.reload(); err != nil {
+			return nil, fmt.Errorf("error loading CRL: %v", err)
+		}
+	}
+
+	return c, nil
+}
+
+// Watch watches the configured CRL path and blocks the current goroutine
+// until the context is done or an error occurred during reloading.
+func (c *RevocationChecker) Watch(ctx context.Context) error {
+	if c.crlPath == "" {
+		<-ctx.Done()
+		return nil
+	}
+
+	t := time.NewTicker(c.interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return nil
+		}
+
+		if err := c.reload(); err != nil {
+			return fmt.Errorf("reloading CRL failed: %v", err)
+		}
+	}
+}
+
+// PrepareReload reads the CA bundle again, such that CRLs of rotated client
+// CAs are accepted. The returned function applies it.
+func (c *RevocationChecker) PrepareReload() (func(), error) {
+	if c.caPath == "" {
+		return func() {}, nil
+	}
+
+	issuers, err := certutil.CertsFromFile(c.caPath)
+	if err != nil {
+		return nil, fmt.Errorf("error loading client CA: %v", err)
+	}
+
+	return func() {
+		c.mu.Lock()
+		c.issuers = issuers
+		c.mu.Unlock()
+	}, nil
+}
+
+func (c *RevocationChecker) reload() error {
+	crlRaw, err := os.ReadFile(c.crlPath)
+	if err != nil {
+		return fmt.Errorf("error loading CRL: %v", err)
+	}
+
+	c.mu.RLock()
+	equal := bytes.Equal(crlRaw, c.crlRaw)
+	c.mu.RUnlock()
+
+	if equal {
+		return nil
+	}
+
+	klog.V(4).InfoS("Reloading CRL", "path", c.crlPath)
+
+	der := crlRaw
+	if block, _ := pem.Decode(crlRaw); block != nil {
+		der = block.Bytes
+	}
+
+	crl, err := x509.ParseRevocationList(der)
+	if err != nil {
+		return fmt.Errorf("error parsing CRL: %v", err)
+	}
+	if err := c.checkCRL(crl); err != nil {
+		return err
+	}
+
+	revoked := make(map[string]struct{}, len(crl.RevokedCertificateEntries))
+	for _, entry := range crl.RevokedCertificateEntries {
+		revoked[revocationKey(crl.RawIssuer, entry.SerialNumber.Bytes())] = struct{}{}
+	}
+
+	c.mu.Lock()
+	c.crlRaw = crlRaw
+	c.revoked = revoked
+	c.nextUpdate = crl.NextUpdate
+	c.mu.Unlock()
+
+	return nil
+}
+
+// checkCRL refuses CRLs that aren't signed by one of the client CAs. CRLs past
+// their next update are only logged, VerifyConnection rejects client
+// certificates until a current CRL is published.
+func (c *RevocationChecker) checkCRL(crl *x509.RevocationList) error {
+	c.mu.RLock()
+	issuers := c.issuers
+	c.mu.RUnlock()
+
+	signed := false
+	for _, issuer := range issuers {
+		if bytes.Equal(issuer.RawSubject, crl.RawIssuer) && crl.CheckSignatureFrom(issuer) == nil {
+			signed = true
+			break
+		}
+	}
+	if !signed {
+		return errors.New("CRL is not signed by a client CA")
+	}
+
+	if !crl.NextUpdate.IsZero() && time.Now().After(crl.NextUpdate) {
+		klog.ErrorS(nil, "CRL is stale, client certificates are rejected until it is updated", "path", c.crlPath, "nextUpdate", crl.NextUpdate)
+	}
+
+	return nil
+}
+
+// VerifyConnection fails the handshake, if a presented client certificate is
+// revoked. Connections without client certificates are accepted, they are
+// subject to the usual request authentication.
+func (c *RevocationChecker) VerifyConnection(cs tls.ConnectionState) error {
+	if len(cs.PeerCertificates) == 0 {
+		return nil
+	}
+
+	c.mu.RLock()
+	if !c.nextUpdate.IsZero() && time.Now().After(c.nextUpdate) {
+		c.mu.RUnlock()
+		return fmt.Errorf("CRL is stale, its next update was due at %s", c.nextUpdate.Format(time.RFC3339))
+	}
+	for _, cert := range cs.PeerCertificates {
+		if _, ok := c.revoked[revocationKey(cert.RawIssuer, cert.SerialNumber.Bytes())]; ok {
+			c.mu.RUnlock()
+			return fmt.Errorf("client certificate %q is revoked by CRL", cert.Subject.CommonName)
+		}
+	}
+	c.mu.RUnlock()
+
+	if c.ocspClient == nil {
+		return nil
+	}
+
+	return c.checkOCSP(cs.PeerCertificates[0])
+}
+
+// checkOCSP queries the OCSP responder of the leaf certificate. Only a
+// revoked status fails the check, responder errors are logged, such that the
+// responder does not become a hard dependency.
+func (c *RevocationChecker) checkOCSP(leaf *x509.Certificate) error {
+	if len(leaf.OCSPServer) == 0 {
+		return nil
+	}
+
+	key := revocationKey(leaf.RawIssuer, leaf.SerialNumber.Bytes())
+	if status, ok := c.ocspCache.Get(key); ok {
+		if status.(int) == ocsp.Revoked {
+			return fmt.Errorf("client certificate %q is revoked by OCSP", leaf.Subject.CommonName)
+		}
+		return nil
+	}
+
+	issuer := c.findIssuer(leaf)
+	if issuer == nil {
+		klog.V(2).InfoS("Skipping OCSP check of client certificate, issuer not found", "commonName", leaf.Subject.CommonName)
+		return nil
+	}
+
+	resp, err := c.queryOCSP(leaf, issuer)
+	if err != nil {
+		klog.ErrorS(err, "OCSP check of client certificate failed", "commonName", leaf.Subject.CommonName)
+		return nil
+	}
+
+	ttl := ocspDefaultTTL
+	if !resp.NextUpdate.IsZero() {
+		ttl = time.Until(resp.NextUpdate)
+	}
+	if ttl > 0 {
+		c.ocspCache.Add(key, resp.Status, ttl)
+	}
+
+	if resp.Status == ocsp.Revoked {
+		return fmt.Errorf("client certificate %q is revoked by OCSP", leaf.Subject.CommonName)
+	}
+
+	return nil
+}
+
+func (c *RevocationChecker) queryOCSP(leaf, issuer *x509.Certificate) (*ocsp.Response, error) {
+	req, err := ocsp.CreateRequest(leaf, issuer, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OCSP request: %w", err)
+	}
+
+	httpResp, err := c.ocspClient.Post(leaf.OCSPServer[0], "application/ocsp-request", bytes.NewReader(req))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query OCSP responder: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected OCSP responder status code %d", httpResp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(httpResp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OCSP response: %w", err)
+	}
+
+	resp, err := ocsp.ParseResponseForCert(body, leaf, issuer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse OCSP response: %w", err)
+	}
+	if resp.Status == ocsp.Unknown {
+		return nil, errors.New("OCSP responder does not know the certificate")
+	}
+
+	return resp, nil
+}
+
+func (c *RevocationChecker) findIssuer(leaf *x509.Certificate) *x509.Certificate {
+	c.mu.RLock()
+	issuers := c.issuers
+	c.mu.RUnlock()
+
+	for _, candidate := range issuers {
+		if bytes.Equal(candidate.RawSubject, leaf.RawIssuer) && leaf.CheckSignatureFrom(candidate) == nil {
+			return candidate
+		}
+	}
+	return nil
+}
+
+func revocationKey(rawIssuer, serial []byte) string {
+	return string(rawIssuer) + "/" + string(serial)
+}
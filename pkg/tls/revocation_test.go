@@ -0,0 +1,253 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tls
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  crypto.Signer
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testCA{cert: cert, key: key}
+}
+
+func (ca *testCA) issue(t *testing.T, serial int64, ocspServer string) *x509.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if ocspServer != "" {
+		tmpl.OCSPServer = []string{ocspServer}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, key.Public(), ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cert
+}
+
+func (ca *testCA) writeCert(t *testing.T, path string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (ca *testCA) writeCRL(t *testing.T, path string, nextUpdate time.Time, revoked ...int64) {
+	t.Helper()
+
+	tmpl := &x509.RevocationList{
+		Number:     big.NewInt(time.Now().UnixNano()),
+		ThisUpdate: time.Now().Add(-2 * time.Hour),
+		NextUpdate: nextUpdate,
+	}
+	for _, serial := range revoked {
+		tmpl.RevokedCertificateEntries = append(tmpl.RevokedCertificateEntries, x509.RevocationListEntry{
+			SerialNumber:   big.NewInt(serial),
+			RevocationTime: time.Now(),
+		})
+	}
+	der, err := x509.CreateRevocationList(rand.Reader, tmpl, ca.cert, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRevocationCheckerCRL(t *testing.T) {
+	ca := newTestCA(t)
+	crlPath := filepath.Join(t.TempDir(), "crl.pem")
+	ca.writeCRL(t, crlPath, time.Now().Add(time.Hour), 2)
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	ca.writeCert(t, caPath)
+
+	c, err := NewRevocationChecker(crlPath, time.Minute, false, caPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.VerifyConnection(tls.ConnectionState{}); err != nil {
+		t.Errorf("want connections without client certificate to pass, got %v", err)
+	}
+	if err := c.VerifyConnection(tls.ConnectionState{PeerCertificates: []*x509.Certificate{ca.issue(t, 2, "")}}); err == nil {
+		t.Error("want revoked certificate to fail")
+	}
+	if err := c.VerifyConnection(tls.ConnectionState{PeerCertificates: []*x509.Certificate{ca.issue(t, 3, "")}}); err != nil {
+		t.Errorf("want valid certificate to pass, got %v", err)
+	}
+
+	ca.writeCRL(t, crlPath, time.Now().Add(time.Hour), 3)
+	if err := c.reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.VerifyConnection(tls.ConnectionState{PeerCertificates: []*x509.Certificate{ca.issue(t, 3, "")}}); err == nil {
+		t.Error("want certificate revoked by reloaded CRL to fail")
+	}
+
+	rotated := newTestCA(t)
+	rotated.writeCRL(t, crlPath, time.Now().Add(time.Hour))
+	if err := c.reload(); err == nil {
+		t.Error("want CRL of another CA to be refused")
+	}
+	if err := c.VerifyConnection(tls.ConnectionState{PeerCertificates: []*x509.Certificate{ca.issue(t, 3, "")}}); err == nil {
+		t.Error("want the previous CRL to be kept after a refused reload")
+	}
+
+	rotated.writeCert(t, caPath)
+	commit, err := c.PrepareReload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit()
+	if err := c.reload(); err != nil {
+		t.Errorf("want CRL of the rotated CA to be accepted, got %v", err)
+	}
+
+	rotated.writeCRL(t, crlPath, time.Now().Add(-time.Minute))
+	if err := c.reload(); err != nil {
+		t.Errorf("want stale CRL to be loaded, got %v", err)
+	}
+	if err := c.VerifyConnection(tls.ConnectionState{PeerCertificates: []*x509.Certificate{rotated.issue(t, 4, "")}}); err == nil {
+		t.Error("want certificates to fail while the CRL is stale")
+	}
+	if err := c.VerifyConnection(tls.ConnectionState{}); err != nil {
+		t.Errorf("want connections without client certificate to pass with a stale CRL, got %v", err)
+	}
+
+	if _, err := NewRevocationChecker(crlPath, time.Minute, false, caPath); err != nil {
+		t.Errorf("want stale CRL to be loaded on start, got %v", err)
+	}
+}
+
+func TestRevocationCheckerOCSP(t *testing.T) {
+	ca := newTestCA(t)
+
+	responder := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := ocsp.ParseRequest(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		status := ocsp.Good
+		if req.SerialNumber.Int64() == 2 {
+			status = ocsp.Revoked
+		}
+		resp, err := ocsp.CreateResponse(ca.cert, ca.cert, ocsp.Response{
+			Status:       status,
+			SerialNumber: req.SerialNumber,
+			ThisUpdate:   time.Now(),
+			NextUpdate:   time.Now().Add(time.Hour),
+			RevokedAt:    time.Now(),
+		}, ca.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write(resp)
+	}))
+	defer responder.Close()
+
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	ca.writeCert(t, caPath)
+
+	c, err := NewRevocationChecker("", time.Minute, true, caPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.VerifyConnection(tls.ConnectionState{PeerCertificates: []*x509.Certificate{ca.issue(t, 2, responder.URL)}}); err == nil {
+		t.Error("want revoked certificate to fail")
+	}
+	if err := c.VerifyConnection(tls.ConnectionState{PeerCertificates: []*x509.Certificate{ca.issue(t, 3, responder.URL)}}); err != nil {
+		t.Errorf("want valid certificate to pass, got %v", err)
+	}
+
+	responder.Close()
+	if err := c.VerifyConnection(tls.ConnectionState{PeerCertificates: []*x509.Certificate{ca.issue(t, 2, responder.URL)}}); err == nil {
+		t.Error("want cached revocation to fail without responder")
+	}
+	if err := c.VerifyConnection(tls.ConnectionState{PeerCertificates: []*x509.Certificate{ca.issue(t, 4, responder.URL)}}); err != nil {
+		t.Errorf("want unreachable responder not to fail the handshake, got %v", err)
+	}
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	certutil "k8s.io/client-go/util/cert"
+)
+
+// SNISelector selects the serving certificate and the client CA requirement
+// by the server name of the TLS handshake, for servers exposed on several
+// host names.
+//
+// The GetCertificate and VerifyConnection signatures are compatible with
+// https://golang.org/pkg/crypto/tls/#Config.
+type SNISelector struct {
+	defaultCert func(*tls.ClientHelloInfo) (*tls.Certificate, error)
+	certs       map[string]*CertReloader
+	clientCAs   map[string]*x509.CertPool
+}
+
+// NewSNISelector creates an SNISelector, which falls back to the given
+// default certificate for unknown server names.
+func NewSNISelector(defaultCert func(*tls.ClientHelloInfo) (*tls.Certificate, error)) *SNISelector {
+	return &SNISelector{
+		defaultCert: defaultCert,
+		certs:       map[string]*CertReloader{},
+		clientCAs:   map[string]*x509.CertPool{},
+	}
+}
+
+// AddCertificate serves the certificate of the reloader for the given server
+// names. Names may have a wildcard as the leftmost label. If no names are
+// given, the DNS names of the certificate are used, or its common name if it
+// has none.
+func (s *SNISelector) AddCertificate(r *CertReloader, names []string) error {
+	if len(names) == 0 {
+		cert, _ := r.GetCertificate(nil)
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			return fmt.Errorf("failed to parse certificate %s: %w", r.certPath, err)
+		}
+
+		names = leaf.DNSNames
+		if len(names) == 0 && leaf.Subject.CommonName != "" {
+			names = []string{leaf.Subject.CommonName}
+		}
+		if len(names) == 0 {
+			return fmt.Errorf("certificate %s has no names to be selected by", r.certPath)
+		}
+	}
+
+	for _, name := range names {
+		s.certs[strings.ToLower(name)] = r
+	}
+
+	return nil
+}
+
+// AddClientCA requires TLS handshakes for the given server name to present a
+// client certificate signed by one of the authorities in the CA file.
+func (s *SNISelector) AddClientCA(name, caFile string) error {
+	certs, err := certutil.CertsFromFile(caFile)
+	if err != nil {
+		return fmt.Errorf("failed to load client CA for %s: %w", name, err)
+	}
+
+	pool := x509.NewCertPool()
+	for _, cert := range certs {
+		pool.AddCert(cert)
+	}
+	s.clientCAs[strings.ToLower(name)] = pool
+
+	return nil
+}
+
+// GetCertificate returns the certificate matching the server name, or the
+// default certificate.
+func (s *SNISelector) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	if r, ok := lookupServerName(s.certs, hello.ServerName); ok {
+		return r.GetCertificate(hello)
+	}
+
+	return s.defaultCert(hello)
+}
+
+// VerifyConnection fails the handshake, if the server name requires a client
+// CA and no client certificate signed by it was presented.
+func (s *SNISelector) VerifyConnection(cs tls.ConnectionState) error {
+	pool, ok := lookupServerName(s.clientCAs, cs.ServerName)
+	if !ok {
+		return nil
+	}
+
+	if len(cs.PeerCertificates) == 0 {
+		return fmt.Errorf("client certificate required for %s", cs.ServerName)
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range cs.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	if _, err := cs.PeerCertificates[0].Verify(x509.VerifyOptions{
+		Roots:         pool,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}); err != nil {
+		return errors.Join(fmt.Errorf("client certificate not accepted for %s", cs.ServerName), err)
+	}
+
+	return nil
+}
+
+// WithHostCheck rejects requests for a host name requiring a client CA with
+// 421 Misdirected Request, unless their TLS handshake was verified against
+// the same CA. Otherwise, clients could skip the client certificate by
+// sending no or another server name and the protected host name in the Host
+// header only.
+func (s *SNISelector) WithHostCheck(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		host := req.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+
+		if pool, ok := lookupServerName(s.clientCAs, strings.TrimSuffix(host, ".")); ok {
+			var verified *x509.CertPool
+			if req.TLS != nil {
+				verified, _ = lookupServerName(s.clientCAs, req.TLS.ServerName)
+			}
+			if verified != pool {
+				http.Error(w, http.StatusText(http.StatusMisdirectedRequest), http.StatusMisdirectedRequest)
+				return
+			}
+		}
+
+		handler.ServeHTTP(w, req)
+	})
+}
+
+// lookupServerName returns the value for the exact server name, or for the
+// wildcard name replacing its leftmost label.
+func lookupServerName[T any](m map[string]T, serverName string) (T, bool) {
+	serverName = strings.ToLower(serverName)
+	if v, ok := m[serverName]; ok {
+		return v, true
+	}
+
+	if i := strings.IndexByte(serverName, '.'); i > 0 {
+		if v, ok := m["*"+serverName[i:]]; ok {
+			return v, true
+		}
+	}
+
+	var zero T
+	return zero, false
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2026 the kube-rbac-proxy maintainers. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	certutil "k8s.io/client-go/util/cert"
+)
+
+func newSNICertReloader(t *testing.T, host string) *CertReloader {
+	t.Helper()
+
+	certPEM, keyPEM, err := certutil.GenerateSelfSignedCertKey(host, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	certPath, keyPath := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	if err := os.WriteFile(certPath, certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyPath, keyPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewCertReloader(certPath, keyPath, Passphrase{}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestSNISelectorGetCertificate(t *testing.T) {
+	defaultCert := &tls.Certificate{}
+	sni := NewSNISelector(func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+		return defaultCert, nil
+	})
+
+	internal := newSNICertReloader(t, "proxy.monitoring.svc")
+	if err := sni.AddCertificate(internal, nil); err != nil {
+		t.Fatal(err)
+	}
+	external := newSNICertReloader(t, "metrics.example.com")
+	if err := sni.AddCertificate(external, []string{"*.example.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		serverName string
+		want       *CertReloader
+	}{
+		{serverName: "proxy.monitoring.svc", want: internal},
+		{serverName: "Proxy.Monitoring.svc", want: internal},
+		{serverName: "metrics.example.com", want: external},
+		{serverName: "other.example.com", want: external},
+		{serverName: "example.com"},
+		{serverName: ""},
+	} {
+		got, err := sni.GetCertificate(&tls.ClientHelloInfo{ServerName: tt.serverName})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := defaultCert
+		if tt.want != nil {
+			want, _ = tt.want.GetCertificate(nil)
+		}
+		if got != want {
+			t.Errorf("%q: got unexpected certificate", tt.serverName)
+		}
+	}
+}
+
+func TestSNISelectorVerifyConnection(t *testing.T) {
+	ca := newTestCA(t)
+	otherCA := newTestCA(t)
+
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	sni := NewSNISelector(nil)
+	if err := sni.AddClientCA("*.internal.example.com", caPath); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name       string
+		serverName string
+		certs      []*x509.Certificate
+		wantErr    bool
+	}{
+		{name: "no requirement", serverName: "example.com"},
+		{name: "missing client certificate", serverName: "proxy.internal.example.com", wantErr: true},
+		{name: "trusted client certificate", serverName: "proxy.internal.example.com", certs: []*x509.Certificate{ca.issue(t, 2, "")}},
+		{name: "untrusted client certificate", serverName: "proxy.internal.example.com", certs: []*x509.Certificate{otherCA.issue(t, 2, "")}, wantErr: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := sni.VerifyConnection(tls.ConnectionState{ServerName: tt.serverName, PeerCertificates: tt.certs})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("want error %t, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestSNISelectorWithHostCheck(t *testing.T) {
+	ca := newTestCA(t)
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	sni := NewSNISelector(nil)
+	if err := sni.AddClientCA("*.internal.example.com", caPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := sni.AddClientCA("admin.example.com", caPath); err != nil {
+		t.Fatal(err)
+	}
+	handler := sni.WithHostCheck(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+
+	for _, tt := range []struct {
+		name       string
+		host       string
+		serverName string
+		plain      bool
+		want       int
+	}{
+		{name: "unprotected host", host: "example.com", want: http.StatusOK},
+		{name: "unprotected host with protected server name", host: "example.com", serverName: "proxy.internal.example.com", want: http.StatusOK},
+		{name: "matching server name", host: "proxy.internal.example.com:8443", serverName: "proxy.internal.example.com", want: http.StatusOK},
+		{name: "other server name of the same pattern", host: "other.internal.example.com", serverName: "proxy.internal.example.com", want: http.StatusOK},
+		{name: "missing server name", host: "proxy.internal.example.com", want: http.StatusMisdirectedRequest},
+		{name: "unprotected server name", host: "proxy.internal.example.com", serverName: "example.com", want: http.StatusMisdirectedRequest},
+		{name: "server name of another CA", host: "admin.example.com.", serverName: "proxy.internal.example.com", want: http.StatusMisdirectedRequest},
+		{name: "plain connection", host: "admin.example.com", plain: true, want: http.StatusMisdirectedRequest},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Host = tt.host
+			req.TLS = &tls.ConnectionState{ServerName: tt.serverName}
+			if tt.plain {
+				req.TLS = nil
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("want status %d, got %d", tt.want, rec.Code)
+			}
+		})
+	}
+}